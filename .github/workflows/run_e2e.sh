@@ -14,7 +14,7 @@ proxy_pid="$!"
 # setup and run e2e
 kubectl create ns "$namespace"
 # deploy CRDs
-kubectl apply -f docs/zalando.org_elasticsearchdatasets.yaml -f docs/zalando.org_elasticsearchmetricsets.yaml
+kubectl apply -f docs/zalando.org_elasticsearchdatasets.yaml -f docs/zalando.org_elasticsearchmetricsets.yaml -f docs/zalando.org_elasticsearchrollouts.yaml -f docs/zalando.org_elasticsearchrestores.yaml -f docs/zalando.org_elasticsearchclusters.yaml
 # deploy sysctl ds
 kubectl apply -f manifests/sysctl.yaml
 # deploy metrics-server
@@ -39,9 +39,11 @@ for pod in es8-master-0 es7-master-0; do
     done
 done
 
-# run e2e
+# run e2e, every test gets its own namespace and operator
 OPERATOR_ID=es-operator-e2e \
 E2E_NAMESPACE=es-operator-e2e \
+E2E_ISOLATE_NAMESPACES=true \
+E2E_OPERATOR_IMAGE="$ES_OPERATOR_IMAGE" \
 ES_SERVICE_ENDPOINT_ES8="http://127.0.0.1:8001/api/v1/namespaces/es-operator-e2e/services/es8-master:9200/proxy" \
 ES_SERVICE_ENDPOINT_ES7="http://127.0.0.1:8001/api/v1/namespaces/es-operator-e2e/services/es7-master:9200/proxy" \
 KUBECONFIG="${HOME}/.kube/config" ./build/linux/e2e -test.v
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// adminIdentity returns the identity of the authenticated caller of the admin
+// API or an empty string if the caller isn't authenticated. Callers
+// authenticate with a verified client certificate or the bearer token.
+func adminIdentity(r *http.Request, token string) string {
+	if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 && len(r.TLS.VerifiedChains[0]) > 0 {
+		return "cert:" + r.TLS.VerifiedChains[0][0].Subject.CommonName
+	}
+	if token == "" {
+		return ""
+	}
+	bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if ok && subtle.ConstantTimeCompare([]byte(bearer), []byte(token)) == 1 {
+		return "token"
+	}
+	return ""
+}
+
+// statusRecorder records the status code of a response.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// authenticateAdmin rejects unauthenticated calls of the admin API and writes
+// an audit log entry for every call, including rejected ones.
+func authenticateAdmin(handler http.Handler, token string, logger log.FieldLogger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		identity := adminIdentity(r, token)
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		if identity == "" {
+			http.Error(recorder, "unauthorized", http.StatusUnauthorized)
+		} else {
+			handler.ServeHTTP(recorder, r)
+		}
+
+		logger.WithFields(log.Fields{
+			"audit":  true,
+			"user":   identity,
+			"remote": r.RemoteAddr,
+			"method": r.Method,
+			"path":   r.URL.Path,
+			"status": recorder.status,
+		}).Info("Admin API call")
+	})
+}
+
+// adminServer serves the admin API as a runnable of the manager. Only the
+// elected leader operates the EDS, so only the leader serves the admin API
+// and the other replicas refuse connections.
+type adminServer struct {
+	server   *http.Server
+	certFile string
+	keyFile  string
+}
+
+// newAdminServer returns the server of the admin API on the address. Callers
+// are authenticated with the token read from tokenFile and, if clientCAFile
+// is set, with client certificates signed by the CA. TLS is used if a
+// certificate is configured.
+func newAdminServer(address string, handler http.Handler, tokenFile, certFile, keyFile, clientCAFile string) (*adminServer, error) {
+	var token string
+	if tokenFile != "" {
+		data, err := os.ReadFile(tokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read admin token: %v", err)
+		}
+		token = strings.TrimSpace(string(data))
+		if token == "" {
+			return nil, fmt.Errorf("admin token file %s is empty", tokenFile)
+		}
+	}
+	if token == "" && clientCAFile == "" {
+		return nil, fmt.Errorf("the admin API requires a token or a client CA for authentication")
+	}
+	if clientCAFile != "" && certFile == "" {
+		return nil, fmt.Errorf("authenticating admin API callers with client certificates requires a TLS certificate")
+	}
+
+	server := &http.Server{
+		Addr:    address,
+		Handler: authenticateAdmin(handler, token, log.WithField("component", "admin")),
+	}
+	if certFile == "" {
+		return &adminServer{server: server}, nil
+	}
+
+	server.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	if clientCAFile != "" {
+		data, err := os.ReadFile(clientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read admin client CA: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no certificates found in admin client CA %s", clientCAFile)
+		}
+		server.TLSConfig.ClientCAs = pool
+		server.TLSConfig.ClientAuth = tls.VerifyClientCertIfGiven
+		if token == "" {
+			server.TLSConfig.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+	return &adminServer{server: server, certFile: certFile, keyFile: keyFile}, nil
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable, the admin API
+// is only served by the leader.
+func (s *adminServer) NeedLeaderElection() bool {
+	return true
+}
+
+// Start serves the admin API until the context is cancelled.
+func (s *adminServer) Start(ctx context.Context) error {
+	go func() {
+		<-ctx.Done()
+		_ = s.server.Shutdown(context.Background())
+	}()
+
+	var err error
+	if s.certFile == "" {
+		log.WithField("component", "admin").Warn("Serving the admin API without TLS, the token is sent in plain text.")
+		err = s.server.ListenAndServe()
+	} else {
+		err = s.server.ListenAndServeTLS(s.certFile, s.keyFile)
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthenticateAdmin(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+	handler := authenticateAdmin(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}), "secret", logger)
+
+	for _, tc := range []struct {
+		msg      string
+		header   string
+		cert     string
+		expected int
+		user     string
+	}{
+		{msg: "no credentials", expected: http.StatusUnauthorized},
+		{msg: "wrong token", header: "Bearer wrong", expected: http.StatusUnauthorized},
+		{msg: "valid token", header: "Bearer secret", expected: http.StatusAccepted, user: "token"},
+		{msg: "client certificate", cert: "automation", expected: http.StatusAccepted, user: "cert:automation"},
+	} {
+		t.Run(tc.msg, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/admin/v1/namespaces/default/pods/es-data-0/drain", nil)
+			if tc.header != "" {
+				req.Header.Set("Authorization", tc.header)
+			}
+			if tc.cert != "" {
+				cert := &x509.Certificate{Subject: pkix.Name{CommonName: tc.cert}}
+				req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			require.Equal(t, tc.expected, w.Code)
+
+			entry := hook.LastEntry()
+			require.NotNil(t, entry)
+			require.Equal(t, true, entry.Data["audit"])
+			require.Equal(t, tc.user, entry.Data["user"])
+			require.Equal(t, tc.expected, entry.Data["status"])
+		})
+	}
+}
+
+func TestNewAdminServerRequiresAuthentication(t *testing.T) {
+	_, err := newAdminServer(":0", http.NotFoundHandler(), "", "", "", "")
+	require.Error(t, err)
+}
+
+func TestAdminServerNeedsLeaderElection(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("secret\n"), 0o600))
+
+	server, err := newAdminServer(":0", http.NotFoundHandler(), tokenFile, "", "", "")
+	require.NoError(t, err)
+	// a non-leader replica must not handle admin calls.
+	require.True(t, server.NeedLeaderElection())
+}
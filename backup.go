@@ -0,0 +1,28 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+)
+
+const (
+	backupPath = "/backup"
+)
+
+// exportBackup fetches a backup of the managed resources from a running
+// operator and writes it to output. If output is empty a timestamped file is
+// created in the current directory.
+func exportBackup(address *url.URL, output string, timeout time.Duration) error {
+	if output == "" {
+		output = fmt.Sprintf("es-operator-backup-%s.json", time.Now().UTC().Format("20060102T150405Z"))
+	}
+
+	err := download(address.JoinPath(backupPath), output, timeout)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Wrote backup to %s\n", output)
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/cenk/backoff"
 	"github.com/stretchr/testify/require"
+	"github.com/zalando-incubator/es-operator/operator"
 	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
 )
 
@@ -15,6 +16,7 @@ func TestEDSCPUAutoscaleUP8(t *testing.T) {
 }
 
 func runTestEDSCPUAutoScaleUP(t *testing.T, version, configMap string) {
+	env := newTestEnvironment(t)
 	edsName := "cpu-autoscale-up-" + strings.Replace(version, ".", "", -1)
 	edsSpecFactory := NewTestEDSSpecFactory(edsName, version, configMap)
 	edsSpecFactory.Scaling(&zv1.ElasticsearchDataSetScaling{
@@ -34,12 +36,13 @@ func runTestEDSCPUAutoScaleUP(t *testing.T, version, configMap string) {
 		DiskUsagePercentScaledownWatermark: 0,
 	})
 	edsSpec := edsSpecFactory.Create()
-	edsSpec.Template.Spec = edsPodSpecCPULoadContainer(edsName, version, configMap)
+	load := selectedLoadGenerator()
+	edsSpec.Template.Spec = edsPodSpecWithLoad(edsName, version, configMap, load)
 
-	err := createEDS(edsName, edsSpec)
+	err := env.createEDS(edsName, edsSpec)
 	require.NoError(t, err)
 
-	esClient, err := setupESClient("http://"+edsName+":9200", version)
+	esClient, err := setupESClient(env.serviceEndpoint(edsName), version)
 	require.NoError(t, err)
 	createIndex := func() error {
 		return esClient.CreateIndex(edsName, edsName, 1, 0)
@@ -47,10 +50,13 @@ func runTestEDSCPUAutoScaleUP(t *testing.T, version, configMap string) {
 	backoffCfg := backoff.NewExponentialBackOff()
 	err = backoff.Retry(createIndex, backoffCfg)
 	require.NoError(t, err)
-	verifyEDS(t, edsName, edsSpec, pint32(3))
+	verifyEDS(env, edsName, edsSpec, pint32(3))
+	if load.ExercisesElasticsearch() {
+		verifyThreadPoolLoad(t, esClient)
+	}
 	err = esClient.DeleteIndex(edsName)
 	require.NoError(t, err)
-	err = deleteEDS(edsName)
+	err = env.deleteEDS(edsName)
 	require.NoError(t, err)
 }
 
@@ -65,6 +71,7 @@ func TestEDSAutoscaleUPOnShardCount7(t *testing.T) {
 }
 
 func runTestEDSAutoscaleUPOnShardCount(t *testing.T, version, configMap string) {
+	env := newTestEnvironment(t)
 	edsName := "shard-autoscale-up-" + strings.Replace(version, ".", "", -1)
 	edsSpecFactory := NewTestEDSSpecFactory(edsName, version, configMap)
 	edsSpecFactory.Scaling(&zv1.ElasticsearchDataSetScaling{
@@ -85,10 +92,10 @@ func runTestEDSAutoscaleUPOnShardCount(t *testing.T, version, configMap string)
 	})
 	edsSpec := edsSpecFactory.Create()
 
-	err := createEDS(edsName, edsSpec)
+	err := env.createEDS(edsName, edsSpec)
 	require.NoError(t, err)
 
-	esClient, err := setupESClient("http://"+edsName+":9200", version)
+	esClient, err := setupESClient(env.serviceEndpoint(edsName), version)
 	require.NoError(t, err)
 	createIndex := func() error {
 		return esClient.CreateIndex(edsName, edsName, 2, 0)
@@ -96,9 +103,20 @@ func runTestEDSAutoscaleUPOnShardCount(t *testing.T, version, configMap string)
 	backoffCfg := backoff.NewExponentialBackOff()
 	err = backoff.Retry(createIndex, backoffCfg)
 	require.NoError(t, err)
-	verifyEDS(t, edsName, edsSpec, pint32(2))
+	verifyEDS(env, edsName, edsSpec, pint32(2))
 	err = esClient.DeleteIndex(edsName)
 	require.NoError(t, err)
-	err = deleteEDS(edsName)
+	err = env.deleteEDS(edsName)
 	require.NoError(t, err)
 }
+
+// verifyThreadPoolLoad verifies that the load generator exercised the search
+// and write thread pools of the cluster.
+func verifyThreadPoolLoad(t *testing.T, esClient *operator.ESClient) {
+	stats, err := getThreadPoolStats(esClient)
+	require.NoError(t, err)
+	for _, pool := range []string{"search", "write"} {
+		require.Greaterf(t, stats[pool].Completed, int64(0), "no completed operations in %s thread pool", pool)
+		t.Logf("%s thread pool: %d completed, %d rejected", pool, stats[pool].Completed, stats[pool].Rejected)
+	}
+}
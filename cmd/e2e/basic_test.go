@@ -1,13 +1,19 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	"github.com/zalando-incubator/es-operator/pkg/await"
 	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 type TestEDSSpecFactory struct {
@@ -16,6 +22,10 @@ type TestEDSSpecFactory struct {
 	scaling   *zv1.ElasticsearchDataSetScaling
 	version   string
 	configMap string
+	// storageClass and storageSize replace the EmptyDir data volume with
+	// a volume claim template if set.
+	storageClass string
+	storageSize  string
 }
 
 func NewTestEDSSpecFactory(edsName, version, configMap string) *TestEDSSpecFactory {
@@ -37,6 +47,12 @@ func (f *TestEDSSpecFactory) Scaling(scaling *zv1.ElasticsearchDataSetScaling) *
 	return f
 }
 
+func (f *TestEDSSpecFactory) VolumeClaim(storageClass, size string) *TestEDSSpecFactory {
+	f.storageClass = storageClass
+	f.storageSize = size
+	return f
+}
+
 func (f *TestEDSSpecFactory) Create() zv1.ElasticsearchDataSetSpec {
 	var result = zv1.ElasticsearchDataSetSpec{
 		Replicas: &f.replicas,
@@ -52,50 +68,70 @@ func (f *TestEDSSpecFactory) Create() zv1.ElasticsearchDataSetSpec {
 		},
 	}
 
+	if f.storageSize != "" {
+		volumes := result.Template.Spec.Volumes[:0]
+		for _, volume := range result.Template.Spec.Volumes {
+			if volume.Name != "data" {
+				volumes = append(volumes, volume)
+			}
+		}
+		result.Template.Spec.Volumes = volumes
+		result.VolumeClaimTemplates = []zv1.PersistentVolumeClaim{
+			{
+				EmbeddedObjectMetaWithName: zv1.EmbeddedObjectMetaWithName{
+					Name:   "data",
+					Labels: runLabels(),
+				},
+				Spec: v1.PersistentVolumeClaimSpec{
+					AccessModes:      []v1.PersistentVolumeAccessMode{v1.ReadWriteOnce},
+					StorageClassName: &f.storageClass,
+					Resources: v1.VolumeResourceRequirements{
+						Requests: v1.ResourceList{v1.ResourceStorage: resource.MustParse(f.storageSize)},
+					},
+				},
+			},
+		}
+	}
+
 	return result
 }
 
-func testEDSCreate(t *testing.T, edsName, version, configMap string) zv1.ElasticsearchDataSetSpec {
+func testEDSCreate(env *testEnvironment, edsName, version, configMap string) zv1.ElasticsearchDataSetSpec {
 	edsSpecFactory := NewTestEDSSpecFactory(edsName, version, configMap)
 	edsSpec := edsSpecFactory.Create()
 
-	err := createEDS(edsName, edsSpec)
-	require.NoError(t, err)
+	err := env.createEDS(edsName, edsSpec)
+	require.NoError(env.t, err)
 	return edsSpec
 }
 
-func verifyEDS(t *testing.T, edsName string, edsSpec zv1.ElasticsearchDataSetSpec, replicas *int32) *zv1.ElasticsearchDataSet {
+func verifyEDS(env *testEnvironment, edsName string, edsSpec zv1.ElasticsearchDataSetSpec, replicas *int32) *zv1.ElasticsearchDataSet {
+	t := env.t
 	// Verify eds
-	eds, err := waitForEDS(t, edsName)
+	eds, err := env.waitForEDS(edsName)
 	require.NoError(t, err)
-	err = waitForEDSCondition(t, eds.Name, func(eds *zv1.ElasticsearchDataSet) error {
+	err = env.waitForEDSCondition(eds.Name, func(eds *zv1.ElasticsearchDataSet) error {
 		if !assert.ObjectsAreEqualValues(replicas, eds.Spec.Replicas) {
 			return fmt.Errorf("%s: replicas %d != expected %d", eds.Name, *eds.Spec.Replicas, *replicas)
 		}
-		if !assert.ObjectsAreEqualValues(edsSpec.Template.Labels, eds.Spec.Template.Labels) {
-			return fmt.Errorf("EDS '%s' labels %v != expected %v", eds.Name, eds.Spec.Template.Labels, edsSpec.Template.Labels)
-		}
-		return nil
+		return await.Equal(fmt.Sprintf("EDS '%s' labels", eds.Name), edsSpec.Template.Labels, eds.Spec.Template.Labels)
 	})
 	require.NoError(t, err)
 
 	// Verify statefulset
-	sts, err := waitForStatefulSet(t, edsName)
+	sts, err := env.waitForStatefulSet(edsName)
 	require.NoError(t, err)
 	require.Equal(t, *replicas, *sts.Spec.Replicas)
 
 	// Verify service
-	service, err := waitForService(t, eds.Name)
+	service, err := env.waitForService(eds.Name)
 	require.NoError(t, err)
 	require.EqualValues(t, eds.Labels, service.Labels)
 	require.EqualValues(t, sts.Spec.Selector.MatchLabels, service.Spec.Selector)
 
 	// wait for this condition to be true
-	err = waitForSTSCondition(t, sts.Name, func(sts *appsv1.StatefulSet) error {
-		if !assert.ObjectsAreEqualValues(mergeLabels(edsSpec.Template.Labels, sts.Spec.Selector.MatchLabels), sts.Spec.Template.Labels) {
-			return fmt.Errorf("EDS '%s' labels %v, does not match STS labels %v", eds.Name, mergeLabels(edsSpec.Template.Labels, sts.Spec.Selector.MatchLabels), sts.Spec.Template.Labels)
-		}
-		return nil
+	err = env.waitForSTSCondition(sts.Name, func(sts *appsv1.StatefulSet) error {
+		return await.Equal(fmt.Sprintf("STS '%s' labels", sts.Name), mergeLabels(edsSpec.Template.Labels, sts.Spec.Selector.MatchLabels), sts.Spec.Template.Labels)
 	},
 		expectedStsStatus{
 			replicas:        replicas,
@@ -118,18 +154,47 @@ func mergeLabels(labelsSlice ...map[string]string) map[string]string {
 
 func TestEDSCreateBasic8(t *testing.T) {
 	t.Parallel()
+	env := newTestEnvironment(t)
 	edsName := "basic8"
-	edsSpec := testEDSCreate(t, edsName, "8.6.2", "es8-config")
-	verifyEDS(t, edsName, edsSpec, edsSpec.Replicas)
-	err := deleteEDS(edsName)
+	edsSpec := testEDSCreate(env, edsName, "8.6.2", "es8-config")
+	verifyEDS(env, edsName, edsSpec, edsSpec.Replicas)
+	err := env.deleteEDS(edsName)
 	require.NoError(t, err)
 }
 
 func TestEDSCreateBasic7(t *testing.T) {
 	t.Parallel()
+	env := newTestEnvironment(t)
 	edsName := "basic7"
-	edsSpec := testEDSCreate(t, edsName, "7.17.2", "es7-config")
-	verifyEDS(t, edsName, edsSpec, edsSpec.Replicas)
-	err := deleteEDS(edsName)
+	edsSpec := testEDSCreate(env, edsName, "7.17.2", "es7-config")
+	verifyEDS(env, edsName, edsSpec, edsSpec.Replicas)
+	err := env.deleteEDS(edsName)
+	require.NoError(t, err)
+}
+
+func TestEDSCreatePersistent8(t *testing.T) {
+	t.Parallel()
+	storageClass := os.Getenv("E2E_STORAGE_CLASS")
+	if storageClass == "" {
+		t.Skip("E2E_STORAGE_CLASS is not set")
+	}
+	env := newTestEnvironment(t)
+	edsName := "persistent8"
+	edsSpec := NewTestEDSSpecFactory(edsName, "8.6.2", "es8-config").VolumeClaim(storageClass, "1Gi").Create()
+	err := env.createEDS(edsName, edsSpec)
+	require.NoError(t, err)
+	verifyEDS(env, edsName, edsSpec, edsSpec.Replicas)
+
+	sts, err := env.statefulSetInterface().Get(context.Background(), edsName, metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Len(t, sts.Spec.VolumeClaimTemplates, 1)
+	require.Equal(t, storageClass, *sts.Spec.VolumeClaimTemplates[0].Spec.StorageClassName)
+	claim, err := kubernetesClient.CoreV1().PersistentVolumeClaims(env.namespace).Get(context.Background(), "data-"+edsName+"-0", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, v1.ClaimBound, claim.Status.Phase)
+
+	err = env.deleteEDS(edsName)
+	require.NoError(t, err)
+	err = kubernetesClient.CoreV1().PersistentVolumeClaims(env.namespace).Delete(context.Background(), claim.Name, metav1.DeleteOptions{})
 	require.NoError(t, err)
 }
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/zalando-incubator/es-operator/pkg/conformance"
+)
+
+func TestConformance8(t *testing.T) {
+	t.Parallel()
+	runConformance(t, "8.6.2", "es8-config")
+}
+
+func TestConformance7(t *testing.T) {
+	t.Parallel()
+	runConformance(t, "7.17.2", "es7-config")
+}
+
+func runConformance(t *testing.T, version, configMap string) {
+	env := newTestEnvironment(t)
+	esClient, err := setupESClient(fmt.Sprintf("http://es%s-master.%s:9200", version[:1], namespace), version)
+	require.NoError(t, err)
+
+	conformance.Run(t, conformance.Config{
+		KubeClient:        kubernetesClient,
+		EDSClient:         edsClient,
+		Namespace:         env.namespace,
+		OperatorID:        env.operatorID,
+		Version:           version,
+		ConfigMap:         configMap,
+		Endpoint:          esClient.Endpoint,
+		Labels:            runLabels(),
+		ImageRewriteRules: imageRewriteRules,
+	})
+}
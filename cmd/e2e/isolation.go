@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/zalando-incubator/es-operator/pkg/await"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	isolatedTestLabelKey   = "e2e.es-operator.zalando.org/test"
+	isolatedClusterRole    = "es-operator-e2e"
+	isolatedServiceAccount = "es-operator"
+	isolatedOperatorName   = "es-operator"
+)
+
+var (
+	// esConfigMaps are the Elasticsearch configs copied from the shared
+	// namespace to the test namespaces.
+	esConfigMaps = []string{"es7-config", "es8-config"}
+	// seedHostsPattern matches the master service in the seed hosts of the
+	// Elasticsearch configs.
+	seedHostsPattern    = regexp.MustCompile(`(discovery\.seed_hosts: \[)([a-z0-9-]+)(\])`)
+	invalidLabelPattern = regexp.MustCompile(`[^a-z0-9-]+`)
+)
+
+// testLabelValue turns the test name into a valid label value.
+func testLabelValue(name string) string {
+	value := invalidLabelPattern.ReplaceAllString(strings.ToLower(name), "-")
+	if len(value) > 63 {
+		value = value[:63]
+	}
+	return strings.Trim(value, "-")
+}
+
+// isolatedLabels returns the labels of the resources created for an
+// isolated test.
+func isolatedLabels(t *testing.T) map[string]string {
+	labels := runLabels()
+	labels[isolatedTestLabelKey] = testLabelValue(t.Name())
+	return labels
+}
+
+// newIsolatedTestEnvironment creates a namespace for the test with a
+// dedicated operator managing only the EDS of the test. The Elasticsearch
+// configs of the shared namespace are copied, so the test EDS join the
+// master nodes running in the shared namespace.
+func newIsolatedTestEnvironment(t *testing.T) *testEnvironment {
+	ctx := context.Background()
+	operatorImage := requiredEnvar("E2E_OPERATOR_IMAGE")
+
+	ns, err := kubernetesClient.CoreV1().Namespaces().Create(ctx, &v1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: namespace + "-",
+			Labels:       isolatedLabels(t),
+		},
+	}, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	env := &testEnvironment{
+		t:          t,
+		namespace:  ns.Name,
+		operatorID: ns.Name,
+	}
+	t.Logf("Running test in namespace %s", env.namespace)
+
+	t.Cleanup(func() {
+		if os.Getenv("E2E_KEEP_NAMESPACES") == "true" {
+			return
+		}
+		err := kubernetesClient.RbacV1().ClusterRoleBindings().Delete(ctx, env.namespace, metav1.DeleteOptions{})
+		if err != nil {
+			t.Logf("Failed to delete ClusterRoleBinding %s: %v", env.namespace, err)
+		}
+		err = kubernetesClient.CoreV1().Namespaces().Delete(ctx, env.namespace, metav1.DeleteOptions{})
+		if err != nil {
+			t.Logf("Failed to delete namespace %s: %v", env.namespace, err)
+		}
+	})
+
+	for _, name := range esConfigMaps {
+		cm, err := kubernetesClient.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+		require.NoError(t, err)
+
+		data := make(map[string]string, len(cm.Data))
+		for key, value := range cm.Data {
+			data[key] = seedHostsPattern.ReplaceAllString(value, fmt.Sprintf("${1}${2}.%s.svc${3}", namespace))
+		}
+		_, err = kubernetesClient.CoreV1().ConfigMaps(env.namespace).Create(ctx, &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Data:       data,
+		}, metav1.CreateOptions{})
+		require.NoError(t, err)
+	}
+
+	_, err = kubernetesClient.CoreV1().ServiceAccounts(env.namespace).Create(ctx, &v1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{Name: isolatedServiceAccount},
+	}, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	// the operator needs to list nodes, so a ClusterRoleBinding is required.
+	_, err = kubernetesClient.RbacV1().ClusterRoleBindings().Create(ctx, &rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   env.namespace,
+			Labels: isolatedLabels(t),
+		},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "ClusterRole",
+			Name:     isolatedClusterRole,
+		},
+		Subjects: []rbacv1.Subject{
+			{
+				Kind:      rbacv1.ServiceAccountKind,
+				Name:      isolatedServiceAccount,
+				Namespace: env.namespace,
+			},
+		},
+	}, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	labels := map[string]string{"application": isolatedOperatorName}
+	_, err = kubernetesClient.AppsV1().Deployments(env.namespace).Create(ctx, &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   isolatedOperatorName,
+			Labels: labels,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: pint32(1),
+			Strategy: appsv1.DeploymentStrategy{Type: appsv1.RecreateDeploymentStrategyType},
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: v1.PodSpec{
+					ServiceAccountName: isolatedServiceAccount,
+					Containers: []v1.Container{
+						{
+							Name:  isolatedOperatorName,
+							Image: operatorImage,
+							Args: []string{
+								"--priority-node-selector=lifecycle-status=ready",
+								"--operator-id=" + env.operatorID,
+								"--interval=30s",
+								"--namespace=" + env.namespace,
+								"--debug",
+							},
+							Resources: v1.ResourceRequirements{
+								Limits: v1.ResourceList{
+									v1.ResourceMemory: resource.MustParse("300Mi"),
+									v1.ResourceCPU:    resource.MustParse("50m"),
+								},
+								Requests: v1.ResourceList{
+									v1.ResourceMemory: resource.MustParse("300Mi"),
+									v1.ResourceCPU:    resource.MustParse("50m"),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	err = configureAwaiter(t, await.Condition(kubernetesClient.AppsV1().Deployments(env.namespace), "deployment", isolatedOperatorName, func(deployment *appsv1.Deployment) error {
+		return await.Equal("readyReplicas", int32(1), deployment.Status.ReadyReplicas)
+	})).WithTimeout(5 * time.Minute).Await(ctx)
+	require.NoError(t, err)
+
+	return env
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/sirupsen/logrus"
+	"github.com/zalando-incubator/es-operator/operator"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// runLabelKey labels all resources created by an e2e run with the ID
+	// of the run, so leftovers of failed or aborted runs can be cleaned up.
+	runLabelKey              = "e2e.es-operator.zalando.org/run"
+	defaultJanitorMinimumAge = time.Hour
+	excludeIPsSetting        = "cluster.routing.allocation.exclude._ip"
+)
+
+var (
+	runID = envOrDefault("E2E_RUN_ID", time.Now().UTC().Format("20060102t150405z"))
+)
+
+// runLabels returns the labels marking resources of the current run.
+func runLabels() map[string]string {
+	return map[string]string{runLabelKey: runID}
+}
+
+// janitor removes the resources of previous e2e runs which were left behind
+// because the run failed or was aborted.
+type janitor struct {
+	logger *logrus.Entry
+	// minimumAge is the minimum age of resources to remove. It protects
+	// the resources of concurrent runs.
+	minimumAge time.Duration
+	esClients  []*operator.ESClient
+}
+
+func newJanitor() (*janitor, error) {
+	minimumAge := defaultJanitorMinimumAge
+	if value := os.Getenv("E2E_JANITOR_MINIMUM_AGE"); value != "" {
+		var err error
+		minimumAge, err = time.ParseDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid E2E_JANITOR_MINIMUM_AGE: %v", err)
+		}
+	}
+
+	esClients := make([]*operator.ESClient, 0, 2)
+	for _, version := range []string{"7", "8"} {
+		client, err := setupESClient(fmt.Sprintf("http://es%s-master.%s:9200", version, namespace), version)
+		if err != nil {
+			return nil, err
+		}
+		esClients = append(esClients, client)
+	}
+
+	return &janitor{
+		logger:     logrus.WithField("janitor", runID),
+		minimumAge: minimumAge,
+		esClients:  esClients,
+	}, nil
+}
+
+// leftover returns true if the resource was created by another run and is
+// old enough to be removed.
+func (j *janitor) leftover(meta metav1.ObjectMeta) bool {
+	run, ok := meta.Labels[runLabelKey]
+	return ok && run != runID && time.Since(meta.CreationTimestamp.Time) > j.minimumAge
+}
+
+// run removes leftover EDS, StatefulSets, Services, PersistentVolumeClaims,
+// test namespaces and ClusterRoleBindings as well as allocation exclusions of
+// IPs not belonging to any pod. Errors are logged, so a failing cleanup doesn't block the run.
+func (j *janitor) run(ctx context.Context) {
+	selector := metav1.ListOptions{LabelSelector: runLabelKey}
+
+	edss, err := edsClient.ZalandoV1().ElasticsearchDataSets(namespace).List(ctx, selector)
+	if err != nil {
+		j.logger.Errorf("Failed to list EDS: %v", err)
+	} else {
+		for _, eds := range edss.Items {
+			if j.leftover(eds.ObjectMeta) {
+				j.delete("eds", eds.Name, edsClient.ZalandoV1().ElasticsearchDataSets(namespace).Delete(ctx, eds.Name, metav1.DeleteOptions{}))
+			}
+		}
+	}
+
+	// StatefulSets and Services are owned by the EDS, but may be orphaned
+	// if the operator was stopped before cleaning up.
+	stss, err := kubernetesClient.AppsV1().StatefulSets(namespace).List(ctx, selector)
+	if err != nil {
+		j.logger.Errorf("Failed to list StatefulSets: %v", err)
+	} else {
+		for _, sts := range stss.Items {
+			if j.leftover(sts.ObjectMeta) {
+				j.delete("sts", sts.Name, kubernetesClient.AppsV1().StatefulSets(namespace).Delete(ctx, sts.Name, metav1.DeleteOptions{}))
+			}
+		}
+	}
+
+	services, err := kubernetesClient.CoreV1().Services(namespace).List(ctx, selector)
+	if err != nil {
+		j.logger.Errorf("Failed to list Services: %v", err)
+	} else {
+		for _, service := range services.Items {
+			if j.leftover(service.ObjectMeta) {
+				j.delete("service", service.Name, kubernetesClient.CoreV1().Services(namespace).Delete(ctx, service.Name, metav1.DeleteOptions{}))
+			}
+		}
+	}
+
+	// volume claims of the StatefulSets are kept when they are deleted.
+	claims, err := kubernetesClient.CoreV1().PersistentVolumeClaims(namespace).List(ctx, selector)
+	if err != nil {
+		j.logger.Errorf("Failed to list PersistentVolumeClaims: %v", err)
+	} else {
+		for _, claim := range claims.Items {
+			if j.leftover(claim.ObjectMeta) {
+				j.delete("pvc", claim.Name, kubernetesClient.CoreV1().PersistentVolumeClaims(namespace).Delete(ctx, claim.Name, metav1.DeleteOptions{}))
+			}
+		}
+	}
+
+	namespaces, err := kubernetesClient.CoreV1().Namespaces().List(ctx, selector)
+	if err != nil {
+		j.logger.Errorf("Failed to list namespaces: %v", err)
+	} else {
+		for _, ns := range namespaces.Items {
+			if j.leftover(ns.ObjectMeta) && ns.DeletionTimestamp == nil {
+				j.delete("namespace", ns.Name, kubernetesClient.CoreV1().Namespaces().Delete(ctx, ns.Name, metav1.DeleteOptions{}))
+			}
+		}
+	}
+
+	bindings, err := kubernetesClient.RbacV1().ClusterRoleBindings().List(ctx, selector)
+	if err != nil {
+		j.logger.Errorf("Failed to list ClusterRoleBindings: %v", err)
+	} else {
+		for _, binding := range bindings.Items {
+			if j.leftover(binding.ObjectMeta) {
+				j.delete("clusterrolebinding", binding.Name, kubernetesClient.RbacV1().ClusterRoleBindings().Delete(ctx, binding.Name, metav1.DeleteOptions{}))
+			}
+		}
+	}
+
+	err = j.cleanupExclusions(ctx)
+	if err != nil {
+		j.logger.Errorf("Failed to clean up allocation exclusions: %v", err)
+	}
+}
+
+func (j *janitor) delete(kind, name string, err error) {
+	if err != nil {
+		j.logger.Errorf("Failed to delete leftover %s %s: %v", kind, name, err)
+		return
+	}
+	j.logger.Infof("Deleted leftover %s %s", kind, name)
+}
+
+// cleanupExclusions removes IPs not belonging to any existing pod from the
+// allocation exclusions of the e2e clusters. IPs of existing pods are kept as
+// they may be drained by a concurrent run.
+func (j *janitor) cleanupExclusions(ctx context.Context) error {
+	pods, err := kubernetesClient.CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	podIPs := make(map[string]struct{}, len(pods.Items))
+	for _, pod := range pods.Items {
+		if pod.Status.PodIP != "" {
+			podIPs[pod.Status.PodIP] = struct{}{}
+		}
+	}
+
+	for _, client := range j.esClients {
+		settings, err := getExclusionSettings(client)
+		if err != nil {
+			j.logger.Errorf("Failed to get cluster settings of %s: %v", client.Endpoint, err)
+			continue
+		}
+
+		update := make(map[string]map[string]interface{})
+		for scope, ips := range settings {
+			kept, removed := filterExcludedIPs(ips, podIPs)
+			if len(removed) == 0 {
+				continue
+			}
+			var value interface{}
+			if kept != "" {
+				value = kept
+			}
+			update[scope] = map[string]interface{}{excludeIPsSetting: value}
+			j.logger.Infof("Removing leftover %s allocation exclusions %s from %s", scope, strings.Join(removed, ","), client.Endpoint)
+		}
+		if len(update) == 0 {
+			continue
+		}
+
+		resp, err := resty.NewWithClient(&http.Client{Transport: http.DefaultTransport}).R().
+			SetHeader("Content-Type", "application/json").
+			SetBody(update).
+			Put(client.Endpoint.String() + "/_cluster/settings")
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode() != http.StatusOK {
+			return fmt.Errorf("code status %d - %s", resp.StatusCode(), resp.Body())
+		}
+	}
+	return nil
+}
+
+// getExclusionSettings returns the excluded IPs by settings scope
+// (transient or persistent).
+func getExclusionSettings(client *operator.ESClient) (map[string]string, error) {
+	resp, err := resty.NewWithClient(&http.Client{Transport: http.DefaultTransport}).R().
+		Get(client.Endpoint.String() + "/_cluster/settings?flat_settings=true")
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, fmt.Errorf("code status %d - %s", resp.StatusCode(), resp.Body())
+	}
+
+	var settings map[string]map[string]interface{}
+	err = json.Unmarshal(resp.Body(), &settings)
+	if err != nil {
+		return nil, err
+	}
+
+	exclusions := make(map[string]string, 2)
+	for _, scope := range []string{"transient", "persistent"} {
+		if ips, ok := settings[scope][excludeIPsSetting].(string); ok && ips != "" {
+			exclusions[scope] = ips
+		}
+	}
+	return exclusions, nil
+}
+
+// filterExcludedIPs splits the comma separated excluded IPs into the IPs of
+// existing pods, which are kept, and the removed leftovers.
+func filterExcludedIPs(ips string, podIPs map[string]struct{}) (string, []string) {
+	kept := make([]string, 0)
+	removed := make([]string, 0)
+	for _, ip := range strings.Split(ips, ",") {
+		ip = strings.TrimSpace(ip)
+		if ip == "" {
+			continue
+		}
+		if _, ok := podIPs[ip]; ok {
+			kept = append(kept, ip)
+		} else {
+			removed = append(removed, ip)
+		}
+	}
+	return strings.Join(kept, ","), removed
+}
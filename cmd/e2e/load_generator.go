@@ -0,0 +1,196 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/zalando-incubator/es-operator/operator"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	loadGeneratorStressNG   = "stress-ng"
+	loadGeneratorBulkSearch = "bulk-search"
+	loadGeneratorRally      = "rally"
+)
+
+// loadGenerator generates load on the Elasticsearch nodes of an EDS used to
+// trigger the autoscaler.
+type loadGenerator interface {
+	// Container returns the sidecar container generating load against
+	// the Elasticsearch node of the pod. The index is the index the test
+	// creates for the EDS.
+	Container(index string) v1.Container
+	// ExercisesElasticsearch is true if the load goes through
+	// Elasticsearch rather than just burning CPU in the pod.
+	ExercisesElasticsearch() bool
+}
+
+// selectedLoadGenerator returns the load generator configured via
+// E2E_LOAD_GENERATOR defaulting to stress-ng.
+func selectedLoadGenerator() loadGenerator {
+	name := os.Getenv("E2E_LOAD_GENERATOR")
+	switch name {
+	case "", loadGeneratorStressNG:
+		return stressNGLoadGenerator{}
+	case loadGeneratorBulkSearch:
+		return bulkSearchLoadGenerator{}
+	case loadGeneratorRally:
+		return rallyLoadGenerator{track: envOrDefault("E2E_RALLY_TRACK", "geonames")}
+	default:
+		panic(fmt.Sprintf("unknown load generator '%s'", name))
+	}
+}
+
+func envOrDefault(envar, defaultValue string) string {
+	if value := os.Getenv(envar); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+func loadGeneratorResources(memory string) v1.ResourceRequirements {
+	return v1.ResourceRequirements{
+		Limits: v1.ResourceList{
+			v1.ResourceMemory: resource.MustParse(memory),
+			v1.ResourceCPU:    resource.MustParse("100m"),
+		},
+		Requests: v1.ResourceList{
+			v1.ResourceMemory: resource.MustParse(memory),
+			v1.ResourceCPU:    resource.MustParse("100m"),
+		},
+	}
+}
+
+// stressNGLoadGenerator burns CPU in a sidecar without touching
+// Elasticsearch.
+type stressNGLoadGenerator struct{}
+
+func (stressNGLoadGenerator) Container(_ string) v1.Container {
+	return v1.Container{
+		Name: "stress-ng",
+		// https://hub.docker.com/r/alexeiled/stress-ng/
+		Image:     "alexeiled/stress-ng",
+		Args:      []string{"--cpu=1", "--cpu-load=10"},
+		Resources: loadGeneratorResources("50Mi"),
+	}
+}
+
+func (stressNGLoadGenerator) ExercisesElasticsearch() bool {
+	return false
+}
+
+// bulkSearchLoadScript indexes documents into the test index and runs
+// concurrent searches against it once the index exists.
+const bulkSearchLoadScript = `
+index="$1"
+until curl -sf "localhost:9200/${index}" > /dev/null; do sleep 5; done
+bulk=""
+for i in $(seq 1 100); do
+  bulk="${bulk}{\"index\":{}}
+{\"message\":\"e2e load document ${i}\",\"value\":${i}}
+"
+done
+while true; do
+  printf '%s' "${bulk}" | curl -s -o /dev/null -H 'Content-Type: application/x-ndjson' \
+    --data-binary @- "localhost:9200/${index}/_bulk"
+  for j in 1 2 3 4; do
+    curl -s -o /dev/null -H 'Content-Type: application/json' \
+      -d '{"query":{"match":{"message":"load"}},"aggs":{"values":{"terms":{"field":"value"}}}}' \
+      "localhost:9200/${index}/_search" &
+  done
+  wait
+done
+`
+
+// bulkSearchLoadGenerator generates bulk indexing and search load against
+// the Elasticsearch node of the pod.
+type bulkSearchLoadGenerator struct{}
+
+func (bulkSearchLoadGenerator) Container(index string) v1.Container {
+	return v1.Container{
+		Name: "bulk-search",
+		// https://hub.docker.com/r/curlimages/curl
+		Image:     "curlimages/curl",
+		Command:   []string{"/bin/sh", "-c", bulkSearchLoadScript, "bulk-search", index},
+		Resources: loadGeneratorResources("50Mi"),
+	}
+}
+
+func (bulkSearchLoadGenerator) ExercisesElasticsearch() bool {
+	return true
+}
+
+// rallyLoadGenerator runs an esrally track in benchmark-only mode against
+// the Elasticsearch node of the pod. Note that the track creates its own
+// indices which are not restricted to the EDS group.
+type rallyLoadGenerator struct {
+	track string
+}
+
+func (g rallyLoadGenerator) Container(_ string) v1.Container {
+	return v1.Container{
+		Name: "rally",
+		// https://hub.docker.com/r/elastic/rally
+		Image:   "elastic/rally",
+		Command: []string{"/bin/sh", "-c"},
+		Args: []string{fmt.Sprintf(
+			"until curl -sf localhost:9200 > /dev/null; do sleep 5; done; "+
+				"while true; do esrally race --track=%s --target-hosts=localhost:9200 "+
+				"--pipeline=benchmark-only --test-mode --on-error=continue --kill-running-processes; done",
+			g.track,
+		)},
+		Resources: loadGeneratorResources("512Mi"),
+	}
+}
+
+func (rallyLoadGenerator) ExercisesElasticsearch() bool {
+	return true
+}
+
+// threadPoolStats are the summed thread pool stats of all nodes.
+type threadPoolStats struct {
+	Completed int64
+	Rejected  int64
+}
+
+// getThreadPoolStats returns the search and write thread pool stats summed
+// over all nodes of the cluster.
+func getThreadPoolStats(client *operator.ESClient) (map[string]threadPoolStats, error) {
+	resp, err := resty.NewWithClient(&http.Client{Transport: http.DefaultTransport}).R().
+		Get(client.Endpoint.String() + "/_nodes/stats/thread_pool?filter_path=nodes.*.thread_pool.search,nodes.*.thread_pool.write")
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, fmt.Errorf("code status %d - %s", resp.StatusCode(), resp.Body())
+	}
+
+	var nodesStats struct {
+		Nodes map[string]struct {
+			ThreadPool map[string]struct {
+				Completed int64 `json:"completed"`
+				Rejected  int64 `json:"rejected"`
+			} `json:"thread_pool"`
+		} `json:"nodes"`
+	}
+	err = json.Unmarshal(resp.Body(), &nodesStats)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make(map[string]threadPoolStats)
+	for _, node := range nodesStats.Nodes {
+		for name, pool := range node.ThreadPool {
+			s := stats[name]
+			s.Completed += pool.Completed
+			s.Rejected += pool.Rejected
+			stats[name] = s
+		}
+	}
+	return stats, nil
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestMain(m *testing.M) {
+	if os.Getenv("E2E_JANITOR") != "false" {
+		j, err := newJanitor()
+		if err != nil {
+			logrus.Fatalf("Failed to set up janitor: %v", err)
+		}
+		j.run(context.Background())
+	}
+	os.Exit(m.Run())
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cenk/backoff"
+	"github.com/stretchr/testify/require"
+	"github.com/zalando-incubator/es-operator/operator"
+	"github.com/zalando-incubator/es-operator/pkg/await"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	defaultSoakOperatorSelector = "application=es-operator,!component"
+	soakDrainingAnnotationKey   = "operator.zalando.org/draining"
+	soakEDSName                 = "soak"
+)
+
+// soakAction is a single step of the soak test disrupting the EDS.
+type soakAction struct {
+	name string
+	run  func(iteration int) (replicas int32)
+}
+
+// TestEDSSoak continuously scales, rolls and restarts the operator for
+// E2E_SOAK_DURATION and verifies after every step that no drain is stuck and
+// no allocation exclusions are leaked. It's skipped unless
+// E2E_SOAK_DURATION is set and should not run in parallel with other tests
+// as it checks the cluster wide exclusions and restarts the operator.
+func TestEDSSoak(t *testing.T) {
+	value := os.Getenv("E2E_SOAK_DURATION")
+	if value == "" {
+		t.Skip("E2E_SOAK_DURATION not set")
+	}
+	duration, err := time.ParseDuration(value)
+	require.NoError(t, err)
+	env := newTestEnvironment(t)
+
+	versions := strings.Split(envOrDefault("E2E_SOAK_VERSIONS", "8.6.2,8.6.0"), ",")
+	operatorSelector := envOrDefault("E2E_SOAK_OPERATOR_SELECTOR", defaultSoakOperatorSelector)
+
+	edsSpec := NewTestEDSSpecFactory(soakEDSName, versions[0], "es8-config").Create()
+	err = env.createEDS(soakEDSName, edsSpec)
+	require.NoError(t, err)
+	verifyEDS(env, soakEDSName, edsSpec, edsSpec.Replicas)
+
+	esClient, err := setupESClient(env.serviceEndpoint(soakEDSName), versions[0])
+	require.NoError(t, err)
+	err = backoff.Retry(func() error {
+		return esClient.CreateIndex(soakEDSName, soakEDSName, 3, 0)
+	}, backoff.NewExponentialBackOff())
+	require.NoError(t, err)
+
+	actions := []soakAction{
+		{name: "scale up", run: func(_ int) int32 {
+			return soakUpdateEDS(env, 3, "")
+		}},
+		{name: "roll version", run: func(iteration int) int32 {
+			version := versions[iteration%len(versions)]
+			return soakUpdateEDS(env, 0, "docker.elastic.co/elasticsearch/elasticsearch:"+version)
+		}},
+		{name: "restart operator", run: func(_ int) int32 {
+			restartOperator(env, operatorSelector)
+			return soakReplicas(env)
+		}},
+		{name: "scale down", run: func(_ int) int32 {
+			return soakUpdateEDS(env, 1, "")
+		}},
+	}
+
+	deadline := time.Now().Add(duration)
+	for iteration := 1; time.Now().Before(deadline); iteration++ {
+		for _, action := range actions {
+			t.Logf("Soak iteration %d: %s", iteration, action.name)
+			replicas := action.run(iteration)
+
+			err := env.waitForSTSCondition(soakEDSName, expectedStsStatus{
+				replicas:        &replicas,
+				updatedReplicas: &replicas,
+				readyReplicas:   &replicas,
+			}.matches)
+			require.NoError(t, err)
+
+			verifyNoStuckDrains(env)
+			verifyNoLeakedExclusions(t, esClient)
+		}
+	}
+
+	err = esClient.DeleteIndex(soakEDSName)
+	require.NoError(t, err)
+	err = env.deleteEDS(soakEDSName)
+	require.NoError(t, err)
+	verifyNoOrphanedResources(env, soakEDSName)
+}
+
+// soakUpdateEDS updates the replicas and/or the image of the soak EDS and
+// returns the expected replicas.
+func soakUpdateEDS(env *testEnvironment, replicas int32, image string) int32 {
+	eds, err := env.edsInterface().Get(context.Background(), soakEDSName, metav1.GetOptions{})
+	require.NoError(env.t, err)
+	if replicas > 0 {
+		eds.Spec.Replicas = &replicas
+	}
+	if image != "" {
+		eds.Spec.Template.Spec.Containers[0].Image = image
+	}
+	err = env.updateEDS(soakEDSName, eds)
+	require.NoError(env.t, err)
+	return *eds.Spec.Replicas
+}
+
+func soakReplicas(env *testEnvironment) int32 {
+	eds, err := env.edsInterface().Get(context.Background(), soakEDSName, metav1.GetOptions{})
+	require.NoError(env.t, err)
+	return *eds.Spec.Replicas
+}
+
+// restartOperator deletes the operator pods and waits for a replacement to be
+// ready.
+func restartOperator(env *testEnvironment, selector string) {
+	t := env.t
+	pods := kubernetesClient.CoreV1().Pods(env.namespace)
+	list, err := pods.List(context.Background(), metav1.ListOptions{LabelSelector: selector})
+	require.NoError(t, err)
+	require.NotEmpty(t, list.Items, "no operator pods matching '%s'", selector)
+
+	restarted := make(map[string]struct{}, len(list.Items))
+	for _, pod := range list.Items {
+		restarted[pod.Name] = struct{}{}
+		err := pods.Delete(context.Background(), pod.Name, metav1.DeleteOptions{})
+		if err != nil && !apiErrors.IsNotFound(err) {
+			require.NoError(t, err)
+		}
+	}
+
+	err = newAwaiter(t, "operator to be restarted", func(ctx context.Context) (bool, error) {
+		list, err := pods.List(ctx, metav1.ListOptions{LabelSelector: selector})
+		if err != nil {
+			return false, err
+		}
+		for _, pod := range list.Items {
+			if _, ok := restarted[pod.Name]; ok || pod.DeletionTimestamp != nil {
+				continue
+			}
+			for _, condition := range pod.Status.Conditions {
+				if condition.Type == "Ready" && condition.Status == "True" {
+					return false, nil
+				}
+			}
+		}
+		return true, fmt.Errorf("no ready operator pod matching '%s'", selector)
+	}).Await(context.Background())
+	require.NoError(t, err)
+}
+
+// verifyNoStuckDrains verifies that no pod of the soak EDS is still marked
+// draining after the EDS reached the desired state.
+func verifyNoStuckDrains(env *testEnvironment) {
+	t := env.t
+	err := newAwaiter(t, "drains to finish", func(ctx context.Context) (bool, error) {
+		list, err := kubernetesClient.CoreV1().Pods(env.namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return false, err
+		}
+		for _, pod := range list.Items {
+			if !strings.HasPrefix(pod.Name, soakEDSName+"-") {
+				continue
+			}
+			if _, ok := pod.Annotations[soakDrainingAnnotationKey]; ok {
+				return true, fmt.Errorf("pod %s is still marked draining", pod.Name)
+			}
+		}
+		return false, nil
+	}).WithTimeout(5 * time.Minute).Await(context.Background())
+	require.NoError(t, err)
+}
+
+// verifyNoLeakedExclusions verifies that the cluster has no IPs excluded from
+// shard allocation once all drains are finished.
+func verifyNoLeakedExclusions(t *testing.T, esClient *operator.ESClient) {
+	err := newAwaiter(t, "allocation exclusions to be cleaned up", func(_ context.Context) (bool, error) {
+		excluded, err := getExcludedIPs(esClient)
+		if err != nil {
+			return true, err
+		}
+		if excluded != "" {
+			return true, fmt.Errorf("IPs still excluded from allocation: %s", excluded)
+		}
+		return false, nil
+	}).WithTimeout(5 * time.Minute).Await(context.Background())
+	require.NoError(t, err)
+}
+
+// getExcludedIPs returns the transient and persistent IPs excluded from
+// shard allocation.
+func getExcludedIPs(esClient *operator.ESClient) (string, error) {
+	settings, err := getExclusionSettings(esClient)
+	if err != nil {
+		return "", err
+	}
+
+	excluded := make([]string, 0, len(settings))
+	for _, scope := range []string{"transient", "persistent"} {
+		if ips, ok := settings[scope]; ok {
+			excluded = append(excluded, ips)
+		}
+	}
+	return strings.Join(excluded, ","), nil
+}
+
+// verifyNoOrphanedResources verifies that the StatefulSet and Service of a
+// deleted EDS are garbage collected.
+func verifyNoOrphanedResources(env *testEnvironment, name string) {
+	t := env.t
+	err := configureAwaiter(t, await.Deleted(env.statefulSetInterface(), "sts", name)).Await(context.Background())
+	require.NoError(t, err)
+	err = configureAwaiter(t, await.Deleted(env.serviceInterface(), "service", name)).Await(context.Background())
+	require.NoError(t, err)
+}
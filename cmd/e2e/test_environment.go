@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"net/url"
 	"os"
+	"strings"
+	"testing"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -22,8 +24,37 @@ var (
 	kubernetesClient, edsClient = createClients()
 	namespace                   = requiredEnvar("E2E_NAMESPACE")
 	operatorId                  = requiredEnvar("OPERATOR_ID")
+	imageRewriteRules           = parseImageRewriteRules(os.Getenv("E2E_IMAGE_REWRITE"))
+	isolateNamespaces           = os.Getenv("E2E_ISOLATE_NAMESPACES") == "true"
 )
 
+// testEnvironment is the namespace and operator a test runs against.
+type testEnvironment struct {
+	t          *testing.T
+	namespace  string
+	operatorID string
+}
+
+// newTestEnvironment returns the environment of the test. If
+// E2E_ISOLATE_NAMESPACES is set, the test gets a fresh namespace with its own
+// operator, which are removed when the test finishes. Otherwise the test
+// runs in the shared E2E_NAMESPACE.
+func newTestEnvironment(t *testing.T) *testEnvironment {
+	if !isolateNamespaces {
+		return &testEnvironment{
+			t:          t,
+			namespace:  namespace,
+			operatorID: operatorId,
+		}
+	}
+	return newIsolatedTestEnvironment(t)
+}
+
+// serviceEndpoint returns the in-cluster endpoint of the EDS service.
+func (env *testEnvironment) serviceEndpoint(edsName string) string {
+	return fmt.Sprintf("http://%s.%s:9200", edsName, env.namespace)
+}
+
 func init() {
 	logrus.SetFormatter(&logrus.TextFormatter{ForceColors: true})
 }
@@ -53,16 +84,16 @@ func createClients() (kubernetes.Interface, clientset.Interface) {
 	return kubeClient, edsClient
 }
 
-func edsInterface() zv1client.ElasticsearchDataSetInterface {
-	return edsClient.ZalandoV1().ElasticsearchDataSets(namespace)
+func (env *testEnvironment) edsInterface() zv1client.ElasticsearchDataSetInterface {
+	return edsClient.ZalandoV1().ElasticsearchDataSets(env.namespace)
 }
 
-func statefulSetInterface() appsv1.StatefulSetInterface {
-	return kubernetesClient.AppsV1().StatefulSets(namespace)
+func (env *testEnvironment) statefulSetInterface() appsv1.StatefulSetInterface {
+	return kubernetesClient.AppsV1().StatefulSets(env.namespace)
 }
 
-func serviceInterface() v1.ServiceInterface {
-	return kubernetesClient.CoreV1().Services(namespace)
+func (env *testEnvironment) serviceInterface() v1.ServiceInterface {
+	return kubernetesClient.CoreV1().Services(env.namespace)
 }
 
 func requiredEnvar(envar string) string {
@@ -73,6 +104,23 @@ func requiredEnvar(envar string) string {
 	return namespace
 }
 
+// parseImageRewriteRules parses image rewrite rules in the same
+// '<from-prefix>=<to-prefix>,+' format as the operator's --image-rewrite flag.
+func parseImageRewriteRules(value string) operator.ImageRewriteRules {
+	rules := operator.ImageRewriteRules{}
+	if value == "" {
+		return rules
+	}
+	for _, rule := range strings.Split(value, ",") {
+		kv := strings.SplitN(rule, "=", 2)
+		if len(kv) != 2 {
+			panic(fmt.Sprintf("invalid image rewrite rule '%s'", rule))
+		}
+		rules[kv[0]] = kv[1]
+	}
+	return rules
+}
+
 func setupESClient(defaultServiceEndpoint, version string) (*operator.ESClient, error) {
 	var envSuffix string
 	if len(version) > 0 {
@@ -3,7 +3,6 @@ package main
 import (
 	"context"
 	"fmt"
-	"reflect"
 	"testing"
 	"time"
 
@@ -12,10 +11,10 @@ import (
 	"k8s.io/apimachinery/pkg/util/intstr"
 
 	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	"github.com/zalando-incubator/es-operator/pkg/await"
 
 	v1 "k8s.io/api/core/v1"
 
-	apiErrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -111,110 +110,45 @@ var (
 			},
 		}
 	}
-	edsPodSpecCPULoadContainer = func(nodeGroup, version, configMap string) v1.PodSpec {
+	edsPodSpecWithLoad = func(nodeGroup, version, configMap string, load loadGenerator) v1.PodSpec {
 		podSpec := edsPodSpec(nodeGroup, version, configMap)
-		podSpec.Containers = append(podSpec.Containers, v1.Container{
-			Name: "stress-ng",
-			// https://hub.docker.com/r/alexeiled/stress-ng/
-			Image: "alexeiled/stress-ng",
-			Args:  []string{"--cpu=1", "--cpu-load=10"},
-			Resources: v1.ResourceRequirements{
-				Limits: v1.ResourceList{
-					v1.ResourceMemory: resource.MustParse("50Mi"),
-					v1.ResourceCPU:    resource.MustParse("100m"),
-				},
-				Requests: v1.ResourceList{
-					v1.ResourceMemory: resource.MustParse("50Mi"),
-					v1.ResourceCPU:    resource.MustParse("100m"),
-				},
-			},
-		})
+		podSpec.Containers = append(podSpec.Containers, load.Container(nodeGroup))
 		return podSpec
 	}
 )
 
-type awaiter struct {
-	t           *testing.T
-	description string
-	timeout     time.Duration
-	poll        func() (retry bool, err error)
-}
-
-func (a *awaiter) withTimeout(timeout time.Duration) *awaiter {
-	a.timeout = timeout
-	return a
-}
-
-func (a *awaiter) withPoll(poll func() (retry bool, err error)) *awaiter {
-	a.poll = poll
-	return a
-}
-
-func newAwaiter(t *testing.T, description string) *awaiter {
-	return &awaiter{
-		t:           t,
-		description: description,
-		timeout:     defaultWaitTimeout,
-	}
+// newAwaiter returns an awaiter logging to the test.
+func newAwaiter(t *testing.T, description string, poll await.Poll) *await.Awaiter {
+	return configureAwaiter(t, await.New(description, poll))
 }
 
-func (a *awaiter) await() error {
-	deadline := time.Now().Add(a.timeout)
-	a.t.Logf("Waiting for %s until %s (UTC)...", a.description, deadline.Format("3:04PM"))
-	for {
-		retry, err := a.poll()
-		if err != nil {
-			a.t.Logf("%v", err)
-			if retry && time.Now().Before(deadline) {
-				time.Sleep(30 * time.Second)
-				continue
-			}
-			return err
-		}
-		a.t.Logf("Finished waiting for %s", a.description)
-		return nil
-	}
+// configureAwaiter applies the test defaults to the awaiter.
+func configureAwaiter(t *testing.T, awaiter *await.Awaiter) *await.Awaiter {
+	return awaiter.WithTimeout(defaultWaitTimeout).WithLogger(t.Logf)
 }
 
-func resourceCreated(t *testing.T, kind string, name string, k8sInterface interface{}) *awaiter {
-	get := reflect.ValueOf(k8sInterface).MethodByName("Get")
-	return newAwaiter(t, fmt.Sprintf("creation of %s %s", kind, name)).withPoll(func() (bool, error) {
-		result := get.Call([]reflect.Value{
-			reflect.ValueOf(context.Background()),
-			reflect.ValueOf(name),
-			reflect.ValueOf(metav1.GetOptions{}),
-		})
-		err := result[1].Interface()
-		if err != nil {
-			t.Logf("%v", err)
-			return apiErrors.IsNotFound(err.(error)), err.(error)
-		}
-		return false, nil
-	})
-}
-
-func waitForEDS(t *testing.T, name string) (*zv1.ElasticsearchDataSet, error) {
-	err := resourceCreated(t, "eds", name, edsInterface()).await()
+func (env *testEnvironment) waitForEDS(name string) (*zv1.ElasticsearchDataSet, error) {
+	err := configureAwaiter(env.t, await.Created(env.edsInterface(), "eds", name)).Await(context.Background())
 	if err != nil {
 		return nil, err
 	}
-	return edsInterface().Get(context.Background(), name, metav1.GetOptions{})
+	return env.edsInterface().Get(context.Background(), name, metav1.GetOptions{})
 }
 
-func waitForStatefulSet(t *testing.T, name string) (*appsv1.StatefulSet, error) {
-	err := resourceCreated(t, "sts", name, statefulSetInterface()).await()
+func (env *testEnvironment) waitForStatefulSet(name string) (*appsv1.StatefulSet, error) {
+	err := configureAwaiter(env.t, await.Created(env.statefulSetInterface(), "sts", name)).Await(context.Background())
 	if err != nil {
 		return nil, err
 	}
-	return statefulSetInterface().Get(context.Background(), name, metav1.GetOptions{})
+	return env.statefulSetInterface().Get(context.Background(), name, metav1.GetOptions{})
 }
 
-func waitForService(t *testing.T, name string) (*v1.Service, error) {
-	err := resourceCreated(t, "service", name, serviceInterface()).await()
+func (env *testEnvironment) waitForService(name string) (*v1.Service, error) {
+	err := configureAwaiter(env.t, await.Created(env.serviceInterface(), "service", name)).Await(context.Background())
 	if err != nil {
 		return nil, err
 	}
-	return serviceInterface().Get(context.Background(), name, metav1.GetOptions{})
+	return env.serviceInterface().Get(context.Background(), name, metav1.GetOptions{})
 }
 
 type expectedStsStatus struct {
@@ -240,39 +174,15 @@ func (expected expectedStsStatus) matches(sts *appsv1.StatefulSet) error {
 	return nil
 }
 
-func waitForEDSCondition(t *testing.T, name string, conditions ...func(eds *zv1.ElasticsearchDataSet) error) error {
-	return newAwaiter(t, fmt.Sprintf("eds %s to reach desired condition", name)).withPoll(func() (retry bool, err error) {
-		eds, err := edsInterface().Get(context.Background(), name, metav1.GetOptions{})
-		if err != nil {
-			return false, err
-		}
-		for _, condition := range conditions {
-			err := condition(eds)
-			if err != nil {
-				return true, err
-			}
-		}
-		return true, nil
-	}).await()
+func (env *testEnvironment) waitForEDSCondition(name string, conditions ...func(eds *zv1.ElasticsearchDataSet) error) error {
+	return configureAwaiter(env.t, await.Condition(env.edsInterface(), "eds", name, conditions...)).Await(context.Background())
 }
 
-func waitForSTSCondition(t *testing.T, stsName string, conditions ...func(sts *appsv1.StatefulSet) error) error {
-	return newAwaiter(t, fmt.Sprintf("sts %s to reach desired condition", stsName)).withPoll(func() (retry bool, err error) {
-		sts, err := statefulSetInterface().Get(context.Background(), stsName, metav1.GetOptions{})
-		if err != nil {
-			return false, err
-		}
-		for _, condition := range conditions {
-			err := condition(sts)
-			if err != nil {
-				return true, err
-			}
-		}
-		return true, nil
-	}).await()
+func (env *testEnvironment) waitForSTSCondition(stsName string, conditions ...func(sts *appsv1.StatefulSet) error) error {
+	return configureAwaiter(env.t, await.Condition(env.statefulSetInterface(), "sts", stsName, conditions...)).Await(context.Background())
 }
 
-func createEDS(name string, spec zv1.ElasticsearchDataSetSpec) error {
+func (env *testEnvironment) createEDS(name string, spec zv1.ElasticsearchDataSetSpec) error {
 	myspec := spec.DeepCopy()
 	for i, env := range myspec.Template.Spec.Containers[0].Env {
 		if env.Name == "node.attr.group" {
@@ -283,27 +193,41 @@ func createEDS(name string, spec zv1.ElasticsearchDataSetSpec) error {
 	eds := &zv1.ElasticsearchDataSet{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      name,
-			Namespace: namespace,
+			Namespace: env.namespace,
+			Labels:    runLabels(),
 			Annotations: map[string]string{
-				"es-operator.zalando.org/operator": operatorId,
+				"es-operator.zalando.org/operator": env.operatorID,
 			},
 		},
 		Spec: *myspec,
 	}
-	_, err := edsInterface().Create(context.Background(), eds, metav1.CreateOptions{})
+	rewriteImages(&eds.Spec.Template.Spec)
+	_, err := env.edsInterface().Create(context.Background(), eds, metav1.CreateOptions{})
 	return err
 }
 
-func updateEDS(name string, eds *zv1.ElasticsearchDataSet) error {
-	_, err := edsInterface().Update(context.Background(), eds, metav1.UpdateOptions{})
+func (env *testEnvironment) updateEDS(name string, eds *zv1.ElasticsearchDataSet) error {
+	rewriteImages(&eds.Spec.Template.Spec)
+	_, err := env.edsInterface().Update(context.Background(), eds, metav1.UpdateOptions{})
 	return err
 }
 
-func deleteEDS(name string) error {
-	err := edsInterface().Delete(context.Background(), name, metav1.DeleteOptions{GracePeriodSeconds: pint64(10)})
+func (env *testEnvironment) deleteEDS(name string) error {
+	err := env.edsInterface().Delete(context.Background(), name, metav1.DeleteOptions{GracePeriodSeconds: pint64(10)})
 	return err
 }
 
+// rewriteImages applies the E2E_IMAGE_REWRITE rules to all containers of the
+// pod spec so the tests can run against a registry mirror.
+func rewriteImages(spec *v1.PodSpec) {
+	for i := range spec.InitContainers {
+		spec.InitContainers[i].Image = imageRewriteRules.Rewrite(spec.InitContainers[i].Image)
+	}
+	for i := range spec.Containers {
+		spec.Containers[i].Image = imageRewriteRules.Rewrite(spec.Containers[i].Image)
+	}
+}
+
 func pbool(b bool) *bool {
 	return &b
 }
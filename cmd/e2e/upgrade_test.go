@@ -8,19 +8,20 @@ import (
 
 func TestEDSUpgradingEDS(t *testing.T) {
 	t.Parallel()
+	env := newTestEnvironment(t)
 	edsName := "upgrade"
-	edsSpec := testEDSCreate(t, edsName, "8.6.2", "es8-config")
-	eds := verifyEDS(t, edsName, edsSpec, edsSpec.Replicas)
+	edsSpec := testEDSCreate(env, edsName, "8.6.2", "es8-config")
+	eds := verifyEDS(env, edsName, edsSpec, edsSpec.Replicas)
 	// this could become a test for a major version upgrade in the future.
 	eds.Spec.Template.Spec.Containers[0].Image = "docker.elastic.co/elasticsearch/elasticsearch:8.6.0"
 
 	var err error
-	eds, err = waitForEDS(t, edsName)
+	eds, err = env.waitForEDS(edsName)
 	require.NoError(t, err)
-	err = updateEDS(edsName, eds)
+	err = env.updateEDS(edsName, eds)
 	require.NoError(t, err)
 
-	verifyEDS(t, edsName, eds.Spec, eds.Spec.Replicas)
-	err = deleteEDS(edsName)
+	verifyEDS(env, edsName, eds.Spec, eds.Spec.Replicas)
+	err = env.deleteEDS(edsName)
 	require.NoError(t, err)
 }
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	debugContainerPrefix = "es-diag-"
+	debugPodLabel        = "es-operator.zalando.org/diagnostics-pod"
+	debugOutputKey       = "diagnostics.txt"
+	debugPollInterval    = 2 * time.Second
+	// maxDebugOutput keeps the output within the size limit of a
+	// ConfigMap.
+	maxDebugOutput = 1000 * 1024
+)
+
+// debugDiagnosticsScript collects the state of the local Elasticsearch node
+// and the cluster. It only relies on sh and curl, which are available in
+// the Elasticsearch images.
+const debugDiagnosticsScript = `es=http://localhost:9200
+for path in \
+  "/_cluster/health?pretty" \
+  "/_cat/nodes?v&h=ip,name,node.role,heap.percent,ram.percent,cpu,load_1m,disk.used_percent" \
+  "/_nodes/_local/stats/jvm,os,process,thread_pool,breaker,fs?pretty" \
+  "/_nodes/_local/hot_threads?threads=10" \
+  "/_cat/shards?v&h=index,shard,prirep,state,docs,store,ip,node,unassigned.reason" \
+  "/_cat/recovery?v&active_only=true" \
+  "/_cluster/allocation/explain?pretty" \
+  "/_cluster/pending_tasks?pretty" \
+  "/_tasks?detailed=true&pretty"; do
+  echo "### GET ${path}"
+  curl -s -m 30 "${es}${path}"
+  echo
+done
+`
+
+// debugTarget returns the namespace and name of the pod given as
+// '<namespace>/<name>' or '<name>'.
+func debugTarget(target, defaultNamespace string) (string, string) {
+	if namespace, name, ok := strings.Cut(target, "/"); ok {
+		return namespace, name
+	}
+	if defaultNamespace == "" {
+		defaultNamespace = "default"
+	}
+	return defaultNamespace, target
+}
+
+// debugContainer returns the ephemeral container running the diagnostics
+// script in the process namespace of the Elasticsearch container of the
+// pod. It uses the image of the Elasticsearch container if no image is
+// given.
+func debugContainer(pod *v1.Pod, name, image string) (v1.EphemeralContainer, error) {
+	if len(pod.Spec.Containers) == 0 {
+		return v1.EphemeralContainer{}, fmt.Errorf("pod %s/%s has no containers", pod.Namespace, pod.Name)
+	}
+	target := pod.Spec.Containers[0]
+	if image == "" {
+		image = target.Image
+	}
+	return v1.EphemeralContainer{
+		EphemeralContainerCommon: v1.EphemeralContainerCommon{
+			Name:                     name,
+			Image:                    image,
+			Command:                  []string{"sh", "-c", debugDiagnosticsScript},
+			TerminationMessagePolicy: v1.TerminationMessageFallbackToLogsOnError,
+		},
+		TargetContainerName: target.Name,
+	}, nil
+}
+
+// debugConfigMap returns the ConfigMap storing the diagnostics output of the
+// pod. The output is truncated to fit into a ConfigMap.
+func debugConfigMap(pod *v1.Pod, container string, output []byte) *v1.ConfigMap {
+	data := string(output)
+	if len(data) > maxDebugOutput {
+		data = data[:maxDebugOutput] + "\n[truncated]\n"
+	}
+	return &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%s", pod.Name, container),
+			Namespace: pod.Namespace,
+			Labels: map[string]string{
+				debugPodLabel: pod.Name,
+			},
+		},
+		Data: map[string]string{
+			debugOutputKey: data,
+		},
+	}
+}
+
+// attachDebugContainer attaches an ephemeral container running the
+// diagnostics script to the pod, waits for it to finish and stores its
+// output in a ConfigMap and, if given, the output file.
+func attachDebugContainer(ctx context.Context, client kubernetes.Interface, namespace, name, image, output string, timeout time.Duration) error {
+	pod, err := client.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	containerName := debugContainerPrefix + time.Now().UTC().Format("20060102t150405")
+	container, err := debugContainer(pod, containerName, image)
+	if err != nil {
+		return err
+	}
+	pod.Spec.EphemeralContainers = append(pod.Spec.EphemeralContainers, container)
+	_, err = client.CoreV1().Pods(namespace).UpdateEphemeralContainers(ctx, name, pod, metav1.UpdateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to attach ephemeral container to pod %s/%s: %v", namespace, name, err)
+	}
+	fmt.Printf("Attached ephemeral container %s to pod %s/%s\n", containerName, namespace, name)
+
+	err = wait.PollUntilContextTimeout(ctx, debugPollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+		pod, err := client.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		for _, status := range pod.Status.EphemeralContainerStatuses {
+			if status.Name == containerName {
+				return status.State.Terminated != nil, nil
+			}
+		}
+		return false, nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed waiting for ephemeral container %s to finish: %v", containerName, err)
+	}
+
+	logs, err := client.CoreV1().Pods(namespace).GetLogs(name, &v1.PodLogOptions{Container: containerName}).DoRaw(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get the output of ephemeral container %s: %v", containerName, err)
+	}
+
+	cm, err := client.CoreV1().ConfigMaps(namespace).Create(ctx, debugConfigMap(pod, containerName, logs), metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to store diagnostics: %v", err)
+	}
+	fmt.Printf("Stored diagnostics in ConfigMap %s/%s\n", cm.Namespace, cm.Name)
+
+	if output != "" {
+		err = os.WriteFile(output, logs, 0o644)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Wrote diagnostics to %s\n", output)
+	}
+	return nil
+}
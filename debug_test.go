@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestDebugTarget(t *testing.T) {
+	namespace, name := debugTarget("logging/es-data-0", "default")
+	require.Equal(t, "logging", namespace)
+	require.Equal(t, "es-data-0", name)
+
+	namespace, name = debugTarget("es-data-0", "")
+	require.Equal(t, "default", namespace)
+	require.Equal(t, "es-data-0", name)
+}
+
+func TestDebugConfigMap(t *testing.T) {
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "es-data-0", Namespace: "default"}}
+	cm := debugConfigMap(pod, "es-diag-1", []byte(strings.Repeat("a", maxDebugOutput+1)))
+	require.Equal(t, "es-data-0-es-diag-1", cm.Name)
+	require.Equal(t, "es-data-0", cm.Labels[debugPodLabel])
+	require.True(t, strings.HasSuffix(cm.Data[debugOutputKey], "[truncated]\n"))
+}
+
+func TestAttachDebugContainer(t *testing.T) {
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "es-data-0", Namespace: "default"},
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{{Name: "elasticsearch", Image: "elasticsearch:8.6.2"}},
+		},
+	}
+	client := fake.NewSimpleClientset(pod)
+
+	// the kubelet reports the ephemeral container as terminated.
+	client.PrependReactor("get", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		obj, err := client.Tracker().Get(v1.SchemeGroupVersion.WithResource("pods"), "default", "es-data-0")
+		if err != nil {
+			return true, nil, err
+		}
+		current := obj.(*v1.Pod).DeepCopy()
+		for _, container := range current.Spec.EphemeralContainers {
+			current.Status.EphemeralContainerStatuses = append(current.Status.EphemeralContainerStatuses, v1.ContainerStatus{
+				Name:  container.Name,
+				State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{}},
+			})
+		}
+		return true, current, nil
+	})
+
+	err := attachDebugContainer(context.Background(), client, "default", "es-data-0", "", "", time.Second)
+	require.NoError(t, err)
+
+	updated, err := client.CoreV1().Pods("default").Get(context.Background(), "es-data-0", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Len(t, updated.Spec.EphemeralContainers, 1)
+	container := updated.Spec.EphemeralContainers[0]
+	require.Equal(t, "elasticsearch:8.6.2", container.Image)
+	require.Equal(t, "elasticsearch", container.TargetContainerName)
+
+	cms, err := client.CoreV1().ConfigMaps("default").List(context.Background(), metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, cms.Items, 1)
+	require.Equal(t, "fake logs", cms.Items[0].Data[debugOutputKey])
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/pprof"
+	"net/url"
+	"os"
+	"time"
+)
+
+const (
+	diagnosticsPath = "/debug/diagnostics"
+)
+
+// registerDiagnostics registers the pprof and diagnostics endpoints on the
+// mux.
+func registerDiagnostics(mux *http.ServeMux, diagnostics http.Handler) {
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle(diagnosticsPath, diagnostics)
+}
+
+// dumpDiagnostics fetches the diagnostics from a running operator and writes
+// them to output. If output is empty a timestamped file is created in the
+// current directory.
+func dumpDiagnostics(address *url.URL, output string, timeout time.Duration) error {
+	if output == "" {
+		output = fmt.Sprintf("es-operator-diagnostics-%s.json", time.Now().UTC().Format("20060102T150405Z"))
+	}
+
+	err := download(address.JoinPath(diagnosticsPath), output, timeout)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Wrote diagnostics to %s\n", output)
+	return nil
+}
+
+// download writes the response body of a GET request to the URL to the
+// output file.
+func download(u *url.URL, output string, timeout time.Duration) error {
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Get(u.String())
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("code status %d - %s", resp.StatusCode, body)
+	}
+
+	f, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(f, resp.Body)
+	return err
+}
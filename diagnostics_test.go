@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDumpDiagnostics(t *testing.T) {
+	mux := http.NewServeMux()
+	registerDiagnostics(mux, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `{"goroutines":1}`)
+	}))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	address, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output := filepath.Join(t.TempDir(), "diagnostics.json")
+	err = dumpDiagnostics(address, output, time.Second)
+	if err != nil {
+		t.Fatalf("failed to dump diagnostics: %v", err)
+	}
+
+	content, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != `{"goroutines":1}` {
+		t.Errorf("unexpected diagnostics: %s", content)
+	}
+
+	// diagnostics not enabled
+	server.Config.Handler = http.NotFoundHandler()
+	err = dumpDiagnostics(address, output, time.Second)
+	if err == nil {
+		t.Error("expected error when diagnostics are not enabled")
+	}
+}
@@ -22,6 +22,9 @@ SRC="github.com"
 GOPKG="$SRC/zalando-incubator/es-operator"
 CUSTOM_RESOURCE_NAME="zalando.org"
 CUSTOM_RESOURCE_VERSION="v1"
+# versions which are only served and converted to the stored version, no
+# clients are generated for them.
+CONVERTED_RESOURCE_VERSIONS="v2"
 
 SCRIPT_ROOT="$(dirname "${BASH_SOURCE[0]}")/.."
 
@@ -35,7 +38,8 @@ go run k8s.io/code-generator/cmd/deepcopy-gen \
   --output-file zz_generated.deepcopy.go \
   --bounding-dirs "${APIS_PKG}" \
   --go-header-file "${SCRIPT_ROOT}/hack/boilerplate.go.txt" \
-  "${APIS_PKG}/${CUSTOM_RESOURCE_NAME}/${CUSTOM_RESOURCE_VERSION}"
+  "${APIS_PKG}/${CUSTOM_RESOURCE_NAME}/${CUSTOM_RESOURCE_VERSION}" \
+  $(for version in ${CONVERTED_RESOURCE_VERSIONS}; do echo "${APIS_PKG}/${CUSTOM_RESOURCE_NAME}/${version}"; done)
 
 echo "Generating clientset for ${GROUPS_WITH_VERSIONS} at ${OUTPUT_PKG}/${CLIENTSET_PKG_NAME:-clientset}"
 go run k8s.io/code-generator/cmd/client-gen \
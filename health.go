@@ -0,0 +1,28 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/zalando-incubator/es-operator/operator"
+)
+
+const healthPath = "/healthz"
+
+// health is the response of the health endpoint.
+type health struct {
+	Status       string                                     `json:"status"`
+	Version      string                                     `json:"version"`
+	FeatureGates map[operator.Feature]operator.FeatureState `json:"featureGates"`
+}
+
+// healthHandler reports that the operator is running, its version and the
+// state of its feature gates.
+func healthHandler(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(health{
+		Status:       "ok",
+		Version:      version,
+		FeatureGates: operator.FeatureStates(),
+	})
+}
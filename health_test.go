@@ -0,0 +1,28 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/zalando-incubator/es-operator/operator"
+)
+
+func TestHealthHandler(t *testing.T) {
+	gates, err := operator.ParseFeatureGates("PredictiveScaling=true")
+	require.NoError(t, err)
+	operator.SetFeatureGates(gates)
+	defer operator.SetFeatureGates(nil)
+
+	recorder := httptest.NewRecorder()
+	healthHandler(recorder, httptest.NewRequest(http.MethodGet, healthPath, nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var response health
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	require.Equal(t, "ok", response.Status)
+	require.Equal(t, operator.FeatureState{Enabled: true, Default: false, Stage: operator.Alpha}, response.FeatureGates[operator.PredictiveScaling])
+	require.False(t, response.FeatureGates[operator.GracefulShutdownAPI].Enabled)
+}
@@ -2,22 +2,30 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"net"
 	"net/http"
 	"net/url"
 	"os"
-	"os/signal"
-	"syscall"
 	"time"
 
 	"github.com/alecthomas/kingpin/v2"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	log "github.com/sirupsen/logrus"
 	"github.com/zalando-incubator/es-operator/operator"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
 	"github.com/zalando-incubator/es-operator/pkg/clientset"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/transport"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/manager/signals"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 )
 
 const (
@@ -26,28 +34,81 @@ const (
 	defaultMetricsAddress     = ":7979"
 	defaultClientGoTimeout    = 30 * time.Second
 	defaultClusterDNSZone     = "cluster.local."
+	defaultKubeAPIQPS         = "100"
+	defaultKubeAPIBurst       = "500"
+	defaultDebugTimeout       = "5m"
+	defaultLeaderElectionID   = "es-operator"
+
+	metricStoreCRD       = "crd"
+	metricStoreConfigMap = "configmap"
+	metricStoreMemory    = "memory"
 )
 
 var (
+	version = "unknown"
+
 	config struct {
 		Interval              time.Duration
 		AutoscalerInterval    time.Duration
 		APIServer             *url.URL
 		PodSelectors          Labels
 		PriorityNodeSelectors Labels
+		ImageRewrites         Labels
+		PinImageDigests       bool
+		ImageSignatureKey     string
+		EnableRollouts        bool
+		EnableRestores        bool
+		EnableClusters        bool
+		EnablePrometheusRules bool
+		EnableDrainCost       bool
+		EnableCostReports     bool
+		PriceTableFile        string
+		PrometheusRuleLabels  Labels
 		MetricsAddress        string
 		ClientGoTimeout       time.Duration
+		KubeAPIQPS            float32
+		KubeAPIBurst          int
+		UserAgentSuffix       string
 		Debug                 bool
 		OperatorID            string
 		Namespace             string
 		ClusterDNSZone        string
+		ExternalDNSZone       string
 		ElasticsearchEndpoint *url.URL
+		EnableDiagnostics     bool
+		DiagnosticsAddress    *url.URL
+		DiagnosticsOutput     string
+		EnableBackup          bool
+		AdminAddress          string
+		AdminTokenFile        string
+		AdminTLSCertFile      string
+		AdminTLSKeyFile       string
+		AdminClientCAFile     string
+		WebhookAddress        string
+		MetricStore           string
+		MetricStorePushURL    string
+		MetricWriteInterval   time.Duration
+		WebhookTLSCertFile    string
+		WebhookTLSKeyFile     string
+		BackupAddress         *url.URL
+		BackupOutput          string
+		ValidateFilename      string
+		DryRun                bool
+		DebugTarget           string
+		DebugImage            string
+		DebugTimeout          time.Duration
+		DebugOutput           string
+		FeatureGates          string
+		LeaderElection        bool
+		LeaderElectionNS      string
 	}
 )
 
 func main() {
 	config.PodSelectors = Labels(map[string]string{})
 	config.PriorityNodeSelectors = Labels(map[string]string{})
+	config.ImageRewrites = Labels(map[string]string{})
+	config.PrometheusRuleLabels = Labels(map[string]string{})
 
 	kingpin.Flag("debug", "Enable debug logging.").BoolVar(&config.Debug)
 	kingpin.Flag("interval", "Interval between syncing.").
@@ -63,63 +124,303 @@ func main() {
 		Default(defaultMetricsAddress).StringVar(&config.MetricsAddress)
 	kingpin.Flag("client-go-timeout", "Set the timeout used for the Kubernetes client").
 		Default(defaultClientGoTimeout.String()).DurationVar(&config.ClientGoTimeout)
+	kingpin.Flag("kube-api-qps", "Maximum queries per second from the operator to the Kubernetes API server.").
+		Default(defaultKubeAPIQPS).Float32Var(&config.KubeAPIQPS)
+	kingpin.Flag("kube-api-burst", "Maximum burst of queries from the operator to the Kubernetes API server.").
+		Default(defaultKubeAPIBurst).IntVar(&config.KubeAPIBurst)
+	kingpin.Flag("user-agent-suffix", "Suffix appended to the user agent of requests to the Kubernetes API server, e.g. to identify an operator instance in audit logs.").
+		StringVar(&config.UserAgentSuffix)
 	kingpin.Flag("operator-id", "ID of the operator used to determine ownership of EDS resources").
 		StringVar(&config.OperatorID)
 	kingpin.Flag("cluster-dns-zone", "The zone used for the cluster internal DNS. Used when generating ES service endpoint").
 		Default(defaultClusterDNSZone).StringVar(&config.ClusterDNSZone)
+	kingpin.Flag("external-dns-zone", "The zone of the default hostname '<name>.<namespace>.<zone>' of the DNS records published for EDS with external DNS.").
+		StringVar(&config.ExternalDNSZone)
 	kingpin.Flag("elasticsearch-endpoint", "The Elasticsearch endpoint to use for reaching Elasticsearch API. By default the service endpoint for the EDS is used").
 		URLVar(&config.ElasticsearchEndpoint)
 	kingpin.Flag("namespace", "Limit operator to a certain namespace").
 		Default(v1.NamespaceAll).StringVar(&config.Namespace)
+	kingpin.Flag("image-rewrite", "Rewrite image prefixes of the generated pod specs, e.g. to use an internal registry mirror in air-gapped environments. <from-prefix>=<to-prefix>,+.").
+		SetValue(&config.ImageRewrites)
+	kingpin.Flag("pin-image-digests", "Resolve image tags to digests when rolling out pod templates to guarantee that all pods of an EDS run identical images.").
+		BoolVar(&config.PinImageDigests)
+	kingpin.Flag("image-signature-key", "PEM encoded cosign public key file. If set, only images whose resolved digest is signed with this key are rolled out. Requires --pin-image-digests.").
+		StringVar(&config.ImageSignatureKey)
+	kingpin.Flag("enable-rollouts", "Enable the controller for ElasticsearchRollout resources, which roll out a new image across many EDS in waves.").
+		BoolVar(&config.EnableRollouts)
+	kingpin.Flag("enable-restores", "Enable the controller for ElasticsearchRestore resources, which restore indices from a snapshot onto the nodes of an EDS.").
+		BoolVar(&config.EnableRestores)
+	kingpin.Flag("enable-clusters", "Enable the controller for ElasticsearchCluster resources, which compose master nodes, Services and several EDS into one cluster.").
+		BoolVar(&config.EnableClusters)
+	kingpin.Flag("enable-prometheus-rules", "Create a PrometheusRule of the Prometheus operator with alerts for red cluster health, stuck draining, stalled rollouts and blocked scaling for every EDS.").
+		BoolVar(&config.EnablePrometheusRules)
+	kingpin.Flag("prometheus-rule-labels", "Labels of the generated PrometheusRules, e.g. to match the rule selector of Prometheus. <key>=<value>,+.").
+		SetValue(&config.PrometheusRuleLabels)
+	kingpin.Flag("enable-drain-cost", "Annotate the pods of every EDS with the data and the primary shards which would be moved by draining them, and export both as metrics, so descheduler and capacity tooling can prefer cheap pods. Rolling updates start with the cheapest pods.").
+		BoolVar(&config.EnableDrainCost)
+	kingpin.Flag("enable-cost-reports", "Sample the requested CPU and memory, the storage and the number of nodes of every EDS, export them as metrics and serve cost reports of the last 24 hours on the admin API for chargeback.").
+		BoolVar(&config.EnableCostReports)
+	kingpin.Flag("price-table-file", "YAML file with the prices per hour of CPU cores, memory and storage the cost of every EDS is estimated with. The cost isn't estimated if empty.").
+		StringVar(&config.PriceTableFile)
+	kingpin.Flag("enable-diagnostics", "Serve pprof and runtime diagnostics endpoints under /debug on the metrics address.").
+		BoolVar(&config.EnableDiagnostics)
+	kingpin.Flag("dry-run", "Only log the changes the operator would make. Changes to Kubernetes resources are sent as server-side dry-run, changes to Elasticsearch aren't sent.").
+		BoolVar(&config.DryRun)
+	kingpin.Flag("enable-backup", "Serve a backup of the managed resources and the cluster settings owned by the operator under /backup on the metrics address.").
+		BoolVar(&config.EnableBackup)
+	kingpin.Flag("admin-address", "Serve the authenticated admin API on this address, which lets external automation pause, resume and restart rollouts, drain pods and read the status of EDS. Disabled if empty.").
+		StringVar(&config.AdminAddress)
+	kingpin.Flag("admin-token-file", "File containing the bearer token authenticating callers of the admin API.").
+		StringVar(&config.AdminTokenFile)
+	kingpin.Flag("admin-tls-cert-file", "TLS certificate of the admin API.").
+		StringVar(&config.AdminTLSCertFile)
+	kingpin.Flag("admin-tls-key-file", "TLS key of the admin API.").
+		StringVar(&config.AdminTLSKeyFile)
+	kingpin.Flag("admin-client-ca-file", "CA authenticating callers of the admin API by their client certificates (mTLS).").
+		StringVar(&config.AdminClientCAFile)
+	kingpin.Flag("webhook-address", "Serve the validating and defaulting admission webhooks for ElasticsearchDataSets on this address. Disabled if empty.").
+		StringVar(&config.WebhookAddress)
+	kingpin.Flag("webhook-tls-cert-file", "TLS certificate of the admission webhooks.").
+		StringVar(&config.WebhookTLSCertFile)
+	kingpin.Flag("webhook-tls-key-file", "TLS key of the admission webhooks.").
+		StringVar(&config.WebhookTLSKeyFile)
+	kingpin.Flag("metric-store", "Where to keep the CPU usage history of the autoscaler: 'crd' (ElasticsearchMetricSet resources), 'configmap' (ConfigMaps owned by the EDS) or 'memory' (lost on restart).").
+		Default(metricStoreCRD).EnumVar(&config.MetricStore, metricStoreCRD, metricStoreConfigMap, metricStoreMemory)
+	kingpin.Flag("metric-store-push-url", "Additionally push the latest CPU usage of every EDS to this Prometheus Pushgateway compatible endpoint, e.g. to keep a long-term history in an external TSDB.").
+		StringVar(&config.MetricStorePushURL)
+	kingpin.Flag("metric-write-interval", "Write the metric set of every EDS at most once per interval and keep the samples in between in memory, to reduce the writes to the Kubernetes API on large fleets. Samples not written yet are lost on restart. Disabled if 0.").
+		Default("0s").DurationVar(&config.MetricWriteInterval)
+	kingpin.Flag("feature-gates", "Enable or disable subsystems of the operator, e.g. to ship new ones dark and enable them per environment. <feature>=<bool>,+. Features: GracefulShutdownAPI, PredictiveScaling, PDBManagement.").
+		StringVar(&config.FeatureGates)
+	kingpin.Flag("leader-elect", "Run several replicas of the operator of which only the elected leader operates the EDS, so a standby takes over quickly if the leader fails. The others still serve the webhooks.").
+		BoolVar(&config.LeaderElection)
+	kingpin.Flag("leader-election-namespace", "Namespace of the Lease used for leader election. Defaults to the namespace the operator runs in.").
+		StringVar(&config.LeaderElectionNS)
+
+	kingpin.Command("run", "Run the operator.").Default()
+	dumpCmd := kingpin.Command("diagnostics", "Operator diagnostics.").
+		Command("dump", "Capture goroutine stacks, reconcile state and recent decisions of a running operator into a bundle. Requires the operator to run with --enable-diagnostics.")
+	dumpCmd.Flag("address", "Address of the metrics endpoint of the running operator.").
+		Default("http://localhost" + defaultMetricsAddress).URLVar(&config.DiagnosticsAddress)
+	dumpCmd.Flag("output", "File to write the diagnostics bundle to. Defaults to a timestamped file in the current directory.").
+		Short('o').StringVar(&config.DiagnosticsOutput)
+
+	exportCmd := kingpin.Command("backup", "Backup of the managed resources.").
+		Command("export", "Export the EDS, MetricSets, Services and ConfigMaps managed by a running operator and the cluster settings it owns into a restorable bundle. Requires the operator to run with --enable-backup.")
+	exportCmd.Flag("address", "Address of the metrics endpoint of the running operator.").
+		Default("http://localhost" + defaultMetricsAddress).URLVar(&config.BackupAddress)
+	exportCmd.Flag("output", "File to write the backup to. Defaults to a timestamped file in the current directory.").
+		Short('o').StringVar(&config.BackupOutput)
 
-	kingpin.Parse()
+	validateCmd := kingpin.Command("validate", "Validate ElasticsearchDataSet manifests offline and print the StatefulSets the operator would create for them.")
+	validateCmd.Flag("filename", "Manifest file with one or more ElasticsearchDataSets, '-' reads from stdin.").
+		Short('f').Required().StringVar(&config.ValidateFilename)
+
+	debugCmd := kingpin.Command("debug", "Debugging of Elasticsearch pods.").
+		Command("attach", "Attach an ephemeral container to a pod which collects the state of its Elasticsearch node and the cluster into a ConfigMap. Requires access to the Kubernetes API, e.g. with --apiserver and kubectl proxy.")
+	debugCmd.Arg("pod", "Pod to debug, as <namespace>/<name> or <name> in the namespace given with --namespace.").
+		Required().StringVar(&config.DebugTarget)
+	debugCmd.Flag("image", "Image of the ephemeral container, with sh and curl. Defaults to the image of the Elasticsearch container.").
+		StringVar(&config.DebugImage)
+	debugCmd.Flag("timeout", "Timeout for the diagnostics to finish.").
+		Default(defaultDebugTimeout).DurationVar(&config.DebugTimeout)
+	debugCmd.Flag("output", "File to additionally write the diagnostics to.").
+		Short('o').StringVar(&config.DebugOutput)
+
+	switch kingpin.Parse() {
+	case dumpCmd.FullCommand():
+		err := dumpDiagnostics(config.DiagnosticsAddress, config.DiagnosticsOutput, config.ClientGoTimeout)
+		if err != nil {
+			log.Fatalf("Failed to dump diagnostics: %v", err)
+		}
+		return
+	case validateCmd.FullCommand():
+		err := validateManifest(config.ValidateFilename, config.ImageRewrites, os.Stdout)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Validation failed:\n%v\n", err)
+			os.Exit(1)
+		}
+		return
+	case debugCmd.FullCommand():
+		kubeConfig, err := configureKubeConfig(config.APIServer, defaultClientGoTimeout, config.KubeAPIQPS, config.KubeAPIBurst, make(chan struct{}))
+		if err != nil {
+			log.Fatalf("Failed to setup Kubernetes config: %v", err)
+		}
+		kubeConfig.UserAgent = userAgent(version, config.OperatorID, config.UserAgentSuffix)
+		client, err := clientset.NewClientset(kubeConfig)
+		if err != nil {
+			log.Fatalf("Failed to setup Kubernetes client: %v", err)
+		}
+		namespace, name := debugTarget(config.DebugTarget, config.Namespace)
+		err = attachDebugContainer(context.Background(), client, namespace, name, config.DebugImage, config.DebugOutput, config.DebugTimeout)
+		if err != nil {
+			log.Fatalf("Failed to debug pod %s/%s: %v", namespace, name, err)
+		}
+		return
+	case exportCmd.FullCommand():
+		err := exportBackup(config.BackupAddress, config.BackupOutput, config.ClientGoTimeout)
+		if err != nil {
+			log.Fatalf("Failed to export backup: %v", err)
+		}
+		return
+	}
 
 	if config.Debug {
 		log.SetLevel(log.DebugLevel)
 	}
 
-	ctx, cancel := context.WithCancel(context.Background())
-	kubeConfig, err := configureKubeConfig(config.APIServer, defaultClientGoTimeout, ctx.Done())
+	featureGates, err := operator.ParseFeatureGates(config.FeatureGates)
+	if err != nil {
+		log.Fatalf("Failed to parse feature gates: %v", err)
+	}
+	operator.SetFeatureGates(featureGates)
+
+	ctx := signals.SetupSignalHandler()
+	kubeConfig, err := configureKubeConfig(config.APIServer, defaultClientGoTimeout, config.KubeAPIQPS, config.KubeAPIBurst, ctx.Done())
 	if err != nil {
 		log.Fatalf("Failed to setup Kubernetes config: %v", err)
 	}
+	kubeConfig.UserAgent = userAgent(version, config.OperatorID, config.UserAgentSuffix)
+	if config.DryRun {
+		log.Info("Running in dry-run mode, changes are only logged.")
+		operator.EnableDryRun(kubeConfig)
+	}
 
 	client, err := clientset.NewClientset(kubeConfig)
 	if err != nil {
 		log.Fatalf("Failed to setup Kubernetes client: %v", err)
 	}
 
-	operator := operator.NewElasticsearchOperator(
-		client,
-		config.PriorityNodeSelectors,
-		config.Interval,
-		config.AutoscalerInterval,
-		config.OperatorID,
-		config.Namespace,
-		config.ClusterDNSZone,
-		config.ElasticsearchEndpoint,
-	)
-
-	go handleSigterm(cancel)
-	go serveMetrics(config.MetricsAddress)
-	err = operator.Run(ctx)
+	var imageResolver operator.ImageResolver
+	switch {
+	case config.PinImageDigests && config.ImageSignatureKey != "":
+		imageResolver, err = operator.NewCosignImageResolver(config.ImageSignatureKey)
+		if err != nil {
+			log.Fatalf("Failed to setup image signature verification: %v", err)
+		}
+	case config.PinImageDigests:
+		imageResolver = operator.NewRegistryImageResolver()
+	case config.ImageSignatureKey != "":
+		log.Fatalf("--image-signature-key requires --pin-image-digests")
+	}
+
+	var prices *operator.PriceTable
+	if config.PriceTableFile != "" {
+		prices, err = operator.LoadPriceTable(config.PriceTableFile)
+		if err != nil {
+			log.Fatalf("Failed to load price table: %v", err)
+		}
+	}
+
+	metricStore := newMetricStore(client, config.MetricStore, config.MetricWriteInterval, config.MetricStorePushURL)
+
+	operator := operator.NewElasticsearchOperator(client, operator.Options{
+		PriorityNodeSelectors: config.PriorityNodeSelectors,
+		Interval:              config.Interval,
+		AutoscalerInterval:    config.AutoscalerInterval,
+		OperatorID:            config.OperatorID,
+		Namespace:             config.Namespace,
+		ClusterDNSZone:        config.ClusterDNSZone,
+		ExternalDNSZone:       config.ExternalDNSZone,
+		ElasticsearchEndpoint: config.ElasticsearchEndpoint,
+		ImageRewriteRules:     config.ImageRewrites,
+		ImageResolver:         imageResolver,
+		MetricStore:           metricStore,
+		PrometheusRuleLabels:  config.PrometheusRuleLabels,
+		Prices:                prices,
+		EnableRollouts:        config.EnableRollouts,
+		EnableRestores:        config.EnableRestores,
+		EnableClusters:        config.EnableClusters,
+		EnablePrometheusRules: config.EnablePrometheusRules,
+		EnableDrainCost:       config.EnableDrainCost,
+		EnableCostReports:     config.EnableCostReports,
+		DryRun:                config.DryRun,
+	})
+
+	var diagnostics http.Handler
+	if config.EnableDiagnostics {
+		diagnostics = operator.DiagnosticsHandler()
+	}
+
+	var backup http.Handler
+	if config.EnableBackup {
+		backup = operator.BackupHandler()
+	}
+
+	scheme := runtime.NewScheme()
+	err = clientgoscheme.AddToScheme(scheme)
+	if err != nil {
+		log.Fatalf("Failed to setup scheme: %v", err)
+	}
+	err = zv1.AddToScheme(scheme)
+	if err != nil {
+		log.Fatalf("Failed to setup scheme: %v", err)
+	}
+
+	cacheOptions := cache.Options{}
+	if config.Namespace != v1.NamespaceAll {
+		cacheOptions.DefaultNamespaces = map[string]cache.Config{config.Namespace: {}}
+	}
+
+	ctrllog.SetLogger(klog.NewKlogr())
+	mgr, err := manager.New(kubeConfig, manager.Options{
+		Scheme: scheme,
+		Cache:  cacheOptions,
+		// metrics are served with the operator's own metrics.
+		Metrics:                       metricsserver.Options{BindAddress: "0"},
+		LeaderElection:                config.LeaderElection,
+		LeaderElectionID:              leaderElectionID(config.OperatorID),
+		LeaderElectionNamespace:       config.LeaderElectionNS,
+		LeaderElectionReleaseOnCancel: true,
+	})
+	if err != nil {
+		log.Fatalf("Failed to setup manager: %v", err)
+	}
+	err = operator.SetupWithManager(mgr)
+	if err != nil {
+		log.Fatalf("Failed to add operator to manager: %v", err)
+	}
+
+	go serveMetrics(config.MetricsAddress, diagnostics, backup)
+	if config.AdminAddress != "" {
+		admin, err := newAdminServer(config.AdminAddress, operator.AdminHandler(), config.AdminTokenFile,
+			config.AdminTLSCertFile, config.AdminTLSKeyFile, config.AdminClientCAFile)
+		if err != nil {
+			log.Fatalf("Failed to setup admin API: %v", err)
+		}
+		err = mgr.Add(admin)
+		if err != nil {
+			log.Fatalf("Failed to add admin API to manager: %v", err)
+		}
+	}
+	if config.WebhookAddress != "" {
+		webhooks, err := newWebhookServer(config.WebhookAddress, config.WebhookTLSCertFile, config.WebhookTLSKeyFile)
+		if err != nil {
+			log.Fatalf("Failed to setup admission webhooks: %v", err)
+		}
+		err = mgr.Add(webhooks)
+		if err != nil {
+			log.Fatalf("Failed to add admission webhooks to manager: %v", err)
+		}
+	}
+	err = mgr.Start(ctx)
 	if err != nil {
-		cancel()
 		log.Fatalf("Failed to run operator: %v", err)
 	}
 }
 
-// handleSigterm handles SIGTERM signal sent to the process.
-func handleSigterm(cancelFunc func()) {
-	signals := make(chan os.Signal, 1)
-	signal.Notify(signals, syscall.SIGTERM)
-	<-signals
-	log.Info("Received Term signal. Terminating...")
-	cancelFunc()
+// leaderElectionID returns the name of the Lease used for leader election.
+// Operators with different IDs manage different EDS, so each elects its own
+// leader.
+func leaderElectionID(operatorID string) string {
+	if operatorID == "" {
+		return defaultLeaderElectionID
+	}
+	return fmt.Sprintf("%s-%s", defaultLeaderElectionID, operatorID)
 }
 
 // configureKubeConfig configures a kubeconfig.
-func configureKubeConfig(apiServerURL *url.URL, timeout time.Duration, stopCh <-chan struct{}) (*rest.Config, error) {
+func configureKubeConfig(apiServerURL *url.URL, timeout time.Duration, qps float32, burst int, stopCh <-chan struct{}) (*rest.Config, error) {
 	tr := &http.Transport{
 		DialContext: (&net.Dialer{
 			Timeout:   timeout,
@@ -152,8 +453,8 @@ func configureKubeConfig(apiServerURL *url.URL, timeout time.Duration, stopCh <-
 			Host:      apiServerURL.String(),
 			Timeout:   timeout,
 			Transport: tr,
-			QPS:       100.0,
-			Burst:     500,
+			QPS:       qps,
+			Burst:     burst,
 		}, nil
 	}
 
@@ -175,15 +476,60 @@ func configureKubeConfig(apiServerURL *url.URL, timeout time.Duration, stopCh <-
 
 	config.Timeout = timeout
 	config.Transport = tr
-	config.QPS = 100.0
-	config.Burst = 500
+	config.QPS = qps
+	config.Burst = burst
 	// disable TLSClientConfig to make the custom Transport work
 	config.TLSClientConfig = rest.TLSClientConfig{}
 	return config, nil
 }
 
-// gather go metrics
-func serveMetrics(address string) {
-	http.Handle("/metrics", promhttp.Handler())
-	log.Fatal(http.ListenAndServe(address, nil))
+// userAgent returns the user agent used for requests to the Kubernetes API
+// server. It identifies the operator version and instance such that requests
+// can be attributed in audit logs and API Priority and Fairness debugging.
+func userAgent(version, operatorID, suffix string) string {
+	ua := fmt.Sprintf("es-operator/%s", version)
+	if operatorID != "" {
+		ua = fmt.Sprintf("%s (operator-id=%s)", ua, operatorID)
+	}
+	if suffix != "" {
+		ua = fmt.Sprintf("%s %s", ua, suffix)
+	}
+	return ua
+}
+
+// gather go metrics, serve the health endpoint and optionally serve
+// diagnostics and backup endpoints.
+func serveMetrics(address string, diagnostics, backup http.Handler) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	mux.HandleFunc(healthPath, healthHandler)
+	if diagnostics != nil {
+		registerDiagnostics(mux, diagnostics)
+	}
+	if backup != nil {
+		mux.Handle(backupPath, backup)
+	}
+	log.Fatal(http.ListenAndServe(address, mux))
+}
+
+// newMetricStore returns the metric store of the given kind, which writes
+// every metric set at most once per writeInterval if it's set and pushes the
+// metrics to pushURL if it's set.
+func newMetricStore(client *clientset.Clientset, kind string, writeInterval time.Duration, pushURL string) operator.MetricStore {
+	var store operator.MetricStore
+	switch kind {
+	case metricStoreMemory:
+		store = operator.NewMemoryMetricStore()
+	case metricStoreConfigMap:
+		store = operator.NewConfigMapMetricStore(client)
+	default:
+		store = operator.NewCRDMetricStore(client)
+	}
+	if writeInterval > 0 && kind != metricStoreMemory {
+		store = operator.NewCoalescingMetricStore(store, writeInterval)
+	}
+	if pushURL != "" {
+		store = operator.NewPushingMetricStore(store, pushURL)
+	}
+	return store
 }
@@ -0,0 +1,47 @@
+package main
+
+import "testing"
+
+func TestUserAgent(t *testing.T) {
+	for _, tc := range []struct {
+		msg        string
+		operatorID string
+		suffix     string
+		expected   string
+	}{
+		{
+			msg:      "default user agent",
+			expected: "es-operator/v1.0.0",
+		},
+		{
+			msg:        "user agent with operator id",
+			operatorID: "team-a",
+			expected:   "es-operator/v1.0.0 (operator-id=team-a)",
+		},
+		{
+			msg:        "user agent with suffix",
+			operatorID: "team-a",
+			suffix:     "cluster/eu-central-1",
+			expected:   "es-operator/v1.0.0 (operator-id=team-a) cluster/eu-central-1",
+		},
+	} {
+		t.Run(tc.msg, func(t *testing.T) {
+			ua := userAgent("v1.0.0", tc.operatorID, tc.suffix)
+			if ua != tc.expected {
+				t.Errorf("expected %s, got %s", tc.expected, ua)
+			}
+		})
+	}
+}
+
+func TestLeaderElectionID(t *testing.T) {
+	for operatorID, expected := range map[string]string{
+		"":       "es-operator",
+		"team-a": "es-operator-team-a",
+	} {
+		id := leaderElectionID(operatorID)
+		if id != expected {
+			t.Errorf("expected %s, got %s", expected, id)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// additionalLabelsAnnotationKey and additionalAnnotationsAnnotationKey
+	// record the keys of the additional metadata set on a resource, such
+	// that keys removed from the EDS are removed from the resource again.
+	additionalLabelsAnnotationKey      = "es-operator.zalando.org/additional-labels"
+	additionalAnnotationsAnnotationKey = "es-operator.zalando.org/additional-annotations"
+)
+
+// applyAdditionalMetadata sets the additional labels and annotations on the
+// object and removes the ones previously set but not given anymore. Returns
+// true if the object was changed.
+func applyAdditionalMetadata(meta *metav1.ObjectMeta, labels, annotations map[string]string) bool {
+	if meta.Labels == nil {
+		meta.Labels = make(map[string]string, len(labels))
+	}
+	if meta.Annotations == nil {
+		meta.Annotations = make(map[string]string, len(annotations)+2)
+	}
+	labelsChanged := applyManagedKeys(meta.Labels, labels, meta.Annotations, additionalLabelsAnnotationKey)
+	annotationsChanged := applyManagedKeys(meta.Annotations, annotations, meta.Annotations, additionalAnnotationsAnnotationKey)
+	return labelsChanged || annotationsChanged
+}
+
+// applyManagedKeys sets the desired values and removes the values of the
+// keys recorded in the annotation which are not desired anymore.
+func applyManagedKeys(values, desired, annotations map[string]string, annotationKey string) bool {
+	changed := false
+	for _, key := range strings.Split(annotations[annotationKey], ",") {
+		if _, ok := desired[key]; ok || key == "" {
+			continue
+		}
+		if _, ok := values[key]; ok {
+			delete(values, key)
+			changed = true
+		}
+	}
+
+	keys := make([]string, 0, len(desired))
+	for key, value := range desired {
+		keys = append(keys, key)
+		if current, ok := values[key]; !ok || current != value {
+			values[key] = value
+			changed = true
+		}
+	}
+	sort.Strings(keys)
+
+	managed := strings.Join(keys, ",")
+	if annotations[annotationKey] != managed {
+		if managed == "" {
+			delete(annotations, annotationKey)
+		} else {
+			annotations[annotationKey] = managed
+		}
+		changed = true
+	}
+	return changed
+}
+
+// withoutKeys returns the values without the keys of the excluded maps.
+func withoutKeys(values map[string]string, excluded ...map[string]string) map[string]string {
+	result := make(map[string]string, len(values))
+	for key, value := range values {
+		result[key] = value
+		for _, exclude := range excluded {
+			if _, ok := exclude[key]; ok {
+				delete(result, key)
+				break
+			}
+		}
+	}
+	return result
+}
+
+// propagateAdditionalMetadata applies the additional metadata of the
+// resource to its pods. It's applied directly to the pods instead of the pod
+// template, such that the pods don't have to be recreated.
+func (o *Operator) propagateAdditionalMetadata(ctx context.Context, sr StatefulResource, pods []*v1.Pod) error {
+	labels, annotations := sr.AdditionalMetadata()
+	for _, pod := range pods {
+		updated := pod.DeepCopy()
+		if !applyAdditionalMetadata(&updated.ObjectMeta, labels, annotations) {
+			continue
+		}
+		_, err := o.kube.CoreV1().Pods(pod.Namespace).Update(ctx, updated, metav1.UpdateOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to update metadata of pod %s/%s: %v", pod.Namespace, pod.Name, err)
+		}
+		o.logger.Infof("Updated additional labels and annotations of pod %s/%s", pod.Namespace, pod.Name)
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package operator
+
+import (
+	"context"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	"github.com/zalando-incubator/es-operator/pkg/clientset"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestApplyAdditionalMetadata(t *testing.T) {
+	meta := metav1.ObjectMeta{Labels: map[string]string{"app": "es"}}
+
+	changed := applyAdditionalMetadata(&meta, map[string]string{"team": "search", "cost-center": "42"}, map[string]string{"owner": "search@example.org"})
+	assert.True(t, changed)
+	assert.Equal(t, map[string]string{"app": "es", "team": "search", "cost-center": "42"}, meta.Labels)
+	assert.Equal(t, map[string]string{
+		"owner":                            "search@example.org",
+		additionalLabelsAnnotationKey:      "cost-center,team",
+		additionalAnnotationsAnnotationKey: "owner",
+	}, meta.Annotations)
+
+	changed = applyAdditionalMetadata(&meta, map[string]string{"team": "search", "cost-center": "42"}, map[string]string{"owner": "search@example.org"})
+	assert.False(t, changed)
+
+	changed = applyAdditionalMetadata(&meta, map[string]string{"team": "storage"}, nil)
+	assert.True(t, changed)
+	assert.Equal(t, map[string]string{"app": "es", "team": "storage"}, meta.Labels)
+	assert.Equal(t, map[string]string{additionalLabelsAnnotationKey: "team"}, meta.Annotations)
+
+	changed = applyAdditionalMetadata(&meta, nil, nil)
+	assert.True(t, changed)
+	assert.Equal(t, map[string]string{"app": "es"}, meta.Labels)
+	assert.Empty(t, meta.Annotations)
+}
+
+func TestEDSAdditionalMetadata(t *testing.T) {
+	r := &EDSResource{
+		eds: &zv1.ElasticsearchDataSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "es-data", Labels: map[string]string{"app": "es"}},
+			Spec: zv1.ElasticsearchDataSetSpec{
+				Template: zv1.PodTemplateSpec{
+					EmbeddedObjectMeta: zv1.EmbeddedObjectMeta{
+						Labels:      map[string]string{"component": "data"},
+						Annotations: map[string]string{"prometheus.io/scrape": "true"},
+					},
+				},
+				AdditionalResourceLabels: map[string]string{
+					esDataSetLabelKey: "other",
+					"app":             "other",
+					"component":       "other",
+					"team":            "search",
+				},
+				AdditionalResourceAnnotations: map[string]string{
+					"prometheus.io/scrape": "false",
+					"owner":                "search@example.org",
+				},
+			},
+		},
+	}
+
+	labels, annotations := r.AdditionalMetadata()
+	assert.Equal(t, map[string]string{"team": "search"}, labels)
+	assert.Equal(t, map[string]string{"owner": "search@example.org"}, annotations)
+}
+
+func TestEnsureServiceAdditionalMetadata(t *testing.T) {
+	eds := &zv1.ElasticsearchDataSet{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "zalando.org/v1", Kind: "ElasticsearchDataSet"},
+		ObjectMeta: metav1.ObjectMeta{Name: "es-data", Namespace: "default", UID: "uid", Labels: map[string]string{"app": "es"}},
+	}
+	kube := fake.NewSimpleClientset()
+	r := &EDSResource{
+		eds:      eds,
+		kube:     &clientset.Clientset{Interface: kube},
+		recorder: record.NewFakeRecorder(10),
+	}
+
+	err := r.ensureService(context.Background())
+	require.NoError(t, err)
+
+	eds.Spec.AdditionalResourceLabels = map[string]string{"team": "search"}
+	err = r.ensureService(context.Background())
+	require.NoError(t, err)
+	svc, err := kube.CoreV1().Services("default").Get(context.Background(), "es-data", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"app": "es", "team": "search"}, svc.Labels)
+
+	err = r.ensurePodDisruptionBudget(context.Background())
+	require.NoError(t, err)
+	eds.Spec.AdditionalResourceLabels = nil
+	err = r.ensurePodDisruptionBudget(context.Background())
+	require.NoError(t, err)
+	pdb, err := kube.PolicyV1().PodDisruptionBudgets("default").Get(context.Background(), "es-data", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"app": "es"}, pdb.Labels)
+}
+
+func TestPropagateAdditionalMetadata(t *testing.T) {
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "es-data-0",
+			Namespace:   "default",
+			Labels:      map[string]string{controllerRevisionHashLabelKey: "es-data-1"},
+			Annotations: map[string]string{additionalLabelsAnnotationKey: "team"},
+		},
+	}
+	kube := fake.NewSimpleClientset(pod)
+	o := &Operator{
+		kube:   &clientset.Clientset{Interface: kube},
+		logger: log.WithField("test", t.Name()),
+	}
+	sr := &mockAdditionalMetadataResource{labels: map[string]string{"cost-center": "42"}}
+
+	err := o.propagateAdditionalMetadata(context.Background(), sr, []*v1.Pod{pod})
+	require.NoError(t, err)
+	updated, err := kube.CoreV1().Pods("default").Get(context.Background(), "es-data-0", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{controllerRevisionHashLabelKey: "es-data-1", "cost-center": "42"}, updated.Labels)
+	assert.Equal(t, "cost-center", updated.Annotations[additionalLabelsAnnotationKey])
+}
+
+type mockAdditionalMetadataResource struct {
+	mockResource
+	labels map[string]string
+}
+
+func (r *mockAdditionalMetadataResource) AdditionalMetadata() (map[string]string, map[string]string) {
+	return r.labels, nil
+}
@@ -0,0 +1,379 @@
+package operator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	zalandov1 "github.com/zalando-incubator/es-operator/pkg/client/clientset/versioned/typed/zalando.org/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	kube_record "k8s.io/client-go/tools/record"
+)
+
+const (
+	// AdminAPIPrefix is the path prefix of the admin API.
+	AdminAPIPrefix = "/admin/v1"
+
+	// restartedAtAnnotationKey is set on the pod template to trigger a
+	// rolling restart of the pods.
+	restartedAtAnnotationKey = "es-operator.zalando.org/restarted-at"
+)
+
+// AdminStatus is the status of an EDS returned by the admin API.
+type AdminStatus struct {
+	Namespace string                         `json:"namespace"`
+	Name      string                         `json:"name"`
+	Replicas  int32                          `json:"replicas"`
+	Paused    bool                           `json:"paused"`
+	Status    zv1.ElasticsearchDataSetStatus `json:"status"`
+}
+
+// adminAPI implements the admin API on the EDS managed by the operator.
+type adminAPI struct {
+	kube     kubernetes.Interface
+	zalando  zalandov1.ZalandoV1Interface
+	owns     func(obj metav1.Object) bool
+	recorder kube_record.EventRecorder
+	logger   *log.Entry
+	now      func() time.Time
+	esClient func(eds *zv1.ElasticsearchDataSet) *ESClient
+	// costs are the footprint samples of the EDS, nil if cost reports are
+	// disabled.
+	costs  *costHistory
+	prices *PriceTable
+}
+
+// AdminHandler returns an http.Handler of the admin API, which lets external
+// automation read the status of EDS, pause, resume and restart their
+// rollouts, drain pods, list and cancel the long-running Elasticsearch
+// tasks on their nodes and read their cost reports. Callers must be
+// authenticated before the handler is called.
+func (o *ElasticsearchOperator) AdminHandler() http.Handler {
+	api := &adminAPI{
+		kube:     o.kube,
+		zalando:  o.kube.ZalandoV1(),
+		owns:     o.hasOwnership,
+		recorder: o.recorder,
+		logger:   o.logger.WithField("component", "admin"),
+		now:      time.Now,
+		esClient: func(eds *zv1.ElasticsearchDataSet) *ESClient {
+			return &ESClient{
+				Endpoint:     o.getElasticsearchEndpoint(eds),
+				transport:    o.elasticsearchTransport(eds),
+				distribution: edsDistribution(eds),
+			}
+		},
+		prices: o.prices,
+	}
+	if o.enableCostReports {
+		api.costs = o.costs
+	}
+	return api.handler()
+}
+
+func (a *adminAPI) handler() http.Handler {
+	eds := AdminAPIPrefix + "/namespaces/{namespace}/elasticsearchdatasets/{name}"
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+eds, a.getStatus)
+	mux.HandleFunc("POST "+eds+"/pause", a.setPaused(true))
+	mux.HandleFunc("POST "+eds+"/resume", a.setPaused(false))
+	mux.HandleFunc("POST "+eds+"/restart", a.restart)
+	mux.HandleFunc("GET "+eds+"/tasks", a.getTasks)
+	mux.HandleFunc("POST "+eds+"/tasks/{task}/cancel", a.cancelTask)
+	mux.HandleFunc("GET "+eds+"/cost", a.getCostReport)
+	mux.HandleFunc("GET "+AdminAPIPrefix+"/costs", a.getCostReports)
+	mux.HandleFunc("POST "+AdminAPIPrefix+"/namespaces/{namespace}/pods/{name}/drain", a.drainPod)
+	return mux
+}
+
+// getEDS returns the EDS of the request. It writes the error response and
+// returns nil if the EDS doesn't exist or isn't managed by the operator.
+func (a *adminAPI) getEDS(ctx context.Context, w http.ResponseWriter, namespace, name string) *zv1.ElasticsearchDataSet {
+	eds, err := a.zalando.ElasticsearchDataSets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		a.writeError(w, err)
+		return nil
+	}
+	if !a.owns(eds) {
+		http.Error(w, fmt.Sprintf("EDS %s/%s is not managed by this operator", namespace, name), http.StatusForbidden)
+		return nil
+	}
+	// set TypeMeta manually because of this bug:
+	// https://github.com/kubernetes/client-go/issues/308
+	eds.APIVersion = "zalando.org/v1"
+	eds.Kind = "ElasticsearchDataSet"
+	return eds
+}
+
+func (a *adminAPI) writeError(w http.ResponseWriter, err error) {
+	if apierrors.IsNotFound(err) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	a.logger.Errorf("Admin API request failed: %v", err)
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
+
+func (a *adminAPI) writeJSON(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(value)
+	if err != nil {
+		a.logger.Errorf("Failed to write admin API response: %v", err)
+	}
+}
+
+func (a *adminAPI) writeStatus(w http.ResponseWriter, eds *zv1.ElasticsearchDataSet) {
+	a.writeJSON(w, AdminStatus{
+		Namespace: eds.Namespace,
+		Name:      eds.Name,
+		Replicas:  edsReplicas(eds),
+		Paused:    rolloutPaused(eds),
+		Status:    eds.Status,
+	})
+}
+
+func (a *adminAPI) getStatus(w http.ResponseWriter, r *http.Request) {
+	eds := a.getEDS(r.Context(), w, r.PathValue("namespace"), r.PathValue("name"))
+	if eds == nil {
+		return
+	}
+	a.writeStatus(w, eds)
+}
+
+// patchEDS applies the merge patch to the EDS and writes its status.
+func (a *adminAPI) patchEDS(w http.ResponseWriter, r *http.Request, eds *zv1.ElasticsearchDataSet, patch interface{}, reason, message string) {
+	body, err := json.Marshal(patch)
+	if err != nil {
+		a.writeError(w, err)
+		return
+	}
+	patched, err := a.zalando.ElasticsearchDataSets(eds.Namespace).Patch(r.Context(), eds.Name, types.MergePatchType, body, metav1.PatchOptions{})
+	if err != nil {
+		a.writeError(w, err)
+		return
+	}
+	a.recorder.Event(eds, v1.EventTypeNormal, reason, message)
+	a.writeStatus(w, patched)
+}
+
+// setPaused pauses or resumes the rollout of the EDS.
+func (a *adminAPI) setPaused(paused bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		eds := a.getEDS(r.Context(), w, r.PathValue("namespace"), r.PathValue("name"))
+		if eds == nil {
+			return
+		}
+		var value interface{}
+		reason, message := "ResumedRollout", "Rollout resumed via the admin API"
+		if paused {
+			value = "true"
+			reason, message = "PausedRollout", "Rollout paused via the admin API"
+		}
+		patch := map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"annotations": map[string]interface{}{pausedAnnotationKey: value},
+			},
+		}
+		a.patchEDS(w, r, eds, patch, reason, message)
+	}
+}
+
+// restart triggers a rolling restart of the pods of the EDS by annotating
+// the pod template.
+func (a *adminAPI) restart(w http.ResponseWriter, r *http.Request) {
+	eds := a.getEDS(r.Context(), w, r.PathValue("namespace"), r.PathValue("name"))
+	if eds == nil {
+		return
+	}
+	patch := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"annotations": map[string]interface{}{
+						restartedAtAnnotationKey: a.now().UTC().Format(time.RFC3339),
+					},
+				},
+			},
+		},
+	}
+	a.patchEDS(w, r, eds, patch, "RestartingPods", "Rolling restart requested via the admin API")
+}
+
+// drainPod marks a pod of an EDS draining, so it's drained and replaced
+// before any other pod.
+func (a *adminAPI) drainPod(w http.ResponseWriter, r *http.Request) {
+	namespace, name := r.PathValue("namespace"), r.PathValue("name")
+	pod, err := a.kube.CoreV1().Pods(namespace).Get(r.Context(), name, metav1.GetOptions{})
+	if err != nil {
+		a.writeError(w, err)
+		return
+	}
+	edsName, ok := pod.Labels[esDataSetLabelKey]
+	if !ok {
+		http.Error(w, fmt.Sprintf("Pod %s/%s is not part of an EDS", namespace, name), http.StatusBadRequest)
+		return
+	}
+	eds := a.getEDS(r.Context(), w, namespace, edsName)
+	if eds == nil {
+		return
+	}
+
+	err = annotatePod(r.Context(), a.kube, pod, operatorPodDrainingAnnotationKey, "true")
+	if err != nil {
+		a.writeError(w, err)
+		return
+	}
+	a.recorder.Event(eds, v1.EventTypeNormal, "DrainRequested", fmt.Sprintf(
+		"Drain of Pod '%s/%s' requested via the admin API", namespace, name))
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// edsTasks returns the long-running tasks on the nodes of the EDS.
+func (a *adminAPI) edsTasks(ctx context.Context, eds *zv1.ElasticsearchDataSet) ([]ESTask, error) {
+	pods, err := a.kube.CoreV1().Pods(eds.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", esDataSetLabelKey, eds.Name),
+	})
+	if err != nil {
+		return nil, err
+	}
+	podIPs := make(map[string]struct{}, len(pods.Items))
+	for _, pod := range pods.Items {
+		if pod.Status.PodIP != "" {
+			podIPs[pod.Status.PodIP] = struct{}{}
+		}
+	}
+
+	tasks, err := a.esClient(eds).GetTasks(taskActions...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tasks: %v", err)
+	}
+	edsTasks := make([]ESTask, 0, len(tasks))
+	for _, task := range tasks {
+		if _, ok := podIPs[task.NodeIP]; ok {
+			edsTasks = append(edsTasks, task)
+		}
+	}
+	return edsTasks, nil
+}
+
+// getTasks lists the long-running tasks on the nodes of the EDS, longest
+// running first. Tasks running for less than the minRunningSeconds query
+// parameter are left out.
+func (a *adminAPI) getTasks(w http.ResponseWriter, r *http.Request) {
+	var minRunningSeconds int64
+	if value := r.URL.Query().Get("minRunningSeconds"); value != "" {
+		seconds, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid minRunningSeconds: %v", err), http.StatusBadRequest)
+			return
+		}
+		minRunningSeconds = seconds
+	}
+	eds := a.getEDS(r.Context(), w, r.PathValue("namespace"), r.PathValue("name"))
+	if eds == nil {
+		return
+	}
+	tasks, err := a.edsTasks(r.Context(), eds)
+	if err != nil {
+		a.writeError(w, err)
+		return
+	}
+	filtered := make([]ESTask, 0, len(tasks))
+	for _, task := range tasks {
+		if task.RunningSeconds >= minRunningSeconds {
+			filtered = append(filtered, task)
+		}
+	}
+	a.writeJSON(w, filtered)
+}
+
+// cancelTask cancels a long-running task on a node of the EDS.
+func (a *adminAPI) cancelTask(w http.ResponseWriter, r *http.Request) {
+	eds := a.getEDS(r.Context(), w, r.PathValue("namespace"), r.PathValue("name"))
+	if eds == nil {
+		return
+	}
+	tasks, err := a.edsTasks(r.Context(), eds)
+	if err != nil {
+		a.writeError(w, err)
+		return
+	}
+	id := r.PathValue("task")
+	var task *ESTask
+	for i := range tasks {
+		if tasks[i].ID == id {
+			task = &tasks[i]
+			break
+		}
+	}
+	if task == nil {
+		http.Error(w, fmt.Sprintf("task %s not found on the nodes of EDS %s/%s", id, eds.Namespace, eds.Name), http.StatusNotFound)
+		return
+	}
+	if !task.Cancellable {
+		http.Error(w, fmt.Sprintf("task %s (%s) can't be cancelled", id, task.Action), http.StatusConflict)
+		return
+	}
+
+	err = a.esClient(eds).CancelTask(id)
+	if err != nil {
+		a.writeError(w, err)
+		return
+	}
+	a.recorder.Event(eds, v1.EventTypeNormal, "CancelledTask", fmt.Sprintf(
+		"Cancelled task %s (%s) on node %s via the admin API", id, task.Action, task.Node))
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// costReportsEnabled writes an error response and returns false if cost
+// reports are disabled.
+func (a *adminAPI) costReportsEnabled(w http.ResponseWriter) bool {
+	if a.costs == nil {
+		http.Error(w, "cost reports are disabled, enable them with --enable-cost-reports", http.StatusNotFound)
+		return false
+	}
+	return true
+}
+
+// getCostReport returns the cost report of the EDS.
+func (a *adminAPI) getCostReport(w http.ResponseWriter, r *http.Request) {
+	if !a.costReportsEnabled(w) {
+		return
+	}
+	eds := a.getEDS(r.Context(), w, r.PathValue("namespace"), r.PathValue("name"))
+	if eds == nil {
+		return
+	}
+	report, ok := a.costs.report(types.NamespacedName{Namespace: eds.Namespace, Name: eds.Name}, a.prices, a.now())
+	if !ok {
+		http.Error(w, fmt.Sprintf("no footprint of EDS %s/%s sampled yet", eds.Namespace, eds.Name), http.StatusNotFound)
+		return
+	}
+	a.writeJSON(w, report)
+}
+
+// getCostReports returns the cost reports of all EDS, optionally only of
+// the namespace query parameter.
+func (a *adminAPI) getCostReports(w http.ResponseWriter, r *http.Request) {
+	if !a.costReportsEnabled(w) {
+		return
+	}
+	namespace := r.URL.Query().Get("namespace")
+	reports := a.costs.reports(a.prices, a.now())
+	filtered := make([]CostReport, 0, len(reports))
+	for _, report := range reports {
+		if namespace == "" || report.Namespace == namespace {
+			filtered = append(filtered, report)
+		}
+	}
+	a.writeJSON(w, filtered)
+}
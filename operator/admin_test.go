@@ -0,0 +1,187 @@
+package operator
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	zfake "github.com/zalando-incubator/es-operator/pkg/client/clientset/versioned/fake"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestAdminAPI(t *testing.T) {
+	eds := &zv1.ElasticsearchDataSet{ObjectMeta: metav1.ObjectMeta{Name: "es-data", Namespace: "default"}}
+	foreign := &zv1.ElasticsearchDataSet{ObjectMeta: metav1.ObjectMeta{
+		Name:        "es-other",
+		Namespace:   "default",
+		Annotations: map[string]string{esOperatorAnnotationKey: "other"},
+	}}
+	pods := []*v1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Name: "es-data-0", Namespace: "default", Labels: map[string]string{esDataSetLabelKey: "es-data"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "es-other-0", Namespace: "default", Labels: map[string]string{esDataSetLabelKey: "es-other"}}},
+	}
+	kube := fake.NewSimpleClientset(pods[0], pods[1])
+	zalando := zfake.NewSimpleClientset(eds, foreign)
+	now := time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC)
+	api := &adminAPI{
+		kube:    kube,
+		zalando: zalando.ZalandoV1(),
+		owns: func(obj metav1.Object) bool {
+			_, ok := obj.GetAnnotations()[esOperatorAnnotationKey]
+			return !ok
+		},
+		recorder: record.NewFakeRecorder(10),
+		logger:   log.WithField("test", t.Name()),
+		now:      func() time.Time { return now },
+	}
+	handler := api.handler()
+	call := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(method, AdminAPIPrefix+path, nil))
+		return w
+	}
+	getEDS := func() *zv1.ElasticsearchDataSet {
+		eds, err := zalando.ZalandoV1().ElasticsearchDataSets("default").Get(context.Background(), "es-data", metav1.GetOptions{})
+		require.NoError(t, err)
+		return eds
+	}
+
+	w := call("GET", "/namespaces/default/elasticsearchdatasets/es-data")
+	require.Equal(t, http.StatusOK, w.Code)
+	var status AdminStatus
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &status))
+	assert.Equal(t, "es-data", status.Name)
+	assert.EqualValues(t, 1, status.Replicas)
+	assert.False(t, status.Paused)
+
+	assert.Equal(t, http.StatusNotFound, call("GET", "/namespaces/default/elasticsearchdatasets/missing").Code)
+	assert.Equal(t, http.StatusForbidden, call("POST", "/namespaces/default/elasticsearchdatasets/es-other/pause").Code)
+	assert.Equal(t, http.StatusMethodNotAllowed, call("GET", "/namespaces/default/elasticsearchdatasets/es-data/pause").Code)
+
+	require.Equal(t, http.StatusOK, call("POST", "/namespaces/default/elasticsearchdatasets/es-data/pause").Code)
+	assert.True(t, rolloutPaused(getEDS()))
+	require.Equal(t, http.StatusOK, call("POST", "/namespaces/default/elasticsearchdatasets/es-data/resume").Code)
+	assert.False(t, rolloutPaused(getEDS()))
+
+	require.Equal(t, http.StatusOK, call("POST", "/namespaces/default/elasticsearchdatasets/es-data/restart").Code)
+	assert.Equal(t, "2024-01-02T12:00:00Z", getEDS().Spec.Template.Annotations[restartedAtAnnotationKey])
+
+	require.Equal(t, http.StatusAccepted, call("POST", "/namespaces/default/pods/es-data-0/drain").Code)
+	pod, err := kube.CoreV1().Pods("default").Get(context.Background(), "es-data-0", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "true", pod.Annotations[operatorPodDrainingAnnotationKey])
+	assert.Equal(t, http.StatusForbidden, call("POST", "/namespaces/default/pods/es-other-0/drain").Code)
+}
+
+func TestAdminAPITasks(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_tasks",
+		httpmock.NewStringResponder(200, tasksResponse))
+	httpmock.RegisterResponder("POST", "http://elasticsearch:9200/_tasks/node-1:10/_cancel",
+		httpmock.NewStringResponder(200, `{"nodes":{}}`))
+
+	eds := &zv1.ElasticsearchDataSet{ObjectMeta: metav1.ObjectMeta{Name: "es-data", Namespace: "default"}}
+	kube := fake.NewSimpleClientset(&v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "es-data-0", Namespace: "default", Labels: map[string]string{esDataSetLabelKey: "es-data"}},
+		Status:     v1.PodStatus{PodIP: "10.0.0.1"},
+	})
+	recorder := record.NewFakeRecorder(10)
+	endpoint, _ := url.Parse("http://elasticsearch:9200")
+	api := &adminAPI{
+		kube:     kube,
+		zalando:  zfake.NewSimpleClientset(eds).ZalandoV1(),
+		owns:     func(metav1.Object) bool { return true },
+		recorder: recorder,
+		logger:   log.WithField("test", t.Name()),
+		now:      time.Now,
+		esClient: func(*zv1.ElasticsearchDataSet) *ESClient { return &ESClient{Endpoint: endpoint} },
+	}
+	handler := api.handler()
+	call := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(method, AdminAPIPrefix+"/namespaces/default/elasticsearchdatasets/es-data"+path, nil))
+		return w
+	}
+
+	// only the tasks on the nodes of the EDS are listed.
+	w := call("GET", "/tasks")
+	require.Equal(t, http.StatusOK, w.Code)
+	var tasks []ESTask
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &tasks))
+	require.Len(t, tasks, 2)
+	assert.Equal(t, "node-1:10", tasks[0].ID)
+	assert.Equal(t, "node-1:11", tasks[1].ID)
+
+	w = call("GET", "/tasks?minRunningSeconds=400")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &tasks))
+	require.Len(t, tasks, 1)
+	assert.Equal(t, http.StatusBadRequest, call("GET", "/tasks?minRunningSeconds=x").Code)
+
+	require.Equal(t, http.StatusAccepted, call("POST", "/tasks/node-1:10/cancel").Code)
+	assert.Equal(t, 1, httpmock.GetCallCountInfo()["POST http://elasticsearch:9200/_tasks/node-1:10/_cancel"])
+	assert.Contains(t, <-recorder.Events, "CancelledTask")
+	assert.Equal(t, http.StatusConflict, call("POST", "/tasks/node-1:11/cancel").Code)
+	assert.Equal(t, http.StatusNotFound, call("POST", "/tasks/node-2:20/cancel").Code)
+}
+
+func TestAdminAPICostReports(t *testing.T) {
+	now := time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC)
+	api := &adminAPI{
+		zalando: zfake.NewSimpleClientset(
+			&zv1.ElasticsearchDataSet{ObjectMeta: metav1.ObjectMeta{Name: "es-data", Namespace: "default"}},
+			&zv1.ElasticsearchDataSet{ObjectMeta: metav1.ObjectMeta{Name: "es-new", Namespace: "default"}},
+		).ZalandoV1(),
+		owns:   func(metav1.Object) bool { return true },
+		logger: log.WithField("test", t.Name()),
+		now:    func() time.Time { return now },
+	}
+	call := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		api.handler().ServeHTTP(w, httptest.NewRequest("GET", AdminAPIPrefix+path, nil))
+		return w
+	}
+
+	// cost reports are disabled.
+	assert.Equal(t, http.StatusNotFound, call("/costs").Code)
+
+	api.costs = newCostHistory(costHistoryRetention)
+	api.prices = &PriceTable{CPUCoreHour: 1}
+	api.costs.add(types.NamespacedName{Namespace: "default", Name: "es-data"}, Footprint{Time: now.Add(-2 * time.Hour), Nodes: 3, CPUCores: 6})
+	api.costs.add(types.NamespacedName{Namespace: "other", Name: "es-data"}, Footprint{Time: now.Add(-time.Hour), Nodes: 1})
+
+	w := call("/namespaces/default/elasticsearchdatasets/es-data/cost")
+	require.Equal(t, http.StatusOK, w.Code)
+	var report CostReport
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	assert.Equal(t, 6.0, report.NodeHours)
+	assert.Equal(t, 12.0, *report.EstimatedCost)
+
+	// EDS without samples have no report yet.
+	assert.Equal(t, http.StatusNotFound, call("/namespaces/default/elasticsearchdatasets/es-new/cost").Code)
+
+	var reports []CostReport
+	w = call("/costs")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &reports))
+	assert.Len(t, reports, 2)
+	w = call("/costs?namespace=other")
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &reports))
+	require.Len(t, reports, 1)
+	assert.Equal(t, "other", reports[0].Namespace)
+}
@@ -0,0 +1,138 @@
+package operator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ValidatingWebhookPath is the path of the validating admission webhook for
+// ElasticsearchDataSets.
+const ValidatingWebhookPath = "/validate/elasticsearchdatasets"
+
+// maxAdmissionReviewBytes limits the size of admission reviews read by the
+// webhook.
+const maxAdmissionReviewBytes = 3 << 20
+
+// ValidateElasticsearchDataSetUpdate validates the changes of an update of
+// the EDS. Fields the operator only reads when it creates the StatefulSet
+// can't be changed, except for growing the storage requests of the volume
+// claim templates.
+func ValidateElasticsearchDataSetUpdate(old, eds *zv1.ElasticsearchDataSet) error {
+	var errs []error
+	if !equality.Semantic.DeepEqual(old.Spec.VolumeClaimTemplates, eds.Spec.VolumeClaimTemplates) {
+		changes := volumeClaimTemplateChanges((&EDSResource{eds: old}).VolumeClaimTemplates(), (&EDSResource{eds: eds}).VolumeClaimTemplates())
+		for _, change := range changes {
+			errs = append(errs, fmt.Errorf("spec.volumeClaimTemplates is immutable, %s", change))
+		}
+		// the storage requests may grow.
+		if len(changes) == 0 && !equality.Semantic.DeepEqual(withoutStorageRequests(old.Spec.VolumeClaimTemplates), withoutStorageRequests(eds.Spec.VolumeClaimTemplates)) {
+			errs = append(errs, fmt.Errorf("spec.volumeClaimTemplates is immutable"))
+		}
+	}
+	if isMasterRole(old) != isMasterRole(eds) {
+		errs = append(errs, fmt.Errorf("spec.role is immutable"))
+	}
+	return errors.Join(errs...)
+}
+
+// ValidatingWebhookHandler returns an http.Handler of the validating
+// admission webhook, which rejects EDS the operator wouldn't manage and
+// changes of immutable fields. Updates of an EDS which was already invalid
+// before are only rejected for changes of immutable fields, so the operator
+// can keep updating EDS created before the webhook was set up.
+func ValidatingWebhookHandler() http.Handler {
+	return admissionHandler(reviewElasticsearchDataSet)
+}
+
+// admissionHandler returns an http.Handler answering admission reviews with
+// the response of review.
+func admissionHandler(review func(*admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse) http.Handler {
+	logger := log.WithField("component", "webhook")
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxAdmissionReviewBytes))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		admissionReview := &admissionv1.AdmissionReview{}
+		if err := json.Unmarshal(body, admissionReview); err != nil || admissionReview.Request == nil {
+			http.Error(w, "invalid admission review", http.StatusBadRequest)
+			return
+		}
+
+		request := admissionReview.Request
+		response := review(request)
+		if !response.Allowed {
+			logger.Infof("Rejected %s of EDS %s/%s: %s", request.Operation, request.Namespace, request.Name, response.Result.Message)
+		}
+		admissionReview.Response = response
+		admissionReview.Request = nil
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(admissionReview); err != nil {
+			logger.Errorf("Failed to write admission review: %v", err)
+		}
+	})
+}
+
+// reviewElasticsearchDataSet returns the response to the admission request
+// of an EDS.
+func reviewElasticsearchDataSet(request *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	response := &admissionv1.AdmissionResponse{UID: request.UID, Allowed: true}
+	if request.Operation != admissionv1.Create && request.Operation != admissionv1.Update {
+		return response
+	}
+
+	deny := func(err error) *admissionv1.AdmissionResponse {
+		response.Allowed = false
+		response.Result = &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Reason:  metav1.StatusReasonInvalid,
+			Code:    http.StatusUnprocessableEntity,
+			Message: err.Error(),
+		}
+		return response
+	}
+
+	eds := &zv1.ElasticsearchDataSet{}
+	if err := json.Unmarshal(request.Object.Raw, eds); err != nil {
+		return deny(fmt.Errorf("failed to decode ElasticsearchDataSet: %v", err))
+	}
+	err := ValidateElasticsearchDataSet(eds)
+	if request.Operation == admissionv1.Create {
+		if err != nil {
+			return deny(err)
+		}
+		return response
+	}
+
+	old := &zv1.ElasticsearchDataSet{}
+	if err := json.Unmarshal(request.OldObject.Raw, old); err != nil {
+		return deny(fmt.Errorf("failed to decode ElasticsearchDataSet: %v", err))
+	}
+	if updateErr := ValidateElasticsearchDataSetUpdate(old, eds); updateErr != nil {
+		return deny(updateErr)
+	}
+	if err != nil {
+		if ValidateElasticsearchDataSet(old) == nil {
+			return deny(err)
+		}
+		response.Warnings = []string{"ElasticsearchDataSet is invalid: " + strings.ReplaceAll(err.Error(), "\n", "; ")}
+	}
+	return response
+}
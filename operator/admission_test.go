@@ -0,0 +1,97 @@
+package operator
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	admissionv1 "k8s.io/api/admission/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestValidatingWebhook(t *testing.T) {
+	valid := func() *zv1.ElasticsearchDataSet {
+		return &zv1.ElasticsearchDataSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "es-data", Namespace: "default"},
+			Spec: zv1.ElasticsearchDataSetSpec{
+				Template: zv1.PodTemplateSpec{
+					Spec: v1.PodSpec{Containers: []v1.Container{{Name: "elasticsearch", Image: "elasticsearch:8.15.0"}}},
+				},
+				VolumeClaimTemplates: []zv1.PersistentVolumeClaim{{
+					EmbeddedObjectMetaWithName: zv1.EmbeddedObjectMetaWithName{Name: "data"},
+					Spec:                       testVolumeClaim("", "", "10Gi").Spec,
+				}},
+			},
+		}
+	}
+	raw := func(eds *zv1.ElasticsearchDataSet) runtime.RawExtension {
+		if eds == nil {
+			return runtime.RawExtension{}
+		}
+		data, err := json.Marshal(eds)
+		require.NoError(t, err)
+		return runtime.RawExtension{Raw: data}
+	}
+	review := func(operation admissionv1.Operation, old, eds *zv1.ElasticsearchDataSet) *admissionv1.AdmissionResponse {
+		body, err := json.Marshal(&admissionv1.AdmissionReview{
+			TypeMeta: metav1.TypeMeta{APIVersion: "admission.k8s.io/v1", Kind: "AdmissionReview"},
+			Request: &admissionv1.AdmissionRequest{
+				UID:       "uid",
+				Operation: operation,
+				Object:    raw(eds),
+				OldObject: raw(old),
+			},
+		})
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		ValidatingWebhookHandler().ServeHTTP(w, httptest.NewRequest(http.MethodPost, ValidatingWebhookPath, bytes.NewReader(body)))
+		require.Equal(t, http.StatusOK, w.Code)
+
+		result := &admissionv1.AdmissionReview{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), result))
+		require.Equal(t, "AdmissionReview", result.Kind)
+		require.EqualValues(t, "uid", result.Response.UID)
+		return result.Response
+	}
+
+	require.True(t, review(admissionv1.Create, nil, valid()).Allowed)
+
+	// min greater than max.
+	invalid := valid()
+	invalid.Spec.Scaling = &zv1.ElasticsearchDataSetScaling{Enabled: true, MinReplicas: 3, MaxReplicas: 2, MinShardsPerNode: 3, MaxShardsPerNode: 2}
+	response := review(admissionv1.Create, nil, invalid)
+	require.False(t, response.Allowed)
+	require.Contains(t, response.Result.Message, "minReplicas(3) can't be greater than maxReplicas(2)")
+
+	// no Elasticsearch container.
+	invalid = valid()
+	invalid.Spec.Template.Spec.Containers = nil
+	require.False(t, review(admissionv1.Create, nil, invalid).Allowed)
+
+	// the volume claim templates are immutable.
+	updated := valid()
+	updated.Spec.VolumeClaimTemplates[0].Name = "other"
+	response = review(admissionv1.Update, valid(), updated)
+	require.False(t, response.Allowed)
+	require.Equal(t, "spec.volumeClaimTemplates is immutable, volume claim template data was renamed to other", response.Result.Message)
+
+	// updates of a valid EDS must keep it valid.
+	invalid = valid()
+	invalid.Spec.Template.Spec.Containers[0].Image = ""
+	require.False(t, review(admissionv1.Update, valid(), invalid).Allowed)
+
+	// EDS which were invalid before can still be updated.
+	old := invalid.DeepCopy()
+	invalid.Annotations = map[string]string{"foo": "bar"}
+	response = review(admissionv1.Update, old, invalid)
+	require.True(t, response.Allowed)
+	require.Len(t, response.Warnings, 1)
+
+	require.True(t, review(admissionv1.Delete, valid(), nil).Allowed)
+}
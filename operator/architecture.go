@@ -0,0 +1,206 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/prometheus/client_golang/prometheus"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/metrics/pkg/apis/metrics/v1beta1"
+)
+
+// unknownArchitecture is the architecture label of pods on nodes without
+// the architecture label, e.g. pods not scheduled yet.
+const unknownArchitecture = "unknown"
+
+var (
+	architecturePods = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "es_operator",
+		Subsystem: "architecture",
+		Name:      "pods",
+		Help:      "Pods of an EDS by the CPU architecture of their node.",
+	}, []string{"namespace", "eds", "arch"})
+	architectureCPUUsage = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "es_operator",
+		Subsystem: "architecture",
+		Name:      "cpu_usage_percent",
+		Help:      "CPU usage of the pods of an EDS relative to their requests by the CPU architecture of their node, aggregated like for scaling.",
+	}, []string{"namespace", "eds", "arch"})
+)
+
+func init() {
+	prometheus.MustRegister(architecturePods, architectureCPUUsage)
+}
+
+// injectArchitecture requires the pods to be scheduled on nodes of the
+// architecture. The requirement is added to every node selector term, as
+// the terms are ORed.
+func injectArchitecture(spec *v1.PodSpec, arch zv1.ElasticsearchDataSetArchitecture) {
+	if arch == "" {
+		return
+	}
+	requirement := v1.NodeSelectorRequirement{
+		Key:      v1.LabelArchStable,
+		Operator: v1.NodeSelectorOpIn,
+		Values:   []string{string(arch)},
+	}
+
+	if spec.Affinity == nil {
+		spec.Affinity = &v1.Affinity{}
+	}
+	if spec.Affinity.NodeAffinity == nil {
+		spec.Affinity.NodeAffinity = &v1.NodeAffinity{}
+	}
+	required := spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+	if required == nil {
+		required = &v1.NodeSelector{}
+		spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution = required
+	}
+	if len(required.NodeSelectorTerms) == 0 {
+		required.NodeSelectorTerms = []v1.NodeSelectorTerm{{}}
+	}
+	for i := range required.NodeSelectorTerms {
+		term := &required.NodeSelectorTerms[i]
+		if !containsNodeSelectorRequirement(term.MatchExpressions, requirement) {
+			term.MatchExpressions = append(term.MatchExpressions, requirement)
+		}
+	}
+}
+
+func containsNodeSelectorRequirement(requirements []v1.NodeSelectorRequirement, requirement v1.NodeSelectorRequirement) bool {
+	for _, r := range requirements {
+		if equality.Semantic.DeepEqual(r, requirement) {
+			return true
+		}
+	}
+	return false
+}
+
+// podSpecArchitectures returns the architectures the pods are restricted to
+// by the node selector or the required node affinity. It returns nil if the
+// pods can run on any architecture.
+func podSpecArchitectures(spec *v1.PodSpec) []string {
+	if arch, ok := spec.NodeSelector[v1.LabelArchStable]; ok {
+		return []string{arch}
+	}
+	if spec.Affinity == nil || spec.Affinity.NodeAffinity == nil || spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	var architectures []string
+	for _, term := range spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms {
+		var values []string
+		for _, expression := range term.MatchExpressions {
+			if expression.Key == v1.LabelArchStable && expression.Operator == v1.NodeSelectorOpIn {
+				values = expression.Values
+			}
+		}
+		// a term without an architecture allows all of them.
+		if values == nil {
+			return nil
+		}
+		for _, value := range values {
+			if !seen[value] {
+				seen[value] = true
+				architectures = append(architectures, value)
+			}
+		}
+	}
+	sort.Strings(architectures)
+	return architectures
+}
+
+// checkImagePlatforms returns an error if the image of a container doesn't
+// support an architecture the pods can be scheduled on. Single platform
+// images can't be checked without pulling their config and are skipped.
+func checkImagePlatforms(ctx context.Context, resolver ImagePlatformResolver, keychain authn.Keychain, spec *v1.PodSpec) error {
+	architectures := podSpecArchitectures(spec)
+	if len(architectures) == 0 {
+		return nil
+	}
+
+	for _, containers := range [][]v1.Container{spec.InitContainers, spec.Containers} {
+		for _, container := range containers {
+			platforms, err := resolver.Platforms(ctx, container.Image, keychain)
+			if err != nil {
+				return err
+			}
+			if len(platforms) == 0 {
+				continue
+			}
+			supported := make(map[string]bool, len(platforms))
+			for _, platform := range platforms {
+				supported[platform] = true
+			}
+			for _, arch := range architectures {
+				if !supported["linux/"+arch] {
+					return fmt.Errorf("image '%s' of container %s doesn't support platform linux/%s", container.Image, container.Name, arch)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// cpuUsagePercentByArchitecture returns the CPU usage of the pods relative
+// to their requests grouped by the architecture of their node.
+func cpuUsagePercentByArchitecture(metrics []v1beta1.PodMetrics, pods []v1.Pod, nodeArchitectures map[string]string) map[string][]int32 {
+	podsByArchitecture := make(map[string][]v1.Pod)
+	for _, pod := range pods {
+		arch, ok := nodeArchitectures[pod.Spec.NodeName]
+		if !ok {
+			arch = unknownArchitecture
+		}
+		podsByArchitecture[arch] = append(podsByArchitecture[arch], pod)
+	}
+
+	usage := make(map[string][]int32, len(podsByArchitecture))
+	for arch, pods := range podsByArchitecture {
+		usage[arch] = getCPUUsagePercent(metrics, pods)
+	}
+	return usage
+}
+
+// recordArchitectureMetrics exposes the number of pods and their aggregated
+// CPU usage per node architecture, to compare the performance of the
+// architectures in mixed node pools.
+func (c *ElasticsearchMetricsCollector) recordArchitectureMetrics(metrics []v1beta1.PodMetrics) {
+	eds := c.es.ElasticsearchDataSet
+	nodeArchitectures := make(map[string]string)
+	if c.nodeLister != nil {
+		for _, pod := range c.es.Pods {
+			if pod.Spec.NodeName == "" {
+				continue
+			}
+			node, err := c.nodeLister.Get(pod.Spec.NodeName)
+			if err != nil {
+				continue
+			}
+			if arch, ok := node.Labels[v1.LabelArchStable]; ok {
+				nodeArchitectures[node.Name] = arch
+			}
+		}
+	}
+
+	labels := prometheus.Labels{"namespace": eds.Namespace, "eds": eds.Name}
+	architecturePods.DeletePartialMatch(labels)
+	architectureCPUUsage.DeletePartialMatch(labels)
+	for _, pod := range c.es.Pods {
+		arch, ok := nodeArchitectures[pod.Spec.NodeName]
+		if !ok {
+			arch = unknownArchitecture
+		}
+		architecturePods.WithLabelValues(eds.Namespace, eds.Name, arch).Inc()
+	}
+	for arch, usage := range cpuUsagePercentByArchitecture(metrics, c.es.Pods, nodeArchitectures) {
+		if len(usage) == 0 {
+			continue
+		}
+		architectureCPUUsage.WithLabelValues(eds.Namespace, eds.Name, arch).Set(float64(aggregateCPUUsage(usage, eds.Spec.Scaling.Aggregation)))
+	}
+}
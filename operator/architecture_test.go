@@ -0,0 +1,112 @@
+package operator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/metrics/pkg/apis/metrics/v1beta1"
+)
+
+func TestInjectArchitecture(t *testing.T) {
+	spec := &v1.PodSpec{}
+	injectArchitecture(spec, "")
+	require.Nil(t, spec.Affinity)
+	require.Nil(t, podSpecArchitectures(spec))
+
+	injectArchitecture(spec, zv1.ArchitectureARM64)
+	injectArchitecture(spec, zv1.ArchitectureARM64)
+	require.Equal(t, []string{"arm64"}, podSpecArchitectures(spec))
+	require.Len(t, spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms[0].MatchExpressions, 1)
+
+	// the requirement is added to all existing terms.
+	spec = &v1.PodSpec{
+		Affinity: &v1.Affinity{
+			NodeAffinity: &v1.NodeAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: &v1.NodeSelector{
+					NodeSelectorTerms: []v1.NodeSelectorTerm{
+						{MatchExpressions: []v1.NodeSelectorRequirement{{Key: "pool", Operator: v1.NodeSelectorOpIn, Values: []string{"a"}}}},
+						{MatchExpressions: []v1.NodeSelectorRequirement{{Key: "pool", Operator: v1.NodeSelectorOpIn, Values: []string{"b"}}}},
+					},
+				},
+			},
+		},
+	}
+	require.Nil(t, podSpecArchitectures(spec))
+	injectArchitecture(spec, zv1.ArchitectureAMD64)
+	for _, term := range spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms {
+		require.Len(t, term.MatchExpressions, 2)
+	}
+	require.Equal(t, []string{"amd64"}, podSpecArchitectures(spec))
+
+	spec = &v1.PodSpec{NodeSelector: map[string]string{v1.LabelArchStable: "arm64"}}
+	require.Equal(t, []string{"arm64"}, podSpecArchitectures(spec))
+}
+
+type staticPlatformResolver map[string][]string
+
+func (r staticPlatformResolver) Platforms(_ context.Context, image string, _ authn.Keychain) ([]string, error) {
+	return r[image], nil
+}
+
+func TestCheckImagePlatforms(t *testing.T) {
+	resolver := staticPlatformResolver{
+		"elasticsearch:8.6.2": {"linux/amd64", "linux/arm64"},
+		"exporter:1.0":        {"linux/amd64"},
+	}
+	spec := &v1.PodSpec{
+		InitContainers: []v1.Container{{Name: "init", Image: "busybox"}},
+		Containers:     []v1.Container{{Name: "elasticsearch", Image: "elasticsearch:8.6.2"}},
+	}
+	require.NoError(t, checkImagePlatforms(context.Background(), resolver, pullSecretsKeychain{}, spec))
+
+	injectArchitecture(spec, zv1.ArchitectureARM64)
+	require.NoError(t, checkImagePlatforms(context.Background(), resolver, pullSecretsKeychain{}, spec))
+
+	spec.Containers = append(spec.Containers, v1.Container{Name: "exporter", Image: "exporter:1.0"})
+	require.Error(t, checkImagePlatforms(context.Background(), resolver, pullSecretsKeychain{}, spec))
+}
+
+func TestCPUUsagePercentByArchitecture(t *testing.T) {
+	pod := func(name, node string) v1.Pod {
+		return v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: v1.PodSpec{
+				NodeName: node,
+				Containers: []v1.Container{
+					{
+						Name: "elasticsearch",
+						Resources: v1.ResourceRequirements{
+							Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1000m")},
+						},
+					},
+				},
+			},
+		}
+	}
+	usage := func(name, cpu string) v1beta1.PodMetrics {
+		return v1beta1.PodMetrics{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Containers: []v1beta1.ContainerMetrics{
+				{Name: "elasticsearch", Usage: v1.ResourceList{v1.ResourceCPU: resource.MustParse(cpu)}},
+			},
+		}
+	}
+
+	pods := []v1.Pod{pod("es-0", "node-a"), pod("es-1", "node-b"), pod("es-2", "node-c"), pod("es-3", "")}
+	metrics := []v1beta1.PodMetrics{usage("es-0", "400m"), usage("es-1", "700m"), usage("es-2", "600m")}
+	require.Equal(t, map[string][]int32{
+		"amd64":             {40},
+		"arm64":             {70, 60},
+		unknownArchitecture: {},
+	}, cpuUsagePercentByArchitecture(metrics, pods, map[string]string{
+		"node-a": "amd64",
+		"node-b": "arm64",
+		"node-c": "arm64",
+	}))
+}
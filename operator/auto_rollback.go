@@ -0,0 +1,142 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	defaultAutoRollbackWindow = 5 * time.Minute
+
+	rolloutFailedConditionType = zv1.ConditionRolloutFailed
+)
+
+// autoRollbackSettings returns the window and unhealthy status of the auto
+// rollback configuration with defaults applied.
+func autoRollbackSettings(config *zv1.ElasticsearchDataSetAutoRollback) (time.Duration, string) {
+	window := defaultAutoRollbackWindow
+	if config.WindowSeconds > 0 {
+		window = time.Duration(config.WindowSeconds) * time.Second
+	}
+	status := "red"
+	if config.UnhealthyStatus != "" {
+		status = config.UnhealthyStatus
+	}
+	return window, status
+}
+
+// rolloutFailure returns why the rollout is unhealthy, or an empty string if
+// it's healthy.
+func rolloutFailure(progress *zv1.ElasticsearchDataSetRolloutProgress, health string, config *zv1.ElasticsearchDataSetAutoRollback) string {
+	for _, revision := range progress.Revisions {
+		if revision.Revision != progress.UpdateRevision {
+			continue
+		}
+		if unready := revision.Pods - revision.ReadyPods; unready > config.MaxUnreadyPods {
+			return fmt.Sprintf("%d updated Pods aren't ready", unready)
+		}
+	}
+
+	_, unhealthyStatus := autoRollbackSettings(config)
+	if health == "red" || (health == "yellow" && unhealthyStatus == "yellow") {
+		return fmt.Sprintf("cluster health is %s", health)
+	}
+	return ""
+}
+
+// autoRollback rolls the Pod template back to the previous revision if the
+// ongoing rollout is unhealthy for the configured window and sets the
+// RolloutFailed condition. The condition is kept until a new template is
+// recorded. It returns the template history including the restored
+// revision.
+func (r *EDSResource) autoRollback(ctx context.Context, progress *zv1.ElasticsearchDataSetRolloutProgress, history []zv1.ElasticsearchDataSetTemplateRevision, conditions *[]metav1.Condition, now time.Time) ([]zv1.ElasticsearchDataSetTemplateRevision, error) {
+	config := r.eds.Spec.AutoRollback
+	if config == nil || !config.Enabled {
+		meta.RemoveStatusCondition(conditions, rolloutFailedConditionType)
+		return history, nil
+	}
+
+	if condition := meta.FindStatusCondition(*conditions, rolloutFailedConditionType); condition != nil {
+		if len(history) > 0 && history[len(history)-1].Generation > condition.ObservedGeneration {
+			meta.RemoveStatusCondition(conditions, rolloutFailedConditionType)
+		} else {
+			// don't roll back the rollback.
+			return history, nil
+		}
+	}
+
+	if progress == nil || progress.Complete || progress.Paused || progress.CurrentRevision == "" {
+		return history, nil
+	}
+	// the failing template must be the latest revision.
+	if len(history) < 2 || !reflect.DeepEqual(history[len(history)-1].Template, r.eds.Spec.Template) {
+		return history, nil
+	}
+
+	health := ""
+	if r.esClient != nil {
+		clusterHealth, err := r.esClient.ClusterHealth()
+		if err != nil {
+			return history, fmt.Errorf("failed to get cluster health: %v", err)
+		}
+		health = clusterHealth.Status
+	}
+
+	failure := rolloutFailure(progress, health, config)
+	if failure == "" {
+		return history, nil
+	}
+
+	previous := r.eds.Status.RolloutProgress
+	if previous != nil && previous.UpdateRevision == progress.UpdateRevision && previous.UnhealthySince != nil {
+		progress.UnhealthySince = previous.UnhealthySince
+	} else {
+		progress.UnhealthySince = &metav1.Time{Time: now}
+	}
+
+	window, _ := autoRollbackSettings(config)
+	if now.Sub(progress.UnhealthySince.Time) < window {
+		return history, nil
+	}
+
+	failed := history[len(history)-1]
+	target := history[len(history)-2]
+	eds := r.eds.DeepCopy()
+	eds.Spec.Template = *target.Template.DeepCopy()
+	eds, err := r.kube.ZalandoV1().ElasticsearchDataSets(eds.Namespace).Update(ctx, eds, metav1.UpdateOptions{})
+	if err != nil {
+		return history, fmt.Errorf("failed to roll back EDS %s/%s: %v", r.eds.Namespace, r.eds.Name, err)
+	}
+
+	// set TypeMeta manually because of this bug:
+	// https://github.com/kubernetes/client-go/issues/308
+	eds.APIVersion = "zalando.org/v1"
+	eds.Kind = "ElasticsearchDataSet"
+	r.eds = eds
+
+	limit := defaultRevisionHistoryLimit
+	if r.eds.Spec.RevisionHistoryLimit != nil {
+		limit = int(*r.eds.Spec.RevisionHistoryLimit)
+	}
+	history = recordTemplateRevision(history, &r.eds.Spec.Template, r.eds.Generation, now, limit)
+	progress.UnhealthySince = nil
+
+	message := fmt.Sprintf("Rolled back Pod template from revision %d to revision %d: %s for %s",
+		failed.Revision, target.Revision, failure, window)
+	meta.SetStatusCondition(conditions, metav1.Condition{
+		Type:               rolloutFailedConditionType,
+		Status:             metav1.ConditionTrue,
+		Reason:             zv1.ReasonRolledBack,
+		Message:            message,
+		ObservedGeneration: r.eds.Generation,
+	})
+	r.recorder.Event(r.eds, v1.EventTypeWarning, "RolloutFailed", message)
+	return history, nil
+}
@@ -0,0 +1,92 @@
+package operator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestRolloutFailure(t *testing.T) {
+	progress := &zv1.ElasticsearchDataSetRolloutProgress{
+		UpdateRevision: "new",
+		Revisions: []zv1.ElasticsearchDataSetRevisionPods{
+			{Revision: "new", Pods: 2, ReadyPods: 1},
+			{Revision: "old", Pods: 2, ReadyPods: 0},
+		},
+	}
+	config := &zv1.ElasticsearchDataSetAutoRollback{Enabled: true}
+
+	require.Equal(t, "1 updated Pods aren't ready", rolloutFailure(progress, "green", config))
+
+	config.MaxUnreadyPods = 1
+	require.Empty(t, rolloutFailure(progress, "green", config))
+	require.Empty(t, rolloutFailure(progress, "yellow", config))
+	require.Equal(t, "cluster health is red", rolloutFailure(progress, "red", config))
+
+	config.UnhealthyStatus = "yellow"
+	require.Equal(t, "cluster health is yellow", rolloutFailure(progress, "yellow", config))
+}
+
+func TestAutoRollbackWindow(t *testing.T) {
+	history := []zv1.ElasticsearchDataSetTemplateRevision{
+		{Revision: 1, Generation: 1, Template: *imageTemplate("es:1")},
+		{Revision: 2, Generation: 2, Template: *imageTemplate("es:2")},
+	}
+	eds := &zv1.ElasticsearchDataSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "logs", Namespace: "default", Generation: 2},
+		Spec: zv1.ElasticsearchDataSetSpec{
+			Template:     *imageTemplate("es:2"),
+			AutoRollback: &zv1.ElasticsearchDataSetAutoRollback{Enabled: true},
+		},
+	}
+	r := &EDSResource{eds: eds, recorder: record.NewFakeRecorder(10)}
+	progress := func() *zv1.ElasticsearchDataSetRolloutProgress {
+		return &zv1.ElasticsearchDataSetRolloutProgress{
+			UpdateRevision:  "new",
+			CurrentRevision: "old",
+			Revisions:       []zv1.ElasticsearchDataSetRevisionPods{{Revision: "new", Pods: 1}},
+		}
+	}
+	now := time.Now()
+	var conditions []metav1.Condition
+
+	// unhealthy, but within the window
+	current := progress()
+	updated, err := r.autoRollback(context.Background(), current, history, &conditions, now)
+	require.NoError(t, err)
+	require.Equal(t, history, updated)
+	require.Equal(t, now.Unix(), current.UnhealthySince.Unix())
+
+	// the start of the unhealthy window is kept
+	eds.Status.RolloutProgress = current
+	next := progress()
+	_, err = r.autoRollback(context.Background(), next, history, &conditions, now.Add(time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, current.UnhealthySince, next.UnhealthySince)
+
+	// no rollback while a previous rollback isn't superseded
+	conditions = []metav1.Condition{{Type: rolloutFailedConditionType, Status: metav1.ConditionTrue, ObservedGeneration: 2}}
+	next = progress()
+	_, err = r.autoRollback(context.Background(), next, history, &conditions, now.Add(time.Hour))
+	require.NoError(t, err)
+	require.Nil(t, next.UnhealthySince)
+
+	// the condition is removed once a new template is recorded
+	history = append(history, zv1.ElasticsearchDataSetTemplateRevision{Revision: 3, Generation: 3, Template: *imageTemplate("es:3")})
+	_, err = r.autoRollback(context.Background(), progress(), history, &conditions, now)
+	require.NoError(t, err)
+	require.Nil(t, meta.FindStatusCondition(conditions, rolloutFailedConditionType))
+
+	// disabled
+	conditions = []metav1.Condition{{Type: rolloutFailedConditionType, Status: metav1.ConditionTrue}}
+	eds.Spec.AutoRollback.Enabled = false
+	_, err = r.autoRollback(context.Background(), progress(), history, &conditions, now)
+	require.NoError(t, err)
+	require.Empty(t, conditions)
+}
@@ -43,6 +43,20 @@ type ScalingOperation struct {
 	NodeReplicas     *int32
 	IndexReplicas    []ESIndex
 	Description      string
+	// ScalingHint is the direction suggested by the CPU usage.
+	ScalingHint ScalingDirection `json:"-"`
+	// PrimariesRecommendations are the indices limiting a scale-up because
+	// of too few primary shards.
+	PrimariesRecommendations []zv1.ElasticsearchDataSetPrimariesRecommendation `json:"-"`
+	// OversizedShards are the primary shards exceeding the maximum shard
+	// size, largest first.
+	OversizedShards []ESShardSize `json:"-"`
+	// RaiseShardLimit is the value cluster.max_shards_per_node must be
+	// raised to before scaling down.
+	RaiseShardLimit int32 `json:"-"`
+	// OverprovisionedReplicas are the node replicas on top of the needed
+	// replicas.
+	OverprovisionedReplicas int32 `json:"-"`
 }
 
 func noopScalingOperation(description string) *ScalingOperation {
@@ -59,22 +73,65 @@ type AutoScaler struct {
 	metricsInterval time.Duration
 	pods            []v1.Pod
 	esClient        *ESClient
+	// overprovisioned are the replicas on top of the replicas of the EDS
+	// kept as headroom.
+	overprovisioned int32
 }
 
 func NewAutoScaler(es *ESResource, metricsInterval time.Duration, esClient *ESClient) *AutoScaler {
+	eds := es.ElasticsearchDataSet
+	// scale based on the settings of an active burst.
+	if scaling := burstScaling(eds, time.Now()); scaling != eds.Spec.Scaling {
+		burstEDS := *eds
+		burstEDS.Spec.Scaling = scaling
+		eds = &burstEDS
+	}
+	// scale based on the needed replicas.
+	overprovisioned := int32(0)
+	if neededEDS := neededReplicasEDS(eds); neededEDS != eds {
+		overprovisioned = *eds.Spec.Replicas - *neededEDS.Spec.Replicas
+		eds = neededEDS
+	}
+
 	return &AutoScaler{
 		logger: log.WithFields(log.Fields{
 			"eds":       es.ElasticsearchDataSet.Name,
 			"namespace": es.ElasticsearchDataSet.Namespace,
 		}),
-		eds:             es.ElasticsearchDataSet,
+		eds:             eds,
 		esMSet:          es.MetricSet,
 		metricsInterval: metricsInterval,
 		pods:            es.Pods,
 		esClient:        esClient,
+		overprovisioned: overprovisioned,
 	}
 }
 
+// sampleWindows returns the number of the latest consecutive metric samples
+// above the scale-up boundary and below the scale-down boundary, each capped
+// at the number of samples needed to scale.
+func (as *AutoScaler) sampleWindows() (int32, int32) {
+	scaling := as.eds.Spec.Scaling
+	if as.esMSet == nil || scaling == nil {
+		return 0, 0
+	}
+
+	count := func(thresholdSeconds int64, matches func(int32) bool) int32 {
+		required := int(math.Ceil(float64(thresholdSeconds) / as.metricsInterval.Seconds()))
+		samples := 0
+		for i := len(as.esMSet.Metrics) - 1; i >= 0 && samples < required; i-- {
+			if !matches(as.esMSet.Metrics[i].Value) {
+				break
+			}
+			samples++
+		}
+		return int32(samples)
+	}
+	scaleUp := count(scaling.ScaleUpThresholdDurationSeconds, func(value int32) bool { return value > scaling.ScaleUpCPUBoundary })
+	scaleDown := count(scaling.ScaleDownThresholdDurationSeconds, func(value int32) bool { return value < scaling.ScaleDownCPUBoundary })
+	return scaleUp, scaleDown
+}
+
 func (as *AutoScaler) scalingHint() ScalingDirection {
 	scaling := as.eds.Spec.Scaling
 
@@ -99,6 +156,10 @@ func (as *AutoScaler) scalingHint() ScalingDirection {
 				break
 			}
 		}
+		if peak, ok := as.predictedPeak(time.Now()); scaleDownRequired && ok && peak >= scaling.ScaleDownCPUBoundary {
+			as.logger.Infof("Not scaling down, predicted CPU usage of %d%% is above the scale-down boundary.", peak)
+			scaleDownRequired = false
+		}
 		if scaleDownRequired {
 			if status.LastScaleDownStarted == nil || status.LastScaleDownStarted.Time.Before(time.Now().Add(-time.Duration(scaling.ScaleDownCooldownSeconds)*time.Second)) {
 				as.logger.Infof("Scaling hint: %s", DOWN)
@@ -126,6 +187,13 @@ func (as *AutoScaler) scalingHint() ScalingDirection {
 			as.logger.Info("Not scaling up, currently in cool-down period.")
 		}
 	}
+
+	// fall back to the prediction if the current usage doesn't require
+	// scaling.
+	if as.predictiveScaleUp(time.Now()) {
+		as.logger.Infof("Scaling hint: %s (predictive)", UP)
+		return UP
+	}
 	return NONE
 }
 
@@ -150,7 +218,26 @@ func (as *AutoScaler) GetScalingOperation() (*ScalingOperation, error) {
 
 	managedIndices := as.getManagedIndices(esIndices, esShards)
 	managedNodes := as.getManagedNodes(as.pods, esNodes)
-	return as.calculateScalingOperation(managedIndices, managedNodes, direction), nil
+	scalingOperation := as.calculateScalingOperation(managedIndices, managedNodes, direction)
+	scalingOperation = as.reviewScalingOperation(managedIndices, managedNodes, scalingOperation)
+	scalingOperation, err = as.reviewShardSize(managedIndices, scalingOperation)
+	if err != nil {
+		return nil, err
+	}
+	scalingOperation, err = as.reviewShardLimit(managedIndices, scalingOperation)
+	if err != nil {
+		return nil, err
+	}
+	scalingOperation = as.overprovision(scalingOperation)
+	scalingOperation, err = as.reviewDiskHeadroom(managedIndices, scalingOperation)
+	if err != nil {
+		return nil, err
+	}
+	scalingOperation.ScalingHint = direction
+	if direction == UP {
+		scalingOperation.PrimariesRecommendations = primariesRecommendations(managedIndices, int32(len(managedNodes)), as.eds.Spec.Scaling, time.Now())
+	}
+	return scalingOperation, nil
 }
 
 func (as *AutoScaler) getManagedNodes(pods []v1.Pod, esNodes []ESNode) []ESNode {
@@ -170,24 +257,7 @@ func (as *AutoScaler) getManagedNodes(pods []v1.Pod, esNodes []ESNode) []ESNode
 }
 
 func (as *AutoScaler) getManagedIndices(esIndices []ESIndex, esShards []ESShard) map[string]ESIndex {
-	podIPs := make(map[string]struct{})
-	for _, pod := range as.pods {
-		if pod.Status.PodIP != "" {
-			podIPs[pod.Status.PodIP] = struct{}{}
-		}
-	}
-	managedIndices := make(map[string]ESIndex)
-	for _, shard := range esShards {
-		if _, ok := podIPs[shard.IP]; ok {
-			for _, index := range esIndices {
-				if shard.Index == index.Index {
-					managedIndices[shard.Index] = index
-					break
-				}
-			}
-		}
-	}
-	return managedIndices
+	return managedIndices(as.pods, esIndices, esShards)
 }
 
 func (as *AutoScaler) calculateScalingOperation(managedIndices map[string]ESIndex, managedNodes []ESNode, scalingHint ScalingDirection) *ScalingOperation {
@@ -214,6 +284,19 @@ func (as *AutoScaler) calculateScalingOperation(managedIndices map[string]ESInde
 		return noopScalingOperation(fmt.Sprintf("Scaling would violate the minimum required disk free percent: %.2f", 75.0))
 	}
 
+	// user defined guards which must be satisfied for a scale-down
+	if scalingOperation.ScalingDirection == DOWN && len(scalingSpec.ScaleDownGuards) > 0 {
+		context := as.scalingContext(managedIndices, managedNodes, *currentDesiredNodeReplicas, scalingOperation)
+		guard, err := failedScalingGuard(scalingSpec.ScaleDownGuards, context)
+		if err != nil {
+			as.logger.Warn(err)
+			return noopScalingOperation(fmt.Sprintf("Not scaling down, scale-down guard %s failed: %v", guard, err))
+		}
+		if guard != "" {
+			return noopScalingOperation(fmt.Sprintf("Not scaling down, scale-down guard %s is not satisfied.", guard))
+		}
+	}
+
 	return scalingOperation
 }
 
@@ -269,11 +352,13 @@ func (as *AutoScaler) scaleUpOrDown(esIndices map[string]ESIndex, scalingHint Sc
 		}
 	}
 
-	currentShardToNodeRatio := shardToNodeRatio(currentTotalShards, currentDesiredNodeReplicas)
+	// the ratio accounts for the capacity of the ordinal overrides.
+	overrides := as.eds.Spec.OrdinalOverrides
+	currentShardToNodeRatio := float64(currentTotalShards) / capacityNodes(overrides, currentDesiredNodeReplicas)
 
 	// independent of the scaling direction: in case the scaling setting MaxShardsPerNode has changed, we might need to scale up.
 	if currentShardToNodeRatio > float64(scalingSpec.MaxShardsPerNode) {
-		newDesiredNodeReplicas := as.ensureBoundsNodeReplicas(int32(math.Ceil(shardToNodeRatio(currentTotalShards, scalingSpec.MaxShardsPerNode))))
+		newDesiredNodeReplicas := as.ensureBoundsNodeReplicas(replicasForCapacity(overrides, shardToNodeRatio(currentTotalShards, scalingSpec.MaxShardsPerNode)))
 		return &ScalingOperation{
 			ScalingDirection: as.calculateScalingDirection(currentDesiredNodeReplicas, newDesiredNodeReplicas),
 			NodeReplicas:     &newDesiredNodeReplicas,
@@ -363,7 +448,7 @@ func (as *AutoScaler) scaleUpOrDown(esIndices map[string]ESIndex, scalingHint Sc
 		}
 		// increase shard-to-node ratio, and scale down by at least one
 		newDesiredNodeReplicas := as.ensureBoundsNodeReplicas(calculateDecreasedNodes(currentDesiredNodeReplicas, currentTotalShards))
-		ratio := shardToNodeRatio(newTotalShards, newDesiredNodeReplicas)
+		ratio := float64(newTotalShards) / capacityNodes(overrides, newDesiredNodeReplicas)
 		if ratio > float64(scalingSpec.MaxShardsPerNode) {
 			return noopScalingOperation(fmt.Sprintf("Scaling would violate the shard-to-node maximum (%.2f/%d).", ratio, scalingSpec.MaxShardsPerNode))
 		}
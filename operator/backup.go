@@ -0,0 +1,180 @@
+package operator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Backup is a point-in-time export of the Kubernetes resources managed by
+// the operator and the Elasticsearch cluster settings it owns. The
+// resources are a List which can be re-applied with kubectl, the cluster
+// settings of each endpoint can be PUT to _cluster/settings.
+type Backup struct {
+	Time            time.Time              `json:"time"`
+	Resources       v1.List                `json:"resources"`
+	ClusterSettings map[string]*ESSettings `json:"clusterSettings"`
+	// Errors lists the parts which could not be exported, e.g. because an
+	// Elasticsearch cluster was unreachable.
+	Errors []string `json:"errors,omitempty"`
+}
+
+// Backup exports the EDS owned by the operator together with their
+// MetricSets, Services and ConfigMaps, and the cluster settings of their
+// Elasticsearch clusters. StatefulSets and PodDisruptionBudgets are not
+// included as they are recreated from the EDS.
+func (o *ElasticsearchOperator) Backup(ctx context.Context) (*Backup, error) {
+	edss, err := o.kube.ZalandoV1().ElasticsearchDataSets(o.namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	owned := make([]zv1.ElasticsearchDataSet, 0, len(edss.Items))
+	for _, eds := range edss.Items {
+		if o.hasOwnership(&eds) {
+			owned = append(owned, eds)
+		}
+	}
+
+	metricSets, err := o.kube.ZalandoV1().ElasticsearchMetricSets(o.namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	services, err := o.kube.CoreV1().Services(o.namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	configMaps, err := o.kube.CoreV1().ConfigMaps(o.namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	resources, err := backupResources(owned, metricSets.Items, services.Items, configMaps.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	backup := &Backup{
+		Time:            time.Now().UTC(),
+		Resources:       v1.List{TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "List"}, Items: resources},
+		ClusterSettings: make(map[string]*ESSettings),
+	}
+
+	for _, eds := range owned {
+		endpoint := o.getElasticsearchEndpoint(&eds)
+		if _, ok := backup.ClusterSettings[endpoint.String()]; ok {
+			continue
+		}
+
+		client := &ESClient{Endpoint: endpoint, transport: o.elasticsearchTransport(&eds)}
+		settings, err := client.getClusterSettings()
+		if err != nil {
+			backup.Errors = append(backup.Errors, fmt.Sprintf("failed to get cluster settings of EDS %s/%s from %s: %v", eds.Namespace, eds.Name, endpoint, err))
+			continue
+		}
+		backup.ClusterSettings[endpoint.String()] = settings
+	}
+
+	return backup, nil
+}
+
+// backupResources returns the EDS and the MetricSets, Services and
+// ConfigMaps owned by them, stripped of server populated fields so they can
+// be created in a new cluster.
+func backupResources(edss []zv1.ElasticsearchDataSet, metricSets []zv1.ElasticsearchMetricSet, services []v1.Service, configMaps []v1.ConfigMap) ([]runtime.RawExtension, error) {
+	owners := make(map[types.UID]struct{}, len(edss))
+	objects := make([]metav1.Object, 0, len(edss))
+
+	for _, eds := range edss {
+		eds := eds
+		owners[eds.UID] = struct{}{}
+		eds.TypeMeta = metav1.TypeMeta{APIVersion: "zalando.org/v1", Kind: "ElasticsearchDataSet"}
+		eds.Status = zv1.ElasticsearchDataSetStatus{}
+		objects = append(objects, &eds)
+	}
+
+	ownedByEDS := func(meta metav1.ObjectMeta) bool {
+		uid, ok := getOwnerUID(meta)
+		if !ok {
+			return false
+		}
+		_, ok = owners[uid]
+		return ok
+	}
+
+	for _, ms := range metricSets {
+		ms := ms
+		if ownedByEDS(ms.ObjectMeta) {
+			ms.TypeMeta = metav1.TypeMeta{APIVersion: "zalando.org/v1", Kind: "ElasticsearchMetricSet"}
+			objects = append(objects, &ms)
+		}
+	}
+
+	for _, svc := range services {
+		svc := svc
+		if ownedByEDS(svc.ObjectMeta) {
+			svc.TypeMeta = metav1.TypeMeta{APIVersion: "v1", Kind: "Service"}
+			// the cluster IP is allocated by the new cluster.
+			svc.Spec.ClusterIP = ""
+			svc.Spec.ClusterIPs = nil
+			svc.Status = v1.ServiceStatus{}
+			objects = append(objects, &svc)
+		}
+	}
+
+	for _, cm := range configMaps {
+		cm := cm
+		if ownedByEDS(cm.ObjectMeta) {
+			cm.TypeMeta = metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"}
+			objects = append(objects, &cm)
+		}
+	}
+
+	items := make([]runtime.RawExtension, 0, len(objects))
+	for _, obj := range objects {
+		// drop fields populated by the API server and the owner references
+		// as the UIDs of the owners change when they are recreated.
+		obj.SetUID("")
+		obj.SetResourceVersion("")
+		obj.SetGeneration(0)
+		obj.SetCreationTimestamp(metav1.Time{})
+		obj.SetManagedFields(nil)
+		obj.SetOwnerReferences(nil)
+
+		raw, err := json.Marshal(obj)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, runtime.RawExtension{Raw: raw})
+	}
+	return items, nil
+}
+
+// BackupHandler returns an http.Handler serving a backup of the managed
+// resources as JSON.
+func (o *ElasticsearchOperator) BackupHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		backup, err := o.Backup(r.Context())
+		if err != nil {
+			o.logger.Errorf("Failed to create backup: %v", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		err = json.NewEncoder(w).Encode(backup)
+		if err != nil {
+			o.logger.Errorf("Failed to write backup: %v", err)
+		}
+	})
+}
@@ -0,0 +1,153 @@
+package operator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	veleroPreBackupContainerAnnotationKey = "pre.hook.backup.velero.io/container"
+	veleroPreBackupCommandAnnotationKey   = "pre.hook.backup.velero.io/command"
+	veleroPreBackupOnErrorAnnotationKey   = "pre.hook.backup.velero.io/on-error"
+	veleroPreBackupTimeoutAnnotationKey   = "pre.hook.backup.velero.io/timeout"
+
+	veleroBackupsPath           = "/apis/velero.io/v1/backups"
+	veleroBackupPhaseInProgress = "InProgress"
+
+	defaultBackupHooksContainer      = "elasticsearch"
+	defaultBackupHooksTimeoutSeconds = 60
+)
+
+// veleroBackup is the part of a Velero Backup resource relevant for pausing
+// operations.
+type veleroBackup struct {
+	Metadata struct {
+		Namespace string `json:"namespace"`
+		Name      string `json:"name"`
+	} `json:"metadata"`
+	Spec struct {
+		IncludedNamespaces []string `json:"includedNamespaces"`
+		ExcludedNamespaces []string `json:"excludedNamespaces"`
+	} `json:"spec"`
+	Status struct {
+		Phase string `json:"phase"`
+	} `json:"status"`
+}
+
+type veleroBackupList struct {
+	Items []veleroBackup `json:"items"`
+}
+
+// injectBackupHooks adds the Velero annotations to the pod template which
+// flush all indices before the volumes of the pod are backed up. Existing
+// hook annotations are left untouched. The credentials are exposed to the
+// hook container as environment variables.
+func injectBackupHooks(template *v1.PodTemplateSpec, hooks *zv1.ElasticsearchDataSetBackupHooks, access localAccess) {
+	if hooks == nil || !hooks.Enabled {
+		return
+	}
+	if _, ok := template.Annotations[veleroPreBackupCommandAnnotationKey]; ok {
+		return
+	}
+
+	container := hooks.Container
+	if container == "" {
+		container = defaultBackupHooksContainer
+	}
+	timeout := hooks.TimeoutSeconds
+	if timeout <= 0 {
+		timeout = defaultBackupHooksTimeoutSeconds
+	}
+
+	command, _ := json.Marshal([]string{
+		"/bin/sh", "-c",
+		access.curlCommand(http.MethodPost, "/_flush?wait_if_ongoing=true"),
+	})
+	for i := range template.Spec.Containers {
+		if template.Spec.Containers[i].Name == container {
+			access.injectEnv(&template.Spec.Containers[i])
+		}
+	}
+
+	if template.Annotations == nil {
+		template.Annotations = map[string]string{}
+	}
+	template.Annotations[veleroPreBackupContainerAnnotationKey] = container
+	template.Annotations[veleroPreBackupCommandAnnotationKey] = string(command)
+	template.Annotations[veleroPreBackupOnErrorAnnotationKey] = "Fail"
+	template.Annotations[veleroPreBackupTimeoutAnnotationKey] = fmt.Sprintf("%ds", timeout)
+}
+
+// backupInProgress returns the name of a backup in progress which includes
+// the namespace.
+func backupInProgress(backups []veleroBackup, namespace string) string {
+	for _, backup := range backups {
+		if backup.Status.Phase != veleroBackupPhaseInProgress {
+			continue
+		}
+		if matchesNamespace(backup.Spec.ExcludedNamespaces, namespace) {
+			continue
+		}
+		if len(backup.Spec.IncludedNamespaces) == 0 || matchesNamespace(backup.Spec.IncludedNamespaces, namespace) {
+			return backup.Metadata.Namespace + "/" + backup.Metadata.Name
+		}
+	}
+	return ""
+}
+
+// matchesNamespace returns true if the namespace matches one of the
+// namespace patterns of a Velero backup.
+func matchesNamespace(patterns []string, namespace string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, namespace); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// veleroBackupInProgress returns the name of a Velero backup in progress
+// which includes the namespace. It returns an empty name if Velero isn't
+// installed.
+func veleroBackupInProgress(ctx context.Context, kube kubernetes.Interface, namespace string) (string, error) {
+	body, err := kube.Discovery().RESTClient().Get().AbsPath(veleroBackupsPath).DoRaw(ctx)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to list Velero backups: %v", err)
+	}
+
+	var backups veleroBackupList
+	err = json.Unmarshal(body, &backups)
+	if err != nil {
+		return "", err
+	}
+	return backupInProgress(backups.Items, namespace), nil
+}
+
+// backupPaused returns the reason if operations on the EDS are paused
+// because a Velero backup of its namespace is in progress.
+func backupPaused(ctx context.Context, kube kubernetes.Interface, eds *zv1.ElasticsearchDataSet) (bool, string) {
+	if eds.Spec.BackupHooks == nil || !eds.Spec.BackupHooks.Enabled {
+		return false, ""
+	}
+
+	backup, err := veleroBackupInProgress(ctx, kube, eds.Namespace)
+	if err != nil {
+		// don't risk inconsistent snapshots if the backups are unknown.
+		return true, err.Error()
+	}
+	if backup == "" {
+		return false, ""
+	}
+	return true, fmt.Sprintf("Velero backup %s is in progress", backup)
+}
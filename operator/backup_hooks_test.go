@@ -0,0 +1,58 @@
+package operator
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestInjectBackupHooks(t *testing.T) {
+	template := &v1.PodTemplateSpec{}
+	injectBackupHooks(template, &zv1.ElasticsearchDataSetBackupHooks{}, localAccess{})
+	assert.Empty(t, template.Annotations)
+
+	injectBackupHooks(template, &zv1.ElasticsearchDataSetBackupHooks{Enabled: true, TimeoutSeconds: 120}, localAccess{})
+	assert.Equal(t, "elasticsearch", template.Annotations[veleroPreBackupContainerAnnotationKey])
+	assert.Equal(t, "Fail", template.Annotations[veleroPreBackupOnErrorAnnotationKey])
+	assert.Equal(t, "120s", template.Annotations[veleroPreBackupTimeoutAnnotationKey])
+
+	var command []string
+	require.NoError(t, json.Unmarshal([]byte(template.Annotations[veleroPreBackupCommandAnnotationKey]), &command))
+	assert.Equal(t, []string{"/bin/sh", "-c", "curl -sf -XPOST 'http://localhost:9200/_flush?wait_if_ongoing=true'"}, command)
+
+	// custom hooks are kept.
+	template = &v1.PodTemplateSpec{}
+	template.Annotations = map[string]string{veleroPreBackupCommandAnnotationKey: `["/custom"]`}
+	injectBackupHooks(template, &zv1.ElasticsearchDataSetBackupHooks{Enabled: true}, localAccess{})
+	assert.Equal(t, map[string]string{veleroPreBackupCommandAnnotationKey: `["/custom"]`}, template.Annotations)
+
+	// the credentials are exposed to the hook container.
+	template = &v1.PodTemplateSpec{Spec: v1.PodSpec{Containers: []v1.Container{{Name: "sidecar"}, {Name: "elasticsearch"}}}}
+	injectBackupHooks(template, &zv1.ElasticsearchDataSetBackupHooks{Enabled: true}, localAccess{tls: true, credentials: &v1.LocalObjectReference{Name: "es-credentials"}})
+	require.NoError(t, json.Unmarshal([]byte(template.Annotations[veleroPreBackupCommandAnnotationKey]), &command))
+	assert.Contains(t, command[2], `curl -sf -k "$@" -XPOST 'https://localhost:9200/_flush?wait_if_ongoing=true'`)
+	assert.Empty(t, template.Spec.Containers[0].Env)
+	assert.Len(t, template.Spec.Containers[1].Env, 3)
+}
+
+func TestBackupInProgress(t *testing.T) {
+	var backups veleroBackupList
+	err := json.Unmarshal([]byte(`{"items":[
+		{"metadata":{"namespace":"velero","name":"done"},"status":{"phase":"Completed"}},
+		{"metadata":{"namespace":"velero","name":"other"},"spec":{"includedNamespaces":["other"]},"status":{"phase":"InProgress"}},
+		{"metadata":{"namespace":"velero","name":"excluded"},"spec":{"includedNamespaces":["*"],"excludedNamespaces":["es-*"]},"status":{"phase":"InProgress"}}
+	]}`), &backups)
+	require.NoError(t, err)
+
+	assert.Equal(t, "", backupInProgress(backups.Items, "es-logs"))
+	assert.Equal(t, "velero/other", backupInProgress(backups.Items, "other"))
+	assert.Equal(t, "velero/excluded", backupInProgress(backups.Items, "default"))
+
+	// all namespaces are included by default.
+	backups.Items[0].Status.Phase = veleroBackupPhaseInProgress
+	assert.Equal(t, "velero/done", backupInProgress(backups.Items, "es-logs"))
+}
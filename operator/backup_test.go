@@ -0,0 +1,71 @@
+package operator
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestBackupResources(t *testing.T) {
+	ownedBy := func(uid string) []metav1.OwnerReference {
+		return []metav1.OwnerReference{{Kind: "ElasticsearchDataSet", UID: types.UID(uid)}}
+	}
+
+	edss := []zv1.ElasticsearchDataSet{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "es-data", Namespace: "default", UID: "eds-uid", ResourceVersion: "42"},
+			Status:     zv1.ElasticsearchDataSetStatus{Replicas: 3},
+		},
+	}
+	metricSets := []zv1.ElasticsearchMetricSet{
+		{ObjectMeta: metav1.ObjectMeta{Name: "es-data", Namespace: "default", OwnerReferences: ownedBy("eds-uid")}},
+	}
+	services := []v1.Service{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "es-data", Namespace: "default", OwnerReferences: ownedBy("eds-uid")},
+			Spec:       v1.ServiceSpec{ClusterIP: "10.0.0.1", ClusterIPs: []string{"10.0.0.1"}},
+		},
+		{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "default", OwnerReferences: ownedBy("other-uid")}},
+	}
+	configMaps := []v1.ConfigMap{
+		{ObjectMeta: metav1.ObjectMeta{Name: "es-data-hot-threads", Namespace: "default", OwnerReferences: ownedBy("eds-uid")}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "unrelated", Namespace: "default"}},
+	}
+
+	items, err := backupResources(edss, metricSets, services, configMaps)
+	require.NoError(t, err)
+
+	kinds := []string{}
+	for _, item := range items {
+		var obj struct {
+			metav1.TypeMeta   `json:",inline"`
+			metav1.ObjectMeta `json:"metadata"`
+			Spec              map[string]interface{} `json:"spec"`
+			Status            map[string]interface{} `json:"status"`
+		}
+		require.NoError(t, json.Unmarshal(item.Raw, &obj))
+		kinds = append(kinds, obj.Kind+"/"+obj.Name)
+
+		assert.Empty(t, obj.UID)
+		assert.Empty(t, obj.ResourceVersion)
+		assert.Empty(t, obj.OwnerReferences)
+		switch obj.Kind {
+		case "ElasticsearchDataSet":
+			assert.EqualValues(t, 0, obj.Status["replicas"])
+		case "Service":
+			assert.NotContains(t, obj.Spec, "clusterIP")
+		}
+	}
+	assert.Equal(t, []string{
+		"ElasticsearchDataSet/es-data",
+		"ElasticsearchMetricSet/es-data",
+		"Service/es-data",
+		"ConfigMap/es-data-hot-threads",
+	}, kinds)
+}
@@ -0,0 +1,113 @@
+package operator
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// burstAnnotationKey requests a temporary override of the scaling settings,
+// e.g. '{"maxReplicas": 20, "scaleUpCPUBoundary": 40, "durationSeconds": 21600}'.
+const burstAnnotationKey = "es-operator.zalando.org/burst"
+
+// burstRequest is the value of the burst annotation.
+type burstRequest struct {
+	MaxReplicas          int32 `json:"maxReplicas"`
+	ScaleUpCPUBoundary   int32 `json:"scaleUpCPUBoundary,omitempty"`
+	ScaleDownCPUBoundary int32 `json:"scaleDownCPUBoundary,omitempty"`
+	DurationSeconds      int64 `json:"durationSeconds"`
+}
+
+// parseBurstRequest parses and validates the burst annotation of the EDS. It
+// returns nil if the annotation isn't set.
+func parseBurstRequest(eds *zv1.ElasticsearchDataSet) (*burstRequest, error) {
+	value, ok := eds.Annotations[burstAnnotationKey]
+	if !ok {
+		return nil, nil
+	}
+
+	var request burstRequest
+	err := json.Unmarshal([]byte(value), &request)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s annotation: %v", burstAnnotationKey, err)
+	}
+	if request.DurationSeconds <= 0 {
+		return nil, fmt.Errorf("invalid %s annotation: durationSeconds must be positive", burstAnnotationKey)
+	}
+	if eds.Spec.Scaling != nil && request.MaxReplicas < eds.Spec.Scaling.MaxReplicas {
+		return nil, fmt.Errorf("invalid %s annotation: maxReplicas(%d) can't be less than the maxReplicas(%d) of the spec",
+			burstAnnotationKey, request.MaxReplicas, eds.Spec.Scaling.MaxReplicas)
+	}
+	if request.ScaleDownCPUBoundary > 0 && request.ScaleUpCPUBoundary > 0 && request.ScaleDownCPUBoundary > request.ScaleUpCPUBoundary {
+		return nil, fmt.Errorf("invalid %s annotation: scaleDownCPUBoundary(%d) can't be greater than scaleUpCPUBoundary(%d)",
+			burstAnnotationKey, request.ScaleDownCPUBoundary, request.ScaleUpCPUBoundary)
+	}
+	return &request, nil
+}
+
+// burstStatus returns the burst status of the EDS based on its burst
+// annotation. An override is started when the annotation is added or
+// changed, and kept in the status after it expired until the annotation is
+// removed.
+func (r *EDSResource) burstStatus(now time.Time) *zv1.ElasticsearchDataSetBurstStatus {
+	previous := r.eds.Status.Burst
+	request, err := parseBurstRequest(r.eds)
+	if err != nil {
+		r.recorder.Event(r.eds, v1.EventTypeWarning, "BurstInvalid", err.Error())
+		return previous
+	}
+	if request == nil {
+		return nil
+	}
+
+	if previous != nil &&
+		previous.MaxReplicas == request.MaxReplicas &&
+		previous.ScaleUpCPUBoundary == request.ScaleUpCPUBoundary &&
+		previous.ScaleDownCPUBoundary == request.ScaleDownCPUBoundary &&
+		previous.DurationSeconds == request.DurationSeconds {
+		return previous
+	}
+
+	started := now.UTC().Truncate(time.Second)
+	status := &zv1.ElasticsearchDataSetBurstStatus{
+		MaxReplicas:          request.MaxReplicas,
+		ScaleUpCPUBoundary:   request.ScaleUpCPUBoundary,
+		ScaleDownCPUBoundary: request.ScaleDownCPUBoundary,
+		DurationSeconds:      request.DurationSeconds,
+		Started:              metav1.NewTime(started),
+		Expires:              metav1.NewTime(started.Add(time.Duration(request.DurationSeconds) * time.Second)),
+	}
+	r.recorder.Event(r.eds, v1.EventTypeNormal, "BurstStarted",
+		fmt.Sprintf("Raising maxReplicas to %d until %s", status.MaxReplicas, status.Expires.Format(time.RFC3339)))
+	return status
+}
+
+// burstActive returns true if the burst of the status hasn't expired yet.
+func burstActive(burst *zv1.ElasticsearchDataSetBurstStatus, now time.Time) bool {
+	return burst != nil && now.Before(burst.Expires.Time)
+}
+
+// burstScaling returns the scaling settings of the EDS with an active burst
+// applied. The scaling settings of the spec are returned as is if no burst is
+// active.
+func burstScaling(eds *zv1.ElasticsearchDataSet, now time.Time) *zv1.ElasticsearchDataSetScaling {
+	scaling := eds.Spec.Scaling
+	burst := eds.Status.Burst
+	if scaling == nil || !burstActive(burst, now) {
+		return scaling
+	}
+
+	scaling = scaling.DeepCopy()
+	scaling.MaxReplicas = burst.MaxReplicas
+	if burst.ScaleUpCPUBoundary > 0 {
+		scaling.ScaleUpCPUBoundary = burst.ScaleUpCPUBoundary
+	}
+	if burst.ScaleDownCPUBoundary > 0 {
+		scaling.ScaleDownCPUBoundary = burst.ScaleDownCPUBoundary
+	}
+	return scaling
+}
@@ -0,0 +1,123 @@
+package operator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestParseBurstRequest(t *testing.T) {
+	eds := edsTestFixture(3)
+	eds.Spec.Scaling.MaxReplicas = 10
+
+	request, err := parseBurstRequest(eds)
+	require.NoError(t, err)
+	require.Nil(t, request)
+
+	for _, tc := range []struct {
+		msg        string
+		annotation string
+		expected   *burstRequest
+	}{
+		{
+			msg:        "valid",
+			annotation: `{"maxReplicas": 20, "scaleUpCPUBoundary": 40, "durationSeconds": 3600}`,
+			expected:   &burstRequest{MaxReplicas: 20, ScaleUpCPUBoundary: 40, DurationSeconds: 3600},
+		},
+		{
+			msg:        "invalid json",
+			annotation: `maxReplicas=20`,
+		},
+		{
+			msg:        "missing duration",
+			annotation: `{"maxReplicas": 20}`,
+		},
+		{
+			msg:        "lower maxReplicas",
+			annotation: `{"maxReplicas": 5, "durationSeconds": 3600}`,
+		},
+		{
+			msg:        "inconsistent boundaries",
+			annotation: `{"maxReplicas": 20, "scaleUpCPUBoundary": 40, "scaleDownCPUBoundary": 50, "durationSeconds": 3600}`,
+		},
+	} {
+		t.Run(tc.msg, func(t *testing.T) {
+			eds.Annotations = map[string]string{burstAnnotationKey: tc.annotation}
+			request, err := parseBurstRequest(eds)
+			if tc.expected == nil {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, request)
+		})
+	}
+}
+
+func TestBurstStatus(t *testing.T) {
+	now := time.Date(2024, 11, 29, 8, 0, 0, 0, time.UTC)
+	eds := edsTestFixture(3)
+	eds.Spec.Scaling.MaxReplicas = 10
+	r := &EDSResource{eds: eds, recorder: record.NewFakeRecorder(10)}
+
+	require.Nil(t, r.burstStatus(now))
+
+	eds.Annotations = map[string]string{burstAnnotationKey: `{"maxReplicas": 20, "durationSeconds": 3600}`}
+	status := r.burstStatus(now)
+	require.Equal(t, &zv1.ElasticsearchDataSetBurstStatus{
+		MaxReplicas:     20,
+		DurationSeconds: 3600,
+		Started:         metav1.NewTime(now),
+		Expires:         metav1.NewTime(now.Add(time.Hour)),
+	}, status)
+
+	// unchanged annotation keeps the burst, also after expiry
+	eds.Status.Burst = status
+	require.Equal(t, status, r.burstStatus(now.Add(2*time.Hour)))
+
+	// invalid annotation keeps the previous burst
+	eds.Annotations[burstAnnotationKey] = `{"maxReplicas": 20}`
+	require.Equal(t, status, r.burstStatus(now.Add(2*time.Hour)))
+
+	// changed annotation starts a new burst
+	eds.Annotations[burstAnnotationKey] = `{"maxReplicas": 30, "durationSeconds": 3600}`
+	changed := r.burstStatus(now.Add(2 * time.Hour))
+	require.Equal(t, int32(30), changed.MaxReplicas)
+	require.Equal(t, now.Add(3*time.Hour), changed.Expires.Time)
+
+	// removed annotation removes the burst
+	delete(eds.Annotations, burstAnnotationKey)
+	require.Nil(t, r.burstStatus(now))
+}
+
+func TestBurstScaling(t *testing.T) {
+	now := time.Now()
+	eds := edsTestFixture(3)
+	eds.Spec.Scaling.MaxReplicas = 10
+	eds.Spec.Scaling.ScaleUpCPUBoundary = 50
+	eds.Spec.Scaling.ScaleDownCPUBoundary = 25
+
+	require.Equal(t, eds.Spec.Scaling, burstScaling(eds, now))
+
+	eds.Status.Burst = &zv1.ElasticsearchDataSetBurstStatus{
+		MaxReplicas:        20,
+		ScaleUpCPUBoundary: 40,
+		Expires:            metav1.NewTime(now.Add(time.Hour)),
+	}
+	scaling := burstScaling(eds, now)
+	require.Equal(t, int32(20), scaling.MaxReplicas)
+	require.Equal(t, int32(40), scaling.ScaleUpCPUBoundary)
+	require.Equal(t, int32(25), scaling.ScaleDownCPUBoundary)
+	// the spec is left untouched
+	require.Equal(t, int32(10), eds.Spec.Scaling.MaxReplicas)
+
+	as := systemUnderTest(eds, nil, nil)
+	require.Equal(t, int32(20), as.ensureBoundsNodeReplicas(25))
+
+	// expired
+	require.Equal(t, eds.Spec.Scaling, burstScaling(eds, now.Add(2*time.Hour)))
+}
@@ -0,0 +1,641 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// clusterLabelKey labels the resources of an ElasticsearchCluster with
+	// its name.
+	clusterLabelKey = "es-operator.zalando.org/cluster"
+	// clusterRoleLabelKey labels the pods of an ElasticsearchCluster with
+	// their role, master or data.
+	clusterRoleLabelKey = "es-operator.zalando.org/cluster-role"
+	clusterRoleMaster   = "master"
+	clusterRoleData     = "data"
+
+	clusterConfigKey             = "elasticsearch.yml"
+	clusterConfigVolumeName      = "es-cluster-config"
+	clusterConfigMountPath       = "/usr/share/elasticsearch/config/elasticsearch.yml"
+	defaultClusterMasterReplicas = 3
+)
+
+// clusterMasterName returns the name of the master StatefulSet and its
+// headless Service, which is the seed host of the cluster.
+func clusterMasterName(cluster *zv1.ElasticsearchCluster) string {
+	return cluster.Name + "-master"
+}
+
+// clusterConfigName returns the name of the ConfigMap with the
+// elasticsearch.yml of the cluster.
+func clusterConfigName(cluster *zv1.ElasticsearchCluster) string {
+	return cluster.Name + "-config"
+}
+
+// clusterDataSetName returns the name of the EDS of the group of data nodes.
+func clusterDataSetName(cluster *zv1.ElasticsearchCluster, dataSet string) string {
+	return cluster.Name + "-" + dataSet
+}
+
+// clusterMasterReplicas returns the desired number of master nodes.
+func clusterMasterReplicas(cluster *zv1.ElasticsearchCluster) int32 {
+	if cluster.Spec.Masters.Replicas == nil {
+		return defaultClusterMasterReplicas
+	}
+	return *cluster.Spec.Masters.Replicas
+}
+
+// clusterOwnerReference returns the owner reference of the resources of the
+// cluster, which are deleted with it.
+func clusterOwnerReference(cluster *zv1.ElasticsearchCluster) metav1.OwnerReference {
+	return metav1.OwnerReference{
+		APIVersion: "zalando.org/v1",
+		Kind:       "ElasticsearchCluster",
+		Name:       cluster.Name,
+		UID:        cluster.UID,
+	}
+}
+
+// isOwnedByCluster returns true if the cluster owns the object.
+func isOwnedByCluster(cluster *zv1.ElasticsearchCluster, object metav1.ObjectMeta) bool {
+	for _, ref := range object.OwnerReferences {
+		if ref.UID == cluster.UID {
+			return true
+		}
+	}
+	return false
+}
+
+// hasSetting returns true if the Elasticsearch setting is set in the
+// settings, either with its full key or nested, e.g. 'cluster.name' or
+// 'cluster: {name: ...}'.
+func hasSetting(settings map[string]interface{}, key string) bool {
+	parts := strings.Split(key, ".")
+	for i := 1; i <= len(parts); i++ {
+		value, ok := settings[strings.Join(parts[:i], ".")]
+		if !ok {
+			continue
+		}
+		if i == len(parts) {
+			return true
+		}
+		if nested, ok := value.(map[string]interface{}); ok && hasSetting(nested, strings.Join(parts[i:], ".")) {
+			return true
+		}
+	}
+	return false
+}
+
+// renderClusterConfig returns the elasticsearch.yml of the cluster, the
+// config of the spec with the settings needed to form the cluster added
+// unless they are set.
+func renderClusterConfig(cluster *zv1.ElasticsearchCluster) (string, error) {
+	settings := make(map[string]interface{})
+	if err := yaml.Unmarshal([]byte(cluster.Spec.Config), &settings); err != nil {
+		return "", fmt.Errorf("invalid config: %v", err)
+	}
+	if settings == nil {
+		settings = make(map[string]interface{})
+	}
+
+	masters := make([]string, 0, clusterMasterReplicas(cluster))
+	for i := int32(0); i < clusterMasterReplicas(cluster); i++ {
+		masters = append(masters, clusterMasterName(cluster)+"-"+strconv.Itoa(int(i)))
+	}
+	for key, value := range map[string]interface{}{
+		"cluster.name":                 cluster.Name,
+		"network.host":                 "0.0.0.0",
+		"discovery.seed_hosts":         []string{clusterMasterName(cluster)},
+		"cluster.initial_master_nodes": masters,
+	} {
+		if !hasSetting(settings, key) {
+			settings[key] = value
+		}
+	}
+
+	config, err := yaml.Marshal(settings)
+	if err != nil {
+		return "", err
+	}
+	return string(config), nil
+}
+
+// applyClusterConfig mounts the elasticsearch.yml of the cluster into the
+// Elasticsearch container, the first container of the pod, unless a file is
+// already mounted there.
+func applyClusterConfig(cluster *zv1.ElasticsearchCluster, podSpec *v1.PodSpec) {
+	if len(podSpec.Containers) == 0 {
+		return
+	}
+	container := &podSpec.Containers[0]
+	for _, mount := range container.VolumeMounts {
+		if mount.MountPath == clusterConfigMountPath {
+			return
+		}
+	}
+
+	podSpec.Volumes = append(podSpec.Volumes, v1.Volume{
+		Name: clusterConfigVolumeName,
+		VolumeSource: v1.VolumeSource{
+			ConfigMap: &v1.ConfigMapVolumeSource{
+				LocalObjectReference: v1.LocalObjectReference{Name: clusterConfigName(cluster)},
+			},
+		},
+	})
+	container.VolumeMounts = append(container.VolumeMounts, v1.VolumeMount{
+		Name:      clusterConfigVolumeName,
+		MountPath: clusterConfigMountPath,
+		SubPath:   clusterConfigKey,
+	})
+}
+
+// setEnvDefault sets the environment variable of the container unless it's
+// set.
+func setEnvDefault(container *v1.Container, env v1.EnvVar) {
+	for _, e := range container.Env {
+		if e.Name == env.Name {
+			return
+		}
+	}
+	container.Env = append(container.Env, env)
+}
+
+// clusterLabels returns the labels of the resources of the cluster with the
+// role.
+func clusterLabels(cluster *zv1.ElasticsearchCluster, role string) map[string]string {
+	return map[string]string{
+		clusterLabelKey:     cluster.Name,
+		clusterRoleLabelKey: role,
+	}
+}
+
+// renderClusterMasters renders the master StatefulSet of the cluster into
+// sts, which is created if it's nil. The pods are updated by the StatefulSet
+// controller one at a time, as master nodes hold no data.
+func renderClusterMasters(cluster *zv1.ElasticsearchCluster, sts *appsv1.StatefulSet) *appsv1.StatefulSet {
+	matchLabels := clusterLabels(cluster, clusterRoleMaster)
+	if sts == nil {
+		sts = &appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            clusterMasterName(cluster),
+				Namespace:       cluster.Namespace,
+				OwnerReferences: []metav1.OwnerReference{clusterOwnerReference(cluster)},
+			},
+		}
+		for _, claim := range cluster.Spec.Masters.VolumeClaimTemplates {
+			sts.Spec.VolumeClaimTemplates = append(sts.Spec.VolumeClaimTemplates, v1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        claim.Name,
+					Labels:      claim.Labels,
+					Annotations: claim.Annotations,
+				},
+				Spec: claim.Spec,
+			})
+		}
+	}
+
+	template := cluster.Spec.Masters.Template.DeepCopy()
+	podTemplate := templateInjectLabels(v1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels:      template.Labels,
+			Annotations: template.Annotations,
+		},
+		Spec: template.Spec,
+	}, matchLabels)
+	applyClusterConfig(cluster, &podTemplate.Spec)
+	if len(podTemplate.Spec.Containers) > 0 {
+		container := &podTemplate.Spec.Containers[0]
+		setEnvDefault(container, v1.EnvVar{
+			Name:      "node.name",
+			ValueFrom: &v1.EnvVarSource{FieldRef: &v1.ObjectFieldSelector{FieldPath: "metadata.name"}},
+		})
+		setEnvDefault(container, v1.EnvVar{Name: "node.roles", Value: "master"})
+	}
+
+	replicas := clusterMasterReplicas(cluster)
+	if sts.Labels == nil {
+		sts.Labels = make(map[string]string)
+	}
+	for key, value := range matchLabels {
+		sts.Labels[key] = value
+	}
+	if sts.Annotations == nil {
+		sts.Annotations = make(map[string]string)
+	}
+	sts.Annotations[operatorParentGenerationAnnotationKey] = fmt.Sprintf("%d", cluster.Generation)
+	sts.Spec.Replicas = &replicas
+	sts.Spec.Template = podTemplate
+	sts.Spec.Selector = &metav1.LabelSelector{MatchLabels: matchLabels}
+	sts.Spec.ServiceName = clusterMasterName(cluster)
+	sts.Spec.PodManagementPolicy = appsv1.ParallelPodManagement
+	return sts
+}
+
+// renderClusterDataSet renders the EDS of the group of data nodes into eds,
+// which is created if it's nil. The replicas of an EDS with scaling enabled
+// are kept, as they are managed by the autoscaler.
+func renderClusterDataSet(cluster *zv1.ElasticsearchCluster, dataSet *zv1.ElasticsearchClusterDataSet, eds *zv1.ElasticsearchDataSet) *zv1.ElasticsearchDataSet {
+	if eds == nil {
+		eds = &zv1.ElasticsearchDataSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            clusterDataSetName(cluster, dataSet.Name),
+				Namespace:       cluster.Namespace,
+				OwnerReferences: []metav1.OwnerReference{clusterOwnerReference(cluster)},
+			},
+		}
+	}
+
+	spec := dataSet.Spec.DeepCopy()
+	if spec.Scaling != nil && spec.Scaling.Enabled && eds.Spec.Replicas != nil {
+		spec.Replicas = eds.Spec.Replicas
+	}
+	if spec.Template.Labels == nil {
+		spec.Template.Labels = make(map[string]string)
+	}
+	for key, value := range clusterLabels(cluster, clusterRoleData) {
+		spec.Template.Labels[key] = value
+	}
+	applyClusterConfig(cluster, &spec.Template.Spec)
+	eds.Spec = *spec
+
+	if eds.Labels == nil {
+		eds.Labels = make(map[string]string)
+	}
+	for key, value := range dataSet.Labels {
+		eds.Labels[key] = value
+	}
+	for key, value := range clusterLabels(cluster, clusterRoleData) {
+		eds.Labels[key] = value
+	}
+	if eds.Annotations == nil {
+		eds.Annotations = make(map[string]string)
+	}
+	if owner, ok := cluster.Annotations[esOperatorAnnotationKey]; ok {
+		eds.Annotations[esOperatorAnnotationKey] = owner
+	}
+	eds.Annotations[operatorParentGenerationAnnotationKey] = fmt.Sprintf("%d", cluster.Generation)
+	return eds
+}
+
+// clusterServices returns the Services of the cluster: the headless Service
+// of the master nodes used for discovery and the Service of the data nodes
+// for clients.
+func clusterServices(cluster *zv1.ElasticsearchCluster) []*v1.Service {
+	masters := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            clusterMasterName(cluster),
+			Namespace:       cluster.Namespace,
+			Labels:          clusterLabels(cluster, clusterRoleMaster),
+			OwnerReferences: []metav1.OwnerReference{clusterOwnerReference(cluster)},
+		},
+		Spec: v1.ServiceSpec{
+			ClusterIP:                v1.ClusterIPNone,
+			PublishNotReadyAddresses: true,
+			Selector:                 clusterLabels(cluster, clusterRoleMaster),
+			Ports: []v1.ServicePort{{
+				Name:       "transport",
+				Protocol:   v1.ProtocolTCP,
+				Port:       elasticsearchTransportPort,
+				TargetPort: intstr.FromInt(elasticsearchTransportPort),
+			}},
+		},
+	}
+	clients := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            cluster.Name,
+			Namespace:       cluster.Namespace,
+			Labels:          clusterLabels(cluster, clusterRoleData),
+			OwnerReferences: []metav1.OwnerReference{clusterOwnerReference(cluster)},
+		},
+		Spec: v1.ServiceSpec{
+			Selector: clusterLabels(cluster, clusterRoleData),
+			Ports: []v1.ServicePort{{
+				Name:       "http",
+				Protocol:   v1.ProtocolTCP,
+				Port:       defaultElasticsearchDataSetEndpointPort,
+				TargetPort: intstr.FromInt(defaultElasticsearchDataSetEndpointPort),
+			}},
+		},
+	}
+	return []*v1.Service{masters, clients}
+}
+
+// runClusters reconciles ElasticsearchCluster resources at an interval.
+func (o *ElasticsearchOperator) runClusters(ctx context.Context) {
+	nextCheck := time.Now().Add(-o.interval)
+
+	for {
+		o.logger.Debug("Checking clusters")
+		select {
+		case <-time.After(time.Until(nextCheck)):
+			nextCheck = time.Now().Add(o.interval)
+
+			clusters, err := o.kube.ZalandoV1().ElasticsearchClusters(o.namespace).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				o.logger.Error(err)
+				continue
+			}
+
+			for _, cluster := range clusters.Items {
+				cluster := cluster
+				if !o.hasOwnership(&cluster) || cluster.DeletionTimestamp != nil {
+					continue
+				}
+
+				err := o.reconcileCluster(ctx, &cluster)
+				if err != nil {
+					o.logger.Errorf("Failed to reconcile cluster %s/%s: %v", cluster.Namespace, cluster.Name, err)
+				}
+			}
+		case <-ctx.Done():
+			o.logger.Info("Terminating cluster loop.")
+			return
+		}
+	}
+}
+
+// reconcileCluster creates or updates the config, the Services, the master
+// StatefulSet and the EDS of the cluster, deletes the EDS of removed groups
+// of data nodes, enforces the settings baseline and the tenant quotas and
+// updates the status.
+func (o *ElasticsearchOperator) reconcileCluster(ctx context.Context, cluster *zv1.ElasticsearchCluster) error {
+	// set TypeMeta manually because of this bug:
+	// https://github.com/kubernetes/client-go/issues/308
+	cluster.APIVersion = "zalando.org/v1"
+	cluster.Kind = "ElasticsearchCluster"
+
+	err := o.ensureClusterConfig(ctx, cluster)
+	if err != nil {
+		return err
+	}
+
+	for _, svc := range clusterServices(cluster) {
+		err := o.ensureClusterService(ctx, cluster, svc)
+		if err != nil {
+			return err
+		}
+	}
+
+	masters, err := o.ensureClusterMasters(ctx, cluster)
+	if err != nil {
+		return err
+	}
+
+	dataSets, err := o.ensureClusterDataSets(ctx, cluster)
+	if err != nil {
+		return err
+	}
+
+	status := clusterStatus(cluster, masters, dataSets)
+	settingsErr := o.enforceClusterSettings(cluster, dataSets, status)
+	quotasErr := o.enforceTenantQuotas(cluster, dataSets, status)
+	err = o.updateClusterStatus(ctx, cluster, status)
+	if err != nil {
+		return err
+	}
+	if settingsErr != nil {
+		return settingsErr
+	}
+	return quotasErr
+}
+
+func (o *ElasticsearchOperator) ensureClusterConfig(ctx context.Context, cluster *zv1.ElasticsearchCluster) error {
+	config, err := renderClusterConfig(cluster)
+	if err != nil {
+		o.recorder.Event(cluster, v1.EventTypeWarning, "InvalidConfig", err.Error())
+		return err
+	}
+
+	cm, err := o.kube.CoreV1().ConfigMaps(cluster.Namespace).Get(ctx, clusterConfigName(cluster), metav1.GetOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return err
+		}
+		cm = &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            clusterConfigName(cluster),
+				Namespace:       cluster.Namespace,
+				Labels:          map[string]string{clusterLabelKey: cluster.Name},
+				OwnerReferences: []metav1.OwnerReference{clusterOwnerReference(cluster)},
+			},
+			Data: map[string]string{clusterConfigKey: config},
+		}
+		_, err = o.kube.CoreV1().ConfigMaps(cm.Namespace).Create(ctx, cm, metav1.CreateOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to create ConfigMap %s/%s: %v", cm.Namespace, cm.Name, err)
+		}
+		return nil
+	}
+	if !isOwnedByCluster(cluster, cm.ObjectMeta) {
+		return fmt.Errorf("ConfigMap %s/%s is not owned by the cluster", cm.Namespace, cm.Name)
+	}
+	if cm.Data[clusterConfigKey] == config {
+		return nil
+	}
+
+	if cm.Data == nil {
+		cm.Data = make(map[string]string)
+	}
+	cm.Data[clusterConfigKey] = config
+	_, err = o.kube.CoreV1().ConfigMaps(cm.Namespace).Update(ctx, cm, metav1.UpdateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to update ConfigMap %s/%s: %v", cm.Namespace, cm.Name, err)
+	}
+	o.recorder.Event(cluster, v1.EventTypeNormal, "UpdatedConfig", fmt.Sprintf(
+		"Updated the config in ConfigMap '%s/%s', it's applied to the nodes on their next restart", cm.Namespace, cm.Name,
+	))
+	return nil
+}
+
+func (o *ElasticsearchOperator) ensureClusterService(ctx context.Context, cluster *zv1.ElasticsearchCluster, desired *v1.Service) error {
+	svc, err := o.kube.CoreV1().Services(desired.Namespace).Get(ctx, desired.Name, metav1.GetOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return err
+		}
+		_, err = o.kube.CoreV1().Services(desired.Namespace).Create(ctx, desired, metav1.CreateOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to create Service %s/%s: %v", desired.Namespace, desired.Name, err)
+		}
+		o.recorder.Event(cluster, v1.EventTypeNormal, "CreatedService", fmt.Sprintf(
+			"Created Service '%s/%s'", desired.Namespace, desired.Name,
+		))
+		return nil
+	}
+	if !isOwnedByCluster(cluster, svc.ObjectMeta) {
+		return fmt.Errorf("Service %s/%s is not owned by the cluster", svc.Namespace, svc.Name)
+	}
+	if equality.Semantic.DeepEqual(svc.Spec.Selector, desired.Spec.Selector) && podServicePortsEqual(svc.Spec.Ports, desired.Spec.Ports) {
+		return nil
+	}
+
+	svc.Spec.Selector = desired.Spec.Selector
+	svc.Spec.Ports = desired.Spec.Ports
+	_, err = o.kube.CoreV1().Services(svc.Namespace).Update(ctx, svc, metav1.UpdateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to update Service %s/%s: %v", svc.Namespace, svc.Name, err)
+	}
+	return nil
+}
+
+func (o *ElasticsearchOperator) ensureClusterMasters(ctx context.Context, cluster *zv1.ElasticsearchCluster) (*appsv1.StatefulSet, error) {
+	sts, err := o.kube.AppsV1().StatefulSets(cluster.Namespace).Get(ctx, clusterMasterName(cluster), metav1.GetOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return nil, err
+		}
+		sts = renderClusterMasters(cluster, nil)
+		created, err := o.kube.AppsV1().StatefulSets(sts.Namespace).Create(ctx, sts, metav1.CreateOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create StatefulSet %s/%s: %v", sts.Namespace, sts.Name, err)
+		}
+		o.recorder.Event(cluster, v1.EventTypeNormal, "CreatedMasters", fmt.Sprintf(
+			"Created StatefulSet '%s/%s' for %d master nodes", sts.Namespace, sts.Name, clusterMasterReplicas(cluster),
+		))
+		return created, nil
+	}
+	if !isOwnedByCluster(cluster, sts.ObjectMeta) {
+		return nil, fmt.Errorf("StatefulSet %s/%s is not owned by the cluster", sts.Namespace, sts.Name)
+	}
+	if getSTSParentGeneration(sts) == cluster.Generation {
+		return sts, nil
+	}
+
+	sts = renderClusterMasters(cluster, sts)
+	updated, err := o.kube.AppsV1().StatefulSets(sts.Namespace).Update(ctx, sts, metav1.UpdateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update StatefulSet %s/%s: %v", sts.Namespace, sts.Name, err)
+	}
+	return updated, nil
+}
+
+// ensureClusterDataSets creates and updates the EDS of the groups of data
+// nodes and deletes the EDS of removed groups. Returns the EDS of the groups.
+func (o *ElasticsearchOperator) ensureClusterDataSets(ctx context.Context, cluster *zv1.ElasticsearchCluster) (map[string]*zv1.ElasticsearchDataSet, error) {
+	selector := labels.Set{clusterLabelKey: cluster.Name}.String()
+	edss, err := o.kube.ZalandoV1().ElasticsearchDataSets(cluster.Namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[string]*zv1.ElasticsearchDataSet, len(edss.Items))
+	for i := range edss.Items {
+		if isOwnedByCluster(cluster, edss.Items[i].ObjectMeta) {
+			existing[edss.Items[i].Name] = &edss.Items[i]
+		}
+	}
+
+	dataSets := make(map[string]*zv1.ElasticsearchDataSet, len(cluster.Spec.DataSets))
+	for i := range cluster.Spec.DataSets {
+		dataSet := &cluster.Spec.DataSets[i]
+		name := clusterDataSetName(cluster, dataSet.Name)
+		eds, ok := existing[name]
+		delete(existing, name)
+
+		switch {
+		case !ok:
+			eds = renderClusterDataSet(cluster, dataSet, nil)
+			eds, err = o.kube.ZalandoV1().ElasticsearchDataSets(cluster.Namespace).Create(ctx, eds, metav1.CreateOptions{})
+			if err != nil {
+				return nil, fmt.Errorf("failed to create EDS %s/%s: %v", cluster.Namespace, name, err)
+			}
+			o.recorder.Event(cluster, v1.EventTypeNormal, "CreatedDataSet", fmt.Sprintf(
+				"Created EDS '%s/%s' for data nodes '%s'", cluster.Namespace, name, dataSet.Name,
+			))
+		case eds.Annotations[operatorParentGenerationAnnotationKey] != fmt.Sprintf("%d", cluster.Generation):
+			eds = renderClusterDataSet(cluster, dataSet, eds)
+			eds, err = o.kube.ZalandoV1().ElasticsearchDataSets(cluster.Namespace).Update(ctx, eds, metav1.UpdateOptions{})
+			if err != nil {
+				return nil, fmt.Errorf("failed to update EDS %s/%s: %v", cluster.Namespace, name, err)
+			}
+		}
+		dataSets[dataSet.Name] = eds
+	}
+
+	for _, eds := range existing {
+		err := o.kube.ZalandoV1().ElasticsearchDataSets(eds.Namespace).Delete(ctx, eds.Name, metav1.DeleteOptions{
+			Preconditions: &metav1.Preconditions{UID: &eds.UID},
+		})
+		if err != nil && !errors.IsNotFound(err) {
+			return nil, fmt.Errorf("failed to delete EDS %s/%s: %v", eds.Namespace, eds.Name, err)
+		}
+		o.recorder.Event(cluster, v1.EventTypeNormal, "DeletedDataSet", fmt.Sprintf(
+			"Deleted EDS '%s/%s' of removed data nodes", eds.Namespace, eds.Name,
+		))
+	}
+	return dataSets, nil
+}
+
+// clusterStatus returns the status of the cluster from the state of the
+// master StatefulSet and the EDS.
+func clusterStatus(cluster *zv1.ElasticsearchCluster, masters *appsv1.StatefulSet, dataSets map[string]*zv1.ElasticsearchDataSet) *zv1.ElasticsearchClusterStatus {
+	status := cluster.Status.DeepCopy()
+	status.ObservedGeneration = cluster.Generation
+	status.Masters = masters.Status.Replicas
+	status.ReadyMasters = masters.Status.ReadyReplicas
+	status.ReadyDataSets = 0
+	status.DataSets = nil
+
+	var notReady []string
+	for _, dataSet := range cluster.Spec.DataSets {
+		eds := dataSets[dataSet.Name]
+		status.DataSets = append(status.DataSets, zv1.ElasticsearchClusterDataSetStatus{
+			Name:          dataSet.Name,
+			DataSet:       eds.Name,
+			Replicas:      eds.Status.Replicas,
+			ReadyReplicas: eds.Status.ReadyReplicas,
+		})
+		if meta.IsStatusConditionTrue(eds.Status.Conditions, zv1.ConditionReady) && eds.Annotations[operatorParentGenerationAnnotationKey] == fmt.Sprintf("%d", cluster.Generation) {
+			status.ReadyDataSets++
+		} else {
+			notReady = append(notReady, eds.Name)
+		}
+	}
+
+	ready := metav1.Condition{
+		Type:               zv1.ConditionReady,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: cluster.Generation,
+	}
+	desired := clusterMasterReplicas(cluster)
+	switch {
+	case getSTSParentGeneration(masters) != cluster.Generation || masters.Status.ObservedGeneration != masters.Generation:
+		ready.Reason = zv1.ReasonReconciling
+		ready.Message = fmt.Sprintf("Generation %d is not reconciled yet.", cluster.Generation)
+	case masters.Status.ReadyReplicas < desired || masters.Status.UpdatedReplicas < desired:
+		ready.Reason = zv1.ReasonPodsNotReady
+		ready.Message = fmt.Sprintf("%d of %d master nodes are updated and ready.", masters.Status.ReadyReplicas, desired)
+	case len(notReady) > 0:
+		ready.Reason = zv1.ReasonDataSetsNotReady
+		ready.Message = fmt.Sprintf("Waiting for EDS %s to be ready.", strings.Join(notReady, ", "))
+	default:
+		ready.Status = metav1.ConditionTrue
+		ready.Reason = zv1.ReasonReady
+		ready.Message = "All master nodes and EDS are ready."
+	}
+	meta.SetStatusCondition(&status.Conditions, ready)
+	return status
+}
+
+func (o *ElasticsearchOperator) updateClusterStatus(ctx context.Context, cluster *zv1.ElasticsearchCluster, status *zv1.ElasticsearchClusterStatus) error {
+	if equality.Semantic.DeepEqual(cluster.Status, *status) {
+		return nil
+	}
+	cluster.Status = *status
+	_, err := o.kube.ZalandoV1().ElasticsearchClusters(cluster.Namespace).UpdateStatus(ctx, cluster, metav1.UpdateOptions{})
+	return err
+}
@@ -0,0 +1,235 @@
+package operator
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	excludeIPsSetting      = "cluster.routing.allocation.exclude._ip"
+	rebalanceEnableSetting = "cluster.routing.rebalance.enable"
+)
+
+// managedSettings returns the settings of the baseline which are managed by
+// the operator while draining, scaling or failing over zones. Setting them
+// back to a baseline would break these operations.
+func managedSettings(baseline map[string]string) []string {
+	managed := make([]string, 0)
+	for setting := range baseline {
+		switch {
+		case setting == excludeIPsSetting, setting == rebalanceEnableSetting, setting == maxShardsPerNodeSetting:
+		case strings.HasPrefix(setting, "cluster.routing.allocation.awareness.force."):
+		default:
+			continue
+		}
+		managed = append(managed, setting)
+	}
+	sort.Strings(managed)
+	return managed
+}
+
+// GetFlatClusterSettings returns the persistent and transient cluster
+// settings by their flat key. Values of list settings are comma-separated.
+func (c *ESClient) GetFlatClusterSettings() (map[string]string, map[string]string, error) {
+	resp, err := c.httpClient().R().
+		Get(c.Endpoint.String() + "/_cluster/settings?flat_settings=true")
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, nil, fmt.Errorf("code status %d - %s", resp.StatusCode(), resp.Body())
+	}
+
+	var settings struct {
+		Persistent map[string]json.RawMessage `json:"persistent"`
+		Transient  map[string]json.RawMessage `json:"transient"`
+	}
+	err = json.Unmarshal(resp.Body(), &settings)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return flatSettingValues(settings.Persistent), flatSettingValues(settings.Transient), nil
+}
+
+// flatSettingValues returns the values of the flat settings as strings.
+func flatSettingValues(settings map[string]json.RawMessage) map[string]string {
+	values := make(map[string]string, len(settings))
+	for setting, raw := range settings {
+		// list settings are returned either as list or as comma separated
+		// string.
+		var list []string
+		if err := json.Unmarshal(raw, &list); err == nil {
+			values[setting] = strings.Join(list, ",")
+			continue
+		}
+		var value string
+		if err := json.Unmarshal(raw, &value); err == nil {
+			values[setting] = value
+			continue
+		}
+		values[setting] = string(raw)
+	}
+	return values
+}
+
+// applySettingsBaseline sets the persistent settings and removes the
+// transient settings overriding them.
+func (c *ESClient) applySettingsBaseline(persistent map[string]string, transient []string) error {
+	body := map[string]map[string]interface{}{
+		"persistent": {},
+	}
+	for setting, value := range persistent {
+		body["persistent"][setting] = value
+	}
+	if len(transient) > 0 {
+		body["transient"] = map[string]interface{}{}
+		for _, setting := range transient {
+			body["transient"][setting] = nil
+		}
+	}
+
+	resp, err := c.httpClient().R().
+		SetHeader("Content-Type", "application/json").
+		SetBody(body).
+		Put(c.Endpoint.String() + "/_cluster/settings")
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return fmt.Errorf("code status %d - %s", resp.StatusCode(), resp.Body())
+	}
+	return nil
+}
+
+// settingsDrift returns the settings of the baseline whose persistent value
+// differs, or which are overridden by a different transient value.
+func settingsDrift(baseline, persistent, transient map[string]string) []zv1.ElasticsearchClusterSettingDrift {
+	var drift []zv1.ElasticsearchClusterSettingDrift
+	for setting, desired := range baseline {
+		transientValue, hasTransient := transient[setting]
+		if persistent[setting] == desired && (!hasTransient || transientValue == desired) {
+			continue
+		}
+		drift = append(drift, zv1.ElasticsearchClusterSettingDrift{
+			Setting:    setting,
+			Desired:    desired,
+			Persistent: persistent[setting],
+			Transient:  transientValue,
+		})
+	}
+	sort.Slice(drift, func(i, j int) bool {
+		return drift[i].Setting < drift[j].Setting
+	})
+	return drift
+}
+
+// formatSettingsDrift returns the drift as list of '<setting>: <actual> ->
+// <desired>'.
+func formatSettingsDrift(drift []zv1.ElasticsearchClusterSettingDrift) string {
+	changes := make([]string, 0, len(drift))
+	for _, d := range drift {
+		actual := d.Persistent
+		if actual == "" {
+			actual = "<unset>"
+		}
+		if d.Transient != "" && d.Transient != d.Desired {
+			actual = fmt.Sprintf("%s (transient %s)", actual, d.Transient)
+		}
+		changes = append(changes, fmt.Sprintf("%s: %s -> %s", d.Setting, actual, d.Desired))
+	}
+	return strings.Join(changes, ", ")
+}
+
+// clusterClient returns a client of the cluster, or nil if none of its EDS
+// exists yet. All EDS of the cluster reach the same cluster, any of them is
+// used.
+func (o *ElasticsearchOperator) clusterClient(cluster *zv1.ElasticsearchCluster, dataSets map[string]*zv1.ElasticsearchDataSet) *ESClient {
+	for _, dataSet := range cluster.Spec.DataSets {
+		if eds := dataSets[dataSet.Name]; eds != nil {
+			return &ESClient{Endpoint: o.getElasticsearchEndpoint(eds), transport: o.elasticsearchTransport(eds)}
+		}
+	}
+	return nil
+}
+
+// enforceClusterSettings compares the persistent cluster settings with the
+// settings baseline of the cluster and sets drifting settings back, unless
+// the drift is only reported. The drift and the SettingsInSync condition are
+// set in the status.
+func (o *ElasticsearchOperator) enforceClusterSettings(cluster *zv1.ElasticsearchCluster, dataSets map[string]*zv1.ElasticsearchDataSet, status *zv1.ElasticsearchClusterStatus) error {
+	baseline := cluster.Spec.Settings
+	if baseline == nil {
+		status.SettingsDrift = nil
+		meta.RemoveStatusCondition(&status.Conditions, zv1.ConditionSettingsInSync)
+		return nil
+	}
+
+	condition := metav1.Condition{
+		Type:               zv1.ConditionSettingsInSync,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: cluster.Generation,
+	}
+	if managed := managedSettings(baseline.Persistent); len(managed) > 0 {
+		condition.Reason = zv1.ReasonInvalid
+		condition.Message = fmt.Sprintf("Settings managed by the operator can't be set: %s.", strings.Join(managed, ", "))
+		o.recorder.Event(cluster, v1.EventTypeWarning, "InvalidSettings", condition.Message)
+		status.SettingsDrift = nil
+		meta.SetStatusCondition(&status.Conditions, condition)
+		return nil
+	}
+
+	client := o.clusterClient(cluster, dataSets)
+	if client == nil {
+		return nil
+	}
+
+	persistent, transient, err := client.GetFlatClusterSettings()
+	if err != nil {
+		return fmt.Errorf("failed to get cluster settings: %v", err)
+	}
+	drift := settingsDrift(baseline.Persistent, persistent, transient)
+
+	switch {
+	case len(drift) == 0:
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = zv1.ReasonAsExpected
+		condition.Message = "The cluster settings match the baseline."
+	case baseline.ReportOnly:
+		condition.Reason = zv1.ReasonDrifted
+		condition.Message = fmt.Sprintf("Cluster settings drift from the baseline: %s.", formatSettingsDrift(drift))
+		// only report new drift, it's checked at every interval.
+		if !equality.Semantic.DeepEqual(status.SettingsDrift, drift) {
+			o.recorder.Event(cluster, v1.EventTypeWarning, "SettingsDrift", condition.Message)
+		}
+	default:
+		settings := make(map[string]string, len(drift))
+		var overridden []string
+		for _, d := range drift {
+			settings[d.Setting] = d.Desired
+			if d.Transient != "" && d.Transient != d.Desired {
+				overridden = append(overridden, d.Setting)
+			}
+		}
+		err := client.applySettingsBaseline(settings, overridden)
+		if err != nil {
+			return fmt.Errorf("failed to set cluster settings: %v", err)
+		}
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = zv1.ReasonCorrected
+		condition.Message = fmt.Sprintf("Cluster settings were set back to the baseline: %s.", formatSettingsDrift(drift))
+		o.recorder.Event(cluster, v1.EventTypeWarning, "CorrectedSettingsDrift", condition.Message)
+	}
+	status.SettingsDrift = drift
+	meta.SetStatusCondition(&status.Conditions, condition)
+	return nil
+}
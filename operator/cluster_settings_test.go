@@ -0,0 +1,126 @@
+package operator
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestSettingsDrift(t *testing.T) {
+	baseline := map[string]string{
+		"indices.recovery.max_bytes_per_sec":                    "200mb",
+		"cluster.routing.allocation.awareness.attributes":       "zone",
+		"cluster.routing.allocation.node_concurrent_recoveries": "4",
+		"action.destructive_requires_name":                      "true",
+	}
+	drift := settingsDrift(baseline, map[string]string{
+		"indices.recovery.max_bytes_per_sec":              "40mb",
+		"cluster.routing.allocation.awareness.attributes": "zone",
+		"action.destructive_requires_name":                "true",
+	}, map[string]string{
+		"cluster.routing.allocation.awareness.attributes": "zone,rack",
+		"action.destructive_requires_name":                "true",
+	})
+	require.Equal(t, []zv1.ElasticsearchClusterSettingDrift{
+		{Setting: "cluster.routing.allocation.awareness.attributes", Desired: "zone", Persistent: "zone", Transient: "zone,rack"},
+		{Setting: "cluster.routing.allocation.node_concurrent_recoveries", Desired: "4"},
+		{Setting: "indices.recovery.max_bytes_per_sec", Desired: "200mb", Persistent: "40mb"},
+	}, drift)
+	require.Equal(t, "cluster.routing.allocation.awareness.attributes: zone (transient zone,rack) -> zone, "+
+		"cluster.routing.allocation.node_concurrent_recoveries: <unset> -> 4, "+
+		"indices.recovery.max_bytes_per_sec: 40mb -> 200mb", formatSettingsDrift(drift))
+
+	require.Empty(t, settingsDrift(baseline, baseline, nil))
+}
+
+func TestManagedSettings(t *testing.T) {
+	require.Equal(t, []string{
+		"cluster.routing.allocation.awareness.force.zone.values",
+		"cluster.routing.allocation.exclude._ip",
+	}, managedSettings(map[string]string{
+		"cluster.routing.allocation.exclude._ip":                 "10.0.0.1",
+		"cluster.routing.allocation.awareness.force.zone.values": "a,b",
+		"cluster.routing.allocation.awareness.attributes":        "zone",
+	}))
+}
+
+func TestEnforceClusterSettings(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_cluster/settings",
+		httpmock.NewStringResponder(200, `{
+			"persistent":{"indices.recovery.max_bytes_per_sec":"40mb","cluster.routing.allocation.awareness.attributes":["zone"]},
+			"transient":{"cluster.routing.allocation.node_concurrent_recoveries":"8"}
+		}`))
+	var body map[string]map[string]interface{}
+	httpmock.RegisterResponder("PUT", "http://elasticsearch:9200/_cluster/settings",
+		func(req *http.Request) (*http.Response, error) {
+			err := json.NewDecoder(req.Body).Decode(&body)
+			if err != nil {
+				return nil, err
+			}
+			return httpmock.NewStringResponse(200, `{"acknowledged":true}`), nil
+		})
+
+	endpoint, _ := url.Parse("http://elasticsearch:9200")
+	recorder := record.NewFakeRecorder(10)
+	o := &ElasticsearchOperator{elasticsearchEndpoint: endpoint, recorder: recorder}
+	cluster := &zv1.ElasticsearchCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "es", Namespace: "default"},
+		Spec: zv1.ElasticsearchClusterSpec{
+			DataSets: []zv1.ElasticsearchClusterDataSet{{Name: "data"}},
+			Settings: &zv1.ElasticsearchClusterSettings{
+				Persistent: map[string]string{
+					"indices.recovery.max_bytes_per_sec":                    "200mb",
+					"cluster.routing.allocation.awareness.attributes":       "zone",
+					"cluster.routing.allocation.node_concurrent_recoveries": "4",
+				},
+				ReportOnly: true,
+			},
+		},
+	}
+	dataSets := map[string]*zv1.ElasticsearchDataSet{"data": {ObjectMeta: metav1.ObjectMeta{Name: "es-data", Namespace: "default"}}}
+	status := &zv1.ElasticsearchClusterStatus{}
+
+	// drift is only reported once.
+	require.NoError(t, o.enforceClusterSettings(cluster, dataSets, status))
+	require.Len(t, status.SettingsDrift, 2)
+	condition := meta.FindStatusCondition(status.Conditions, zv1.ConditionSettingsInSync)
+	require.Equal(t, metav1.ConditionFalse, condition.Status)
+	require.Equal(t, zv1.ReasonDrifted, condition.Reason)
+	require.Contains(t, <-recorder.Events, "SettingsDrift")
+	require.NoError(t, o.enforceClusterSettings(cluster, dataSets, status))
+	require.Empty(t, recorder.Events)
+	require.Zero(t, httpmock.GetCallCountInfo()["PUT http://elasticsearch:9200/_cluster/settings"])
+
+	// drift is set back.
+	cluster.Spec.Settings.ReportOnly = false
+	require.NoError(t, o.enforceClusterSettings(cluster, dataSets, status))
+	require.Equal(t, zv1.ReasonCorrected, meta.FindStatusCondition(status.Conditions, zv1.ConditionSettingsInSync).Reason)
+	require.Contains(t, <-recorder.Events, "CorrectedSettingsDrift")
+	require.Equal(t, map[string]interface{}{
+		"indices.recovery.max_bytes_per_sec":                    "200mb",
+		"cluster.routing.allocation.node_concurrent_recoveries": "4",
+	}, body["persistent"])
+	require.Equal(t, map[string]interface{}{"cluster.routing.allocation.node_concurrent_recoveries": nil}, body["transient"])
+
+	// settings managed by the operator are rejected.
+	cluster.Spec.Settings.Persistent[excludeIPsSetting] = "10.0.0.1"
+	require.NoError(t, o.enforceClusterSettings(cluster, dataSets, status))
+	require.Equal(t, zv1.ReasonInvalid, meta.FindStatusCondition(status.Conditions, zv1.ConditionSettingsInSync).Reason)
+	require.Nil(t, status.SettingsDrift)
+
+	// without baseline the condition is removed.
+	cluster.Spec.Settings = nil
+	require.NoError(t, o.enforceClusterSettings(cluster, dataSets, status))
+	require.Nil(t, meta.FindStatusCondition(status.Conditions, zv1.ConditionSettingsInSync))
+}
@@ -0,0 +1,154 @@
+package operator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+func clusterTestFixture() *zv1.ElasticsearchCluster {
+	masters := int32(3)
+	return &zv1.ElasticsearchCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "es", Namespace: "default", UID: "cluster-uid", Generation: 2},
+		Spec: zv1.ElasticsearchClusterSpec{
+			Config: "bootstrap.memory_lock: false\n",
+			Masters: zv1.ElasticsearchClusterMasters{
+				Replicas: &masters,
+				Template: zv1.PodTemplateSpec{
+					Spec: v1.PodSpec{Containers: []v1.Container{{Name: "elasticsearch", Image: "elasticsearch:8.15.0"}}},
+				},
+			},
+			DataSets: []zv1.ElasticsearchClusterDataSet{{
+				Name:   "hot",
+				Labels: map[string]string{"group": "hot"},
+				Spec: zv1.ElasticsearchDataSetSpec{
+					Template: zv1.PodTemplateSpec{
+						Spec: v1.PodSpec{Containers: []v1.Container{{Name: "elasticsearch", Image: "elasticsearch:8.15.0"}}},
+					},
+				},
+			}},
+		},
+	}
+}
+
+func TestHasSetting(t *testing.T) {
+	settings := map[string]interface{}{
+		"cluster.name": "es",
+		"discovery":    map[string]interface{}{"seed_hosts": []string{"es-master"}},
+		"node":         map[string]interface{}{"attr.group": "hot"},
+	}
+	require.True(t, hasSetting(settings, "cluster.name"))
+	require.True(t, hasSetting(settings, "discovery.seed_hosts"))
+	require.True(t, hasSetting(settings, "node.attr.group"))
+	require.False(t, hasSetting(settings, "cluster.initial_master_nodes"))
+	require.False(t, hasSetting(settings, "discovery.type"))
+}
+
+func TestRenderClusterConfig(t *testing.T) {
+	cluster := clusterTestFixture()
+	config, err := renderClusterConfig(cluster)
+	require.NoError(t, err)
+	settings := make(map[string]interface{})
+	require.NoError(t, yaml.Unmarshal([]byte(config), &settings))
+	require.Equal(t, false, settings["bootstrap.memory_lock"])
+	require.Equal(t, "es", settings["cluster.name"])
+	require.Equal(t, []interface{}{"es-master"}, settings["discovery.seed_hosts"])
+	require.Equal(t, []interface{}{"es-master-0", "es-master-1", "es-master-2"}, settings["cluster.initial_master_nodes"])
+
+	// settings of the spec take precedence.
+	cluster.Spec.Config = "cluster:\n  name: logs\n"
+	config, err = renderClusterConfig(cluster)
+	require.NoError(t, err)
+	settings = make(map[string]interface{})
+	require.NoError(t, yaml.Unmarshal([]byte(config), &settings))
+	require.Equal(t, map[string]interface{}{"name": "logs"}, settings["cluster"])
+	require.NotContains(t, settings, "cluster.name")
+
+	cluster.Spec.Config = "- invalid"
+	_, err = renderClusterConfig(cluster)
+	require.Error(t, err)
+}
+
+func TestRenderClusterMasters(t *testing.T) {
+	cluster := clusterTestFixture()
+	sts := renderClusterMasters(cluster, nil)
+	require.Equal(t, "es-master", sts.Name)
+	require.Equal(t, "es-master", sts.Spec.ServiceName)
+	require.EqualValues(t, 3, *sts.Spec.Replicas)
+	require.Equal(t, "cluster-uid", string(sts.OwnerReferences[0].UID))
+	require.EqualValues(t, 2, getSTSParentGeneration(sts))
+	require.Equal(t, clusterLabels(cluster, clusterRoleMaster), sts.Spec.Selector.MatchLabels)
+	require.Equal(t, clusterRoleMaster, sts.Spec.Template.Labels[clusterRoleLabelKey])
+
+	container := sts.Spec.Template.Spec.Containers[0]
+	require.Contains(t, container.Env, v1.EnvVar{Name: "node.roles", Value: "master"})
+	require.Equal(t, clusterConfigMountPath, container.VolumeMounts[0].MountPath)
+	require.Equal(t, "es-config", sts.Spec.Template.Spec.Volumes[0].ConfigMap.Name)
+
+	// the config isn't mounted twice.
+	cluster.Spec.Masters.Template.Spec = sts.Spec.Template.Spec
+	sts = renderClusterMasters(cluster, sts)
+	require.Len(t, sts.Spec.Template.Spec.Volumes, 1)
+	require.Len(t, sts.Spec.Template.Spec.Containers[0].Env, 2)
+}
+
+func TestRenderClusterDataSet(t *testing.T) {
+	cluster := clusterTestFixture()
+	cluster.Annotations = map[string]string{esOperatorAnnotationKey: "operator"}
+	dataSet := &cluster.Spec.DataSets[0]
+	eds := renderClusterDataSet(cluster, dataSet, nil)
+	require.Equal(t, "es-hot", eds.Name)
+	require.Equal(t, "hot", eds.Labels["group"])
+	require.Equal(t, "es", eds.Labels[clusterLabelKey])
+	require.Equal(t, "operator", eds.Annotations[esOperatorAnnotationKey])
+	require.Equal(t, clusterRoleData, eds.Spec.Template.Labels[clusterRoleLabelKey])
+	require.Equal(t, clusterConfigMountPath, eds.Spec.Template.Spec.Containers[0].VolumeMounts[0].MountPath)
+	require.Nil(t, dataSet.Spec.Template.Labels)
+
+	// the replicas of the autoscaler are kept.
+	replicas, scaled := int32(3), int32(5)
+	dataSet.Spec.Replicas = &replicas
+	dataSet.Spec.Scaling = &zv1.ElasticsearchDataSetScaling{Enabled: true}
+	eds.Spec.Replicas = &scaled
+	eds = renderClusterDataSet(cluster, dataSet, eds)
+	require.EqualValues(t, 5, *eds.Spec.Replicas)
+
+	dataSet.Spec.Scaling.Enabled = false
+	eds = renderClusterDataSet(cluster, dataSet, eds)
+	require.EqualValues(t, 3, *eds.Spec.Replicas)
+}
+
+func TestClusterStatus(t *testing.T) {
+	cluster := clusterTestFixture()
+	masters := renderClusterMasters(cluster, nil)
+	masters.Generation = 1
+	masters.Status = appsv1.StatefulSetStatus{ObservedGeneration: 1, Replicas: 3, ReadyReplicas: 2, UpdatedReplicas: 3}
+	eds := renderClusterDataSet(cluster, &cluster.Spec.DataSets[0], nil)
+	eds.Status.Replicas = 2
+	dataSets := map[string]*zv1.ElasticsearchDataSet{"hot": eds}
+
+	status := clusterStatus(cluster, masters, dataSets)
+	require.EqualValues(t, 2, status.ObservedGeneration)
+	require.EqualValues(t, 2, status.ReadyMasters)
+	require.Equal(t, []zv1.ElasticsearchClusterDataSetStatus{{Name: "hot", DataSet: "es-hot", Replicas: 2}}, status.DataSets)
+	require.Equal(t, zv1.ReasonPodsNotReady, meta.FindStatusCondition(status.Conditions, zv1.ConditionReady).Reason)
+
+	masters.Status.ReadyReplicas = 3
+	status = clusterStatus(cluster, masters, dataSets)
+	require.Equal(t, zv1.ReasonDataSetsNotReady, meta.FindStatusCondition(status.Conditions, zv1.ConditionReady).Reason)
+
+	eds.Status.Conditions = []metav1.Condition{{Type: zv1.ConditionReady, Status: metav1.ConditionTrue}}
+	status = clusterStatus(cluster, masters, dataSets)
+	require.EqualValues(t, 1, status.ReadyDataSets)
+	require.True(t, meta.IsStatusConditionTrue(status.Conditions, zv1.ConditionReady))
+
+	cluster.Generation = 3
+	status = clusterStatus(cluster, masters, dataSets)
+	require.Equal(t, zv1.ReasonReconciling, meta.FindStatusCondition(status.Conditions, zv1.ConditionReady).Reason)
+}
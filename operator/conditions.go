@@ -0,0 +1,151 @@
+package operator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// setReadinessConditions sets the Ready, Progressing and Degraded conditions
+// of the EDS from the state of the StatefulSet, the rollout progress, the
+// failing pods and the other conditions. The conditions must be set after
+// the RolloutFailed and ZoneFailure conditions.
+func (r *EDSResource) setReadinessConditions(sts *appsv1.StatefulSet, progress *zv1.ElasticsearchDataSetRolloutProgress, failingPods []zv1.ElasticsearchDataSetPodFailure, conditions *[]metav1.Condition) {
+	generation := r.eds.Generation
+	desired := r.Replicas()
+	replicas := int32(0)
+	if sts.Spec.Replicas != nil {
+		replicas = *sts.Spec.Replicas
+	}
+
+	ready := metav1.Condition{
+		Type:               zv1.ConditionReady,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: generation,
+	}
+	progressing := metav1.Condition{
+		Type:               zv1.ConditionProgressing,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: generation,
+	}
+
+	switch {
+	case getSTSParentGeneration(sts) != generation || sts.Status.ObservedGeneration != sts.Generation:
+		ready.Reason = zv1.ReasonReconciling
+		ready.Message = fmt.Sprintf("Generation %d is not reconciled yet.", generation)
+	case replicas != desired || sts.Status.Replicas != replicas:
+		ready.Reason = zv1.ReasonScaling
+		ready.Message = fmt.Sprintf("Scaling from %d to %d Pods.", sts.Status.Replicas, desired)
+	case progress != nil && (progress.CurrentRevision != "" || len(progress.Revisions) > 1):
+		ready.Reason = zv1.ReasonRollingOut
+		ready.Message = fmt.Sprintf("%d of %d Pods are updated and ready.", progress.UpdatedReplicas, progress.Replicas)
+		if progress.Paused {
+			ready.Reason = zv1.ReasonRolloutPaused
+			ready.Message += " The rollout is paused."
+			progressing.Status = metav1.ConditionFalse
+		}
+	case sts.Status.ReadyReplicas < desired:
+		ready.Reason = zv1.ReasonPodsNotReady
+		ready.Message = fmt.Sprintf("%d of %d Pods are ready.", sts.Status.ReadyReplicas, desired)
+		progressing.Status = metav1.ConditionFalse
+	default:
+		ready.Status = metav1.ConditionTrue
+		ready.Reason = zv1.ReasonReady
+		ready.Message = fmt.Sprintf("All %d Pods are updated and ready.", desired)
+		progressing.Status = metav1.ConditionFalse
+	}
+	progressing.Reason, progressing.Message = ready.Reason, ready.Message
+	if progressing.Status == metav1.ConditionFalse && ready.Reason != zv1.ReasonRolloutPaused {
+		progressing.Reason, progressing.Message = zv1.ReasonComplete, "The latest generation is reconciled."
+	}
+
+	degraded := metav1.Condition{
+		Type:               zv1.ConditionDegraded,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: generation,
+	}
+	switch {
+	case meta.IsStatusConditionTrue(*conditions, rolloutFailedConditionType):
+		degraded.Reason = zv1.ReasonRolledBack
+		degraded.Message = meta.FindStatusCondition(*conditions, rolloutFailedConditionType).Message
+	case meta.IsStatusConditionTrue(*conditions, zoneFailureConditionType):
+		degraded.Reason = zv1.ReasonZonesLost
+		degraded.Message = meta.FindStatusCondition(*conditions, zoneFailureConditionType).Message
+	case len(failingPods) > 0:
+		degraded.Reason = zv1.ReasonPodsFailing
+		degraded.Message = fmt.Sprintf("%d Pods are failing.", len(failingPods))
+	default:
+		degraded.Status = metav1.ConditionFalse
+		degraded.Reason = zv1.ReasonAsExpected
+		degraded.Message = "No Pods are failing."
+	}
+
+	meta.SetStatusCondition(conditions, ready)
+	meta.SetStatusCondition(conditions, progressing)
+	meta.SetStatusCondition(conditions, degraded)
+}
+
+// setActivityConditions sets the ScalingActive and DrainInProgress conditions
+// of the EDS from its pending scaling operation, the scale-down being
+// monitored, the StatefulSet and the Pods being drained.
+func (r *EDSResource) setActivityConditions(sts *appsv1.StatefulSet, pods []*v1.Pod, scaleDownRecovery *zv1.ElasticsearchDataSetScaleDownRecoveryStatus, conditions *[]metav1.Condition) {
+	generation := r.eds.Generation
+	desired := r.Replicas()
+	replicas := int32(0)
+	if sts.Spec.Replicas != nil {
+		replicas = *sts.Spec.Replicas
+	}
+
+	scaling := metav1.Condition{
+		Type:               zv1.ConditionScalingActive,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: generation,
+	}
+	switch {
+	case hasScalingOperation(r.eds):
+		scaling.Reason = zv1.ReasonScalingOperation
+		scaling.Message = "A scaling operation of the autoscaler is pending."
+		if operation, err := edsScalingOperation(r.eds); err == nil && operation != nil && operation.Description != "" {
+			scaling.Message = operation.Description
+		}
+	case replicas != desired || sts.Status.Replicas != replicas:
+		scaling.Reason = zv1.ReasonScaling
+		scaling.Message = fmt.Sprintf("Scaling from %d to %d Pods.", sts.Status.Replicas, desired)
+	case scaleDownRecovery != nil:
+		scaling.Reason = zv1.ReasonScaleDownMonitored
+		scaling.Message = fmt.Sprintf("Monitoring the cluster after scaling down from %d to %d Pods.", scaleDownRecovery.FromReplicas, scaleDownRecovery.ToReplicas)
+	default:
+		scaling.Status = metav1.ConditionFalse
+		scaling.Reason = zv1.ReasonStable
+		scaling.Message = fmt.Sprintf("Running %d Pods.", desired)
+	}
+
+	var draining []string
+	for _, pod := range pods {
+		if _, ok := pod.Annotations[operatorPodDrainingAnnotationKey]; ok {
+			draining = append(draining, pod.Name)
+		}
+	}
+	sort.Strings(draining)
+	drain := metav1.Condition{
+		Type:               zv1.ConditionDrainInProgress,
+		Status:             metav1.ConditionFalse,
+		Reason:             zv1.ReasonNoDrain,
+		Message:            "No Pods are drained.",
+		ObservedGeneration: generation,
+	}
+	if len(draining) > 0 {
+		drain.Status = metav1.ConditionTrue
+		drain.Reason = zv1.ReasonDraining
+		drain.Message = fmt.Sprintf("Draining %d Pods: %s.", len(draining), strings.Join(draining, ", "))
+	}
+
+	meta.SetStatusCondition(conditions, scaling)
+	meta.SetStatusCondition(conditions, drain)
+}
@@ -0,0 +1,149 @@
+package operator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSetReadinessConditions(t *testing.T) {
+	replicas := int32(3)
+	eds := &zv1.ElasticsearchDataSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "logs", Namespace: "default", Generation: 2},
+		Spec:       zv1.ElasticsearchDataSetSpec{Replicas: &replicas},
+	}
+	r := &EDSResource{eds: eds}
+	sts := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Generation:  4,
+			Annotations: map[string]string{operatorParentGenerationAnnotationKey: "1"},
+		},
+		Spec: appsv1.StatefulSetSpec{Replicas: &replicas},
+		Status: appsv1.StatefulSetStatus{
+			ObservedGeneration: 4,
+			Replicas:           3,
+			ReadyReplicas:      3,
+		},
+	}
+	var conditions []metav1.Condition
+	reasons := func() (string, string, string) {
+		return meta.FindStatusCondition(conditions, zv1.ConditionReady).Reason,
+			meta.FindStatusCondition(conditions, zv1.ConditionProgressing).Reason,
+			meta.FindStatusCondition(conditions, zv1.ConditionDegraded).Reason
+	}
+
+	// the latest generation isn't reconciled.
+	r.setReadinessConditions(sts, nil, nil, &conditions)
+	ready, progressing, degraded := reasons()
+	require.Equal(t, []string{zv1.ReasonReconciling, zv1.ReasonReconciling, zv1.ReasonAsExpected}, []string{ready, progressing, degraded})
+	require.True(t, meta.IsStatusConditionTrue(conditions, zv1.ConditionProgressing))
+	require.Equal(t, int64(2), meta.FindStatusCondition(conditions, zv1.ConditionReady).ObservedGeneration)
+
+	// rolling out a new revision.
+	sts.Annotations[operatorParentGenerationAnnotationKey] = "2"
+	progress := &zv1.ElasticsearchDataSetRolloutProgress{
+		UpdateRevision:  "b",
+		CurrentRevision: "a",
+		Replicas:        3,
+		UpdatedReplicas: 1,
+	}
+	r.setReadinessConditions(sts, progress, nil, &conditions)
+	ready, progressing, _ = reasons()
+	require.Equal(t, []string{zv1.ReasonRollingOut, zv1.ReasonRollingOut}, []string{ready, progressing})
+
+	// a paused rollout doesn't progress.
+	progress.Paused = true
+	r.setReadinessConditions(sts, progress, nil, &conditions)
+	ready, progressing, _ = reasons()
+	require.Equal(t, []string{zv1.ReasonRolloutPaused, zv1.ReasonRolloutPaused}, []string{ready, progressing})
+	require.False(t, meta.IsStatusConditionTrue(conditions, zv1.ConditionProgressing))
+
+	// failing pods outside of a rollout.
+	progress = &zv1.ElasticsearchDataSetRolloutProgress{UpdateRevision: "b", Replicas: 3, UpdatedReplicas: 2}
+	sts.Status.ReadyReplicas = 2
+	failing := []zv1.ElasticsearchDataSetPodFailure{{PodName: "logs-2"}}
+	r.setReadinessConditions(sts, progress, failing, &conditions)
+	ready, progressing, degraded = reasons()
+	require.Equal(t, []string{zv1.ReasonPodsNotReady, zv1.ReasonComplete, zv1.ReasonPodsFailing}, []string{ready, progressing, degraded})
+	require.True(t, meta.IsStatusConditionTrue(conditions, zv1.ConditionDegraded))
+
+	// scaling up.
+	replicas = 4
+	r.setReadinessConditions(sts, progress, nil, &conditions)
+	ready, _, _ = reasons()
+	require.Equal(t, zv1.ReasonScaling, ready)
+
+	// all pods are updated and ready.
+	sts.Spec.Replicas = &replicas
+	sts.Status.Replicas, sts.Status.ReadyReplicas = 4, 4
+	r.setReadinessConditions(sts, progress, nil, &conditions)
+	ready, progressing, degraded = reasons()
+	require.Equal(t, []string{zv1.ReasonReady, zv1.ReasonComplete, zv1.ReasonAsExpected}, []string{ready, progressing, degraded})
+	require.True(t, meta.IsStatusConditionTrue(conditions, zv1.ConditionReady))
+
+	// a rolled back rollout degrades the EDS.
+	meta.SetStatusCondition(&conditions, metav1.Condition{Type: zv1.ConditionRolloutFailed, Status: metav1.ConditionTrue, Reason: zv1.ReasonRolledBack})
+	r.setReadinessConditions(sts, progress, nil, &conditions)
+	_, _, degraded = reasons()
+	require.Equal(t, zv1.ReasonRolledBack, degraded)
+}
+
+func TestSetActivityConditions(t *testing.T) {
+	replicas := int32(3)
+	eds := &zv1.ElasticsearchDataSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "logs", Namespace: "default", Generation: 2},
+		Spec:       zv1.ElasticsearchDataSetSpec{Replicas: &replicas},
+	}
+	r := &EDSResource{eds: eds}
+	stsReplicas := int32(3)
+	sts := &appsv1.StatefulSet{
+		Spec:   appsv1.StatefulSetSpec{Replicas: &stsReplicas},
+		Status: appsv1.StatefulSetStatus{Replicas: 3},
+	}
+	pods := []*v1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Name: "logs-0"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "logs-1"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "logs-2"}},
+	}
+	var conditions []metav1.Condition
+	reasons := func() (string, string) {
+		return meta.FindStatusCondition(conditions, zv1.ConditionScalingActive).Reason,
+			meta.FindStatusCondition(conditions, zv1.ConditionDrainInProgress).Reason
+	}
+
+	// nothing to do.
+	r.setActivityConditions(sts, pods, nil, &conditions)
+	scaling, drain := reasons()
+	require.Equal(t, []string{zv1.ReasonStable, zv1.ReasonNoDrain}, []string{scaling, drain})
+	require.False(t, meta.IsStatusConditionTrue(conditions, zv1.ConditionScalingActive))
+	require.False(t, meta.IsStatusConditionTrue(conditions, zv1.ConditionDrainInProgress))
+
+	// monitoring a scale-down.
+	recovery := &zv1.ElasticsearchDataSetScaleDownRecoveryStatus{FromReplicas: 4, ToReplicas: 3}
+	r.setActivityConditions(sts, pods, recovery, &conditions)
+	scaling, _ = reasons()
+	require.Equal(t, zv1.ReasonScaleDownMonitored, scaling)
+
+	// scaling down and draining a pod.
+	replicas = 2
+	pods[2].Annotations = map[string]string{operatorPodDrainingAnnotationKey: "true"}
+	r.setActivityConditions(sts, pods, nil, &conditions)
+	scaling, drain = reasons()
+	require.Equal(t, []string{zv1.ReasonScaling, zv1.ReasonDraining}, []string{scaling, drain})
+	require.True(t, meta.IsStatusConditionTrue(conditions, zv1.ConditionScalingActive))
+	require.Equal(t, "Draining 1 Pods: logs-2.", meta.FindStatusCondition(conditions, zv1.ConditionDrainInProgress).Message)
+
+	// a pending scaling operation takes precedence.
+	eds.Status.ScalingState = &zv1.ElasticsearchDataSetScalingState{
+		Operation: &zv1.ElasticsearchDataSetScalingOperation{Direction: zv1.ScalingDirectionDown, Description: "Decreasing node replicas to 2."},
+	}
+	r.setActivityConditions(sts, pods, nil, &conditions)
+	scaling, _ = reasons()
+	require.Equal(t, zv1.ReasonScalingOperation, scaling)
+	require.Equal(t, "Decreasing node replicas to 2.", meta.FindStatusCondition(conditions, zv1.ConditionScalingActive).Message)
+}
@@ -0,0 +1,101 @@
+package operator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	zv2 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v2"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// ConversionWebhookPath is the path of the conversion webhook for
+// ElasticsearchDataSets.
+const ConversionWebhookPath = "/convert"
+
+// maxConversionReviewBytes limits the size of conversion reviews read by the
+// webhook. They contain all EDS of a list request.
+const maxConversionReviewBytes = 64 << 20
+
+// ConversionWebhookHandler returns an http.Handler of the conversion webhook,
+// which converts ElasticsearchDataSets between the v1 and v2 versions.
+func ConversionWebhookHandler() http.Handler {
+	logger := log.WithField("component", "webhook")
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxConversionReviewBytes))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		conversionReview := &apiextensionsv1.ConversionReview{}
+		if err := json.Unmarshal(body, conversionReview); err != nil || conversionReview.Request == nil {
+			http.Error(w, "invalid conversion review", http.StatusBadRequest)
+			return
+		}
+
+		request := conversionReview.Request
+		response := &apiextensionsv1.ConversionResponse{
+			UID:    request.UID,
+			Result: metav1.Status{Status: metav1.StatusSuccess},
+		}
+		for _, object := range request.Objects {
+			converted, err := convertElasticsearchDataSet(object.Raw, request.DesiredAPIVersion)
+			if err != nil {
+				logger.Errorf("Failed to convert EDS to %s: %v", request.DesiredAPIVersion, err)
+				response.ConvertedObjects = nil
+				response.Result = metav1.Status{Status: metav1.StatusFailure, Message: err.Error()}
+				break
+			}
+			response.ConvertedObjects = append(response.ConvertedObjects, runtime.RawExtension{Raw: converted})
+		}
+		conversionReview.Response = response
+		conversionReview.Request = nil
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(conversionReview); err != nil {
+			logger.Errorf("Failed to write conversion review: %v", err)
+		}
+	})
+}
+
+// convertElasticsearchDataSet converts the raw EDS to the desired API
+// version.
+func convertElasticsearchDataSet(raw []byte, desiredAPIVersion string) ([]byte, error) {
+	var typeMeta metav1.TypeMeta
+	if err := json.Unmarshal(raw, &typeMeta); err != nil {
+		return nil, err
+	}
+	if typeMeta.Kind != "ElasticsearchDataSet" {
+		return nil, fmt.Errorf("unsupported kind %q", typeMeta.Kind)
+	}
+	if typeMeta.APIVersion == desiredAPIVersion {
+		return raw, nil
+	}
+
+	switch {
+	case typeMeta.APIVersion == zv1.SchemeGroupVersion.String() && desiredAPIVersion == zv2.SchemeGroupVersion.String():
+		eds := &zv1.ElasticsearchDataSet{}
+		if err := json.Unmarshal(raw, eds); err != nil {
+			return nil, err
+		}
+		return json.Marshal(zv2.ConvertFromV1(eds))
+	case typeMeta.APIVersion == zv2.SchemeGroupVersion.String() && desiredAPIVersion == zv1.SchemeGroupVersion.String():
+		eds := &zv2.ElasticsearchDataSet{}
+		if err := json.Unmarshal(raw, eds); err != nil {
+			return nil, err
+		}
+		return json.Marshal(zv2.ConvertToV1(eds))
+	default:
+		return nil, fmt.Errorf("unsupported conversion from %s to %s", typeMeta.APIVersion, desiredAPIVersion)
+	}
+}
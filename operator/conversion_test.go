@@ -0,0 +1,148 @@
+package operator
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	zv2 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v2"
+	v1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func conversionTestFixture() *zv1.ElasticsearchDataSet {
+	replicas := int32(3)
+	nodeShutdown := false
+	return &zv1.ElasticsearchDataSet{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "zalando.org/v1", Kind: "ElasticsearchDataSet"},
+		ObjectMeta: metav1.ObjectMeta{Name: "es-data", Namespace: "default", Labels: map[string]string{"group": "a"}},
+		Spec: zv1.ElasticsearchDataSetSpec{
+			Replicas:     &replicas,
+			SkipDraining: true,
+			Template: zv1.PodTemplateSpec{
+				Spec: v1.PodSpec{Containers: []v1.Container{{Name: "elasticsearch", Image: "elasticsearch:8.15.0"}}},
+			},
+			Scaling: &zv1.ElasticsearchDataSetScaling{
+				Enabled:                            true,
+				MinReplicas:                        2,
+				MaxReplicas:                        10,
+				MinIndexReplicas:                   1,
+				MaxIndexReplicas:                   3,
+				MinShardsPerNode:                   2,
+				MaxShardsPerNode:                   6,
+				ScaleUpCPUBoundary:                 50,
+				ScaleUpThresholdDurationSeconds:    240,
+				ScaleUpCooldownSeconds:             600,
+				ScaleDownCPUBoundary:               20,
+				ScaleDownThresholdDurationSeconds:  1200,
+				ScaleDownCooldownSeconds:           3600,
+				DiskUsagePercentScaledownWatermark: 80,
+				ScaleDownGuards:                    []zv1.ElasticsearchDataSetScalingGuard{{Name: "shards", Expression: "shardsPerNode < 30.0"}},
+				ScaleDownRecovery:                  &zv1.ElasticsearchDataSetScaleDownRecovery{WindowSeconds: 300},
+			},
+			DrainWriteBlock: &zv1.ElasticsearchDataSetDrainWriteBlock{IndexPatterns: []string{"logs-*"}},
+			Experimental: &zv1.ExperimentalSpec{
+				Draining: &zv1.ElasticsearchDataSetDraining{
+					MaxRetries:                     5,
+					MinimumWaitTimeDurationSeconds: 1,
+					MaximumWaitTimeDurationSeconds: 2,
+					NodeShutdown:                   &nodeShutdown,
+				},
+			},
+			Indices: []string{"logs-*"},
+		},
+		Status: zv1.ElasticsearchDataSetStatus{Replicas: 3},
+	}
+}
+
+func TestConvertElasticsearchDataSet(t *testing.T) {
+	eds := conversionTestFixture()
+	converted := zv2.ConvertFromV1(eds)
+	require.Equal(t, "zalando.org/v2", converted.APIVersion)
+	require.Equal(t, zv2.ElasticsearchDataSetScalingRange{Min: 2, Max: 10}, converted.Spec.Scaling.Replicas)
+	require.EqualValues(t, 50, converted.Spec.Scaling.ScaleUp.CPUBoundary)
+	require.EqualValues(t, 3600, converted.Spec.Scaling.ScaleDown.CooldownSeconds)
+	require.EqualValues(t, 80, converted.Spec.Scaling.ScaleDown.DiskUsagePercentWatermark)
+	require.True(t, converted.Spec.Draining.Skip)
+	require.EqualValues(t, 5, *converted.Spec.Draining.MaxRetries)
+	require.Equal(t, eds.Spec.DrainWriteBlock, converted.Spec.Draining.WriteBlock)
+	require.Equal(t, eds.Status, converted.Status)
+
+	// converting back is lossless.
+	require.Equal(t, eds, zv2.ConvertToV1(converted))
+
+	// EDS without draining settings have no draining section.
+	eds.Spec.SkipDraining = false
+	eds.Spec.DrainWriteBlock = nil
+	eds.Spec.Experimental = nil
+	require.Nil(t, zv2.ConvertFromV1(eds).Spec.Draining)
+	require.Equal(t, eds, zv2.ConvertToV1(zv2.ConvertFromV1(eds)))
+
+	// retry settings left out of the v2 draining section are defaulted.
+	maxRetries := int32(3)
+	converted.Spec.Draining = &zv2.ElasticsearchDataSetDraining{MaxRetries: &maxRetries}
+	require.Equal(t, &zv1.ElasticsearchDataSetDraining{
+		MaxRetries:                     3,
+		MinimumWaitTimeDurationSeconds: 10,
+		MaximumWaitTimeDurationSeconds: 30,
+	}, zv2.ConvertToV1(converted).Spec.Experimental.Draining)
+}
+
+func TestConversionWebhook(t *testing.T) {
+	review := func(desiredAPIVersion string, objects ...interface{}) *apiextensionsv1.ConversionResponse {
+		request := &apiextensionsv1.ConversionRequest{UID: "uid", DesiredAPIVersion: desiredAPIVersion}
+		for _, object := range objects {
+			data, err := json.Marshal(object)
+			require.NoError(t, err)
+			request.Objects = append(request.Objects, runtime.RawExtension{Raw: data})
+		}
+		body, err := json.Marshal(&apiextensionsv1.ConversionReview{
+			TypeMeta: metav1.TypeMeta{APIVersion: "apiextensions.k8s.io/v1", Kind: "ConversionReview"},
+			Request:  request,
+		})
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		ConversionWebhookHandler().ServeHTTP(w, httptest.NewRequest(http.MethodPost, ConversionWebhookPath, bytes.NewReader(body)))
+		require.Equal(t, http.StatusOK, w.Code)
+
+		result := &apiextensionsv1.ConversionReview{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), result))
+		require.Equal(t, "ConversionReview", result.Kind)
+		require.EqualValues(t, "uid", result.Response.UID)
+		return result.Response
+	}
+
+	eds := conversionTestFixture()
+	response := review("zalando.org/v2", eds)
+	require.Equal(t, metav1.StatusSuccess, response.Result.Status)
+	require.Len(t, response.ConvertedObjects, 1)
+	converted := &zv2.ElasticsearchDataSet{}
+	require.NoError(t, json.Unmarshal(response.ConvertedObjects[0].Raw, converted))
+	require.Equal(t, "zalando.org/v2", converted.APIVersion)
+	require.EqualValues(t, 10, converted.Spec.Scaling.Replicas.Max)
+
+	response = review("zalando.org/v1", converted, eds)
+	require.Equal(t, metav1.StatusSuccess, response.Result.Status)
+	require.Len(t, response.ConvertedObjects, 2)
+	for _, object := range response.ConvertedObjects {
+		back := &zv1.ElasticsearchDataSet{}
+		require.NoError(t, json.Unmarshal(object.Raw, back))
+		require.Equal(t, eds.Spec, back.Spec)
+	}
+
+	// unknown versions fail the whole conversion.
+	eds.APIVersion = "zalando.org/v3"
+	response = review("zalando.org/v1", conversionTestFixture(), eds)
+	require.Equal(t, metav1.StatusFailure, response.Result.Status)
+	require.Empty(t, response.ConvertedObjects)
+
+	w := httptest.NewRecorder()
+	ConversionWebhookHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, ConversionWebhookPath, nil))
+	require.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
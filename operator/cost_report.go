@@ -0,0 +1,368 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// costSampleInterval is the interval between two footprint samples of
+	// an EDS.
+	costSampleInterval = 5 * time.Minute
+	// costHistoryRetention is the time the footprint samples are kept in
+	// memory for the cost reports.
+	costHistoryRetention = 24 * time.Hour
+)
+
+var (
+	edsNodes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "es_operator",
+		Subsystem: "eds",
+		Name:      "nodes",
+		Help:      "Number of scheduled pods of an EDS.",
+	}, []string{"namespace", "eds"})
+	edsRequestedCPUCores = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "es_operator",
+		Subsystem: "eds",
+		Name:      "requested_cpu_cores",
+		Help:      "CPU cores requested by the scheduled pods of an EDS.",
+	}, []string{"namespace", "eds"})
+	edsRequestedMemoryBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "es_operator",
+		Subsystem: "eds",
+		Name:      "requested_memory_bytes",
+		Help:      "Memory requested by the scheduled pods of an EDS.",
+	}, []string{"namespace", "eds"})
+	edsStorageBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "es_operator",
+		Subsystem: "eds",
+		Name:      "storage_bytes",
+		Help:      "Capacity of the volumes of an EDS by storage class.",
+	}, []string{"namespace", "eds", "storage_class"})
+	edsEstimatedCostPerHour = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "es_operator",
+		Subsystem: "eds",
+		Name:      "estimated_cost_per_hour",
+		Help:      "Estimated cost per hour of the resources of an EDS based on the price table.",
+	}, []string{"namespace", "eds"})
+)
+
+func init() {
+	prometheus.MustRegister(edsNodes, edsRequestedCPUCores, edsRequestedMemoryBytes, edsStorageBytes, edsEstimatedCostPerHour)
+}
+
+// PriceTable holds the prices the cost of an EDS is estimated with. All
+// prices are per hour in the same currency.
+type PriceTable struct {
+	Currency       string  `json:"currency,omitempty"`
+	CPUCoreHour    float64 `json:"cpuCoreHour"`
+	MemoryGiBHour  float64 `json:"memoryGiBHour"`
+	StorageGiBHour float64 `json:"storageGiBHour"`
+	// StorageClasses overrides StorageGiBHour for volumes of the storage
+	// classes.
+	StorageClasses map[string]float64 `json:"storageClasses,omitempty"`
+}
+
+// LoadPriceTable reads a price table from a YAML or JSON file.
+func LoadPriceTable(path string) (*PriceTable, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var prices PriceTable
+	err = yaml.UnmarshalStrict(data, &prices)
+	if err != nil {
+		return nil, fmt.Errorf("invalid price table %s: %v", path, err)
+	}
+	return &prices, nil
+}
+
+// storageGiBHour returns the price of the storage class.
+func (p *PriceTable) storageGiBHour(storageClass string) float64 {
+	if price, ok := p.StorageClasses[storageClass]; ok {
+		return price
+	}
+	return p.StorageGiBHour
+}
+
+// Footprint is the resource footprint of an EDS at a point in time.
+type Footprint struct {
+	Time        time.Time `json:"time"`
+	Nodes       int       `json:"nodes"`
+	CPUCores    float64   `json:"cpuCores"`
+	MemoryBytes int64     `json:"memoryBytes"`
+	// StorageBytes is the capacity of the volumes by storage class,
+	// including volumes retained after a scale down.
+	StorageBytes map[string]int64 `json:"storageBytes,omitempty"`
+}
+
+// costPerHour returns the estimated cost of the footprint per hour.
+func (f Footprint) costPerHour(prices *PriceTable) float64 {
+	cost := f.CPUCores*prices.CPUCoreHour + float64(f.MemoryBytes)/gib*prices.MemoryGiBHour
+	for storageClass, bytes := range f.StorageBytes {
+		cost += float64(bytes) / gib * prices.storageGiBHour(storageClass)
+	}
+	return cost
+}
+
+// CostReport is the resource footprint of an EDS over the retained history
+// and its estimated cost. The cost is only estimated with a price table.
+type CostReport struct {
+	Namespace string      `json:"namespace"`
+	Name      string      `json:"name"`
+	Since     time.Time   `json:"since"`
+	Current   Footprint   `json:"current"`
+	History   []Footprint `json:"history"`
+	// NodeHours is the number of nodes integrated over the history.
+	NodeHours     float64  `json:"nodeHours"`
+	Currency      string   `json:"currency,omitempty"`
+	CostPerHour   *float64 `json:"costPerHour,omitempty"`
+	EstimatedCost *float64 `json:"estimatedCost,omitempty"`
+}
+
+// costReport returns the report of the footprint samples of an EDS, oldest
+// first. Each sample is accounted until the next one, the last one until
+// now.
+func costReport(namespace, name string, samples []Footprint, prices *PriceTable, now time.Time) CostReport {
+	report := CostReport{
+		Namespace: namespace,
+		Name:      name,
+		Since:     samples[0].Time,
+		Current:   samples[len(samples)-1],
+		History:   samples,
+	}
+
+	var cost float64
+	for i, sample := range samples {
+		end := now
+		if i+1 < len(samples) {
+			end = samples[i+1].Time
+		}
+		hours := end.Sub(sample.Time).Hours()
+		report.NodeHours += float64(sample.Nodes) * hours
+		if prices != nil {
+			cost += sample.costPerHour(prices) * hours
+		}
+	}
+
+	if prices != nil {
+		costPerHour := report.Current.costPerHour(prices)
+		report.Currency = prices.Currency
+		report.CostPerHour = &costPerHour
+		report.EstimatedCost = &cost
+	}
+	return report
+}
+
+// podRequests returns the effective CPU cores and memory requested by the
+// pod, the larger of the sum of its containers and its largest init
+// container, plus its overhead.
+func podRequests(pod *v1.Pod) (float64, int64) {
+	var cpu, memory resource.Quantity
+	for _, container := range pod.Spec.Containers {
+		cpu.Add(container.Resources.Requests[v1.ResourceCPU])
+		memory.Add(container.Resources.Requests[v1.ResourceMemory])
+	}
+	for _, container := range pod.Spec.InitContainers {
+		if request := container.Resources.Requests[v1.ResourceCPU]; request.Cmp(cpu) > 0 {
+			cpu = request
+		}
+		if request := container.Resources.Requests[v1.ResourceMemory]; request.Cmp(memory) > 0 {
+			memory = request
+		}
+	}
+	cpu.Add(pod.Spec.Overhead[v1.ResourceCPU])
+	memory.Add(pod.Spec.Overhead[v1.ResourceMemory])
+	return float64(cpu.MilliValue()) / 1000, memory.Value()
+}
+
+// edsFootprint returns the footprint of the pods and the volume claims of
+// an EDS. Only scheduled pods are counted, pending pods don't use
+// resources yet.
+func edsFootprint(pods []v1.Pod, claims []v1.PersistentVolumeClaim, now time.Time) Footprint {
+	footprint := Footprint{Time: now, StorageBytes: make(map[string]int64)}
+	for i := range pods {
+		pod := &pods[i]
+		if pod.Spec.NodeName == "" || pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+			continue
+		}
+		cpu, memory := podRequests(pod)
+		footprint.Nodes++
+		footprint.CPUCores += cpu
+		footprint.MemoryBytes += memory
+	}
+	for i := range claims {
+		claim := &claims[i]
+		// the capacity is only known once the claim is bound.
+		size, ok := claim.Status.Capacity[v1.ResourceStorage]
+		if !ok {
+			size = claim.Spec.Resources.Requests[v1.ResourceStorage]
+		}
+		storageClass := ""
+		if claim.Spec.StorageClassName != nil {
+			storageClass = *claim.Spec.StorageClassName
+		}
+		footprint.StorageBytes[storageClass] += size.Value()
+	}
+	return footprint
+}
+
+// costHistory keeps the footprint samples of the EDS for the retention
+// period.
+type costHistory struct {
+	sync.Mutex
+	retention time.Duration
+	samples   map[types.NamespacedName][]Footprint
+}
+
+func newCostHistory(retention time.Duration) *costHistory {
+	return &costHistory{
+		retention: retention,
+		samples:   make(map[types.NamespacedName][]Footprint),
+	}
+}
+
+// add adds a sample of the EDS and drops its samples older than the
+// retention period.
+func (h *costHistory) add(key types.NamespacedName, footprint Footprint) {
+	h.Lock()
+	defer h.Unlock()
+	samples := append(h.samples[key], footprint)
+	oldest := footprint.Time.Add(-h.retention)
+	for len(samples) > 1 && samples[0].Time.Before(oldest) {
+		samples = samples[1:]
+	}
+	h.samples[key] = samples
+}
+
+// remove drops the samples of the EDS.
+func (h *costHistory) remove(key types.NamespacedName) {
+	h.Lock()
+	defer h.Unlock()
+	delete(h.samples, key)
+}
+
+// report returns the cost report of the EDS, or false if there are no
+// samples of it.
+func (h *costHistory) report(key types.NamespacedName, prices *PriceTable, now time.Time) (CostReport, bool) {
+	h.Lock()
+	defer h.Unlock()
+	samples, ok := h.samples[key]
+	if !ok {
+		return CostReport{}, false
+	}
+	return costReport(key.Namespace, key.Name, append([]Footprint(nil), samples...), prices, now), true
+}
+
+// reports returns the cost reports of all EDS ordered by namespace and
+// name.
+func (h *costHistory) reports(prices *PriceTable, now time.Time) []CostReport {
+	h.Lock()
+	keys := make([]types.NamespacedName, 0, len(h.samples))
+	for key := range h.samples {
+		keys = append(keys, key)
+	}
+	h.Unlock()
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].String() < keys[j].String()
+	})
+	reports := make([]CostReport, 0, len(keys))
+	for _, key := range keys {
+		if report, ok := h.report(key, prices, now); ok {
+			reports = append(reports, report)
+		}
+	}
+	return reports
+}
+
+// deleteCostMetrics deletes the footprint metrics of the EDS.
+func deleteCostMetrics(namespace, name string) {
+	labels := prometheus.Labels{"namespace": namespace, "eds": name}
+	edsNodes.DeletePartialMatch(labels)
+	edsRequestedCPUCores.DeletePartialMatch(labels)
+	edsRequestedMemoryBytes.DeletePartialMatch(labels)
+	edsStorageBytes.DeletePartialMatch(labels)
+	edsEstimatedCostPerHour.DeletePartialMatch(labels)
+}
+
+// runCostReports periodically samples the footprint of all EDS for the cost
+// reports and exports it as metrics.
+func (o *ElasticsearchOperator) runCostReports(ctx context.Context) {
+	nextCheck := time.Now().Add(-costSampleInterval)
+	known := make(map[types.NamespacedName]struct{})
+
+	for {
+		o.logger.Debug("Sampling footprint of EDS")
+		select {
+		case <-time.After(time.Until(nextCheck)):
+			nextCheck = time.Now().Add(costSampleInterval)
+
+			resources, err := o.collectResources(ctx)
+			if err != nil {
+				o.logger.Error(err)
+				continue
+			}
+
+			seen := make(map[types.NamespacedName]struct{}, len(resources))
+			for _, es := range resources {
+				key := types.NamespacedName{Namespace: es.ElasticsearchDataSet.Namespace, Name: es.ElasticsearchDataSet.Name}
+				seen[key] = struct{}{}
+				err := o.sampleFootprint(ctx, es, time.Now())
+				if err != nil {
+					o.logger.Errorf("Failed to sample footprint of EDS %s: %v", key, err)
+				}
+			}
+
+			for key := range known {
+				if _, ok := seen[key]; !ok {
+					o.costs.remove(key)
+					deleteCostMetrics(key.Namespace, key.Name)
+				}
+			}
+			known = seen
+		case <-ctx.Done():
+			o.logger.Info("Terminating cost report loop.")
+			return
+		}
+	}
+}
+
+// sampleFootprint adds a footprint sample of the EDS to the cost history and
+// exports it as metrics.
+func (o *ElasticsearchOperator) sampleFootprint(ctx context.Context, es *ESResource, now time.Time) error {
+	eds := es.ElasticsearchDataSet
+	// the volume claims get the labels of the StatefulSet selector.
+	claims, err := o.kube.CoreV1().PersistentVolumeClaims(eds.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", esDataSetLabelKey, eds.Name),
+	})
+	if err != nil {
+		return err
+	}
+
+	footprint := edsFootprint(es.Pods, claims.Items, now)
+	o.costs.add(types.NamespacedName{Namespace: eds.Namespace, Name: eds.Name}, footprint)
+
+	deleteCostMetrics(eds.Namespace, eds.Name)
+	edsNodes.WithLabelValues(eds.Namespace, eds.Name).Set(float64(footprint.Nodes))
+	edsRequestedCPUCores.WithLabelValues(eds.Namespace, eds.Name).Set(footprint.CPUCores)
+	edsRequestedMemoryBytes.WithLabelValues(eds.Namespace, eds.Name).Set(float64(footprint.MemoryBytes))
+	for storageClass, bytes := range footprint.StorageBytes {
+		edsStorageBytes.WithLabelValues(eds.Namespace, eds.Name, storageClass).Set(float64(bytes))
+	}
+	if o.prices != nil {
+		edsEstimatedCostPerHour.WithLabelValues(eds.Namespace, eds.Name).Set(footprint.costPerHour(o.prices))
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package operator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	"github.com/zalando-incubator/es-operator/pkg/clientset"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func costTestPod(name, node string) v1.Pod {
+	return v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{esDataSetLabelKey: "es-data"}},
+		Spec: v1.PodSpec{
+			NodeName: node,
+			InitContainers: []v1.Container{{Resources: v1.ResourceRequirements{Requests: v1.ResourceList{
+				v1.ResourceCPU: resource.MustParse("4"),
+			}}}},
+			Containers: []v1.Container{
+				{Resources: v1.ResourceRequirements{Requests: v1.ResourceList{
+					v1.ResourceCPU:    resource.MustParse("1500m"),
+					v1.ResourceMemory: resource.MustParse("4Gi"),
+				}}},
+				{Resources: v1.ResourceRequirements{Requests: v1.ResourceList{
+					v1.ResourceCPU:    resource.MustParse("500m"),
+					v1.ResourceMemory: resource.MustParse("1Gi"),
+				}}},
+			},
+		},
+	}
+}
+
+func costTestClaim(name, storageClass, size string) v1.PersistentVolumeClaim {
+	claim := v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{esDataSetLabelKey: "es-data"}},
+		Spec: v1.PersistentVolumeClaimSpec{
+			Resources: v1.VolumeResourceRequirements{Requests: v1.ResourceList{v1.ResourceStorage: resource.MustParse(size)}},
+		},
+	}
+	if storageClass != "" {
+		claim.Spec.StorageClassName = &storageClass
+	}
+	return claim
+}
+
+func TestEDSFootprint(t *testing.T) {
+	now := time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC)
+	bound := costTestClaim("data-es-data-1", "gp3", "100Gi")
+	bound.Status.Capacity = v1.ResourceList{v1.ResourceStorage: resource.MustParse("120Gi")}
+
+	footprint := edsFootprint(
+		[]v1.Pod{costTestPod("es-data-0", "node-1"), costTestPod("es-data-1", "node-2"), costTestPod("es-data-2", "")},
+		[]v1.PersistentVolumeClaim{costTestClaim("data-es-data-0", "gp3", "100Gi"), bound, costTestClaim("logs-es-data-0", "", "10Gi")},
+		now,
+	)
+	require.Equal(t, Footprint{
+		Time:         now,
+		Nodes:        2,
+		CPUCores:     8,
+		MemoryBytes:  10 * gib,
+		StorageBytes: map[string]int64{"gp3": 220 * gib, "": 10 * gib},
+	}, footprint)
+}
+
+func TestCostReport(t *testing.T) {
+	start := time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC)
+	samples := []Footprint{
+		{Time: start, Nodes: 2, CPUCores: 4, MemoryBytes: 8 * gib, StorageBytes: map[string]int64{"gp3": 100 * gib}},
+		{Time: start.Add(time.Hour), Nodes: 4, CPUCores: 8, MemoryBytes: 16 * gib, StorageBytes: map[string]int64{"gp3": 200 * gib}},
+	}
+
+	report := costReport("default", "es-data", samples, nil, start.Add(3*time.Hour))
+	require.Equal(t, start, report.Since)
+	require.Equal(t, samples[1], report.Current)
+	require.Equal(t, 10.0, report.NodeHours)
+	require.Nil(t, report.CostPerHour)
+	require.Nil(t, report.EstimatedCost)
+
+	prices := &PriceTable{
+		Currency:       "EUR",
+		CPUCoreHour:    0.5,
+		MemoryGiBHour:  0.25,
+		StorageGiBHour: 1,
+		StorageClasses: map[string]float64{"gp3": 0.01},
+	}
+	report = costReport("default", "es-data", samples, prices, start.Add(3*time.Hour))
+	require.Equal(t, "EUR", report.Currency)
+	require.InDelta(t, 10.0, *report.CostPerHour, 0.0001)
+	require.InDelta(t, 5+2*10, *report.EstimatedCost, 0.0001)
+}
+
+func TestCostHistory(t *testing.T) {
+	start := time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC)
+	history := newCostHistory(2 * time.Hour)
+	key := types.NamespacedName{Namespace: "default", Name: "es-data"}
+	for i := 0; i < 4; i++ {
+		history.add(key, Footprint{Time: start.Add(time.Duration(i) * time.Hour), Nodes: i})
+	}
+	history.add(types.NamespacedName{Namespace: "a", Name: "es-data"}, Footprint{Time: start})
+
+	// samples older than the retention are dropped.
+	report, ok := history.report(key, nil, start.Add(4*time.Hour))
+	require.True(t, ok)
+	require.Equal(t, start.Add(time.Hour), report.Since)
+	require.Len(t, report.History, 3)
+
+	reports := history.reports(nil, start.Add(4*time.Hour))
+	require.Len(t, reports, 2)
+	require.Equal(t, "a", reports[0].Namespace)
+
+	history.remove(key)
+	_, ok = history.report(key, nil, start)
+	require.False(t, ok)
+}
+
+func TestLoadPriceTable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prices.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("currency: USD\ncpuCoreHour: 0.03\nmemoryGiBHour: 0.004\nstorageGiBHour: 0.0001\nstorageClasses:\n  io2: 0.0002\n"), 0o600))
+	prices, err := LoadPriceTable(path)
+	require.NoError(t, err)
+	require.Equal(t, &PriceTable{
+		Currency:       "USD",
+		CPUCoreHour:    0.03,
+		MemoryGiBHour:  0.004,
+		StorageGiBHour: 0.0001,
+		StorageClasses: map[string]float64{"io2": 0.0002},
+	}, prices)
+	require.Equal(t, 0.0002, prices.storageGiBHour("io2"))
+	require.Equal(t, 0.0001, prices.storageGiBHour("gp3"))
+
+	// typos aren't silently ignored.
+	require.NoError(t, os.WriteFile(path, []byte("cpuCoresHour: 0.03\n"), 0o600))
+	_, err = LoadPriceTable(path)
+	require.Error(t, err)
+}
+
+func TestSampleFootprint(t *testing.T) {
+	claim := costTestClaim("data-es-data-0", "gp3", "100Gi")
+	other := costTestClaim("data-other-0", "gp3", "100Gi")
+	other.Labels = map[string]string{esDataSetLabelKey: "other"}
+	kube := fake.NewSimpleClientset(&claim, &other)
+	prices := &PriceTable{CPUCoreHour: 1}
+	esOperator := NewElasticsearchOperator(&clientset.Clientset{Interface: kube}, Options{Interval: time.Second, AutoscalerInterval: time.Second, ClusterDNSZone: "cluster.local.", Prices: prices, EnableCostReports: true})
+	es := &ESResource{
+		ElasticsearchDataSet: &zv1.ElasticsearchDataSet{ObjectMeta: metav1.ObjectMeta{Name: "es-data", Namespace: "default"}},
+		Pods:                 []v1.Pod{costTestPod("es-data-0", "node-1")},
+	}
+
+	now := time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, esOperator.sampleFootprint(context.Background(), es, now))
+	report, ok := esOperator.costs.report(types.NamespacedName{Namespace: "default", Name: "es-data"}, prices, now)
+	require.True(t, ok)
+	require.Equal(t, map[string]int64{"gp3": 100 * gib}, report.Current.StorageBytes)
+
+	require.EqualValues(t, 1, testutil.ToFloat64(edsNodes.WithLabelValues("default", "es-data")))
+	require.EqualValues(t, 4, testutil.ToFloat64(edsRequestedCPUCores.WithLabelValues("default", "es-data")))
+	require.EqualValues(t, 5*gib, testutil.ToFloat64(edsRequestedMemoryBytes.WithLabelValues("default", "es-data")))
+	require.EqualValues(t, 100*gib, testutil.ToFloat64(edsStorageBytes.WithLabelValues("default", "es-data", "gp3")))
+	require.EqualValues(t, 4, testutil.ToFloat64(edsEstimatedCostPerHour.WithLabelValues("default", "es-data")))
+
+	deleteCostMetrics("default", "es-data")
+	require.Equal(t, 0, testutil.CollectAndCount(edsStorageBytes))
+}
@@ -0,0 +1,123 @@
+package operator
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// criticalIndicesDegradedError is returned if critical indices aren't green.
+type criticalIndicesDegradedError struct {
+	indices map[string]string
+}
+
+func (e *criticalIndicesDegradedError) Error() string {
+	degraded := make([]string, 0, len(e.indices))
+	for index, status := range e.indices {
+		degraded = append(degraded, fmt.Sprintf("%s (%s)", index, status))
+	}
+	sort.Strings(degraded)
+	return fmt.Sprintf("critical indices are not green: %s", strings.Join(degraded, ", "))
+}
+
+// criticalIndicesDegraded returns an error describing the critical indices
+// which aren't green, or nil if all of them are green.
+func (c *ESClient) criticalIndicesDegraded() (*criticalIndicesDegradedError, error) {
+	if len(c.criticalIndices) == 0 {
+		return nil, nil
+	}
+
+	health, err := c.GetIndicesHealth(c.criticalIndices)
+	if err != nil {
+		return nil, err
+	}
+
+	degraded := make(map[string]string)
+	for index, status := range health {
+		if status != "green" {
+			degraded[index] = status
+		}
+	}
+	if len(degraded) == 0 {
+		return nil, nil
+	}
+	return &criticalIndicesDegradedError{indices: degraded}, nil
+}
+
+// GetIndicesHealth returns the health status of the indices matching the
+// index patterns by index name.
+func (c *ESClient) GetIndicesHealth(patterns []string) (map[string]string, error) {
+	resp, err := c.httpClient().R().
+		Get(fmt.Sprintf("%s/_cluster/health/%s?level=indices", c.Endpoint.String(),
+			url.PathEscape(strings.Join(patterns, ","))))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, fmt.Errorf("code status %d - %s", resp.StatusCode(), resp.Body())
+	}
+
+	var health struct {
+		Indices map[string]struct {
+			Status string `json:"status"`
+		} `json:"indices"`
+	}
+	err = json.Unmarshal(resp.Body(), &health)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make(map[string]string, len(health.Indices))
+	for index, indexHealth := range health.Indices {
+		statuses[index] = indexHealth.Status
+	}
+	return statuses, nil
+}
+
+// checkCriticalIndices returns an error if the critical indices aren't
+// green or their health can't be determined.
+func (c *ESClient) checkCriticalIndices() error {
+	degraded, err := c.criticalIndicesDegraded()
+	if err != nil {
+		return fmt.Errorf("failed to check health of critical indices: %v", err)
+	}
+	if degraded != nil {
+		return degraded
+	}
+	return nil
+}
+
+// includePodIP removes the podIP from the Elasticsearch exclude._ip list.
+func (c *ESClient) includePodIP(pod *v1.Pod) error {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	esSettings, err := c.getClusterSettings()
+	if err != nil {
+		return err
+	}
+
+	excludeString := esSettings.GetPersistentExcludeIPs().ValueOrZero()
+	if excludeString == "" {
+		return nil
+	}
+
+	ips := []string{}
+	for _, ip := range strings.Split(excludeString, ",") {
+		if ip != pod.Status.PodIP {
+			ips = append(ips, ip)
+		}
+	}
+
+	newExcludeString := strings.Join(ips, ",")
+	if newExcludeString == excludeString {
+		return nil
+	}
+	c.logger().Infof("Including pod %s/%s in shard allocation again", pod.Namespace, pod.Name)
+	return c.setExcludeIPs(newExcludeString, esSettings)
+}
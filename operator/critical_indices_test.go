@@ -0,0 +1,82 @@
+package operator
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+)
+
+func newCriticalIndicesClient() *ESClient {
+	esURL, _ := url.Parse("http://elasticsearch:9200")
+	return &ESClient{
+		Endpoint: esURL,
+		DrainingConfig: &DrainingConfig{
+			MaxRetries:      5,
+			MinimumWaitTime: 1 * time.Millisecond,
+			MaximumWaitTime: 3 * time.Millisecond,
+		},
+		criticalIndices: []string{"orders", "users-*"},
+	}
+}
+
+func TestDrainRefusedWithDegradedCriticalIndices(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_cluster/health",
+		httpmock.NewStringResponder(200, `{"status":"green"}`))
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_cluster/health/orders%2Cusers-%2A",
+		httpmock.NewStringResponder(200, `{"indices":{"orders":{"status":"green"},"users-1":{"status":"yellow"}}}`))
+
+	err := newCriticalIndicesClient().Drain(context.Background(), &v1.Pod{Status: v1.PodStatus{PodIP: "1.2.3.4"}})
+	require.Error(t, err)
+	assert.Equal(t, "critical indices are not green: users-1 (yellow)", err.Error())
+	assert.Equal(t, 0, httpmock.GetCallCountInfo()["PUT http://elasticsearch:9200/_cluster/settings"])
+}
+
+func TestDrainAbortedWithDegradedCriticalIndices(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_cluster/health",
+		httpmock.NewStringResponder(200, `{"status":"green"}`))
+	healthChecks := 0
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_cluster/health/orders%2Cusers-%2A",
+		func(req *http.Request) (*http.Response, error) {
+			healthChecks++
+			if healthChecks == 1 {
+				return httpmock.NewStringResponse(200, `{"indices":{"orders":{"status":"green"}}}`), nil
+			}
+			return httpmock.NewStringResponse(200, `{"indices":{"orders":{"status":"red"}}}`), nil
+		})
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_cluster/settings",
+		httpmock.NewStringResponder(200, `{"persistent":{"cluster":{"routing":{"allocation":{"exclude":{"_ip":"1.2.3.4,5.6.7.8"}}}}}}`))
+	var lastSettings string
+	httpmock.RegisterResponder("PUT", "http://elasticsearch:9200/_cluster/settings",
+		func(req *http.Request) (*http.Response, error) {
+			body, err := io.ReadAll(req.Body)
+			if err != nil {
+				return nil, err
+			}
+			lastSettings = string(body)
+			return httpmock.NewStringResponse(200, `{}`), nil
+		})
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_cat/shards",
+		httpmock.NewStringResponder(200, `[{"index":"orders","ip":"1.2.3.4"}]`))
+
+	err := newCriticalIndicesClient().Drain(context.Background(), &v1.Pod{Status: v1.PodStatus{PodIP: "1.2.3.4"}})
+	var degraded *criticalIndicesDegradedError
+	require.True(t, errors.As(err, &degraded))
+	assert.Equal(t, map[string]string{"orders": "red"}, degraded.indices)
+	// the pod is included in the shard allocation again.
+	assert.Contains(t, lastSettings, `"_ip":"5.6.7.8"`)
+}
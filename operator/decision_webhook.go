@@ -0,0 +1,216 @@
+package operator
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+)
+
+const (
+	defaultDecisionWebhookTimeoutSeconds = 10
+
+	decisionApprove = "approve"
+	decisionVeto    = "veto"
+	decisionModify  = "modify"
+)
+
+// decisionWebhookIndex is the number of replicas of an index as sent to and
+// received from the decision webhook.
+type decisionWebhookIndex struct {
+	Index     string `json:"index"`
+	Primaries int32  `json:"primaries"`
+	Replicas  int32  `json:"replicas"`
+}
+
+// decisionWebhookOperation is a scaling operation as sent to and received
+// from the decision webhook.
+type decisionWebhookOperation struct {
+	Direction     string                 `json:"direction"`
+	NodeReplicas  *int32                 `json:"nodeReplicas,omitempty"`
+	IndexReplicas []decisionWebhookIndex `json:"indexReplicas,omitempty"`
+	Description   string                 `json:"description"`
+}
+
+// decisionWebhookRequest is the body POSTed to the decision webhook.
+type decisionWebhookRequest struct {
+	Namespace  string                           `json:"namespace"`
+	Name       string                           `json:"name"`
+	Scaling    *zv1.ElasticsearchDataSetScaling `json:"scaling"`
+	Proposed   decisionWebhookOperation         `json:"proposed"`
+	Context    scalingGuardContext              `json:"context"`
+	CPUSamples []zv1.ElasticsearchMetric        `json:"cpuSamples"`
+}
+
+// decisionWebhookResponse is the response of the decision webhook. On
+// 'modify' the node and index replicas replace the proposed ones if set.
+type decisionWebhookResponse struct {
+	Decision      string                 `json:"decision"`
+	NodeReplicas  *int32                 `json:"nodeReplicas,omitempty"`
+	IndexReplicas []decisionWebhookIndex `json:"indexReplicas,omitempty"`
+	Reason        string                 `json:"reason,omitempty"`
+}
+
+// callDecisionWebhook POSTs the request to the webhook and returns its
+// decision.
+func callDecisionWebhook(webhook *zv1.ElasticsearchDataSetDecisionWebhook, request *decisionWebhookRequest) (*decisionWebhookResponse, error) {
+	timeout := time.Duration(webhook.TimeoutSeconds) * time.Second
+	if webhook.TimeoutSeconds <= 0 {
+		timeout = defaultDecisionWebhookTimeoutSeconds * time.Second
+	}
+
+	resp, err := resty.NewWithClient(&http.Client{Transport: http.DefaultTransport, Timeout: timeout}).R().
+		SetHeader("Content-Type", "application/json").
+		SetBody(request).
+		Post(webhook.URL)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, fmt.Errorf("code status %d - %s", resp.StatusCode(), resp.Body())
+	}
+
+	var response decisionWebhookResponse
+	err = json.Unmarshal(resp.Body(), &response)
+	if err != nil {
+		return nil, err
+	}
+	switch response.Decision {
+	case decisionApprove, decisionVeto, decisionModify:
+		return &response, nil
+	}
+	return nil, fmt.Errorf("unknown decision '%s'", response.Decision)
+}
+
+// reviewScalingOperation lets the decision webhook review the scaling
+// operation and returns the operation to apply. Modified node replicas are
+// bounded by minReplicas and maxReplicas, modified index replicas by
+// minIndexReplicas and maxIndexReplicas. Modifications leaving copies of
+// shards unassigned are rejected.
+func (as *AutoScaler) reviewScalingOperation(managedIndices map[string]ESIndex, managedNodes []ESNode, operation *ScalingOperation) *ScalingOperation {
+	webhook := as.eds.Spec.Scaling.DecisionWebhook
+	if webhook == nil || operation.ScalingDirection == NONE {
+		return operation
+	}
+	currentDesiredNodeReplicas := *as.eds.Spec.Replicas
+
+	request := &decisionWebhookRequest{
+		Namespace: as.eds.Namespace,
+		Name:      as.eds.Name,
+		Scaling:   as.eds.Spec.Scaling,
+		Proposed: decisionWebhookOperation{
+			Direction:     operation.ScalingDirection.String(),
+			NodeReplicas:  operation.NodeReplicas,
+			IndexReplicas: toDecisionWebhookIndices(operation.IndexReplicas),
+			Description:   operation.Description,
+		},
+		Context: as.scalingContext(managedIndices, managedNodes, currentDesiredNodeReplicas, operation),
+	}
+	if as.esMSet != nil {
+		request.CPUSamples = as.esMSet.Metrics
+	}
+
+	response, err := callDecisionWebhook(webhook, request)
+	if err != nil {
+		if webhook.FailurePolicy == zv1.DecisionWebhookFailurePolicyFail {
+			return noopScalingOperation(fmt.Sprintf("Not scaling, decision webhook failed: %v", err))
+		}
+		as.logger.Warnf("Decision webhook failed, ignoring it: %v", err)
+		return operation
+	}
+
+	switch response.Decision {
+	case decisionVeto:
+		return noopScalingOperation(fmt.Sprintf("Scaling vetoed by decision webhook: %s", response.Reason))
+	case decisionModify:
+		modified := &ScalingOperation{
+			ScalingDirection: operation.ScalingDirection,
+			NodeReplicas:     operation.NodeReplicas,
+			IndexReplicas:    operation.IndexReplicas,
+			Description:      fmt.Sprintf("Scaling modified by decision webhook: %s", response.Reason),
+		}
+		if response.NodeReplicas != nil {
+			nodeReplicas := as.ensureBoundsNodeReplicas(*response.NodeReplicas)
+			modified.NodeReplicas = &nodeReplicas
+			if direction := as.calculateScalingDirection(currentDesiredNodeReplicas, nodeReplicas); direction != NONE {
+				modified.ScalingDirection = direction
+			}
+		}
+		if response.IndexReplicas != nil {
+			modified.IndexReplicas = as.boundDecisionWebhookIndices(managedIndices, response.IndexReplicas)
+		}
+		// every copy of a shard needs its own node. The scale-down isn't
+		// checked again before the pods are drained, as it's made by the
+		// autoscaler.
+		if modified.NodeReplicas != nil {
+			required, index := requiredNodes(indicesAfterScaling(managedIndices, modified))
+			if *modified.NodeReplicas < required {
+				return noopScalingOperation(fmt.Sprintf("Not scaling, decision webhook modified the replicas to %d, but index %s needs %d nodes for all copies of its shards.",
+					*modified.NodeReplicas, index, required))
+			}
+		}
+		if modified.NodeReplicas != nil && *modified.NodeReplicas == currentDesiredNodeReplicas && len(modified.IndexReplicas) == 0 {
+			modified.ScalingDirection = NONE
+		}
+		return modified
+	}
+	return operation
+}
+
+// boundDecisionWebhookIndices returns the index replicas modified by the
+// decision webhook for the indices managed by the EDS, bounded by
+// minIndexReplicas and maxIndexReplicas. Other indices are dropped.
+func (as *AutoScaler) boundDecisionWebhookIndices(managedIndices map[string]ESIndex, indices []decisionWebhookIndex) []ESIndex {
+	scalingSpec := as.eds.Spec.Scaling
+	result := make([]ESIndex, 0, len(indices))
+	for _, index := range indices {
+		managed, ok := managedIndices[index.Index]
+		if !ok {
+			as.logger.Warnf("Ignoring replicas of index %s modified by decision webhook, it's not managed by the EDS", index.Index)
+			continue
+		}
+		replicas := index.Replicas
+		if replicas < scalingSpec.MinIndexReplicas {
+			replicas = scalingSpec.MinIndexReplicas
+		}
+		if replicas > scalingSpec.MaxIndexReplicas {
+			replicas = scalingSpec.MaxIndexReplicas
+		}
+		result = append(result, ESIndex{
+			Index:     managed.Index,
+			Primaries: managed.Primaries,
+			Replicas:  replicas,
+		})
+	}
+	return result
+}
+
+// indicesAfterScaling returns the managed indices with the index replicas of
+// the operation applied.
+func indicesAfterScaling(managedIndices map[string]ESIndex, operation *ScalingOperation) map[string]ESIndex {
+	indices := make(map[string]ESIndex, len(managedIndices))
+	for name, index := range managedIndices {
+		indices[name] = index
+	}
+	for _, index := range operation.IndexReplicas {
+		if _, ok := indices[index.Index]; ok {
+			indices[index.Index] = index
+		}
+	}
+	return indices
+}
+
+func toDecisionWebhookIndices(indices []ESIndex) []decisionWebhookIndex {
+	result := make([]decisionWebhookIndex, 0, len(indices))
+	for _, index := range indices {
+		result = append(result, decisionWebhookIndex{
+			Index:     index.Index,
+			Primaries: index.Primaries,
+			Replicas:  index.Replicas,
+		})
+	}
+	return result
+}
@@ -0,0 +1,149 @@
+package operator
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+)
+
+func TestReviewScalingOperation(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	esIndices := map[string]ESIndex{
+		"ad1": {Replicas: 1, Primaries: 6, Index: "ad1"},
+	}
+	esNodes := []ESNode{{IP: "1.2.3.4", DiskUsedPercent: 60}}
+
+	for _, tc := range []struct {
+		msg           string
+		failurePolicy zv1.ElasticsearchDataSetDecisionWebhookFailurePolicy
+		status        int
+		response      string
+		direction     ScalingDirection
+		nodeReplicas  int32
+		indexReplicas []ESIndex
+	}{
+		{
+			msg:          "approve",
+			status:       http.StatusOK,
+			response:     `{"decision":"approve"}`,
+			direction:    DOWN,
+			nodeReplicas: 3,
+		},
+		{
+			msg:       "veto",
+			status:    http.StatusOK,
+			response:  `{"decision":"veto","reason":"peak expected"}`,
+			direction: NONE,
+		},
+		{
+			msg:          "modify",
+			status:       http.StatusOK,
+			response:     `{"decision":"modify","nodeReplicas":2}`,
+			direction:    DOWN,
+			nodeReplicas: 2,
+		},
+		{
+			msg:          "modify bounded by minReplicas",
+			status:       http.StatusOK,
+			response:     `{"decision":"modify","nodeReplicas":1}`,
+			direction:    DOWN,
+			nodeReplicas: 2,
+		},
+		{
+			msg:          "modify to current replicas",
+			status:       http.StatusOK,
+			response:     `{"decision":"modify","nodeReplicas":4}`,
+			direction:    NONE,
+			nodeReplicas: 4,
+		},
+		{
+			msg:           "modify index replicas bounded by maxIndexReplicas",
+			status:        http.StatusOK,
+			response:      `{"decision":"modify","nodeReplicas":5,"indexReplicas":[{"index":"ad1","primaries":1,"replicas":7},{"index":"other","replicas":0}]}`,
+			direction:     UP,
+			nodeReplicas:  5,
+			indexReplicas: []ESIndex{{Index: "ad1", Primaries: 6, Replicas: 3}},
+		},
+		{
+			msg:           "modify index replicas bounded by minIndexReplicas",
+			status:        http.StatusOK,
+			response:      `{"decision":"modify","indexReplicas":[{"index":"ad1","replicas":0}]}`,
+			direction:     DOWN,
+			nodeReplicas:  3,
+			indexReplicas: []ESIndex{{Index: "ad1", Primaries: 6, Replicas: 1}},
+		},
+		{
+			msg:       "modify below the copies of an index",
+			status:    http.StatusOK,
+			response:  `{"decision":"modify","nodeReplicas":3,"indexReplicas":[{"index":"ad1","replicas":3}]}`,
+			direction: NONE,
+		},
+		{
+			msg:          "failure ignored",
+			status:       http.StatusInternalServerError,
+			direction:    DOWN,
+			nodeReplicas: 3,
+		},
+		{
+			msg:          "unknown decision ignored",
+			status:       http.StatusOK,
+			response:     `{"decision":"maybe"}`,
+			direction:    DOWN,
+			nodeReplicas: 3,
+		},
+		{
+			msg:           "failure fails",
+			failurePolicy: zv1.DecisionWebhookFailurePolicyFail,
+			status:        http.StatusInternalServerError,
+			direction:     NONE,
+		},
+	} {
+		t.Run(tc.msg, func(t *testing.T) {
+			var request decisionWebhookRequest
+			httpmock.RegisterResponder("POST", "http://planner/decide",
+				func(req *http.Request) (*http.Response, error) {
+					require.NoError(t, json.NewDecoder(req.Body).Decode(&request))
+					return httpmock.NewStringResponse(tc.status, tc.response), nil
+				})
+
+			eds := edsTestFixture(4)
+			eds.Spec.Scaling.MinReplicas = 2
+			eds.Spec.Scaling.DecisionWebhook = &zv1.ElasticsearchDataSetDecisionWebhook{
+				URL:           "http://planner/decide",
+				FailurePolicy: tc.failurePolicy,
+			}
+			as := systemUnderTest(eds, nil, nil)
+
+			proposed := as.calculateScalingOperation(esIndices, esNodes, DOWN)
+			require.Equal(t, DOWN, proposed.ScalingDirection, proposed.Description)
+
+			actual := as.reviewScalingOperation(esIndices, esNodes, proposed)
+			require.Equal(t, tc.direction, actual.ScalingDirection, actual.Description)
+			if tc.nodeReplicas > 0 {
+				require.Equal(t, tc.nodeReplicas, *actual.NodeReplicas, actual.Description)
+			}
+			if tc.indexReplicas != nil {
+				require.Equal(t, tc.indexReplicas, actual.IndexReplicas, actual.Description)
+			}
+
+			require.Equal(t, "DOWN", request.Proposed.Direction)
+			require.Equal(t, int32(3), *request.Proposed.NodeReplicas)
+			require.Equal(t, int32(4), request.Context.Replicas)
+			require.Equal(t, int32(12), request.Context.Shards)
+			require.Equal(t, 60.0, request.Context.DiskUsedPercent)
+		})
+	}
+}
+
+func TestReviewScalingOperationWithoutWebhook(t *testing.T) {
+	eds := edsTestFixture(4)
+	as := systemUnderTest(eds, nil, nil)
+	operation := noopScalingOperation("Nothing to do")
+	require.Equal(t, operation, as.reviewScalingOperation(nil, nil, operation))
+}
@@ -0,0 +1,232 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// decommissionFinalizer protects an EDS being decommissioned from
+	// deletion until no shards are left on its nodes.
+	decommissionFinalizer = "es-operator.zalando.org/decommission"
+
+	decommissionedConditionType = zv1.ConditionDecommissioned
+)
+
+// decommissionEnabled returns true if the EDS is being decommissioned.
+func decommissionEnabled(eds *zv1.ElasticsearchDataSet) bool {
+	return eds.Spec.Decommission != nil && eds.Spec.Decommission.Enabled
+}
+
+// decommissionMoves returns the target group of each index allocated to the
+// group. The indices are distributed over the target groups in order of
+// their names.
+func decommissionMoves(allocation map[string]string, group string, targetGroups []string) map[string]string {
+	indices := make([]string, 0)
+	for index, allocated := range allocation {
+		if allocated == group {
+			indices = append(indices, index)
+		}
+	}
+	sort.Strings(indices)
+
+	moves := make(map[string]string, len(indices))
+	for i, index := range indices {
+		moves[index] = targetGroups[i%len(targetGroups)]
+	}
+	return moves
+}
+
+// remainingShards returns the indices of the shards on the pods, and the
+// number of shards.
+func remainingShards(shards []ESShard, pods []*v1.Pod) ([]string, int) {
+	podIPs := make(map[string]struct{}, len(pods))
+	for _, pod := range pods {
+		if pod.Status.PodIP != "" {
+			podIPs[pod.Status.PodIP] = struct{}{}
+		}
+	}
+
+	indices := make(map[string]struct{})
+	count := 0
+	for _, shard := range shards {
+		if _, ok := podIPs[shard.IP]; ok {
+			indices[shard.Index] = struct{}{}
+			count++
+		}
+	}
+
+	names := make([]string, 0, len(indices))
+	for index := range indices {
+		names = append(names, index)
+	}
+	sort.Strings(names)
+	return names, count
+}
+
+// hasFinalizer returns true if the EDS has the finalizer.
+func hasFinalizer(eds *zv1.ElasticsearchDataSet, finalizer string) bool {
+	for _, f := range eds.Finalizers {
+		if f == finalizer {
+			return true
+		}
+	}
+	return false
+}
+
+// setDecommissionFinalizer adds or removes the decommission finalizer of the
+// EDS.
+func (r *EDSResource) setDecommissionFinalizer(ctx context.Context, present bool) error {
+	if hasFinalizer(r.eds, decommissionFinalizer) == present {
+		return nil
+	}
+
+	eds := r.eds.DeepCopy()
+	if present {
+		eds.Finalizers = append(eds.Finalizers, decommissionFinalizer)
+	} else {
+		finalizers := make([]string, 0, len(eds.Finalizers))
+		for _, f := range eds.Finalizers {
+			if f != decommissionFinalizer {
+				finalizers = append(finalizers, f)
+			}
+		}
+		eds.Finalizers = finalizers
+	}
+
+	eds, err := r.kube.ZalandoV1().ElasticsearchDataSets(eds.Namespace).Update(ctx, eds, metav1.UpdateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to update finalizers of EDS %s/%s: %v", r.eds.Namespace, r.eds.Name, err)
+	}
+
+	// set TypeMeta manually because of this bug:
+	// https://github.com/kubernetes/client-go/issues/308
+	eds.APIVersion = "zalando.org/v1"
+	eds.Kind = "ElasticsearchDataSet"
+	r.eds = eds
+	return nil
+}
+
+// decommission migrates the indices allocated to the group of the EDS to the
+// target groups and removes the decommission finalizer once no shards are
+// left on the pods. It sets the Decommissioned condition and records the
+// write indices blocked by the ingestion pause in blocked.
+func (r *EDSResource) decommission(ctx context.Context, pods []*v1.Pod, conditions *[]metav1.Condition, blocked *[]string) error {
+	if !decommissionEnabled(r.eds) {
+		if r.eds.Spec.Decommission != nil && r.eds.Spec.Decommission.IngestionPause != nil && r.esClient != nil &&
+			meta.IsStatusConditionTrue(*conditions, ingestionPausedConditionType) {
+			config := r.eds.Spec.Decommission.IngestionPause
+			writeIndices, err := r.esClient.GetAliasWriteIndices(config.WriteAlias)
+			if err != nil {
+				return fmt.Errorf("failed to get the write indices of alias %s: %v", config.WriteAlias, err)
+			}
+			err = r.setIngestionPaused(config, writeIndices, false, conditions, blocked)
+			if err != nil {
+				return fmt.Errorf("failed to resume ingestion into alias %s: %v", config.WriteAlias, err)
+			}
+		}
+		meta.RemoveStatusCondition(conditions, ingestionPausedConditionType)
+		meta.RemoveStatusCondition(conditions, decommissionedConditionType)
+		return r.setDecommissionFinalizer(ctx, false)
+	}
+
+	condition := metav1.Condition{
+		Type:               decommissionedConditionType,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: r.eds.Generation,
+	}
+	setCondition := func(status metav1.ConditionStatus, reason, message string) {
+		condition.Status, condition.Reason, condition.Message = status, reason, message
+		meta.SetStatusCondition(conditions, condition)
+	}
+
+	group := r.eds.Labels[groupLabelKey]
+	targetGroups := r.eds.Spec.Decommission.TargetGroups
+	if group == "" || len(targetGroups) == 0 {
+		setCondition(metav1.ConditionFalse, zv1.ReasonInvalid, fmt.Sprintf("Decommissioning requires a '%s' label and target groups.", groupLabelKey))
+		return nil
+	}
+
+	// the finalizer is kept until no shards are left on the pods.
+	if !meta.IsStatusConditionTrue(*conditions, decommissionedConditionType) {
+		err := r.setDecommissionFinalizer(ctx, true)
+		if err != nil {
+			return err
+		}
+	}
+	if r.esClient == nil {
+		setCondition(metav1.ConditionFalse, zv1.ReasonWaitingForPods, "Waiting for the Pods to be created.")
+		return nil
+	}
+
+	allocation, err := r.esClient.GetIndexAllocationGroups()
+	if err != nil {
+		setCondition(metav1.ConditionFalse, zv1.ReasonMigrationFailed, fmt.Sprintf("Failed to get the allocation of the indices: %v", err))
+		return nil
+	}
+	moves := decommissionMoves(allocation, group, targetGroups)
+
+	// ingestion is paused right before the write indices are moved.
+	pause := r.eds.Spec.Decommission.IngestionPause
+	var writeIndices []string
+	if pause != nil {
+		writeIndices, err = r.esClient.GetAliasWriteIndices(pause.WriteAlias)
+		if err != nil {
+			setCondition(metav1.ConditionFalse, zv1.ReasonMigrationFailed, fmt.Sprintf("Failed to get the write indices of alias %s: %v", pause.WriteAlias, err))
+			return nil
+		}
+		moved := make([]string, 0, len(moves))
+		for index := range moves {
+			moved = append(moved, index)
+		}
+		if containsAnyIndex(moved, writeIndices) {
+			err := r.setIngestionPaused(pause, writeIndices, true, conditions, blocked)
+			if err != nil {
+				setCondition(metav1.ConditionFalse, zv1.ReasonMigrationFailed, fmt.Sprintf("Failed to pause ingestion into alias %s: %v", pause.WriteAlias, err))
+				return nil
+			}
+		}
+	}
+
+	for index, target := range moves {
+		err := r.esClient.SetIndexAllocationGroup(index, target)
+		if err != nil {
+			setCondition(metav1.ConditionFalse, zv1.ReasonMigrationFailed, fmt.Sprintf("Failed to move index %s to group %s: %v", index, target, err))
+			return nil
+		}
+		r.recorder.Event(r.eds, v1.EventTypeNormal, "Decommissioning", fmt.Sprintf("Moved index %s to group %s", index, target))
+	}
+
+	shards, err := r.esClient.GetShards()
+	if err != nil {
+		setCondition(metav1.ConditionFalse, zv1.ReasonMigrationFailed, fmt.Sprintf("Failed to get the shards: %v", err))
+		return nil
+	}
+	indices, count := remainingShards(shards, pods)
+
+	// ingestion is resumed once the write indices are switched over.
+	if pause != nil && !containsAnyIndex(indices, writeIndices) {
+		err := r.setIngestionPaused(pause, writeIndices, false, conditions, blocked)
+		if err != nil {
+			setCondition(metav1.ConditionFalse, zv1.ReasonMigrationFailed, fmt.Sprintf("Failed to resume ingestion into alias %s: %v", pause.WriteAlias, err))
+			return nil
+		}
+	}
+	if count > 0 {
+		setCondition(metav1.ConditionFalse, zv1.ReasonMigrating, fmt.Sprintf("%d shards of indices %s are left on the Pods.", count, strings.Join(indices, ",")))
+		return nil
+	}
+
+	if !meta.IsStatusConditionTrue(*conditions, decommissionedConditionType) {
+		r.recorder.Event(r.eds, v1.EventTypeNormal, "Decommissioned", "No shards are left on the Pods, the EDS can be deleted")
+	}
+	setCondition(metav1.ConditionTrue, zv1.ReasonDecommissioned, "No shards are left on the Pods.")
+	return r.setDecommissionFinalizer(ctx, false)
+}
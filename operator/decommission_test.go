@@ -0,0 +1,182 @@
+package operator
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestDecommissionMoves(t *testing.T) {
+	moves := decommissionMoves(map[string]string{
+		"logs-3": "a",
+		"logs-1": "a",
+		"logs-2": "a",
+		"other":  "b",
+	}, "a", []string{"b", "c"})
+	require.Equal(t, map[string]string{"logs-1": "b", "logs-2": "c", "logs-3": "b"}, moves)
+
+	require.Empty(t, decommissionMoves(map[string]string{"other": "b"}, "a", []string{"b"}))
+}
+
+func TestRemainingShards(t *testing.T) {
+	pods := []*v1.Pod{
+		{Status: v1.PodStatus{PodIP: "10.0.0.1"}},
+		{Status: v1.PodStatus{}},
+	}
+	indices, count := remainingShards([]ESShard{
+		{IP: "10.0.0.1", Index: "logs-2"},
+		{IP: "10.0.0.1", Index: "logs-1"},
+		{IP: "10.0.0.1", Index: "logs-1"},
+		{IP: "10.0.0.2", Index: "logs-3"},
+		{IP: "", Index: "unassigned"},
+	}, pods)
+	require.Equal(t, []string{"logs-1", "logs-2"}, indices)
+	require.Equal(t, 3, count)
+}
+
+func TestDecommission(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_all/_settings/index.routing.allocation.include.group",
+		httpmock.NewStringResponder(200, `{"logs-1":{"settings":{"index.routing.allocation.include.group":"a"}},"logs-2":{"settings":{"index.routing.allocation.include.group":"b"}}}`))
+	httpmock.RegisterResponder("PUT", "http://elasticsearch:9200/logs-1/_settings",
+		httpmock.NewStringResponder(200, `{"acknowledged":true}`))
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_cat/shards",
+		httpmock.NewStringResponder(200, `[{"index":"logs-1","ip":"10.0.0.1"},{"index":"logs-2","ip":"10.0.0.2"}]`))
+
+	esURL, _ := url.Parse("http://elasticsearch:9200")
+	eds := &zv1.ElasticsearchDataSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "logs",
+			Namespace:  "default",
+			Labels:     map[string]string{groupLabelKey: "a"},
+			Finalizers: []string{decommissionFinalizer},
+		},
+		Spec: zv1.ElasticsearchDataSetSpec{
+			Decommission: &zv1.ElasticsearchDataSetDecommission{
+				Enabled:      true,
+				TargetGroups: []string{"b"},
+			},
+		},
+	}
+	r := &EDSResource{
+		eds:      eds,
+		esClient: &ESClient{Endpoint: esURL},
+		recorder: record.NewFakeRecorder(10),
+	}
+	pods := []*v1.Pod{{Status: v1.PodStatus{PodIP: "10.0.0.1"}}}
+	var conditions []metav1.Condition
+
+	var blocked []string
+	require.NoError(t, r.decommission(context.Background(), pods, &conditions, &blocked))
+	condition := meta.FindStatusCondition(conditions, decommissionedConditionType)
+	require.Equal(t, metav1.ConditionFalse, condition.Status)
+	require.Equal(t, "Migrating", condition.Reason)
+	require.Equal(t, 1, httpmock.GetCallCountInfo()["PUT http://elasticsearch:9200/logs-1/_settings"])
+	require.True(t, hasFinalizer(r.eds, decommissionFinalizer))
+
+	// without group label
+	eds.Labels = nil
+	require.NoError(t, r.decommission(context.Background(), pods, &conditions, &blocked))
+	require.Equal(t, "Invalid", meta.FindStatusCondition(conditions, decommissionedConditionType).Reason)
+}
+
+func TestDecommissionIngestionPause(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_all/_settings/index.routing.allocation.include.group",
+		httpmock.NewStringResponder(200, `{"logs-1":{"settings":{"index.routing.allocation.include.group":"a"}},"logs-2":{"settings":{"index.routing.allocation.include.group":"a"}}}`))
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_alias/logs",
+		httpmock.NewStringResponder(200, `{"logs-1":{"aliases":{"logs":{"is_write_index":false}}},"logs-2":{"aliases":{"logs":{"is_write_index":true}}}}`))
+	httpmock.RegisterResponder("PUT", "http://elasticsearch:9200/logs-1/_settings",
+		httpmock.NewStringResponder(200, `{"acknowledged":true}`))
+	httpmock.RegisterResponder("PUT", "http://elasticsearch:9200/logs-2/_settings",
+		httpmock.NewStringResponder(200, `{"acknowledged":true}`))
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/logs-2/_settings/index.blocks.write",
+		httpmock.NewStringResponder(200, `{"logs-2":{"settings":{}}}`))
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_cat/shards",
+		httpmock.NewStringResponder(200, `[{"index":"logs-1","ip":"10.0.0.1"},{"index":"logs-2","ip":"10.0.0.1"}]`))
+
+	esURL, _ := url.Parse("http://elasticsearch:9200")
+	eds := &zv1.ElasticsearchDataSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "logs",
+			Namespace:  "default",
+			Labels:     map[string]string{groupLabelKey: "a"},
+			Finalizers: []string{decommissionFinalizer},
+		},
+		Spec: zv1.ElasticsearchDataSetSpec{
+			Decommission: &zv1.ElasticsearchDataSetDecommission{
+				Enabled:        true,
+				TargetGroups:   []string{"b"},
+				IngestionPause: &zv1.ElasticsearchDataSetIngestionPause{WriteAlias: "logs"},
+			},
+		},
+	}
+	recorder := record.NewFakeRecorder(10)
+	r := &EDSResource{
+		eds:      eds,
+		esClient: &ESClient{Endpoint: esURL},
+		recorder: recorder,
+	}
+	pods := []*v1.Pod{{Status: v1.PodStatus{PodIP: "10.0.0.1"}}}
+	var conditions []metav1.Condition
+
+	// paused before the write index is moved
+	var blocked []string
+	require.NoError(t, r.decommission(context.Background(), pods, &conditions, &blocked))
+	condition := meta.FindStatusCondition(conditions, ingestionPausedConditionType)
+	require.Equal(t, metav1.ConditionTrue, condition.Status)
+	require.Equal(t, zv1.ReasonSwitchover, condition.Reason)
+	require.Equal(t, "Normal PausedIngestion Paused ingestion into alias logs while write indices logs-2 are moved", <-recorder.Events)
+	// the write block and the allocation of both indices
+	require.Equal(t, 2, httpmock.GetCallCountInfo()["PUT http://elasticsearch:9200/logs-2/_settings"])
+	require.Equal(t, 1, httpmock.GetCallCountInfo()["PUT http://elasticsearch:9200/logs-1/_settings"])
+	require.Equal(t, []string{"logs-2"}, blocked)
+
+	// resumed once no shards of the write index are left on the pods. Only
+	// the blocked index is unblocked, not the write index rolled over to.
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_alias/logs",
+		httpmock.NewStringResponder(200, `{"logs-2":{"aliases":{"logs":{"is_write_index":false}}},"logs-3":{"aliases":{"logs":{"is_write_index":true}}}}`))
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_all/_settings/index.routing.allocation.include.group",
+		httpmock.NewStringResponder(200, `{"logs-1":{"settings":{"index.routing.allocation.include.group":"b"}},"logs-2":{"settings":{"index.routing.allocation.include.group":"b"}}}`))
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_cat/shards",
+		httpmock.NewStringResponder(200, `[{"index":"logs-1","ip":"10.0.0.1"},{"index":"logs-2","ip":"10.0.0.2"}]`))
+	require.NoError(t, r.decommission(context.Background(), pods, &conditions, &blocked))
+	condition = meta.FindStatusCondition(conditions, ingestionPausedConditionType)
+	require.Equal(t, metav1.ConditionFalse, condition.Status)
+	require.Equal(t, zv1.ReasonIngestionResumed, condition.Reason)
+	require.Equal(t, "Migrating", meta.FindStatusCondition(conditions, decommissionedConditionType).Reason)
+	require.Equal(t, 3, httpmock.GetCallCountInfo()["PUT http://elasticsearch:9200/logs-2/_settings"])
+	require.Empty(t, blocked)
+
+	// indices blocked by someone else are left alone.
+	conditions = nil
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/logs-2/_settings/index.blocks.write",
+		httpmock.NewStringResponder(200, `{"logs-2":{"settings":{"index.blocks.write":"true"}}}`))
+	require.NoError(t, r.setIngestionPaused(eds.Spec.Decommission.IngestionPause, []string{"logs-2"}, true, &conditions, &blocked))
+	require.Empty(t, blocked)
+	require.NoError(t, r.setIngestionPaused(eds.Spec.Decommission.IngestionPause, []string{"logs-2"}, false, &conditions, &blocked))
+	require.Equal(t, 3, httpmock.GetCallCountInfo()["PUT http://elasticsearch:9200/logs-2/_settings"])
+
+	// the pipeline is toggled instead of the write block
+	conditions = nil
+	eds.Spec.Decommission.IngestionPause.Pipeline = "pause"
+	httpmock.RegisterResponder("GET", "=~^http://elasticsearch:9200/_ingest/pipeline/",
+		httpmock.NewStringResponder(404, `{}`))
+	httpmock.RegisterResponder("PUT", "=~^http://elasticsearch:9200/_ingest/pipeline/",
+		httpmock.NewStringResponder(200, `{"acknowledged":true}`))
+	require.NoError(t, r.setIngestionPaused(eds.Spec.Decommission.IngestionPause, []string{"logs-2"}, true, &conditions, &blocked))
+	require.Equal(t, 1, httpmock.GetCallCountInfo()["PUT http://elasticsearch:9200/_ingest/pipeline/pause"])
+	require.Equal(t, 3, httpmock.GetCallCountInfo()["PUT http://elasticsearch:9200/logs-2/_settings"])
+}
@@ -0,0 +1,160 @@
+package operator
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	jsonpatch "gopkg.in/evanphx/json-patch.v4"
+	admissionv1 "k8s.io/api/admission/v1"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	// MutatingWebhookPath is the path of the defaulting admission webhook
+	// for ElasticsearchDataSets.
+	MutatingWebhookPath = "/mutate/elasticsearchdatasets"
+
+	defaultScaleUpCooldownSeconds   = 600
+	defaultScaleDownCooldownSeconds = 3600
+
+	// groupNodeAttribute is the environment variable setting the 'group'
+	// node attribute indices are allocated to.
+	groupNodeAttribute = "node.attr.group"
+)
+
+// jsonPatchOperation is an operation of a JSON patch (RFC 6902).
+type jsonPatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value"`
+}
+
+// MutatingWebhookHandler returns an http.Handler of the defaulting admission
+// webhook, which fills in defaults for fields left out of EDS when they are
+// created or updated. Fields which are set, even to their zero value, are
+// never changed.
+func MutatingWebhookHandler() http.Handler {
+	return admissionHandler(defaultElasticsearchDataSetRequest)
+}
+
+// defaultElasticsearchDataSetRequest returns the response to the admission
+// request of an EDS with a patch adding the defaults.
+func defaultElasticsearchDataSetRequest(request *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	response := &admissionv1.AdmissionResponse{UID: request.UID, Allowed: true}
+	if request.Operation != admissionv1.Create && request.Operation != admissionv1.Update {
+		return response
+	}
+
+	patch, err := elasticsearchDataSetDefaults(request.Object.Raw)
+	if err != nil || len(patch) == 0 {
+		// invalid objects are left to the validating webhook.
+		return response
+	}
+	data, err := json.Marshal(patch)
+	if err != nil {
+		return response
+	}
+	patchType := admissionv1.PatchTypeJSONPatch
+	response.Patch = data
+	response.PatchType = &patchType
+	return response
+}
+
+// ApplyElasticsearchDataSetDefaults returns the JSON of the EDS with the
+// defaults of the defaulting admission webhook applied, e.g. to render it
+// like the operator would see it once created.
+func ApplyElasticsearchDataSetDefaults(raw []byte) ([]byte, error) {
+	patch, err := elasticsearchDataSetDefaults(raw)
+	if err != nil || len(patch) == 0 {
+		return raw, err
+	}
+	data, err := json.Marshal(patch)
+	if err != nil {
+		return nil, err
+	}
+	decoded, err := jsonpatch.DecodePatch(data)
+	if err != nil {
+		return nil, err
+	}
+	return decoded.Apply(raw)
+}
+
+// elasticsearchDataSetDefaults returns the JSON patch adding the defaults to
+// the EDS:
+//
+//   - skipDraining is set to false explicitly.
+//   - The scale-up and scale-down cooldowns of an enabled scaling
+//     configuration are set to 10 minutes and 1 hour.
+//   - The readiness probe based on the health check is enabled if the
+//     Elasticsearch container has no readiness probe.
+//   - The 'node.attr.group' environment variable of the Elasticsearch
+//     container is set to the 'group' label of the EDS.
+func elasticsearchDataSetDefaults(raw []byte) ([]jsonPatchOperation, error) {
+	eds := &zv1.ElasticsearchDataSet{}
+	if err := json.Unmarshal(raw, eds); err != nil {
+		return nil, err
+	}
+	// the typed EDS can't tell fields left out from zero values.
+	var object struct {
+		Spec map[string]json.RawMessage `json:"spec"`
+	}
+	if err := json.Unmarshal(raw, &object); err != nil {
+		return nil, err
+	}
+	if object.Spec == nil {
+		return nil, fmt.Errorf("spec is missing")
+	}
+
+	var patch []jsonPatchOperation
+	add := func(path string, value interface{}) {
+		patch = append(patch, jsonPatchOperation{Op: "add", Path: path, Value: value})
+	}
+
+	if _, ok := object.Spec["skipDraining"]; !ok {
+		add("/spec/skipDraining", false)
+	}
+
+	if eds.Spec.Scaling != nil && eds.Spec.Scaling.Enabled {
+		var scaling map[string]json.RawMessage
+		if err := json.Unmarshal(object.Spec["scaling"], &scaling); err != nil {
+			return nil, err
+		}
+		if _, ok := scaling["scaleUpCooldownSeconds"]; !ok {
+			add("/spec/scaling/scaleUpCooldownSeconds", defaultScaleUpCooldownSeconds)
+		}
+		if _, ok := scaling["scaleDownCooldownSeconds"]; !ok {
+			add("/spec/scaling/scaleDownCooldownSeconds", defaultScaleDownCooldownSeconds)
+		}
+	}
+
+	containers := eds.Spec.Template.Spec.Containers
+	if len(containers) == 0 {
+		return patch, nil
+	}
+	elasticsearch := containers[0]
+
+	if eds.Spec.Health == nil && elasticsearch.ReadinessProbe == nil {
+		add("/spec/health", zv1.ElasticsearchDataSetHealth{ReadinessProbe: true})
+	}
+
+	if group, ok := eds.Labels[groupLabelKey]; ok && group != "" {
+		hasGroup := false
+		for _, env := range elasticsearch.Env {
+			if env.Name == groupNodeAttribute {
+				hasGroup = true
+				break
+			}
+		}
+		env := v1.EnvVar{Name: groupNodeAttribute, Value: group}
+		switch {
+		case hasGroup:
+		case len(elasticsearch.Env) == 0:
+			add("/spec/template/spec/containers/0/env", []v1.EnvVar{env})
+		default:
+			add("/spec/template/spec/containers/0/env/-", env)
+		}
+	}
+	return patch, nil
+}
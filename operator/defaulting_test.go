@@ -0,0 +1,81 @@
+package operator
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestElasticsearchDataSetDefaults(t *testing.T) {
+	paths := func(manifest string) map[string]string {
+		patch, err := elasticsearchDataSetDefaults([]byte(manifest))
+		require.NoError(t, err)
+		values := make(map[string]string, len(patch))
+		for _, op := range patch {
+			require.Equal(t, "add", op.Op)
+			value, err := json.Marshal(op.Value)
+			require.NoError(t, err)
+			values[op.Path] = string(value)
+		}
+		return values
+	}
+
+	require.Equal(t, map[string]string{
+		"/spec/skipDraining": "false",
+		"/spec/health":       `{"readinessProbe":true}`,
+	}, paths(`{"spec":{"template":{"spec":{"containers":[{"name":"elasticsearch"}]}}}}`))
+
+	// set fields are never changed, even to their zero value.
+	require.Empty(t, paths(`{"spec":{"skipDraining":false,"health":{},"scaling":{"enabled":false},"template":{"spec":{"containers":[{"name":"elasticsearch"}]}}}}`))
+	require.Empty(t, paths(`{"spec":{"skipDraining":true,"template":{"spec":{"containers":[{"name":"elasticsearch","readinessProbe":{"tcpSocket":{"port":9300}}}]}}}}`))
+
+	require.Equal(t, map[string]string{
+		"/spec/scaling/scaleDownCooldownSeconds": "3600",
+	}, paths(`{"spec":{"skipDraining":true,"health":{},"scaling":{"enabled":true,"scaleUpCooldownSeconds":0},"template":{"spec":{"containers":[{"name":"elasticsearch"}]}}}}`))
+
+	// the group node attribute.
+	require.Equal(t, map[string]string{
+		"/spec/template/spec/containers/0/env": `[{"name":"node.attr.group","value":"hot"}]`,
+	}, paths(`{"metadata":{"labels":{"group":"hot"}},"spec":{"skipDraining":true,"health":{},"template":{"spec":{"containers":[{"name":"elasticsearch"}]}}}}`))
+	require.Equal(t, map[string]string{
+		"/spec/template/spec/containers/0/env/-": `{"name":"node.attr.group","value":"hot"}`,
+	}, paths(`{"metadata":{"labels":{"group":"hot"}},"spec":{"skipDraining":true,"health":{},"template":{"spec":{"containers":[{"name":"elasticsearch","env":[{"name":"node.name"}]}]}}}}`))
+	require.Empty(t, paths(`{"metadata":{"labels":{"group":"hot"}},"spec":{"skipDraining":true,"health":{},"template":{"spec":{"containers":[{"name":"elasticsearch","env":[{"name":"node.attr.group","value":"warm"}]}]}}}}`))
+}
+
+func TestMutatingWebhook(t *testing.T) {
+	review := func(operation admissionv1.Operation, manifest string) *admissionv1.AdmissionResponse {
+		body, err := json.Marshal(&admissionv1.AdmissionReview{
+			Request: &admissionv1.AdmissionRequest{
+				UID:       "uid",
+				Operation: operation,
+				Object:    runtime.RawExtension{Raw: []byte(manifest)},
+			},
+		})
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		MutatingWebhookHandler().ServeHTTP(w, httptest.NewRequest(http.MethodPost, MutatingWebhookPath, bytes.NewReader(body)))
+		require.Equal(t, http.StatusOK, w.Code)
+
+		result := &admissionv1.AdmissionReview{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), result))
+		require.True(t, result.Response.Allowed)
+		return result.Response
+	}
+
+	response := review(admissionv1.Create, `{"spec":{"template":{"spec":{"containers":[{"name":"elasticsearch"}]}}}}`)
+	require.Equal(t, admissionv1.PatchTypeJSONPatch, *response.PatchType)
+	require.JSONEq(t, `[{"op":"add","path":"/spec/skipDraining","value":false},{"op":"add","path":"/spec/health","value":{"readinessProbe":true}}]`, string(response.Patch))
+
+	// nothing to default.
+	require.Nil(t, review(admissionv1.Update, `{"spec":{"skipDraining":true,"health":{},"template":{"spec":{"containers":[]}}}}`).Patch)
+
+	// invalid objects are left to the validating webhook.
+	require.Nil(t, review(admissionv1.Create, `{"spec":"invalid"}`).Patch)
+}
@@ -0,0 +1,149 @@
+package operator
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"runtime/pprof"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	defaultDecisionLogSize = 100
+)
+
+// Decision is a decision taken by the operator, e.g. to scale an EDS. The
+// most recent decisions are kept in memory for diagnostics.
+type Decision struct {
+	Time      time.Time `json:"time"`
+	Kind      string    `json:"kind"`
+	Namespace string    `json:"namespace"`
+	Name      string    `json:"name"`
+	Message   string    `json:"message"`
+}
+
+// decisionLog is a fixed size ring buffer of the most recent decisions.
+type decisionLog struct {
+	sync.Mutex
+	entries []Decision
+	next    int
+	size    int
+}
+
+func newDecisionLog(size int) *decisionLog {
+	return &decisionLog{
+		entries: make([]Decision, 0, size),
+		size:    size,
+	}
+}
+
+// Record adds a decision to the log, dropping the oldest decision if the log
+// is full.
+func (l *decisionLog) Record(kind, namespace, name, message string) {
+	l.Lock()
+	defer l.Unlock()
+
+	decision := Decision{
+		Time:      time.Now().UTC(),
+		Kind:      kind,
+		Namespace: namespace,
+		Name:      name,
+		Message:   message,
+	}
+
+	if len(l.entries) < l.size {
+		l.entries = append(l.entries, decision)
+		return
+	}
+	l.entries[l.next] = decision
+	l.next = (l.next + 1) % l.size
+}
+
+// List returns the decisions in the log from oldest to newest.
+func (l *decisionLog) List() []Decision {
+	l.Lock()
+	defer l.Unlock()
+
+	decisions := make([]Decision, 0, len(l.entries))
+	decisions = append(decisions, l.entries[l.next:]...)
+	decisions = append(decisions, l.entries[:l.next]...)
+	return decisions
+}
+
+// OperatingDiagnostics describes an EDS currently being reconciled.
+type OperatingDiagnostics struct {
+	Namespace string    `json:"namespace"`
+	Name      string    `json:"name"`
+	Started   time.Time `json:"started"`
+}
+
+// Diagnostics is a snapshot of the operator state useful for debugging
+// stuck reconciles.
+type Diagnostics struct {
+	Time       time.Time              `json:"time"`
+	Goroutines int                    `json:"goroutines"`
+	Operating  []OperatingDiagnostics `json:"operating"`
+	// OperatingLocked is true if the operating table could not be read
+	// because an EDS operation is being restarted.
+	OperatingLocked bool       `json:"operatingLocked,omitempty"`
+	Decisions       []Decision `json:"decisions"`
+	Stacks          string     `json:"stacks"`
+}
+
+// Diagnostics returns a snapshot of the operator state.
+func (o *ElasticsearchOperator) Diagnostics() *Diagnostics {
+	diagnostics := &Diagnostics{
+		Time:       time.Now().UTC(),
+		Goroutines: runtime.NumGoroutine(),
+		Operating:  []OperatingDiagnostics{},
+		Decisions:  o.decisions.List(),
+	}
+
+	// don't block on the lock as it's held while waiting for a previous
+	// operation to terminate, which is exactly when diagnostics are
+	// needed.
+	if o.TryLock() {
+		for _, entry := range o.operating {
+			diagnostics.Operating = append(diagnostics.Operating, OperatingDiagnostics{
+				Namespace: entry.namespace,
+				Name:      entry.name,
+				Started:   entry.started,
+			})
+		}
+		o.Unlock()
+	} else {
+		diagnostics.OperatingLocked = true
+	}
+
+	sort.Slice(diagnostics.Operating, func(i, j int) bool {
+		a, b := diagnostics.Operating[i], diagnostics.Operating[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+
+	var stacks bytes.Buffer
+	err := pprof.Lookup("goroutine").WriteTo(&stacks, 2)
+	if err != nil {
+		o.logger.Errorf("Failed to capture goroutine stacks: %v", err)
+	}
+	diagnostics.Stacks = stacks.String()
+
+	return diagnostics
+}
+
+// DiagnosticsHandler returns an http.Handler serving the operator
+// diagnostics as JSON.
+func (o *ElasticsearchOperator) DiagnosticsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		err := json.NewEncoder(w).Encode(o.Diagnostics())
+		if err != nil {
+			o.logger.Errorf("Failed to write diagnostics: %v", err)
+		}
+	})
+}
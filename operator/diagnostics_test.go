@@ -0,0 +1,58 @@
+package operator
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestDecisionLog(t *testing.T) {
+	decisions := newDecisionLog(3)
+	assert.Empty(t, decisions.List())
+
+	for i := 0; i < 5; i++ {
+		decisions.Record("Scaling", "default", fmt.Sprintf("eds-%d", i), "")
+	}
+
+	names := []string{}
+	for _, d := range decisions.List() {
+		names = append(names, d.Name)
+	}
+	assert.Equal(t, []string{"eds-2", "eds-3", "eds-4"}, names)
+}
+
+func TestDiagnosticsHandler(t *testing.T) {
+	o := &ElasticsearchOperator{
+		operating: map[types.UID]operatingEntry{
+			"b": {namespace: "default", name: "eds-b"},
+			"a": {namespace: "default", name: "eds-a"},
+		},
+		decisions: newDecisionLog(defaultDecisionLogSize),
+	}
+	o.decisions.Record("Scaling", "default", "eds-a", "UP to 3 replicas.")
+
+	rec := httptest.NewRecorder()
+	o.DiagnosticsHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/diagnostics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var diagnostics Diagnostics
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &diagnostics))
+	require.Len(t, diagnostics.Operating, 2)
+	assert.Equal(t, "eds-a", diagnostics.Operating[0].Name)
+	assert.Equal(t, "eds-b", diagnostics.Operating[1].Name)
+	assert.False(t, diagnostics.OperatingLocked)
+	require.Len(t, diagnostics.Decisions, 1)
+	assert.Equal(t, "UP to 3 replicas.", diagnostics.Decisions[0].Message)
+	assert.Contains(t, diagnostics.Stacks, "goroutine")
+
+	// diagnostics don't block while an operation is being restarted.
+	o.Lock()
+	defer o.Unlock()
+	assert.True(t, o.Diagnostics().OperatingLocked)
+}
@@ -0,0 +1,312 @@
+package operator
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	floodStageWatermarkSetting = "cluster.routing.allocation.disk.watermark.flood_stage"
+	// defaultFloodStageWatermark is the default of the flood-stage
+	// watermark.
+	defaultFloodStageWatermark = "95%"
+	defaultDiskHeadroomPercent = 10
+)
+
+// ESNodeDisk is the disk usage of a node.
+type ESNodeDisk struct {
+	IP         string
+	UsedBytes  int64
+	TotalBytes int64
+}
+
+// ESShardCopy is a shard copy allocated to a node.
+type ESShardCopy struct {
+	Index string
+	IP    string
+	Bytes int64
+}
+
+// GetNodesDisk returns the disk usage of the data nodes from _cat/allocation.
+func (c *ESClient) GetNodesDisk() ([]ESNodeDisk, error) {
+	resp, err := c.httpClient().R().
+		Get(c.Endpoint.String() + "/_cat/allocation?h=ip,disk.used,disk.total&bytes=b&format=json")
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, fmt.Errorf("code status %d - %s", resp.StatusCode(), resp.Body())
+	}
+
+	var allocation []struct {
+		IP    string `json:"ip"`
+		Used  string `json:"disk.used"`
+		Total string `json:"disk.total"`
+	}
+	err = json.Unmarshal(resp.Body(), &allocation)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make([]ESNodeDisk, 0, len(allocation))
+	for _, node := range allocation {
+		// unassigned shards are reported without a node.
+		if node.IP == "" || node.Total == "" {
+			continue
+		}
+		used, err := strconv.ParseInt(node.Used, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse disk usage of node %s: %v", node.IP, err)
+		}
+		total, err := strconv.ParseInt(node.Total, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse disk size of node %s: %v", node.IP, err)
+		}
+		nodes = append(nodes, ESNodeDisk{IP: node.IP, UsedBytes: used, TotalBytes: total})
+	}
+	return nodes, nil
+}
+
+// GetShardCopies returns the size of the assigned shard copies in the
+// cluster.
+func (c *ESClient) GetShardCopies() ([]ESShardCopy, error) {
+	resp, err := c.httpClient().R().
+		Get(c.Endpoint.String() + "/_cat/shards?h=index,ip,store&bytes=b&format=json")
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, fmt.Errorf("code status %d - %s", resp.StatusCode(), resp.Body())
+	}
+
+	var esShards []struct {
+		Index string `json:"index"`
+		IP    string `json:"ip"`
+		Store string `json:"store"`
+	}
+	err = json.Unmarshal(resp.Body(), &esShards)
+	if err != nil {
+		return nil, err
+	}
+
+	shards := make([]ESShardCopy, 0, len(esShards))
+	for _, shard := range esShards {
+		if shard.IP == "" || shard.Store == "" {
+			continue
+		}
+		size, err := strconv.ParseInt(shard.Store, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		shards = append(shards, ESShardCopy{Index: shard.Index, IP: shard.IP, Bytes: size})
+	}
+	return shards, nil
+}
+
+// GetFloodStageWatermark returns the effective flood-stage watermark.
+func (c *ESClient) GetFloodStageWatermark() (string, error) {
+	resp, err := c.httpClient().R().
+		Get(c.Endpoint.String() + "/_cluster/settings?include_defaults=true&flat_settings=true")
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return "", fmt.Errorf("code status %d - %s", resp.StatusCode(), resp.Body())
+	}
+
+	var settings struct {
+		Persistent map[string]string `json:"persistent"`
+		Transient  map[string]string `json:"transient"`
+		Defaults   map[string]string `json:"defaults"`
+	}
+	err = json.Unmarshal(resp.Body(), &settings)
+	if err != nil {
+		return "", err
+	}
+
+	watermark := defaultFloodStageWatermark
+	for _, values := range []map[string]string{settings.Defaults, settings.Persistent, settings.Transient} {
+		if value, ok := values[floodStageWatermarkSetting]; ok {
+			watermark = value
+		}
+	}
+	return watermark, nil
+}
+
+// byteSizeUnits are the units of byte sizes in Elasticsearch settings.
+var byteSizeUnits = []struct {
+	suffix     string
+	multiplier int64
+}{
+	{"pb", 1 << 50},
+	{"tb", 1 << 40},
+	{"gb", 1 << 30},
+	{"mb", 1 << 20},
+	{"kb", 1 << 10},
+	{"b", 1},
+}
+
+// parseByteSize parses a byte size setting like '500mb'.
+func parseByteSize(value string) (int64, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	for _, unit := range byteSizeUnits {
+		if number, ok := strings.CutSuffix(value, unit.suffix); ok {
+			size, err := strconv.ParseFloat(number, 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid byte size '%s'", value)
+			}
+			return int64(size * float64(unit.multiplier)), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid byte size '%s'", value)
+}
+
+// watermarkBytes returns the disk usage in bytes of a disk of the size at
+// the watermark. Watermarks are either a percentage or ratio of used disk
+// space or an absolute amount of free disk space.
+func watermarkBytes(watermark string, totalBytes int64) (int64, error) {
+	if percent, ok := strings.CutSuffix(watermark, "%"); ok {
+		value, err := strconv.ParseFloat(percent, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid watermark '%s'", watermark)
+		}
+		return int64(float64(totalBytes) * value / 100), nil
+	}
+	if ratio, err := strconv.ParseFloat(watermark, 64); err == nil {
+		return int64(float64(totalBytes) * ratio), nil
+	}
+	free, err := parseByteSize(watermark)
+	if err != nil {
+		return 0, fmt.Errorf("invalid watermark '%s'", watermark)
+	}
+	if free > totalBytes {
+		return 0, nil
+	}
+	return totalBytes - free, nil
+}
+
+// projectedDiskUsage returns the disk usage of each remaining node, keyed by
+// IP, once the shards of the removed nodes are moved to them and the
+// removed index replicas are deleted. The moved bytes are assumed to spread
+// evenly across the remaining nodes.
+func projectedDiskUsage(nodes []ESNodeDisk, shards []ESShardCopy, removedIPs map[string]struct{}, managedIndices map[string]ESIndex, operation *ScalingOperation) map[string]int64 {
+	remaining := make([]ESNodeDisk, 0, len(nodes))
+	for _, node := range nodes {
+		if _, ok := removedIPs[node.IP]; !ok {
+			remaining = append(remaining, node)
+		}
+	}
+	if len(remaining) == 0 {
+		return nil
+	}
+
+	// shrinking indices lose the size of a copy per removed replica.
+	reducedReplicas := make(map[string]int32, len(operation.IndexReplicas))
+	for _, index := range operation.IndexReplicas {
+		if current, ok := managedIndices[index.Index]; ok && index.Replicas < current.Replicas {
+			reducedReplicas[index.Index] = current.Replicas - index.Replicas
+		}
+	}
+
+	moved := int64(0)
+	indexBytes := make(map[string]int64)
+	for _, shard := range shards {
+		indexBytes[shard.Index] += shard.Bytes
+		if _, ok := removedIPs[shard.IP]; ok {
+			moved += shard.Bytes
+		}
+	}
+	for index, reduced := range reducedReplicas {
+		copies := managedIndices[index].Replicas + 1
+		moved -= indexBytes[index] * int64(reduced) / int64(copies)
+	}
+	if moved < 0 {
+		moved = 0
+	}
+
+	share := moved / int64(len(remaining))
+	usage := make(map[string]int64, len(remaining))
+	for _, node := range remaining {
+		usage[node.IP] = node.UsedBytes + share
+	}
+	return usage
+}
+
+// reviewDiskHeadroom turns a scale-down into a no-op if the projected disk
+// usage of a remaining node plus the headroom would exceed the flood-stage
+// watermark, at which Elasticsearch blocks writes to the indices on the
+// node.
+func (as *AutoScaler) reviewDiskHeadroom(managedIndices map[string]ESIndex, operation *ScalingOperation) (*ScalingOperation, error) {
+	config := as.eds.Spec.Scaling.DiskHeadroom
+	if config == nil || operation.ScalingDirection != DOWN || operation.NodeReplicas == nil {
+		return operation, nil
+	}
+
+	removedIPs := make(map[string]struct{})
+	for _, pod := range as.pods {
+		ordinal, err := strconv.Atoi(strings.TrimPrefix(pod.Name, as.eds.Name+"-"))
+		if err != nil || pod.Status.PodIP == "" {
+			continue
+		}
+		if int32(ordinal) >= *operation.NodeReplicas {
+			removedIPs[pod.Status.PodIP] = struct{}{}
+		}
+	}
+	if len(removedIPs) == 0 {
+		return operation, nil
+	}
+
+	nodes, err := as.esClient.GetNodesDisk()
+	if err != nil {
+		return nil, err
+	}
+	shards, err := as.esClient.GetShardCopies()
+	if err != nil {
+		return nil, err
+	}
+	watermark, err := as.esClient.GetFloodStageWatermark()
+	if err != nil {
+		return nil, err
+	}
+
+	// only the nodes of the EDS receive the shards of the removed nodes.
+	managedIPs := make(map[string]struct{}, len(as.pods))
+	for _, pod := range as.pods {
+		if pod.Status.PodIP != "" {
+			managedIPs[pod.Status.PodIP] = struct{}{}
+		}
+	}
+	managedNodes := make([]ESNodeDisk, 0, len(nodes))
+	for _, node := range nodes {
+		if _, ok := managedIPs[node.IP]; ok {
+			managedNodes = append(managedNodes, node)
+		}
+	}
+
+	headroom := int64(defaultDiskHeadroomPercent)
+	if config.HeadroomPercent != nil {
+		headroom = int64(*config.HeadroomPercent)
+	}
+	usage := projectedDiskUsage(managedNodes, shards, removedIPs, managedIndices, operation)
+	for _, node := range managedNodes {
+		projected, ok := usage[node.IP]
+		if !ok {
+			continue
+		}
+		limit, err := watermarkBytes(watermark, node.TotalBytes)
+		if err != nil {
+			return nil, err
+		}
+		projected += projected * headroom / 100
+		if projected > limit {
+			operation = noopScalingOperation(fmt.Sprintf("Not scaling down, the projected disk usage of node %s of %.1f%% including %d%% headroom would exceed the flood-stage watermark of %s.",
+				node.IP, float64(projected)*100/float64(node.TotalBytes), headroom, watermark))
+			as.logger.Info(operation.Description)
+			return operation, nil
+		}
+	}
+	return operation, nil
+}
@@ -0,0 +1,110 @@
+package operator
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestWatermarkBytes(t *testing.T) {
+	for _, tc := range []struct {
+		watermark string
+		expected  int64
+	}{
+		{"95%", 950},
+		{"0.9", 900},
+		{"100b", 900},
+		{"1kb", 0},
+	} {
+		actual, err := watermarkBytes(tc.watermark, 1000)
+		require.NoError(t, err, tc.watermark)
+		require.Equal(t, tc.expected, actual, tc.watermark)
+	}
+
+	_, err := watermarkBytes("lots", 1000)
+	require.Error(t, err)
+}
+
+func TestProjectedDiskUsage(t *testing.T) {
+	nodes := []ESNodeDisk{
+		{IP: "1.1.1.1", UsedBytes: 500, TotalBytes: 1000},
+		{IP: "1.1.1.2", UsedBytes: 400, TotalBytes: 1000},
+		{IP: "1.1.1.3", UsedBytes: 300, TotalBytes: 1000},
+	}
+	shards := []ESShardCopy{
+		{Index: "logs", IP: "1.1.1.1", Bytes: 100},
+		{Index: "logs", IP: "1.1.1.2", Bytes: 100},
+		{Index: "logs", IP: "1.1.1.3", Bytes: 100},
+		{Index: "other", IP: "1.1.1.3", Bytes: 200},
+	}
+	removed := map[string]struct{}{"1.1.1.3": {}}
+	managedIndices := map[string]ESIndex{"logs": {Index: "logs", Primaries: 1, Replicas: 2}}
+
+	require.Equal(t, map[string]int64{"1.1.1.1": 650, "1.1.1.2": 550},
+		projectedDiskUsage(nodes, shards, removed, managedIndices, &ScalingOperation{}))
+
+	// dropping an index replica frees the size of a copy.
+	operation := &ScalingOperation{IndexReplicas: []ESIndex{{Index: "logs", Primaries: 1, Replicas: 1}}}
+	require.Equal(t, map[string]int64{"1.1.1.1": 600, "1.1.1.2": 500},
+		projectedDiskUsage(nodes, shards, removed, managedIndices, operation))
+}
+
+func TestReviewDiskHeadroom(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_cat/allocation",
+		httpmock.NewStringResponder(200, `[{"ip":"1.1.1.1","disk.used":"600","disk.total":"1000"},{"ip":"1.1.1.2","disk.used":"500","disk.total":"1000"},{"ip":"1.1.1.3","disk.used":"400","disk.total":"1000"},{"ip":null,"disk.used":null,"disk.total":null}]`))
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_cat/shards",
+		httpmock.NewStringResponder(200, `[{"index":"logs","ip":"1.1.1.1","store":"300"},{"index":"logs","ip":"1.1.1.2","store":"300"},{"index":"logs","ip":"1.1.1.3","store":"300"},{"index":"logs","ip":null,"store":null}]`))
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_cluster/settings",
+		httpmock.NewStringResponder(200, `{"persistent":{},"transient":{},"defaults":{"cluster.routing.allocation.disk.watermark.flood_stage":"95%"}}`))
+
+	esURL, _ := url.Parse("http://elasticsearch:9200")
+	replicas := int32(3)
+	headroom := int32(0)
+	pod := func(name, ip string) v1.Pod {
+		return v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name}, Status: v1.PodStatus{PodIP: ip}}
+	}
+	as := &AutoScaler{
+		logger: log.WithField("eds", "logs"),
+		eds: &zv1.ElasticsearchDataSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "logs"},
+			Spec: zv1.ElasticsearchDataSetSpec{
+				Replicas: &replicas,
+				Scaling: &zv1.ElasticsearchDataSetScaling{
+					DiskHeadroom: &zv1.ElasticsearchDataSetDiskHeadroom{HeadroomPercent: &headroom},
+				},
+			},
+		},
+		pods:     []v1.Pod{pod("logs-0", "1.1.1.1"), pod("logs-1", "1.1.1.2"), pod("logs-2", "1.1.1.3")},
+		esClient: &ESClient{Endpoint: esURL},
+	}
+	scaleDown := func(nodes int32) *ScalingOperation {
+		return &ScalingOperation{ScalingDirection: DOWN, NodeReplicas: &nodes}
+	}
+
+	// 150 bytes move to each remaining node: 750 and 650 of 1000.
+	operation, err := as.reviewDiskHeadroom(nil, scaleDown(2))
+	require.NoError(t, err)
+	require.Equal(t, DOWN, operation.ScalingDirection)
+
+	// 750 bytes with 30% headroom exceed the watermark.
+	headroom = 30
+	operation, err = as.reviewDiskHeadroom(nil, scaleDown(2))
+	require.NoError(t, err)
+	require.Equal(t, NONE, operation.ScalingDirection)
+	require.Contains(t, operation.Description, "1.1.1.1")
+
+	// the review is disabled without config.
+	as.eds.Spec.Scaling.DiskHeadroom = nil
+	operation, err = as.reviewDiskHeadroom(nil, scaleDown(2))
+	require.NoError(t, err)
+	require.Equal(t, DOWN, operation.ScalingDirection)
+}
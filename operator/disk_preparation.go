@@ -0,0 +1,99 @@
+package operator
+
+import (
+	"fmt"
+	"strings"
+
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	diskPreparationContainer = "es-operator-disk-preparation"
+	defaultDataPath          = "/usr/share/elasticsearch/data"
+
+	// diskPreparationMarker is written to the data volume once it's
+	// pre-touched.
+	diskPreparationMarker = ".es-operator-pretouched"
+
+	noatimeWarn    = "warn"
+	noatimeRequire = "require"
+	noatimeSkip    = "skip"
+)
+
+// diskPreparationScript checks the mount options of the data volume at the
+// path and pre-touches the volume once by writing and removing a file of
+// the size in MiB. Failures to pre-touch don't fail the pod.
+func diskPreparationScript(path string, preTouchMiB int64, noatime string) string {
+	var script strings.Builder
+	fmt.Fprintf(&script, "dir=%s\n", shellQuote(path))
+	if noatime != noatimeSkip {
+		script.WriteString(`opts=$(grep " $dir " /proc/mounts | tail -n 1 | cut -d " " -f 4)
+case ",$opts," in
+  *,noatime,*) ;;
+  *)
+    echo "The data volume at $dir is mounted without noatime: $opts"
+`)
+		if noatime == noatimeRequire {
+			script.WriteString("    exit 1\n")
+		}
+		script.WriteString("    ;;\nesac\n")
+	}
+	if preTouchMiB > 0 {
+		fmt.Fprintf(&script, `if [ ! -f "$dir/%[1]s" ]; then
+  echo "Pre-touching %[2]d MiB of the data volume at $dir"
+  if dd if=/dev/zero of="$dir/%[1]s.tmp" bs=1M count=%[2]d conv=fsync 2>/dev/null; then
+    touch "$dir/%[1]s"
+  else
+    echo "Failed to pre-touch the data volume at $dir"
+  fi
+  rm -f "$dir/%[1]s.tmp"
+fi
+`, diskPreparationMarker, preTouchMiB)
+	}
+	return script.String()
+}
+
+// injectDiskPreparation adds an init container to the pod template which
+// prepares the data volume mounted into the Elasticsearch container.
+// Nothing is injected if the path isn't a volume mount of the container.
+func injectDiskPreparation(spec *v1.PodSpec, config *zv1.ElasticsearchDataSetDiskPreparation) {
+	if config == nil || !config.Enabled || len(spec.Containers) == 0 {
+		return
+	}
+
+	path := config.MountPath
+	if path == "" {
+		path = defaultDataPath
+	}
+	container := &spec.Containers[0]
+	var mount *v1.VolumeMount
+	for i := range container.VolumeMounts {
+		if container.VolumeMounts[i].MountPath == path {
+			mount = &container.VolumeMounts[i]
+			break
+		}
+	}
+	if mount == nil {
+		return
+	}
+
+	preTouchMiB := int64(0)
+	if config.PreTouchSize != nil {
+		preTouchMiB = config.PreTouchSize.Value() >> 20
+	}
+	noatime := config.Noatime
+	if noatime == "" {
+		noatime = noatimeWarn
+	}
+
+	spec.InitContainers = append(spec.InitContainers, v1.Container{
+		Name:            diskPreparationContainer,
+		Image:           container.Image,
+		ImagePullPolicy: container.ImagePullPolicy,
+		Command:         []string{"sh", "-c", diskPreparationScript(path, preTouchMiB, noatime)},
+		VolumeMounts: []v1.VolumeMount{
+			{Name: mount.Name, MountPath: path, SubPath: mount.SubPath},
+		},
+	})
+}
@@ -0,0 +1,52 @@
+package operator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestDiskPreparationScript(t *testing.T) {
+	script := diskPreparationScript(defaultDataPath, 512, noatimeWarn)
+	require.Contains(t, script, "dir='/usr/share/elasticsearch/data'\n")
+	require.Contains(t, script, "mounted without noatime")
+	require.NotContains(t, script, "exit 1")
+	require.Contains(t, script, "bs=1M count=512 conv=fsync")
+	require.Contains(t, script, `touch "$dir/.es-operator-pretouched"`)
+
+	script = diskPreparationScript(defaultDataPath, 0, noatimeRequire)
+	require.Contains(t, script, "exit 1")
+	require.NotContains(t, script, "dd ")
+
+	script = diskPreparationScript(defaultDataPath, 0, noatimeSkip)
+	require.NotContains(t, script, "/proc/mounts")
+}
+
+func TestInjectDiskPreparation(t *testing.T) {
+	spec := &v1.PodSpec{Containers: []v1.Container{{
+		Name:  "elasticsearch",
+		Image: "es:8",
+		VolumeMounts: []v1.VolumeMount{
+			{Name: "config", MountPath: elasticsearchConfigPath},
+			{Name: "data", MountPath: defaultDataPath, SubPath: "es"},
+		},
+	}}}
+
+	injectDiskPreparation(spec, nil)
+	require.Empty(t, spec.InitContainers)
+	injectDiskPreparation(spec, &zv1.ElasticsearchDataSetDiskPreparation{Enabled: true, MountPath: "/data"})
+	require.Empty(t, spec.InitContainers)
+
+	size := resource.MustParse("1Gi")
+	injectDiskPreparation(spec, &zv1.ElasticsearchDataSetDiskPreparation{Enabled: true, PreTouchSize: &size})
+	require.Len(t, spec.InitContainers, 1)
+	container := spec.InitContainers[0]
+	require.Equal(t, diskPreparationContainer, container.Name)
+	require.Equal(t, "es:8", container.Image)
+	require.Equal(t, []v1.VolumeMount{{Name: "data", MountPath: defaultDataPath, SubPath: "es"}}, container.VolumeMounts)
+	require.Contains(t, container.Command[2], "count=1024 ")
+	require.Contains(t, container.Command[2], "mounted without noatime")
+}
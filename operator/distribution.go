@@ -0,0 +1,145 @@
+package operator
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+)
+
+const (
+	distributionElasticsearch = "elasticsearch"
+	distributionOpenSearch    = "opensearch"
+
+	// clusterManagerMinimumOpenSearchMajorVersion is the first version of
+	// OpenSearch with the _cat/cluster_manager API replacing _cat/master.
+	clusterManagerMinimumOpenSearchMajorVersion = 2
+)
+
+// esInfo represents the response of the root endpoint.
+type esInfo struct {
+	Version struct {
+		Number string `json:"number"`
+		// Distribution is only set by OpenSearch.
+		Distribution string `json:"distribution"`
+	} `json:"version"`
+}
+
+// majorVersion returns the major version of the cluster.
+func (i *esInfo) majorVersion() (int, error) {
+	major, err := strconv.Atoi(strings.SplitN(i.Version.Number, ".", 2)[0])
+	if err != nil {
+		return 0, fmt.Errorf("invalid version '%s': %v", i.Version.Number, err)
+	}
+	return major, nil
+}
+
+// atLeast returns true if the version of the cluster is at least
+// major.minor.
+func (i *esInfo) atLeast(major, minor int) (bool, error) {
+	actualMajor, err := i.majorVersion()
+	if err != nil {
+		return false, err
+	}
+	if actualMajor != major {
+		return actualMajor > major, nil
+	}
+	parts := strings.SplitN(i.Version.Number, ".", 3)
+	if len(parts) < 2 {
+		return minor == 0, nil
+	}
+	actualMinor, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return false, fmt.Errorf("invalid version '%s': %v", i.Version.Number, err)
+	}
+	return actualMinor >= minor, nil
+}
+
+// distribution returns the distribution of the cluster.
+func (i *esInfo) distribution() string {
+	if strings.EqualFold(i.Version.Distribution, distributionOpenSearch) {
+		return distributionOpenSearch
+	}
+	return distributionElasticsearch
+}
+
+// edsDistribution returns the distribution configured for the EDS, or an
+// empty string if it's detected.
+func edsDistribution(eds *zv1.ElasticsearchDataSet) string {
+	if eds.Spec.Elasticsearch == nil {
+		return ""
+	}
+	return eds.Spec.Elasticsearch.Distribution
+}
+
+// getInfo returns the version of the cluster. It's only requested once per
+// client, as the version doesn't change without an update of the EDS.
+func (c *ESClient) getInfo() (*esInfo, error) {
+	c.infoMux.Lock()
+	defer c.infoMux.Unlock()
+	if c.info != nil {
+		return c.info, nil
+	}
+
+	resp, err := c.httpClient().R().
+		Get(c.Endpoint.String() + "/")
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, fmt.Errorf("code status %d - %s", resp.StatusCode(), resp.Body())
+	}
+
+	var info esInfo
+	err = json.Unmarshal(resp.Body(), &info)
+	if err != nil {
+		return nil, err
+	}
+	c.info = &info
+	return c.info, nil
+}
+
+// getDistribution returns the configured distribution of the cluster, or
+// detects it from its version.
+func (c *ESClient) getDistribution() (string, error) {
+	if c.distribution != "" {
+		return c.distribution, nil
+	}
+	info, err := c.getInfo()
+	if err != nil {
+		return "", fmt.Errorf("failed to detect distribution: %v", err)
+	}
+	return info.distribution(), nil
+}
+
+// isOpenSearch returns true if the cluster is an OpenSearch cluster.
+func (c *ESClient) isOpenSearch() (bool, error) {
+	distribution, err := c.getDistribution()
+	if err != nil {
+		return false, err
+	}
+	return distribution == distributionOpenSearch, nil
+}
+
+// catMasterPath returns the path of the cat API of the elected master,
+// which OpenSearch 2 renamed to _cat/cluster_manager.
+func (c *ESClient) catMasterPath() (string, error) {
+	openSearch, err := c.isOpenSearch()
+	if err != nil {
+		return "", err
+	}
+	if !openSearch {
+		return "/_cat/master", nil
+	}
+	major, err := c.GetMajorVersion()
+	if err != nil {
+		return "", err
+	}
+	if major >= clusterManagerMinimumOpenSearchMajorVersion {
+		return "/_cat/cluster_manager", nil
+	}
+	return "/_cat/master", nil
+}
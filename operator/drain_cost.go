@@ -0,0 +1,200 @@
+package operator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// drainCostGiBAnnotationKey is the data in GiB, rounded up, which is
+	// moved by draining the pod.
+	drainCostGiBAnnotationKey = "es-operator.zalando.org/drain-cost-gib"
+	// drainCostPrimariesAnnotationKey is the number of primary shards on
+	// the pod, which are promoted elsewhere when it's drained.
+	drainCostPrimariesAnnotationKey = "es-operator.zalando.org/drain-cost-primaries"
+
+	gib = 1 << 30
+)
+
+var (
+	podDrainCostBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "es_operator",
+		Subsystem: "pod",
+		Name:      "drain_cost_bytes",
+		Help:      "Size of the shards which would be moved by draining a pod of an EDS.",
+	}, []string{"namespace", "eds", "pod"})
+	podDrainCostPrimaries = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "es_operator",
+		Subsystem: "pod",
+		Name:      "drain_cost_primaries",
+		Help:      "Number of primary shards on a pod of an EDS.",
+	}, []string{"namespace", "eds", "pod"})
+)
+
+func init() {
+	prometheus.MustRegister(podDrainCostBytes, podDrainCostPrimaries)
+}
+
+// DrainCost is the cost of draining a node.
+type DrainCost struct {
+	Bytes     int64
+	Shards    int
+	Primaries int
+}
+
+// GiB returns the size of the shards in GiB, rounded up.
+func (c DrainCost) GiB() int64 {
+	return (c.Bytes + gib - 1) / gib
+}
+
+// GetDrainCosts returns the drain cost of the nodes by IP, based on the
+// shard copies allocated to them.
+func (c *ESClient) GetDrainCosts() (map[string]DrainCost, error) {
+	resp, err := c.httpClient().R().
+		Get(c.Endpoint.String() + "/_cat/shards?h=ip,prirep,store&bytes=b&format=json")
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, fmt.Errorf("code status %d - %s", resp.StatusCode(), resp.Body())
+	}
+
+	var shards []struct {
+		IP     string `json:"ip"`
+		PriRep string `json:"prirep"`
+		Store  string `json:"store"`
+	}
+	err = json.Unmarshal(resp.Body(), &shards)
+	if err != nil {
+		return nil, err
+	}
+
+	costs := make(map[string]DrainCost)
+	for _, shard := range shards {
+		// unassigned shards are reported without a node.
+		if shard.IP == "" {
+			continue
+		}
+		cost := costs[shard.IP]
+		cost.Shards++
+		if shard.PriRep == "p" {
+			cost.Primaries++
+		}
+		// initializing shards have no store size.
+		if shard.Store != "" {
+			size, err := strconv.ParseInt(shard.Store, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse size of shard on node %s: %v", shard.IP, err)
+			}
+			cost.Bytes += size
+		}
+		costs[shard.IP] = cost
+	}
+	return costs, nil
+}
+
+// podDrainCost returns the drain cost of the pod from its annotations. Pods
+// without annotations, e.g. new pods, are free to drain.
+func podDrainCost(pod *v1.Pod) (int64, int64) {
+	size, _ := strconv.ParseInt(pod.Annotations[drainCostGiBAnnotationKey], 10, 64)
+	primaries, _ := strconv.ParseInt(pod.Annotations[drainCostPrimariesAnnotationKey], 10, 64)
+	return size, primaries
+}
+
+// deleteDrainCostMetrics deletes the drain cost metrics of the pods of the
+// EDS.
+func deleteDrainCostMetrics(namespace, name string) {
+	labels := prometheus.Labels{"namespace": namespace, "eds": name}
+	podDrainCostBytes.DeletePartialMatch(labels)
+	podDrainCostPrimaries.DeletePartialMatch(labels)
+}
+
+// runDrainCosts periodically publishes the drain cost of the pods of all
+// EDS.
+func (o *ElasticsearchOperator) runDrainCosts(ctx context.Context) {
+	nextCheck := time.Now().Add(-o.interval)
+	known := make(map[types.NamespacedName]struct{})
+
+	for {
+		o.logger.Debug("Checking drain costs")
+		select {
+		case <-time.After(time.Until(nextCheck)):
+			nextCheck = time.Now().Add(o.interval)
+
+			resources, err := o.collectResources(ctx)
+			if err != nil {
+				o.logger.Error(err)
+				continue
+			}
+
+			seen := make(map[types.NamespacedName]struct{}, len(resources))
+			for _, es := range resources {
+				seen[types.NamespacedName{Namespace: es.ElasticsearchDataSet.Namespace, Name: es.ElasticsearchDataSet.Name}] = struct{}{}
+				err := o.publishDrainCosts(ctx, es)
+				if err != nil {
+					o.logger.Errorf("Failed to publish drain costs of EDS %s/%s: %v", es.ElasticsearchDataSet.Namespace, es.ElasticsearchDataSet.Name, err)
+				}
+			}
+
+			for key := range known {
+				if _, ok := seen[key]; !ok {
+					deleteDrainCostMetrics(key.Namespace, key.Name)
+				}
+			}
+			known = seen
+		case <-ctx.Done():
+			o.logger.Info("Terminating drain cost loop.")
+			return
+		}
+	}
+}
+
+// publishDrainCosts annotates the pods of the EDS with their drain cost and
+// exports it as metrics. Pods are only patched if the rounded cost changed.
+func (o *ElasticsearchOperator) publishDrainCosts(ctx context.Context, es *ESResource) error {
+	eds := es.ElasticsearchDataSet
+	client := &ESClient{Endpoint: o.getElasticsearchEndpoint(eds), transport: o.elasticsearchTransport(eds)}
+	costs, err := client.GetDrainCosts()
+	if err != nil {
+		return err
+	}
+
+	deleteDrainCostMetrics(eds.Namespace, eds.Name)
+	for _, pod := range es.Pods {
+		if pod.Status.PodIP == "" || pod.DeletionTimestamp != nil {
+			continue
+		}
+		cost := costs[pod.Status.PodIP]
+		podDrainCostBytes.WithLabelValues(eds.Namespace, eds.Name, pod.Name).Set(float64(cost.Bytes))
+		podDrainCostPrimaries.WithLabelValues(eds.Namespace, eds.Name, pod.Name).Set(float64(cost.Primaries))
+
+		annotations := map[string]string{
+			drainCostGiBAnnotationKey:       strconv.FormatInt(cost.GiB(), 10),
+			drainCostPrimariesAnnotationKey: strconv.Itoa(cost.Primaries),
+		}
+		if pod.Annotations[drainCostGiBAnnotationKey] == annotations[drainCostGiBAnnotationKey] &&
+			pod.Annotations[drainCostPrimariesAnnotationKey] == annotations[drainCostPrimariesAnnotationKey] {
+			continue
+		}
+		patch, err := json.Marshal(map[string]interface{}{
+			"metadata": map[string]interface{}{"annotations": annotations},
+		})
+		if err != nil {
+			return err
+		}
+		_, err = o.kube.CoreV1().Pods(pod.Namespace).Patch(ctx, pod.Name, types.StrategicMergePatchType, patch, metav1.PatchOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to annotate pod %s/%s: %v", pod.Namespace, pod.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package operator
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	"github.com/zalando-incubator/es-operator/pkg/clientset"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestGetDrainCosts(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_cat/shards?h=ip,prirep,store&bytes=b&format=json",
+		httpmock.NewStringResponder(200, `[
+			{"ip":"1.2.3.4","prirep":"p","store":"1073741824"},
+			{"ip":"1.2.3.4","prirep":"r","store":"1"},
+			{"ip":"1.2.3.5","prirep":"p","store":null},
+			{"ip":null,"prirep":"r","store":null}
+		]`))
+
+	endpoint, _ := url.Parse("http://elasticsearch:9200")
+	costs, err := (&ESClient{Endpoint: endpoint}).GetDrainCosts()
+	require.NoError(t, err)
+	require.Equal(t, map[string]DrainCost{
+		"1.2.3.4": {Bytes: 1073741825, Shards: 2, Primaries: 1},
+		"1.2.3.5": {Shards: 1, Primaries: 1},
+	}, costs)
+	require.EqualValues(t, 2, costs["1.2.3.4"].GiB())
+	require.EqualValues(t, 0, costs["1.2.3.5"].GiB())
+}
+
+func TestPublishDrainCosts(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_cat/shards?h=ip,prirep,store&bytes=b&format=json",
+		httpmock.NewStringResponder(200, `[{"ip":"1.2.3.4","prirep":"p","store":"3221225472"}]`))
+
+	pods := []v1.Pod{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "es-data-0", Namespace: "default"},
+			Status:     v1.PodStatus{PodIP: "1.2.3.4"},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "es-data-1", Namespace: "default"},
+			Status:     v1.PodStatus{PodIP: "1.2.3.5"},
+		},
+	}
+	kube := fake.NewSimpleClientset(&pods[0], &pods[1])
+	endpoint, _ := url.Parse("http://elasticsearch:9200")
+	esOperator := NewElasticsearchOperator(&clientset.Clientset{Interface: kube}, Options{Interval: time.Second, AutoscalerInterval: time.Second, ClusterDNSZone: "cluster.local.", ElasticsearchEndpoint: endpoint, EnableDrainCost: true})
+	es := &ESResource{
+		ElasticsearchDataSet: &zv1.ElasticsearchDataSet{ObjectMeta: metav1.ObjectMeta{Name: "es-data", Namespace: "default"}},
+		Pods:                 pods,
+	}
+
+	require.NoError(t, esOperator.publishDrainCosts(context.Background(), es))
+	pod, err := kube.CoreV1().Pods("default").Get(context.Background(), "es-data-0", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{drainCostGiBAnnotationKey: "3", drainCostPrimariesAnnotationKey: "1"}, pod.Annotations)
+	size, primaries := podDrainCost(pod)
+	require.EqualValues(t, 3, size)
+	require.EqualValues(t, 1, primaries)
+	pod, err = kube.CoreV1().Pods("default").Get(context.Background(), "es-data-1", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{drainCostGiBAnnotationKey: "0", drainCostPrimariesAnnotationKey: "0"}, pod.Annotations)
+
+	require.EqualValues(t, 3<<30, testutil.ToFloat64(podDrainCostBytes.WithLabelValues("default", "es-data", "es-data-0")))
+	require.EqualValues(t, 1, testutil.ToFloat64(podDrainCostPrimaries.WithLabelValues("default", "es-data", "es-data-0")))
+
+	deleteDrainCostMetrics("default", "es-data")
+	require.Equal(t, 0, testutil.CollectAndCount(podDrainCostBytes))
+}
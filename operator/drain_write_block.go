@@ -0,0 +1,391 @@
+package operator
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	defaultDrainWriteBlockMaxPrimaries = 1
+	defaultDrainWriteBlockMaxDuration  = 5 * time.Minute
+	indexBlocksWriteSetting            = "index.blocks.write"
+
+	// writeBlockMetaKey is the key of the marker in the _meta of the
+	// mapping of the indices blocked while draining. It records the
+	// ownership of the block across restarts of the operator.
+	writeBlockMetaKey = "es-operator-write-block"
+)
+
+// writeBlockMarker marks the write block of an index as set by the operator.
+// The block is stale after Until, when the drain setting it has removed it
+// at the latest.
+type writeBlockMarker struct {
+	Until time.Time `json:"until"`
+}
+
+// drainWriteBlock tracks the write block set on the indices whose last
+// primaries relocate off a drained pod. The block is only set once per
+// drain.
+type drainWriteBlock struct {
+	config  *zv1.ElasticsearchDataSetDrainWriteBlock
+	indices []string
+	since   time.Time
+	done    bool
+}
+
+// newDrainWriteBlock returns the write block of a drain, or nil if it's not
+// configured.
+func newDrainWriteBlock(config *zv1.ElasticsearchDataSetDrainWriteBlock) *drainWriteBlock {
+	if config == nil || len(config.IndexPatterns) == 0 {
+		return nil
+	}
+	return &drainWriteBlock{config: config}
+}
+
+func (b *drainWriteBlock) maxPrimaries() int {
+	if b.config.MaxPrimaries > 0 {
+		return int(b.config.MaxPrimaries)
+	}
+	return defaultDrainWriteBlockMaxPrimaries
+}
+
+func (b *drainWriteBlock) maxDuration() time.Duration {
+	if b.config.MaxDurationSeconds > 0 {
+		return time.Duration(b.config.MaxDurationSeconds) * time.Second
+	}
+	return defaultDrainWriteBlockMaxDuration
+}
+
+// _ESShardCopy represents a shard copy from the response of _cat/shards
+// (only used internally)
+type _ESShardCopy struct {
+	Index  string `json:"index"`
+	IP     string `json:"ip"`
+	PriRep string `json:"prirep"`
+}
+
+// primariesOnNode returns the number of primaries on the node by index for
+// the indices matching the patterns.
+func (c *ESClient) primariesOnNode(ip string, patterns []string) (map[string]int, error) {
+	resp, err := c.httpClient().R().
+		Get(fmt.Sprintf("%s/_cat/shards/%s?h=index,ip,prirep&format=json", c.Endpoint.String(),
+			url.PathEscape(strings.Join(patterns, ","))))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, fmt.Errorf("code status %d - %s", resp.StatusCode(), resp.Body())
+	}
+
+	var shards []_ESShardCopy
+	err = json.Unmarshal(resp.Body(), &shards)
+	if err != nil {
+		return nil, err
+	}
+	primaries := make(map[string]int)
+	for _, shard := range shards {
+		if shard.PriRep == "p" && shard.IP == ip {
+			primaries[shard.Index]++
+		}
+	}
+	return primaries, nil
+}
+
+// writeBlockedIndices returns the indices which already have a write block.
+func (c *ESClient) writeBlockedIndices(indices []string) (map[string]bool, error) {
+	resp, err := c.httpClient().R().
+		Get(fmt.Sprintf("%s/%s/_settings/%s?flat_settings=true", c.Endpoint.String(),
+			url.PathEscape(strings.Join(indices, ",")), indexBlocksWriteSetting))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, fmt.Errorf("code status %d - %s", resp.StatusCode(), resp.Body())
+	}
+
+	var settings map[string]struct {
+		Settings map[string]string `json:"settings"`
+	}
+	err = json.Unmarshal(resp.Body(), &settings)
+	if err != nil {
+		return nil, err
+	}
+	blocked := make(map[string]bool, len(settings))
+	for index, s := range settings {
+		blocked[index] = s.Settings[indexBlocksWriteSetting] == "true"
+	}
+	return blocked, nil
+}
+
+// setWriteBlock sets or removes the write block of the indices.
+func (c *ESClient) setWriteBlock(indices []string, block bool) error {
+	var value interface{}
+	if block {
+		value = true
+	}
+	resp, err := c.httpClient().R().
+		SetHeader("Content-Type", "application/json").
+		SetBody(map[string]interface{}{indexBlocksWriteSetting: value}).
+		Put(fmt.Sprintf("%s/%s/_settings", c.Endpoint.String(), url.PathEscape(strings.Join(indices, ","))))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return fmt.Errorf("code status %d - %s", resp.StatusCode(), resp.Body())
+	}
+	return nil
+}
+
+// updateDrainWriteBlock blocks writes to the indices matching the patterns
+// once at most the configured number of their primaries is left on the
+// pod. The block is removed once the primaries relocated or after the
+// maximum duration.
+func (c *ESClient) updateDrainWriteBlock(pod *v1.Pod, block *drainWriteBlock, now time.Time) error {
+	if block == nil || block.done {
+		return nil
+	}
+
+	if len(block.indices) > 0 && now.Sub(block.since) >= block.maxDuration() {
+		c.logger().Warnf("Removing write block of indices %s after %s while draining pod %s/%s",
+			strings.Join(block.indices, ","), block.maxDuration(), pod.Namespace, pod.Name)
+		return c.removeDrainWriteBlock(block)
+	}
+
+	primaries, err := c.primariesOnNode(pod.Status.PodIP, block.config.IndexPatterns)
+	if err != nil {
+		return err
+	}
+	remaining := 0
+	for _, count := range primaries {
+		remaining += count
+	}
+
+	if len(block.indices) > 0 {
+		if remaining == 0 {
+			c.logger().Infof("Primaries relocated off pod %s/%s, removing write block of indices %s",
+				pod.Namespace, pod.Name, strings.Join(block.indices, ","))
+			return c.removeDrainWriteBlock(block)
+		}
+		return nil
+	}
+	if remaining == 0 || remaining > block.maxPrimaries() {
+		return nil
+	}
+
+	indices := make([]string, 0, len(primaries))
+	for index := range primaries {
+		indices = append(indices, index)
+	}
+	sort.Strings(indices)
+
+	c.writeBlocksMux.Lock()
+	defer c.writeBlocksMux.Unlock()
+	if c.writeBlocks == nil {
+		c.writeBlocks = make(map[string]int)
+	}
+	// indices blocked by a parallel drain share its block.
+	shared := make([]string, 0, len(indices))
+	candidates := make([]string, 0, len(indices))
+	for _, index := range indices {
+		if c.writeBlocks[index] > 0 {
+			shared = append(shared, index)
+		} else {
+			candidates = append(candidates, index)
+		}
+	}
+
+	unblocked := make([]string, 0, len(candidates))
+	if len(candidates) > 0 {
+		blocked, err := c.writeBlockedIndices(candidates)
+		if err != nil {
+			return err
+		}
+		// indices blocked by someone else are left alone.
+		for _, index := range candidates {
+			if !blocked[index] {
+				unblocked = append(unblocked, index)
+			}
+		}
+	}
+	if len(shared) == 0 && len(unblocked) == 0 {
+		block.done = true
+		return nil
+	}
+
+	owned := append(shared, unblocked...)
+	sort.Strings(owned)
+	// the marker is set first, a block is never left without one.
+	err = c.markWriteBlocks(owned, &writeBlockMarker{Until: now.Add(block.maxDuration())})
+	if err != nil {
+		return err
+	}
+	if len(unblocked) > 0 {
+		err = c.setWriteBlock(unblocked, true)
+		if err != nil {
+			return err
+		}
+	}
+	block.indices = owned
+	for _, index := range block.indices {
+		c.writeBlocks[index]++
+	}
+	block.since = now
+	c.logger().Warnf("Blocked writes to indices %s while their last %d primaries relocate off pod %s/%s",
+		strings.Join(block.indices, ","), remaining, pod.Namespace, pod.Name)
+	return nil
+}
+
+// removeDrainWriteBlock removes the write block set during the drain, if
+// any. It's not set again during the drain. The block of indices shared
+// with parallel drains is only removed by the last of them.
+func (c *ESClient) removeDrainWriteBlock(block *drainWriteBlock) error {
+	if block == nil || len(block.indices) == 0 {
+		return nil
+	}
+
+	c.writeBlocksMux.Lock()
+	defer c.writeBlocksMux.Unlock()
+	released := make([]string, 0, len(block.indices))
+	for _, index := range block.indices {
+		if c.writeBlocks[index] <= 1 {
+			released = append(released, index)
+		}
+	}
+	if len(released) > 0 {
+		err := c.setWriteBlock(released, false)
+		if err != nil {
+			return fmt.Errorf("failed to remove write block of indices %s: %v", strings.Join(released, ","), err)
+		}
+		err = c.markWriteBlocks(released, nil)
+		if err != nil {
+			return fmt.Errorf("failed to remove write block marker of indices %s: %v", strings.Join(released, ","), err)
+		}
+	}
+	for _, index := range block.indices {
+		c.writeBlocks[index]--
+		if c.writeBlocks[index] <= 0 {
+			delete(c.writeBlocks, index)
+		}
+	}
+	block.indices = nil
+	block.done = true
+	return nil
+}
+
+// mappingMeta returns the _meta of the mappings of the indices by index.
+func (c *ESClient) mappingMeta(indices string) (map[string]map[string]json.RawMessage, error) {
+	resp, err := c.httpClient().R().
+		Get(fmt.Sprintf("%s/%s/_mapping?ignore_unavailable=true&filter_path=*.mappings._meta", c.Endpoint.String(),
+			url.PathEscape(indices)))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, fmt.Errorf("code status %d - %s", resp.StatusCode(), resp.Body())
+	}
+
+	var mappings map[string]struct {
+		Mappings struct {
+			Meta map[string]json.RawMessage `json:"_meta"`
+		} `json:"mappings"`
+	}
+	err = json.Unmarshal(resp.Body(), &mappings)
+	if err != nil {
+		return nil, err
+	}
+	meta := make(map[string]map[string]json.RawMessage, len(mappings))
+	for index, m := range mappings {
+		meta[index] = m.Mappings.Meta
+	}
+	return meta, nil
+}
+
+// markWriteBlocks sets the write block marker in the _meta of the mappings
+// of the indices, or removes it if marker is nil. Other keys of the _meta
+// are kept.
+func (c *ESClient) markWriteBlocks(indices []string, marker *writeBlockMarker) error {
+	metas, err := c.mappingMeta(strings.Join(indices, ","))
+	if err != nil {
+		return err
+	}
+	for _, index := range indices {
+		meta := metas[index]
+		if meta == nil {
+			meta = make(map[string]json.RawMessage)
+		}
+		if marker != nil {
+			data, err := json.Marshal(marker)
+			if err != nil {
+				return err
+			}
+			meta[writeBlockMetaKey] = data
+		} else {
+			if _, ok := meta[writeBlockMetaKey]; !ok {
+				continue
+			}
+			delete(meta, writeBlockMetaKey)
+		}
+
+		resp, err := c.httpClient().R().
+			SetHeader("Content-Type", "application/json").
+			SetBody(map[string]interface{}{"_meta": meta}).
+			Put(fmt.Sprintf("%s/%s/_mapping", c.Endpoint.String(), url.PathEscape(index)))
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode() != http.StatusOK {
+			return fmt.Errorf("code status %d - %s", resp.StatusCode(), resp.Body())
+		}
+	}
+	return nil
+}
+
+// removeStaleWriteBlocks removes the write blocks of the indices matching
+// the patterns whose marker expired before now, which are left over if the
+// operator stopped while draining. Blocks of running drains are kept.
+func (c *ESClient) removeStaleWriteBlocks(now time.Time) error {
+	if c.drainWriteBlock == nil || len(c.drainWriteBlock.IndexPatterns) == 0 {
+		return nil
+	}
+	metas, err := c.mappingMeta(strings.Join(c.drainWriteBlock.IndexPatterns, ","))
+	if err != nil {
+		return err
+	}
+
+	c.writeBlocksMux.Lock()
+	defer c.writeBlocksMux.Unlock()
+	stale := make([]string, 0, len(metas))
+	for index, meta := range metas {
+		data, ok := meta[writeBlockMetaKey]
+		if !ok || c.writeBlocks[index] > 0 {
+			continue
+		}
+		var marker writeBlockMarker
+		err := json.Unmarshal(data, &marker)
+		if err != nil {
+			return fmt.Errorf("invalid write block marker of index %s: %v", index, err)
+		}
+		if now.After(marker.Until) {
+			stale = append(stale, index)
+		}
+	}
+	if len(stale) == 0 {
+		return nil
+	}
+	sort.Strings(stale)
+
+	c.logger().Warnf("Removing stale write block of indices %s", strings.Join(stale, ","))
+	err = c.setWriteBlock(stale, false)
+	if err != nil {
+		return err
+	}
+	return c.markWriteBlocks(stale, nil)
+}
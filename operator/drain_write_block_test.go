@@ -0,0 +1,201 @@
+package operator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// registerMappingMeta registers responders keeping the _meta of the mapping
+// of the index in meta.
+func registerMappingMeta(index string, meta map[string]json.RawMessage) {
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/"+index+"/_mapping",
+		func(req *http.Request) (*http.Response, error) {
+			return httpmock.NewJsonResponse(200, map[string]interface{}{index: map[string]interface{}{"mappings": map[string]interface{}{"_meta": meta}}})
+		})
+	httpmock.RegisterResponder("PUT", "http://elasticsearch:9200/"+index+"/_mapping",
+		func(req *http.Request) (*http.Response, error) {
+			var mapping struct {
+				Meta map[string]json.RawMessage `json:"_meta"`
+			}
+			err := json.NewDecoder(req.Body).Decode(&mapping)
+			if err != nil {
+				return nil, err
+			}
+			for key := range meta {
+				delete(meta, key)
+			}
+			for key, value := range mapping.Meta {
+				meta[key] = value
+			}
+			return httpmock.NewStringResponse(200, `{"acknowledged":true}`), nil
+		})
+}
+
+func TestUpdateDrainWriteBlock(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	shardsResponder := func(primaries int) httpmock.Responder {
+		body := `[{"index":"logs","ip":"10.2.10.2","prirep":"r"}`
+		for i := 0; i < primaries; i++ {
+			body += `,{"index":"logs","ip":"10.2.10.1","prirep":"p"}`
+		}
+		return httpmock.NewStringResponder(200, body+"]")
+	}
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/logs/_settings/index.blocks.write",
+		httpmock.NewStringResponder(200, `{"logs":{"settings":{}}}`))
+	meta := map[string]json.RawMessage{"owner": json.RawMessage(`"logging"`)}
+	registerMappingMeta("logs", meta)
+	var updates []string
+	httpmock.RegisterResponder("PUT", "http://elasticsearch:9200/logs/_settings",
+		func(req *http.Request) (*http.Response, error) {
+			body, _ := io.ReadAll(req.Body)
+			updates = append(updates, string(body))
+			return httpmock.NewStringResponse(200, `{"acknowledged":true}`), nil
+		})
+
+	esURL, _ := url.Parse("http://elasticsearch:9200")
+	client := &ESClient{Endpoint: esURL}
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "es-data-0", Namespace: "default"},
+		Status:     v1.PodStatus{PodIP: "10.2.10.1"},
+	}
+	now := time.Now()
+
+	require.Nil(t, newDrainWriteBlock(nil))
+	block := newDrainWriteBlock(&zv1.ElasticsearchDataSetDrainWriteBlock{IndexPatterns: []string{"logs"}})
+
+	// more primaries left than configured
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_cat/shards/logs", shardsResponder(2))
+	require.NoError(t, client.updateDrainWriteBlock(pod, block, now))
+	require.Empty(t, updates)
+
+	// the last primary is relocating
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_cat/shards/logs", shardsResponder(1))
+	require.NoError(t, client.updateDrainWriteBlock(pod, block, now))
+	require.Equal(t, []string{`{"index.blocks.write":true}`}, updates)
+	require.Equal(t, []string{"logs"}, block.indices)
+	// the ownership of the block is marked in the index.
+	require.JSONEq(t, fmt.Sprintf(`{"until":%q}`, now.Add(5*time.Minute).Format(time.RFC3339Nano)), string(meta[writeBlockMetaKey]))
+	require.JSONEq(t, `"logging"`, string(meta["owner"]))
+
+	// the last primary relocated
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_cat/shards/logs", shardsResponder(0))
+	require.NoError(t, client.updateDrainWriteBlock(pod, block, now.Add(time.Minute)))
+	require.Equal(t, []string{`{"index.blocks.write":true}`, `{"index.blocks.write":null}`}, updates)
+	require.True(t, block.done)
+	require.NotContains(t, meta, writeBlockMetaKey)
+	require.Contains(t, meta, "owner")
+
+	// the block is removed after the maximum duration and not set again
+	updates = nil
+	block = newDrainWriteBlock(&zv1.ElasticsearchDataSetDrainWriteBlock{IndexPatterns: []string{"logs"}, MaxDurationSeconds: 60})
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_cat/shards/logs", shardsResponder(1))
+	require.NoError(t, client.updateDrainWriteBlock(pod, block, now))
+	require.NoError(t, client.updateDrainWriteBlock(pod, block, now.Add(time.Minute)))
+	require.NoError(t, client.updateDrainWriteBlock(pod, block, now.Add(2*time.Minute)))
+	require.Equal(t, []string{`{"index.blocks.write":true}`, `{"index.blocks.write":null}`}, updates)
+}
+
+func TestParallelDrainWriteBlocks(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	// the last primaries of logs are on both pods.
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_cat/shards/logs",
+		httpmock.NewStringResponder(200, `[{"index":"logs","ip":"10.2.10.1","prirep":"p"},{"index":"logs","ip":"10.2.10.2","prirep":"p"}]`))
+	blocked := false
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/logs/_settings/index.blocks.write",
+		func(req *http.Request) (*http.Response, error) {
+			if blocked {
+				return httpmock.NewStringResponse(200, `{"logs":{"settings":{"index.blocks.write":"true"}}}`), nil
+			}
+			return httpmock.NewStringResponse(200, `{"logs":{"settings":{}}}`), nil
+		})
+	registerMappingMeta("logs", map[string]json.RawMessage{})
+	var updates []string
+	httpmock.RegisterResponder("PUT", "http://elasticsearch:9200/logs/_settings",
+		func(req *http.Request) (*http.Response, error) {
+			body, _ := io.ReadAll(req.Body)
+			updates = append(updates, string(body))
+			blocked = string(body) == `{"index.blocks.write":true}`
+			return httpmock.NewStringResponse(200, `{"acknowledged":true}`), nil
+		})
+
+	esURL, _ := url.Parse("http://elasticsearch:9200")
+	client := &ESClient{Endpoint: esURL}
+	config := &zv1.ElasticsearchDataSetDrainWriteBlock{IndexPatterns: []string{"logs"}}
+	now := time.Now()
+	blocks := make([]*drainWriteBlock, 0, 2)
+	for _, ip := range []string{"10.2.10.1", "10.2.10.2"} {
+		block := newDrainWriteBlock(config)
+		pod := &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "es-data-" + ip, Namespace: "default"},
+			Status:     v1.PodStatus{PodIP: ip},
+		}
+		require.NoError(t, client.updateDrainWriteBlock(pod, block, now))
+		require.Equal(t, []string{"logs"}, block.indices)
+		blocks = append(blocks, block)
+	}
+	// the block of the first drain is shared by the second one.
+	require.Equal(t, []string{`{"index.blocks.write":true}`}, updates)
+
+	// the block is only removed by the last drain.
+	require.NoError(t, client.removeDrainWriteBlock(blocks[0]))
+	require.Len(t, updates, 1)
+	require.NoError(t, client.removeDrainWriteBlock(blocks[1]))
+	require.Equal(t, []string{`{"index.blocks.write":true}`, `{"index.blocks.write":null}`}, updates)
+	require.Empty(t, client.writeBlocks)
+}
+
+func TestRemoveStaleWriteBlocks(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	now := time.Now()
+	marker := func(until time.Time) json.RawMessage {
+		return json.RawMessage(fmt.Sprintf(`{"until":%q}`, until.Format(time.RFC3339Nano)))
+	}
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/logs-%2A/_mapping",
+		httpmock.NewJsonResponderOrPanic(200, map[string]interface{}{
+			"logs-1": map[string]interface{}{"mappings": map[string]interface{}{"_meta": map[string]json.RawMessage{writeBlockMetaKey: marker(now.Add(-time.Minute))}}},
+			"logs-2": map[string]interface{}{"mappings": map[string]interface{}{"_meta": map[string]json.RawMessage{writeBlockMetaKey: marker(now.Add(time.Minute))}}},
+			"logs-3": map[string]interface{}{"mappings": map[string]interface{}{}},
+		}))
+	meta := map[string]json.RawMessage{writeBlockMetaKey: marker(now.Add(-time.Minute))}
+	registerMappingMeta("logs-1", meta)
+	var updates []string
+	httpmock.RegisterResponder("PUT", "http://elasticsearch:9200/logs-1/_settings",
+		func(req *http.Request) (*http.Response, error) {
+			body, _ := io.ReadAll(req.Body)
+			updates = append(updates, string(body))
+			return httpmock.NewStringResponse(200, `{"acknowledged":true}`), nil
+		})
+
+	// a restarted operator removes the expired block only.
+	esURL, _ := url.Parse("http://elasticsearch:9200")
+	client := &ESClient{
+		Endpoint:        esURL,
+		drainWriteBlock: &zv1.ElasticsearchDataSetDrainWriteBlock{IndexPatterns: []string{"logs-*"}},
+	}
+	require.NoError(t, client.removeStaleWriteBlocks(now))
+	require.Equal(t, []string{`{"index.blocks.write":null}`}, updates)
+	require.NotContains(t, meta, writeBlockMetaKey)
+
+	// blocks of running drains are kept.
+	updates = nil
+	client.writeBlocks = map[string]int{"logs-1": 1}
+	require.NoError(t, client.removeStaleWriteBlocks(now))
+	require.Empty(t, updates)
+}
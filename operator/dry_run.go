@@ -0,0 +1,106 @@
+package operator
+
+import (
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"k8s.io/client-go/rest"
+)
+
+const dryRunResponse = `{"acknowledged":true}`
+
+// readOnlyRequest returns true if the request doesn't change anything.
+func readOnlyRequest(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+// readOnlyElasticsearchRequest returns true if the request to Elasticsearch
+// doesn't change anything. Searches, node stats and cat APIs only read, even
+// when they're sent with POST to pass a body.
+func readOnlyElasticsearchRequest(req *http.Request) bool {
+	if readOnlyRequest(req) {
+		return true
+	}
+	if req.Method != http.MethodPost {
+		return false
+	}
+	// index names can't start with '_', the segments are API names.
+	segments := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	last := segments[len(segments)-1]
+	switch {
+	case segments[0] == "_cat":
+		return true
+	case segments[0] == "_nodes" && slices.Contains(segments, "stats"):
+		return true
+	case last == "_search" || last == "_msearch" || last == "_count":
+		return true
+	}
+	return false
+}
+
+// kubeDryRunTransport sends changing requests to the Kubernetes API server
+// as server-side dry-run, such that they are validated but not persisted.
+type kubeDryRunTransport struct {
+	next http.RoundTripper
+}
+
+func (t *kubeDryRunTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if readOnlyRequest(req) {
+		return t.next.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	query := req.URL.Query()
+	query.Set("dryRun", "All")
+	req.URL.RawQuery = query.Encode()
+	log.Infof("Dry-run: %s %s", req.Method, req.URL.Path)
+	return t.next.RoundTrip(req)
+}
+
+// elasticsearchDryRunTransport only logs changing requests to Elasticsearch
+// and responds as if they were acknowledged.
+type elasticsearchDryRunTransport struct {
+	next http.RoundTripper
+}
+
+func (t *elasticsearchDryRunTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if readOnlyElasticsearchRequest(req) {
+		return t.next.RoundTrip(req)
+	}
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	log.Infof("Dry-run: not sending %s %s", req.Method, req.URL.Redacted())
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(strings.NewReader(dryRunResponse)),
+		ContentLength: int64(len(dryRunResponse)),
+		Request:       req,
+	}, nil
+}
+
+// EnableDryRun sends the changes to Kubernetes resources made with the
+// config as server-side dry-run. Changing requests to Elasticsearch aren't
+// sent at all with Options.DryRun.
+func EnableDryRun(config *rest.Config) {
+	config.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return &kubeDryRunTransport{next: rt}
+	})
+}
+
+// dryRun returns true if the changing requests of the client aren't sent.
+func (c *ESClient) dryRun() bool {
+	_, ok := c.transport.(*elasticsearchDryRunTransport)
+	return ok
+}
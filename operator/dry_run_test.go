@@ -0,0 +1,87 @@
+package operator
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+)
+
+type recordingTransport struct {
+	requests []*http.Request
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requests = append(t.requests, req)
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("{}")), Request: req}, nil
+}
+
+func TestKubeDryRunTransport(t *testing.T) {
+	next := &recordingTransport{}
+	transport := &kubeDryRunTransport{next: next}
+
+	req, _ := http.NewRequest(http.MethodGet, "https://kubernetes/api/v1/pods?limit=10", nil)
+	_, err := transport.RoundTrip(req)
+	require.NoError(t, err)
+	req, _ = http.NewRequest(http.MethodDelete, "https://kubernetes/api/v1/namespaces/default/pods/es-0", nil)
+	_, err = transport.RoundTrip(req)
+	require.NoError(t, err)
+
+	require.Len(t, next.requests, 2)
+	require.Equal(t, "limit=10", next.requests[0].URL.RawQuery)
+	require.Equal(t, "dryRun=All", next.requests[1].URL.RawQuery)
+	// the original request isn't modified
+	require.Empty(t, req.URL.RawQuery)
+}
+
+func TestElasticsearchDryRunTransport(t *testing.T) {
+	next := &recordingTransport{}
+	transport := &elasticsearchDryRunTransport{next: next}
+
+	req, _ := http.NewRequest(http.MethodPut, "http://elasticsearch:9200/_cluster/settings", strings.NewReader("{}"))
+	resp, err := transport.RoundTrip(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, dryRunResponse, string(body))
+	require.Empty(t, next.requests)
+
+	req, _ = http.NewRequest(http.MethodGet, "http://elasticsearch:9200/_cluster/health", nil)
+	_, err = transport.RoundTrip(req)
+	require.NoError(t, err)
+	require.Len(t, next.requests, 1)
+
+	// reading requests with a body are sent.
+	for _, path := range []string{"/logs/_search", "/_nodes/stats", "/_nodes/node-a/stats/indices", "/_cat/indices"} {
+		req, _ = http.NewRequest(http.MethodPost, "http://elasticsearch:9200"+path, strings.NewReader("{}"))
+		_, err = transport.RoundTrip(req)
+		require.NoError(t, err)
+	}
+	require.Len(t, next.requests, 5)
+	for _, path := range []string{"/_cluster/reroute", "/_tasks/node-a:1/_cancel", "/_snapshot/backup/snap/_restore"} {
+		req, _ = http.NewRequest(http.MethodPost, "http://elasticsearch:9200"+path, strings.NewReader("{}"))
+		_, err = transport.RoundTrip(req)
+		require.NoError(t, err)
+	}
+	require.Len(t, next.requests, 5)
+}
+
+func TestElasticsearchTransportDryRun(t *testing.T) {
+	defaultTransport := http.DefaultTransport
+	o := &ElasticsearchOperator{dryRun: true}
+	eds := &zv1.ElasticsearchDataSet{}
+
+	client := &ESClient{transport: o.elasticsearchTransport(eds)}
+	require.True(t, client.dryRun())
+	require.IsType(t, &elasticsearchDryRunTransport{}, client.transport)
+	// the transport of other clients isn't changed.
+	require.Equal(t, defaultTransport, http.DefaultTransport)
+
+	o.dryRun = false
+	client = &ESClient{transport: o.elasticsearchTransport(eds)}
+	require.False(t, client.dryRun())
+}
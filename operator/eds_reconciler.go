@@ -0,0 +1,73 @@
+package operator
+
+import (
+	"context"
+
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// edsReconciler reconciles the EDS by operating each of them in a goroutine,
+// which is restarted when the EDS changes and stopped when it's deleted.
+// Draining pods blocks for a long time, so the operation isn't done within
+// Reconcile.
+type edsReconciler struct {
+	client   client.Client
+	operator *ElasticsearchOperator
+}
+
+// SetupWithManager adds the operator to the manager: the periodic loops as a
+// runnable and the EDS reconciler as a controller. Both only run on the
+// elected leader. The scheme of the manager must contain the zalando.org/v1
+// types.
+func (o *ElasticsearchOperator) SetupWithManager(mgr manager.Manager) error {
+	err := mgr.Add(o)
+	if err != nil {
+		return err
+	}
+
+	return builder.ControllerManagedBy(mgr).
+		Named("elasticsearchdataset").
+		For(&zv1.ElasticsearchDataSet{}).
+		Complete(&edsReconciler{client: mgr.GetClient(), operator: o})
+}
+
+// Reconcile (re)starts the operation of the EDS, or stops it if the EDS was
+// deleted.
+func (r *edsReconciler) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	select {
+	case <-r.operator.ready:
+	case <-ctx.Done():
+		return reconcile.Result{}, ctx.Err()
+	}
+
+	eds := &zv1.ElasticsearchDataSet{}
+	err := r.client.Get(ctx, request.NamespacedName, eds)
+	if errors.IsNotFound(err) {
+		eds.Namespace, eds.Name = request.Namespace, request.Name
+		eds.UID = r.operator.operatingUID(request.NamespacedName)
+		return reconcile.Result{}, r.operator.operateEDS(eds, true)
+	}
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{}, r.operator.operateEDS(eds, false)
+}
+
+// operatingUID returns the UID of the operated EDS with the name. It's empty
+// if the EDS isn't operated.
+func (o *ElasticsearchOperator) operatingUID(name types.NamespacedName) types.UID {
+	o.Lock()
+	defer o.Unlock()
+	for uid, entry := range o.operating {
+		if entry.namespace == name.Namespace && entry.name == name.Name {
+			return uid
+		}
+	}
+	return ""
+}
@@ -2,10 +2,10 @@ package operator
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"math"
 	"net/url"
+	"reflect"
 	"sync"
 	"time"
 
@@ -17,15 +17,17 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
 	pv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/wait"
 	kubeinformers "k8s.io/client-go/informers"
 	informersv1 "k8s.io/client-go/informers/core/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/tools/cache"
 	kube_record "k8s.io/client-go/tools/record"
 )
@@ -46,19 +48,60 @@ type ElasticsearchOperator struct {
 	autoscalerInterval    time.Duration
 	metricsInterval       time.Duration
 	priorityNodeSelectors labels.Set
+	imageRewriteRules     ImageRewriteRules
+	imageResolver         ImageResolver
+	metricStore           MetricStore
+	dryRun                bool
+	enableRollouts        bool
+	enableRestores        bool
+	enableClusters        bool
+	enablePrometheusRules bool
+	enableDrainCost       bool
+	enableCostReports     bool
+	prometheusRuleLabels  map[string]string
 	operatorID            string
 	namespace             string
 	clusterDNSZone        string
+	externalDNSZone       string
 	elasticsearchEndpoint *url.URL
 	operating             map[types.UID]operatingEntry
+	masterTrackers        map[types.UID]*masterStabilityTracker
+	drainingPauses        map[types.UID]*drainingPause
+	// shardOps are the operation counters of the shard copies of the
+	// previous hot shard check, only accessed by the autoscaler loop.
+	shardOps map[types.UID]map[string]int64
+	// scalingSampleWrites limits the status writes only updating the
+	// sample counters of the scaling state.
+	scalingSampleWrites *statusWriteLimiter
+	decisions           *decisionLog
+	// costs are the footprint samples of the EDS for the cost reports.
+	costs *costHistory
+	// prices estimate the cost of the EDS, nil if the cost isn't
+	// estimated.
+	prices *PriceTable
+	// secrets are the TLS and credentials Secrets of the EDS.
+	secrets *secretCache
+	// tlsTransports are the transports of the EDS connecting to
+	// Elasticsearch with HTTPS.
+	tlsTransports *tlsTransportCache
+	// ready is closed once the pod and node informers are synced, the EDS
+	// are only operated afterwards.
+	ready chan struct{}
+	// stopped is set once the operations are stopped because the
+	// operator lost the leadership or terminates.
+	stopped bool
 	sync.Mutex
 	recorder kube_record.EventRecorder
 }
 
 type operatingEntry struct {
-	cancel context.CancelFunc
-	doneCh <-chan struct{}
-	logger *log.Entry
+	cancel          context.CancelFunc
+	doneCh          <-chan struct{}
+	logger          *log.Entry
+	namespace       string
+	name            string
+	resourceVersion string
+	started         time.Time
 }
 
 // DrainingConfig specifies the configuration settings for the behavior of draining Elasticsearch nodes.
@@ -66,20 +109,73 @@ type DrainingConfig struct {
 	MaxRetries      int
 	MinimumWaitTime time.Duration
 	MaximumWaitTime time.Duration
+	// NodeShutdown enables the node shutdown API on Elasticsearch 7.14+.
+	NodeShutdown bool
+	// TaskDeadline is the drain time after which the tasks on the node
+	// are reported. Tasks aren't checked if 0.
+	TaskDeadline time.Duration
+	// CancelTasks cancels the reported tasks.
+	CancelTasks bool
+	// MaxParallelDrains is the maximum number of pods drained at once when
+	// scaling down.
+	MaxParallelDrains int
+}
+
+// Options configures an ElasticsearchOperator.
+type Options struct {
+	// PriorityNodeSelectors selects the nodes with the highest priority,
+	// e.g. nodes which are ready over nodes about to be terminated.
+	PriorityNodeSelectors map[string]string
+	// Interval is the interval in which the EDS are operated.
+	Interval time.Duration
+	// AutoscalerInterval is the interval in which the autoscaler runs.
+	AutoscalerInterval time.Duration
+	// OperatorID determines the ownership of EDS resources.
+	OperatorID string
+	// Namespace limits the operator to a single namespace, all namespaces
+	// are operated if it's empty.
+	Namespace string
+	// ClusterDNSZone is the DNS zone of the Kubernetes cluster.
+	ClusterDNSZone string
+	// ExternalDNSZone is the zone of the default hostname of the DNS
+	// records published for EDS with external DNS.
+	ExternalDNSZone string
+	// ElasticsearchEndpoint overrides the service endpoint of the EDS used
+	// to reach Elasticsearch.
+	ElasticsearchEndpoint *url.URL
+	// ImageRewriteRules rewrites the image prefixes of the generated Pod
+	// specs.
+	ImageRewriteRules map[string]string
+	// ImageResolver pins the images of an EDS to their digests, if set.
+	ImageResolver ImageResolver
+	// MetricStore stores the metrics of the EDS. It defaults to the
+	// ElasticsearchMetricSet resources.
+	MetricStore MetricStore
+	// PrometheusRuleLabels are added to the PrometheusRules of an EDS.
+	PrometheusRuleLabels map[string]string
+	// Prices is the price table the costs of the EDS are estimated with.
+	Prices *PriceTable
+
+	// DryRun only logs the changing requests to Elasticsearch instead of
+	// sending them.
+	DryRun bool
+
+	EnableRollouts        bool
+	EnableRestores        bool
+	EnableClusters        bool
+	EnablePrometheusRules bool
+	EnableDrainCost       bool
+	EnableCostReports     bool
 }
 
 // NewElasticsearchOperator initializes a new ElasticsearchDataSet operator instance.
-func NewElasticsearchOperator(
-	client *clientset.Clientset,
-	priorityNodeSelectors map[string]string,
-	interval,
-	autoscalerInterval time.Duration,
-	operatorID,
-	namespace,
-	clusterDNSZone string,
-	elasticsearchEndpoint *url.URL,
-) *ElasticsearchOperator {
+func NewElasticsearchOperator(client *clientset.Clientset, options Options) *ElasticsearchOperator {
+	metricStore := options.MetricStore
+	if metricStore == nil {
+		metricStore = NewCRDMetricStore(client)
+	}
 
+	secrets := newSecretCache(client, secretRefreshInterval)
 	return &ElasticsearchOperator{
 		logger: log.WithFields(
 			log.Fields{
@@ -87,19 +183,47 @@ func NewElasticsearchOperator(
 			},
 		),
 		kube:                  client,
-		interval:              interval,
-		autoscalerInterval:    autoscalerInterval,
+		interval:              options.Interval,
+		autoscalerInterval:    options.AutoscalerInterval,
 		metricsInterval:       60 * time.Second,
-		priorityNodeSelectors: labels.Set(priorityNodeSelectors),
-		operatorID:            operatorID,
-		namespace:             namespace,
-		clusterDNSZone:        clusterDNSZone,
-		elasticsearchEndpoint: elasticsearchEndpoint,
+		priorityNodeSelectors: labels.Set(options.PriorityNodeSelectors),
+		imageRewriteRules:     ImageRewriteRules(options.ImageRewriteRules),
+		imageResolver:         options.ImageResolver,
+		metricStore:           metricStore,
+		dryRun:                options.DryRun,
+		enableRollouts:        options.EnableRollouts,
+		enableRestores:        options.EnableRestores,
+		enableClusters:        options.EnableClusters,
+		enablePrometheusRules: options.EnablePrometheusRules,
+		enableDrainCost:       options.EnableDrainCost,
+		enableCostReports:     options.EnableCostReports,
+		prometheusRuleLabels:  options.PrometheusRuleLabels,
+		operatorID:            options.OperatorID,
+		namespace:             options.Namespace,
+		clusterDNSZone:        options.ClusterDNSZone,
+		externalDNSZone:       options.ExternalDNSZone,
+		elasticsearchEndpoint: options.ElasticsearchEndpoint,
 		operating:             make(map[types.UID]operatingEntry),
+		masterTrackers:        make(map[types.UID]*masterStabilityTracker),
+		drainingPauses:        make(map[types.UID]*drainingPause),
+		shardOps:              make(map[types.UID]map[string]int64),
+		scalingSampleWrites:   newStatusWriteLimiter(scalingSamplesWriteInterval),
+		decisions:             newDecisionLog(defaultDecisionLogSize),
+		costs:                 newCostHistory(costHistoryRetention),
+		prices:                options.Prices,
+		secrets:               secrets,
+		tlsTransports:         newTLSTransportCache(secrets),
+		ready:                 make(chan struct{}),
 		recorder:              createEventRecorder(client),
 	}
 }
 
+// NeedLeaderElection implements manager.LeaderElectionRunnable. Only one
+// operator may operate the EDS at a time.
+func (o *ElasticsearchOperator) NeedLeaderElection() bool {
+	return true
+}
+
 // setup informers for pods and nodes.
 func (o *ElasticsearchOperator) setupInformers(ctx context.Context) error {
 	informerFactory := kubeinformers.NewSharedInformerFactoryWithOptions(o.kube, 0, kubeinformers.WithNamespace(o.namespace))
@@ -140,99 +264,60 @@ func (o *ElasticsearchOperator) setupInformers(ctx context.Context) error {
 	return nil
 }
 
-// Run runs the main loop of the operator.
-func (o *ElasticsearchOperator) Run(ctx context.Context) error {
+// Start runs the periodic loops of the operator until the context is
+// cancelled and lets the EDS reconciler operate the EDS once the informers
+// are synced. It implements manager.Runnable, so the operator is only run by
+// the elected leader if the manager runs with leader election.
+func (o *ElasticsearchOperator) Start(ctx context.Context) error {
 	err := o.setupInformers(ctx)
 	if err != nil {
 		return err
 	}
+	close(o.ready)
 
 	go o.collectMetrics(ctx)
 	go o.runAutoscaler(ctx)
-
-	// run EDS watcher
-	err = o.runWatch(ctx)
-	if err != nil {
-		return err
+	go o.runGCRecycler(ctx)
+	go o.runRebalancer(ctx)
+	go o.runMetricSetGC(ctx)
+	go o.runSchedulingGates(ctx)
+	go o.runVolumeClaimRetention(ctx)
+	go o.runVolumeExpansion(ctx)
+	if o.enableRollouts {
+		go o.runRollouts(ctx)
 	}
-	<-ctx.Done()
-	o.logger.Info("Terminating main operator loop.")
-	return nil
-}
-
-// Run setups up a shared informer for listing and watching changes to pods and
-// starts listening for events.
-func (o *ElasticsearchOperator) runWatch(ctx context.Context) error {
-	informer := cache.NewSharedIndexInformer(
-		cache.NewListWatchFromClient(
-			o.kube.ZalandoV1().RESTClient(),
-			"elasticsearchdatasets",
-			o.namespace, fields.Everything(),
-		),
-		&zv1.ElasticsearchDataSet{},
-		0, // skip resync
-		cache.Indexers{},
-	)
-
-	_, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
-		AddFunc:    o.add,
-		UpdateFunc: o.update,
-		DeleteFunc: o.del,
-	})
-
-	if err != nil {
-		return fmt.Errorf("Failed to add event handler: %w", err)
+	if o.enableRestores {
+		go o.runRestores(ctx)
 	}
-
-	go informer.Run(ctx.Done())
-
-	if !cache.WaitForCacheSync(ctx.Done(), informer.HasSynced) {
-		return fmt.Errorf("Timed out waiting for caches to sync")
+	if o.enableClusters {
+		go o.runClusters(ctx)
 	}
-
-	log.Info("Synced ElasticsearchDataSet watcher")
-	return nil
-}
-
-// add is the handler for an EDS getting added.
-func (o *ElasticsearchOperator) add(obj interface{}) {
-	eds, ok := obj.(*zv1.ElasticsearchDataSet)
-	if !ok {
-		log.Errorf("Failed to get EDS object")
-		return
+	if o.enablePrometheusRules {
+		go o.runPrometheusRules(ctx)
 	}
-
-	err := o.operateEDS(eds, false)
-	if err != nil {
-		log.Errorf("Add failed, this is bad!: %v", err)
+	if o.enableDrainCost {
+		go o.runDrainCosts(ctx)
 	}
-}
-
-// update is the handler for an EDS getting updated.
-func (o *ElasticsearchOperator) update(oldObj, newObj interface{}) {
-	newEDS, ok := newObj.(*zv1.ElasticsearchDataSet)
-	if !ok {
-		log.Errorf("Failed to get EDS object")
-		return
+	if o.enableCostReports {
+		go o.runCostReports(ctx)
 	}
 
-	err := o.operateEDS(newEDS, false)
-	if err != nil {
-		log.Errorf("Add failed, this is bad!: %v", err)
-	}
+	<-ctx.Done()
+	o.logger.Info("Terminating main operator loop.")
+	o.stopOperations()
+	return nil
 }
 
-// del is the handler for an EDS getting deleted.
-func (o *ElasticsearchOperator) del(obj interface{}) {
-	eds, ok := obj.(*zv1.ElasticsearchDataSet)
-	if !ok {
-		log.Errorf("Failed to get EDS object")
-		return
-	}
-
-	err := o.operateEDS(eds, true)
-	if err != nil {
-		log.Errorf("Add failed, this is bad!: %v", err)
+// stopOperations stops the operations of all EDS and waits for them to
+// terminate, such that a new leader doesn't operate them concurrently.
+func (o *ElasticsearchOperator) stopOperations() {
+	o.Lock()
+	defer o.Unlock()
+	o.stopped = true
+	for uid, entry := range o.operating {
+		entry.cancel()
+		<-entry.doneCh
+		delete(o.operating, uid)
 	}
 }
 
@@ -258,9 +343,13 @@ func (o *ElasticsearchOperator) collectMetrics(ctx context.Context) {
 				if es.ElasticsearchDataSet.Spec.Scaling != nil && es.ElasticsearchDataSet.Spec.Scaling.Enabled {
 					metrics := &ElasticsearchMetricsCollector{
 						kube:   o.kube,
+						store:  o.metricStore,
 						logger: log.WithFields(log.Fields{"collector": "metrics"}),
 						es:     *es,
 					}
+					if o.nodeInformer != nil {
+						metrics.nodeLister = o.nodeInformer.Lister()
+					}
 					err := metrics.collectMetrics(ctx)
 					if err != nil {
 						o.logger.Error(err)
@@ -277,9 +366,8 @@ func (o *ElasticsearchOperator) collectMetrics(ctx context.Context) {
 
 // runAutoscaler runs the EDS autoscaler which checks at an interval if any
 // EDS resources needs to be autoscaled. If autoscaling is needed this will be
-// indicated on the EDS with the
-// 'es-operator.zalando.org/current-scaling-operation' annotation. The
-// annotation indicates the desired scaling which will be reconciled by the
+// indicated by the operation in the scaling state of the EDS status. The
+// operation indicates the desired scaling which will be reconciled by the
 // operator.
 func (o *ElasticsearchOperator) runAutoscaler(ctx context.Context) {
 	nextCheck := time.Now().Add(-o.autoscalerInterval)
@@ -297,12 +385,16 @@ func (o *ElasticsearchOperator) runAutoscaler(ctx context.Context) {
 			}
 
 			for _, es := range resources {
-				if es.ElasticsearchDataSet.Spec.Scaling != nil && es.ElasticsearchDataSet.Spec.Scaling.Enabled {
+				if es.ElasticsearchDataSet.Spec.Scaling != nil && es.ElasticsearchDataSet.Spec.Scaling.Enabled && !decommissionEnabled(es.ElasticsearchDataSet) {
 					endpoint := o.getElasticsearchEndpoint(es.ElasticsearchDataSet)
 					client := &ESClient{
 						Endpoint:             endpoint,
 						excludeSystemIndices: es.ElasticsearchDataSet.Spec.ExcludeSystemIndices,
 						DrainingConfig:       o.getDrainingConfig(es.ElasticsearchDataSet),
+						health:               es.ElasticsearchDataSet.Spec.Health,
+						criticalIndices:      es.ElasticsearchDataSet.Spec.CriticalIndices,
+						transport:            o.elasticsearchTransport(es.ElasticsearchDataSet),
+						distribution:         edsDistribution(es.ElasticsearchDataSet),
 					}
 
 					err := o.scaleEDS(ctx, es.ElasticsearchDataSet, es, client)
@@ -320,10 +412,16 @@ func (o *ElasticsearchOperator) runAutoscaler(ctx context.Context) {
 }
 
 type EDSResource struct {
-	eds      *zv1.ElasticsearchDataSet
-	kube     *clientset.Clientset
-	esClient *ESClient
-	recorder kube_record.EventRecorder
+	eds             *zv1.ElasticsearchDataSet
+	kube            *clientset.Clientset
+	esClient        *ESClient
+	recorder        kube_record.EventRecorder
+	masterTracker   *masterStabilityTracker
+	nodeLister      corelisters.NodeLister
+	externalDNSZone string
+	// status holds the results of the actions of OnObservedHook until
+	// they're recorded by UpdateStatus.
+	status *zv1.ElasticsearchDataSetStatus
 }
 
 func (r *EDSResource) Name() string {
@@ -350,6 +448,30 @@ func (r *EDSResource) LabelSelector() map[string]string {
 	return map[string]string{esDataSetLabelKey: r.Name()}
 }
 
+// AdditionalMetadata returns the additional labels and annotations of the
+// EDS. Labels and annotations set by the EDS itself take precedence.
+func (r *EDSResource) AdditionalMetadata() (map[string]string, map[string]string) {
+	template := r.eds.Spec.Template
+	labels := withoutKeys(r.eds.Spec.AdditionalResourceLabels, r.LabelSelector(), r.eds.Labels, template.Labels)
+	annotations := withoutKeys(r.eds.Spec.AdditionalResourceAnnotations, template.Annotations)
+	return labels, annotations
+}
+
+// applyOwnedResourceMetadata sets the labels of the EDS and the additional
+// metadata on a resource owned by the EDS. Returns true if the resource was
+// changed.
+func (r *EDSResource) applyOwnedResourceMetadata(meta *metav1.ObjectMeta) bool {
+	labels, annotations := r.AdditionalMetadata()
+	changed := applyAdditionalMetadata(meta, labels, annotations)
+	for k, v := range r.eds.Labels {
+		if current, ok := meta.Labels[k]; !ok || current != v {
+			meta.Labels[k] = v
+			changed = true
+		}
+	}
+	return changed
+}
+
 func (r *EDSResource) Generation() int64 {
 	return r.eds.Generation
 }
@@ -359,18 +481,48 @@ func (r *EDSResource) UID() types.UID {
 }
 
 func (r *EDSResource) Replicas() int32 {
-	return edsReplicas(r.eds)
+	replicas := edsReplicas(r.eds)
+	// replace the pods in lost zones.
+	if zoneFailure := r.eds.Status.ZoneFailure; zoneFailure != nil {
+		replicas += zoneFailure.ReplacementReplicas
+	}
+	return replicas
+}
+
+func (r *EDSResource) MaxFailingPods() *int32 {
+	return r.eds.Spec.MaxFailingPods
+}
+
+func (r *EDSResource) RolloutPaused() bool {
+	return rolloutPaused(r.eds)
+}
+
+func (r *EDSResource) ServingGate() *ServingGate {
+	return servingGate(r.eds.Spec.TrafficSteering, r.esClient)
 }
 
 func (r *EDSResource) PodTemplateSpec() *v1.PodTemplateSpec {
 	template := r.eds.Spec.Template.DeepCopy()
-	return &v1.PodTemplateSpec{
+	injectExtraPorts(&template.Spec, r.eds.Spec.ExtraPorts)
+	injectReadinessProbe(&template.Spec, r.eds.Spec.Health, edsLocalAccess(r.eds))
+	injectOrdinalOverrides(&template.Spec, r.eds.Spec.OrdinalOverrides)
+	injectHeapDumps(&template.Spec, r.eds.Name, r.eds.Spec.HeapDumps)
+	injectDiskPreparation(&template.Spec, r.eds.Spec.DiskPreparation)
+	injectServingGate(&template.Spec, r.ServingGate())
+	injectArchitecture(&template.Spec, r.eds.Spec.Architecture)
+	injectSchedulingGate(&template.Spec, r.eds.Spec.SchedulingGate)
+	podTemplate := &v1.PodTemplateSpec{
 		ObjectMeta: metav1.ObjectMeta{
 			Annotations: template.Annotations,
 			Labels:      template.Labels,
 		},
 		Spec: template.Spec,
 	}
+	injectCoordinatedTermination(podTemplate, r.eds.Spec.Lifecycle)
+	injectBackupHooks(podTemplate, r.eds.Spec.BackupHooks, edsLocalAccess(r.eds))
+	injectSecurityProfiles(&podTemplate.Spec, r.eds.Spec.PodSecurity)
+	injectPodSecurity(&podTemplate.Spec, r.eds.Spec.PodSecurity)
+	return podTemplate
 }
 
 func (r *EDSResource) VolumeClaimTemplates() []v1.PersistentVolumeClaim {
@@ -389,18 +541,35 @@ func (r *EDSResource) VolumeClaimTemplates() []v1.PersistentVolumeClaim {
 }
 
 func (r *EDSResource) EnsureResources(ctx context.Context) error {
-	// ensure PDB
-	err := r.ensurePodDisruptionBudget(ctx)
+	err := r.validateSecurityProfiles()
 	if err != nil {
 		return err
 	}
 
+	err = r.validatePodSecurity(ctx)
+	if err != nil {
+		return err
+	}
+
+	// ensure PDB
+	if featureEnabled(PDBManagement) {
+		err = r.ensurePodDisruptionBudget(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
 	// ensure service
 	err = r.ensureService(ctx)
 	if err != nil {
 		return err
 	}
 
+	err = r.ensurePodServices(ctx)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -409,7 +578,8 @@ func (r *EDSResource) Self() runtime.Object {
 }
 
 // ensurePodDisruptionBudget creates a PodDisruptionBudget for the
-// ElasticsearchDataSet if it doesn't already exist.
+// ElasticsearchDataSet if it doesn't already exist and keeps its labels and
+// annotations up to date.
 func (r *EDSResource) ensurePodDisruptionBudget(ctx context.Context) error {
 	var pdb *pv1.PodDisruptionBudget
 	var err error
@@ -463,7 +633,7 @@ func (r *EDSResource) ensurePodDisruptionBudget(ctx context.Context) error {
 		}
 	}
 
-	pdb.Labels = r.eds.Labels
+	updatePDB := r.applyOwnedResourceMetadata(&pdb.ObjectMeta) && !createPDB
 	pdb.Spec.Selector = &metav1.LabelSelector{
 		MatchLabels: matchLabels,
 	}
@@ -485,11 +655,27 @@ func (r *EDSResource) ensurePodDisruptionBudget(ctx context.Context) error {
 		))
 	}
 
+	if updatePDB {
+		_, err = r.kube.PolicyV1().PodDisruptionBudgets(pdb.Namespace).Update(ctx, pdb, metav1.UpdateOptions{})
+		if err != nil {
+			return fmt.Errorf(
+				"failed to update PodDisruptionBudget for %s %s/%s: %v",
+				r.eds.Kind,
+				r.eds.Namespace, r.eds.Name,
+				err,
+			)
+		}
+		r.recorder.Event(r.eds, v1.EventTypeNormal, "UpdatedPDB", fmt.Sprintf(
+			"Updated labels and annotations of PodDisruptionBudget '%s/%s' for %s",
+			pdb.Namespace, pdb.Name, r.eds.Kind,
+		))
+	}
+
 	return nil
 }
 
 // ensureService creates a Service for the ElasticsearchDataSet if it doesn't
-// already exist.
+// already exist and keeps its ports, labels and annotations up to date.
 func (r *EDSResource) ensureService(ctx context.Context) error {
 	var svc *v1.Service
 	var err error
@@ -542,17 +728,13 @@ func (r *EDSResource) ensureService(ctx context.Context) error {
 		}
 	}
 
-	svc.Labels = r.eds.Labels
+	ports := edsServicePorts(r.eds.Spec.ExtraPorts)
+	updatePorts := !createService && !equality.Semantic.DeepEqual(svc.Spec.Ports, ports)
+	updateMetadata := r.applyOwnedResourceMetadata(&svc.ObjectMeta) && !createService
+	updateService := updatePorts || updateMetadata
+
 	svc.Spec.Selector = matchLabels
-	// TODO: derive port from EDS
-	svc.Spec.Ports = []v1.ServicePort{
-		{
-			Name:       "elasticsearch",
-			Protocol:   v1.ProtocolTCP,
-			Port:       defaultElasticsearchDataSetEndpointPort,
-			TargetPort: intstr.FromInt(defaultElasticsearchDataSetEndpointPort),
-		},
-	}
+	svc.Spec.Ports = ports
 
 	if createService {
 		var err error
@@ -571,27 +753,81 @@ func (r *EDSResource) ensureService(ctx context.Context) error {
 		))
 	}
 
+	if updateService {
+		_, err = r.kube.CoreV1().Services(svc.Namespace).Update(ctx, svc, metav1.UpdateOptions{})
+		if err != nil {
+			return fmt.Errorf(
+				"failed to update Service for %s %s/%s: %v",
+				r.eds.Kind,
+				r.eds.Namespace, r.eds.Name,
+				err,
+			)
+		}
+		what := "ports"
+		if !updatePorts {
+			what = "labels and annotations"
+		}
+		r.recorder.Event(r.eds, v1.EventTypeNormal, "UpdatedService", fmt.Sprintf(
+			"Updated %s of Service '%s/%s' for %s",
+			what, svc.Namespace, svc.Name, r.eds.Kind,
+		))
+	}
+
 	return nil
 }
 
-// Drain drains a pod for Elasticsearch data.
+// Drain drains a pod for Elasticsearch data. Masters hold no data, their
+// vote is withdrawn instead.
 func (r *EDSResource) Drain(ctx context.Context, pod *v1.Pod) error {
+	if isMasterRole(r.eds) {
+		return r.esClient.ExcludeVotingNode(ctx, pod)
+	}
 	if r.eds.Spec.SkipDraining {
 		return nil
 	}
 	return r.esClient.Drain(ctx, pod)
 }
 
+// PrepareRestart lets Elasticsearch prepare for the restart of an undrained
+// data node, which keeps its data when its pod is recreated.
+func (r *EDSResource) PrepareRestart(ctx context.Context, pod *v1.Pod) error {
+	if isMasterRole(r.eds) || !r.eds.Spec.SkipDraining {
+		return nil
+	}
+	return r.esClient.PrepareRestart(ctx, pod)
+}
+
+// MaxParallelDrains returns the number of pods drained at once when scaling
+// down. Masters are drained one at a time to keep the voting quorum.
+func (r *EDSResource) MaxParallelDrains() int {
+	if isMasterRole(r.eds) {
+		return 1
+	}
+	return r.esClient.DrainingConfig.MaxParallelDrains
+}
+
 // PreScaleDownHook ensures that the IndexReplicas is set as defined in the EDS
-// 'scaling-operation' annotation prior to scaling down the internal
-// StatefulSet.
+// scaling operation prior to scaling down the internal
+// StatefulSet. Scale-downs from outside of the autoscaler are blocked if the
+// remaining nodes can't hold all copies of the indices. Masters hold no
+// indices.
 func (r *EDSResource) PreScaleDownHook(ctx context.Context) error {
+	if isMasterRole(r.eds) {
+		return nil
+	}
+	err := r.checkExternalScaleDown(ctx)
+	if err != nil {
+		return err
+	}
 	return r.applyScalingOperation(ctx)
 }
 
 // OnStableReplicasHook ensures that the indexReplicas is set as defined in the
-// EDS scaling-operation annotation.
+// EDS scaling operation. The voting exclusions of masters are removed.
 func (r *EDSResource) OnStableReplicasHook(ctx context.Context) error {
+	if isMasterRole(r.eds) {
+		return r.esClient.clearVotingExclusions()
+	}
 	err := r.applyScalingOperation(ctx)
 	if err != nil {
 		return err
@@ -601,38 +837,193 @@ func (r *EDSResource) OnStableReplicasHook(ctx context.Context) error {
 	return r.esClient.Cleanup(ctx)
 }
 
-// UpdateStatus updates the status of the EDS to set the current replicas from
-// StatefulSet and updating the observedGeneration.
-func (r *EDSResource) UpdateStatus(ctx context.Context, sts *appsv1.StatefulSet) error {
-	observedGeneration := int64(0)
-	if r.eds.Status.ObservedGeneration != nil {
-		observedGeneration = *r.eds.Status.ObservedGeneration
+// OnObservedHook takes the actions of the EDS which depend on the observed
+// StatefulSet and pods: undoing and rolling back rollouts, adjusting the
+// memory after OOM kills, allocating indices, decommissioning and checking
+// the recovery from scale-downs. Their results are recorded in the status by
+// the following UpdateStatus.
+func (r *EDSResource) OnObservedHook(ctx context.Context, sts *appsv1.StatefulSet, pods []*v1.Pod) error {
+	err := r.undoRollout(ctx)
+	if err != nil {
+		return err
+	}
+
+	status := r.eds.Status.DeepCopy()
+	err = r.handleOOMKills(ctx, pods, status)
+	if err != nil {
+		return err
 	}
 
-	replicas := int32(0)
+	status.AllocatedIndices = r.allocateIndices(sts, &status.Conditions)
+
+	err = r.decommission(ctx, pods, &status.Conditions, &status.IngestionPauseBlockedIndices)
+	if err != nil {
+		return err
+	}
+
+	status.RolloutProgress = rolloutProgress(r.eds.Generation, sts, pods)
+	if status.RolloutProgress != nil {
+		status.RolloutProgress.Paused = rolloutPaused(r.eds)
+	}
+	history := r.templateHistory(getSTSParentGeneration(sts), time.Now())
+	status.TemplateHistory, err = r.autoRollback(ctx, status.RolloutProgress, history, &status.Conditions, time.Now())
+	if err != nil {
+		return err
+	}
+
+	status.ScaleDownRecovery, err = r.scaleDownRecovery(ctx, sts, time.Now())
+	if err != nil {
+		return err
+	}
+
+	r.status = status
+	return nil
+}
+
+// UpdateStatus updates the status of the EDS to set the current and ready
+// replicas, resolved images, failing pods, master status, rollout progress,
+// conditions and schema version and updating the observedGeneration. The
+// results of the preceding OnObservedHook are recorded along with them.
+func (r *EDSResource) UpdateStatus(ctx context.Context, sts *appsv1.StatefulSet, pods []*v1.Pod) error {
+	status := r.status
+	if status == nil {
+		status = r.eds.Status.DeepCopy()
+	}
+
+	// generation of the EDS reconciled into the StatefulSet.
+	generation := r.eds.Generation
+	status.ObservedGeneration = &generation
+
+	status.Replicas = 0
 	if sts.Spec.Replicas != nil {
-		replicas = *sts.Spec.Replicas
+		status.Replicas = *sts.Spec.Replicas
+	}
+	status.ReadyReplicas = sts.Status.ReadyReplicas
+
+	selector, err := statefulSetSelector(sts)
+	if err != nil {
+		return err
+	}
+	status.Selector = selector
+	status.SchemaVersion = currentSchemaVersion
+
+	status.ResolvedImages = pinnedImages(&sts.Spec.Template.Spec)
+	if len(status.ResolvedImages) == 0 {
+		status.ResolvedImages = nil
+	}
+
+	status.FailingPods = podFailures(pods, r.nodeLister, time.Now())
+	if len(status.FailingPods) == 0 {
+		status.FailingPods = nil
+	}
+
+	status.Master = r.masterStatus()
+	status.Burst = r.burstStatus(time.Now())
+
+	// the unhealthy time of the rollout is tracked by the auto rollback.
+	progress := rolloutProgress(generation, sts, pods)
+	if progress != nil {
+		progress.Paused = rolloutPaused(r.eds)
+		if previous := status.RolloutProgress; previous != nil && previous.UpdateRevision == progress.UpdateRevision {
+			progress.UnhealthySince = previous.UnhealthySince
+		}
+	}
+	status.RolloutProgress = progress
+
+	status.ZoneFailure = r.zoneFailureStatus(pods)
+	setZoneFailureCondition(r.eds, &status.Conditions, status.ZoneFailure)
+	r.setReadinessConditions(sts, progress, status.FailingPods, &status.Conditions)
+	r.setActivityConditions(sts, pods, status.ScaleDownRecovery, &status.Conditions)
+	if len(status.Conditions) == 0 {
+		status.Conditions = nil
 	}
 
-	if r.eds.Generation != observedGeneration ||
-		r.eds.Status.Replicas != replicas {
-		r.eds.Status.Replicas = replicas
-		r.eds.Status.ObservedGeneration = &r.eds.Generation
-		eds, err := r.kube.ZalandoV1().ElasticsearchDataSets(r.eds.Namespace).UpdateStatus(ctx, r.eds, metav1.UpdateOptions{})
+	if !reflect.DeepEqual(r.eds.Status, *status) {
+		oldStatus := r.eds.Status.DeepCopy()
+		r.eds.Status = *status
+		eds, err := patchEDSStatus(ctx, r.kube.ZalandoV1(), r.eds, oldStatus)
 		if err != nil {
 			return err
 		}
-
-		// set TypeMeta manually because of this bug:
-		// https://github.com/kubernetes/client-go/issues/308
-		eds.APIVersion = "zalando.org/v1"
-		eds.Kind = "ElasticsearchDataSet"
 		r.eds = eds
 	}
 
 	return nil
 }
 
+// statefulSetSelector returns the label selector of the StatefulSet in its
+// string form as used by the scale subresource.
+func statefulSetSelector(sts *appsv1.StatefulSet) (string, error) {
+	if sts.Spec.Selector == nil {
+		return "", nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(sts.Spec.Selector)
+	if err != nil {
+		return "", fmt.Errorf("invalid selector of StatefulSet %s/%s: %v", sts.Namespace, sts.Name, err)
+	}
+	return selector.String(), nil
+}
+
+// handleOOMKills counts the OOM kills of the pods into the status and
+// adjusts the memory of the EDS once enough of them happened. The count is
+// reset after an adjustment.
+func (r *EDSResource) handleOOMKills(ctx context.Context, pods []*v1.Pod, status *zv1.ElasticsearchDataSetStatus) error {
+	newOOMKills, lastOOMKill := countOOMKills(pods, status.LastOOMKill)
+	status.OOMKills += newOOMKills
+	status.LastOOMKill = lastOOMKill
+	if newOOMKills > 0 && heapDumpsEnabled(r.eds) {
+		r.recorder.Event(r.eds, v1.EventTypeWarning, "OOMKilled", heapDumpsMessage(r.eds, newOOMKills))
+	}
+	if !memoryAdjustmentDue(r.eds.Spec.MemoryAdjustment, status.OOMKills) {
+		return nil
+	}
+
+	adjusted, err := r.adjustMemory(ctx, status.OOMKills)
+	if err != nil {
+		return err
+	}
+	if adjusted {
+		status.OOMKills = 0
+	}
+	return nil
+}
+
+// adjustMemory increases the memory of the EDS pods after repeated OOM kills.
+// The changed pod template is rolled out like any other update of the EDS. It
+// returns false if the memory is already at the configured maximum.
+func (r *EDSResource) adjustMemory(ctx context.Context, oomKills int32) (bool, error) {
+	adjustment := r.eds.Spec.MemoryAdjustment
+	containerName := adjustment.ContainerName
+	if containerName == "" {
+		containerName = defaultRolloutContainerName
+	}
+
+	eds := r.eds.DeepCopy()
+	memory, err := increaseContainerMemory(&eds.Spec.Template.Spec, containerName, adjustment.Step, adjustment.Max)
+	if err != nil {
+		return false, fmt.Errorf("failed to adjust memory of EDS %s/%s: %v", r.eds.Namespace, r.eds.Name, err)
+	}
+	if memory == nil {
+		return false, nil
+	}
+
+	eds, err = r.kube.ZalandoV1().ElasticsearchDataSets(eds.Namespace).Update(ctx, eds, metav1.UpdateOptions{})
+	if err != nil {
+		return false, fmt.Errorf("failed to adjust memory of EDS %s/%s: %v", r.eds.Namespace, r.eds.Name, err)
+	}
+
+	// set TypeMeta manually because of this bug:
+	// https://github.com/kubernetes/client-go/issues/308
+	eds.APIVersion = "zalando.org/v1"
+	eds.Kind = "ElasticsearchDataSet"
+	r.eds = eds
+
+	r.recorder.Event(r.eds, v1.EventTypeNormal, "AdjustedMemory", fmt.Sprintf(
+		"Increased memory of container '%s' to %s after %d OOM kills", containerName, memory.String(), oomKills,
+	))
+	return true, nil
+}
+
 func (r *EDSResource) applyScalingOperation(ctx context.Context) error {
 	operation, err := edsScalingOperation(r.eds)
 	if err != nil {
@@ -640,11 +1031,14 @@ func (r *EDSResource) applyScalingOperation(ctx context.Context) error {
 	}
 
 	if operation != nil && operation.ScalingDirection != NONE {
-		err = r.esClient.UpdateIndexSettings(operation.IndexReplicas)
-		if err != nil {
-			return err
+		// the index replicas only fit the node replicas of the operation.
+		if !staleScalingOperation(r.eds, operation) {
+			err = r.esClient.UpdateIndexSettings(operation.IndexReplicas)
+			if err != nil {
+				return err
+			}
 		}
-		err = r.removeScalingOperationAnnotation(ctx)
+		err = r.removeScalingOperation(ctx)
 		if err != nil {
 			return err
 		}
@@ -652,22 +1046,6 @@ func (r *EDSResource) applyScalingOperation(ctx context.Context) error {
 	return nil
 }
 
-// removeScalingOperationAnnotation removes the 'scaling-operation' annotation
-// from the EDS.
-// If the annotation is already gone, this is a no-op.
-func (r *EDSResource) removeScalingOperationAnnotation(ctx context.Context) error {
-	if _, ok := r.eds.Annotations[esScalingOperationKey]; ok {
-		delete(r.eds.Annotations, esScalingOperationKey)
-		_, err := r.kube.ZalandoV1().
-			ElasticsearchDataSets(r.eds.Namespace).
-			Update(ctx, r.eds, metav1.UpdateOptions{})
-		if err != nil {
-			return fmt.Errorf("failed to remove 'scaling-operating' annotation from EDS: %v", err)
-		}
-	}
-	return nil
-}
-
 // calls kubernetes APIs to refresh itself
 func (r *EDSResource) Get(ctx context.Context) (StatefulResource, error) {
 	newEds, err := r.kube.ZalandoV1().ElasticsearchDataSets(r.eds.Namespace).Get(ctx, r.eds.Name, metav1.GetOptions{})
@@ -680,10 +1058,13 @@ func (r *EDSResource) Get(ctx context.Context) (StatefulResource, error) {
 	newEds.Kind = r.Kind()
 	newEds.APIVersion = r.APIVersion()
 	sr := &EDSResource{
-		eds:      newEds,
-		kube:     r.kube,
-		esClient: r.esClient, // TODO: think about not setting this twice
-		recorder: r.recorder,
+		eds:             newEds,
+		kube:            r.kube,
+		esClient:        r.esClient, // TODO: think about not setting this twice
+		recorder:        r.recorder,
+		masterTracker:   r.masterTracker,
+		nodeLister:      r.nodeLister,
+		externalDNSZone: r.externalDNSZone,
 	}
 
 	return sr, nil
@@ -699,15 +1080,29 @@ func (o *ElasticsearchOperator) operateEDS(eds *zv1.ElasticsearchDataSet, delete
 	o.Lock()
 	defer o.Unlock()
 
+	if o.stopped {
+		return nil
+	}
+
 	// restart if already being operated on.
 	if entry, ok := o.operating[eds.UID]; ok {
+		// the EDS is reconciled again without changes, e.g. on a resync of
+		// the cache.
+		if !deleted && entry.resourceVersion == eds.ResourceVersion {
+			return nil
+		}
 		entry.cancel()
 		// wait for previous operation to terminate
 		entry.logger.Infof("Waiting for operation to stop")
 		<-entry.doneCh
+		delete(o.operating, eds.UID)
 	}
 
 	if deleted {
+		delete(o.masterTrackers, eds.UID)
+		delete(o.drainingPauses, eds.UID)
+		deleteMasterStabilityMetrics(eds.Namespace, eds.Name)
+		o.scalingSampleWrites.forget(eds.UID)
 		return nil
 	}
 
@@ -716,6 +1111,15 @@ func (o *ElasticsearchOperator) operateEDS(eds *zv1.ElasticsearchDataSet, delete
 		return nil
 	}
 
+	err := checkSchemaVersion(eds)
+	if err != nil {
+		o.logger.Errorf("Skipping EDS %s/%s: %v", eds.Namespace, eds.Name, err)
+		o.recorder.Event(eds, v1.EventTypeWarning, "SchemaVersionSkew", fmt.Sprintf(
+			"Not managing EDS: %v. Upgrade the operator.", err),
+		)
+		return nil
+	}
+
 	doneCh := make(chan struct{})
 	ctx, cancel := context.WithCancel(context.Background())
 
@@ -725,17 +1129,42 @@ func (o *ElasticsearchOperator) operateEDS(eds *zv1.ElasticsearchDataSet, delete
 	})
 	// add to operating table
 	o.operating[eds.UID] = operatingEntry{
-		cancel: cancel,
-		doneCh: doneCh,
-		logger: logger,
+		cancel:          cancel,
+		doneCh:          doneCh,
+		logger:          logger,
+		namespace:       eds.Namespace,
+		name:            eds.Name,
+		resourceVersion: eds.ResourceVersion,
+		started:         time.Now().UTC(),
 	}
 
 	endpoint := o.getElasticsearchEndpoint(eds)
 
 	// TODO: abstract this
 	client := &ESClient{
-		Endpoint:       endpoint,
-		DrainingConfig: o.getDrainingConfig(eds),
+		Endpoint:        endpoint,
+		DrainingConfig:  o.getDrainingConfig(eds),
+		health:          eds.Spec.Health,
+		criticalIndices: eds.Spec.CriticalIndices,
+		drainWriteBlock: eds.Spec.DrainWriteBlock,
+		transport:       o.elasticsearchTransport(eds),
+		distribution:    edsDistribution(eds),
+		recordEvent: func(eventType, reason, message string) {
+			o.recorder.Event(eds, eventType, reason, message)
+		},
+	}
+
+	// keep the paused draining and the observed master elections across
+	// restarts of the operation.
+	pause, ok := o.drainingPauses[eds.UID]
+	if !ok {
+		pause = &drainingPause{}
+		o.drainingPauses[eds.UID] = pause
+	}
+	tracker, ok := o.masterTrackers[eds.UID]
+	if !ok {
+		tracker = &masterStabilityTracker{}
+		o.masterTrackers[eds.UID] = tracker
 	}
 
 	operator := &Operator{
@@ -743,16 +1172,24 @@ func (o *ElasticsearchOperator) operateEDS(eds *zv1.ElasticsearchDataSet, delete
 		podInformer:           o.podInformer,
 		nodeInformer:          o.nodeInformer,
 		priorityNodeSelectors: o.priorityNodeSelectors,
+		imageRewriteRules:     o.imageRewriteRules,
+		imageResolver:         o.imageResolver,
 		interval:              o.interval,
 		logger:                logger,
 		recorder:              o.recorder,
+		drainingPause:         pause,
 	}
 
 	rs := &EDSResource{
-		eds:      eds,
-		kube:     o.kube,
-		esClient: client, // TODO: think about not setting this twice
-		recorder: o.recorder,
+		eds:             eds,
+		kube:            o.kube,
+		esClient:        client, // TODO: think about not setting this twice
+		recorder:        o.recorder,
+		masterTracker:   tracker,
+		externalDNSZone: o.externalDNSZone,
+	}
+	if o.nodeInformer != nil {
+		rs.nodeLister = o.nodeInformer.Lister()
 	}
 
 	go operator.Run(ctx, doneCh, rs)
@@ -760,14 +1197,15 @@ func (o *ElasticsearchOperator) operateEDS(eds *zv1.ElasticsearchDataSet, delete
 	return nil
 }
 
-// hasOwnership returns true if the operator is the "owner" of the EDS.
+// hasOwnership returns true if the operator is the "owner" of the EDS (or
+// any other resource managed by the operator).
 // Whether it's owner is determined by the value of the
 // 'es-operator.zalando.org/operator' annotation. If the value
 // matches the operatorID then it owns it, or if the operatorID is
 // "" and there's no annotation set.
-func (o *ElasticsearchOperator) hasOwnership(eds *zv1.ElasticsearchDataSet) bool {
-	if eds.Annotations != nil {
-		if owner, ok := eds.Annotations[esOperatorAnnotationKey]; ok {
+func (o *ElasticsearchOperator) hasOwnership(obj metav1.Object) bool {
+	if annotations := obj.GetAnnotations(); annotations != nil {
+		if owner, ok := annotations[esOperatorAnnotationKey]; ok {
 			return owner == o.operatorID
 		}
 	}
@@ -779,9 +1217,14 @@ func (o *ElasticsearchOperator) getElasticsearchEndpoint(eds *zv1.ElasticsearchD
 		return o.elasticsearchEndpoint
 	}
 
+	scheme := "http"
+	if eds.Spec.TLS != nil {
+		scheme = "https"
+	}
+
 	// TODO: discover port from EDS
 	return &url.URL{
-		Scheme: "http",
+		Scheme: scheme,
 		Host: fmt.Sprintf(
 			"%s.%s.svc.%s:%d",
 			eds.Name,
@@ -797,15 +1240,29 @@ func (o *ElasticsearchOperator) getDrainingConfig(eds *zv1.ElasticsearchDataSet)
 	// Fallback to default configurations if draining configuration is not specified.
 	if eds.Spec.Experimental == nil || eds.Spec.Experimental.Draining == nil {
 		return &DrainingConfig{
-			MaxRetries:      999,
-			MinimumWaitTime: 10 * time.Second,
-			MaximumWaitTime: 30 * time.Second,
+			MaxRetries:        999,
+			MinimumWaitTime:   10 * time.Second,
+			MaximumWaitTime:   30 * time.Second,
+			MaxParallelDrains: 1,
 		}
 	}
+	nodeShutdown := eds.Spec.Experimental.Draining.NodeShutdown
+	var taskDeadline time.Duration
+	if seconds := eds.Spec.Experimental.Draining.TaskDeadlineSeconds; seconds != nil {
+		taskDeadline = time.Duration(*seconds) * time.Second
+	}
+	maxParallelDrains := 1
+	if parallel := eds.Spec.Experimental.Draining.MaxParallelDrains; parallel != nil && *parallel > 1 {
+		maxParallelDrains = int(*parallel)
+	}
 	return &DrainingConfig{
-		MaxRetries:      int(eds.Spec.Experimental.Draining.MaxRetries),
-		MinimumWaitTime: time.Duration(eds.Spec.Experimental.Draining.MinimumWaitTimeDurationSeconds) * time.Second,
-		MaximumWaitTime: time.Duration(eds.Spec.Experimental.Draining.MaximumWaitTimeDurationSeconds) * time.Second,
+		MaxRetries:        int(eds.Spec.Experimental.Draining.MaxRetries),
+		MinimumWaitTime:   time.Duration(eds.Spec.Experimental.Draining.MinimumWaitTimeDurationSeconds) * time.Second,
+		MaximumWaitTime:   time.Duration(eds.Spec.Experimental.Draining.MaximumWaitTimeDurationSeconds) * time.Second,
+		NodeShutdown:      featureEnabled(GracefulShutdownAPI) && nodeShutdown != nil && *nodeShutdown,
+		TaskDeadline:      taskDeadline,
+		CancelTasks:       eds.Spec.Experimental.Draining.CancelTasks,
+		MaxParallelDrains: maxParallelDrains,
 	}
 }
 
@@ -826,7 +1283,7 @@ func (es *ESResource) Replicas() int32 {
 // In case it was not specified, it will return '1'.
 func edsReplicas(eds *zv1.ElasticsearchDataSet) int32 {
 	scaling := eds.Spec.Scaling
-	if scaling == nil || !scaling.Enabled {
+	if scaling == nil || !scaling.Enabled || scaling.Shadow {
 		if eds.Spec.Replicas == nil {
 			return 1
 		}
@@ -859,6 +1316,12 @@ func (o *ElasticsearchOperator) collectResources(ctx context.Context) (map[types
 			continue
 		}
 
+		err := checkSchemaVersion(&eds)
+		if err != nil {
+			o.logger.Errorf("Skipping EDS %s/%s: %v", eds.Namespace, eds.Name, err)
+			continue
+		}
+
 		// set TypeMeta manually because of this bug:
 		// https://github.com/kubernetes/client-go/issues/308
 		eds.APIVersion = "zalando.org/v1"
@@ -869,13 +1332,13 @@ func (o *ElasticsearchOperator) collectResources(ctx context.Context) (map[types
 		}
 	}
 
-	metricSets, err := o.kube.ZalandoV1().ElasticsearchMetricSets(o.namespace).List(ctx, metav1.ListOptions{})
+	metricSets, err := o.metricStore.List(ctx, o.namespace)
 	if err != nil {
 		return nil, err
 	}
 
 	// Map metricSets to the owning ElasticsearchDataSet resource.
-	for _, ms := range metricSets.Items {
+	for _, ms := range metricSets {
 		ms := ms
 		if uid, ok := getOwnerUID(ms.ObjectMeta); ok {
 			if er, ok := resources[uid]; ok {
@@ -914,14 +1377,21 @@ func (o *ElasticsearchOperator) scaleEDS(ctx context.Context, eds *zv1.Elasticse
 		return err
 	}
 
-	// first, try to find an existing annotation and return it
+	// don't change the number of pods while their volumes are backed up.
+	if paused, reason := backupPaused(ctx, o.kube, eds); paused {
+		o.logger.Infof("Not scaling EDS %s/%s: %s", eds.Namespace, eds.Name, reason)
+		return nil
+	}
+
+	// first, try to find an existing operation and return it
 	scalingOperation, err := edsScalingOperation(eds)
 	if err != nil {
 		return err
 	}
 
-	// exit early if the scaling operation is already defined
-	if scalingOperation != nil && scalingOperation.ScalingDirection != NONE {
+	// exit early if the scaling operation is already defined, stale
+	// operations are replaced.
+	if scalingOperation != nil && scalingOperation.ScalingDirection != NONE && !staleScalingOperation(eds, scalingOperation) {
 		return nil
 	}
 
@@ -935,44 +1405,115 @@ func (o *ElasticsearchOperator) scaleEDS(ctx context.Context, eds *zv1.Elasticse
 	as := NewAutoScaler(es, o.metricsInterval, client)
 
 	if scaling != nil && scaling.Enabled {
+		if scaling.Shadow {
+			return o.shadowScaling(eds, as, currentReplicas)
+		}
+
+		// a hot shard is moved instead of scaling out.
+		var rerouted bool
+		eds, rerouted, err = o.handleHotShards(ctx, eds, es, client)
+		if err != nil {
+			o.logger.Warnf("Failed to check hot shards of EDS %s/%s: %v", namespace, name, err)
+		} else if rerouted {
+			return nil
+		}
+
 		scalingOperation, err := as.GetScalingOperation()
 		if err != nil {
 			return err
 		}
+		setScalingBlockedMetric(eds, scalingOperation)
+
+		eds, err = o.updatePrimariesRecommendations(ctx, eds, scalingOperation.ScalingHint, scalingOperation.PrimariesRecommendations)
+		if err != nil {
+			o.logger.Warnf("Failed to update primaries recommendations of EDS %s/%s: %v", namespace, name, err)
+		}
+
+		eds, err = o.adjustShardLimit(ctx, eds, client, scalingOperation)
+		if err != nil {
+			// don't scale down without the raised shard limit.
+			if scalingOperation.RaiseShardLimit > 0 {
+				return err
+			}
+			o.logger.Warnf("Failed to restore the shard limit of EDS %s/%s: %v", namespace, name, err)
+		}
+
+		if len(scalingOperation.OversizedShards) > 0 {
+			largest := scalingOperation.OversizedShards[0]
+			o.recorder.Event(eds, v1.EventTypeWarning, "OversizedShards", fmt.Sprintf(
+				"%d shards are larger than %s, the largest is shard %d of index %s with %s",
+				len(scalingOperation.OversizedShards), scaling.ShardSize.MaxShardSize.String(), largest.Shard, largest.Index,
+				resource.NewQuantity(largest.Bytes, resource.BinarySI).String()))
+		}
+
+		overprovisionedReplicasMetric.WithLabelValues(namespace, name).Set(float64(scalingOperation.OverprovisionedReplicas))
+
+		var operation *ScalingOperation
+		if scalingOperation.ScalingDirection != NONE {
+			operation = scalingOperation
+		}
+		oldStatus := eds.Status.DeepCopy()
+		evaluated := time.Now()
+		scaleUpSamples, scaleDownSamples := as.sampleWindows()
+		scalingState := updateScalingState(eds.Status.ScalingState, scalingOperation.ScalingHint, scaleUpSamples, scaleDownSamples, operation)
+		// the sample counters change with every new metric, they are
+		// only written at a lower rate.
+		statusChanged := !equality.Semantic.DeepEqual(eds.Status.ScalingState, scalingState) &&
+			(!scalingSamplesOnly(eds.Status.ScalingState, scalingState) || o.scalingSampleWrites.allow(eds.UID, evaluated))
+		if statusChanged {
+			eds.Status.ScalingState = scalingState
+		}
 
 		// update EDS definition.
-		if scalingOperation.NodeReplicas != nil && *scalingOperation.NodeReplicas != currentReplicas {
+		replicasChanged := scalingOperation.NodeReplicas != nil && (*scalingOperation.NodeReplicas != currentReplicas ||
+			scalingOperation.OverprovisionedReplicas != eds.Status.OverprovisionedReplicas)
+		if replicasChanged {
 			now := metav1.Now()
 			if *scalingOperation.NodeReplicas > currentReplicas {
 				eds.Status.LastScaleUpStarted = &now
-			} else {
+			} else if *scalingOperation.NodeReplicas < currentReplicas {
 				eds.Status.LastScaleDownStarted = &now
 			}
+			if *scalingOperation.NodeReplicas != currentReplicas {
+				eds.Status.ScaleDownRecovery = scaleDownRecoveryStatus(scaling, currentReplicas, *scalingOperation.NodeReplicas)
+			}
+			eds.Status.OverprovisionedReplicas = scalingOperation.OverprovisionedReplicas
 			log.Infof("Updating last scaling event in EDS '%s/%s'", namespace, name)
+			statusChanged = true
+		}
 
-			// update status
-			eds, err = o.kube.ZalandoV1().ElasticsearchDataSets(eds.Namespace).UpdateStatus(ctx, eds, metav1.UpdateOptions{})
+		// update status before the replicas, so the operation is known
+		// before pods are drained.
+		if statusChanged {
+			eds, err = patchEDSStatus(ctx, o.kube.ZalandoV1(), eds, oldStatus)
 			if err != nil {
 				return err
 			}
-			eds.Spec.Replicas = scalingOperation.NodeReplicas
+			o.scalingSampleWrites.record(eds.UID, evaluated)
 		}
-
-		// TODO: move to a function
-		jsonBytes, err := json.Marshal(scalingOperation)
-		if err != nil {
-			return err
+		if replicasChanged {
+			eds.Spec.Replicas = scalingOperation.NodeReplicas
 		}
 
 		if scalingOperation.ScalingDirection != NONE {
-			eds.Annotations[esScalingOperationKey] = string(jsonBytes)
+			setSchemaVersion(eds)
 
 			// persist changes of EDS
 			log.Infof("Updating desired scaling for EDS '%s/%s'. New desired replicas: %d. %s", namespace, name, *eds.Spec.Replicas, scalingOperation.Description)
+			o.decisions.Record("Scaling", namespace, name, fmt.Sprintf("%s to %d replicas. %s", scalingOperation.ScalingDirection, *eds.Spec.Replicas, scalingOperation.Description))
 			_, err = o.kube.ZalandoV1().ElasticsearchDataSets(eds.Namespace).Update(ctx, eds, metav1.UpdateOptions{})
 			if err != nil {
 				return err
 			}
+
+			// scale up is caused by high CPU usage, capture what the
+			// nodes are busy with.
+			if scalingOperation.ScalingDirection == UP && scaling.CaptureHotThreads {
+				err = o.captureHotThreads(ctx, es.ElasticsearchDataSet, es.Pods, client, fmt.Sprintf("Scaling up: %s", scalingOperation.Description))
+				if err != nil {
+					log.Warnf("Failed to capture hot threads for EDS '%s/%s': %v", namespace, name, err)
+				}
+			}
 		}
 
 	}
@@ -1001,21 +1542,6 @@ func templateInjectLabels(template v1.PodTemplateSpec, labels map[string]string)
 	return template
 }
 
-// edsScalingOperation returns the scaling operation read from the
-// scaling-operation annotation on the EDS. If no operation is defined it will
-// return nil.
-func edsScalingOperation(eds *zv1.ElasticsearchDataSet) (*ScalingOperation, error) {
-	if op, ok := eds.Annotations[esScalingOperationKey]; ok {
-		scalingOperation := &ScalingOperation{}
-		err := json.Unmarshal([]byte(op), scalingOperation)
-		if err != nil {
-			return nil, err
-		}
-		return scalingOperation, nil
-	}
-	return nil, nil
-}
-
 // validateScalingSettings checks that the scaling settings are valid.
 //
 //   - min values can not be > than the corresponding max values.
@@ -1070,5 +1596,13 @@ func validateScalingSettings(scaling *zv1.ElasticsearchDataSetScaling) error {
 		)
 	}
 
-	return nil
+	if scaling.ScaleDownCPUBoundary > scaling.ScaleUpCPUBoundary {
+		return fmt.Errorf(
+			"scaleDownCPUBoundary(%d) can't be greater than scaleUpCPUBoundary(%d)",
+			scaling.ScaleDownCPUBoundary,
+			scaling.ScaleUpCPUBoundary,
+		)
+	}
+
+	return validateScalingGuards(scaling.ScaleDownGuards)
 }
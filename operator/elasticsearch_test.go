@@ -10,6 +10,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -43,7 +44,7 @@ func TestGetElasticsearchEndpoint(t *testing.T) {
 	faker := &clientset.Clientset{
 		Interface: fake.NewSimpleClientset(),
 	}
-	esOperator := NewElasticsearchOperator(faker, nil, 1*time.Second, 1*time.Second, "", "", "cluster.local.", nil)
+	esOperator := NewElasticsearchOperator(faker, Options{Interval: 1 * time.Second, AutoscalerInterval: 1 * time.Second, ClusterDNSZone: "cluster.local."})
 
 	eds := &zv1.ElasticsearchDataSet{
 		ObjectMeta: metav1.ObjectMeta{
@@ -59,7 +60,7 @@ func TestGetElasticsearchEndpoint(t *testing.T) {
 	customEndpoint, err := url.Parse(customURL)
 	assert.NoError(t, err)
 
-	esOperator = NewElasticsearchOperator(faker, nil, 1*time.Second, 1*time.Second, "", "", ".cluster.local.", customEndpoint)
+	esOperator = NewElasticsearchOperator(faker, Options{Interval: 1 * time.Second, AutoscalerInterval: 1 * time.Second, ClusterDNSZone: ".cluster.local.", ElasticsearchEndpoint: customEndpoint})
 	url = esOperator.getElasticsearchEndpoint(eds)
 	assert.Equal(t, customURL, url.String())
 }
@@ -68,7 +69,7 @@ func TestGetEmptyElasticSearchDrainingSpec(t *testing.T) {
 	faker := &clientset.Clientset{
 		Interface: fake.NewSimpleClientset(),
 	}
-	esOperator := NewElasticsearchOperator(faker, nil, 1*time.Second, 1*time.Second, "", "", "cluster.local.", nil)
+	esOperator := NewElasticsearchOperator(faker, Options{Interval: 1 * time.Second, AutoscalerInterval: 1 * time.Second, ClusterDNSZone: "cluster.local."})
 
 	eds := &zv1.ElasticsearchDataSet{
 		ObjectMeta: metav1.ObjectMeta{
@@ -82,13 +83,18 @@ func TestGetEmptyElasticSearchDrainingSpec(t *testing.T) {
 	assert.Equal(t, config.MaxRetries, 999)
 	assert.Equal(t, config.MinimumWaitTime, 10*time.Second)
 	assert.Equal(t, config.MaximumWaitTime, 30*time.Second)
+	assert.False(t, config.NodeShutdown)
+	assert.Equal(t, 1, config.MaxParallelDrains)
 }
 
 func TestGetNotEmptyElasticSearchDrainingSpec(t *testing.T) {
+	SetFeatureGates(FeatureGates{GracefulShutdownAPI: true})
+	defer SetFeatureGates(nil)
+
 	faker := &clientset.Clientset{
 		Interface: fake.NewSimpleClientset(),
 	}
-	esOperator := NewElasticsearchOperator(faker, nil, 1*time.Second, 1*time.Second, "", "", "cluster.local.", nil)
+	esOperator := NewElasticsearchOperator(faker, Options{Interval: 1 * time.Second, AutoscalerInterval: 1 * time.Second, ClusterDNSZone: "cluster.local."})
 
 	eds := &zv1.ElasticsearchDataSet{
 		ObjectMeta: metav1.ObjectMeta{
@@ -111,6 +117,16 @@ func TestGetNotEmptyElasticSearchDrainingSpec(t *testing.T) {
 	assert.Equal(t, config.MaxRetries, 7)
 	assert.Equal(t, config.MinimumWaitTime, 2*time.Second)
 	assert.Equal(t, config.MaximumWaitTime, 34*time.Second)
+	assert.False(t, config.NodeShutdown)
+	assert.Equal(t, 1, config.MaxParallelDrains)
+
+	enabled := true
+	eds.Spec.Experimental.Draining.NodeShutdown = &enabled
+	assert.True(t, esOperator.getDrainingConfig(eds).NodeShutdown)
+
+	parallel := int32(4)
+	eds.Spec.Experimental.Draining.MaxParallelDrains = &parallel
+	assert.Equal(t, 4, esOperator.getDrainingConfig(eds).MaxParallelDrains)
 }
 
 func TestGetOwnerUID(t *testing.T) {
@@ -145,6 +161,23 @@ func TestTemplateInjectLabels(t *testing.T) {
 	assert.Equal(t, expectedTemplate, newTemplate)
 }
 
+func TestStatefulSetSelector(t *testing.T) {
+	sts := &appsv1.StatefulSet{
+		Spec: appsv1.StatefulSetSpec{
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"es.zalan.do/group": "data", "application": "es"},
+			},
+		},
+	}
+	selector, err := statefulSetSelector(sts)
+	require.NoError(t, err)
+	assert.Equal(t, "application=es,es.zalan.do/group=data", selector)
+
+	selector, err = statefulSetSelector(&appsv1.StatefulSet{})
+	require.NoError(t, err)
+	assert.Empty(t, selector)
+}
+
 func TestValidateScalingSettings(tt *testing.T) {
 	for _, tc := range []struct {
 		msg     string
@@ -228,6 +261,21 @@ func TestValidateScalingSettings(tt *testing.T) {
 			},
 			err: true,
 		},
+		{
+			msg: "test scaleDownCPUBoundary > scaleUpCPUBoundary",
+			scaling: &zv1.ElasticsearchDataSetScaling{
+				Enabled:              true,
+				MinReplicas:          2,
+				MaxReplicas:          3,
+				MinIndexReplicas:     1,
+				MaxIndexReplicas:     2,
+				MinShardsPerNode:     1,
+				MaxShardsPerNode:     2,
+				ScaleUpCPUBoundary:   40,
+				ScaleDownCPUBoundary: 50,
+			},
+			err: true,
+		},
 		{
 			msg: "scaling disabled",
 			scaling: &zv1.ElasticsearchDataSetScaling{
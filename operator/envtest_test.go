@@ -0,0 +1,215 @@
+package operator
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	"github.com/zalando-incubator/es-operator/pkg/clientset"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+)
+
+// TestEnvtestEDSLifecycle runs the operator in a manager against a real API
+// server and creates, scales, updates and deletes an EDS. It's skipped unless
+// KUBEBUILDER_ASSETS points to the kube-apiserver and etcd binaries, see
+// 'make test.envtest'.
+func TestEnvtestEDSLifecycle(t *testing.T) {
+	if os.Getenv("KUBEBUILDER_ASSETS") == "" {
+		t.Skip("KUBEBUILDER_ASSETS is not set")
+	}
+
+	env := &envtest.Environment{
+		CRDDirectoryPaths: []string{
+			filepath.Join("..", "docs", "zalando.org_elasticsearchdatasets.yaml"),
+			filepath.Join("..", "docs", "zalando.org_elasticsearchmetricsets.yaml"),
+		},
+		ErrorIfCRDPathMissing: true,
+	}
+	cfg, err := env.Start()
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, env.Stop())
+	}()
+
+	// Elasticsearch without indices.
+	es := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/":
+			_, _ = w.Write([]byte(`{"version":{"number":"8.15.0"}}`))
+		case "/_cat/indices", "/_cat/shards", "/_cat/nodes":
+			_, _ = w.Write([]byte(`[]`))
+		default:
+			_, _ = w.Write([]byte(`{}`))
+		}
+	}))
+	defer es.Close()
+	esURL, err := url.Parse(es.URL)
+	require.NoError(t, err)
+
+	client, err := clientset.NewClientset(cfg)
+	require.NoError(t, err)
+
+	ctrllog.SetLogger(klog.NewKlogr())
+	operator := NewElasticsearchOperator(client, Options{
+		Interval:              time.Second,
+		AutoscalerInterval:    time.Minute,
+		Namespace:             "default",
+		ClusterDNSZone:        "cluster.local.",
+		ElasticsearchEndpoint: esURL,
+	})
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, zv1.AddToScheme(scheme))
+	mgr, err := manager.New(cfg, manager.Options{Scheme: scheme, Metrics: metricsserver.Options{BindAddress: "0"}})
+	require.NoError(t, err)
+	require.NoError(t, operator.SetupWithManager(mgr))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error)
+	go func() {
+		done <- mgr.Start(ctx)
+	}()
+
+	// there's no StatefulSet controller and no kubelet, so no pods are
+	// created and the replicas are reported ready as soon as they're
+	// scaled.
+	go func() {
+		for ctx.Err() == nil {
+			time.Sleep(100 * time.Millisecond)
+			sts, err := client.AppsV1().StatefulSets("default").Get(ctx, "es-data", metav1.GetOptions{})
+			if err != nil || sts.Spec.Replicas == nil || sts.Status.ReadyReplicas == *sts.Spec.Replicas {
+				continue
+			}
+			sts.Status.Replicas = *sts.Spec.Replicas
+			sts.Status.ReadyReplicas = *sts.Spec.Replicas
+			_, _ = client.AppsV1().StatefulSets("default").UpdateStatus(ctx, sts, metav1.UpdateOptions{})
+		}
+	}()
+
+	statefulSet := func(condition func(sts *appsv1.StatefulSet) bool) *appsv1.StatefulSet {
+		var sts *appsv1.StatefulSet
+		require.Eventually(t, func() bool {
+			var err error
+			sts, err = client.AppsV1().StatefulSets("default").Get(ctx, "es-data", metav1.GetOptions{})
+			return err == nil && condition(sts)
+		}, 30*time.Second, 100*time.Millisecond)
+		return sts
+	}
+	stsReplicas := func(replicas int32) func(sts *appsv1.StatefulSet) bool {
+		return func(sts *appsv1.StatefulSet) bool {
+			return sts.Spec.Replicas != nil && *sts.Spec.Replicas == replicas
+		}
+	}
+	edsReplicas := func(replicas int32) {
+		require.Eventually(t, func() bool {
+			eds, err := client.ZalandoV1().ElasticsearchDataSets("default").Get(ctx, "es-data", metav1.GetOptions{})
+			return err == nil && eds.Status.Replicas == replicas
+		}, 30*time.Second, 100*time.Millisecond)
+	}
+	updateEDS := func(update func(eds *zv1.ElasticsearchDataSet)) {
+		err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			eds, err := client.ZalandoV1().ElasticsearchDataSets("default").Get(ctx, "es-data", metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			update(eds)
+			_, err = client.ZalandoV1().ElasticsearchDataSets("default").Update(ctx, eds, metav1.UpdateOptions{})
+			return err
+		})
+		require.NoError(t, err)
+	}
+
+	// create.
+	replicas := int32(2)
+	eds := &zv1.ElasticsearchDataSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "es-data", Namespace: "default"},
+		Spec: zv1.ElasticsearchDataSetSpec{
+			Replicas: &replicas,
+			Template: zv1.PodTemplateSpec{
+				EmbeddedObjectMeta: zv1.EmbeddedObjectMeta{
+					Labels: map[string]string{"application": "elasticsearch", "role": "data"},
+				},
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{{
+						Name:  "elasticsearch",
+						Image: "docker.elastic.co/elasticsearch/elasticsearch:8.15.0",
+						Resources: v1.ResourceRequirements{
+							Requests: v1.ResourceList{
+								v1.ResourceCPU:    resource.MustParse("100m"),
+								v1.ResourceMemory: resource.MustParse("1Gi"),
+							},
+						},
+					}},
+				},
+			},
+		},
+	}
+	eds, err = client.ZalandoV1().ElasticsearchDataSets("default").Create(ctx, eds, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	sts := statefulSet(stsReplicas(2))
+	require.Len(t, sts.OwnerReferences, 1)
+	require.Equal(t, eds.UID, sts.OwnerReferences[0].UID)
+	_, err = client.CoreV1().Services("default").Get(ctx, "es-data", metav1.GetOptions{})
+	require.NoError(t, err)
+	edsReplicas(2)
+
+	// scale up and down.
+	updateEDS(func(eds *zv1.ElasticsearchDataSet) {
+		replicas := int32(3)
+		eds.Spec.Replicas = &replicas
+	})
+	statefulSet(stsReplicas(3))
+	edsReplicas(3)
+	updateEDS(func(eds *zv1.ElasticsearchDataSet) {
+		replicas := int32(1)
+		eds.Spec.Replicas = &replicas
+	})
+	statefulSet(stsReplicas(1))
+	edsReplicas(1)
+
+	// update.
+	updateEDS(func(eds *zv1.ElasticsearchDataSet) {
+		eds.Spec.Template.Spec.Containers[0].Image = "docker.elastic.co/elasticsearch/elasticsearch:8.15.1"
+	})
+	statefulSet(func(sts *appsv1.StatefulSet) bool {
+		return sts.Spec.Template.Spec.Containers[0].Image == "docker.elastic.co/elasticsearch/elasticsearch:8.15.1"
+	})
+
+	// delete. The resources are garbage collected by their owner
+	// reference, the operator must stop reconciling them.
+	require.NoError(t, client.ZalandoV1().ElasticsearchDataSets("default").Delete(ctx, "es-data", metav1.DeleteOptions{}))
+	require.Eventually(t, func() bool {
+		_, err := client.ZalandoV1().ElasticsearchDataSets("default").Get(ctx, "es-data", metav1.GetOptions{})
+		return err != nil
+	}, 30*time.Second, 100*time.Millisecond)
+	require.NoError(t, client.AppsV1().StatefulSets("default").Delete(ctx, "es-data", metav1.DeleteOptions{}))
+	require.Never(t, func() bool {
+		_, err := client.AppsV1().StatefulSets("default").Get(ctx, "es-data", metav1.GetOptions{})
+		return err == nil
+	}, 3*time.Second, 100*time.Millisecond)
+
+	// the operator stops with the manager.
+	cancel()
+	require.NoError(t, <-done)
+}
@@ -3,6 +3,7 @@ package operator
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -10,11 +11,13 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/go-resty/resty/v2"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/zalando-incubator/es-operator/operator/null"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
 	v1 "k8s.io/api/core/v1"
 )
 
@@ -24,6 +27,35 @@ type ESClient struct {
 	mux                  sync.Mutex
 	excludeSystemIndices bool
 	DrainingConfig       *DrainingConfig
+	// health configures the health check used to gate operations.
+	health *zv1.ElasticsearchDataSetHealth
+	// criticalIndices must be green before and while draining.
+	criticalIndices []string
+	// drainWriteBlock configures the write block set while the last
+	// primaries relocate off a drained pod.
+	drainWriteBlock *zv1.ElasticsearchDataSetDrainWriteBlock
+	// writeBlocks counts the parallel drains sharing the write block of
+	// an index, such that it's only removed by the last of them. The
+	// ownership of the blocks is also marked in the indices, such that
+	// blocks left over after a restart are removed by Cleanup.
+	writeBlocksMux sync.Mutex
+	writeBlocks    map[string]int
+	// transport sends the requests, http.DefaultTransport if nil.
+	transport http.RoundTripper
+	// recordEvent records an event on the EDS of the client, if set.
+	recordEvent func(eventType, reason, message string)
+	// distribution is the configured distribution of the cluster, detected
+	// if empty.
+	distribution string
+	infoMux      sync.Mutex
+	info         *esInfo
+}
+
+// event records an event on the EDS of the client.
+func (c *ESClient) event(eventType, reason, message string) {
+	if c.recordEvent != nil {
+		c.recordEvent(eventType, reason, message)
+	}
 }
 
 // ESIndex represent an index to be used in public APIs
@@ -58,8 +90,38 @@ type _ESNode struct {
 	DiskUsedPercent string `json:"dup"`
 }
 
+// ESNodeJVMStats represent the JVM memory stats of a single Elasticsearch node
+type ESNodeJVMStats struct {
+	IP                string
+	HeapUsedPercent   float64
+	OldGenUsedPercent float64
+}
+
+// _ESNodesJVMStats represent the response of _nodes/stats/jvm (only used internally)
+type _ESNodesJVMStats struct {
+	Nodes map[string]struct {
+		IP  string `json:"ip"`
+		JVM struct {
+			Mem struct {
+				HeapUsedPercent float64 `json:"heap_used_percent"`
+				Pools           struct {
+					Old struct {
+						UsedInBytes int64 `json:"used_in_bytes"`
+						MaxInBytes  int64 `json:"max_in_bytes"`
+					} `json:"old"`
+				} `json:"pools"`
+			} `json:"mem"`
+		} `json:"jvm"`
+	} `json:"nodes"`
+}
+
 type ESHealth struct {
-	Status string `json:"status"`
+	Status             string `json:"status"`
+	RelocatingShards   int    `json:"relocating_shards"`
+	NumberOfDataNodes  int    `json:"number_of_data_nodes"`
+	ActiveShards       int    `json:"active_shards"`
+	InitializingShards int    `json:"initializing_shards"`
+	UnassignedShards   int    `json:"unassigned_shards"`
 }
 
 type Exclude struct {
@@ -90,6 +152,22 @@ type ClusterSettings struct {
 type ESSettings struct {
 	Transient  ClusterSettings `json:"transient,omitempty"`
 	Persistent ClusterSettings `json:"persistent,omitempty"`
+	// clearTransient is set if transient settings were merged into the
+	// persistent ones and have to be removed.
+	clearTransient bool
+}
+
+// MarshalJSON leaves out the transient settings unless they have to be
+// removed. Transient settings are deprecated since Elasticsearch 7.16 and
+// every update of them is logged as deprecated on Elasticsearch 8.
+func (esSettings ESSettings) MarshalJSON() ([]byte, error) {
+	if esSettings.clearTransient {
+		type settings ESSettings
+		return json.Marshal(settings(esSettings))
+	}
+	return json.Marshal(struct {
+		Persistent ClusterSettings `json:"persistent"`
+	}{esSettings.Persistent})
 }
 
 func deduplicateIPs(excludedIPsString string) string {
@@ -111,6 +189,8 @@ func deduplicateIPs(excludedIPsString string) string {
 }
 
 func (esSettings *ESSettings) MergeNonEmptyTransientSettings() {
+	esSettings.clearTransient = esSettings.GetTransientRebalance().Valid || esSettings.GetTransientExcludeIPs().Valid
+
 	if value := esSettings.GetTransientRebalance().ValueOrZero(); value != "" {
 		esSettings.Persistent.Cluster.Routing.Rebalance.Enable = null.StringFromPtr(&value)
 		esSettings.Transient.Cluster.Routing.Rebalance.Enable = null.StringFromPtr(nil)
@@ -151,6 +231,16 @@ func (esSettings *ESSettings) GetPersistentRebalance() null.String {
 	return esSettings.Persistent.Cluster.Routing.Rebalance.Enable
 }
 
+// httpClient returns a client sending requests with the transport of the
+// ES client.
+func (c *ESClient) httpClient() *resty.Client {
+	transport := c.transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return resty.NewWithClient(&http.Client{Transport: transport})
+}
+
 func (c *ESClient) logger() *log.Entry {
 	return log.WithFields(log.Fields{
 		"endpoint": c.Endpoint,
@@ -159,6 +249,10 @@ func (c *ESClient) logger() *log.Entry {
 
 // Drain drains data from an Elasticsearch pod.
 func (c *ESClient) Drain(ctx context.Context, pod *v1.Pod) error {
+	if c.dryRun() {
+		c.logger().Infof("Dry-run: not draining pod %s/%s", pod.Namespace, pod.Name)
+		return nil
+	}
 
 	c.logger().Info("Ensuring cluster is in green state")
 
@@ -166,6 +260,21 @@ func (c *ESClient) Drain(ctx context.Context, pod *v1.Pod) error {
 	if err != nil {
 		return err
 	}
+
+	err = c.checkCriticalIndices()
+	if err != nil {
+		return err
+	}
+
+	nodeShutdown, err := c.nodeShutdownEnabled()
+	if err != nil {
+		return err
+	}
+	if nodeShutdown {
+		c.logger().Info("Waiting for node shutdown to finish")
+		return c.shutdownNode(ctx, pod, nodeShutdownTypeRemove)
+	}
+
 	c.logger().Info("Disabling auto-rebalance")
 	esSettings, err := c.getClusterSettings()
 	if err != nil {
@@ -183,10 +292,36 @@ func (c *ESClient) Drain(ctx context.Context, pod *v1.Pod) error {
 	}
 
 	c.logger().Info("Waiting for draining to finish")
-	return c.waitForEmptyEsNode(ctx, pod)
+	err = c.waitForEmptyEsNode(ctx, pod)
+	if err != nil {
+		var degraded *criticalIndicesDegradedError
+		if errors.As(err, &degraded) {
+			c.logger().Warnf("Aborting drain of pod %s/%s: %v", pod.Namespace, pod.Name, err)
+			if includeErr := c.includePodIP(pod); includeErr != nil {
+				return fmt.Errorf("%v, failed to include pod in shard allocation again: %v", err, includeErr)
+			}
+		}
+		return err
+	}
+	return nil
 }
 
 func (c *ESClient) Cleanup(ctx context.Context) error {
+	nodeShutdown, err := c.nodeShutdownEnabled()
+	if err != nil {
+		return err
+	}
+	if nodeShutdown {
+		err = c.cleanupNodeShutdowns(time.Now())
+		if err != nil {
+			return err
+		}
+	}
+
+	err = c.removeStaleWriteBlocks(time.Now())
+	if err != nil {
+		return err
+	}
 
 	// 1. fetch IPs from _cat/nodes
 	nodes, err := c.GetNodes()
@@ -234,8 +369,8 @@ func (c *ESClient) Cleanup(ctx context.Context) error {
 
 // ensures cluster is in green state
 func (c *ESClient) ensureGreenClusterState() error {
-	resp, err := resty.NewWithClient(&http.Client{Transport: http.DefaultTransport}).R().
-		Get(c.Endpoint.String() + "/_cluster/health?wait_for_status=green&timeout=60s")
+	resp, err := c.httpClient().R().
+		Get(c.Endpoint.String() + healthPath(c.health, "green", "60s"))
 	if err != nil {
 		return err
 	}
@@ -253,10 +388,28 @@ func (c *ESClient) ensureGreenClusterState() error {
 	return nil
 }
 
+// ClusterHealth returns the current health of the cluster.
+func (c *ESClient) ClusterHealth() (*ESHealth, error) {
+	resp, err := c.httpClient().R().
+		Get(c.Endpoint.String() + healthPath(c.health, "", ""))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, fmt.Errorf("code status %d - %s", resp.StatusCode(), resp.Body())
+	}
+	var esHealth ESHealth
+	err = json.Unmarshal(resp.Body(), &esHealth)
+	if err != nil {
+		return nil, err
+	}
+	return &esHealth, nil
+}
+
 // returns the response of the call to _cluster/settings
 func (c *ESClient) getClusterSettings() (*ESSettings, error) {
 	// get _cluster/settings for current exclude list
-	resp, err := resty.NewWithClient(&http.Client{Transport: http.DefaultTransport}).R().
+	resp, err := c.httpClient().R().
 		Get(c.Endpoint.String() + "/_cluster/settings")
 	if err != nil {
 		return nil, err
@@ -312,7 +465,7 @@ func (c *ESClient) excludePodIP(pod *v1.Pod) error {
 
 func (c *ESClient) setExcludeIPs(ips string, originalESSettings *ESSettings) error {
 	originalESSettings.updateExcludeIps(ips)
-	resp, err := resty.NewWithClient(&http.Client{Transport: http.DefaultTransport}).R().
+	resp, err := c.httpClient().R().
 		SetHeader("Content-Type", "application/json").
 		SetBody(originalESSettings).
 		Put(c.Endpoint.String() + "/_cluster/settings")
@@ -331,7 +484,7 @@ func (esSettings *ESSettings) updateExcludeIps(ips string) {
 
 func (c *ESClient) updateAutoRebalance(value string, originalESSettings *ESSettings) error {
 	originalESSettings.updateRebalance(value)
-	resp, err := resty.NewWithClient(&http.Client{Transport: http.DefaultTransport}).R().
+	resp, err := c.httpClient().R().
 		SetHeader("Content-Type", "application/json").
 		SetBody(originalESSettings).
 		Put(c.Endpoint.String() + "/_cluster/settings")
@@ -354,8 +507,19 @@ func (c *ESClient) waitForEmptyEsNode(ctx context.Context, pod *v1.Pod) error {
 
 	// Counter to track the number of retries
 	retryCount := 0
+	// set if the drain is aborted because critical indices degraded.
+	var abortErr error
+	// the tasks blocking the drain which were already reported.
+	started, handledTasks := time.Now(), make(map[string]struct{})
+
+	block := newDrainWriteBlock(c.drainWriteBlock)
+	defer func() {
+		if err := c.removeDrainWriteBlock(block); err != nil {
+			c.logger().Error(err)
+		}
+	}()
 
-	_, err := resty.NewWithClient(&http.Client{Transport: http.DefaultTransport}).
+	_, err := c.httpClient().
 		SetRetryCount(c.DrainingConfig.MaxRetries).
 		SetRetryWaitTime(c.DrainingConfig.MinimumWaitTime).
 		SetRetryMaxWaitTime(c.DrainingConfig.MaximumWaitTime).
@@ -392,6 +556,28 @@ func (c *ESClient) waitForEmptyEsNode(ctx context.Context, pod *v1.Pod) error {
 					}
 					c.logger().Infof("Found %d remaining shards on %s/%s (%s)", remainingShards, pod.Namespace, pod.Name, podIP)
 
+					if remainingShards > 0 {
+						if degraded, checkErr := c.criticalIndicesDegraded(); checkErr != nil {
+							log.Warnf("Failed to check health of critical indices: %v. Details: Namespace=%s, PodName=%s, PodIP=%s, RetryCount=%d.",
+								checkErr, pod.Namespace, pod.Name, podIP, retryCount)
+						} else if degraded != nil {
+							abortErr = degraded
+							return false
+						}
+
+						err = c.updateDrainWriteBlock(pod, block, time.Now())
+						if err != nil {
+							log.Warnf("Failed to update write block: %v. Details: Namespace=%s, PodName=%s, PodIP=%s, RetryCount=%d.",
+								err, pod.Namespace, pod.Name, podIP, retryCount)
+						}
+
+						err = c.checkDrainTasks(pod, started, time.Now(), handledTasks)
+						if err != nil {
+							log.Warnf("Failed to check tasks blocking the drain: %v. Details: Namespace=%s, PodName=%s, PodIP=%s, RetryCount=%d.",
+								err, pod.Namespace, pod.Name, podIP, retryCount)
+						}
+					}
+
 					// make sure the IP is still excluded, this could have been updated in the meantime.
 					if remainingShards > 0 {
 						err = c.excludePodIP(pod)
@@ -409,11 +595,11 @@ func (c *ESClient) waitForEmptyEsNode(ctx context.Context, pod *v1.Pod) error {
 	if err != nil {
 		return err
 	}
-	return nil
+	return abortErr
 }
 
 func (c *ESClient) GetNodes() ([]ESNode, error) {
-	resp, err := resty.NewWithClient(&http.Client{Transport: http.DefaultTransport}).R().
+	resp, err := c.httpClient().R().
 		Get(c.Endpoint.String() + "/_cat/nodes?h=ip,dup&format=json")
 	if err != nil {
 		return nil, err
@@ -446,8 +632,64 @@ func (c *ESClient) GetNodes() ([]ESNode, error) {
 	return returnStruct, nil
 }
 
+// GetNodesJVMStats returns the JVM memory stats of all nodes in the cluster.
+func (c *ESClient) GetNodesJVMStats() ([]ESNodeJVMStats, error) {
+	resp, err := c.httpClient().R().
+		Get(c.Endpoint.String() + "/_nodes/stats/jvm?filter_path=nodes.*.ip,nodes.*.jvm.mem.heap_used_percent,nodes.*.jvm.mem.pools.old")
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, fmt.Errorf("code status %d - %s", resp.StatusCode(), resp.Body())
+	}
+	var esStats _ESNodesJVMStats
+	err = json.Unmarshal(resp.Body(), &esStats)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make([]ESNodeJVMStats, 0, len(esStats.Nodes))
+	for _, node := range esStats.Nodes {
+		mem := node.JVM.Mem
+		// fall back to heap usage if the old generation has no
+		// upper bound.
+		oldGenUsedPercent := mem.HeapUsedPercent
+		if mem.Pools.Old.MaxInBytes > 0 {
+			oldGenUsedPercent = float64(mem.Pools.Old.UsedInBytes) / float64(mem.Pools.Old.MaxInBytes) * 100
+		}
+		stats = append(stats, ESNodeJVMStats{
+			IP:                strings.Split(node.IP, ":")[0],
+			HeapUsedPercent:   mem.HeapUsedPercent,
+			OldGenUsedPercent: oldGenUsedPercent,
+		})
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].IP < stats[j].IP
+	})
+	return stats, nil
+}
+
+// GetHotThreads returns the hot threads of the nodes with the given IPs, or of
+// all nodes if no IPs are given.
+func (c *ESClient) GetHotThreads(nodeIPs []string) (string, error) {
+	nodes := ""
+	if len(nodeIPs) > 0 {
+		nodes = "/" + strings.Join(nodeIPs, ",")
+	}
+	resp, err := c.httpClient().R().
+		Get(c.Endpoint.String() + "/_nodes" + nodes + "/hot_threads")
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return "", fmt.Errorf("code status %d - %s", resp.StatusCode(), resp.Body())
+	}
+	return string(resp.Body()), nil
+}
+
 func (c *ESClient) GetShards() ([]ESShard, error) {
-	resp, err := resty.NewWithClient(&http.Client{Transport: http.DefaultTransport}).R().
+	resp, err := c.httpClient().R().
 		Get(c.Endpoint.String() + "/_cat/shards?h=index,ip&format=json")
 
 	if err != nil {
@@ -465,8 +707,50 @@ func (c *ESClient) GetShards() ([]ESShard, error) {
 	return esShards, nil
 }
 
+// GetNodeShardStats returns the number of shards and their total size in
+// bytes on the node with the IP.
+func (c *ESClient) GetNodeShardStats(ip string) (int, int64, error) {
+	resp, err := c.httpClient().R().
+		Get(c.Endpoint.String() + "/_cat/shards?h=ip,store&bytes=b&format=json")
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if resp.StatusCode() != http.StatusOK {
+		return 0, 0, fmt.Errorf("code status %d - %s", resp.StatusCode(), resp.Body())
+	}
+
+	var shards []struct {
+		IP    string `json:"ip"`
+		Store string `json:"store"`
+	}
+	err = json.Unmarshal(resp.Body(), &shards)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	count := 0
+	size := int64(0)
+	for _, shard := range shards {
+		if shard.IP != ip {
+			continue
+		}
+		count++
+		// unassigned or initializing shards have no store size.
+		if shard.Store == "" {
+			continue
+		}
+		bytes, err := strconv.ParseInt(shard.Store, 10, 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid store size '%s': %v", shard.Store, err)
+		}
+		size += bytes
+	}
+	return count, size, nil
+}
+
 func (c *ESClient) GetIndices() ([]ESIndex, error) {
-	resp, err := resty.NewWithClient(&http.Client{Transport: http.DefaultTransport}).R().
+	resp, err := c.httpClient().R().
 		Get(c.Endpoint.String() + "/_cat/indices?h=index,pri,rep&format=json")
 
 	if err != nil {
@@ -520,7 +804,7 @@ func (c *ESClient) UpdateIndexSettings(indices []ESIndex) error {
 
 	for _, index := range indices {
 		c.logger().Infof("Setting number_of_replicas for index '%s' to %d.", index.Index, index.Replicas)
-		resp, err := resty.NewWithClient(&http.Client{Transport: http.DefaultTransport}).R().
+		resp, err := c.httpClient().R().
 			SetHeader("Content-Type", "application/json").
 			SetBody([]byte(
 				fmt.Sprintf(
@@ -546,7 +830,7 @@ func (c *ESClient) UpdateIndexSettings(indices []ESIndex) error {
 }
 
 func (c *ESClient) CreateIndex(indexName, groupName string, shards, replicas int) error {
-	resp, err := resty.NewWithClient(&http.Client{Transport: http.DefaultTransport}).R().
+	resp, err := c.httpClient().R().
 		SetHeader("Content-Type", "application/json").
 		SetBody([]byte(
 			fmt.Sprintf(
@@ -568,7 +852,7 @@ func (c *ESClient) CreateIndex(indexName, groupName string, shards, replicas int
 }
 
 func (c *ESClient) DeleteIndex(indexName string) error {
-	resp, err := resty.NewWithClient(&http.Client{Transport: http.DefaultTransport}).R().
+	resp, err := c.httpClient().R().
 		Delete(fmt.Sprintf("%s/%s", c.Endpoint.String(), indexName))
 	if err != nil {
 		return err
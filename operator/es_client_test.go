@@ -278,6 +278,26 @@ func TestGetNodes(t *testing.T) {
 
 }
 
+func TestGetNodesJVMStats(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_nodes/stats/jvm",
+		httpmock.NewStringResponder(200, `{"nodes":{"a":{"ip":"10.2.10.2","jvm":{"mem":{"heap_used_percent":92,"pools":{"old":{"used_in_bytes":900,"max_in_bytes":1000}}}}},"b":{"ip":"10.2.1.3:9300","jvm":{"mem":{"heap_used_percent":40,"pools":{"old":{"used_in_bytes":300,"max_in_bytes":-1}}}}}}}`))
+
+	esUrl, _ := url.Parse("http://elasticsearch:9200")
+	systemUnderTest := &ESClient{
+		Endpoint: esUrl,
+	}
+
+	stats, err := systemUnderTest.GetNodesJVMStats()
+	require.NoError(t, err)
+	assert.Equal(t, []ESNodeJVMStats{
+		{IP: "10.2.1.3", HeapUsedPercent: 40, OldGenUsedPercent: 40},
+		{IP: "10.2.10.2", HeapUsedPercent: 92, OldGenUsedPercent: 90},
+	}, stats)
+}
+
 func TestGetShards(t *testing.T) {
 	httpmock.Activate()
 	defer httpmock.DeactivateAndReset()
@@ -457,6 +477,23 @@ func TestEnsureGreenClusterState(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestClusterHealth(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_cluster/health",
+		httpmock.NewStringResponder(200, `{"status":"red"}`))
+
+	url, _ := url.Parse("http://elasticsearch:9200")
+	client := &ESClient{
+		Endpoint: url,
+	}
+
+	health, err := client.ClusterHealth()
+	require.NoError(t, err)
+	assert.Equal(t, "red", health.Status)
+}
+
 func TestExcludeSystemIndices(t *testing.T) {
 	httpmock.Activate()
 	defer httpmock.DeactivateAndReset()
@@ -572,3 +609,26 @@ func TestESSettingsMergeNonEmtpyTransientSettings(t *testing.T) {
 		})
 	}
 }
+
+func TestESSettingsMarshalJSON(t *testing.T) {
+	esSettings := &ESSettings{}
+	require.NoError(t, json.Unmarshal([]byte(`{"persistent":{"cluster":{"routing":{"rebalance":{"enable":"all"}}}}}`), esSettings))
+	esSettings.MergeNonEmptyTransientSettings()
+	body, err := json.Marshal(esSettings)
+	require.NoError(t, err)
+	assert.NotContains(t, string(body), "transient")
+
+	// transient settings are only sent to remove them.
+	esSettings = &ESSettings{}
+	require.NoError(t, json.Unmarshal([]byte(`{"transient":{"cluster":{"routing":{"allocation":{"exclude":{"_ip":"1.2.3.4"}}}}}}`), esSettings))
+	esSettings.MergeNonEmptyTransientSettings()
+	body, err = json.Marshal(esSettings)
+	require.NoError(t, err)
+	var sent map[string]map[string]interface{}
+	require.NoError(t, json.Unmarshal(body, &sent))
+	require.Contains(t, sent, "transient")
+	assert.Equal(t, map[string]interface{}{"routing": map[string]interface{}{
+		"allocation": map[string]interface{}{"exclude": map[string]interface{}{"_ip": nil}},
+		"rebalance":  map[string]interface{}{"enable": nil},
+	}}, sent["transient"]["cluster"])
+}
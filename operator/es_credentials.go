@@ -0,0 +1,55 @@
+package operator
+
+import (
+	"fmt"
+	"net/http"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// credentialsAPIKeyKey is the key of the encoded API key in the credentials
+// Secret.
+const credentialsAPIKeyKey = "apiKey"
+
+// credentialsTransport authenticates the requests to Elasticsearch with the
+// API key or the basic auth credentials of a Secret.
+type credentialsTransport struct {
+	secrets   *secretCache
+	namespace string
+	name      string
+	// next sends the requests, http.DefaultTransport if nil.
+	next http.RoundTripper
+}
+
+func (t *credentialsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	secret, err := t.secrets.get(req.Context(), t.namespace, t.name)
+	if err != nil {
+		return nil, err
+	}
+	authorized := req.Clone(req.Context())
+	err = setCredentials(authorized, secret)
+	if err != nil {
+		return nil, fmt.Errorf("invalid credentials Secret %s/%s: %v", t.namespace, t.name, err)
+	}
+
+	next := t.next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return next.RoundTrip(authorized)
+}
+
+// setCredentials sets the authorization header of the request to the API
+// key of the Secret, or to its username and password.
+func setCredentials(req *http.Request, secret *v1.Secret) error {
+	if apiKey, ok := secret.Data[credentialsAPIKeyKey]; ok && len(apiKey) > 0 {
+		req.Header.Set("Authorization", "ApiKey "+string(apiKey))
+		return nil
+	}
+	username, password := secret.Data[v1.BasicAuthUsernameKey], secret.Data[v1.BasicAuthPasswordKey]
+	if len(username) == 0 {
+		return fmt.Errorf("neither %s nor %s is set", credentialsAPIKeyKey, v1.BasicAuthUsernameKey)
+	}
+	req.SetBasicAuth(string(username), string(password))
+	return nil
+}
@@ -0,0 +1,73 @@
+package operator
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	"github.com/zalando-incubator/es-operator/pkg/clientset"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestCredentialsTransport(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var authorization string
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_cluster/health",
+		func(req *http.Request) (*http.Response, error) {
+			authorization = req.Header.Get("Authorization")
+			return httpmock.NewStringResponse(200, `{"status":"green"}`), nil
+		})
+
+	kube := fake.NewSimpleClientset(
+		&v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "basic-auth", Namespace: "default"},
+			Data:       map[string][]byte{v1.BasicAuthUsernameKey: []byte("es-operator"), v1.BasicAuthPasswordKey: []byte("secret")},
+		},
+		&v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "api-key", Namespace: "default"},
+			Data:       map[string][]byte{credentialsAPIKeyKey: []byte("aWQ6a2V5")},
+		},
+		&v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "empty", Namespace: "default"},
+		},
+	)
+	o := &ElasticsearchOperator{secrets: newSecretCache(&clientset.Clientset{Interface: kube}, time.Hour)}
+	eds := &zv1.ElasticsearchDataSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "es-data", Namespace: "default"},
+		Spec: zv1.ElasticsearchDataSetSpec{
+			Elasticsearch: &zv1.ElasticsearchDataSetElasticsearch{
+				CredentialsSecretRef: &v1.LocalObjectReference{Name: "basic-auth"},
+			},
+		},
+	}
+	esURL, _ := url.Parse("http://elasticsearch:9200")
+
+	client := &ESClient{Endpoint: esURL, transport: o.elasticsearchTransport(eds)}
+	_, err := client.ClusterHealth()
+	require.NoError(t, err)
+	require.Equal(t, "Basic ZXMtb3BlcmF0b3I6c2VjcmV0", authorization)
+
+	eds.Spec.Elasticsearch.CredentialsSecretRef.Name = "api-key"
+	client = &ESClient{Endpoint: esURL, transport: o.elasticsearchTransport(eds)}
+	_, err = client.ClusterHealth()
+	require.NoError(t, err)
+	require.Equal(t, "ApiKey aWQ6a2V5", authorization)
+
+	eds.Spec.Elasticsearch.CredentialsSecretRef.Name = "empty"
+	client = &ESClient{Endpoint: esURL, transport: o.elasticsearchTransport(eds)}
+	_, err = client.ClusterHealth()
+	require.ErrorContains(t, err, "invalid credentials Secret default/empty: neither apiKey nor username is set")
+
+	eds.Spec.Elasticsearch.CredentialsSecretRef.Name = "missing"
+	client = &ESClient{Endpoint: esURL, transport: o.elasticsearchTransport(eds)}
+	_, err = client.ClusterHealth()
+	require.ErrorContains(t, err, "failed to get Secret default/missing")
+}
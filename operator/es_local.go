@@ -0,0 +1,100 @@
+package operator
+
+import (
+	"fmt"
+
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	localAPIKeyEnv   = "ES_OPERATOR_API_KEY"
+	localUsernameEnv = "ES_OPERATOR_USERNAME"
+	localPasswordEnv = "ES_OPERATOR_PASSWORD"
+)
+
+// localAccess describes how Elasticsearch is reached from within its pods,
+// e.g. by the readiness probe and the backup hooks.
+type localAccess struct {
+	// tls is set if Elasticsearch serves HTTPS.
+	tls bool
+	// credentials references the Secret with the API key or the username
+	// and password, if the security features are enabled.
+	credentials *v1.LocalObjectReference
+}
+
+// edsLocalAccess returns how Elasticsearch of the EDS is reached from within
+// its pods, with the same TLS and credentials as used by the operator.
+func edsLocalAccess(eds *zv1.ElasticsearchDataSet) localAccess {
+	access := localAccess{tls: eds.Spec.TLS != nil}
+	if config := eds.Spec.Elasticsearch; config != nil && config.CredentialsSecretRef != nil {
+		access.credentials = config.CredentialsSecretRef.DeepCopy()
+	}
+	return access
+}
+
+// url returns the URL of the path on the local node.
+func (a localAccess) url(path string) string {
+	scheme := "http"
+	if a.tls {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://localhost:%d%s", scheme, defaultElasticsearchDataSetEndpointPort, path)
+}
+
+// env returns the environment variables exposing the credentials to the
+// curl command.
+func (a localAccess) env() []v1.EnvVar {
+	if a.credentials == nil {
+		return nil
+	}
+	optional := true
+	secretKey := func(name, key string) v1.EnvVar {
+		return v1.EnvVar{
+			Name: name,
+			ValueFrom: &v1.EnvVarSource{
+				SecretKeyRef: &v1.SecretKeySelector{
+					LocalObjectReference: *a.credentials,
+					Key:                  key,
+					Optional:             &optional,
+				},
+			},
+		}
+	}
+	return []v1.EnvVar{
+		secretKey(localAPIKeyEnv, credentialsAPIKeyKey),
+		secretKey(localUsernameEnv, v1.BasicAuthUsernameKey),
+		secretKey(localPasswordEnv, v1.BasicAuthPasswordKey),
+	}
+}
+
+// injectEnv adds the environment variables of the credentials to the
+// container.
+func (a localAccess) injectEnv(container *v1.Container) {
+	for _, env := range a.env() {
+		setEnvDefault(container, env)
+	}
+}
+
+// curlCommand returns a shell command sending the request to the local
+// node. Like the operator it prefers the API key over basic auth. The
+// certificate isn't verified, as it's issued for the Service rather than
+// localhost.
+func (a localAccess) curlCommand(method, path string) string {
+	command := ""
+	if a.credentials != nil {
+		command = fmt.Sprintf(`if [ -n "$%[1]s" ]; then set -- -H "Authorization: ApiKey $%[1]s"; else set -- -u "$%[2]s:$%[3]s"; fi; `,
+			localAPIKeyEnv, localUsernameEnv, localPasswordEnv)
+	}
+	command += "curl -sf"
+	if a.tls {
+		command += " -k"
+	}
+	if a.credentials != nil {
+		command += ` "$@"`
+	}
+	if method != "" {
+		command += " -X" + method
+	}
+	return fmt.Sprintf("%s '%s'", command, a.url(path))
+}
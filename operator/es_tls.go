@@ -0,0 +1,188 @@
+package operator
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	"github.com/zalando-incubator/es-operator/pkg/clientset"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// secretRefreshInterval is the time after which the TLS and
+	// credentials Secrets of an EDS are read again.
+	secretRefreshInterval = time.Minute
+
+	tlsSecretCAKey = "ca.crt"
+)
+
+// secretCache caches the Secrets referenced by the EDS, such that they
+// aren't read for every request to Elasticsearch.
+type secretCache struct {
+	sync.Mutex
+	kube     *clientset.Clientset
+	interval time.Duration
+	secrets  map[string]*cachedSecret
+}
+
+type cachedSecret struct {
+	secret *v1.Secret
+	loaded time.Time
+}
+
+// newSecretCache returns a cache reading the Secrets again after the
+// interval.
+func newSecretCache(kube *clientset.Clientset, interval time.Duration) *secretCache {
+	return &secretCache{kube: kube, interval: interval, secrets: make(map[string]*cachedSecret)}
+}
+
+// get returns the Secret, which is read again if it was loaded before the
+// interval.
+func (c *secretCache) get(ctx context.Context, namespace, name string) (*v1.Secret, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	key := namespace + "/" + name
+	now := time.Now()
+	if cached, ok := c.secrets[key]; ok && now.Sub(cached.loaded) < c.interval {
+		return cached.secret, nil
+	}
+	secret, err := c.kube.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Secret %s: %v", key, err)
+	}
+	c.secrets[key] = &cachedSecret{secret: secret, loaded: now}
+	return secret, nil
+}
+
+// tlsTransportCache caches the transports built from the TLS Secrets of the
+// EDS, which are only rebuilt if their Secret changed.
+type tlsTransportCache struct {
+	sync.Mutex
+	secrets    *secretCache
+	transports map[string]*cachedTLSTransport
+}
+
+type cachedTLSTransport struct {
+	transport       *http.Transport
+	resourceVersion string
+}
+
+// newTLSTransportCache returns a cache building the transports from the
+// Secrets of the cache.
+func newTLSTransportCache(secrets *secretCache) *tlsTransportCache {
+	return &tlsTransportCache{secrets: secrets, transports: make(map[string]*cachedTLSTransport)}
+}
+
+// get returns the transport of the TLS configuration in the namespace.
+func (c *tlsTransportCache) get(req *http.Request, namespace string, config *zv1.ElasticsearchDataSetTLS) (*http.Transport, error) {
+	secret, err := c.secrets.get(req.Context(), namespace, config.SecretName)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	key := namespace + "/" + config.SecretName + "/" + config.ServerName
+	cached, ok := c.transports[key]
+	if ok && cached.resourceVersion == secret.ResourceVersion {
+		return cached.transport, nil
+	}
+
+	tlsConfig, err := tlsClientConfig(secret, config.ServerName)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TLS Secret %s/%s: %v", namespace, config.SecretName, err)
+	}
+	if ok {
+		cached.transport.CloseIdleConnections()
+	}
+	transport := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		TLSClientConfig:       tlsConfig,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+	c.transports[key] = &cachedTLSTransport{transport: transport, resourceVersion: secret.ResourceVersion}
+	return transport, nil
+}
+
+// tlsClientConfig returns the TLS configuration with the CA bundle and the
+// client certificate of the Secret.
+func tlsClientConfig(secret *v1.Secret, serverName string) (*tls.Config, error) {
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: serverName,
+	}
+	if ca, ok := secret.Data[tlsSecretCAKey]; ok {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificates found in %s", tlsSecretCAKey)
+		}
+		config.RootCAs = pool
+	}
+	cert, hasCert := secret.Data[v1.TLSCertKey]
+	key, hasKey := secret.Data[v1.TLSPrivateKeyKey]
+	if hasCert != hasKey {
+		return nil, fmt.Errorf("%s and %s must be set together", v1.TLSCertKey, v1.TLSPrivateKeyKey)
+	}
+	if hasCert {
+		certificate, err := tls.X509KeyPair(cert, key)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{certificate}
+	}
+	return config, nil
+}
+
+// tlsTransport sends the requests to Elasticsearch with the transport of the
+// TLS configuration of an EDS.
+type tlsTransport struct {
+	cache     *tlsTransportCache
+	namespace string
+	config    *zv1.ElasticsearchDataSetTLS
+}
+
+func (t *tlsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	transport, err := t.cache.get(req, t.namespace, t.config)
+	if err != nil {
+		return nil, err
+	}
+	return transport.RoundTrip(req)
+}
+
+// elasticsearchTransport returns the transport of the Elasticsearch client
+// of the EDS, or nil if it neither uses TLS nor credentials. In dry-run mode
+// changing requests aren't sent.
+func (o *ElasticsearchOperator) elasticsearchTransport(eds *zv1.ElasticsearchDataSet) http.RoundTripper {
+	var transport http.RoundTripper
+	if eds.Spec.TLS != nil {
+		transport = &tlsTransport{cache: o.tlsTransports, namespace: eds.Namespace, config: eds.Spec.TLS.DeepCopy()}
+	}
+	if config := eds.Spec.Elasticsearch; config != nil && config.CredentialsSecretRef != nil {
+		transport = &credentialsTransport{
+			secrets:   o.secrets,
+			namespace: eds.Namespace,
+			name:      config.CredentialsSecretRef.Name,
+			next:      transport,
+		}
+	}
+	if o.dryRun {
+		if transport == nil {
+			transport = http.DefaultTransport
+		}
+		transport = &elasticsearchDryRunTransport{next: transport}
+	}
+	return transport
+}
@@ -0,0 +1,75 @@
+package operator
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	"github.com/zalando-incubator/es-operator/pkg/clientset"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestTLSTransport(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status":"green"}`))
+	}))
+	defer server.Close()
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+	kube := fake.NewSimpleClientset(&v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "es-tls", Namespace: "default", ResourceVersion: "1"},
+		Data:       map[string][]byte{tlsSecretCAKey: ca},
+	})
+	secrets := newSecretCache(&clientset.Clientset{Interface: kube}, time.Hour)
+	o := &ElasticsearchOperator{secrets: secrets, tlsTransports: newTLSTransportCache(secrets)}
+	eds := &zv1.ElasticsearchDataSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "es-data", Namespace: "default"},
+		Spec: zv1.ElasticsearchDataSetSpec{
+			TLS: &zv1.ElasticsearchDataSetTLS{SecretName: "es-tls", ServerName: "example.com"},
+		},
+	}
+	require.Equal(t, "https", o.getElasticsearchEndpoint(eds).Scheme)
+
+	endpoint, _ := url.Parse(server.URL)
+	client := &ESClient{Endpoint: endpoint, transport: o.elasticsearchTransport(eds)}
+	health, err := client.ClusterHealth()
+	require.NoError(t, err)
+	require.Equal(t, "green", health.Status)
+
+	// the transport is cached until the Secret is read again.
+	require.NoError(t, kube.CoreV1().Secrets("default").Delete(context.Background(), "es-tls", metav1.DeleteOptions{}))
+	_, err = client.ClusterHealth()
+	require.NoError(t, err)
+
+	o.secrets.interval = 0
+	_, err = client.ClusterHealth()
+	require.Error(t, err)
+
+	// without TLS plain HTTP is used with the default transport.
+	eds.Spec.TLS = nil
+	require.Nil(t, o.elasticsearchTransport(eds))
+	require.Equal(t, "http", o.getElasticsearchEndpoint(eds).Scheme)
+}
+
+func TestTLSClientConfig(t *testing.T) {
+	config, err := tlsClientConfig(&v1.Secret{}, "es.example.org")
+	require.NoError(t, err)
+	require.Nil(t, config.RootCAs)
+	require.Empty(t, config.Certificates)
+	require.Equal(t, "es.example.org", config.ServerName)
+
+	_, err = tlsClientConfig(&v1.Secret{Data: map[string][]byte{tlsSecretCAKey: []byte("invalid")}}, "")
+	require.Error(t, err)
+
+	_, err = tlsClientConfig(&v1.Secret{Data: map[string][]byte{v1.TLSCertKey: []byte("cert")}}, "")
+	require.EqualError(t, err, "tls.crt and tls.key must be set together")
+}
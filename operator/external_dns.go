@@ -0,0 +1,234 @@
+package operator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	dnsEndpointAPIVersion        = "externaldns.k8s.io/v1alpha1"
+	dnsEndpointKind              = "DNSEndpoint"
+	defaultExternalDNSRecordTTL  = 30
+	externalDNSRecordTypeAddress = "A"
+)
+
+// dnsEndpoint is a DNSEndpoint resource of external-dns.
+type dnsEndpoint struct {
+	APIVersion string            `json:"apiVersion"`
+	Kind       string            `json:"kind"`
+	Metadata   metav1.ObjectMeta `json:"metadata"`
+	Spec       dnsEndpointSpec   `json:"spec"`
+}
+
+type dnsEndpointSpec struct {
+	Endpoints []externalDNSEndpoint `json:"endpoints"`
+}
+
+type externalDNSEndpoint struct {
+	DNSName    string   `json:"dnsName"`
+	RecordType string   `json:"recordType"`
+	RecordTTL  int64    `json:"recordTTL,omitempty"`
+	Targets    []string `json:"targets"`
+}
+
+// servingAddresses returns the sorted IPs of the ready pods, except for
+// draining, terminating and excluded pods.
+func servingAddresses(pods []*v1.Pod, excluded ...*v1.Pod) []string {
+	addresses := make([]string, 0, len(pods))
+	for _, pod := range pods {
+		if pod.Status.PodIP == "" || pod.DeletionTimestamp != nil {
+			continue
+		}
+		if _, ok := pod.Annotations[operatorPodDrainingAnnotationKey]; ok {
+			continue
+		}
+		if ready := podCondition(pod, v1.PodReady); ready == nil || ready.Status != v1.ConditionTrue {
+			continue
+		}
+		exclude := false
+		for _, e := range excluded {
+			if e.Namespace == pod.Namespace && e.Name == pod.Name {
+				exclude = true
+				break
+			}
+		}
+		if !exclude {
+			addresses = append(addresses, pod.Status.PodIP)
+		}
+	}
+	sort.Strings(addresses)
+	return addresses
+}
+
+// publishAddresses publishes the addresses of the serving pods of the
+// resource without the excluded pods, which are about to be drained.
+func (o *Operator) publishAddresses(ctx context.Context, sr StatefulResource, excluded ...*v1.Pod) error {
+	pods, err := o.podInformer.Lister().Pods(sr.Namespace()).List(labels.Set(sr.LabelSelector()).AsSelector())
+	if err != nil {
+		return fmt.Errorf("failed to list pods of StatefulSet: %v", err)
+	}
+	err = sr.PublishAddresses(ctx, servingAddresses(pods, excluded...))
+	if err != nil {
+		return fmt.Errorf("failed to publish addresses: %v", err)
+	}
+	return nil
+}
+
+// externalDNSHostname returns the hostname of the DNS record of the EDS or
+// an empty string if neither the hostname nor the zone is configured.
+func (r *EDSResource) externalDNSHostname() string {
+	if hostname := r.eds.Spec.ExternalDNS.Hostname; hostname != "" {
+		return hostname
+	}
+	if r.externalDNSZone == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s.%s.%s", r.eds.Name, r.eds.Namespace, strings.TrimSuffix(r.externalDNSZone, "."))
+}
+
+func (r *EDSResource) dnsEndpointPath(name string) string {
+	path := fmt.Sprintf("/apis/%s/namespaces/%s/dnsendpoints", dnsEndpointAPIVersion, r.eds.Namespace)
+	if name != "" {
+		path += "/" + name
+	}
+	return path
+}
+
+// getDNSEndpoint returns the DNSEndpoint of the EDS or nil if it doesn't
+// exist.
+func (r *EDSResource) getDNSEndpoint(ctx context.Context) (*dnsEndpoint, error) {
+	body, err := r.kube.Discovery().RESTClient().Get().AbsPath(r.dnsEndpointPath(r.eds.Name)).DoRaw(ctx)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var endpoint dnsEndpoint
+	err = json.Unmarshal(body, &endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return &endpoint, nil
+}
+
+// desiredDNSEndpoint sets the record of the addresses on the DNSEndpoint.
+// Returns true if it was changed.
+func (r *EDSResource) desiredDNSEndpoint(endpoint *dnsEndpoint, hostname string, addresses []string) bool {
+	ttl := r.eds.Spec.ExternalDNS.RecordTTLSeconds
+	if ttl == 0 {
+		ttl = defaultExternalDNSRecordTTL
+	}
+	spec := dnsEndpointSpec{
+		Endpoints: []externalDNSEndpoint{
+			{
+				DNSName:    hostname,
+				RecordType: externalDNSRecordTypeAddress,
+				RecordTTL:  ttl,
+				Targets:    addresses,
+			},
+		},
+	}
+	changed := r.applyOwnedResourceMetadata(&endpoint.Metadata)
+	if !reflect.DeepEqual(endpoint.Spec, spec) {
+		endpoint.Spec = spec
+		changed = true
+	}
+	return changed
+}
+
+// PublishAddresses publishes the addresses as the targets of the DNS record
+// of the EDS. While no pod is serving, the previous addresses are kept, as
+// the hostname would not resolve otherwise. The DNSEndpoint is deleted once
+// publishing is disabled.
+func (r *EDSResource) PublishAddresses(ctx context.Context, addresses []string) error {
+	current, err := r.getDNSEndpoint(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get DNSEndpoint %s/%s: %v", r.eds.Namespace, r.eds.Name, err)
+	}
+	if current != nil && !isOwnedReference(r, current.Metadata) {
+		return fmt.Errorf(
+			"the DNSEndpoint '%s/%s' is not owned by the %s '%s/%s'",
+			current.Metadata.Namespace, current.Metadata.Name,
+			r.eds.Kind,
+			r.eds.Namespace, r.eds.Name,
+		)
+	}
+
+	if r.eds.Spec.ExternalDNS == nil {
+		if current == nil {
+			return nil
+		}
+		err = r.kube.Discovery().RESTClient().Delete().AbsPath(r.dnsEndpointPath(r.eds.Name)).Do(ctx).Error()
+		if err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete DNSEndpoint %s/%s: %v", r.eds.Namespace, r.eds.Name, err)
+		}
+		r.recorder.Event(r.eds, v1.EventTypeNormal, "DeletedDNSEndpoint", fmt.Sprintf(
+			"Deleted DNSEndpoint '%s/%s' for %s", r.eds.Namespace, r.eds.Name, r.eds.Kind,
+		))
+		return nil
+	}
+
+	hostname := r.externalDNSHostname()
+	if hostname == "" {
+		return fmt.Errorf("no hostname configured for the external DNS of %s %s/%s and no zone configured for the operator", r.eds.Kind, r.eds.Namespace, r.eds.Name)
+	}
+	if len(addresses) == 0 {
+		if current == nil || len(current.Spec.Endpoints) == 0 {
+			return nil
+		}
+		addresses = current.Spec.Endpoints[0].Targets
+	}
+
+	endpoint := current
+	if endpoint == nil {
+		endpoint = &dnsEndpoint{
+			APIVersion: dnsEndpointAPIVersion,
+			Kind:       dnsEndpointKind,
+			Metadata: metav1.ObjectMeta{
+				Name:      r.eds.Name,
+				Namespace: r.eds.Namespace,
+				OwnerReferences: []metav1.OwnerReference{
+					{
+						APIVersion: r.eds.APIVersion,
+						Kind:       r.eds.Kind,
+						Name:       r.eds.Name,
+						UID:        r.eds.UID,
+					},
+				},
+			},
+		}
+	}
+	if !r.desiredDNSEndpoint(endpoint, hostname, addresses) {
+		return nil
+	}
+
+	body, err := json.Marshal(endpoint)
+	if err != nil {
+		return err
+	}
+	// the record is replaced with all its targets in a single update.
+	if current == nil {
+		err = r.kube.Discovery().RESTClient().Post().AbsPath(r.dnsEndpointPath("")).
+			SetHeader("Content-Type", "application/json").Body(body).Do(ctx).Error()
+	} else {
+		err = r.kube.Discovery().RESTClient().Put().AbsPath(r.dnsEndpointPath(r.eds.Name)).
+			SetHeader("Content-Type", "application/json").Body(body).Do(ctx).Error()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update DNSEndpoint %s/%s: %v", r.eds.Namespace, r.eds.Name, err)
+	}
+	r.recorder.Event(r.eds, v1.EventTypeNormal, "PublishedAddresses", fmt.Sprintf(
+		"Published %d addresses as %s", len(addresses), hostname,
+	))
+	return nil
+}
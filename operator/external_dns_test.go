@@ -0,0 +1,119 @@
+package operator
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	"github.com/zalando-incubator/es-operator/pkg/clientset"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestServingAddresses(t *testing.T) {
+	pod := func(name, ip string, ready v1.ConditionStatus) *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Status: v1.PodStatus{
+				PodIP:      ip,
+				Conditions: []v1.PodCondition{{Type: v1.PodReady, Status: ready}},
+			},
+		}
+	}
+	draining := pod("es-data-3", "10.2.10.4", v1.ConditionTrue)
+	draining.Annotations = map[string]string{operatorPodDrainingAnnotationKey: "true"}
+	pods := []*v1.Pod{
+		pod("es-data-0", "10.2.10.2", v1.ConditionTrue),
+		pod("es-data-1", "10.2.10.1", v1.ConditionTrue),
+		pod("es-data-2", "10.2.10.3", v1.ConditionFalse),
+		draining,
+		pod("es-data-4", "", v1.ConditionTrue),
+		pod("es-data-5", "10.2.10.6", v1.ConditionTrue),
+	}
+
+	assert.Equal(t, []string{"10.2.10.1", "10.2.10.2", "10.2.10.6"}, servingAddresses(pods))
+	assert.Equal(t, []string{"10.2.10.1", "10.2.10.2"}, servingAddresses(pods, pods[5]))
+}
+
+func TestPublishAddresses(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	path := "http://kubernetes/apis/externaldns.k8s.io/v1alpha1/namespaces/default/dnsendpoints"
+	var current *dnsEndpoint
+	var writes []dnsEndpoint
+	write := func(req *http.Request) (*http.Response, error) {
+		body, _ := io.ReadAll(req.Body)
+		var endpoint dnsEndpoint
+		require.NoError(t, json.Unmarshal(body, &endpoint))
+		writes = append(writes, endpoint)
+		current = &endpoint
+		return httpmock.NewStringResponse(200, string(body)), nil
+	}
+	httpmock.RegisterResponder("GET", path+"/es-data", func(req *http.Request) (*http.Response, error) {
+		if current == nil {
+			return httpmock.NewStringResponse(404, `{"kind":"Status","apiVersion":"v1","status":"Failure","reason":"NotFound","code":404}`), nil
+		}
+		return httpmock.NewJsonResponse(200, current)
+	})
+	httpmock.RegisterResponder("POST", path, write)
+	httpmock.RegisterResponder("PUT", path+"/es-data", write)
+	httpmock.RegisterResponder("DELETE", path+"/es-data", func(req *http.Request) (*http.Response, error) {
+		current = nil
+		return httpmock.NewStringResponse(200, `{}`), nil
+	})
+
+	eds := &zv1.ElasticsearchDataSet{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "zalando.org/v1", Kind: "ElasticsearchDataSet"},
+		ObjectMeta: metav1.ObjectMeta{Name: "es-data", Namespace: "default", UID: "uid"},
+		Spec: zv1.ElasticsearchDataSetSpec{
+			ExternalDNS: &zv1.ElasticsearchDataSetExternalDNS{},
+		},
+	}
+	r := &EDSResource{
+		eds:      eds,
+		kube:     &clientset.Clientset{Interface: kubernetes.NewForConfigOrDie(&rest.Config{Host: "http://kubernetes"})},
+		recorder: record.NewFakeRecorder(10),
+	}
+
+	// neither a hostname nor a zone is configured.
+	require.Error(t, r.PublishAddresses(context.Background(), []string{"10.2.10.1"}))
+
+	// nothing is published before a pod is serving.
+	r.externalDNSZone = "example.org."
+	require.NoError(t, r.PublishAddresses(context.Background(), nil))
+	require.Empty(t, writes)
+
+	require.NoError(t, r.PublishAddresses(context.Background(), []string{"10.2.10.1", "10.2.10.2"}))
+	require.Len(t, writes, 1)
+	assert.Equal(t, []externalDNSEndpoint{{
+		DNSName:    "es-data.default.example.org",
+		RecordType: "A",
+		RecordTTL:  defaultExternalDNSRecordTTL,
+		Targets:    []string{"10.2.10.1", "10.2.10.2"},
+	}}, writes[0].Spec.Endpoints)
+
+	// unchanged addresses aren't written again and the last addresses are
+	// kept while no pod is serving.
+	require.NoError(t, r.PublishAddresses(context.Background(), []string{"10.2.10.1", "10.2.10.2"}))
+	require.NoError(t, r.PublishAddresses(context.Background(), nil))
+	require.Len(t, writes, 1)
+
+	require.NoError(t, r.PublishAddresses(context.Background(), []string{"10.2.10.2"}))
+	require.Len(t, writes, 2)
+	assert.Equal(t, []string{"10.2.10.2"}, writes[1].Spec.Endpoints[0].Targets)
+
+	// the record is deleted once disabled.
+	eds.Spec.ExternalDNS = nil
+	require.NoError(t, r.PublishAddresses(context.Background(), nil))
+	require.Nil(t, current)
+}
@@ -0,0 +1,83 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// managedIndices returns the indices with shards on the pods.
+func managedIndices(pods []v1.Pod, esIndices []ESIndex, esShards []ESShard) map[string]ESIndex {
+	podIPs := make(map[string]struct{})
+	for _, pod := range pods {
+		if pod.Status.PodIP != "" {
+			podIPs[pod.Status.PodIP] = struct{}{}
+		}
+	}
+	managed := make(map[string]ESIndex)
+	for _, shard := range esShards {
+		if _, ok := podIPs[shard.IP]; ok {
+			for _, index := range esIndices {
+				if shard.Index == index.Index {
+					managed[shard.Index] = index
+					break
+				}
+			}
+		}
+	}
+	return managed
+}
+
+// requiredNodes returns the number of nodes needed to allocate all copies of
+// the indices, as Elasticsearch never allocates two copies of a shard to the
+// same node, and the index needing the most nodes.
+func requiredNodes(indices map[string]ESIndex) (int32, string) {
+	required := int32(0)
+	name := ""
+	for _, index := range indices {
+		if copies := index.Replicas + 1; copies > required || (copies == required && index.Index < name) {
+			required = copies
+			name = index.Index
+		}
+	}
+	return required, name
+}
+
+// checkExternalScaleDown returns an error if the replicas were reduced
+// outside of the autoscaler, e.g. via the scale subresource, below the
+// number of nodes needed to allocate all copies of the indices on the EDS.
+// Scale-downs of the autoscaler reduce the index replicas first and are not
+// checked.
+func (r *EDSResource) checkExternalScaleDown(ctx context.Context) error {
+	if hasScalingOperation(r.eds) {
+		return nil
+	}
+
+	pods, err := r.kube.CoreV1().Pods(r.eds.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: labels.Set(r.LabelSelector()).String(),
+	})
+	if err != nil {
+		return err
+	}
+	esIndices, err := r.esClient.GetIndices()
+	if err != nil {
+		return err
+	}
+	esShards, err := r.esClient.GetShards()
+	if err != nil {
+		return err
+	}
+
+	replicas := edsReplicas(r.eds)
+	required, index := requiredNodes(managedIndices(pods.Items, esIndices, esShards))
+	if replicas >= required {
+		return nil
+	}
+	message := fmt.Sprintf("Not scaling down to %d replicas, index %s needs %d nodes for all copies of its shards. Reduce its number_of_replicas first.",
+		replicas, index, required)
+	r.recorder.Event(r.eds, v1.EventTypeWarning, "ScaleDownBlocked", message)
+	return fmt.Errorf("%s", message)
+}
@@ -0,0 +1,72 @@
+package operator
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	"github.com/zalando-incubator/es-operator/pkg/clientset"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestRequiredNodes(t *testing.T) {
+	required, index := requiredNodes(nil)
+	require.Zero(t, required)
+	require.Empty(t, index)
+
+	required, index = requiredNodes(map[string]ESIndex{
+		"a": {Index: "a", Primaries: 5, Replicas: 1},
+		"b": {Index: "b", Primaries: 1, Replicas: 2},
+		"c": {Index: "c", Primaries: 1, Replicas: 2},
+	})
+	require.EqualValues(t, 3, required)
+	require.Equal(t, "b", index)
+}
+
+func TestCheckExternalScaleDown(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_cat/indices",
+		httpmock.NewStringResponder(200, `[{"index":"logs","pri":"2","rep":"2"},{"index":"other","pri":"1","rep":"4"}]`))
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_cat/shards",
+		httpmock.NewStringResponder(200, `[{"index":"logs","ip":"10.2.0.1"},{"index":"logs","ip":"10.2.0.2"},{"index":"other","ip":"10.3.0.1"}]`))
+
+	pod := func(name, ip string) *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{esDataSetLabelKey: "logs"}},
+			Status:     v1.PodStatus{PodIP: ip},
+		}
+	}
+	esURL, _ := url.Parse("http://elasticsearch:9200")
+	replicas := int32(3)
+	recorder := record.NewFakeRecorder(10)
+	r := &EDSResource{
+		eds: &zv1.ElasticsearchDataSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "logs", Namespace: "default"},
+			Spec:       zv1.ElasticsearchDataSetSpec{Replicas: &replicas},
+		},
+		kube:     &clientset.Clientset{Interface: fake.NewSimpleClientset(pod("logs-0", "10.2.0.1"), pod("logs-1", "10.2.0.2"), pod("logs-2", "10.2.0.3"))},
+		esClient: &ESClient{Endpoint: esURL},
+		recorder: recorder,
+	}
+
+	// 3 nodes hold the 3 copies of the shards of logs.
+	require.NoError(t, r.checkExternalScaleDown(context.Background()))
+
+	replicas = 2
+	require.Error(t, r.checkExternalScaleDown(context.Background()))
+	require.Len(t, recorder.Events, 1)
+
+	// scale-downs of the autoscaler reduce the index replicas first.
+	r.eds.Status.ScalingState = &zv1.ElasticsearchDataSetScalingState{
+		Operation: &zv1.ElasticsearchDataSetScalingOperation{Direction: zv1.ScalingDirectionDown, NodeReplicas: &replicas},
+	}
+	require.NoError(t, r.checkExternalScaleDown(context.Background()))
+}
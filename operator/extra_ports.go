@@ -0,0 +1,86 @@
+package operator
+
+import (
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// extraPortProtocol returns the protocol of the port defaulting to TCP.
+func extraPortProtocol(port zv1.ElasticsearchDataSetPort) v1.Protocol {
+	if port.Protocol == "" {
+		return v1.ProtocolTCP
+	}
+	return port.Protocol
+}
+
+// injectExtraPorts adds the extra ports to the containers of the pod spec.
+// Ports already defined on the container, by name or port number, are not
+// added again.
+func injectExtraPorts(spec *v1.PodSpec, ports []zv1.ElasticsearchDataSetPort) {
+	if len(spec.Containers) == 0 {
+		return
+	}
+
+	for _, port := range ports {
+		idx := 0
+		if port.Container != "" {
+			idx = -1
+			for i, container := range spec.Containers {
+				if container.Name == port.Container {
+					idx = i
+					break
+				}
+			}
+			if idx < 0 {
+				continue
+			}
+		}
+
+		container := &spec.Containers[idx]
+		exists := false
+		for _, containerPort := range container.Ports {
+			if containerPort.Name == port.Name ||
+				(containerPort.ContainerPort == port.ContainerPort && containerPort.Protocol == extraPortProtocol(port)) {
+				exists = true
+				break
+			}
+		}
+		if exists {
+			continue
+		}
+
+		container.Ports = append(container.Ports, v1.ContainerPort{
+			Name:          port.Name,
+			ContainerPort: port.ContainerPort,
+			Protocol:      extraPortProtocol(port),
+		})
+	}
+}
+
+// edsServicePorts returns the ports of the EDS service, the Elasticsearch
+// HTTP port followed by the extra ports.
+func edsServicePorts(ports []zv1.ElasticsearchDataSetPort) []v1.ServicePort {
+	servicePorts := []v1.ServicePort{
+		{
+			Name:       "elasticsearch",
+			Protocol:   v1.ProtocolTCP,
+			Port:       defaultElasticsearchDataSetEndpointPort,
+			TargetPort: intstr.FromInt(defaultElasticsearchDataSetEndpointPort),
+		},
+	}
+
+	for _, port := range ports {
+		servicePort := port.ServicePort
+		if servicePort == 0 {
+			servicePort = port.ContainerPort
+		}
+		servicePorts = append(servicePorts, v1.ServicePort{
+			Name:       port.Name,
+			Protocol:   extraPortProtocol(port),
+			Port:       servicePort,
+			TargetPort: intstr.FromInt(int(port.ContainerPort)),
+		})
+	}
+	return servicePorts
+}
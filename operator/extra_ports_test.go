@@ -0,0 +1,83 @@
+package operator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	"github.com/zalando-incubator/es-operator/pkg/clientset"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestInjectExtraPorts(t *testing.T) {
+	spec := &v1.PodSpec{
+		Containers: []v1.Container{
+			{
+				Name:  "elasticsearch",
+				Ports: []v1.ContainerPort{{ContainerPort: 9200, Protocol: v1.ProtocolTCP}},
+			},
+			{Name: "exporter"},
+		},
+	}
+
+	injectExtraPorts(spec, []zv1.ElasticsearchDataSetPort{
+		{Name: "transport", ContainerPort: 9300},
+		{Name: "http", ContainerPort: 9200},
+		{Name: "metrics", ContainerPort: 9114, Container: "exporter"},
+		{Name: "unknown", ContainerPort: 8080, Container: "missing"},
+	})
+
+	assert.Equal(t, []v1.ContainerPort{
+		{ContainerPort: 9200, Protocol: v1.ProtocolTCP},
+		{Name: "transport", ContainerPort: 9300, Protocol: v1.ProtocolTCP},
+	}, spec.Containers[0].Ports)
+	assert.Equal(t, []v1.ContainerPort{
+		{Name: "metrics", ContainerPort: 9114, Protocol: v1.ProtocolTCP},
+	}, spec.Containers[1].Ports)
+}
+
+func TestEDSServicePorts(t *testing.T) {
+	ports := edsServicePorts([]zv1.ElasticsearchDataSetPort{
+		{Name: "metrics", ContainerPort: 9114, ServicePort: 80},
+		{Name: "transport", ContainerPort: 9300},
+	})
+
+	assert.Equal(t, []v1.ServicePort{
+		{Name: "elasticsearch", Protocol: v1.ProtocolTCP, Port: 9200, TargetPort: intstr.FromInt(9200)},
+		{Name: "metrics", Protocol: v1.ProtocolTCP, Port: 80, TargetPort: intstr.FromInt(9114)},
+		{Name: "transport", Protocol: v1.ProtocolTCP, Port: 9300, TargetPort: intstr.FromInt(9300)},
+	}, ports)
+}
+
+func TestEnsureServiceExtraPorts(t *testing.T) {
+	eds := &zv1.ElasticsearchDataSet{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "zalando.org/v1", Kind: "ElasticsearchDataSet"},
+		ObjectMeta: metav1.ObjectMeta{Name: "es-data", Namespace: "default", UID: "uid"},
+	}
+	kube := fake.NewSimpleClientset()
+	r := &EDSResource{
+		eds:      eds,
+		kube:     &clientset.Clientset{Interface: kube},
+		recorder: record.NewFakeRecorder(10),
+	}
+
+	err := r.ensureService(context.Background())
+	require.NoError(t, err)
+	svc, err := kube.CoreV1().Services("default").Get(context.Background(), "es-data", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Len(t, svc.Spec.Ports, 1)
+
+	eds.Spec.ExtraPorts = []zv1.ElasticsearchDataSetPort{{Name: "metrics", ContainerPort: 9114}}
+	err = r.ensureService(context.Background())
+	require.NoError(t, err)
+	svc, err = kube.CoreV1().Services("default").Get(context.Background(), "es-data", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Len(t, svc.Spec.Ports, 2)
+	assert.Equal(t, "metrics", svc.Spec.Ports[1].Name)
+}
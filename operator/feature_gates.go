@@ -0,0 +1,128 @@
+package operator
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Feature is the name of a subsystem of the operator which can be enabled
+// or disabled with the --feature-gates flag.
+type Feature string
+
+const (
+	// GracefulShutdownAPI registers drained nodes with the node shutdown
+	// API of Elasticsearch 7.14+.
+	GracefulShutdownAPI Feature = "GracefulShutdownAPI"
+	// PredictiveScaling scales EDS up ahead of their recurring daily peaks.
+	PredictiveScaling Feature = "PredictiveScaling"
+	// PDBManagement creates and updates a PodDisruptionBudget for every EDS.
+	PDBManagement Feature = "PDBManagement"
+)
+
+// FeatureStage is the maturity of a feature. Alpha features are disabled by
+// default, so large new subsystems can ship dark and be enabled per
+// environment.
+type FeatureStage string
+
+const (
+	Alpha FeatureStage = "Alpha"
+	Beta  FeatureStage = "Beta"
+)
+
+// FeatureSpec is the default state and the stage of a feature.
+type FeatureSpec struct {
+	Default bool
+	Stage   FeatureStage
+}
+
+// knownFeatures are the features which can be gated.
+var knownFeatures = map[Feature]FeatureSpec{
+	GracefulShutdownAPI: {Default: false, Stage: Alpha},
+	PredictiveScaling:   {Default: false, Stage: Alpha},
+	PDBManagement:       {Default: false, Stage: Alpha},
+}
+
+// FeatureGates are the features explicitly enabled or disabled, features
+// not set are in their default state.
+type FeatureGates map[Feature]bool
+
+// ParseFeatureGates parses a comma-separated list of <feature>=<bool>.
+func ParseFeatureGates(value string) (FeatureGates, error) {
+	gates := make(FeatureGates)
+	for _, gate := range strings.Split(value, ",") {
+		gate = strings.TrimSpace(gate)
+		if gate == "" {
+			continue
+		}
+		name, enabled, ok := strings.Cut(gate, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid feature gate %s, expected <feature>=<bool>", gate)
+		}
+		feature := Feature(strings.TrimSpace(name))
+		if _, ok := knownFeatures[feature]; !ok {
+			return nil, fmt.Errorf("unknown feature gate %s", feature)
+		}
+		b, err := strconv.ParseBool(strings.TrimSpace(enabled))
+		if err != nil {
+			return nil, fmt.Errorf("invalid value of feature gate %s: %v", feature, err)
+		}
+		gates[feature] = b
+	}
+	return gates, nil
+}
+
+// String returns the gates as comma-separated list of <feature>=<bool>.
+func (g FeatureGates) String() string {
+	gates := make([]string, 0, len(g))
+	for feature, enabled := range g {
+		gates = append(gates, fmt.Sprintf("%s=%t", feature, enabled))
+	}
+	sort.Strings(gates)
+	return strings.Join(gates, ",")
+}
+
+var (
+	featureGatesMux sync.RWMutex
+	featureGates    FeatureGates
+)
+
+// SetFeatureGates sets the state of the gated features. It must be called
+// before the operator runs.
+func SetFeatureGates(gates FeatureGates) {
+	featureGatesMux.Lock()
+	defer featureGatesMux.Unlock()
+	featureGates = gates
+}
+
+// featureEnabled returns true if the feature is enabled.
+func featureEnabled(feature Feature) bool {
+	featureGatesMux.RLock()
+	defer featureGatesMux.RUnlock()
+	if enabled, ok := featureGates[feature]; ok {
+		return enabled
+	}
+	return knownFeatures[feature].Default
+}
+
+// FeatureState is the state of a feature reported by the health endpoint.
+type FeatureState struct {
+	Enabled bool         `json:"enabled"`
+	Default bool         `json:"default"`
+	Stage   FeatureStage `json:"stage"`
+}
+
+// FeatureStates returns the state of all known features.
+func FeatureStates() map[Feature]FeatureState {
+	states := make(map[Feature]FeatureState, len(knownFeatures))
+	for feature, spec := range knownFeatures {
+		states[feature] = FeatureState{
+			Enabled: featureEnabled(feature),
+			Default: spec.Default,
+			Stage:   spec.Stage,
+		}
+	}
+	return states
+}
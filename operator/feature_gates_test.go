@@ -0,0 +1,55 @@
+package operator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+)
+
+func TestParseFeatureGates(t *testing.T) {
+	gates, err := ParseFeatureGates("GracefulShutdownAPI=false, PDBManagement=true")
+	require.NoError(t, err)
+	require.Equal(t, FeatureGates{GracefulShutdownAPI: false, PDBManagement: true}, gates)
+	require.Equal(t, "GracefulShutdownAPI=false,PDBManagement=true", gates.String())
+
+	gates, err = ParseFeatureGates("")
+	require.NoError(t, err)
+	require.Empty(t, gates)
+
+	_, err = ParseFeatureGates("Unknown=true")
+	require.EqualError(t, err, "unknown feature gate Unknown")
+	_, err = ParseFeatureGates("PredictiveScaling")
+	require.Error(t, err)
+	_, err = ParseFeatureGates("PredictiveScaling=maybe")
+	require.Error(t, err)
+}
+
+func TestFeatureGates(t *testing.T) {
+	defer SetFeatureGates(nil)
+
+	nodeShutdown := true
+	eds := &zv1.ElasticsearchDataSet{
+		Spec: zv1.ElasticsearchDataSetSpec{
+			Experimental: &zv1.ExperimentalSpec{
+				Draining: &zv1.ElasticsearchDataSetDraining{NodeShutdown: &nodeShutdown},
+			},
+			Scaling: &zv1.ElasticsearchDataSetScaling{
+				Predictive: &zv1.ElasticsearchDataSetPredictiveScaling{Enabled: true},
+			},
+		},
+	}
+	o := &ElasticsearchOperator{}
+
+	// the features ship dark.
+	require.False(t, featureEnabled(GracefulShutdownAPI))
+	require.False(t, o.getDrainingConfig(eds).NodeShutdown)
+	require.False(t, predictiveScalingEnabled(eds))
+	require.False(t, featureEnabled(PDBManagement))
+
+	SetFeatureGates(FeatureGates{GracefulShutdownAPI: true, PredictiveScaling: true})
+	require.True(t, o.getDrainingConfig(eds).NodeShutdown)
+	require.True(t, predictiveScalingEnabled(eds))
+	require.False(t, featureEnabled(PDBManagement))
+	require.Equal(t, FeatureState{Enabled: true, Default: false, Stage: Alpha}, FeatureStates()[PredictiveScaling])
+}
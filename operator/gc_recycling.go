@@ -0,0 +1,161 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	defaultGCOldGenUsedPercentThreshold = 85
+	defaultGCThresholdDuration          = 10 * time.Minute
+)
+
+// gcPressure is the GC pressure of a single pod.
+type gcPressure struct {
+	pod               v1.Pod
+	oldGenUsedPercent float64
+	since             time.Time
+}
+
+// gcPressureSince returns the pods of an EDS under GC pressure, i.e. with an
+// old generation heap usage above the threshold. The time since when a pod
+// is under pressure is taken from previous or set to now if the pod was not
+// under pressure before. The result is sorted by old generation heap usage
+// starting with the highest usage.
+func gcPressureSince(pods []v1.Pod, stats []ESNodeJVMStats, threshold float64, previous map[types.UID]time.Time, now time.Time) []gcPressure {
+	statsByIP := make(map[string]ESNodeJVMStats, len(stats))
+	for _, s := range stats {
+		statsByIP[s.IP] = s
+	}
+
+	pressured := make([]gcPressure, 0)
+	for _, pod := range pods {
+		s, ok := statsByIP[pod.Status.PodIP]
+		if !ok || s.OldGenUsedPercent < threshold {
+			continue
+		}
+
+		since, ok := previous[pod.UID]
+		if !ok {
+			since = now
+		}
+		pressured = append(pressured, gcPressure{
+			pod:               pod,
+			oldGenUsedPercent: s.OldGenUsedPercent,
+			since:             since,
+		})
+	}
+
+	sort.Slice(pressured, func(i, j int) bool {
+		return pressured[i].oldGenUsedPercent > pressured[j].oldGenUsedPercent
+	})
+	return pressured
+}
+
+// gcRecyclingSettings returns the threshold and duration of the GC recycling
+// policy with defaults applied.
+func gcRecyclingSettings(recycling *zv1.ElasticsearchDataSetGCRecycling) (float64, time.Duration) {
+	threshold := float64(defaultGCOldGenUsedPercentThreshold)
+	if recycling.OldGenUsedPercentThreshold > 0 {
+		threshold = float64(recycling.OldGenUsedPercentThreshold)
+	}
+
+	duration := defaultGCThresholdDuration
+	if recycling.ThresholdDurationSeconds > 0 {
+		duration = time.Duration(recycling.ThresholdDurationSeconds) * time.Second
+	}
+	return threshold, duration
+}
+
+// runGCRecycler checks at an interval if pods of EDS with GC recycling
+// enabled are under sustained GC pressure. Such pods are marked draining,
+// which makes the operator drain and recreate them one at a time.
+func (o *ElasticsearchOperator) runGCRecycler(ctx context.Context) {
+	nextCheck := time.Now().Add(-o.autoscalerInterval)
+	pressure := make(map[types.UID]map[types.UID]time.Time)
+
+	for {
+		o.logger.Debug("Checking GC pressure")
+		select {
+		case <-time.After(time.Until(nextCheck)):
+			nextCheck = time.Now().Add(o.autoscalerInterval)
+
+			resources, err := o.collectResources(ctx)
+			if err != nil {
+				o.logger.Error(err)
+				continue
+			}
+
+			next := make(map[types.UID]map[types.UID]time.Time, len(pressure))
+			for uid, es := range resources {
+				recycling := es.ElasticsearchDataSet.Spec.GCRecycling
+				if recycling == nil || !recycling.Enabled {
+					continue
+				}
+
+				since, err := o.recycleGCPressuredPod(ctx, es, pressure[uid])
+				if err != nil {
+					o.logger.Errorf("Failed to check GC pressure of EDS %s/%s: %v", es.ElasticsearchDataSet.Namespace, es.ElasticsearchDataSet.Name, err)
+				}
+				next[uid] = since
+			}
+			pressure = next
+		case <-ctx.Done():
+			o.logger.Info("Terminating GC recycler loop.")
+			return
+		}
+	}
+}
+
+// recycleGCPressuredPod marks the pod of the EDS with the highest GC pressure
+// draining, if it has been under pressure for longer than the configured
+// duration. It returns the time since when the pods are under pressure.
+func (o *ElasticsearchOperator) recycleGCPressuredPod(ctx context.Context, es *ESResource, previous map[types.UID]time.Time) (map[types.UID]time.Time, error) {
+	eds := es.ElasticsearchDataSet
+	threshold, duration := gcRecyclingSettings(eds.Spec.GCRecycling)
+
+	client := &ESClient{Endpoint: o.getElasticsearchEndpoint(eds), transport: o.elasticsearchTransport(eds)}
+	stats, err := client.GetNodesJVMStats()
+	if err != nil {
+		return previous, err
+	}
+
+	now := time.Now()
+	pressured := gcPressureSince(es.Pods, stats, threshold, previous, now)
+	since := make(map[types.UID]time.Time, len(pressured))
+	for _, p := range pressured {
+		since[p.pod.UID] = p.since
+	}
+
+	// only recycle one pod at a time.
+	for _, pod := range es.Pods {
+		if _, ok := pod.Annotations[operatorPodDrainingAnnotationKey]; ok {
+			return since, nil
+		}
+	}
+
+	for _, p := range pressured {
+		if now.Sub(p.since) < duration {
+			continue
+		}
+
+		err := annotatePod(ctx, o.kube, &p.pod, operatorPodDrainingAnnotationKey, "true")
+		if err != nil {
+			return since, fmt.Errorf("failed to mark Pod %s/%s draining: %v", p.pod.Namespace, p.pod.Name, err)
+		}
+
+		message := fmt.Sprintf("Recycling Pod '%s/%s': old generation heap usage %.0f%% above %.0f%% for %s",
+			p.pod.Namespace, p.pod.Name, p.oldGenUsedPercent, threshold, now.Sub(p.since).Round(time.Second))
+		o.recorder.Event(eds, v1.EventTypeNormal, "RecyclingPod", message)
+		o.decisions.Record("GCRecycling", eds.Namespace, eds.Name, message)
+		delete(since, p.pod.UID)
+		break
+	}
+	return since, nil
+}
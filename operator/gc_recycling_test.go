@@ -0,0 +1,120 @@
+package operator
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	"github.com/zalando-incubator/es-operator/pkg/clientset"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+)
+
+func gcTestPod(name, ip string) v1.Pod {
+	return v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", UID: types.UID(name)},
+		Status:     v1.PodStatus{PodIP: ip},
+	}
+}
+
+func TestGCPressureSince(t *testing.T) {
+	now := time.Now()
+	pods := []v1.Pod{
+		gcTestPod("es-data-0", "10.0.0.1"),
+		gcTestPod("es-data-1", "10.0.0.2"),
+		gcTestPod("es-data-2", "10.0.0.3"),
+	}
+	stats := []ESNodeJVMStats{
+		{IP: "10.0.0.1", OldGenUsedPercent: 90},
+		{IP: "10.0.0.2", OldGenUsedPercent: 50},
+		{IP: "10.0.0.3", OldGenUsedPercent: 95},
+	}
+	previous := map[types.UID]time.Time{
+		"es-data-0": now.Add(-time.Hour),
+		"es-data-1": now.Add(-time.Hour),
+	}
+
+	pressured := gcPressureSince(pods, stats, 85, previous, now)
+	require.Len(t, pressured, 2)
+	assert.Equal(t, "es-data-2", pressured[0].pod.Name)
+	assert.Equal(t, now, pressured[0].since)
+	assert.Equal(t, "es-data-0", pressured[1].pod.Name)
+	assert.Equal(t, now.Add(-time.Hour), pressured[1].since)
+}
+
+func TestGCRecyclingSettings(t *testing.T) {
+	threshold, duration := gcRecyclingSettings(&zv1.ElasticsearchDataSetGCRecycling{Enabled: true})
+	assert.Equal(t, float64(defaultGCOldGenUsedPercentThreshold), threshold)
+	assert.Equal(t, defaultGCThresholdDuration, duration)
+
+	threshold, duration = gcRecyclingSettings(&zv1.ElasticsearchDataSetGCRecycling{
+		Enabled:                    true,
+		OldGenUsedPercentThreshold: 75,
+		ThresholdDurationSeconds:   60,
+	})
+	assert.Equal(t, float64(75), threshold)
+	assert.Equal(t, time.Minute, duration)
+}
+
+func TestRecycleGCPressuredPod(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_nodes/stats/jvm",
+		httpmock.NewStringResponder(200, `{"nodes":{"a":{"ip":"10.0.0.1","jvm":{"mem":{"pools":{"old":{"used_in_bytes":90,"max_in_bytes":100}}}}},"b":{"ip":"10.0.0.2","jvm":{"mem":{"pools":{"old":{"used_in_bytes":95,"max_in_bytes":100}}}}}}}`))
+
+	pods := []v1.Pod{
+		gcTestPod("es-data-0", "10.0.0.1"),
+		gcTestPod("es-data-1", "10.0.0.2"),
+	}
+	kube := fake.NewSimpleClientset(&pods[0], &pods[1])
+	endpoint, err := url.Parse("http://elasticsearch:9200")
+	require.NoError(t, err)
+	esOperator := NewElasticsearchOperator(&clientset.Clientset{Interface: kube}, Options{Interval: time.Second, AutoscalerInterval: time.Second, ClusterDNSZone: "cluster.local.", ElasticsearchEndpoint: endpoint})
+	esOperator.recorder = record.NewFakeRecorder(10)
+
+	es := &ESResource{
+		ElasticsearchDataSet: &zv1.ElasticsearchDataSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "es-data", Namespace: "default"},
+			Spec: zv1.ElasticsearchDataSetSpec{
+				GCRecycling: &zv1.ElasticsearchDataSetGCRecycling{Enabled: true},
+			},
+		},
+		Pods: pods,
+	}
+
+	// pods under pressure are not recycled immediately.
+	since, err := esOperator.recycleGCPressuredPod(context.Background(), es, nil)
+	require.NoError(t, err)
+	assert.Len(t, since, 2)
+
+	// the pod with the highest pressure is recycled after the duration.
+	since["es-data-0"] = time.Now().Add(-time.Hour)
+	since["es-data-1"] = time.Now().Add(-time.Hour)
+	since, err = esOperator.recycleGCPressuredPod(context.Background(), es, since)
+	require.NoError(t, err)
+	assert.Len(t, since, 1)
+	assert.Contains(t, since, types.UID("es-data-0"))
+
+	pod, err := kube.CoreV1().Pods("default").Get(context.Background(), "es-data-1", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Contains(t, pod.Annotations, operatorPodDrainingAnnotationKey)
+
+	// only one pod is recycled at a time.
+	es.Pods[1] = *pod
+	since["es-data-0"] = time.Now().Add(-time.Hour)
+	_, err = esOperator.recycleGCPressuredPod(context.Background(), es, since)
+	require.NoError(t, err)
+
+	pod, err = kube.CoreV1().Pods("default").Get(context.Background(), "es-data-0", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.NotContains(t, pod.Annotations, operatorPodDrainingAnnotationKey)
+}
@@ -0,0 +1,81 @@
+package operator
+
+import (
+	"net/url"
+
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	readinessProbeWaitForStatus = "yellow"
+	readinessProbeTimeout       = "1s"
+)
+
+// healthPath returns the path of the health endpoint of the health check.
+// If waitForStatus is set, the request waits up to the timeout for the
+// status and fails if it isn't reached.
+func healthPath(health *zv1.ElasticsearchDataSetHealth, waitForStatus, timeout string) string {
+	path := "/_cluster/health"
+	query := url.Values{}
+
+	if health != nil {
+		switch health.Source {
+		case zv1.HealthSourceLocal:
+			query.Set("local", "true")
+		case zv1.HealthSourceIndex:
+			if health.Index != "" {
+				path += "/" + url.PathEscape(health.Index)
+			}
+		}
+	}
+
+	if waitForStatus != "" {
+		query.Set("wait_for_status", waitForStatus)
+		query.Set("timeout", timeout)
+	}
+
+	if len(query) == 0 {
+		return path
+	}
+	return path + "?" + query.Encode()
+}
+
+// injectReadinessProbe adds a readiness probe based on the health check to
+// the Elasticsearch container, the first container of the pod, if it
+// doesn't have one. With credentials the kubelet can't authenticate the
+// request, so the probe runs curl in the container instead.
+func injectReadinessProbe(spec *v1.PodSpec, health *zv1.ElasticsearchDataSetHealth, access localAccess) {
+	if health == nil || !health.ReadinessProbe || len(spec.Containers) == 0 {
+		return
+	}
+
+	container := &spec.Containers[0]
+	if container.ReadinessProbe != nil {
+		return
+	}
+
+	path := healthPath(health, readinessProbeWaitForStatus, readinessProbeTimeout)
+	var handler v1.ProbeHandler
+	if access.credentials != nil {
+		access.injectEnv(container)
+		handler.Exec = &v1.ExecAction{
+			Command: []string{"/bin/sh", "-c", access.curlCommand("", path)},
+		}
+	} else {
+		handler.HTTPGet = &v1.HTTPGetAction{
+			Path: path,
+			Port: intstr.FromInt(defaultElasticsearchDataSetEndpointPort),
+		}
+		if access.tls {
+			handler.HTTPGet.Scheme = v1.URISchemeHTTPS
+		}
+	}
+
+	container.ReadinessProbe = &v1.Probe{
+		ProbeHandler:   handler,
+		TimeoutSeconds: 5,
+		PeriodSeconds:  10,
+	}
+}
@@ -0,0 +1,77 @@
+package operator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestHealthPath(t *testing.T) {
+	for _, tc := range []struct {
+		msg      string
+		health   *zv1.ElasticsearchDataSetHealth
+		wait     string
+		expected string
+	}{
+		{
+			msg:      "default is the cluster health",
+			expected: "/_cluster/health",
+		},
+		{
+			msg:      "wait for status",
+			health:   &zv1.ElasticsearchDataSetHealth{Source: zv1.HealthSourceCluster},
+			wait:     "green",
+			expected: "/_cluster/health?timeout=60s&wait_for_status=green",
+		},
+		{
+			msg:      "local health",
+			health:   &zv1.ElasticsearchDataSetHealth{Source: zv1.HealthSourceLocal},
+			wait:     "green",
+			expected: "/_cluster/health?local=true&timeout=60s&wait_for_status=green",
+		},
+		{
+			msg:      "index health",
+			health:   &zv1.ElasticsearchDataSetHealth{Source: zv1.HealthSourceIndex, Index: "logs-*"},
+			expected: "/_cluster/health/logs-%2A",
+		},
+	} {
+		t.Run(tc.msg, func(t *testing.T) {
+			assert.Equal(t, tc.expected, healthPath(tc.health, tc.wait, "60s"))
+		})
+	}
+}
+
+func TestInjectReadinessProbe(t *testing.T) {
+	spec := &v1.PodSpec{Containers: []v1.Container{{Name: "elasticsearch"}}}
+	injectReadinessProbe(spec, &zv1.ElasticsearchDataSetHealth{Source: zv1.HealthSourceLocal}, localAccess{})
+	assert.Nil(t, spec.Containers[0].ReadinessProbe)
+
+	injectReadinessProbe(spec, &zv1.ElasticsearchDataSetHealth{Source: zv1.HealthSourceLocal, ReadinessProbe: true}, localAccess{})
+	require.NotNil(t, spec.Containers[0].ReadinessProbe)
+	assert.Equal(t, "/_cluster/health?local=true&timeout=1s&wait_for_status=yellow", spec.Containers[0].ReadinessProbe.HTTPGet.Path)
+
+	// an existing probe is kept.
+	probe := spec.Containers[0].ReadinessProbe
+	injectReadinessProbe(spec, &zv1.ElasticsearchDataSetHealth{Source: zv1.HealthSourceCluster, ReadinessProbe: true}, localAccess{})
+	assert.Equal(t, probe, spec.Containers[0].ReadinessProbe)
+
+	// with TLS the probe uses HTTPS.
+	spec = &v1.PodSpec{Containers: []v1.Container{{Name: "elasticsearch"}}}
+	injectReadinessProbe(spec, &zv1.ElasticsearchDataSetHealth{ReadinessProbe: true}, localAccess{tls: true})
+	assert.Equal(t, v1.URISchemeHTTPS, spec.Containers[0].ReadinessProbe.HTTPGet.Scheme)
+
+	// with credentials curl authenticates the request.
+	spec = &v1.PodSpec{Containers: []v1.Container{{Name: "elasticsearch"}}}
+	injectReadinessProbe(spec, &zv1.ElasticsearchDataSetHealth{ReadinessProbe: true}, localAccess{tls: true, credentials: &v1.LocalObjectReference{Name: "es-credentials"}})
+	require.NotNil(t, spec.Containers[0].ReadinessProbe.Exec)
+	assert.Nil(t, spec.Containers[0].ReadinessProbe.HTTPGet)
+	assert.Equal(t, []string{"/bin/sh", "-c", `if [ -n "$ES_OPERATOR_API_KEY" ]; then set -- -H "Authorization: ApiKey $ES_OPERATOR_API_KEY"; else set -- -u "$ES_OPERATOR_USERNAME:$ES_OPERATOR_PASSWORD"; fi; ` +
+		`curl -sf -k "$@" 'https://localhost:9200/_cluster/health?timeout=1s&wait_for_status=yellow'`}, spec.Containers[0].ReadinessProbe.Exec.Command)
+	require.Len(t, spec.Containers[0].Env, 3)
+	assert.Equal(t, "ES_OPERATOR_API_KEY", spec.Containers[0].Env[0].Name)
+	assert.Equal(t, "es-credentials", spec.Containers[0].Env[0].ValueFrom.SecretKeyRef.Name)
+	assert.Equal(t, "apiKey", spec.Containers[0].Env[0].ValueFrom.SecretKeyRef.Key)
+}
@@ -0,0 +1,125 @@
+package operator
+
+import (
+	"fmt"
+	"strings"
+
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	heapDumpsVolume            = "es-operator-heap-dumps"
+	heapDumpsPath              = "/usr/share/elasticsearch/heap-dumps"
+	heapDumpsUploaderContainer = "es-operator-heap-dump-uploader"
+	javaOptsEnv                = "ES_JAVA_OPTS"
+)
+
+// heapDumpsEnabled returns true if heap dumps are enabled for the EDS.
+func heapDumpsEnabled(eds *zv1.ElasticsearchDataSet) bool {
+	return eds.Spec.HeapDumps != nil && eds.Spec.HeapDumps.Enabled
+}
+
+// heapDumpsMessage describes where to find the heap dumps of OOM killed
+// pods. The kernel OOM killer doesn't leave the JVM a chance to write a
+// heap dump, only an OutOfMemoryError of the JVM does.
+func heapDumpsMessage(eds *zv1.ElasticsearchDataSet, oomKills int32) string {
+	location := fmt.Sprintf("the volume %s of the pods", heapDumpsVolume)
+	if upload := eds.Spec.HeapDumps.Upload; upload != nil {
+		location = fmt.Sprintf("%s/%s/%s/", strings.TrimSuffix(upload.URL, "/"), eds.Namespace, eds.Name)
+	}
+	return fmt.Sprintf("%d containers were OOMKilled, heap dumps of OutOfMemoryErrors of the JVM are collected in %s", oomKills, location)
+}
+
+// heapDumpJavaOpts are the JVM options writing heap dumps and fatal error
+// logs to the volume.
+func heapDumpJavaOpts() string {
+	return fmt.Sprintf("-XX:+HeapDumpOnOutOfMemoryError -XX:HeapDumpPath=%s -XX:ErrorFile=%s/hs_err_pid%%p.log", heapDumpsPath, heapDumpsPath)
+}
+
+// heapDumpUploadScript uploads the heap dumps and fatal error logs which
+// aren't written anymore to the URL and removes them from the volume.
+func heapDumpUploadScript(url string) string {
+	upload := `aws s3 cp --only-show-errors --metadata "namespace=${NAMESPACE},eds=${EDS_NAME},pod=${POD_NAME}" "$f" "$target"`
+	if strings.HasPrefix(url, "gs://") {
+		upload = `gsutil -q -h "x-goog-meta-namespace:${NAMESPACE}" -h "x-goog-meta-eds:${EDS_NAME}" -h "x-goog-meta-pod:${POD_NAME}" cp "$f" "$target"`
+	}
+	return fmt.Sprintf(`cd %s || exit 1
+while true; do
+  for f in *.hprof hs_err_pid*.log; do
+    [ -f "$f" ] || continue
+    # skip files which are still written.
+    [ -n "$(find "$f" -mmin -1)" ] && continue
+    target="%s/${NAMESPACE}/${EDS_NAME}/${POD_NAME}/$(date -u +%%Y%%m%%dT%%H%%M%%SZ)-${f}"
+    %s && rm -f "$f" && echo "Uploaded $f to $target"
+  done
+  sleep 30
+done
+`, heapDumpsPath, strings.TrimSuffix(url, "/"), upload)
+}
+
+// injectHeapDumps mounts the heap dump volume into the Elasticsearch
+// container, adds the heap dump options to its ES_JAVA_OPTS and, if
+// configured, adds the uploader sidecar.
+func injectHeapDumps(spec *v1.PodSpec, edsName string, config *zv1.ElasticsearchDataSetHeapDumps) {
+	if config == nil || !config.Enabled || len(spec.Containers) == 0 {
+		return
+	}
+
+	var sizeLimit *resource.Quantity
+	if config.SizeLimit != nil {
+		limit := config.SizeLimit.DeepCopy()
+		sizeLimit = &limit
+	}
+	spec.Volumes = append(spec.Volumes, v1.Volume{
+		Name:         heapDumpsVolume,
+		VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{SizeLimit: sizeLimit}},
+	})
+
+	container := &spec.Containers[0]
+	container.VolumeMounts = append(container.VolumeMounts, v1.VolumeMount{
+		Name:      heapDumpsVolume,
+		MountPath: heapDumpsPath,
+	})
+	javaOpts := false
+	for i, env := range container.Env {
+		if env.Name == javaOptsEnv && env.ValueFrom == nil {
+			container.Env[i].Value = strings.TrimSpace(env.Value + " " + heapDumpJavaOpts())
+			javaOpts = true
+		}
+	}
+	if !javaOpts {
+		container.Env = append(container.Env, v1.EnvVar{Name: javaOptsEnv, Value: heapDumpJavaOpts()})
+	}
+
+	upload := config.Upload
+	if upload == nil {
+		return
+	}
+	uploader := v1.Container{
+		Name:    heapDumpsUploaderContainer,
+		Image:   upload.Image,
+		Command: []string{"sh", "-c", heapDumpUploadScript(upload.URL)},
+		Env: []v1.EnvVar{
+			{Name: "EDS_NAME", Value: edsName},
+			{Name: "NAMESPACE", ValueFrom: &v1.EnvVarSource{FieldRef: &v1.ObjectFieldSelector{FieldPath: "metadata.namespace"}}},
+			{Name: "POD_NAME", ValueFrom: &v1.EnvVarSource{FieldRef: &v1.ObjectFieldSelector{FieldPath: "metadata.name"}}},
+		},
+		Resources: v1.ResourceRequirements{
+			Requests: v1.ResourceList{
+				v1.ResourceCPU:    resource.MustParse("10m"),
+				v1.ResourceMemory: resource.MustParse("128Mi"),
+			},
+		},
+		VolumeMounts: []v1.VolumeMount{
+			{Name: heapDumpsVolume, MountPath: heapDumpsPath},
+		},
+	}
+	if upload.SecretName != "" {
+		uploader.EnvFrom = []v1.EnvFromSource{
+			{SecretRef: &v1.SecretEnvSource{LocalObjectReference: v1.LocalObjectReference{Name: upload.SecretName}}},
+		}
+	}
+	spec.Containers = append(spec.Containers, uploader)
+}
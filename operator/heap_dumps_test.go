@@ -0,0 +1,55 @@
+package operator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestInjectHeapDumps(t *testing.T) {
+	spec := func() *v1.PodSpec {
+		return &v1.PodSpec{
+			Containers: []v1.Container{{
+				Name:  "elasticsearch",
+				Image: "elasticsearch:8.6.2",
+				Env:   []v1.EnvVar{{Name: "ES_JAVA_OPTS", Value: "-Xms1g -Xmx1g"}},
+			}},
+		}
+	}
+
+	// disabled
+	disabled := spec()
+	injectHeapDumps(disabled, "logs", &zv1.ElasticsearchDataSetHeapDumps{})
+	require.Equal(t, spec(), disabled)
+
+	sizeLimit := resource.MustParse("4Gi")
+	config := &zv1.ElasticsearchDataSetHeapDumps{Enabled: true, SizeLimit: &sizeLimit}
+	withoutUpload := spec()
+	injectHeapDumps(withoutUpload, "logs", config)
+	require.Len(t, withoutUpload.Containers, 1)
+	require.Equal(t, "-Xms1g -Xmx1g "+heapDumpJavaOpts(), withoutUpload.Containers[0].Env[0].Value)
+	require.Equal(t, heapDumpsPath, withoutUpload.Containers[0].VolumeMounts[0].MountPath)
+	require.Equal(t, sizeLimit, *withoutUpload.Volumes[0].EmptyDir.SizeLimit)
+
+	config.Upload = &zv1.ElasticsearchDataSetHeapDumpUpload{URL: "gs://bucket/dumps/", Image: "google/cloud-sdk", SecretName: "gcs"}
+	withUpload := spec()
+	withUpload.Containers[0].Env = nil
+	injectHeapDumps(withUpload, "logs", config)
+	require.Equal(t, []v1.EnvVar{{Name: "ES_JAVA_OPTS", Value: heapDumpJavaOpts()}}, withUpload.Containers[0].Env)
+	require.Len(t, withUpload.Containers, 2)
+	uploader := withUpload.Containers[1]
+	require.Equal(t, heapDumpsUploaderContainer, uploader.Name)
+	require.Equal(t, "google/cloud-sdk", uploader.Image)
+	require.Equal(t, "gcs", uploader.EnvFrom[0].SecretRef.Name)
+	require.Equal(t, "logs", uploader.Env[0].Value)
+	require.Contains(t, uploader.Command[2], `target="gs://bucket/dumps/${NAMESPACE}/${EDS_NAME}/${POD_NAME}/`)
+	require.Contains(t, uploader.Command[2], "gsutil")
+}
+
+func TestHeapDumpUploadScript(t *testing.T) {
+	require.Contains(t, heapDumpUploadScript("s3://bucket"), "aws s3 cp")
+	require.Contains(t, heapDumpUploadScript("s3://bucket"), "%Y%m%dT%H%M%SZ")
+}
@@ -0,0 +1,357 @@
+package operator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	defaultHotNodeCPUThresholdPercent = 200
+	defaultHotShardDominancePercent   = 50
+)
+
+// ESNodeCPU is the CPU usage of an Elasticsearch node.
+type ESNodeCPU struct {
+	IP         string
+	Name       string
+	CPUPercent int32
+}
+
+// ESShardOps is the number of indexing and search operations served by a
+// shard copy since it was started.
+type ESShardOps struct {
+	Index string
+	Shard int32
+	IP    string
+	Node  string
+	Ops   int64
+}
+
+// _ESShardOps represents a shard copy from the response of _cat/shards
+// (only used internally)
+type _ESShardOps struct {
+	Index       string `json:"index"`
+	Shard       string `json:"shard"`
+	IP          string `json:"ip"`
+	Node        string `json:"node"`
+	IndexTotal  string `json:"indexing.index_total"`
+	SearchTotal string `json:"search.query_total"`
+}
+
+// hotShard is a shard dominating the load of a hot node.
+type hotShard struct {
+	shard       ESShardOps
+	node        ESNodeCPU
+	medianCPU   int32
+	loadPercent int32
+	// target is the least busy node of the EDS not hosting a copy of the
+	// shard, if any.
+	target *ESNodeCPU
+}
+
+// GetNodesCPU returns the CPU usage of all nodes in the cluster.
+func (c *ESClient) GetNodesCPU() ([]ESNodeCPU, error) {
+	resp, err := c.httpClient().R().
+		Get(c.Endpoint.String() + "/_nodes/stats/os?filter_path=nodes.*.ip,nodes.*.name,nodes.*.os.cpu.percent")
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, fmt.Errorf("code status %d - %s", resp.StatusCode(), resp.Body())
+	}
+
+	var esStats struct {
+		Nodes map[string]struct {
+			IP   string `json:"ip"`
+			Name string `json:"name"`
+			OS   struct {
+				CPU struct {
+					Percent int32 `json:"percent"`
+				} `json:"cpu"`
+			} `json:"os"`
+		} `json:"nodes"`
+	}
+	err = json.Unmarshal(resp.Body(), &esStats)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make([]ESNodeCPU, 0, len(esStats.Nodes))
+	for _, node := range esStats.Nodes {
+		nodes = append(nodes, ESNodeCPU{
+			IP:         strings.Split(node.IP, ":")[0],
+			Name:       node.Name,
+			CPUPercent: node.OS.CPU.Percent,
+		})
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].IP < nodes[j].IP
+	})
+	return nodes, nil
+}
+
+// GetShardOps returns the operations served by the started shard copies.
+func (c *ESClient) GetShardOps() ([]ESShardOps, error) {
+	resp, err := c.httpClient().R().
+		Get(c.Endpoint.String() + "/_cat/shards?h=index,shard,ip,node,indexing.index_total,search.query_total&format=json")
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, fmt.Errorf("code status %d - %s", resp.StatusCode(), resp.Body())
+	}
+
+	var esShards []_ESShardOps
+	err = json.Unmarshal(resp.Body(), &esShards)
+	if err != nil {
+		return nil, err
+	}
+
+	shards := make([]ESShardOps, 0, len(esShards))
+	for _, shard := range esShards {
+		// unassigned shards have no node.
+		if shard.IP == "" {
+			continue
+		}
+		number, err := strconv.Atoi(shard.Shard)
+		if err != nil {
+			return nil, err
+		}
+		indexTotal, _ := strconv.ParseInt(shard.IndexTotal, 10, 64)
+		searchTotal, _ := strconv.ParseInt(shard.SearchTotal, 10, 64)
+		shards = append(shards, ESShardOps{
+			Index: shard.Index,
+			Shard: int32(number),
+			IP:    shard.IP,
+			Node:  shard.Node,
+			Ops:   indexTotal + searchTotal,
+		})
+	}
+	return shards, nil
+}
+
+// MoveShard moves the copy of the shard from one node to another.
+func (c *ESClient) MoveShard(index string, shard int32, fromNode, toNode string) error {
+	command := map[string]interface{}{
+		"commands": []map[string]interface{}{
+			{
+				"move": map[string]interface{}{
+					"index":     index,
+					"shard":     shard,
+					"from_node": fromNode,
+					"to_node":   toNode,
+				},
+			},
+		},
+	}
+	resp, err := c.httpClient().R().
+		SetHeader("Content-Type", "application/json").
+		SetBody(command).
+		Post(c.Endpoint.String() + "/_cluster/reroute")
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return fmt.Errorf("code status %d - %s", resp.StatusCode(), resp.Body())
+	}
+	return nil
+}
+
+// hotShardSettings returns the node CPU threshold and the dominance of the
+// hot shard configuration with defaults applied.
+func hotShardSettings(config *zv1.ElasticsearchDataSetHotShards) (int32, int32) {
+	threshold := int32(defaultHotNodeCPUThresholdPercent)
+	if config.NodeCPUThresholdPercent > 0 {
+		threshold = config.NodeCPUThresholdPercent
+	}
+	dominance := int32(defaultHotShardDominancePercent)
+	if config.DominancePercent > 0 {
+		dominance = config.DominancePercent
+	}
+	return threshold, dominance
+}
+
+// shardOpsKey identifies a shard copy.
+func shardOpsKey(shard ESShardOps) string {
+	return fmt.Sprintf("%s/%d/%s", shard.Index, shard.Shard, shard.IP)
+}
+
+// detectHotShard returns the shard dominating the operations of the busiest
+// node, if its CPU usage exceeds the median of the nodes by the threshold.
+// The operations are the difference to the previous counters, so shard
+// copies without previous counters are ignored.
+func detectHotShard(nodes []ESNodeCPU, shards []ESShardOps, previous map[string]int64, thresholdPercent, dominancePercent int32) *hotShard {
+	if len(nodes) < 2 {
+		return nil
+	}
+
+	sorted := append([]ESNodeCPU(nil), nodes...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].CPUPercent < sorted[j].CPUPercent
+	})
+	median := sorted[(len(sorted)-1)/2].CPUPercent
+	busiest := sorted[len(sorted)-1]
+	if median <= 0 || busiest.CPUPercent*100 <= median*thresholdPercent {
+		return nil
+	}
+
+	var top *ESShardOps
+	topOps, totalOps := int64(0), int64(0)
+	for i, shard := range shards {
+		if shard.IP != busiest.IP {
+			continue
+		}
+		before, ok := previous[shardOpsKey(shard)]
+		if !ok || shard.Ops < before {
+			continue
+		}
+		ops := shard.Ops - before
+		totalOps += ops
+		if top == nil || ops > topOps {
+			top, topOps = &shards[i], ops
+		}
+	}
+	if top == nil || totalOps == 0 || topOps*100 < totalOps*int64(dominancePercent) {
+		return nil
+	}
+
+	hot := &hotShard{
+		shard:       *top,
+		node:        busiest,
+		medianCPU:   median,
+		loadPercent: int32(topOps * 100 / totalOps),
+	}
+
+	// the target must not host a copy of the shard already.
+	copies := make(map[string]struct{})
+	for _, shard := range shards {
+		if shard.Index == top.Index && shard.Shard == top.Shard {
+			copies[shard.IP] = struct{}{}
+		}
+	}
+	for i := range sorted {
+		if _, ok := copies[sorted[i].IP]; !ok {
+			hot.target = &sorted[i]
+			break
+		}
+	}
+	return hot
+}
+
+// shardOpsCounters returns the operation counters of the shard copies keyed
+// by shardOpsKey.
+func shardOpsCounters(shards []ESShardOps) map[string]int64 {
+	counters := make(map[string]int64, len(shards))
+	for _, shard := range shards {
+		counters[shardOpsKey(shard)] = shard.Ops
+	}
+	return counters
+}
+
+// handleHotShards detects a hot shard on the nodes of the EDS, records it in
+// the status and, if configured, moves it to the least busy node. It returns
+// the updated EDS and true if the shard was moved, in which case the EDS
+// isn't scaled.
+func (o *ElasticsearchOperator) handleHotShards(ctx context.Context, eds *zv1.ElasticsearchDataSet, es *ESResource, client *ESClient) (*zv1.ElasticsearchDataSet, bool, error) {
+	if o.shardOps == nil {
+		o.shardOps = make(map[types.UID]map[string]int64)
+	}
+	key := eds.UID
+
+	config := eds.Spec.Scaling.HotShards
+	if config == nil || !config.Enabled {
+		delete(o.shardOps, key)
+		return eds, false, nil
+	}
+
+	podIPs := make(map[string]struct{}, len(es.Pods))
+	for _, pod := range es.Pods {
+		if pod.Status.PodIP != "" {
+			podIPs[pod.Status.PodIP] = struct{}{}
+		}
+	}
+
+	allNodes, err := client.GetNodesCPU()
+	if err != nil {
+		return eds, false, err
+	}
+	nodes := make([]ESNodeCPU, 0, len(allNodes))
+	for _, node := range allNodes {
+		if _, ok := podIPs[node.IP]; ok {
+			nodes = append(nodes, node)
+		}
+	}
+	shards, err := client.GetShardOps()
+	if err != nil {
+		return eds, false, err
+	}
+
+	threshold, dominance := hotShardSettings(config)
+	hot := detectHotShard(nodes, shards, o.shardOps[key], threshold, dominance)
+	o.shardOps[key] = shardOpsCounters(shards)
+
+	var status *zv1.ElasticsearchDataSetHotShardStatus
+	if hot != nil {
+		status = &zv1.ElasticsearchDataSetHotShardStatus{
+			Index:            hot.shard.Index,
+			Shard:            hot.shard.Shard,
+			Node:             hot.node.Name,
+			NodeCPUPercent:   hot.node.CPUPercent,
+			MedianCPUPercent: hot.medianCPU,
+			LoadPercent:      hot.loadPercent,
+			Detected:         metav1.NewTime(time.Now()),
+		}
+		previous := eds.Status.HotShard
+		if previous != nil && previous.Index == status.Index && previous.Shard == status.Shard && previous.Node == status.Node {
+			status.Detected = previous.Detected
+		} else {
+			o.recorder.Event(eds, v1.EventTypeWarning, "HotShard", fmt.Sprintf(
+				"Shard %d of index %s serves %d%% of the operations of node %s using %d%% CPU, the median is %d%%",
+				status.Shard, status.Index, status.LoadPercent, status.Node, status.NodeCPUPercent, status.MedianCPUPercent))
+		}
+	}
+
+	if (status == nil) != (eds.Status.HotShard == nil) || (status != nil && status.Detected != eds.Status.HotShard.Detected) {
+		replicas := eds.Spec.Replicas
+		eds.Status.HotShard = status
+		updated, err := o.kube.ZalandoV1().ElasticsearchDataSets(eds.Namespace).UpdateStatus(ctx, eds, metav1.UpdateOptions{})
+		if err != nil {
+			return eds, false, err
+		}
+		updated.Spec.Replicas = replicas
+		eds = updated
+	}
+
+	if hot == nil || !config.Reroute || hot.target == nil {
+		return eds, false, nil
+	}
+
+	// don't move shards while others are still relocating.
+	health, err := client.ClusterHealth()
+	if err != nil {
+		return eds, false, err
+	}
+	if health.RelocatingShards > 0 {
+		return eds, true, nil
+	}
+
+	err = client.MoveShard(hot.shard.Index, hot.shard.Shard, hot.node.Name, hot.target.Name)
+	if err != nil {
+		return eds, false, fmt.Errorf("failed to move shard %d of index %s: %v", hot.shard.Shard, hot.shard.Index, err)
+	}
+	o.recorder.Event(eds, v1.EventTypeNormal, "ReroutedHotShard", fmt.Sprintf(
+		"Moved shard %d of index %s from node %s to node %s (%d%% CPU)",
+		hot.shard.Shard, hot.shard.Index, hot.node.Name, hot.target.Name, hot.target.CPUPercent))
+	return eds, true, nil
+}
@@ -0,0 +1,74 @@
+package operator
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHotShardClient(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_nodes/stats/os",
+		httpmock.NewStringResponder(200, `{"nodes":{"a":{"ip":"10.0.0.2:9300","name":"es-1","os":{"cpu":{"percent":90}}},"b":{"ip":"10.0.0.1","name":"es-0","os":{"cpu":{"percent":20}}}}}`))
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_cat/shards",
+		httpmock.NewStringResponder(200, `[{"index":"logs","shard":"1","ip":"10.0.0.1","node":"es-0","indexing.index_total":"10","search.query_total":"5"},{"index":"logs","shard":"2","ip":null,"node":null}]`))
+	httpmock.RegisterResponder("POST", "http://elasticsearch:9200/_cluster/reroute",
+		httpmock.NewStringResponder(200, `{"acknowledged":true}`))
+
+	esURL, _ := url.Parse("http://elasticsearch:9200")
+	client := &ESClient{Endpoint: esURL}
+
+	nodes, err := client.GetNodesCPU()
+	require.NoError(t, err)
+	require.Equal(t, []ESNodeCPU{
+		{IP: "10.0.0.1", Name: "es-0", CPUPercent: 20},
+		{IP: "10.0.0.2", Name: "es-1", CPUPercent: 90},
+	}, nodes)
+
+	shards, err := client.GetShardOps()
+	require.NoError(t, err)
+	require.Equal(t, []ESShardOps{{Index: "logs", Shard: 1, IP: "10.0.0.1", Node: "es-0", Ops: 15}}, shards)
+
+	require.NoError(t, client.MoveShard("logs", 1, "es-0", "es-1"))
+}
+
+func TestDetectHotShard(t *testing.T) {
+	nodes := []ESNodeCPU{
+		{IP: "10.0.0.1", Name: "es-0", CPUPercent: 30},
+		{IP: "10.0.0.2", Name: "es-1", CPUPercent: 95},
+		{IP: "10.0.0.3", Name: "es-2", CPUPercent: 20},
+	}
+	shards := []ESShardOps{
+		{Index: "hot", Shard: 0, IP: "10.0.0.2", Ops: 1900},
+		{Index: "hot", Shard: 0, IP: "10.0.0.1", Ops: 500},
+		{Index: "cold", Shard: 0, IP: "10.0.0.2", Ops: 300},
+		{Index: "new", Shard: 0, IP: "10.0.0.2", Ops: 5000},
+	}
+	previous := map[string]int64{
+		"hot/0/10.0.0.2":  1000,
+		"hot/0/10.0.0.1":  400,
+		"cold/0/10.0.0.2": 200,
+	}
+
+	hot := detectHotShard(nodes, shards, previous, 200, 50)
+	require.NotNil(t, hot)
+	require.Equal(t, "hot", hot.shard.Index)
+	require.Equal(t, "es-1", hot.node.Name)
+	require.Equal(t, int32(30), hot.medianCPU)
+	require.Equal(t, int32(90), hot.loadPercent)
+	// the least busy node without a copy of the shard
+	require.Equal(t, "es-2", hot.target.Name)
+
+	// not dominating
+	require.Nil(t, detectHotShard(nodes, shards, previous, 200, 95))
+	// node not hot enough
+	require.Nil(t, detectHotShard(nodes, shards, previous, 400, 50))
+	// no previous counters
+	require.Nil(t, detectHotShard(nodes, shards, nil, 200, 50))
+	// single node
+	require.Nil(t, detectHotShard(nodes[:1], shards, previous, 200, 50))
+}
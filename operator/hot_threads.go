@@ -0,0 +1,109 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	hotThreadsConfigMapSuffix = "-hot-threads"
+	// maxHotThreadsCaptures is the number of captures kept in the
+	// ConfigMap. Older captures are removed.
+	maxHotThreadsCaptures = 5
+	// maxHotThreadsCaptureSize limits the size of a single capture to
+	// keep the ConfigMap well below the 1MiB size limit.
+	maxHotThreadsCaptureSize = 128 * 1024
+	hotThreadsTruncatedNote  = "\n[truncated]\n"
+)
+
+// addHotThreadsCapture adds a capture to the ConfigMap data keyed by the
+// capture time and removes the oldest captures exceeding
+// maxHotThreadsCaptures.
+func addHotThreadsCapture(data map[string]string, at time.Time, capture string) {
+	if len(capture) > maxHotThreadsCaptureSize {
+		capture = capture[:maxHotThreadsCaptureSize-len(hotThreadsTruncatedNote)] + hotThreadsTruncatedNote
+	}
+	data[at.UTC().Format("20060102T150405Z")+".txt"] = capture
+
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys[:max(0, len(keys)-maxHotThreadsCaptures)] {
+		delete(data, key)
+	}
+}
+
+// captureHotThreads captures the hot threads of the EDS nodes and stores them
+// in the '<name>-hot-threads' ConfigMap owned by the EDS.
+func (o *ElasticsearchOperator) captureHotThreads(ctx context.Context, eds *zv1.ElasticsearchDataSet, pods []v1.Pod, client *ESClient, reason string) error {
+	ips := make([]string, 0, len(pods))
+	for _, pod := range pods {
+		if pod.Status.PodIP != "" {
+			ips = append(ips, pod.Status.PodIP)
+		}
+	}
+	if len(ips) == 0 {
+		return nil
+	}
+
+	hotThreads, err := client.GetHotThreads(ips)
+	if err != nil {
+		return fmt.Errorf("failed to get hot threads: %v", err)
+	}
+
+	name := eds.Name + hotThreadsConfigMapSuffix
+	cm, err := o.kube.CoreV1().ConfigMaps(eds.Namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return err
+		}
+		cm = nil
+	}
+
+	capture := fmt.Sprintf("# %s\n\n%s", reason, hotThreads)
+	now := time.Now()
+
+	if cm == nil {
+		cm = &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: eds.Namespace,
+				Labels:    eds.Labels,
+				OwnerReferences: []metav1.OwnerReference{
+					{
+						APIVersion: eds.APIVersion,
+						Kind:       eds.Kind,
+						Name:       eds.Name,
+						UID:        eds.UID,
+					},
+				},
+			},
+			Data: map[string]string{},
+		}
+		addHotThreadsCapture(cm.Data, now, capture)
+		_, err = o.kube.CoreV1().ConfigMaps(eds.Namespace).Create(ctx, cm, metav1.CreateOptions{})
+	} else {
+		if cm.Data == nil {
+			cm.Data = map[string]string{}
+		}
+		addHotThreadsCapture(cm.Data, now, capture)
+		_, err = o.kube.CoreV1().ConfigMaps(eds.Namespace).Update(ctx, cm, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to store hot threads in ConfigMap %s/%s: %v", eds.Namespace, name, err)
+	}
+
+	o.recorder.Event(eds, v1.EventTypeNormal, "CapturedHotThreads", fmt.Sprintf(
+		"Captured hot threads of %d nodes in ConfigMap '%s/%s'", len(ips), eds.Namespace, name,
+	))
+	return nil
+}
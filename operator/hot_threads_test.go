@@ -0,0 +1,78 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	"github.com/zalando-incubator/es-operator/pkg/clientset"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestAddHotThreadsCapture(t *testing.T) {
+	data := map[string]string{}
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < maxHotThreadsCaptures+2; i++ {
+		addHotThreadsCapture(data, start.Add(time.Duration(i)*time.Minute), fmt.Sprintf("capture %d", i))
+	}
+
+	assert.Len(t, data, maxHotThreadsCaptures)
+	assert.NotContains(t, data, "20240101T120000Z.txt")
+	assert.NotContains(t, data, "20240101T120100Z.txt")
+	assert.Equal(t, "capture 6", data["20240101T120600Z.txt"])
+
+	addHotThreadsCapture(data, start.Add(time.Hour), strings.Repeat("x", 2*maxHotThreadsCaptureSize))
+	capture := data["20240101T130000Z.txt"]
+	assert.Len(t, capture, maxHotThreadsCaptureSize)
+	assert.True(t, strings.HasSuffix(capture, hotThreadsTruncatedNote))
+}
+
+func TestCaptureHotThreads(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_nodes/10.0.0.1,10.0.0.2/hot_threads",
+		httpmock.NewStringResponder(200, "::: {es-data-0}\n   100.0% cpu usage by thread 'search'"))
+
+	kube := fake.NewSimpleClientset()
+	esOperator := NewElasticsearchOperator(&clientset.Clientset{Interface: kube}, Options{Interval: time.Second, AutoscalerInterval: time.Second, ClusterDNSZone: "cluster.local."})
+	esOperator.recorder = record.NewFakeRecorder(10)
+
+	endpoint, err := url.Parse("http://elasticsearch:9200")
+	require.NoError(t, err)
+	client := &ESClient{Endpoint: endpoint}
+
+	eds := &zv1.ElasticsearchDataSet{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "zalando.org/v1", Kind: "ElasticsearchDataSet"},
+		ObjectMeta: metav1.ObjectMeta{Name: "es-data", Namespace: "default", UID: "uid"},
+	}
+	pods := []v1.Pod{
+		{Status: v1.PodStatus{PodIP: "10.0.0.1"}},
+		{Status: v1.PodStatus{PodIP: "10.0.0.2"}},
+	}
+
+	for i := 0; i < 2; i++ {
+		err = esOperator.captureHotThreads(context.Background(), eds, pods, client, "Scaling up")
+		require.NoError(t, err)
+	}
+
+	cm, err := kube.CoreV1().ConfigMaps("default").Get(context.Background(), "es-data-hot-threads", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Len(t, cm.OwnerReferences, 1)
+	assert.Equal(t, "es-data", cm.OwnerReferences[0].Name)
+	require.NotEmpty(t, cm.Data)
+	for _, capture := range cm.Data {
+		assert.Contains(t, capture, "# Scaling up")
+		assert.Contains(t, capture, "cpu usage by thread 'search'")
+	}
+}
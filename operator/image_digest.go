@@ -0,0 +1,169 @@
+package operator
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	// podTemplateHashAnnotationKey is the hash of the pod template of a
+	// StatefulSet before its images were pinned.
+	podTemplateHashAnnotationKey = "es-operator.zalando.org/pod-template-hash"
+)
+
+// ImageResolver resolves an image reference to a reference pinned by digest.
+// The registry is accessed with the credentials of the keychain.
+type ImageResolver interface {
+	Resolve(ctx context.Context, image string, keychain authn.Keychain) (string, error)
+}
+
+// ImagePlatformResolver is implemented by image resolvers which can list the
+// platforms an image supports.
+type ImagePlatformResolver interface {
+	Platforms(ctx context.Context, image string, keychain authn.Keychain) ([]string, error)
+}
+
+// registryImageResolver resolves image tags to digests by querying the
+// registry API.
+type registryImageResolver struct{}
+
+// NewRegistryImageResolver returns an ImageResolver which queries the image
+// registries directly.
+func NewRegistryImageResolver() ImageResolver {
+	return &registryImageResolver{}
+}
+
+// Resolve returns the image pinned to the digest the tag currently points
+// to. Images which are already pinned are returned unchanged.
+func (r *registryImageResolver) Resolve(ctx context.Context, image string, keychain authn.Keychain) (string, error) {
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		return "", err
+	}
+
+	if _, ok := ref.(name.Digest); ok {
+		return image, nil
+	}
+
+	descriptor, err := remote.Head(ref, remoteOptions(ctx, keychain)...)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve '%s': %v", image, err)
+	}
+	return image + "@" + descriptor.Digest.String(), nil
+}
+
+// Platforms returns the platforms of the image index of the image in the
+// form 'os/architecture'. It returns nil for single platform images, as their
+// platform is only known from their config.
+func (r *registryImageResolver) Platforms(ctx context.Context, image string, keychain authn.Keychain) ([]string, error) {
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		return nil, err
+	}
+
+	descriptor, err := remote.Get(ref, remoteOptions(ctx, keychain)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get manifest of '%s': %v", image, err)
+	}
+	if !descriptor.MediaType.IsIndex() {
+		return nil, nil
+	}
+
+	index, err := descriptor.ImageIndex()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse manifest of '%s': %v", image, err)
+	}
+	manifest, err := index.IndexManifest()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse manifest of '%s': %v", image, err)
+	}
+
+	var platforms []string
+	for _, manifest := range manifest.Manifests {
+		if manifest.Platform == nil {
+			continue
+		}
+		platforms = append(platforms, manifest.Platform.OS+"/"+manifest.Platform.Architecture)
+	}
+	return platforms, nil
+}
+
+// remoteOptions are the options of the requests to the registries.
+func remoteOptions(ctx context.Context, keychain authn.Keychain) []remote.Option {
+	return []remote.Option{
+		remote.WithContext(ctx),
+		remote.WithAuthFromKeychain(keychain),
+	}
+}
+
+// pinImageDigests resolves the images of all containers in the pod spec to
+// digests.
+func pinImageDigests(ctx context.Context, resolver ImageResolver, keychain authn.Keychain, spec *v1.PodSpec) error {
+	for _, containers := range [][]v1.Container{spec.InitContainers, spec.Containers} {
+		for i := range containers {
+			image, err := resolver.Resolve(ctx, containers[i].Image, keychain)
+			if err != nil {
+				return err
+			}
+			containers[i].Image = image
+		}
+	}
+	return nil
+}
+
+// pinnedImages returns the digest pinned images of the containers in the pod
+// spec keyed by container name.
+func pinnedImages(spec *v1.PodSpec) map[string]string {
+	images := make(map[string]string)
+	for _, containers := range [][]v1.Container{spec.InitContainers, spec.Containers} {
+		for _, container := range containers {
+			if strings.Contains(container.Image, "@") {
+				images[container.Name] = container.Image
+			}
+		}
+	}
+	return images
+}
+
+// reusePinnedImages pins the images of the containers in the pod spec to the
+// digests they're pinned to in the previous pod spec. It returns false, and
+// leaves the pod spec unchanged, if any image isn't pinned in the previous
+// pod spec.
+func reusePinnedImages(spec, previous *v1.PodSpec) bool {
+	pinned := pinnedImages(previous)
+	for _, containers := range [][]v1.Container{spec.InitContainers, spec.Containers} {
+		for _, container := range containers {
+			if !strings.HasPrefix(pinned[container.Name], container.Image+"@") {
+				return false
+			}
+		}
+	}
+
+	for _, containers := range [][]v1.Container{spec.InitContainers, spec.Containers} {
+		for i := range containers {
+			containers[i].Image = pinned[containers[i].Name]
+		}
+	}
+	return true
+}
+
+// podTemplateHash returns a hash of the pod template, used to detect changes
+// of the rendered pod template independent of the pinned images and the
+// defaults set by the API server.
+func podTemplateHash(template *v1.PodTemplateSpec) (string, error) {
+	data, err := json.Marshal(template)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:16], nil
+}
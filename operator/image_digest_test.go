@@ -0,0 +1,207 @@
+package operator
+
+import (
+	"context"
+	"io"
+	stdlog "log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	containerregistryv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	"github.com/zalando-incubator/es-operator/pkg/clientset"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+)
+
+// newTestRegistry starts an in-memory registry requiring basic auth with the
+// username and password and returns its host.
+func newTestRegistry(t *testing.T, username, password string) string {
+	handler := registry.New(registry.Logger(stdlog.New(io.Discard, "", 0)))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if user, pass, ok := req.BasicAuth(); !ok || user != username || pass != password {
+			w.Header().Set("WWW-Authenticate", `Basic realm="registry"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, req)
+	}))
+	t.Cleanup(server.Close)
+	return strings.TrimPrefix(server.URL, "http://")
+}
+
+func TestResolveImageDigest(t *testing.T) {
+	host := newTestRegistry(t, "user", "secret")
+	keychain := pullSecretsKeychain{host: {Username: "user", Password: "secret"}}
+
+	image, err := random.Image(100, 1)
+	require.NoError(t, err)
+	ref, err := name.ParseReference(host + "/elasticsearch/elasticsearch:8.6.2")
+	require.NoError(t, err)
+	require.NoError(t, remote.Write(ref, image, remote.WithAuthFromKeychain(keychain)))
+	digest, err := image.Digest()
+	require.NoError(t, err)
+
+	resolver := NewRegistryImageResolver()
+
+	pinned, err := resolver.Resolve(context.Background(), host+"/elasticsearch/elasticsearch:8.6.2", keychain)
+	require.NoError(t, err)
+	assert.Equal(t, host+"/elasticsearch/elasticsearch:8.6.2@"+digest.String(), pinned)
+
+	// already pinned images are not resolved again.
+	pinned, err = resolver.Resolve(context.Background(), "registry.internal/es@"+digest.String(), keychain)
+	require.NoError(t, err)
+	assert.Equal(t, "registry.internal/es@"+digest.String(), pinned)
+
+	_, err = resolver.Resolve(context.Background(), host+"/missing", keychain)
+	assert.Error(t, err)
+
+	// the registry requires credentials.
+	_, err = resolver.Resolve(context.Background(), host+"/elasticsearch/elasticsearch:8.6.2", pullSecretsKeychain{})
+	assert.Error(t, err)
+}
+
+func TestImagePlatforms(t *testing.T) {
+	host := newTestRegistry(t, "user", "secret")
+	keychain := pullSecretsKeychain{host: {Username: "user", Password: "secret"}}
+
+	var index containerregistryv1.ImageIndex = empty.Index
+	for _, arch := range []string{"amd64", "arm64"} {
+		image, err := random.Image(100, 1)
+		require.NoError(t, err)
+		index = mutate.AppendManifests(index, mutate.IndexAddendum{
+			Add:        image,
+			Descriptor: containerregistryv1.Descriptor{Platform: &containerregistryv1.Platform{OS: "linux", Architecture: arch}},
+		})
+	}
+	ref, err := name.ParseReference(host + "/elasticsearch:8.6.2")
+	require.NoError(t, err)
+	require.NoError(t, remote.WriteIndex(ref, index, remote.WithAuthFromKeychain(keychain)))
+
+	image, err := random.Image(100, 1)
+	require.NoError(t, err)
+	ref, err = name.ParseReference(host + "/exporter:1.0")
+	require.NoError(t, err)
+	require.NoError(t, remote.Write(ref, image, remote.WithAuthFromKeychain(keychain)))
+	digest, err := image.Digest()
+	require.NoError(t, err)
+
+	resolver := NewRegistryImageResolver().(ImagePlatformResolver)
+
+	platforms, err := resolver.Platforms(context.Background(), host+"/elasticsearch:8.6.2", keychain)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"linux/amd64", "linux/arm64"}, platforms)
+
+	// single platform images are requested by digest once pinned.
+	platforms, err = resolver.Platforms(context.Background(), host+"/exporter:1.0@"+digest.String(), keychain)
+	require.NoError(t, err)
+	assert.Nil(t, platforms)
+}
+
+type staticImageResolver map[string]string
+
+func (r staticImageResolver) Resolve(_ context.Context, image string, _ authn.Keychain) (string, error) {
+	return r[image], nil
+}
+
+func TestPinImageDigests(t *testing.T) {
+	spec := &v1.PodSpec{
+		InitContainers: []v1.Container{
+			{Name: "init", Image: "busybox"},
+		},
+		Containers: []v1.Container{
+			{Name: "elasticsearch", Image: "elasticsearch:8.6.2"},
+		},
+	}
+
+	err := pinImageDigests(context.Background(), staticImageResolver{
+		"busybox":             "busybox@sha256:a",
+		"elasticsearch:8.6.2": "elasticsearch:8.6.2@sha256:b",
+	}, pullSecretsKeychain{}, spec)
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{
+		"init":          "busybox@sha256:a",
+		"elasticsearch": "elasticsearch:8.6.2@sha256:b",
+	}, pinnedImages(spec))
+}
+
+func TestReusePinnedImages(t *testing.T) {
+	previous := &v1.PodSpec{
+		InitContainers: []v1.Container{{Name: "init", Image: "busybox@sha256:a"}},
+		Containers:     []v1.Container{{Name: "elasticsearch", Image: "elasticsearch:8.6.2@sha256:b"}},
+	}
+
+	spec := &v1.PodSpec{
+		InitContainers: []v1.Container{{Name: "init", Image: "busybox"}},
+		Containers:     []v1.Container{{Name: "elasticsearch", Image: "elasticsearch:8.6.2"}},
+	}
+	require.True(t, reusePinnedImages(spec, previous))
+	assert.Equal(t, pinnedImages(previous), pinnedImages(spec))
+
+	// a changed image isn't pinned and the spec is left unchanged.
+	spec = &v1.PodSpec{
+		InitContainers: []v1.Container{{Name: "init", Image: "busybox"}},
+		Containers:     []v1.Container{{Name: "elasticsearch", Image: "elasticsearch:8.7.0"}},
+	}
+	require.False(t, reusePinnedImages(spec, previous))
+	assert.Empty(t, pinnedImages(spec))
+}
+
+func TestReconcileStatefulSetKeepsPinnedImages(t *testing.T) {
+	resolver := staticImageResolver{"elasticsearch:8.6.2": "elasticsearch:8.6.2@sha256:a"}
+	template := func(env ...v1.EnvVar) *v1.PodTemplateSpec {
+		return &v1.PodTemplateSpec{
+			Spec: v1.PodSpec{Containers: []v1.Container{{Name: "elasticsearch", Image: "elasticsearch:8.6.2", Env: env}}},
+		}
+	}
+	o := &Operator{
+		kube:          &clientset.Clientset{Interface: fake.NewSimpleClientset()},
+		recorder:      record.NewFakeRecorder(10),
+		logger:        log.WithField("test", t.Name()),
+		imageResolver: resolver,
+	}
+	sr := &mockResource{
+		apiVersion:      "zalando.org/v1",
+		kind:            "ElasticsearchDataSet",
+		name:            "es-data",
+		namespace:       "default",
+		generation:      1,
+		replicas:        3,
+		podTemplateSpec: template(),
+		eds:             &zv1.ElasticsearchDataSet{},
+	}
+
+	sts, err := o.reconcileStatefulset(context.Background(), sr)
+	require.NoError(t, err)
+	require.Equal(t, "elasticsearch:8.6.2@sha256:a", sts.Spec.Template.Spec.Containers[0].Image)
+
+	// the tag is moved, but scaling keeps the digest of the pod template.
+	resolver["elasticsearch:8.6.2"] = "elasticsearch:8.6.2@sha256:b"
+	sr.generation = 2
+	sr.replicas = 4
+	sr.podTemplateSpec = template()
+	sts, err = o.reconcileStatefulset(context.Background(), sr)
+	require.NoError(t, err)
+	require.Equal(t, "elasticsearch:8.6.2@sha256:a", sts.Spec.Template.Spec.Containers[0].Image)
+
+	// changes of the pod template resolve the tag again.
+	sr.generation = 3
+	sr.podTemplateSpec = template(v1.EnvVar{Name: "ES_JAVA_OPTS", Value: "-Xms1g"})
+	sts, err = o.reconcileStatefulset(context.Background(), sr)
+	require.NoError(t, err)
+	require.Equal(t, "elasticsearch:8.6.2@sha256:b", sts.Spec.Template.Spec.Containers[0].Image)
+}
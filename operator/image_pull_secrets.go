@@ -0,0 +1,133 @@
+package operator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// pullSecretsKeychain holds the registry credentials of image pull secrets
+// keyed by registry.
+type pullSecretsKeychain map[string]authn.AuthConfig
+
+// Resolve returns the credentials for the registry of the resource, or
+// anonymous access if there are none.
+func (k pullSecretsKeychain) Resolve(target authn.Resource) (authn.Authenticator, error) {
+	config, ok := k[target.RegistryStr()]
+	if !ok {
+		return authn.Anonymous, nil
+	}
+	return authn.FromConfig(config), nil
+}
+
+// imagePullKeychain returns the keychain used to pull the images of the pod
+// spec in the namespace. Like the kubelet, it uses the image pull secrets of
+// the pod and its service account, missing secrets are ignored. The docker
+// config of the operator is used for registries without a pull secret.
+func imagePullKeychain(ctx context.Context, kube kubernetes.Interface, namespace string, spec *v1.PodSpec) (authn.Keychain, error) {
+	secretNames := make([]string, 0, len(spec.ImagePullSecrets))
+	for _, secret := range spec.ImagePullSecrets {
+		secretNames = append(secretNames, secret.Name)
+	}
+
+	serviceAccountName := spec.ServiceAccountName
+	if serviceAccountName == "" {
+		serviceAccountName = "default"
+	}
+	serviceAccount, err := kube.CoreV1().ServiceAccounts(namespace).Get(ctx, serviceAccountName, metav1.GetOptions{})
+	switch {
+	case err == nil:
+		for _, secret := range serviceAccount.ImagePullSecrets {
+			secretNames = append(secretNames, secret.Name)
+		}
+	case !apierrors.IsNotFound(err):
+		return nil, fmt.Errorf("failed to get service account %s/%s: %v", namespace, serviceAccountName, err)
+	}
+
+	keychain := pullSecretsKeychain{}
+	for _, secretName := range secretNames {
+		secret, err := kube.CoreV1().Secrets(namespace).Get(ctx, secretName, metav1.GetOptions{})
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to get image pull secret %s/%s: %v", namespace, secretName, err)
+		}
+
+		auths, err := parseDockerConfigSecret(secret)
+		if err != nil {
+			return nil, fmt.Errorf("invalid image pull secret %s/%s: %v", namespace, secretName, err)
+		}
+		for registry, config := range auths {
+			// the first secret wins like for the kubelet.
+			if _, ok := keychain[registry]; !ok {
+				keychain[registry] = config
+			}
+		}
+	}
+	return authn.NewMultiKeychain(keychain, authn.DefaultKeychain), nil
+}
+
+// parseDockerConfigSecret returns the credentials of a secret of type
+// kubernetes.io/dockerconfigjson or kubernetes.io/dockercfg keyed by
+// registry. Secrets of other types have no credentials.
+func parseDockerConfigSecret(secret *v1.Secret) (map[string]authn.AuthConfig, error) {
+	var auths map[string]authn.AuthConfig
+	switch secret.Type {
+	case v1.SecretTypeDockerConfigJson:
+		var config struct {
+			Auths map[string]authn.AuthConfig `json:"auths"`
+		}
+		err := json.Unmarshal(secret.Data[v1.DockerConfigJsonKey], &config)
+		if err != nil {
+			return nil, err
+		}
+		auths = config.Auths
+	case v1.SecretTypeDockercfg:
+		err := json.Unmarshal(secret.Data[v1.DockerConfigKey], &auths)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, nil
+	}
+
+	configs := make(map[string]authn.AuthConfig, len(auths))
+	for server, config := range auths {
+		registry, err := dockerConfigRegistry(server)
+		if err != nil {
+			return nil, err
+		}
+		configs[registry] = config
+	}
+	return configs, nil
+}
+
+// dockerConfigRegistry returns the registry of a server in a docker config,
+// which may be a URL like 'https://index.docker.io/v1/'.
+func dockerConfigRegistry(server string) (string, error) {
+	host := server
+	if strings.Contains(server, "://") {
+		u, err := url.Parse(server)
+		if err != nil {
+			return "", fmt.Errorf("invalid registry '%s': %v", server, err)
+		}
+		host = u.Host
+	}
+	host, _, _ = strings.Cut(host, "/")
+
+	registry, err := name.NewRegistry(host)
+	if err != nil {
+		return "", fmt.Errorf("invalid registry '%s': %v", server, err)
+	}
+	return registry.RegistryStr(), nil
+}
@@ -0,0 +1,64 @@
+package operator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestImagePullKeychain(t *testing.T) {
+	kube := fake.NewSimpleClientset(
+		&v1.ServiceAccount{
+			ObjectMeta:       metav1.ObjectMeta{Name: "default", Namespace: "default"},
+			ImagePullSecrets: []v1.LocalObjectReference{{Name: "service-account"}},
+		},
+		&v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"},
+			Type:       v1.SecretTypeDockerConfigJson,
+			Data: map[string][]byte{
+				v1.DockerConfigJsonKey: []byte(`{"auths":{"registry.internal":{"username":"pod","password":"a"},"https://index.docker.io/v1/":{"auth":"aHViOmI="}}}`),
+			},
+		},
+		&v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "service-account", Namespace: "default"},
+			Type:       v1.SecretTypeDockercfg,
+			Data: map[string][]byte{
+				v1.DockerConfigKey: []byte(`{"registry.internal":{"username":"service-account","password":"c"},"quay.io":{"username":"quay","password":"d"}}`),
+			},
+		},
+	)
+	spec := &v1.PodSpec{ImagePullSecrets: []v1.LocalObjectReference{{Name: "pod"}, {Name: "missing"}}}
+
+	keychain, err := imagePullKeychain(context.Background(), kube, "default", spec)
+	require.NoError(t, err)
+
+	credentials := func(image string) *authn.AuthConfig {
+		ref, err := name.ParseReference(image)
+		require.NoError(t, err)
+		authenticator, err := keychain.Resolve(ref.Context())
+		require.NoError(t, err)
+		config, err := authenticator.Authorization()
+		require.NoError(t, err)
+		return config
+	}
+
+	// the secrets of the pod come first.
+	assert.Equal(t, "pod", credentials("registry.internal/es:8.6.2").Username)
+	assert.Equal(t, "quay", credentials("quay.io/es:8.6.2").Username)
+	hub := credentials("elasticsearch:8.6.2")
+	assert.Equal(t, "hub", hub.Username)
+	assert.Equal(t, "b", hub.Password)
+	assert.Equal(t, &authn.AuthConfig{}, credentials("other.internal/es:8.6.2"))
+
+	// a pod without secrets and service account pulls anonymously.
+	keychain, err = imagePullKeychain(context.Background(), fake.NewSimpleClientset(), "default", &v1.PodSpec{})
+	require.NoError(t, err)
+	assert.Equal(t, &authn.AuthConfig{}, credentials("registry.internal/es:8.6.2"))
+}
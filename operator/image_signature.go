@@ -0,0 +1,179 @@
+package operator
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	containerregistryv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+const (
+	cosignSignatureAnnotationKey = "dev.cosignproject.cosign/signature"
+	cosignSignatureMediaType     = "application/vnd.dev.cosign.simplesigning.v1+json"
+)
+
+// cosignImageResolver resolves image tags to digests like the
+// registryImageResolver and additionally verifies that the resolved digest
+// is signed with cosign by the owner of the public key. The signatures are
+// looked up by the 'sha256-<digest>.sig' tag cosign stores them at, with the
+// same registry credentials as the image.
+type cosignImageResolver struct {
+	*registryImageResolver
+	key *ecdsa.PublicKey
+}
+
+// NewCosignImageResolver returns an ImageResolver which queries the image
+// registries directly and only accepts images signed with the ECDSA public
+// key in the PEM encoded key file, as generated by 'cosign generate-key-pair'.
+func NewCosignImageResolver(keyFile string) (ImageResolver, error) {
+	data, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := parseCosignPublicKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key %s: %v", keyFile, err)
+	}
+
+	return &cosignImageResolver{
+		registryImageResolver: NewRegistryImageResolver().(*registryImageResolver),
+		key:                   key,
+	}, nil
+}
+
+// parseCosignPublicKey parses a PEM encoded ECDSA public key.
+func parseCosignPublicKey(data []byte) (*ecdsa.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block found")
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	ecdsaKey, ok := key.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("unsupported key type %T, only ECDSA keys are supported", key)
+	}
+	return ecdsaKey, nil
+}
+
+// Resolve returns the image pinned to the digest the tag currently points to
+// if the digest is signed. Images which are already pinned are only
+// verified.
+func (r *cosignImageResolver) Resolve(ctx context.Context, image string, keychain authn.Keychain) (string, error) {
+	pinned, err := r.registryImageResolver.Resolve(ctx, image, keychain)
+	if err != nil {
+		return "", err
+	}
+
+	err = r.verify(ctx, pinned, keychain)
+	if err != nil {
+		return "", fmt.Errorf("failed to verify signature of '%s': %v", pinned, err)
+	}
+	return pinned, nil
+}
+
+// verify verifies that one of the cosign signatures of the digest pinned
+// image is valid for the public key and signs the digest.
+func (r *cosignImageResolver) verify(ctx context.Context, image string, keychain authn.Keychain) error {
+	ref, err := name.NewDigest(image)
+	if err != nil {
+		return err
+	}
+
+	algorithm, digest, found := strings.Cut(ref.DigestStr(), ":")
+	if !found || algorithm != "sha256" {
+		return fmt.Errorf("unsupported digest '%s'", ref.DigestStr())
+	}
+
+	signatures, err := remote.Image(ref.Context().Tag(fmt.Sprintf("sha256-%s.sig", digest)), remoteOptions(ctx, keychain)...)
+	if err != nil {
+		return fmt.Errorf("no signatures found: %v", err)
+	}
+	manifest, err := signatures.Manifest()
+	if err != nil {
+		return fmt.Errorf("failed to parse signature manifest: %v", err)
+	}
+
+	for _, layer := range manifest.Layers {
+		signature, ok := layer.Annotations[cosignSignatureAnnotationKey]
+		if !ok || string(layer.MediaType) != cosignSignatureMediaType {
+			continue
+		}
+
+		payload, err := signaturePayload(signatures, layer.Digest)
+		if err != nil {
+			return fmt.Errorf("failed to get signature payload: %v", err)
+		}
+
+		if verifyCosignSignature(r.key, payload, layer.Digest.String(), signature, ref.DigestStr()) == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("no valid signature found")
+}
+
+// signaturePayload returns the payload of the signature layer.
+func signaturePayload(signatures containerregistryv1.Image, digest containerregistryv1.Hash) ([]byte, error) {
+	layer, err := signatures.LayerByDigest(digest)
+	if err != nil {
+		return nil, err
+	}
+	blob, err := layer.Compressed()
+	if err != nil {
+		return nil, err
+	}
+	defer blob.Close()
+	return io.ReadAll(blob)
+}
+
+// verifyCosignSignature verifies that the base64 encoded signature of the
+// simple signing payload is valid for the key, and that the payload is the
+// layer with the digest and signs the image digest.
+func verifyCosignSignature(key *ecdsa.PublicKey, payload []byte, payloadDigest, signature, imageDigest string) error {
+	sum := sha256.Sum256(payload)
+	if "sha256:"+hex.EncodeToString(sum[:]) != payloadDigest {
+		return fmt.Errorf("payload doesn't match digest %s", payloadDigest)
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("invalid signature encoding: %v", err)
+	}
+	if !ecdsa.VerifyASN1(key, sum[:], sig) {
+		return fmt.Errorf("invalid signature")
+	}
+
+	var simpleSigning struct {
+		Critical struct {
+			Image struct {
+				DockerManifestDigest string `json:"docker-manifest-digest"`
+			} `json:"image"`
+		} `json:"critical"`
+	}
+	err = json.Unmarshal(payload, &simpleSigning)
+	if err != nil {
+		return fmt.Errorf("failed to parse signature payload: %v", err)
+	}
+	if simpleSigning.Critical.Image.DockerManifestDigest != imageDigest {
+		return fmt.Errorf("signature is for digest %s", simpleSigning.Critical.Image.DockerManifestDigest)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package operator
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	containerregistryv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCosignImageResolver(t *testing.T) {
+	host := newTestRegistry(t, "user", "secret")
+	keychain := pullSecretsKeychain{host: {Username: "user", Password: "secret"}}
+	push := func(tag string, image containerregistryv1.Image) string {
+		ref, err := name.ParseReference(host + "/es:" + tag)
+		require.NoError(t, err)
+		require.NoError(t, remote.Write(ref, image, remote.WithAuthFromKeychain(keychain)))
+		digest, err := image.Digest()
+		require.NoError(t, err)
+		return digest.String()
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	publicKey, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	keyFile := filepath.Join(t.TempDir(), "cosign.pub")
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKey}), 0o600))
+
+	signedImage, err := random.Image(100, 1)
+	require.NoError(t, err)
+	signed := push("signed", signedImage)
+	unsignedImage, err := random.Image(100, 1)
+	require.NoError(t, err)
+	unsigned := push("unsigned", unsignedImage)
+	push("forged", unsignedImage)
+
+	payload := []byte(fmt.Sprintf(`{"critical":{"identity":{"docker-reference":"%s/es"},"image":{"docker-manifest-digest":"%s"},"type":"cosign container image signature"},"optional":null}`, host, signed))
+	sum := sha256.Sum256(payload)
+	signature, err := ecdsa.SignASN1(rand.Reader, key, sum[:])
+	require.NoError(t, err)
+	signatures, err := mutate.Append(empty.Image, mutate.Addendum{
+		Layer:       static.NewLayer(payload, types.MediaType(cosignSignatureMediaType)),
+		Annotations: map[string]string{cosignSignatureAnnotationKey: base64.StdEncoding.EncodeToString(signature)},
+	})
+	require.NoError(t, err)
+	push(fmt.Sprintf("sha256-%s.sig", signed[7:]), signatures)
+
+	resolver, err := NewCosignImageResolver(keyFile)
+	require.NoError(t, err)
+
+	image, err := resolver.Resolve(context.Background(), host+"/es:signed", keychain)
+	require.NoError(t, err)
+	assert.Equal(t, host+"/es:signed@"+signed, image)
+
+	// already pinned images are verified as well.
+	_, err = resolver.Resolve(context.Background(), host+"/es@"+unsigned, keychain)
+	assert.ErrorContains(t, err, "no signatures found")
+
+	_, err = resolver.Resolve(context.Background(), host+"/es:unsigned", keychain)
+	assert.ErrorContains(t, err, "no signatures found")
+
+	// the signature of another digest isn't accepted.
+	push(fmt.Sprintf("sha256-%s.sig", unsigned[7:]), signatures)
+	_, err = resolver.Resolve(context.Background(), host+"/es:forged", keychain)
+	assert.ErrorContains(t, err, "no valid signature found")
+
+	_, ok := resolver.(ImagePlatformResolver)
+	assert.True(t, ok)
+}
+
+func TestVerifyCosignSignature(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	payload := []byte(`{"critical":{"image":{"docker-manifest-digest":"sha256:a"}}}`)
+	sum := sha256.Sum256(payload)
+	payloadDigest := "sha256:" + hex.EncodeToString(sum[:])
+	sig, err := ecdsa.SignASN1(rand.Reader, key, sum[:])
+	require.NoError(t, err)
+	signature := base64.StdEncoding.EncodeToString(sig)
+
+	require.NoError(t, verifyCosignSignature(&key.PublicKey, payload, payloadDigest, signature, "sha256:a"))
+	require.Error(t, verifyCosignSignature(&otherKey.PublicKey, payload, payloadDigest, signature, "sha256:a"))
+	require.Error(t, verifyCosignSignature(&key.PublicKey, payload, "sha256:b", signature, "sha256:a"))
+	require.Error(t, verifyCosignSignature(&key.PublicKey, payload, payloadDigest, signature, "sha256:b"))
+}
@@ -0,0 +1,63 @@
+package operator
+
+import (
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// ImageRewriteRules maps image prefixes (registry or registry/repository) to
+// the prefix they should be replaced with. It's used to redirect images
+// referenced by EDS pod templates to an internal mirror, e.g. in air-gapped
+// environments, without having to edit every EDS.
+type ImageRewriteRules map[string]string
+
+// Rewrite returns the image with the longest matching prefix replaced. If no
+// rule matches, the image is returned unchanged.
+func (r ImageRewriteRules) Rewrite(image string) string {
+	longest := ""
+	for from := range r {
+		if len(from) > len(longest) && hasImagePrefix(image, from) {
+			longest = from
+		}
+	}
+	if longest == "" {
+		return image
+	}
+	return r[longest] + strings.TrimPrefix(image, longest)
+}
+
+// rewritePodSpec rewrites the images of all containers and init containers
+// in the pod spec.
+func (r ImageRewriteRules) rewritePodSpec(spec *v1.PodSpec) {
+	if len(r) == 0 {
+		return
+	}
+
+	for i := range spec.InitContainers {
+		spec.InitContainers[i].Image = r.Rewrite(spec.InitContainers[i].Image)
+	}
+	for i := range spec.Containers {
+		spec.Containers[i].Image = r.Rewrite(spec.Containers[i].Image)
+	}
+}
+
+// hasImagePrefix returns true if the image starts with the prefix on a path
+// component boundary, such that 'docker.elastic.co' matches
+// 'docker.elastic.co/elasticsearch/elasticsearch:8.6.2' but not
+// 'docker.elastic.company/elasticsearch'.
+func hasImagePrefix(image, prefix string) bool {
+	if prefix == "" || !strings.HasPrefix(image, prefix) {
+		return false
+	}
+
+	if len(image) == len(prefix) || strings.HasSuffix(prefix, "/") {
+		return true
+	}
+
+	switch image[len(prefix)] {
+	case '/', ':', '@':
+		return true
+	}
+	return false
+}
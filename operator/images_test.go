@@ -0,0 +1,73 @@
+package operator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestImageRewrite(t *testing.T) {
+	rules := ImageRewriteRules{
+		"docker.elastic.co":               "registry.internal/elastic",
+		"docker.elastic.co/elasticsearch": "registry.internal/es",
+		"busybox":                         "registry.internal/library/busybox",
+	}
+
+	for _, tc := range []struct {
+		image    string
+		expected string
+	}{
+		{
+			image:    "docker.elastic.co/elasticsearch/elasticsearch:8.6.2",
+			expected: "registry.internal/es/elasticsearch:8.6.2",
+		},
+		{
+			image:    "docker.elastic.co/kibana/kibana:8.6.2",
+			expected: "registry.internal/elastic/kibana/kibana:8.6.2",
+		},
+		{
+			image:    "docker.elastic.company/elasticsearch:8.6.2",
+			expected: "docker.elastic.company/elasticsearch:8.6.2",
+		},
+		{
+			image:    "busybox:1.36",
+			expected: "registry.internal/library/busybox:1.36",
+		},
+		{
+			image:    "busybox",
+			expected: "registry.internal/library/busybox",
+		},
+		{
+			image:    "alexeiled/stress-ng",
+			expected: "alexeiled/stress-ng",
+		},
+	} {
+		t.Run(tc.image, func(t *testing.T) {
+			assert.Equal(t, tc.expected, rules.Rewrite(tc.image))
+		})
+	}
+}
+
+func TestImageRewritePodSpec(t *testing.T) {
+	spec := &v1.PodSpec{
+		InitContainers: []v1.Container{
+			{Name: "init", Image: "busybox:1.36"},
+		},
+		Containers: []v1.Container{
+			{Name: "elasticsearch", Image: "docker.elastic.co/elasticsearch/elasticsearch:8.6.2"},
+		},
+	}
+
+	ImageRewriteRules{
+		"docker.elastic.co": "registry.internal/elastic",
+		"busybox":           "registry.internal/busybox",
+	}.rewritePodSpec(spec)
+
+	assert.Equal(t, "registry.internal/busybox:1.36", spec.InitContainers[0].Image)
+	assert.Equal(t, "registry.internal/elastic/elasticsearch/elasticsearch:8.6.2", spec.Containers[0].Image)
+
+	// no rules is a no-op
+	ImageRewriteRules(nil).rewritePodSpec(spec)
+	assert.Equal(t, "registry.internal/busybox:1.36", spec.InitContainers[0].Image)
+}
@@ -0,0 +1,102 @@
+package operator
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const indicesAllocatedConditionType = zv1.ConditionIndicesAllocated
+
+// misallocatedIndices returns the indices which aren't allocated to the
+// group, sorted by name.
+func misallocatedIndices(allocation map[string]string, group string) []string {
+	indices := make([]string, 0)
+	for index, allocated := range allocation {
+		if allocated != group {
+			indices = append(indices, index)
+		}
+	}
+	sort.Strings(indices)
+	return indices
+}
+
+// allocateIndices allocates the indices matching the index patterns of the
+// spec to the nodes of the EDS once its first pod is ready. The allocation is
+// done once per set of patterns, so indices moved to other groups later on,
+// e.g. by rebalancing, aren't moved back. It returns the allocated patterns
+// and sets the IndicesAllocated condition.
+func (r *EDSResource) allocateIndices(sts *appsv1.StatefulSet, conditions *[]metav1.Condition) []string {
+	patterns := r.eds.Spec.Indices
+	if len(patterns) == 0 {
+		meta.RemoveStatusCondition(conditions, indicesAllocatedConditionType)
+		return nil
+	}
+
+	allocated := r.eds.Status.AllocatedIndices
+	condition := metav1.Condition{
+		Type:               indicesAllocatedConditionType,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: r.eds.Generation,
+	}
+	setCondition := func(status metav1.ConditionStatus, reason, message string) {
+		condition.Status, condition.Reason, condition.Message = status, reason, message
+		meta.SetStatusCondition(conditions, condition)
+	}
+
+	if reflect.DeepEqual(allocated, patterns) && meta.IsStatusConditionTrue(*conditions, indicesAllocatedConditionType) {
+		return allocated
+	}
+
+	group := r.eds.Labels[groupLabelKey]
+	if group == "" {
+		setCondition(metav1.ConditionFalse, zv1.ReasonNoGroup, fmt.Sprintf("The EDS has no '%s' label.", groupLabelKey))
+		return allocated
+	}
+	if sts.Status.ReadyReplicas == 0 || r.esClient == nil {
+		setCondition(metav1.ConditionFalse, zv1.ReasonWaitingForPods, "Waiting for the first Pod to be ready.")
+		return allocated
+	}
+
+	if !reflect.DeepEqual(allocated, patterns) {
+		allocation, err := r.esClient.GetIndexAllocationGroups(patterns...)
+		if err != nil {
+			setCondition(metav1.ConditionFalse, zv1.ReasonAllocationFailed, fmt.Sprintf("Failed to get the allocation of the indices: %v", err))
+			return allocated
+		}
+
+		indices := misallocatedIndices(allocation, group)
+		for _, index := range indices {
+			err := r.esClient.SetIndexAllocationGroup(index, group)
+			if err != nil {
+				setCondition(metav1.ConditionFalse, zv1.ReasonAllocationFailed, fmt.Sprintf("Failed to allocate index %s: %v", index, err))
+				return allocated
+			}
+		}
+		if len(indices) > 0 {
+			r.recorder.Event(r.eds, v1.EventTypeNormal, "AllocatedIndices",
+				fmt.Sprintf("Allocated indices %s to group %s", strings.Join(indices, ","), group))
+		}
+		allocated = append([]string(nil), patterns...)
+	}
+
+	health, err := r.esClient.ClusterHealth()
+	if err != nil {
+		setCondition(metav1.ConditionFalse, zv1.ReasonRelocating, fmt.Sprintf("Failed to get the cluster health: %v", err))
+		return allocated
+	}
+	if health.RelocatingShards > 0 {
+		setCondition(metav1.ConditionFalse, zv1.ReasonRelocating, fmt.Sprintf("%d shards are relocating.", health.RelocatingShards))
+		return allocated
+	}
+
+	setCondition(metav1.ConditionTrue, zv1.ReasonAllocated, fmt.Sprintf("Indices %s are allocated to group %s.", strings.Join(patterns, ","), group))
+	return allocated
+}
@@ -0,0 +1,91 @@
+package operator
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestMisallocatedIndices(t *testing.T) {
+	require.Equal(t, []string{"logs-2", "logs-3"}, misallocatedIndices(map[string]string{
+		"logs-3": "",
+		"logs-1": "a",
+		"logs-2": "b",
+	}, "a"))
+	require.Empty(t, misallocatedIndices(map[string]string{"logs-1": "a"}, "a"))
+}
+
+func TestAllocateIndices(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	relocating := `{"status":"green","relocating_shards":3}`
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/logs-*,metrics-*/_settings/index.routing.allocation.include.group",
+		httpmock.NewStringResponder(200, `{"logs-1":{"settings":{"index.routing.allocation.include.group":"a"}},"metrics-1":{"settings":{}}}`))
+	httpmock.RegisterResponder("PUT", "http://elasticsearch:9200/metrics-1/_settings",
+		httpmock.NewStringResponder(200, `{"acknowledged":true}`))
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_cluster/health",
+		func(req *http.Request) (*http.Response, error) {
+			return httpmock.NewStringResponse(200, relocating), nil
+		})
+
+	esURL, _ := url.Parse("http://elasticsearch:9200")
+	eds := &zv1.ElasticsearchDataSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "logs",
+			Namespace:  "default",
+			Generation: 2,
+		},
+		Spec: zv1.ElasticsearchDataSetSpec{
+			Indices: []string{"logs-*", "metrics-*"},
+		},
+	}
+	r := &EDSResource{
+		eds:      eds,
+		esClient: &ESClient{Endpoint: esURL},
+		recorder: record.NewFakeRecorder(10),
+	}
+	sts := &appsv1.StatefulSet{}
+	var conditions []metav1.Condition
+
+	// no group label
+	require.Nil(t, r.allocateIndices(sts, &conditions))
+	require.Equal(t, "NoGroup", meta.FindStatusCondition(conditions, indicesAllocatedConditionType).Reason)
+
+	// no ready pods
+	eds.Labels = map[string]string{groupLabelKey: "a"}
+	require.Nil(t, r.allocateIndices(sts, &conditions))
+	require.Equal(t, "WaitingForPods", meta.FindStatusCondition(conditions, indicesAllocatedConditionType).Reason)
+
+	// allocated, waiting for the relocation
+	sts.Status.ReadyReplicas = 1
+	allocated := r.allocateIndices(sts, &conditions)
+	require.Equal(t, []string{"logs-*", "metrics-*"}, allocated)
+	require.Equal(t, "Relocating", meta.FindStatusCondition(conditions, indicesAllocatedConditionType).Reason)
+	require.Equal(t, 1, httpmock.GetCallCountInfo()["PUT http://elasticsearch:9200/metrics-1/_settings"])
+
+	// relocated
+	eds.Status.AllocatedIndices = allocated
+	relocating = `{"status":"green","relocating_shards":0}`
+	require.Equal(t, allocated, r.allocateIndices(sts, &conditions))
+	require.True(t, meta.IsStatusConditionTrue(conditions, indicesAllocatedConditionType))
+	require.Equal(t, 1, httpmock.GetCallCountInfo()["PUT http://elasticsearch:9200/metrics-1/_settings"])
+
+	// done, indices aren't allocated again
+	httpmock.Reset()
+	require.Equal(t, allocated, r.allocateIndices(sts, &conditions))
+	require.Equal(t, 0, httpmock.GetTotalCallCount())
+
+	// without index patterns
+	eds.Spec.Indices = nil
+	require.Nil(t, r.allocateIndices(sts, &conditions))
+	require.Empty(t, conditions)
+}
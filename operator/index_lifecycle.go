@@ -0,0 +1,91 @@
+package operator
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+// lifecycleShrinkAction is the action of ILM and ISM policies allocating
+// all shards of an index to a single node before shrinking it.
+const lifecycleShrinkAction = "shrink"
+
+// GetShrinkingIndices returns the indices whose lifecycle policy is in the
+// shrink action. The policies are managed by ILM on Elasticsearch and by
+// ISM on OpenSearch.
+func (c *ESClient) GetShrinkingIndices() ([]string, error) {
+	openSearch, err := c.isOpenSearch()
+	if err != nil {
+		return nil, err
+	}
+	if openSearch {
+		return c.getISMShrinkingIndices()
+	}
+	return c.getILMShrinkingIndices()
+}
+
+func (c *ESClient) getILMShrinkingIndices() ([]string, error) {
+	resp, err := c.httpClient().R().
+		Get(c.Endpoint.String() + "/_all/_ilm/explain?only_managed=true&filter_path=indices.*.action")
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, fmt.Errorf("code status %d - %s", resp.StatusCode(), resp.Body())
+	}
+
+	var explain struct {
+		Indices map[string]struct {
+			Action string `json:"action"`
+		} `json:"indices"`
+	}
+	err = json.Unmarshal(resp.Body(), &explain)
+	if err != nil {
+		return nil, err
+	}
+
+	indices := make([]string, 0)
+	for index, state := range explain.Indices {
+		if state.Action == lifecycleShrinkAction {
+			indices = append(indices, index)
+		}
+	}
+	sort.Strings(indices)
+	return indices, nil
+}
+
+func (c *ESClient) getISMShrinkingIndices() ([]string, error) {
+	resp, err := c.httpClient().R().
+		Get(c.Endpoint.String() + "/_plugins/_ism/explain/*")
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, fmt.Errorf("code status %d - %s", resp.StatusCode(), resp.Body())
+	}
+
+	// the indices are top level keys next to total_managed_indices.
+	var explain map[string]json.RawMessage
+	err = json.Unmarshal(resp.Body(), &explain)
+	if err != nil {
+		return nil, err
+	}
+
+	indices := make([]string, 0)
+	for index, raw := range explain {
+		var state struct {
+			Action *struct {
+				Name string `json:"name"`
+			} `json:"action"`
+		}
+		if json.Unmarshal(raw, &state) != nil {
+			continue
+		}
+		if state.Action != nil && state.Action.Name == lifecycleShrinkAction {
+			indices = append(indices, index)
+		}
+	}
+	sort.Strings(indices)
+	return indices, nil
+}
@@ -0,0 +1,44 @@
+package operator
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetShrinkingIndices(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_all/_ilm/explain",
+		httpmock.NewStringResponder(200, `{"indices":{"logs-2":{"action":"shrink"},"logs-1":{"action":"shrink"},"logs-3":{"action":"rollover"}}}`))
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_plugins/_ism/explain/*",
+		httpmock.NewStringResponder(200, `{
+			"logs-1":{"index.plugins.index_state_management.policy_id":"hot-warm","action":{"name":"shrink"}},
+			"logs-2":{"index.plugins.index_state_management.policy_id":"hot-warm","action":{"name":"rollover"}},
+			"logs-3":{"index.plugins.index_state_management.policy_id":null},
+			"total_managed_indices":2
+		}`))
+
+	esURL, _ := url.Parse("http://elasticsearch:9200")
+
+	// the distribution is detected from the version.
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/",
+		httpmock.NewStringResponder(200, `{"version":{"number":"8.6.2"}}`))
+	indices, err := (&ESClient{Endpoint: esURL}).GetShrinkingIndices()
+	require.NoError(t, err)
+	require.Equal(t, []string{"logs-1", "logs-2"}, indices)
+
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/",
+		httpmock.NewStringResponder(200, `{"version":{"distribution":"opensearch","number":"2.11.0"}}`))
+	indices, err = (&ESClient{Endpoint: esURL}).GetShrinkingIndices()
+	require.NoError(t, err)
+	require.Equal(t, []string{"logs-1"}, indices)
+
+	// the configured distribution takes precedence.
+	indices, err = (&ESClient{Endpoint: esURL, distribution: distributionElasticsearch}).GetShrinkingIndices()
+	require.NoError(t, err)
+	require.Equal(t, []string{"logs-1", "logs-2"}, indices)
+}
@@ -0,0 +1,263 @@
+package operator
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	ingestionPausedConditionType = zv1.ConditionIngestionPaused
+
+	// pausedPipelineMessage is the error of documents failed by the
+	// pipeline while ingestion is paused.
+	pausedPipelineMessage = "ingestion is paused by es-operator"
+
+	// pausedPipelineTag is the tag of the processor failing the documents.
+	pausedPipelineTag = "es-operator-ingestion-pause"
+
+	// originalPipelineSuffix is the suffix of the pipeline the original
+	// definition of a paused pipeline is kept in.
+	originalPipelineSuffix = "-es-operator-original"
+)
+
+// GetAliasWriteIndices returns the write indices of the alias in order of
+// their names. The only index of an alias without an explicit write index
+// is its write index.
+func (c *ESClient) GetAliasWriteIndices(alias string) ([]string, error) {
+	resp, err := c.httpClient().R().
+		Get(fmt.Sprintf("%s/_alias/%s", c.Endpoint.String(), url.PathEscape(alias)))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, fmt.Errorf("code status %d - %s", resp.StatusCode(), resp.Body())
+	}
+
+	var aliases map[string]struct {
+		Aliases map[string]struct {
+			IsWriteIndex *bool `json:"is_write_index"`
+		} `json:"aliases"`
+	}
+	err = json.Unmarshal(resp.Body(), &aliases)
+	if err != nil {
+		return nil, err
+	}
+
+	indices := make([]string, 0, len(aliases))
+	for index, a := range aliases {
+		isWriteIndex := a.Aliases[alias].IsWriteIndex
+		if (isWriteIndex == nil && len(aliases) == 1) || (isWriteIndex != nil && *isWriteIndex) {
+			indices = append(indices, index)
+		}
+	}
+	sort.Strings(indices)
+	return indices, nil
+}
+
+// getPipeline returns the definition of the ingest pipeline, or nil if it
+// doesn't exist.
+func (c *ESClient) getPipeline(pipeline string) (json.RawMessage, error) {
+	resp, err := c.httpClient().R().
+		Get(fmt.Sprintf("%s/_ingest/pipeline/%s", c.Endpoint.String(), url.PathEscape(pipeline)))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, fmt.Errorf("code status %d - %s", resp.StatusCode(), resp.Body())
+	}
+
+	var pipelines map[string]json.RawMessage
+	err = json.Unmarshal(resp.Body(), &pipelines)
+	if err != nil {
+		return nil, err
+	}
+	return pipelines[pipeline], nil
+}
+
+// putPipeline creates or replaces the ingest pipeline.
+func (c *ESClient) putPipeline(pipeline string, definition interface{}) error {
+	resp, err := c.httpClient().R().
+		SetHeader("Content-Type", "application/json").
+		SetBody(definition).
+		Put(fmt.Sprintf("%s/_ingest/pipeline/%s", c.Endpoint.String(), url.PathEscape(pipeline)))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return fmt.Errorf("code status %d - %s", resp.StatusCode(), resp.Body())
+	}
+	return nil
+}
+
+// deletePipeline deletes the ingest pipeline.
+func (c *ESClient) deletePipeline(pipeline string) error {
+	resp, err := c.httpClient().R().
+		Delete(fmt.Sprintf("%s/_ingest/pipeline/%s", c.Endpoint.String(), url.PathEscape(pipeline)))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode() != http.StatusOK && resp.StatusCode() != http.StatusNotFound {
+		return fmt.Errorf("code status %d - %s", resp.StatusCode(), resp.Body())
+	}
+	return nil
+}
+
+// SetPausePipeline replaces the ingest pipeline with one failing all
+// documents if paused, and restores it otherwise. The definition of the
+// pipeline is kept in the '<pipeline>-es-operator-original' pipeline while
+// paused, so it survives restarts of the operator. A pipeline which didn't
+// exist before is restored as one passing all documents.
+func (c *ESClient) SetPausePipeline(pipeline string, paused bool) error {
+	original := pipeline + originalPipelineSuffix
+	definition, err := c.getPipeline(original)
+	if err != nil {
+		return err
+	}
+
+	if !paused {
+		if definition != nil {
+			err = c.putPipeline(pipeline, definition)
+			if err != nil {
+				return err
+			}
+			return c.deletePipeline(original)
+		}
+
+		// without the original, only the pipeline of the operator is
+		// replaced.
+		definition, err = c.getPipeline(pipeline)
+		if err != nil {
+			return err
+		}
+		if !strings.Contains(string(definition), pausedPipelineTag) {
+			return nil
+		}
+		return c.putPipeline(pipeline, pausePipeline(false))
+	}
+
+	// the original is only saved once, it's the paused pipeline otherwise.
+	if definition == nil {
+		definition, err = c.getPipeline(pipeline)
+		if err != nil {
+			return err
+		}
+		if definition == nil || strings.Contains(string(definition), pausedPipelineTag) {
+			definition, err = json.Marshal(pausePipeline(false))
+			if err != nil {
+				return err
+			}
+		}
+		err = c.putPipeline(original, definition)
+		if err != nil {
+			return err
+		}
+	}
+	return c.putPipeline(pipeline, pausePipeline(true))
+}
+
+// pausePipeline returns the definition of the ingest pipeline failing all
+// documents if paused, and passing them otherwise.
+func pausePipeline(paused bool) map[string]interface{} {
+	processors := []interface{}{}
+	if paused {
+		processors = append(processors, map[string]interface{}{
+			"fail": map[string]string{"tag": pausedPipelineTag, "message": pausedPipelineMessage},
+		})
+	}
+	return map[string]interface{}{
+		"description": "Managed by es-operator to pause ingestion during decommissioning",
+		"processors":  processors,
+	}
+}
+
+// containsAnyIndex returns true if any of the write indices is one of the
+// indices.
+func containsAnyIndex(indices, writeIndices []string) bool {
+	for _, index := range writeIndices {
+		if slices.Contains(indices, index) {
+			return true
+		}
+	}
+	return false
+}
+
+// setIngestionPaused pauses or resumes the ingestion into the write indices
+// by toggling the pipeline, or the write block of the indices if no pipeline
+// is configured, and sets the IngestionPaused condition. The indices blocked
+// when pausing are recorded in blocked, only those are unblocked when
+// resuming, which leaves the blocks of others alone and also covers write
+// indices rolled over in the meantime.
+func (r *EDSResource) setIngestionPaused(config *zv1.ElasticsearchDataSetIngestionPause, writeIndices []string, paused bool, conditions *[]metav1.Condition, blocked *[]string) error {
+	if meta.IsStatusConditionTrue(*conditions, ingestionPausedConditionType) == paused {
+		return nil
+	}
+
+	var err error
+	switch {
+	case config.Pipeline != "":
+		err = r.esClient.SetPausePipeline(config.Pipeline, paused)
+	case paused && len(writeIndices) > 0:
+		*blocked, err = r.esClient.blockWrites(writeIndices)
+	case !paused && len(*blocked) > 0:
+		err = r.esClient.setWriteBlock(*blocked, false)
+		if err == nil {
+			*blocked = nil
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	condition := metav1.Condition{
+		Type:               ingestionPausedConditionType,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: r.eds.Generation,
+		Reason:             zv1.ReasonIngestionResumed,
+		Message:            fmt.Sprintf("Ingestion into alias %s is running.", config.WriteAlias),
+	}
+	reason, message := "ResumedIngestion", fmt.Sprintf("Resumed ingestion into alias %s", config.WriteAlias)
+	if paused {
+		condition.Status, condition.Reason = metav1.ConditionTrue, zv1.ReasonSwitchover
+		condition.Message = fmt.Sprintf("Ingestion into alias %s is paused while write indices %s are moved.", config.WriteAlias, strings.Join(writeIndices, ","))
+		reason, message = "PausedIngestion", fmt.Sprintf("Paused ingestion into alias %s while write indices %s are moved", config.WriteAlias, strings.Join(writeIndices, ","))
+	}
+	meta.SetStatusCondition(conditions, condition)
+	r.recorder.Event(r.eds, v1.EventTypeNormal, reason, message)
+	return nil
+}
+
+// blockWrites blocks writes to the indices which aren't blocked yet and
+// returns them.
+func (c *ESClient) blockWrites(indices []string) ([]string, error) {
+	blocked, err := c.writeBlockedIndices(indices)
+	if err != nil {
+		return nil, err
+	}
+	unblocked := make([]string, 0, len(indices))
+	for _, index := range indices {
+		if !blocked[index] {
+			unblocked = append(unblocked, index)
+		}
+	}
+	if len(unblocked) == 0 {
+		return nil, nil
+	}
+	err = c.setWriteBlock(unblocked, true)
+	if err != nil {
+		return nil, err
+	}
+	return unblocked, nil
+}
@@ -0,0 +1,128 @@
+package operator
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetAliasWriteIndices(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	esURL, _ := url.Parse("http://elasticsearch:9200")
+	client := &ESClient{Endpoint: esURL}
+
+	for _, tc := range []struct {
+		msg      string
+		body     string
+		expected []string
+	}{
+		{
+			msg:      "the only index is the implicit write index",
+			body:     `{"logs-1":{"aliases":{"logs":{}}}}`,
+			expected: []string{"logs-1"},
+		},
+		{
+			msg:      "several indices without explicit write index",
+			body:     `{"logs-1":{"aliases":{"logs":{}}},"logs-2":{"aliases":{"logs":{}}}}`,
+			expected: []string{},
+		},
+		{
+			msg:      "explicit write index",
+			body:     `{"logs-1":{"aliases":{"logs":{"is_write_index":false}}},"logs-2":{"aliases":{"logs":{"is_write_index":true}}},"logs-3":{"aliases":{"logs":{}}}}`,
+			expected: []string{"logs-2"},
+		},
+		{
+			msg:      "only index which isn't the write index",
+			body:     `{"logs-1":{"aliases":{"logs":{"is_write_index":false}}}}`,
+			expected: []string{},
+		},
+		{
+			msg:      "write indices of other aliases are ignored",
+			body:     `{"logs-2":{"aliases":{"logs":{"is_write_index":true},"other":{"is_write_index":false}}},"logs-1":{"aliases":{"logs":{"is_write_index":false},"other":{"is_write_index":true}}}}`,
+			expected: []string{"logs-2"},
+		},
+	} {
+		t.Run(tc.msg, func(t *testing.T) {
+			httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_alias/logs",
+				httpmock.NewStringResponder(200, tc.body))
+			indices, err := client.GetAliasWriteIndices("logs")
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, indices)
+		})
+	}
+
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_alias/logs",
+		httpmock.NewStringResponder(404, `{"error":"alias [logs] missing"}`))
+	_, err := client.GetAliasWriteIndices("logs")
+	require.Error(t, err)
+}
+
+func TestSetPausePipeline(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	// the pipelines stored in the cluster by name.
+	pipelines := map[string]string{
+		"logs": `{"description":"parse logs","processors":[{"json":{"field":"message"}}],"on_failure":[{"set":{"field":"error","value":"{{ _ingest.on_failure_message }}"}}]}`,
+	}
+	httpmock.RegisterResponder("GET", `=~^http://elasticsearch:9200/_ingest/pipeline/([^/]+)\z`,
+		func(req *http.Request) (*http.Response, error) {
+			name := httpmock.MustGetSubmatch(req, 1)
+			definition, ok := pipelines[name]
+			if !ok {
+				return httpmock.NewStringResponse(404, `{}`), nil
+			}
+			return httpmock.NewStringResponse(200, `{"`+name+`":`+definition+`}`), nil
+		})
+	httpmock.RegisterResponder("PUT", `=~^http://elasticsearch:9200/_ingest/pipeline/([^/]+)\z`,
+		func(req *http.Request) (*http.Response, error) {
+			body, err := io.ReadAll(req.Body)
+			if err != nil {
+				return nil, err
+			}
+			pipelines[httpmock.MustGetSubmatch(req, 1)] = string(body)
+			return httpmock.NewStringResponse(200, `{"acknowledged":true}`), nil
+		})
+	httpmock.RegisterResponder("DELETE", `=~^http://elasticsearch:9200/_ingest/pipeline/([^/]+)\z`,
+		func(req *http.Request) (*http.Response, error) {
+			delete(pipelines, httpmock.MustGetSubmatch(req, 1))
+			return httpmock.NewStringResponse(200, `{"acknowledged":true}`), nil
+		})
+
+	esURL, _ := url.Parse("http://elasticsearch:9200")
+	client := &ESClient{Endpoint: esURL}
+	original := pipelines["logs"]
+	paused := `{"description":"Managed by es-operator to pause ingestion during decommissioning","processors":[{"fail":{"message":"ingestion is paused by es-operator","tag":"es-operator-ingestion-pause"}}]}`
+	passing := `{"description":"Managed by es-operator to pause ingestion during decommissioning","processors":[]}`
+
+	// the configured pipeline is kept while paused.
+	require.NoError(t, client.SetPausePipeline("logs", true))
+	require.JSONEq(t, paused, pipelines["logs"])
+	require.JSONEq(t, original, pipelines["logs-es-operator-original"])
+
+	// pausing again doesn't overwrite the original.
+	require.NoError(t, client.SetPausePipeline("logs", true))
+	require.JSONEq(t, original, pipelines["logs-es-operator-original"])
+
+	// the configured pipeline is restored on resume.
+	require.NoError(t, client.SetPausePipeline("logs", false))
+	require.JSONEq(t, original, pipelines["logs"])
+	require.NotContains(t, pipelines, "logs-es-operator-original")
+
+	// resuming again leaves the restored pipeline alone.
+	require.NoError(t, client.SetPausePipeline("logs", false))
+	require.JSONEq(t, original, pipelines["logs"])
+
+	// a pipeline which didn't exist passes all documents once resumed.
+	require.NoError(t, client.SetPausePipeline("pause", true))
+	require.JSONEq(t, paused, pipelines["pause"])
+	require.NoError(t, client.SetPausePipeline("pause", false))
+	require.JSONEq(t, passing, pipelines["pause"])
+	require.NotContains(t, pipelines, "pause-es-operator-original")
+}
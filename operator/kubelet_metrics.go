@@ -0,0 +1,97 @@
+package operator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/metrics/pkg/apis/metrics/v1beta1"
+)
+
+// kubeletSummary is the part of the kubelet summary API response holding the
+// CPU usage of the containers.
+type kubeletSummary struct {
+	Pods []struct {
+		PodRef struct {
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+		} `json:"podRef"`
+		Containers []struct {
+			Name string `json:"name"`
+			CPU  *struct {
+				UsageNanoCores *uint64 `json:"usageNanoCores"`
+			} `json:"cpu"`
+		} `json:"containers"`
+	} `json:"pods"`
+}
+
+// podMetricsFromSummary converts the CPU usage of the pods of the namespace
+// in the kubelet summary to pod metrics. Containers without CPU usage are
+// omitted.
+func podMetricsFromSummary(data []byte, namespace string) ([]v1beta1.PodMetrics, error) {
+	var summary kubeletSummary
+	err := json.Unmarshal(data, &summary)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics := make([]v1beta1.PodMetrics, 0, len(summary.Pods))
+	for _, pod := range summary.Pods {
+		if pod.PodRef.Namespace != namespace {
+			continue
+		}
+		podMetrics := v1beta1.PodMetrics{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      pod.PodRef.Name,
+				Namespace: pod.PodRef.Namespace,
+			},
+		}
+		for _, container := range pod.Containers {
+			if container.CPU == nil || container.CPU.UsageNanoCores == nil {
+				continue
+			}
+			podMetrics.Containers = append(podMetrics.Containers, v1beta1.ContainerMetrics{
+				Name: container.Name,
+				Usage: v1.ResourceList{
+					v1.ResourceCPU: *resource.NewScaledQuantity(int64(*container.CPU.UsageNanoCores), resource.Nano),
+				},
+			})
+		}
+		metrics = append(metrics, podMetrics)
+	}
+	return metrics, nil
+}
+
+// kubeletPodMetrics returns the metrics of the pods of the EDS from the
+// summary API of the kubelets of their nodes, proxied by the API server. It's
+// the fallback if metrics-server isn't available.
+func (c *ElasticsearchMetricsCollector) kubeletPodMetrics(ctx context.Context) ([]v1beta1.PodMetrics, error) {
+	nodes := make(map[string]struct{})
+	for _, pod := range c.es.Pods {
+		if pod.Spec.NodeName != "" {
+			nodes[pod.Spec.NodeName] = struct{}{}
+		}
+	}
+
+	metrics := make([]v1beta1.PodMetrics, 0, len(c.es.Pods))
+	for node := range nodes {
+		data, err := c.kube.CoreV1().RESTClient().Get().
+			Resource("nodes").
+			Name(node).
+			SubResource("proxy", "stats", "summary").
+			DoRaw(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get kubelet summary of node %s: %v", node, err)
+		}
+
+		nodeMetrics, err := podMetricsFromSummary(data, c.es.ElasticsearchDataSet.Namespace)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse kubelet summary of node %s: %v", node, err)
+		}
+		metrics = append(metrics, nodeMetrics...)
+	}
+	return metrics, nil
+}
@@ -0,0 +1,190 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	// operatorPodDrainedAnnotationKey marks a pod drained by the operator.
+	// The preStop hook of the Elasticsearch container waits for it.
+	operatorPodDrainedAnnotationKey = "operator.zalando.org/drained"
+	// coordinatedTerminationAnnotationKey marks pods with coordinated
+	// termination, which the operator drains when they are terminated by
+	// someone else, e.g. on eviction.
+	coordinatedTerminationAnnotationKey = "es-operator.zalando.org/coordinated-termination"
+
+	defaultLifecycleContainer           = "elasticsearch"
+	defaultLifecycleDrainTimeoutSeconds = 300
+	defaultLifecycleShutdownSeconds     = 60
+
+	lifecyclePodInfoVolume    = "es-operator-podinfo"
+	lifecyclePodInfoPath      = "/etc/es-operator/podinfo"
+	lifecycleSharedVolume     = "es-operator-lifecycle"
+	lifecycleSharedPath       = "/var/run/es-operator"
+	lifecycleTerminatingFile  = lifecycleSharedPath + "/elasticsearch-terminating"
+	defaultTerminationSeconds = 30
+)
+
+// lifecycleSettings returns the container name, drain timeout and shutdown
+// time of the lifecycle with defaults applied.
+func lifecycleSettings(lifecycle *zv1.ElasticsearchDataSetLifecycle) (string, int64, int64) {
+	container := lifecycle.Container
+	if container == "" {
+		container = defaultLifecycleContainer
+	}
+	drainTimeout := lifecycle.DrainTimeoutSeconds
+	if drainTimeout <= 0 {
+		drainTimeout = defaultLifecycleDrainTimeoutSeconds
+	}
+	shutdown := lifecycle.ShutdownSeconds
+	if shutdown <= 0 {
+		shutdown = defaultLifecycleShutdownSeconds
+	}
+	return container, drainTimeout, shutdown
+}
+
+// elasticsearchPreStopScript waits until the operator marked the pod drained
+// and signals the sidecars that Elasticsearch is terminating.
+func elasticsearchPreStopScript(drainTimeout int64) string {
+	return fmt.Sprintf(`i=0
+while [ "$i" -lt %d ]; do
+  grep -q '^%s="true"' %s/annotations && break
+  sleep 1
+  i=$((i+1))
+done
+touch %s`, drainTimeout, operatorPodDrainedAnnotationKey, lifecyclePodInfoPath, lifecycleTerminatingFile)
+}
+
+// sidecarPreStopScript waits until Elasticsearch is terminating and gives it
+// time to shut down.
+func sidecarPreStopScript(drainTimeout, shutdown int64) string {
+	return fmt.Sprintf(`i=0
+while [ "$i" -lt %d ] && [ ! -f %s ]; do
+  sleep 1
+  i=$((i+1))
+done
+sleep %d`, drainTimeout, lifecycleTerminatingFile, shutdown)
+}
+
+// injectCoordinatedTermination adds preStop hooks to the containers of the
+// pod template, so the Elasticsearch container is only terminated after the
+// operator drained the pod and the sidecars only after Elasticsearch. The
+// termination grace period is raised to cover the drain and shutdown.
+// Existing preStop hooks are left untouched.
+func injectCoordinatedTermination(template *v1.PodTemplateSpec, lifecycle *zv1.ElasticsearchDataSetLifecycle) {
+	if lifecycle == nil || !lifecycle.CoordinatedTermination {
+		return
+	}
+	esContainer, drainTimeout, shutdown := lifecycleSettings(lifecycle)
+
+	if template.Annotations == nil {
+		template.Annotations = map[string]string{}
+	}
+	template.Annotations[coordinatedTerminationAnnotationKey] = "true"
+
+	template.Spec.Volumes = append(template.Spec.Volumes,
+		v1.Volume{
+			Name: lifecyclePodInfoVolume,
+			VolumeSource: v1.VolumeSource{
+				DownwardAPI: &v1.DownwardAPIVolumeSource{
+					Items: []v1.DownwardAPIVolumeFile{
+						{
+							Path:     "annotations",
+							FieldRef: &v1.ObjectFieldSelector{FieldPath: "metadata.annotations"},
+						},
+					},
+				},
+			},
+		},
+		v1.Volume{
+			Name:         lifecycleSharedVolume,
+			VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}},
+		},
+	)
+
+	for i := range template.Spec.Containers {
+		container := &template.Spec.Containers[i]
+		container.VolumeMounts = append(container.VolumeMounts, v1.VolumeMount{
+			Name:      lifecycleSharedVolume,
+			MountPath: lifecycleSharedPath,
+		})
+
+		script := sidecarPreStopScript(drainTimeout, shutdown)
+		if container.Name == esContainer {
+			container.VolumeMounts = append(container.VolumeMounts, v1.VolumeMount{
+				Name:      lifecyclePodInfoVolume,
+				MountPath: lifecyclePodInfoPath,
+				ReadOnly:  true,
+			})
+			script = elasticsearchPreStopScript(drainTimeout)
+		}
+
+		if container.Lifecycle == nil {
+			container.Lifecycle = &v1.Lifecycle{}
+		}
+		if container.Lifecycle.PreStop == nil {
+			container.Lifecycle.PreStop = &v1.LifecycleHandler{
+				Exec: &v1.ExecAction{Command: []string{"/bin/sh", "-c", script}},
+			}
+		}
+	}
+
+	// the sidecars outlive Elasticsearch by the shutdown time, add the
+	// same again as headroom for Elasticsearch to stop.
+	grace := drainTimeout + 2*shutdown
+	current := int64(defaultTerminationSeconds)
+	if template.Spec.TerminationGracePeriodSeconds != nil {
+		current = *template.Spec.TerminationGracePeriodSeconds
+	}
+	if grace > current {
+		template.Spec.TerminationGracePeriodSeconds = &grace
+	}
+}
+
+// hasCoordinatedTermination returns true if the pod waits for the operator
+// to drain it before terminating Elasticsearch.
+func hasCoordinatedTermination(pod *v1.Pod) bool {
+	return pod.Annotations[coordinatedTerminationAnnotationKey] == "true"
+}
+
+// markPodDrained marks a pod with coordinated termination drained, which
+// lets its Elasticsearch container terminate.
+func (o *Operator) markPodDrained(ctx context.Context, pod *v1.Pod) error {
+	if !hasCoordinatedTermination(pod) {
+		return nil
+	}
+	return annotatePod(ctx, o.kube, pod, operatorPodDrainedAnnotationKey, "true")
+}
+
+// drainTerminatingPods drains pods with coordinated termination which are
+// terminated without the operator, e.g. when evicted, so their
+// Elasticsearch container can terminate after the drain.
+func (o *Operator) drainTerminatingPods(ctx context.Context, sr StatefulResource, pods []*v1.Pod) error {
+	for _, pod := range pods {
+		if pod.DeletionTimestamp == nil || !hasCoordinatedTermination(pod) {
+			continue
+		}
+		if _, ok := pod.Annotations[operatorPodDrainedAnnotationKey]; ok {
+			continue
+		}
+
+		log.Infof("Draining terminating Pod %s/%s", pod.Namespace, pod.Name)
+		o.recorder.Event(sr.Self(), v1.EventTypeNormal, "DrainingPod", fmt.Sprintf("Draining terminating Pod '%s/%s'", pod.Namespace,
+			pod.Name))
+		err := sr.Drain(ctx, pod)
+		if err != nil {
+			return fmt.Errorf("failed to drain terminating Pod %s/%s: %v", pod.Namespace, pod.Name, err)
+		}
+
+		err = o.markPodDrained(ctx, pod)
+		if err != nil {
+			return fmt.Errorf("failed to mark Pod %s/%s drained: %v", pod.Namespace, pod.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package operator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	"github.com/zalando-incubator/es-operator/pkg/clientset"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestInjectCoordinatedTermination(t *testing.T) {
+	userPreStop := &v1.LifecycleHandler{Exec: &v1.ExecAction{Command: []string{"true"}}}
+	newTemplate := func() *v1.PodTemplateSpec {
+		return &v1.PodTemplateSpec{
+			Spec: v1.PodSpec{
+				Containers: []v1.Container{
+					{Name: "elasticsearch"},
+					{Name: "log-shipper"},
+					{Name: "exporter", Lifecycle: &v1.Lifecycle{PreStop: userPreStop}},
+				},
+			},
+		}
+	}
+
+	template := newTemplate()
+	injectCoordinatedTermination(template, nil)
+	assert.Equal(t, newTemplate(), template)
+
+	injectCoordinatedTermination(template, &zv1.ElasticsearchDataSetLifecycle{
+		CoordinatedTermination: true,
+		DrainTimeoutSeconds:    120,
+		ShutdownSeconds:        20,
+	})
+
+	assert.Equal(t, "true", template.Annotations[coordinatedTerminationAnnotationKey])
+	assert.Len(t, template.Spec.Volumes, 2)
+	assert.Equal(t, int64(160), *template.Spec.TerminationGracePeriodSeconds)
+
+	es := template.Spec.Containers[0]
+	assert.Len(t, es.VolumeMounts, 2)
+	assert.Contains(t, es.Lifecycle.PreStop.Exec.Command[2], `grep -q '^operator.zalando.org/drained="true"'`)
+	assert.Contains(t, es.Lifecycle.PreStop.Exec.Command[2], "-lt 120")
+
+	sidecar := template.Spec.Containers[1]
+	assert.Len(t, sidecar.VolumeMounts, 1)
+	assert.Contains(t, sidecar.Lifecycle.PreStop.Exec.Command[2], lifecycleTerminatingFile)
+	assert.Contains(t, sidecar.Lifecycle.PreStop.Exec.Command[2], "sleep 20")
+
+	// existing preStop hooks are kept
+	assert.Equal(t, userPreStop, template.Spec.Containers[2].Lifecycle.PreStop)
+
+	// a longer grace period is not reduced
+	template = newTemplate()
+	grace := int64(3600)
+	template.Spec.TerminationGracePeriodSeconds = &grace
+	injectCoordinatedTermination(template, &zv1.ElasticsearchDataSetLifecycle{CoordinatedTermination: true})
+	assert.Equal(t, int64(3600), *template.Spec.TerminationGracePeriodSeconds)
+}
+
+func TestDrainTerminatingPods(t *testing.T) {
+	now := metav1.Now()
+	pods := []*v1.Pod{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "terminating",
+				Namespace:   "default",
+				Annotations: map[string]string{coordinatedTerminationAnnotationKey: "true"},
+				// set by the API server on deletion.
+				DeletionTimestamp: &now,
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "drained",
+				Namespace: "default",
+				Annotations: map[string]string{
+					coordinatedTerminationAnnotationKey: "true",
+					operatorPodDrainedAnnotationKey:     "true",
+				},
+				DeletionTimestamp: &now,
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "uncoordinated",
+				Namespace:         "default",
+				DeletionTimestamp: &now,
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "running",
+				Namespace:   "default",
+				Annotations: map[string]string{coordinatedTerminationAnnotationKey: "true"},
+			},
+		},
+	}
+
+	objects := make([]runtime.Object, 0, len(pods))
+	for _, pod := range pods {
+		objects = append(objects, pod.DeepCopy())
+	}
+	kube := fake.NewSimpleClientset(objects...)
+	o := &Operator{
+		kube:     &clientset.Clientset{Interface: kube},
+		recorder: record.NewFakeRecorder(10),
+	}
+
+	err := o.drainTerminatingPods(context.Background(), &mockResource{eds: &zv1.ElasticsearchDataSet{}}, pods)
+	require.NoError(t, err)
+
+	for name, drained := range map[string]bool{"terminating": true, "drained": true, "uncoordinated": false, "running": false} {
+		pod, err := kube.CoreV1().Pods("default").Get(context.Background(), name, metav1.GetOptions{})
+		require.NoError(t, err)
+		_, ok := pod.Annotations[operatorPodDrainedAnnotationKey]
+		assert.Equal(t, drained, ok, name)
+	}
+}
@@ -0,0 +1,101 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	v1 "k8s.io/api/core/v1"
+)
+
+// votingExclusionTimeout is how long Elasticsearch waits for an excluded
+// node to be removed from the voting configuration.
+const votingExclusionTimeout = "2m"
+
+// isMasterRole returns true if the EDS manages dedicated master nodes.
+func isMasterRole(eds *zv1.ElasticsearchDataSet) bool {
+	return eds.Spec.Role == zv1.RoleMaster
+}
+
+// masterQuorumSafe returns false and the reason if taking one more master
+// down would lose the quorum of the desired masters.
+func masterQuorumSafe(desired, ready int32) (bool, string) {
+	quorum := desired/2 + 1
+	if ready-1 < quorum {
+		return false, fmt.Sprintf("%d of %d masters are ready, restarting one would lose the quorum of %d", ready, desired, quorum)
+	}
+	return true, ""
+}
+
+// masterClusterStable returns false and the reason if a master of the EDS
+// must not be restarted or removed: while no master is elected or the
+// remaining masters wouldn't keep the quorum.
+func (r *EDSResource) masterClusterStable() (bool, string) {
+	if ok, reason := masterQuorumSafe(r.Replicas(), r.eds.Status.ReadyReplicas); !ok {
+		return false, reason
+	}
+	master, err := r.esClient.GetMaster()
+	if err != nil {
+		return false, fmt.Sprintf("failed to get elected master: %v", err)
+	}
+	if master == "" {
+		return false, "no master is elected"
+	}
+	return true, ""
+}
+
+// ExcludeVotingNode withdraws the vote of the master node of the pod, so
+// that it can be stopped without affecting the quorum. An elected master
+// steps down. Pods which didn't join the cluster are skipped.
+func (c *ESClient) ExcludeVotingNode(_ context.Context, pod *v1.Pod) error {
+	if c.dryRun() {
+		return nil
+	}
+	nodes, err := c.getNodeInfos()
+	if err != nil {
+		return err
+	}
+	nodeID := ""
+	for id, node := range nodes {
+		if node.IP == pod.Status.PodIP {
+			nodeID = id
+			break
+		}
+	}
+	if nodeID == "" {
+		c.logger().Infof("Pod %s/%s didn't join the cluster, not excluding it from voting", pod.Namespace, pod.Name)
+		return nil
+	}
+
+	c.logger().Infof("Excluding node %s of pod %s/%s from voting", nodeID, pod.Namespace, pod.Name)
+	resp, err := c.httpClient().R().
+		SetQueryParam("node_ids", nodeID).
+		SetQueryParam("timeout", votingExclusionTimeout).
+		Post(c.Endpoint.String() + "/_cluster/voting_config_exclusions")
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return fmt.Errorf("code status %d - %s", resp.StatusCode(), resp.Body())
+	}
+	return nil
+}
+
+// clearVotingExclusions removes the voting exclusions, so restarted masters
+// vote again.
+func (c *ESClient) clearVotingExclusions() error {
+	if c.dryRun() {
+		return nil
+	}
+	resp, err := c.httpClient().R().
+		SetQueryParam("wait_for_removal", "false").
+		Delete(c.Endpoint.String() + "/_cluster/voting_config_exclusions")
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return fmt.Errorf("code status %d - %s", resp.StatusCode(), resp.Body())
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package operator
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestMasterQuorumSafe(t *testing.T) {
+	for _, tc := range []struct {
+		desired, ready int32
+		safe           bool
+	}{
+		{desired: 3, ready: 3, safe: true},
+		{desired: 3, ready: 4, safe: true},
+		{desired: 3, ready: 2, safe: false},
+		{desired: 5, ready: 4, safe: true},
+		{desired: 5, ready: 3, safe: false},
+		{desired: 1, ready: 1, safe: false},
+		{desired: 1, ready: 2, safe: true},
+	} {
+		safe, reason := masterQuorumSafe(tc.desired, tc.ready)
+		require.Equal(t, tc.safe, safe, "%d of %d ready", tc.ready, tc.desired)
+		require.Equal(t, tc.safe, reason == "")
+	}
+}
+
+func TestMasterRole(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_nodes/_all/jvm",
+		httpmock.NewStringResponder(200, `{"nodes":{"node-a":{"ip":"1.2.3.4"},"node-b":{"ip":"1.2.3.5"}}}`))
+	httpmock.RegisterResponder("POST", "http://elasticsearch:9200/_cluster/voting_config_exclusions?node_ids=node-a&timeout=2m",
+		httpmock.NewStringResponder(200, ``))
+	httpmock.RegisterResponder("DELETE", "http://elasticsearch:9200/_cluster/voting_config_exclusions?wait_for_removal=false",
+		httpmock.NewStringResponder(200, ``))
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/",
+		httpmock.NewStringResponder(200, `{"version":{"number":"8.6.2"}}`))
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_cat/master?h=id&format=json",
+		httpmock.NewStringResponder(200, `[{"id":"node-b"}]`))
+
+	esURL, _ := url.Parse("http://elasticsearch:9200")
+	replicas := int32(3)
+	eds := &zv1.ElasticsearchDataSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "es-master", Namespace: "default"},
+		Spec:       zv1.ElasticsearchDataSetSpec{Replicas: &replicas, Role: zv1.RoleMaster},
+		Status:     zv1.ElasticsearchDataSetStatus{ReadyReplicas: 3},
+	}
+	r := &EDSResource{eds: eds, esClient: &ESClient{Endpoint: esURL}}
+
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "es-master-0", Namespace: "default"},
+		Status:     v1.PodStatus{PodIP: "1.2.3.4"},
+	}
+	require.NoError(t, r.Drain(context.Background(), pod))
+	require.Equal(t, 1, httpmock.GetCallCountInfo()["POST http://elasticsearch:9200/_cluster/voting_config_exclusions?node_ids=node-a&timeout=2m"])
+	// masters aren't drained of shards.
+	require.Equal(t, 0, httpmock.GetCallCountInfo()["PUT http://elasticsearch:9200/_cluster/settings"])
+
+	// pods which didn't join the cluster aren't excluded.
+	pod.Status.PodIP = "1.2.3.6"
+	require.NoError(t, r.Drain(context.Background(), pod))
+	require.Equal(t, 1, httpmock.GetCallCountInfo()["POST http://elasticsearch:9200/_cluster/voting_config_exclusions?node_ids=node-a&timeout=2m"])
+
+	require.NoError(t, r.OnStableReplicasHook(context.Background()))
+	require.Equal(t, 1, httpmock.GetCallCountInfo()["DELETE http://elasticsearch:9200/_cluster/voting_config_exclusions?wait_for_removal=false"])
+
+	stable, reason := r.masterClusterStable()
+	require.True(t, stable, reason)
+
+	eds.Status.ReadyReplicas = 2
+	stable, _ = r.masterClusterStable()
+	require.False(t, stable)
+
+	eds.Status.ReadyReplicas = 3
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_cat/master?h=id&format=json",
+		httpmock.NewStringResponder(503, ``))
+	stable, reason = r.masterClusterStable()
+	require.False(t, stable)
+	require.Equal(t, "no master is elected", reason)
+}
+
+func TestValidateMasterRole(t *testing.T) {
+	eds := conversionTestFixture()
+	eds.Spec.Role = zv1.RoleMaster
+	require.Error(t, ValidateElasticsearchDataSet(eds))
+	eds.Spec.Scaling.Enabled = false
+	require.NoError(t, ValidateElasticsearchDataSet(eds))
+
+	old := eds.DeepCopy()
+	old.Spec.Role = ""
+	require.Error(t, ValidateElasticsearchDataSetUpdate(old, eds))
+	eds.Spec.Role = zv1.RoleData
+	require.NoError(t, ValidateElasticsearchDataSetUpdate(old, eds))
+}
@@ -0,0 +1,219 @@
+package operator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	defaultMasterMaxElections  = 2
+	defaultMasterWindowSeconds = 600
+)
+
+var (
+	edsMasterStable = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "es_operator",
+		Subsystem: "eds",
+		Name:      "master_stable",
+		Help:      "Whether the master of the cluster of an EDS is stable (1) or drains are paused (0).",
+	}, []string{"namespace", "eds"})
+	edsMasterElected = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "es_operator",
+		Subsystem: "eds",
+		Name:      "master_elected",
+		Help:      "Whether a master is elected in the cluster of an EDS.",
+	}, []string{"namespace", "eds"})
+	edsMasterRecentElections = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "es_operator",
+		Subsystem: "eds",
+		Name:      "master_recent_elections",
+		Help:      "Number of master elections in the cluster of an EDS within the window of its master stability configuration.",
+	}, []string{"namespace", "eds"})
+)
+
+func init() {
+	prometheus.MustRegister(edsMasterStable, edsMasterElected, edsMasterRecentElections)
+}
+
+// GetMaster returns the node ID of the elected master. It returns an empty
+// ID if no master is elected.
+func (c *ESClient) GetMaster() (string, error) {
+	path, err := c.catMasterPath()
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.httpClient().R().
+		Get(c.Endpoint.String() + path + "?h=id&format=json")
+	if err != nil {
+		return "", err
+	}
+	// Elasticsearch responds with 503 if no master is discovered.
+	if resp.StatusCode() == http.StatusServiceUnavailable {
+		return "", nil
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return "", fmt.Errorf("code status %d - %s", resp.StatusCode(), resp.Body())
+	}
+
+	var masters []struct {
+		ID string `json:"id"`
+	}
+	err = json.Unmarshal(resp.Body(), &masters)
+	if err != nil {
+		return "", err
+	}
+	if len(masters) == 0 {
+		return "", nil
+	}
+	return masters[0].ID, nil
+}
+
+// masterStabilityTracker tracks the master elections of a cluster.
+type masterStabilityTracker struct {
+	sync.Mutex
+	observed  bool
+	master    string
+	elections []time.Time
+}
+
+// observe records the currently elected master. A change to a new master
+// counts as an election, elections older than the window are forgotten.
+func (t *masterStabilityTracker) observe(master string, now time.Time, window time.Duration) {
+	t.Lock()
+	defer t.Unlock()
+
+	if t.observed && master != "" && master != t.master {
+		t.elections = append(t.elections, now)
+	}
+	t.observed = true
+	t.master = master
+
+	recent := t.elections[:0]
+	for _, election := range t.elections {
+		if now.Sub(election) <= window {
+			recent = append(recent, election)
+		}
+	}
+	t.elections = recent
+}
+
+// status returns the master status based on the observed elections.
+func (t *masterStabilityTracker) status(maxElections int32, window time.Duration) *zv1.ElasticsearchDataSetMasterStatus {
+	t.Lock()
+	defer t.Unlock()
+
+	status := &zv1.ElasticsearchDataSetMasterStatus{
+		NodeID:          t.master,
+		Stable:          true,
+		RecentElections: int32(len(t.elections)),
+	}
+	if len(t.elections) > 0 {
+		lastElection := metav1.NewTime(t.elections[len(t.elections)-1])
+		status.LastElection = &lastElection
+	}
+
+	switch {
+	case t.master == "":
+		status.Stable = false
+		status.Reason = "no master is elected"
+	case status.RecentElections > maxElections:
+		status.Stable = false
+		status.Reason = fmt.Sprintf("%d master elections within %s", status.RecentElections, window)
+	}
+	return status
+}
+
+// masterStabilitySettings returns the maximum number of elections and the
+// window of the configuration with defaults applied.
+func masterStabilitySettings(config *zv1.ElasticsearchDataSetMasterStability) (int32, time.Duration) {
+	maxElections := int32(defaultMasterMaxElections)
+	if config.MaxElections != nil {
+		maxElections = *config.MaxElections
+	}
+	windowSeconds := config.WindowSeconds
+	if windowSeconds <= 0 {
+		windowSeconds = defaultMasterWindowSeconds
+	}
+	return maxElections, time.Duration(windowSeconds) * time.Second
+}
+
+// masterStatus polls the elected master and returns the resulting master
+// status. Nil is returned if master stability monitoring is disabled.
+func (r *EDSResource) masterStatus() *zv1.ElasticsearchDataSetMasterStatus {
+	config := r.eds.Spec.MasterStability
+	if config == nil || !config.Enabled || r.masterTracker == nil {
+		deleteMasterStabilityMetrics(r.eds.Namespace, r.eds.Name)
+		return nil
+	}
+	maxElections, window := masterStabilitySettings(config)
+
+	var status *zv1.ElasticsearchDataSetMasterStatus
+	master, err := r.esClient.GetMaster()
+	if err != nil {
+		status = &zv1.ElasticsearchDataSetMasterStatus{
+			Reason: fmt.Sprintf("failed to get elected master: %v", err),
+		}
+	} else {
+		r.masterTracker.observe(master, time.Now().UTC(), window)
+		status = r.masterTracker.status(maxElections, window)
+		elected := 0.0
+		if status.NodeID != "" {
+			elected = 1
+		}
+		edsMasterElected.WithLabelValues(r.eds.Namespace, r.eds.Name).Set(elected)
+		edsMasterRecentElections.WithLabelValues(r.eds.Namespace, r.eds.Name).Set(float64(status.RecentElections))
+	}
+	stable := 0.0
+	if status.Stable {
+		stable = 1
+	}
+	edsMasterStable.WithLabelValues(r.eds.Namespace, r.eds.Name).Set(stable)
+
+	previous := r.eds.Status.Master
+	switch {
+	case !status.Stable && (previous == nil || previous.Stable):
+		r.recorder.Event(r.eds, v1.EventTypeWarning, "MasterUnstable",
+			fmt.Sprintf("Pausing drains, the master is unstable: %s", status.Reason))
+	case status.Stable && previous != nil && !previous.Stable:
+		r.recorder.Event(r.eds, v1.EventTypeNormal, "MasterStable", "The master is stable again, resuming drains")
+	}
+	return status
+}
+
+// deleteMasterStabilityMetrics deletes the master stability metrics of the
+// EDS.
+func deleteMasterStabilityMetrics(namespace, name string) {
+	labels := prometheus.Labels{"namespace": namespace, "eds": name}
+	edsMasterStable.Delete(labels)
+	edsMasterElected.Delete(labels)
+	edsMasterRecentElections.Delete(labels)
+}
+
+// ClusterStable returns false and the reason if the master of the cluster
+// is unstable, if a Velero backup of the EDS is in progress or, for masters,
+// if restarting one would lose the quorum.
+func (r *EDSResource) ClusterStable(ctx context.Context) (bool, string) {
+	if paused, reason := backupPaused(ctx, r.kube, r.eds); paused {
+		return false, reason
+	}
+	if isMasterRole(r.eds) {
+		if stable, reason := r.masterClusterStable(); !stable {
+			return false, reason
+		}
+	}
+
+	config := r.eds.Spec.MasterStability
+	if config == nil || !config.Enabled || r.eds.Status.Master == nil {
+		return true, ""
+	}
+	return r.eds.Status.Master.Stable, r.eds.Status.Master.Reason
+}
@@ -0,0 +1,159 @@
+package operator
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestGetMaster(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	esURL, _ := url.Parse("http://elasticsearch:9200")
+	client := &ESClient{Endpoint: esURL}
+
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/",
+		httpmock.NewStringResponder(200, `{"version":{"number":"8.6.2"}}`))
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_cat/master",
+		httpmock.NewStringResponder(200, `[{"id":"node-a"}]`))
+	master, err := client.GetMaster()
+	require.NoError(t, err)
+	assert.Equal(t, "node-a", master)
+
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_cat/master",
+		httpmock.NewStringResponder(503, `{"error":{"type":"master_not_discovered_exception"},"status":503}`))
+	master, err = client.GetMaster()
+	require.NoError(t, err)
+	assert.Equal(t, "", master)
+
+	// OpenSearch 2 renamed the API.
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/",
+		httpmock.NewStringResponder(200, `{"version":{"distribution":"opensearch","number":"2.11.0"}}`))
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_cat/cluster_manager",
+		httpmock.NewStringResponder(200, `[{"id":"node-b"}]`))
+	master, err = (&ESClient{Endpoint: esURL}).GetMaster()
+	require.NoError(t, err)
+	assert.Equal(t, "node-b", master)
+}
+
+func TestMasterStabilityTracker(t *testing.T) {
+	window := 10 * time.Minute
+	now := time.Now()
+	tracker := &masterStabilityTracker{}
+
+	// the first observation isn't an election.
+	tracker.observe("a", now, window)
+	status := tracker.status(1, window)
+	assert.True(t, status.Stable)
+	assert.Equal(t, "a", status.NodeID)
+	assert.Equal(t, int32(0), status.RecentElections)
+
+	tracker.observe("", now.Add(time.Minute), window)
+	status = tracker.status(1, window)
+	assert.False(t, status.Stable)
+	assert.Equal(t, "no master is elected", status.Reason)
+
+	// a re-election of the same master counts.
+	tracker.observe("a", now.Add(2*time.Minute), window)
+	tracker.observe("b", now.Add(3*time.Minute), window)
+	status = tracker.status(1, window)
+	assert.False(t, status.Stable)
+	assert.Equal(t, int32(2), status.RecentElections)
+	assert.Equal(t, now.Add(3*time.Minute).Unix(), status.LastElection.Unix())
+
+	// elections outside of the window are forgotten.
+	tracker.observe("b", now.Add(12*time.Minute+30*time.Second), window)
+	status = tracker.status(1, window)
+	assert.True(t, status.Stable)
+	assert.Equal(t, int32(1), status.RecentElections)
+}
+
+func TestEDSResourceClusterStable(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/",
+		httpmock.NewStringResponder(200, `{"version":{"number":"8.6.2"}}`))
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_cat/master",
+		httpmock.NewStringResponder(503, `{}`))
+
+	esURL, _ := url.Parse("http://elasticsearch:9200")
+	recorder := record.NewFakeRecorder(10)
+	r := &EDSResource{
+		eds:           &zv1.ElasticsearchDataSet{},
+		esClient:      &ESClient{Endpoint: esURL},
+		recorder:      recorder,
+		masterTracker: &masterStabilityTracker{},
+	}
+
+	// disabled by default.
+	assert.Nil(t, r.masterStatus())
+	stable, _ := r.ClusterStable(context.Background())
+	assert.True(t, stable)
+
+	r.eds.Spec.MasterStability = &zv1.ElasticsearchDataSetMasterStability{Enabled: true}
+	r.eds.Status.Master = r.masterStatus()
+	require.NotNil(t, r.eds.Status.Master)
+	assert.Len(t, recorder.Events, 1)
+
+	stable, reason := r.ClusterStable(context.Background())
+	assert.False(t, stable)
+	assert.Equal(t, "no master is elected", reason)
+}
+
+func TestMasterStabilityMetrics(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/",
+		httpmock.NewStringResponder(200, `{"version":{"number":"8.6.2"}}`))
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_cat/master",
+		httpmock.NewStringResponder(200, `[{"id":"node-1"}]`))
+
+	esURL, _ := url.Parse("http://elasticsearch:9200")
+	r := &EDSResource{
+		eds: &zv1.ElasticsearchDataSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "es-master", Namespace: "default"},
+			Spec: zv1.ElasticsearchDataSetSpec{
+				MasterStability: &zv1.ElasticsearchDataSetMasterStability{Enabled: true},
+			},
+		},
+		esClient:      &ESClient{Endpoint: esURL},
+		recorder:      record.NewFakeRecorder(10),
+		masterTracker: &masterStabilityTracker{},
+	}
+
+	require.NotNil(t, r.masterStatus())
+	assert.EqualValues(t, 1, testutil.ToFloat64(edsMasterStable.WithLabelValues("default", "es-master")))
+	assert.EqualValues(t, 1, testutil.ToFloat64(edsMasterElected.WithLabelValues("default", "es-master")))
+	assert.EqualValues(t, 0, testutil.ToFloat64(edsMasterRecentElections.WithLabelValues("default", "es-master")))
+
+	// a new master is elected.
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_cat/master",
+		httpmock.NewStringResponder(200, `[{"id":"node-2"}]`))
+	require.NotNil(t, r.masterStatus())
+	assert.EqualValues(t, 1, testutil.ToFloat64(edsMasterRecentElections.WithLabelValues("default", "es-master")))
+
+	// no master is elected.
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_cat/master",
+		httpmock.NewStringResponder(503, `{}`))
+	require.NotNil(t, r.masterStatus())
+	assert.EqualValues(t, 0, testutil.ToFloat64(edsMasterStable.WithLabelValues("default", "es-master")))
+	assert.EqualValues(t, 0, testutil.ToFloat64(edsMasterElected.WithLabelValues("default", "es-master")))
+
+	// the metrics are removed once monitoring is disabled.
+	r.eds.Spec.MasterStability = nil
+	require.Nil(t, r.masterStatus())
+	assert.False(t, edsMasterStable.DeleteLabelValues("default", "es-master"))
+	assert.False(t, edsMasterRecentElections.DeleteLabelValues("default", "es-master"))
+}
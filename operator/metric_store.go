@@ -0,0 +1,355 @@
+package operator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+	log "github.com/sirupsen/logrus"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	"github.com/zalando-incubator/es-operator/pkg/clientset"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// metricSetConfigMapLabelKey labels the ConfigMaps holding metric
+	// sets.
+	metricSetConfigMapLabelKey = "es-operator.zalando.org/metricset"
+	// metricSetConfigMapKey is the key of the metric set in its ConfigMap.
+	metricSetConfigMapKey = "metricset.json"
+	// metricSetConfigMapSuffix is appended to the name of the EDS to name
+	// the ConfigMap of its metric set.
+	metricSetConfigMapSuffix = "-metricset"
+)
+
+// MetricStore stores the metric sets with the CPU usage history of the EDS
+// used by the autoscaler. A metric set without resourceVersion is created,
+// otherwise it's updated if it wasn't changed since it was listed.
+type MetricStore interface {
+	// List returns the metric sets in the namespace, or in all namespaces
+	// if it's empty.
+	List(ctx context.Context, namespace string) ([]zv1.ElasticsearchMetricSet, error)
+	// Store creates or updates the metric set.
+	Store(ctx context.Context, metricSet *zv1.ElasticsearchMetricSet) error
+	// Delete deletes the metric set if it still has the same UID.
+	Delete(ctx context.Context, metricSet *zv1.ElasticsearchMetricSet) error
+}
+
+// crdMetricStore stores the metric sets as ElasticsearchMetricSet resources.
+type crdMetricStore struct {
+	kube *clientset.Clientset
+}
+
+// NewCRDMetricStore returns a metric store which stores the metric sets as
+// ElasticsearchMetricSet resources. This is the default.
+func NewCRDMetricStore(kube *clientset.Clientset) MetricStore {
+	return &crdMetricStore{kube: kube}
+}
+
+func (s *crdMetricStore) List(ctx context.Context, namespace string) ([]zv1.ElasticsearchMetricSet, error) {
+	metricSets, err := s.kube.ZalandoV1().ElasticsearchMetricSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return metricSets.Items, nil
+}
+
+func (s *crdMetricStore) Store(ctx context.Context, metricSet *zv1.ElasticsearchMetricSet) error {
+	if metricSet.ResourceVersion == "" {
+		_, err := s.kube.ZalandoV1().ElasticsearchMetricSets(metricSet.Namespace).Create(ctx, metricSet, metav1.CreateOptions{})
+		return err
+	}
+	_, err := s.kube.ZalandoV1().ElasticsearchMetricSets(metricSet.Namespace).Update(ctx, metricSet, metav1.UpdateOptions{})
+	return err
+}
+
+func (s *crdMetricStore) Delete(ctx context.Context, metricSet *zv1.ElasticsearchMetricSet) error {
+	uid := metricSet.UID
+	return s.kube.ZalandoV1().ElasticsearchMetricSets(metricSet.Namespace).Delete(ctx, metricSet.Name, metav1.DeleteOptions{
+		Preconditions: &metav1.Preconditions{UID: &uid},
+	})
+}
+
+// memoryMetricStore keeps the metric sets in the memory of the operator.
+type memoryMetricStore struct {
+	sync.Mutex
+	metricSets map[types.NamespacedName]*zv1.ElasticsearchMetricSet
+	version    int64
+}
+
+// NewMemoryMetricStore returns a metric store which keeps the metric sets in
+// the memory of the operator. The CPU usage history is lost when the operator
+// restarts, so the autoscaler waits for new samples before scaling.
+func NewMemoryMetricStore() MetricStore {
+	return &memoryMetricStore{metricSets: make(map[types.NamespacedName]*zv1.ElasticsearchMetricSet)}
+}
+
+func (s *memoryMetricStore) List(_ context.Context, namespace string) ([]zv1.ElasticsearchMetricSet, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	metricSets := make([]zv1.ElasticsearchMetricSet, 0, len(s.metricSets))
+	for key, metricSet := range s.metricSets {
+		if namespace == "" || key.Namespace == namespace {
+			metricSets = append(metricSets, *metricSet.DeepCopy())
+		}
+	}
+	return metricSets, nil
+}
+
+func (s *memoryMetricStore) Store(_ context.Context, metricSet *zv1.ElasticsearchMetricSet) error {
+	s.Lock()
+	defer s.Unlock()
+
+	key := types.NamespacedName{Namespace: metricSet.Namespace, Name: metricSet.Name}
+	current, ok := s.metricSets[key]
+	if ok != (metricSet.ResourceVersion != "") || (ok && current.ResourceVersion != metricSet.ResourceVersion) {
+		return fmt.Errorf("metric set %s was changed since it was read", key)
+	}
+
+	stored := metricSet.DeepCopy()
+	if !ok {
+		stored.UID = types.UID(fmt.Sprintf("%s-%d", key, s.version))
+	}
+	s.version++
+	stored.ResourceVersion = fmt.Sprintf("%d", s.version)
+	s.metricSets[key] = stored
+	return nil
+}
+
+func (s *memoryMetricStore) Delete(_ context.Context, metricSet *zv1.ElasticsearchMetricSet) error {
+	s.Lock()
+	defer s.Unlock()
+
+	key := types.NamespacedName{Namespace: metricSet.Namespace, Name: metricSet.Name}
+	if current, ok := s.metricSets[key]; ok && current.UID == metricSet.UID {
+		delete(s.metricSets, key)
+	}
+	return nil
+}
+
+// configMapMetricStore stores the metric sets in ConfigMaps.
+type configMapMetricStore struct {
+	kube kubernetes.Interface
+}
+
+// NewConfigMapMetricStore returns a metric store which stores the metric sets
+// in ConfigMaps named '<eds-name>-metricset' owned by the EDS.
+func NewConfigMapMetricStore(kube kubernetes.Interface) MetricStore {
+	return &configMapMetricStore{kube: kube}
+}
+
+func (s *configMapMetricStore) List(ctx context.Context, namespace string) ([]zv1.ElasticsearchMetricSet, error) {
+	configMaps, err := s.kube.CoreV1().ConfigMaps(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: metricSetConfigMapLabelKey + "=true",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	metricSets := make([]zv1.ElasticsearchMetricSet, 0, len(configMaps.Items))
+	for _, cm := range configMaps.Items {
+		metricSet := zv1.ElasticsearchMetricSet{}
+		err := json.Unmarshal([]byte(cm.Data[metricSetConfigMapKey]), &metricSet)
+		if err != nil {
+			return nil, fmt.Errorf("invalid metric set in ConfigMap %s/%s: %v", cm.Namespace, cm.Name, err)
+		}
+		// the metadata of the ConfigMap identifies the metric set.
+		metricSet.ObjectMeta = metav1.ObjectMeta{
+			Name:              metricSet.Name,
+			Namespace:         cm.Namespace,
+			Labels:            metricSet.Labels,
+			UID:               cm.UID,
+			ResourceVersion:   cm.ResourceVersion,
+			CreationTimestamp: cm.CreationTimestamp,
+			OwnerReferences:   cm.OwnerReferences,
+		}
+		metricSets = append(metricSets, metricSet)
+	}
+	return metricSets, nil
+}
+
+func (s *configMapMetricStore) Store(ctx context.Context, metricSet *zv1.ElasticsearchMetricSet) error {
+	stored := zv1.ElasticsearchMetricSet{
+		ObjectMeta: metav1.ObjectMeta{Name: metricSet.Name, Labels: metricSet.Labels},
+		Metrics:    metricSet.Metrics,
+		Samples:    metricSet.Samples,
+		Capacity:   metricSet.Capacity,
+		Baseline:   metricSet.Baseline,
+	}
+	data, err := json.Marshal(stored)
+	if err != nil {
+		return err
+	}
+
+	cm := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            metricSet.Name + metricSetConfigMapSuffix,
+			Namespace:       metricSet.Namespace,
+			Labels:          map[string]string{metricSetConfigMapLabelKey: "true"},
+			ResourceVersion: metricSet.ResourceVersion,
+			OwnerReferences: metricSet.OwnerReferences,
+		},
+		Data: map[string]string{metricSetConfigMapKey: string(data)},
+	}
+	if cm.ResourceVersion == "" {
+		_, err = s.kube.CoreV1().ConfigMaps(cm.Namespace).Create(ctx, cm, metav1.CreateOptions{})
+		return err
+	}
+	_, err = s.kube.CoreV1().ConfigMaps(cm.Namespace).Update(ctx, cm, metav1.UpdateOptions{})
+	return err
+}
+
+func (s *configMapMetricStore) Delete(ctx context.Context, metricSet *zv1.ElasticsearchMetricSet) error {
+	uid := metricSet.UID
+	return s.kube.CoreV1().ConfigMaps(metricSet.Namespace).Delete(ctx, metricSet.Name+metricSetConfigMapSuffix, metav1.DeleteOptions{
+		Preconditions: &metav1.Preconditions{UID: &uid},
+	})
+}
+
+// pushingMetricStore pushes the latest metric of every stored metric set to
+// a Prometheus Pushgateway compatible endpoint.
+type pushingMetricStore struct {
+	MetricStore
+	url string
+}
+
+// NewPushingMetricStore returns a metric store which stores the metric sets
+// in the given store and additionally pushes the latest aggregated CPU usage
+// of every metric set to the Prometheus Pushgateway compatible endpoint at
+// url, e.g. to keep a long-term history in an external TSDB. Failed pushes
+// don't fail storing the metric set.
+func NewPushingMetricStore(store MetricStore, url string) MetricStore {
+	return &pushingMetricStore{MetricStore: store, url: url}
+}
+
+func (s *pushingMetricStore) Store(ctx context.Context, metricSet *zv1.ElasticsearchMetricSet) error {
+	err := s.MetricStore.Store(ctx, metricSet)
+	if err != nil {
+		return err
+	}
+	if len(metricSet.Metrics) == 0 {
+		return nil
+	}
+
+	latest := metricSet.Metrics[len(metricSet.Metrics)-1]
+	usage := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "es_operator_eds_cpu_usage_percent",
+		Help: "The aggregated CPU usage of the pods of the EDS relative to their requests.",
+	})
+	usage.Set(float64(latest.Value))
+	pods := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "es_operator_eds_cpu_usage_pods",
+		Help: "The number of pods the CPU usage is aggregated from.",
+	})
+	pods.Set(float64(latest.Pods))
+
+	err = push.New(s.url, "es-operator").
+		Grouping("namespace", metricSet.Namespace).
+		Grouping("eds", metricSet.Name).
+		Collector(usage).
+		Collector(pods).
+		PushContext(ctx)
+	if err != nil {
+		log.Warnf("Failed to push metrics of EDS %s/%s: %v", metricSet.Namespace, metricSet.Name, err)
+	}
+	return nil
+}
+
+// coalescingMetricStore writes every metric set at most once per interval
+// and keeps the changes in between in memory.
+type coalescingMetricStore struct {
+	MetricStore
+	sync.Mutex
+	interval time.Duration
+	now      func() time.Time
+	pending  map[types.NamespacedName]*zv1.ElasticsearchMetricSet
+	written  map[types.NamespacedName]time.Time
+}
+
+// NewCoalescingMetricStore returns a metric store which writes every metric
+// set to the given store at most once per interval to reduce the writes on
+// large fleets. Changes in between are kept in memory and listed in place of
+// the stored metric sets, changes not written yet are lost when the operator
+// restarts.
+func NewCoalescingMetricStore(store MetricStore, interval time.Duration) MetricStore {
+	return &coalescingMetricStore{
+		MetricStore: store,
+		interval:    interval,
+		now:         time.Now,
+		pending:     make(map[types.NamespacedName]*zv1.ElasticsearchMetricSet),
+		written:     make(map[types.NamespacedName]time.Time),
+	}
+}
+
+func (s *coalescingMetricStore) List(ctx context.Context, namespace string) ([]zv1.ElasticsearchMetricSet, error) {
+	metricSets, err := s.MetricStore.List(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	s.Lock()
+	defer s.Unlock()
+	for i, metricSet := range metricSets {
+		key := types.NamespacedName{Namespace: metricSet.Namespace, Name: metricSet.Name}
+		pending, ok := s.pending[key]
+		if !ok {
+			continue
+		}
+		// drop the changes of metric sets deleted since.
+		if pending.UID != metricSet.UID {
+			delete(s.pending, key)
+			continue
+		}
+		metricSets[i] = *pending.DeepCopy()
+		metricSets[i].ResourceVersion = metricSet.ResourceVersion
+	}
+	return metricSets, nil
+}
+
+func (s *coalescingMetricStore) Store(ctx context.Context, metricSet *zv1.ElasticsearchMetricSet) error {
+	key := types.NamespacedName{Namespace: metricSet.Namespace, Name: metricSet.Name}
+	now := s.now()
+
+	s.Lock()
+	if metricSet.ResourceVersion != "" && now.Sub(s.written[key]) < s.interval {
+		s.pending[key] = metricSet.DeepCopy()
+		s.Unlock()
+		return nil
+	}
+	s.Unlock()
+
+	err := s.MetricStore.Store(ctx, metricSet)
+	if err != nil {
+		return err
+	}
+
+	s.Lock()
+	defer s.Unlock()
+	s.written[key] = now
+	delete(s.pending, key)
+	return nil
+}
+
+func (s *coalescingMetricStore) Delete(ctx context.Context, metricSet *zv1.ElasticsearchMetricSet) error {
+	key := types.NamespacedName{Namespace: metricSet.Namespace, Name: metricSet.Name}
+	s.Lock()
+	delete(s.pending, key)
+	delete(s.written, key)
+	s.Unlock()
+	return s.MetricStore.Delete(ctx, metricSet)
+}
+
+// isMetricSetGone returns true if the error of deleting a metric set means
+// it's already gone or was replaced.
+func isMetricSetGone(err error) bool {
+	return apierrors.IsNotFound(err) || apierrors.IsConflict(err)
+}
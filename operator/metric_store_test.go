@@ -0,0 +1,153 @@
+package operator
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func testMetricStore(t *testing.T, store MetricStore) {
+	ctx := context.Background()
+	metricSet := &zv1.ElasticsearchMetricSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "es-data",
+			Namespace:       "default",
+			OwnerReferences: []metav1.OwnerReference{{Name: "es-data", UID: "eds-uid"}},
+		},
+		Metrics: []zv1.ElasticsearchMetric{{Timestamp: metav1.Now(), Value: 40, Pods: 3}},
+	}
+	require.NoError(t, store.Store(ctx, metricSet))
+
+	metricSets, err := store.List(ctx, "default")
+	require.NoError(t, err)
+	require.Len(t, metricSets, 1)
+	stored := metricSets[0]
+	require.Equal(t, "es-data", stored.Name)
+	require.Equal(t, metricSet.OwnerReferences, stored.OwnerReferences)
+	require.EqualValues(t, 40, stored.Metrics[0].Value)
+
+	metricSets, err = store.List(ctx, "other")
+	require.NoError(t, err)
+	require.Empty(t, metricSets)
+
+	if stored.ResourceVersion == "" {
+		// the fake clientset doesn't set resource versions.
+		stored.ResourceVersion = "1"
+	}
+	stored.Metrics = append(stored.Metrics, zv1.ElasticsearchMetric{Timestamp: metav1.Now(), Value: 50})
+	require.NoError(t, store.Store(ctx, &stored))
+	metricSets, err = store.List(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, metricSets[0].Metrics, 2)
+
+	require.NoError(t, store.Delete(ctx, &metricSets[0]))
+	metricSets, err = store.List(ctx, "")
+	require.NoError(t, err)
+	require.Empty(t, metricSets)
+}
+
+func TestMemoryMetricStore(t *testing.T) {
+	testMetricStore(t, NewMemoryMetricStore())
+
+	// stale metric sets aren't stored.
+	store := NewMemoryMetricStore()
+	metricSet := &zv1.ElasticsearchMetricSet{ObjectMeta: metav1.ObjectMeta{Name: "es-data", Namespace: "default"}}
+	require.NoError(t, store.Store(context.Background(), metricSet))
+	require.Error(t, store.Store(context.Background(), metricSet))
+}
+
+func TestConfigMapMetricStore(t *testing.T) {
+	kube := fake.NewSimpleClientset()
+	store := NewConfigMapMetricStore(kube)
+	require.NoError(t, store.Store(context.Background(), &zv1.ElasticsearchMetricSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "es-data", Namespace: "default"},
+	}))
+	cm, err := kube.CoreV1().ConfigMaps("default").Get(context.Background(), "es-data-metricset", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "true", cm.Labels[metricSetConfigMapLabelKey])
+	require.NoError(t, kube.CoreV1().ConfigMaps("default").Delete(context.Background(), cm.Name, metav1.DeleteOptions{}))
+
+	testMetricStore(t, store)
+}
+
+func TestPushingMetricStore(t *testing.T) {
+	var pushed []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pushed = append(pushed, r.Method+" "+r.URL.Path)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	testMetricStore(t, NewPushingMetricStore(NewMemoryMetricStore(), server.URL))
+	require.Len(t, pushed, 2)
+	require.Contains(t, pushed[0], "PUT /metrics/job/es-operator/")
+	require.Contains(t, pushed[0], "/namespace/default")
+	require.Contains(t, pushed[0], "/eds/es-data")
+
+	// failed pushes don't fail storing the metric set.
+	server.Close()
+	store := NewPushingMetricStore(NewMemoryMetricStore(), server.URL)
+	require.NoError(t, store.Store(context.Background(), &zv1.ElasticsearchMetricSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "es-data", Namespace: "default"},
+		Metrics:    []zv1.ElasticsearchMetric{{Value: 40}},
+	}))
+}
+
+func TestCoalescingMetricStore(t *testing.T) {
+	testMetricStore(t, NewCoalescingMetricStore(NewMemoryMetricStore(), 0))
+
+	ctx := context.Background()
+	backend := NewMemoryMetricStore()
+	store := NewCoalescingMetricStore(backend, time.Minute).(*coalescingMetricStore)
+	now := time.Now()
+	store.now = func() time.Time { return now }
+
+	require.NoError(t, store.Store(ctx, &zv1.ElasticsearchMetricSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "es-data", Namespace: "default"},
+	}))
+	metricSets, err := store.List(ctx, "default")
+	require.NoError(t, err)
+	stored := metricSets[0]
+
+	// updates within the interval are only listed.
+	stored.Metrics = []zv1.ElasticsearchMetric{{Value: 40}}
+	require.NoError(t, store.Store(ctx, &stored))
+	metricSets, err = backend.List(ctx, "default")
+	require.NoError(t, err)
+	require.Empty(t, metricSets[0].Metrics)
+	metricSets, err = store.List(ctx, "default")
+	require.NoError(t, err)
+	require.Len(t, metricSets[0].Metrics, 1)
+	require.Equal(t, stored.ResourceVersion, metricSets[0].ResourceVersion)
+
+	// the next update after the interval is written.
+	now = now.Add(time.Minute)
+	stored = metricSets[0]
+	stored.Metrics = append(stored.Metrics, zv1.ElasticsearchMetric{Value: 50})
+	require.NoError(t, store.Store(ctx, &stored))
+	metricSets, err = backend.List(ctx, "default")
+	require.NoError(t, err)
+	require.Len(t, metricSets[0].Metrics, 2)
+
+	// pending changes of replaced metric sets are dropped.
+	now = now.Add(time.Second)
+	stored = metricSets[0]
+	stored.Metrics = nil
+	require.NoError(t, store.Store(ctx, &stored))
+	require.NoError(t, backend.Delete(ctx, &metricSets[0]))
+	require.NoError(t, backend.Store(ctx, &zv1.ElasticsearchMetricSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "es-data", Namespace: "default"},
+		Metrics:    []zv1.ElasticsearchMetric{{Value: 60}},
+	}))
+	metricSets, err = store.List(ctx, "default")
+	require.NoError(t, err)
+	require.EqualValues(t, 60, metricSets[0].Metrics[0].Value)
+	require.Empty(t, store.pending)
+}
@@ -10,77 +10,191 @@ import (
 	v12 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
 	"github.com/zalando-incubator/es-operator/pkg/clientset"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
 	"k8s.io/metrics/pkg/apis/metrics/v1beta1"
 )
 
+const (
+	aggregationMean        = "Mean"
+	aggregationTrimmedMean = "TrimmedMean"
+	defaultTrimPercent     = 10
+)
+
 type ElasticsearchMetricsCollector struct {
-	logger *log.Entry
-	kube   *clientset.Clientset
-	es     ESResource
+	logger     *log.Entry
+	kube       *clientset.Clientset
+	store      MetricStore
+	nodeLister corelisters.NodeLister
+	es         ESResource
 }
 
 func (c *ElasticsearchMetricsCollector) collectMetrics(ctx context.Context) error {
 	// first, collect metrics for all pods....
+	var podMetrics []v1beta1.PodMetrics
+	source := v12.MetricSourceMetricsServer
 	metrics, err := c.kube.MetricsV1Beta1().PodMetricses(c.es.ElasticsearchDataSet.Namespace).List(ctx, metav1.ListOptions{})
 	if err != nil {
-		return err
+		// fall back to the kubelets if metrics-server isn't available.
+		c.logger.Warnf("Failed to get metrics from metrics-server, falling back to the kubelet summary API: %v", err)
+		podMetrics, err = c.kubeletPodMetrics(ctx)
+		if err != nil {
+			return err
+		}
+		source = v12.MetricSourceKubelet
+	} else {
+		podMetrics = metrics.Items
 	}
 
-	cpuUsagePercent := getCPUUsagePercent(metrics.Items, c.es.Pods)
+	c.recordArchitectureMetrics(podMetrics)
 
-	if len(cpuUsagePercent) == 0 {
+	usages := podCPUUsages(podMetrics, c.es.Pods)
+	if len(usages) == 0 {
 		c.logger.Debug("Didn't have any metrics to collect.")
 		return nil
 	}
 
-	// calculate its median
-	median := calculateMedian(cpuUsagePercent)
+	cpuUsagePercent := make([]int32, 0, len(usages))
+	for _, usage := range usages {
+		cpuUsagePercent = append(cpuUsagePercent, usage.percent)
+	}
 
-	// next, get the configmap and store the sample
-	return c.storeMedian(ctx, median)
+	// aggregate the usage of the pods, by default to its median
+	now := metav1.Now()
+	metric := v12.ElasticsearchMetric{
+		Timestamp: now,
+		Value:     aggregateCPUUsage(cpuUsagePercent, c.es.ElasticsearchDataSet.Spec.Scaling.Aggregation),
+		Source:    source,
+		Pods:      int32(len(usages)),
+	}
+	samples, capacity := metricSamples(usages, c.nodeLabels(), source, now)
+
+	// next, get the metric set and store the sample
+	return c.storeMetric(ctx, metric, samples, capacity)
 }
 
-func getCPUUsagePercent(metrics []v1beta1.PodMetrics, pods []v1.Pod) []int32 {
+// podCPUUsage is the CPU usage of a pod.
+type podCPUUsage struct {
+	pod *v1.Pod
+	// percent is the highest usage of a container relative to its
+	// requests.
+	percent int32
+	// usedMilliCPU and requestedMilliCPU are the CPU used and requested
+	// by the containers with requests.
+	usedMilliCPU      int64
+	requestedMilliCPU int64
+}
+
+// podCPUUsages returns the CPU usage of the pods with metrics.
+func podCPUUsages(metrics []v1beta1.PodMetrics, pods []v1.Pod) []podCPUUsage {
+	podsByName := make(map[string]*v1.Pod, len(pods))
 	podResources := make(map[string]map[string]v1.ResourceRequirements, len(pods))
-	for _, pod := range pods {
+	for i, pod := range pods {
 		containerMap := make(map[string]v1.ResourceRequirements, len(pod.Spec.Containers))
 		for _, container := range pod.Spec.Containers {
 			containerMap[container.Name] = container.Resources
 		}
 		podResources[pod.Name] = containerMap
+		podsByName[pod.Name] = &pods[i]
 	}
 
 	// calculate the max usage/request value for each pod by looking at all
 	// containers in every pod.
-	cpuUsagePercent := []int32{}
+	usages := []podCPUUsage{}
 	for _, podMetrics := range metrics {
 		if containerResources, ok := podResources[podMetrics.Name]; ok {
-			podMax := int32(0)
+			usage := podCPUUsage{pod: podsByName[podMetrics.Name]}
 			for _, container := range podMetrics.Containers {
 				if resources, ok := containerResources[container.Name]; ok {
 					requestedCPU := resources.Requests.Cpu().MilliValue()
 					usageCPU := container.Usage.Cpu().MilliValue()
 					if requestedCPU > 0 {
 						usagePcnt := int32(100 * usageCPU / requestedCPU)
-						if usagePcnt > podMax {
-							podMax = usagePcnt
+						if usagePcnt > usage.percent {
+							usage.percent = usagePcnt
 						}
+						usage.usedMilliCPU += usageCPU
+						usage.requestedMilliCPU += requestedCPU
 					}
 				}
 			}
-			cpuUsagePercent = append(cpuUsagePercent, podMax)
+			usages = append(usages, usage)
 		}
 	}
+	return usages
+}
+
+func getCPUUsagePercent(metrics []v1beta1.PodMetrics, pods []v1.Pod) []int32 {
+	cpuUsagePercent := []int32{}
+	for _, usage := range podCPUUsages(metrics, pods) {
+		cpuUsagePercent = append(cpuUsagePercent, usage.percent)
+	}
 	return cpuUsagePercent
 }
 
-func (c *ElasticsearchMetricsCollector) storeMedian(ctx context.Context, i int32) error {
-	currentValue := v12.ElasticsearchMetric{
-		Timestamp: metav1.Now(),
-		Value:     i,
+// sampleNodeLabels are the node labels copied to the samples of the pods.
+var sampleNodeLabels = []string{v1.LabelArchStable, v1.LabelTopologyZone}
+
+// nodeLabels returns the sample labels of the nodes of the pods, keyed by
+// node name.
+func (c *ElasticsearchMetricsCollector) nodeLabels() map[string]map[string]string {
+	nodeLabels := make(map[string]map[string]string)
+	if c.nodeLister == nil {
+		return nodeLabels
 	}
+	for _, pod := range c.es.Pods {
+		if pod.Spec.NodeName == "" {
+			continue
+		}
+		if _, ok := nodeLabels[pod.Spec.NodeName]; ok {
+			continue
+		}
+		node, err := c.nodeLister.Get(pod.Spec.NodeName)
+		if err != nil {
+			continue
+		}
+		labels := make(map[string]string, len(sampleNodeLabels))
+		for _, key := range sampleNodeLabels {
+			if value, ok := node.Labels[key]; ok {
+				labels[key] = value
+			}
+		}
+		nodeLabels[node.Name] = labels
+	}
+	return nodeLabels
+}
+
+// metricSamples returns the samples of the individual pods and the capacity
+// they sum up to.
+func metricSamples(usages []podCPUUsage, nodeLabels map[string]map[string]string, source v12.ElasticsearchMetricSource, now metav1.Time) ([]v12.ElasticsearchMetricSample, *v12.ElasticsearchMetricCapacity) {
+	samples := make([]v12.ElasticsearchMetricSample, 0, len(usages))
+	used, requested := int64(0), int64(0)
+	for _, usage := range usages {
+		sample := v12.ElasticsearchMetricSample{
+			Timestamp: now,
+			Value:     usage.percent,
+			Source:    source,
+			Pod:       usage.pod.Name,
+			Node:      usage.pod.Spec.NodeName,
+		}
+		if labels := nodeLabels[sample.Node]; len(labels) > 0 {
+			sample.Labels = labels
+		}
+		samples = append(samples, sample)
+		used += usage.usedMilliCPU
+		requested += usage.requestedMilliCPU
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i].Pod < samples[j].Pod })
 
+	return samples, &v12.ElasticsearchMetricCapacity{
+		Pods:         int32(len(usages)),
+		RequestedCPU: *resource.NewMilliQuantity(requested, resource.DecimalSI),
+		UsedCPU:      *resource.NewMilliQuantity(used, resource.DecimalSI),
+	}
+}
+
+func (c *ElasticsearchMetricsCollector) storeMetric(ctx context.Context, currentValue v12.ElasticsearchMetric, samples []v12.ElasticsearchMetricSample, capacity *v12.ElasticsearchMetricCapacity) error {
 	if c.es.MetricSet == nil {
 		c.es.MetricSet = &v12.ElasticsearchMetricSet{
 			ObjectMeta: metav1.ObjectMeta{
@@ -88,19 +202,15 @@ func (c *ElasticsearchMetricsCollector) storeMedian(ctx context.Context, i int32
 				Namespace: c.es.ElasticsearchDataSet.Namespace,
 				Labels:    c.es.ElasticsearchDataSet.Labels,
 				OwnerReferences: []metav1.OwnerReference{
-					{
-						APIVersion: c.es.ElasticsearchDataSet.APIVersion,
-						Kind:       c.es.ElasticsearchDataSet.Kind,
-						Name:       c.es.ElasticsearchDataSet.Name,
-						UID:        c.es.ElasticsearchDataSet.UID,
-					},
+					metricSetOwnerReference(c.es.ElasticsearchDataSet),
 				},
 			},
-			Metrics: []v12.ElasticsearchMetric{currentValue},
+			Metrics:  []v12.ElasticsearchMetric{currentValue},
+			Samples:  samples,
+			Capacity: capacity,
 		}
-		_, err := c.kube.ZalandoV1().ElasticsearchMetricSets(c.es.MetricSet.Namespace).Create(ctx, c.es.MetricSet, metav1.CreateOptions{})
-		if err != nil {
-			return err
+		if predictiveScalingEnabled(c.es.ElasticsearchDataSet) {
+			c.es.MetricSet.Baseline = updateBaseline(nil, currentValue)
 		}
 	} else {
 		threshold := time.Duration(math.Max(float64(c.es.ElasticsearchDataSet.Spec.Scaling.ScaleDownThresholdDurationSeconds), float64(c.es.ElasticsearchDataSet.Spec.Scaling.ScaleUpThresholdDurationSeconds))) * time.Second
@@ -115,13 +225,52 @@ func (c *ElasticsearchMetricsCollector) storeMedian(ctx context.Context, i int32
 		}
 		newMetricsList = append(newMetricsList, currentValue)
 		c.es.MetricSet.Metrics = newMetricsList
+		c.es.MetricSet.Samples = samples
+		c.es.MetricSet.Capacity = capacity
+		if predictiveScalingEnabled(c.es.ElasticsearchDataSet) {
+			c.es.MetricSet.Baseline = updateBaseline(c.es.MetricSet.Baseline, currentValue)
+		}
 
-		_, err := c.kube.ZalandoV1().ElasticsearchMetricSets(c.es.MetricSet.Namespace).Update(ctx, c.es.MetricSet, metav1.UpdateOptions{})
-		if err != nil {
-			return err
+	}
+	return c.store.Store(ctx, c.es.MetricSet)
+}
+
+// aggregateCPUUsage aggregates the CPU usage of the pods according to the
+// aggregation settings.
+func aggregateCPUUsage(cpuMetrics []int32, aggregation *v12.ElasticsearchDataSetCPUAggregation) int32 {
+	if aggregation == nil {
+		return calculateMedian(cpuMetrics)
+	}
+	switch aggregation.Method {
+	case aggregationMean:
+		return calculateTrimmedMean(cpuMetrics, 0)
+	case aggregationTrimmedMean:
+		trimPercent := int32(defaultTrimPercent)
+		if aggregation.TrimPercent != nil {
+			trimPercent = *aggregation.TrimPercent
 		}
+		return calculateTrimmedMean(cpuMetrics, trimPercent)
+	default:
+		return calculateMedian(cpuMetrics)
+	}
+}
+
+// calculateTrimmedMean returns the mean of the metrics without the lowest
+// and the highest trimPercent of the values each. At least one value is
+// kept.
+func calculateTrimmedMean(cpuMetrics []int32, trimPercent int32) int32 {
+	sort.Slice(cpuMetrics, func(i, j int) bool { return cpuMetrics[i] < cpuMetrics[j] })
+	trim := len(cpuMetrics) * int(trimPercent) / 100
+	if 2*trim >= len(cpuMetrics) {
+		trim = (len(cpuMetrics) - 1) / 2
+	}
+	trimmed := cpuMetrics[trim : len(cpuMetrics)-trim]
+
+	sum := int64(0)
+	for _, value := range trimmed {
+		sum += int64(value)
 	}
-	return nil
+	return int32(sum / int64(len(trimmed)))
 }
 
 func calculateMedian(cpuMetrics []int32) int32 {
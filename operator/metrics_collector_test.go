@@ -5,6 +5,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -16,6 +17,23 @@ func TestCalculateMedian(t *testing.T) {
 	assert.Equal(t, int32(1), calculateMedian([]int32{1, 4}))
 }
 
+func TestAggregateCPUUsage(t *testing.T) {
+	values := func() []int32 { return []int32{90, 10, 12, 11, 300, 13, 9, 10, 12, 11} }
+	trimPercent := int32(20)
+
+	assert.Equal(t, int32(11), aggregateCPUUsage(values(), nil))
+	assert.Equal(t, int32(47), aggregateCPUUsage(values(), &zv1.ElasticsearchDataSetCPUAggregation{Method: "Mean"}))
+	// the lowest and highest 10% are ignored by default
+	assert.Equal(t, int32(21), aggregateCPUUsage(values(), &zv1.ElasticsearchDataSetCPUAggregation{Method: "TrimmedMean"}))
+	assert.Equal(t, int32(11), aggregateCPUUsage(values(), &zv1.ElasticsearchDataSetCPUAggregation{Method: "TrimmedMean", TrimPercent: &trimPercent}))
+}
+
+func TestCalculateTrimmedMean(t *testing.T) {
+	assert.Equal(t, int32(5), calculateTrimmedMean([]int32{5}, 49))
+	assert.Equal(t, int32(15), calculateTrimmedMean([]int32{10, 20}, 49))
+	assert.Equal(t, int32(20), calculateTrimmedMean([]int32{10, 20, 90}, 40))
+}
+
 func TestGetCPUUsagePercent(t *testing.T) {
 	pods := []v1.Pod{
 		{
@@ -90,3 +108,69 @@ func TestGetCPUUsagePercent(t *testing.T) {
 	require.Len(t, cpuUsagePercent, 1)
 	require.EqualValues(t, 50, cpuUsagePercent[0])
 }
+
+func TestPodMetricsFromSummary(t *testing.T) {
+	summary := `{
+  "node": {"nodeName": "node1"},
+  "pods": [
+    {"podRef": {"name": "es-data-0", "namespace": "default"}, "containers": [
+      {"name": "elasticsearch", "cpu": {"usageNanoCores": 250000000}},
+      {"name": "starting"}
+    ]},
+    {"podRef": {"name": "other", "namespace": "kube-system"}, "containers": [
+      {"name": "other", "cpu": {"usageNanoCores": 1000000000}}
+    ]}
+  ]
+}`
+	metrics, err := podMetricsFromSummary([]byte(summary), "default")
+	require.NoError(t, err)
+	require.Len(t, metrics, 1)
+	require.Equal(t, "es-data-0", metrics[0].Name)
+	require.Len(t, metrics[0].Containers, 1)
+	require.Equal(t, int64(250), metrics[0].Containers[0].Usage.Cpu().MilliValue())
+
+	_, err = podMetricsFromSummary([]byte("invalid"), "default")
+	require.Error(t, err)
+}
+
+func TestMetricSamples(t *testing.T) {
+	pod := func(name, node, request string) v1.Pod {
+		return v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: v1.PodSpec{
+				NodeName: node,
+				Containers: []v1.Container{
+					{
+						Name: "elasticsearch",
+						Resources: v1.ResourceRequirements{
+							Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse(request)},
+						},
+					},
+				},
+			},
+		}
+	}
+	usage := func(name, cpu string) v1beta1.PodMetrics {
+		return v1beta1.PodMetrics{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Containers: []v1beta1.ContainerMetrics{
+				{Name: "elasticsearch", Usage: v1.ResourceList{v1.ResourceCPU: resource.MustParse(cpu)}},
+			},
+		}
+	}
+
+	pods := []v1.Pod{pod("es-1", "node-b", "2"), pod("es-0", "node-a", "1"), pod("es-2", "", "1")}
+	metrics := []v1beta1.PodMetrics{usage("es-1", "500m"), usage("es-0", "800m")}
+	now := metav1.Now()
+	samples, capacity := metricSamples(podCPUUsages(metrics, pods), map[string]map[string]string{
+		"node-a": {v1.LabelArchStable: "arm64"},
+	}, zv1.MetricSourceKubelet, now)
+
+	require.Equal(t, []zv1.ElasticsearchMetricSample{
+		{Timestamp: now, Value: 80, Source: zv1.MetricSourceKubelet, Pod: "es-0", Node: "node-a", Labels: map[string]string{v1.LabelArchStable: "arm64"}},
+		{Timestamp: now, Value: 25, Source: zv1.MetricSourceKubelet, Pod: "es-1", Node: "node-b"},
+	}, samples)
+	require.EqualValues(t, 2, capacity.Pods)
+	require.Equal(t, "3", capacity.RequestedCPU.String())
+	require.Equal(t, "1300m", capacity.UsedCPU.String())
+}
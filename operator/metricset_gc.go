@@ -0,0 +1,125 @@
+package operator
+
+import (
+	"context"
+	"time"
+
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// metricSetGCInterval is the interval between the checks for orphaned
+// metric sets.
+const metricSetGCInterval = 10 * time.Minute
+
+// metricSetOwnerReference returns the owner reference of the metric set of
+// the EDS.
+func metricSetOwnerReference(eds *zv1.ElasticsearchDataSet) metav1.OwnerReference {
+	return metav1.OwnerReference{
+		APIVersion: "zalando.org/v1",
+		Kind:       "ElasticsearchDataSet",
+		Name:       eds.Name,
+		UID:        eds.UID,
+	}
+}
+
+// orphanedMetricSets sorts the metric sets into the ones owned by an EDS
+// that doesn't exist anymore and the ones without an owner reference that
+// can be adopted by the EDS of the same name. Metric sets without an owner
+// reference and without an EDS of the same name are orphaned as well.
+func orphanedMetricSets(metricSets []zv1.ElasticsearchMetricSet, edss []zv1.ElasticsearchDataSet) ([]zv1.ElasticsearchMetricSet, map[*zv1.ElasticsearchMetricSet]*zv1.ElasticsearchDataSet) {
+	byUID := make(map[types.UID]struct{}, len(edss))
+	byName := make(map[string]*zv1.ElasticsearchDataSet, len(edss))
+	for i, eds := range edss {
+		byUID[eds.UID] = struct{}{}
+		byName[eds.Namespace+"/"+eds.Name] = &edss[i]
+	}
+
+	var orphaned []zv1.ElasticsearchMetricSet
+	adoptable := make(map[*zv1.ElasticsearchMetricSet]*zv1.ElasticsearchDataSet)
+	for i, ms := range metricSets {
+		if len(ms.OwnerReferences) == 0 {
+			if eds, ok := byName[ms.Namespace+"/"+ms.Name]; ok {
+				adoptable[&metricSets[i]] = eds
+				continue
+			}
+			orphaned = append(orphaned, ms)
+			continue
+		}
+
+		owned := false
+		for _, ref := range ms.OwnerReferences {
+			if _, ok := byUID[ref.UID]; ok {
+				owned = true
+				break
+			}
+		}
+		if !owned {
+			orphaned = append(orphaned, ms)
+		}
+	}
+	return orphaned, adoptable
+}
+
+// runMetricSetGC deletes the metric sets of deleted EDS at an interval and
+// adds owner references to metric sets created without one. The Kubernetes
+// garbage collector deletes metric sets stored as resources with an owner
+// reference once their EDS is deleted, this covers metric sets it doesn't,
+// e.g. because their owner reference is missing or incomplete or they are
+// kept in memory.
+func (o *ElasticsearchOperator) runMetricSetGC(ctx context.Context) {
+	nextCheck := time.Now().Add(-metricSetGCInterval)
+
+	for {
+		o.logger.Debug("Checking for orphaned metric sets")
+		select {
+		case <-time.After(time.Until(nextCheck)):
+			nextCheck = time.Now().Add(metricSetGCInterval)
+
+			err := o.collectMetricSetGarbage(ctx)
+			if err != nil {
+				o.logger.Errorf("Failed to delete orphaned metric sets: %v", err)
+			}
+		case <-ctx.Done():
+			o.logger.Info("Terminating metric set GC loop.")
+			return
+		}
+	}
+}
+
+// collectMetricSetGarbage deletes the orphaned metric sets and adopts the
+// metric sets without an owner reference. The metric sets are listed before
+// the EDS, so the owner of every listed metric set is listed as well unless
+// it was deleted.
+func (o *ElasticsearchOperator) collectMetricSetGarbage(ctx context.Context) error {
+	metricSets, err := o.metricStore.List(ctx, o.namespace)
+	if err != nil {
+		return err
+	}
+	edss, err := o.kube.ZalandoV1().ElasticsearchDataSets(o.namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+
+	orphaned, adoptable := orphanedMetricSets(metricSets, edss.Items)
+	for _, ms := range orphaned {
+		err := o.metricStore.Delete(ctx, &ms)
+		if err != nil && !isMetricSetGone(err) {
+			o.logger.Errorf("Failed to delete orphaned metric set %s/%s: %v", ms.Namespace, ms.Name, err)
+			continue
+		}
+		o.logger.Infof("Deleted orphaned metric set %s/%s", ms.Namespace, ms.Name)
+	}
+
+	for ms, eds := range adoptable {
+		ms.OwnerReferences = []metav1.OwnerReference{metricSetOwnerReference(eds)}
+		err := o.metricStore.Store(ctx, ms)
+		if err != nil {
+			o.logger.Errorf("Failed to add owner reference to metric set %s/%s: %v", ms.Namespace, ms.Name, err)
+			continue
+		}
+		o.logger.Infof("Added owner reference of EDS %s/%s to metric set", eds.Namespace, eds.Name)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package operator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestOrphanedMetricSets(t *testing.T) {
+	eds := func(name string, uid types.UID) zv1.ElasticsearchDataSet {
+		return zv1.ElasticsearchDataSet{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", UID: uid}}
+	}
+	metricSet := func(name string, owners ...types.UID) zv1.ElasticsearchMetricSet {
+		ms := zv1.ElasticsearchMetricSet{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"}}
+		for _, uid := range owners {
+			ms.OwnerReferences = append(ms.OwnerReferences, metav1.OwnerReference{Name: name, UID: uid})
+		}
+		return ms
+	}
+
+	edss := []zv1.ElasticsearchDataSet{eds("logs", "logs-2"), eds("metrics", "metrics-1")}
+	metricSets := []zv1.ElasticsearchMetricSet{
+		metricSet("metrics", "metrics-1"),
+		// the EDS was deleted and recreated.
+		metricSet("logs", "logs-1"),
+		metricSet("deleted", "deleted-1"),
+		metricSet("metrics-legacy"),
+		metricSet("metrics"),
+	}
+
+	orphaned, adoptable := orphanedMetricSets(metricSets, edss)
+	names := make([]string, 0, len(orphaned))
+	for _, ms := range orphaned {
+		names = append(names, ms.Name)
+	}
+	require.Equal(t, []string{"logs", "deleted", "metrics-legacy"}, names)
+	require.Len(t, adoptable, 1)
+	for ms, owner := range adoptable {
+		require.Equal(t, "metrics", ms.Name)
+		require.Equal(t, types.UID("metrics-1"), owner.UID)
+		require.Equal(t, types.UID("metrics-1"), metricSetOwnerReference(owner).UID)
+		require.Equal(t, "ElasticsearchDataSet", metricSetOwnerReference(owner).Kind)
+	}
+}
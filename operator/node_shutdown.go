@@ -0,0 +1,390 @@
+package operator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	nodeShutdownTypeRemove  = "remove"
+	nodeShutdownTypeRestart = "restart"
+
+	nodeShutdownStatusComplete = "COMPLETE"
+	nodeShutdownStatusStalled  = "STALLED"
+
+	// nodeShutdownReasonPrefix identifies node shutdowns registered by the
+	// operator, only those are cleaned up.
+	nodeShutdownReasonPrefix = "es-operator:"
+
+	// nodeShutdownMinimumMajorVersion and nodeShutdownMinimumMinorVersion
+	// are the first version of Elasticsearch with the node shutdown API.
+	nodeShutdownMinimumMajorVersion = 7
+	nodeShutdownMinimumMinorVersion = 14
+
+	// nodeShutdownRestartTimeout is the time after which the restart
+	// registration of a node which didn't rejoin the cluster is removed.
+	nodeShutdownRestartTimeout = time.Hour
+)
+
+// esNodeInfo represents a node from the response of _nodes/_all/jvm.
+type esNodeInfo struct {
+	IP  string `json:"ip"`
+	JVM struct {
+		StartTimeInMillis int64 `json:"start_time_in_millis"`
+	} `json:"jvm"`
+}
+
+// esNodeShutdown represents a node shutdown from the response of
+// _nodes/shutdown.
+type esNodeShutdown struct {
+	NodeID             string `json:"node_id"`
+	Type               string `json:"type"`
+	Reason             string `json:"reason"`
+	Status             string `json:"status"`
+	StartedAtMillis    int64  `json:"shutdown_startedmillis"`
+	ShardMigrationInfo struct {
+		ShardsRemaining int    `json:"shard_migrations_remaining"`
+		Explanation     string `json:"explanation"`
+	} `json:"shard_migration"`
+}
+
+type esNodeShutdowns struct {
+	Nodes []esNodeShutdown `json:"nodes"`
+}
+
+// GetMajorVersion returns the major version of Elasticsearch.
+func (c *ESClient) GetMajorVersion() (int, error) {
+	info, err := c.getInfo()
+	if err != nil {
+		return 0, err
+	}
+	return info.majorVersion()
+}
+
+// nodeShutdownEnabled returns true if nodes should be drained using the node
+// shutdown API, which OpenSearch and Elasticsearch before 7.14 don't
+// provide. Nodes of these are drained with allocation exclusions.
+func (c *ESClient) nodeShutdownEnabled() (bool, error) {
+	if c.DrainingConfig == nil || !c.DrainingConfig.NodeShutdown {
+		return false, nil
+	}
+
+	openSearch, err := c.isOpenSearch()
+	if err != nil {
+		return false, err
+	}
+	if openSearch {
+		return false, nil
+	}
+
+	info, err := c.getInfo()
+	if err != nil {
+		return false, fmt.Errorf("failed to get Elasticsearch version: %v", err)
+	}
+	return info.atLeast(nodeShutdownMinimumMajorVersion, nodeShutdownMinimumMinorVersion)
+}
+
+// getNodeInfos returns the nodes of the cluster by node ID.
+func (c *ESClient) getNodeInfos() (map[string]esNodeInfo, error) {
+	resp, err := c.httpClient().R().
+		Get(c.Endpoint.String() + "/_nodes/_all/jvm")
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, fmt.Errorf("code status %d - %s", resp.StatusCode(), resp.Body())
+	}
+
+	var nodes struct {
+		Nodes map[string]esNodeInfo `json:"nodes"`
+	}
+	err = json.Unmarshal(resp.Body(), &nodes)
+	if err != nil {
+		return nil, err
+	}
+	return nodes.Nodes, nil
+}
+
+// getNodeID returns the ID of the node with the IP.
+func (c *ESClient) getNodeID(ip string) (string, error) {
+	nodes, err := c.getNodeInfos()
+	if err != nil {
+		return "", err
+	}
+
+	for id, node := range nodes {
+		if node.IP == ip {
+			return id, nil
+		}
+	}
+	return "", fmt.Errorf("no node with IP %s found", ip)
+}
+
+// getNodeShutdowns returns the registered node shutdowns.
+func (c *ESClient) getNodeShutdowns() ([]esNodeShutdown, error) {
+	resp, err := c.httpClient().R().
+		Get(c.Endpoint.String() + "/_nodes/shutdown")
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, fmt.Errorf("code status %d - %s", resp.StatusCode(), resp.Body())
+	}
+
+	var shutdowns esNodeShutdowns
+	err = json.Unmarshal(resp.Body(), &shutdowns)
+	if err != nil {
+		return nil, err
+	}
+	return shutdowns.Nodes, nil
+}
+
+// putNodeShutdown registers the shutdown of the node.
+func (c *ESClient) putNodeShutdown(nodeID, shutdownType string, pod *v1.Pod) error {
+	body := map[string]string{
+		"type":   shutdownType,
+		"reason": fmt.Sprintf("%s draining Pod %s/%s", nodeShutdownReasonPrefix, pod.Namespace, pod.Name),
+	}
+
+	resp, err := c.httpClient().R().
+		SetHeader("Content-Type", "application/json").
+		SetBody(body).
+		Put(fmt.Sprintf("%s/_nodes/%s/shutdown", c.Endpoint.String(), nodeID))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return fmt.Errorf("code status %d - %s", resp.StatusCode(), resp.Body())
+	}
+	return nil
+}
+
+// deleteNodeShutdown removes the shutdown registration of the node.
+func (c *ESClient) deleteNodeShutdown(nodeID string) error {
+	resp, err := c.httpClient().R().
+		Delete(fmt.Sprintf("%s/_nodes/%s/shutdown", c.Endpoint.String(), nodeID))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode() != http.StatusOK && resp.StatusCode() != http.StatusNotFound {
+		return fmt.Errorf("code status %d - %s", resp.StatusCode(), resp.Body())
+	}
+	return nil
+}
+
+// shutdownNode registers the shutdown of the node of the pod and waits until
+// Elasticsearch is ready for it. For the 'remove' type this means all shards
+// were moved off the node.
+func (c *ESClient) shutdownNode(ctx context.Context, pod *v1.Pod, shutdownType string) error {
+	nodeID, err := c.getNodeID(pod.Status.PodIP)
+	if err != nil {
+		return err
+	}
+
+	c.logger().Infof("Registering %s shutdown of node %s of pod %s/%s", shutdownType, nodeID, pod.Namespace, pod.Name)
+	err = c.putNodeShutdown(nodeID, shutdownType, pod)
+	if err != nil {
+		return err
+	}
+
+	retryCount := 0
+	// set if the shutdown is aborted because critical indices degraded.
+	var abortErr error
+	// set once the stalled shutdown was reported.
+	stalledReported := false
+	// the tasks blocking the shutdown which were already reported.
+	started, handledTasks := time.Now(), make(map[string]struct{})
+	var block *drainWriteBlock
+	if shutdownType == nodeShutdownTypeRemove {
+		block = newDrainWriteBlock(c.drainWriteBlock)
+	}
+	defer func() {
+		if err := c.removeDrainWriteBlock(block); err != nil {
+			c.logger().Error(err)
+		}
+	}()
+	resp, err := c.httpClient().
+		SetRetryCount(c.DrainingConfig.MaxRetries).
+		SetRetryWaitTime(c.DrainingConfig.MinimumWaitTime).
+		SetRetryMaxWaitTime(c.DrainingConfig.MaximumWaitTime).
+		AddRetryCondition(
+			func(r *resty.Response, err error) bool {
+				retryCount++
+				select {
+				case <-ctx.Done():
+					return false
+				default:
+					if err != nil {
+						log.Warnf("Failed to get shutdown status of node %s: %v. Details: Namespace=%s, PodName=%s, RetryCount=%d.",
+							nodeID, err, pod.Namespace, pod.Name, retryCount)
+						return true
+					}
+					shutdown, err := nodeShutdownStatus(r.Body(), nodeID)
+					if err != nil {
+						log.Warnf("Failed to get shutdown status of node %s: %v. Details: Namespace=%s, PodName=%s, RetryCount=%d.",
+							nodeID, err, pod.Namespace, pod.Name, retryCount)
+						return true
+					}
+					if shutdown.Status == nodeShutdownStatusComplete {
+						c.logger().Infof("Shutdown of node %s of pod %s/%s is %s", nodeID, pod.Namespace, pod.Name, shutdown.Status)
+						return false
+					}
+					c.logger().Infof("Shutdown of node %s of pod %s/%s is %s, %d shards remaining", nodeID, pod.Namespace, pod.Name,
+						shutdown.Status, shutdown.ShardMigrationInfo.ShardsRemaining)
+					if shutdown.Status == nodeShutdownStatusStalled && !stalledReported {
+						stalledReported = true
+						c.event(v1.EventTypeWarning, "DrainStalled", fmt.Sprintf(
+							"Shutdown of node %s of Pod %s/%s is stalled with %d shards remaining: %s", nodeID, pod.Namespace, pod.Name,
+							shutdown.ShardMigrationInfo.ShardsRemaining, shutdown.ShardMigrationInfo.Explanation))
+					}
+
+					if shutdownType == nodeShutdownTypeRemove {
+						if degraded, checkErr := c.criticalIndicesDegraded(); checkErr != nil {
+							log.Warnf("Failed to check health of critical indices: %v. Details: Namespace=%s, PodName=%s, RetryCount=%d.",
+								checkErr, pod.Namespace, pod.Name, retryCount)
+						} else if degraded != nil {
+							abortErr = degraded
+							return false
+						}
+
+						err = c.updateDrainWriteBlock(pod, block, time.Now())
+						if err != nil {
+							log.Warnf("Failed to update write block: %v. Details: Namespace=%s, PodName=%s, RetryCount=%d.",
+								err, pod.Namespace, pod.Name, retryCount)
+						}
+
+						err = c.checkDrainTasks(pod, started, time.Now(), handledTasks)
+						if err != nil {
+							log.Warnf("Failed to check tasks blocking the drain: %v. Details: Namespace=%s, PodName=%s, RetryCount=%d.",
+								err, pod.Namespace, pod.Name, retryCount)
+						}
+					}
+					return true
+				}
+			},
+		).R().
+		Get(fmt.Sprintf("%s/_nodes/%s/shutdown", c.Endpoint.String(), nodeID))
+	if ctx.Err() != nil {
+		// the node keeps running, e.g. the scale-down was aborted, so it
+		// must not be emptied by a leftover registration.
+		c.logger().Warnf("Removing %s shutdown of node %s of pod %s/%s: %v", shutdownType, nodeID, pod.Namespace, pod.Name, ctx.Err())
+		err = c.deleteNodeShutdown(nodeID)
+		if err != nil {
+			return fmt.Errorf("%v, failed to remove shutdown of node %s: %v", ctx.Err(), nodeID, err)
+		}
+		return ctx.Err()
+	}
+	if err != nil {
+		return err
+	}
+
+	if abortErr != nil {
+		c.logger().Warnf("Aborting shutdown of node %s of pod %s/%s: %v", nodeID, pod.Namespace, pod.Name, abortErr)
+		err = c.deleteNodeShutdown(nodeID)
+		if err != nil {
+			return fmt.Errorf("%v, failed to remove shutdown of node %s: %v", abortErr, nodeID, err)
+		}
+		return abortErr
+	}
+
+	shutdown, err := nodeShutdownStatus(resp.Body(), nodeID)
+	if err != nil {
+		return err
+	}
+	if shutdown.Status != nodeShutdownStatusComplete {
+		return fmt.Errorf("shutdown of node %s is %s after %d retries with %d shards remaining", nodeID, shutdown.Status, retryCount,
+			shutdown.ShardMigrationInfo.ShardsRemaining)
+	}
+	return nil
+}
+
+// nodeShutdownStatus returns the shutdown of the node from the response of
+// _nodes/<id>/shutdown.
+func nodeShutdownStatus(body []byte, nodeID string) (*esNodeShutdown, error) {
+	var shutdowns esNodeShutdowns
+	err := json.Unmarshal(body, &shutdowns)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, shutdown := range shutdowns.Nodes {
+		if shutdown.NodeID != nodeID {
+			continue
+		}
+		if shutdown.Status == nodeShutdownStatusStalled {
+			log.Warnf("Shutdown of node %s is stalled: %s", nodeID, shutdown.ShardMigrationInfo.Explanation)
+		}
+		return &shutdowns.Nodes[i], nil
+	}
+	return nil, fmt.Errorf("no shutdown registered for node %s", nodeID)
+}
+
+// PrepareRestart registers a restart of the node of the pod, which lets
+// Elasticsearch delay the reallocation of its shards while the pod is
+// restarted with its data. It's a no-op if the node shutdown API isn't
+// used.
+func (c *ESClient) PrepareRestart(ctx context.Context, pod *v1.Pod) error {
+	if c.dryRun() {
+		return nil
+	}
+	enabled, err := c.nodeShutdownEnabled()
+	if err != nil || !enabled {
+		return err
+	}
+	return c.shutdownNode(ctx, pod, nodeShutdownTypeRestart)
+}
+
+// cleanupNodeShutdowns removes the shutdown registrations of the operator
+// for nodes which left the cluster, or which were restarted after the
+// registration. Nodes with persistent volumes rejoin with the same node ID
+// once their pod was recreated, a leftover 'remove' registration would keep
+// moving all shards off them. A node is absent while it restarts, so its
+// 'restart' registration is kept until it rejoined or
+// nodeShutdownRestartTimeout passed.
+func (c *ESClient) cleanupNodeShutdowns(now time.Time) error {
+	shutdowns, err := c.getNodeShutdowns()
+	if err != nil {
+		return err
+	}
+	if len(shutdowns) == 0 {
+		return nil
+	}
+
+	nodes, err := c.getNodeInfos()
+	if err != nil {
+		return err
+	}
+
+	for _, shutdown := range shutdowns {
+		if !strings.HasPrefix(shutdown.Reason, nodeShutdownReasonPrefix) {
+			continue
+		}
+
+		node, ok := nodes[shutdown.NodeID]
+		switch {
+		case !ok && shutdown.Type == nodeShutdownTypeRestart:
+			if now.Sub(time.UnixMilli(shutdown.StartedAtMillis)) < nodeShutdownRestartTimeout {
+				continue
+			}
+		case !ok:
+		case node.JVM.StartTimeInMillis > shutdown.StartedAtMillis:
+		default:
+			continue
+		}
+
+		c.logger().Infof("Removing %s shutdown of node %s", shutdown.Type, shutdown.NodeID)
+		err := c.deleteNodeShutdown(shutdown.NodeID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
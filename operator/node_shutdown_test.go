@@ -0,0 +1,275 @@
+package operator
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newNodeShutdownClient() *ESClient {
+	esURL, _ := url.Parse("http://elasticsearch:9200")
+	return &ESClient{
+		Endpoint: esURL,
+		DrainingConfig: &DrainingConfig{
+			MaxRetries:      5,
+			MinimumWaitTime: 1 * time.Millisecond,
+			MaximumWaitTime: 3 * time.Millisecond,
+			NodeShutdown:    true,
+		},
+	}
+}
+
+func TestDrainWithNodeShutdown(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_cluster/health",
+		httpmock.NewStringResponder(200, `{"status":"green"}`))
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/",
+		httpmock.NewStringResponder(200, `{"version":{"number":"8.6.2"}}`))
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_nodes/_all/jvm",
+		httpmock.NewStringResponder(200, `{"nodes":{"node-a":{"ip":"1.2.3.4"},"node-b":{"ip":"1.2.3.5"}}}`))
+
+	var shutdownType string
+	httpmock.RegisterResponder("PUT", "http://elasticsearch:9200/_nodes/node-a/shutdown",
+		func(req *http.Request) (*http.Response, error) {
+			body := map[string]string{}
+			err := json.NewDecoder(req.Body).Decode(&body)
+			if err != nil {
+				return nil, err
+			}
+			shutdownType = body["type"]
+			return httpmock.NewStringResponse(200, `{"acknowledged":true}`), nil
+		})
+	responses := []string{
+		`{"nodes":[{"node_id":"node-a","type":"remove","status":"IN_PROGRESS"}]}`,
+		`{"nodes":[{"node_id":"node-a","type":"remove","status":"COMPLETE"}]}`,
+	}
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_nodes/node-a/shutdown",
+		func(req *http.Request) (*http.Response, error) {
+			resp := responses[0]
+			if len(responses) > 1 {
+				responses = responses[1:]
+			}
+			return httpmock.NewStringResponse(200, resp), nil
+		})
+
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "es-data-0", Namespace: "default"},
+		Status:     v1.PodStatus{PodIP: "1.2.3.4"},
+	}
+
+	err := newNodeShutdownClient().Drain(context.Background(), pod)
+	require.NoError(t, err)
+	assert.Equal(t, nodeShutdownTypeRemove, shutdownType)
+	// allocation exclusions aren't used.
+	assert.Equal(t, 0, httpmock.GetCallCountInfo()["PUT http://elasticsearch:9200/_cluster/settings"])
+
+	err = newNodeShutdownClient().PrepareRestart(context.Background(), pod)
+	require.NoError(t, err)
+	assert.Equal(t, nodeShutdownTypeRestart, shutdownType)
+}
+
+func TestNodeShutdownEnabled(t *testing.T) {
+	for _, tc := range []struct {
+		version  string
+		expected bool
+	}{
+		{version: "6.8.23", expected: false},
+		{version: "7.13.4", expected: false},
+		{version: "7.14.0", expected: true},
+		{version: "7.17.3", expected: true},
+		{version: "8.6.2", expected: true},
+		{version: "8.0.0-SNAPSHOT", expected: true},
+	} {
+		t.Run(tc.version, func(t *testing.T) {
+			httpmock.Activate()
+			defer httpmock.DeactivateAndReset()
+			httpmock.RegisterResponder("GET", "http://elasticsearch:9200/",
+				httpmock.NewStringResponder(200, `{"version":{"number":"`+tc.version+`"}}`))
+
+			enabled, err := newNodeShutdownClient().nodeShutdownEnabled()
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, enabled)
+		})
+	}
+}
+
+func TestDrainWithStalledNodeShutdown(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_cluster/health",
+		httpmock.NewStringResponder(200, `{"status":"green"}`))
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/",
+		httpmock.NewStringResponder(200, `{"version":{"number":"7.17.3"}}`))
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_nodes/_all/jvm",
+		httpmock.NewStringResponder(200, `{"nodes":{"node-a":{"ip":"1.2.3.4"}}}`))
+	httpmock.RegisterResponder("PUT", "http://elasticsearch:9200/_nodes/node-a/shutdown",
+		httpmock.NewStringResponder(200, `{"acknowledged":true}`))
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_nodes/node-a/shutdown",
+		httpmock.NewStringResponder(200, `{"nodes":[{"node_id":"node-a","type":"remove","status":"STALLED",
+			"shard_migration":{"shard_migrations_remaining":3,"explanation":"no other node can hold the shards"}}]}`))
+
+	var events []string
+	client := newNodeShutdownClient()
+	client.recordEvent = func(eventType, reason, message string) {
+		assert.Equal(t, v1.EventTypeWarning, eventType)
+		events = append(events, reason+": "+message)
+	}
+	err := client.Drain(context.Background(), &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "es-data-0", Namespace: "default"},
+		Status:     v1.PodStatus{PodIP: "1.2.3.4"},
+	})
+	require.EqualError(t, err, "shutdown of node node-a is STALLED after 6 retries with 3 shards remaining")
+	// the stalled shutdown is only reported once.
+	assert.Equal(t, []string{"DrainStalled: Shutdown of node node-a of Pod default/es-data-0 is stalled with 3 shards remaining: no other node can hold the shards"}, events)
+}
+
+func TestPrepareRestartBeforeES714(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/",
+		httpmock.NewStringResponder(200, `{"version":{"number":"7.13.4"}}`))
+
+	err := newNodeShutdownClient().PrepareRestart(context.Background(), &v1.Pod{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, httpmock.GetTotalCallCount())
+}
+
+func TestPrepareRestartOpenSearch(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	// OpenSearch doesn't provide the node shutdown API.
+	client := newNodeShutdownClient()
+	client.distribution = distributionOpenSearch
+	err := client.PrepareRestart(context.Background(), &v1.Pod{})
+	require.NoError(t, err)
+	assert.Equal(t, 0, httpmock.GetTotalCallCount())
+}
+
+func TestCleanupNodeShutdowns(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_nodes/shutdown",
+		httpmock.NewStringResponder(200, `{"nodes":[
+			{"node_id":"removed","type":"remove","reason":"es-operator: draining Pod default/a","shutdown_startedmillis":1000},
+			{"node_id":"draining","type":"remove","reason":"es-operator: draining Pod default/b","shutdown_startedmillis":1000},
+			{"node_id":"restarted","type":"restart","reason":"es-operator: draining Pod default/c","shutdown_startedmillis":1000},
+			{"node_id":"restarting","type":"restart","reason":"es-operator: draining Pod default/d","shutdown_startedmillis":1000},
+			{"node_id":"rejoined","type":"remove","reason":"es-operator: draining Pod default/e","shutdown_startedmillis":1000},
+			{"node_id":"absent","type":"restart","reason":"es-operator: draining Pod default/f","shutdown_startedmillis":9000000},
+			{"node_id":"expired","type":"restart","reason":"es-operator: draining Pod default/g","shutdown_startedmillis":1000},
+			{"node_id":"manual","type":"remove","reason":"maintenance","shutdown_startedmillis":1000}
+		]}`))
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_nodes/_all/jvm",
+		httpmock.NewStringResponder(200, `{"nodes":{
+			"draining":{"ip":"1.2.3.4","jvm":{"start_time_in_millis":500}},
+			"restarted":{"ip":"1.2.3.5","jvm":{"start_time_in_millis":2000}},
+			"restarting":{"ip":"1.2.3.6","jvm":{"start_time_in_millis":500}},
+			"rejoined":{"ip":"1.2.3.7","jvm":{"start_time_in_millis":2000}}
+		}}`))
+	for _, node := range []string{"removed", "draining", "restarted", "restarting", "rejoined", "absent", "expired", "manual"} {
+		httpmock.RegisterResponder("DELETE", "http://elasticsearch:9200/_nodes/"+node+"/shutdown",
+			httpmock.NewStringResponder(200, `{"acknowledged":true}`))
+	}
+
+	err := newNodeShutdownClient().cleanupNodeShutdowns(time.UnixMilli(10000000))
+	require.NoError(t, err)
+
+	calls := httpmock.GetCallCountInfo()
+	assert.Equal(t, 1, calls["DELETE http://elasticsearch:9200/_nodes/removed/shutdown"])
+	assert.Equal(t, 1, calls["DELETE http://elasticsearch:9200/_nodes/restarted/shutdown"])
+	// a pod with persistent volumes rejoins with the same node ID.
+	assert.Equal(t, 1, calls["DELETE http://elasticsearch:9200/_nodes/rejoined/shutdown"])
+	assert.Equal(t, 0, calls["DELETE http://elasticsearch:9200/_nodes/draining/shutdown"])
+	assert.Equal(t, 0, calls["DELETE http://elasticsearch:9200/_nodes/restarting/shutdown"])
+	// a restarting node is absent until its pod was recreated.
+	assert.Equal(t, 0, calls["DELETE http://elasticsearch:9200/_nodes/absent/shutdown"])
+	assert.Equal(t, 1, calls["DELETE http://elasticsearch:9200/_nodes/expired/shutdown"])
+	assert.Equal(t, 0, calls["DELETE http://elasticsearch:9200/_nodes/manual/shutdown"])
+}
+
+func TestDrainWithNodeShutdownCancelled(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_cluster/health",
+		httpmock.NewStringResponder(200, `{"status":"green"}`))
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/",
+		httpmock.NewStringResponder(200, `{"version":{"number":"8.6.2"}}`))
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_nodes/_all/jvm",
+		httpmock.NewStringResponder(200, `{"nodes":{"node-a":{"ip":"1.2.3.4"}}}`))
+	httpmock.RegisterResponder("PUT", "http://elasticsearch:9200/_nodes/node-a/shutdown",
+		httpmock.NewStringResponder(200, `{"acknowledged":true}`))
+	httpmock.RegisterResponder("DELETE", "http://elasticsearch:9200/_nodes/node-a/shutdown",
+		httpmock.NewStringResponder(200, `{"acknowledged":true}`))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_nodes/node-a/shutdown",
+		func(req *http.Request) (*http.Response, error) {
+			cancel()
+			return httpmock.NewStringResponse(200, `{"nodes":[{"node_id":"node-a","type":"remove","status":"IN_PROGRESS"}]}`), nil
+		})
+
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "es-data-0", Namespace: "default"},
+		Status:     v1.PodStatus{PodIP: "1.2.3.4"},
+	}
+
+	err := newNodeShutdownClient().Drain(ctx, pod)
+	require.ErrorIs(t, err, context.Canceled)
+	// the node keeps running and must not be emptied.
+	assert.Equal(t, 1, httpmock.GetCallCountInfo()["DELETE http://elasticsearch:9200/_nodes/node-a/shutdown"])
+}
+
+func TestSkipDrainingPrepareRestart(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/",
+		httpmock.NewStringResponder(200, `{"version":{"number":"8.6.2"}}`))
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_nodes/_all/jvm",
+		httpmock.NewStringResponder(200, `{"nodes":{"node-a":{"ip":"1.2.3.4"}}}`))
+	httpmock.RegisterResponder("PUT", "http://elasticsearch:9200/_nodes/node-a/shutdown",
+		httpmock.NewStringResponder(200, `{"acknowledged":true}`))
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_nodes/node-a/shutdown",
+		httpmock.NewStringResponder(200, `{"nodes":[{"node_id":"node-a","type":"restart","status":"COMPLETE"}]}`))
+
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "es-data-0", Namespace: "default"},
+		Status:     v1.PodStatus{PodIP: "1.2.3.4"},
+	}
+	r := &EDSResource{
+		eds:      &zv1.ElasticsearchDataSet{Spec: zv1.ElasticsearchDataSetSpec{SkipDraining: true}},
+		esClient: newNodeShutdownClient(),
+	}
+
+	// pods removed by a scale-down aren't touched.
+	require.NoError(t, r.Drain(context.Background(), pod))
+	assert.Equal(t, 0, httpmock.GetTotalCallCount())
+
+	// pods recreated by a rolling update are restarted.
+	require.NoError(t, r.PrepareRestart(context.Background(), pod))
+	assert.Equal(t, 1, httpmock.GetCallCountInfo()["PUT http://elasticsearch:9200/_nodes/node-a/shutdown"])
+
+	// drained pods don't need a restart registration.
+	httpmock.ZeroCallCounters()
+	r.eds.Spec.SkipDraining = false
+	require.NoError(t, r.PrepareRestart(context.Background(), pod))
+	assert.Equal(t, 0, httpmock.GetTotalCallCount())
+}
@@ -0,0 +1,100 @@
+package operator
+
+import (
+	"fmt"
+
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	oomKilledReason                  = "OOMKilled"
+	defaultMemoryAdjustmentThreshold = 3
+)
+
+// countOOMKills counts the containers of the pods which were OOMKilled after
+// the since time. It returns the number of OOM kills and the time of the
+// latest OOM kill.
+func countOOMKills(pods []*v1.Pod, since *metav1.Time) (int32, *metav1.Time) {
+	count := int32(0)
+	latest := since
+	for _, pod := range pods {
+		for _, statuses := range [][]v1.ContainerStatus{pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses} {
+			for _, status := range statuses {
+				for _, terminated := range []*v1.ContainerStateTerminated{status.State.Terminated, status.LastTerminationState.Terminated} {
+					if terminated == nil || terminated.Reason != oomKilledReason {
+						continue
+					}
+
+					if since != nil && !terminated.FinishedAt.After(since.Time) {
+						continue
+					}
+
+					count++
+					if latest == nil || terminated.FinishedAt.After(latest.Time) {
+						finishedAt := terminated.FinishedAt
+						latest = &finishedAt
+					}
+				}
+			}
+		}
+	}
+	return count, latest
+}
+
+// memoryAdjustmentDue returns true if the EDS had enough OOM kills to
+// increase the memory of its pods.
+func memoryAdjustmentDue(adjustment *zv1.ElasticsearchDataSetMemoryAdjustment, oomKills int32) bool {
+	if adjustment == nil || !adjustment.Enabled {
+		return false
+	}
+
+	threshold := adjustment.OOMKillThreshold
+	if threshold <= 0 {
+		threshold = defaultMemoryAdjustmentThreshold
+	}
+	return oomKills >= threshold
+}
+
+// increaseContainerMemory increases the memory requests and limits of the
+// container by step up to max. It returns the new memory or nil if the
+// memory could not be increased, because it's not defined for the
+// container or is already at max.
+func increaseContainerMemory(spec *v1.PodSpec, containerName string, step, max resource.Quantity) (*resource.Quantity, error) {
+	if step.Sign() <= 0 {
+		return nil, fmt.Errorf("memory step must be positive, got %s", step.String())
+	}
+
+	for i, container := range spec.Containers {
+		if container.Name != containerName {
+			continue
+		}
+
+		current, ok := container.Resources.Requests[v1.ResourceMemory]
+		if limit, hasLimit := container.Resources.Limits[v1.ResourceMemory]; hasLimit && (!ok || limit.Cmp(current) > 0) {
+			current, ok = limit, true
+		}
+		if !ok || current.Cmp(max) >= 0 {
+			return nil, nil
+		}
+
+		memory := current.DeepCopy()
+		memory.Add(step)
+		if memory.Cmp(max) > 0 {
+			memory = max.DeepCopy()
+		}
+
+		resources := &spec.Containers[i].Resources
+		if resources.Requests == nil {
+			resources.Requests = v1.ResourceList{}
+		}
+		resources.Requests[v1.ResourceMemory] = memory
+		if _, ok := resources.Limits[v1.ResourceMemory]; ok {
+			resources.Limits[v1.ResourceMemory] = memory
+		}
+		return &memory, nil
+	}
+	return nil, nil
+}
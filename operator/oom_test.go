@@ -0,0 +1,118 @@
+package operator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCountOOMKills(t *testing.T) {
+	now := time.Now()
+	oomKilledAt := func(at time.Time) v1.ContainerState {
+		return v1.ContainerState{
+			Terminated: &v1.ContainerStateTerminated{
+				Reason:     oomKilledReason,
+				FinishedAt: metav1.NewTime(at),
+			},
+		}
+	}
+
+	pods := []*v1.Pod{
+		{
+			Status: v1.PodStatus{
+				ContainerStatuses: []v1.ContainerStatus{
+					{LastTerminationState: oomKilledAt(now.Add(-time.Hour))},
+				},
+			},
+		},
+		{
+			Status: v1.PodStatus{
+				ContainerStatuses: []v1.ContainerStatus{
+					{State: oomKilledAt(now.Add(-time.Minute))},
+				},
+			},
+		},
+		{
+			Status: v1.PodStatus{
+				ContainerStatuses: []v1.ContainerStatus{
+					{
+						LastTerminationState: v1.ContainerState{
+							Terminated: &v1.ContainerStateTerminated{Reason: "Error", FinishedAt: metav1.NewTime(now)},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	count, latest := countOOMKills(pods, nil)
+	assert.EqualValues(t, 2, count)
+	assert.Equal(t, now.Add(-time.Minute).Unix(), latest.Unix())
+
+	// only OOM kills after the last observed one are counted.
+	since := metav1.NewTime(now.Add(-30 * time.Minute))
+	count, latest = countOOMKills(pods, &since)
+	assert.EqualValues(t, 1, count)
+	assert.Equal(t, now.Add(-time.Minute).Unix(), latest.Unix())
+
+	count, latest = countOOMKills(pods, latest)
+	assert.EqualValues(t, 0, count)
+	assert.Equal(t, now.Add(-time.Minute).Unix(), latest.Unix())
+}
+
+func TestMemoryAdjustmentDue(t *testing.T) {
+	assert.False(t, memoryAdjustmentDue(nil, 10))
+	assert.False(t, memoryAdjustmentDue(&zv1.ElasticsearchDataSetMemoryAdjustment{}, 10))
+	assert.False(t, memoryAdjustmentDue(&zv1.ElasticsearchDataSetMemoryAdjustment{Enabled: true}, 2))
+	assert.True(t, memoryAdjustmentDue(&zv1.ElasticsearchDataSetMemoryAdjustment{Enabled: true}, 3))
+	assert.True(t, memoryAdjustmentDue(&zv1.ElasticsearchDataSetMemoryAdjustment{Enabled: true, OOMKillThreshold: 1}, 1))
+}
+
+func TestIncreaseContainerMemory(t *testing.T) {
+	spec := &v1.PodSpec{
+		Containers: []v1.Container{
+			{Name: "sidecar"},
+			{
+				Name: "elasticsearch",
+				Resources: v1.ResourceRequirements{
+					Requests: v1.ResourceList{v1.ResourceMemory: resource.MustParse("4Gi")},
+					Limits:   v1.ResourceList{v1.ResourceMemory: resource.MustParse("4Gi")},
+				},
+			},
+		},
+	}
+	step := resource.MustParse("1Gi")
+	max := resource.MustParse("5500Mi")
+
+	memory, err := increaseContainerMemory(spec, "elasticsearch", step, max)
+	require.NoError(t, err)
+	require.NotNil(t, memory)
+	assert.Equal(t, "5Gi", memory.String())
+	assert.Equal(t, "5Gi", spec.Containers[1].Resources.Requests.Memory().String())
+	assert.Equal(t, "5Gi", spec.Containers[1].Resources.Limits.Memory().String())
+
+	// capped at max.
+	memory, err = increaseContainerMemory(spec, "elasticsearch", step, max)
+	require.NoError(t, err)
+	require.NotNil(t, memory)
+	assert.Equal(t, "5500Mi", spec.Containers[1].Resources.Limits.Memory().String())
+
+	// no change once max is reached.
+	memory, err = increaseContainerMemory(spec, "elasticsearch", step, max)
+	require.NoError(t, err)
+	assert.Nil(t, memory)
+
+	// containers without memory are not adjusted.
+	memory, err = increaseContainerMemory(spec, "sidecar", step, max)
+	require.NoError(t, err)
+	assert.Nil(t, memory)
+
+	_, err = increaseContainerMemory(spec, "elasticsearch", resource.MustParse("0"), max)
+	assert.Error(t, err)
+}
@@ -2,10 +2,12 @@ package operator
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"k8s.io/apimachinery/pkg/runtime"
@@ -15,12 +17,13 @@ import (
 	"github.com/zalando-incubator/es-operator/pkg/clientset"
 	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/api/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	informersv1 "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
 	kube_record "k8s.io/client-go/tools/record"
 )
 
@@ -59,8 +62,21 @@ type StatefulResource interface {
 	Labels() map[string]string
 	// LabelSelector returns a set of labels to be used for label selecting.
 	LabelSelector() map[string]string
+	// AdditionalMetadata returns the labels and annotations added to the
+	// StatefulSet and the pods of the resource.
+	AdditionalMetadata() (map[string]string, map[string]string)
+	// ServingGate returns the readiness gate controlling whether the pods
+	// receive client traffic. Nil means pods aren't steered.
+	ServingGate() *ServingGate
 	// Replicas returns the desired replicas of the resource.
 	Replicas() int32
+	// MaxFailingPods returns the number of failing pods above which
+	// rolling updates are paused. Nil means rolling updates are never
+	// paused.
+	MaxFailingPods() *int32
+	// RolloutPaused returns true if Pods of an old revision must not be
+	// updated.
+	RolloutPaused() bool
 	// PodTemplateSpec returns the pod template spec of the resource. This
 	// is added to the underlying StatefulSet.
 	PodTemplateSpec() *v1.PodTemplateSpec
@@ -73,10 +89,15 @@ type StatefulResource interface {
 	// EnsureResources
 	EnsureResources(ctx context.Context) error
 
+	// OnObservedHook is triggered once the statefulset and pods of the
+	// resource are observed, before the status is updated. It performs
+	// the actions depending on their state, e.g. rollbacks.
+	OnObservedHook(ctx context.Context, sts *appsv1.StatefulSet, pods []*v1.Pod) error
+
 	// UpdateStatus updates the status of the StatefulResource. The
-	// statefulset is parsed to provide additional information like
-	// replicas to the status.
-	UpdateStatus(ctx context.Context, sts *appsv1.StatefulSet) error
+	// statefulset and pods are parsed to provide additional information
+	// like replicas and failing pods to the status.
+	UpdateStatus(ctx context.Context, sts *appsv1.StatefulSet, pods []*v1.Pod) error
 
 	// PreScaleDownHook is triggered when a scaledown is to be performed.
 	// It's ensured that the hook will be triggered at least once, but it
@@ -84,6 +105,10 @@ type StatefulResource interface {
 	// stage and has to be retried.
 	PreScaleDownHook(ctx context.Context) error
 
+	// PreScaleUpHook is triggered before the StatefulSet is scaled up to
+	// the replicas.
+	PreScaleUpHook(ctx context.Context, sts *appsv1.StatefulSet, replicas int32) error
+
 	// OnStableReplicasHook is triggered when the statefulSet is observed
 	// to be stable meaning readyReplicas == desiredReplicas.
 	// This hook can for instance be used to perform cleanup tasks.
@@ -92,6 +117,28 @@ type StatefulResource interface {
 	// Drain drains a pod for data. It's expected that the method only
 	// returns after the pod has been drained.
 	Drain(ctx context.Context, pod *v1.Pod) error
+
+	// PrepareRestart is called after Drain when a pod is recreated during
+	// a rolling update.
+	PrepareRestart(ctx context.Context, pod *v1.Pod) error
+
+	// MaxParallelDrains returns the maximum number of pods drained at the
+	// same time when scaling down by more than one replica.
+	MaxParallelDrains() int
+
+	// ClusterStable returns false and the reason if the cluster is too
+	// unstable for disruptive operations like draining pods, or if it must
+	// not be disrupted, e.g. while it's backed up.
+	ClusterStable(ctx context.Context) (bool, string)
+
+	// TerminationGracePeriod returns the termination grace period used
+	// when deleting the pod. It's called before the pod is drained.
+	TerminationGracePeriod(ctx context.Context, pod *v1.Pod) *int64
+
+	// PublishAddresses publishes the IPs of the pods serving clients, e.g.
+	// as a DNS record for clients outside of the cluster. It's called
+	// before pods are drained, without them.
+	PublishAddresses(ctx context.Context, addresses []string) error
 }
 
 // Operator is a generic operator that can manage Pods filtered by a selector.
@@ -100,9 +147,22 @@ type Operator struct {
 	podInformer           informersv1.PodInformer
 	nodeInformer          informersv1.NodeInformer
 	priorityNodeSelectors labels.Set
+	imageRewriteRules     ImageRewriteRules
+	imageResolver         ImageResolver
 	interval              time.Duration
 	logger                *log.Entry
 	recorder              kube_record.EventRecorder
+	// drainingPause is shared by the Operators of a resource, which are
+	// recreated on every change of it, such that pausing draining is only
+	// reported once. It's kept in memory only, a pause is reported again
+	// after the es-operator process restarted.
+	drainingPause *drainingPause
+}
+
+// drainingPause remembers whether draining the pods of a resource is paused
+// because the cluster is unstable.
+type drainingPause struct {
+	paused bool
 }
 
 func (o *Operator) Run(ctx context.Context, done chan<- struct{}, srg StatefulResourceGetter) {
@@ -143,7 +203,28 @@ func (o *Operator) operate(ctx context.Context, srg StatefulResourceGetter) erro
 		return fmt.Errorf("failed to reconcile StatefulSet: %v", err)
 	}
 
-	err = sr.UpdateStatus(ctx, sts)
+	pods, err := o.podInformer.Lister().Pods(sr.Namespace()).List(labels.Set(sr.LabelSelector()).AsSelector())
+	if err != nil {
+		return fmt.Errorf("failed to list pods of StatefulSet: %v", err)
+	}
+
+	err = o.propagateAdditionalMetadata(ctx, sr, pods)
+	if err != nil {
+		return err
+	}
+	o.updateServingConditions(ctx, sr, pods, time.Now())
+
+	err = sr.PublishAddresses(ctx, servingAddresses(pods))
+	if err != nil {
+		return fmt.Errorf("failed to publish addresses: %v", err)
+	}
+
+	err = sr.OnObservedHook(ctx, sts, pods)
+	if err != nil {
+		return fmt.Errorf("failed to run the observed hook: %v", err)
+	}
+
+	err = sr.UpdateStatus(ctx, sts, pods)
 	if err != nil {
 		return fmt.Errorf("failed to update status: %v", err)
 	}
@@ -163,7 +244,7 @@ func (o *Operator) reconcileStatefulset(ctx context.Context, srg StatefulResourc
 
 	sts, err = o.kube.AppsV1().StatefulSets(sr.Namespace()).Get(ctx, sr.Name(), metav1.GetOptions{})
 	if err != nil {
-		if !errors.IsNotFound(err) {
+		if !apierrors.IsNotFound(err) {
 			return nil, err
 		}
 		sts = nil
@@ -179,19 +260,85 @@ func (o *Operator) reconcileStatefulset(ctx context.Context, srg StatefulResourc
 		)
 	}
 
+	createStatefulSet := sts == nil
+	var previous *v1.PodSpec
+	if sts != nil {
+		previous = sts.Spec.Template.Spec.DeepCopy()
+	}
+	sts = renderStatefulSet(sr, sts, o.imageRewriteRules)
+
+	if createStatefulSet {
+		err := o.pinImageDigests(ctx, sts, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		sts, err = o.kube.AppsV1().StatefulSets(sts.Namespace).Create(ctx, sts, metav1.CreateOptions{})
+		if err != nil {
+			return nil, err
+		}
+		o.recorder.Event(sr.Self(), v1.EventTypeNormal, "CreatedStatefulSet",
+			fmt.Sprintf(
+				"Created StatefulSet '%s/%s'",
+				sts.Namespace,
+				sts.Name,
+			))
+	} else {
+		parentGeneration := getSTSParentGeneration(sts)
+
+		// only update the resource if there are changes.
+		// We determine changes by comparing the parentGeneration
+		// (observed generation) stored on the statefulset with the
+		// generation of the StatefulResource.
+		if parentGeneration != sr.Generation() {
+			if sts.Annotations == nil {
+				sts.Annotations = make(map[string]string, 1)
+			}
+			sts.Annotations[operatorParentGenerationAnnotationKey] = fmt.Sprintf("%d", sr.Generation())
+
+			// volume claim templates of a StatefulSet are immutable,
+			// so changes are only reported. Growing storage requests
+			// are applied by expanding the volumes.
+			for _, change := range volumeClaimTemplateChanges(sts.Spec.VolumeClaimTemplates, sr.VolumeClaimTemplates()) {
+				o.recorder.Event(sr.Self(), v1.EventTypeWarning, "IgnoredVolumeClaimTemplates",
+					fmt.Sprintf("Ignored change of StatefulSet '%s/%s': %s", sts.Namespace, sts.Name, change))
+			}
+
+			err := o.pinImageDigests(ctx, sts, previous)
+			if err != nil {
+				return nil, err
+			}
+
+			sts, err = o.kube.AppsV1().StatefulSets(sts.Namespace).Update(ctx, sts, metav1.UpdateOptions{})
+			if err != nil {
+				return nil, err
+			}
+			o.recorder.Event(sr.Self(), v1.EventTypeNormal, "UpdatedStatefulSet",
+				fmt.Sprintf(
+					"Updated StatefulSet '%s/%s'",
+					sts.Namespace,
+					sts.Name,
+				))
+		}
+	}
+
+	return sts, nil
+}
+
+// renderStatefulSet renders the StatefulSet of the resource. If sts is nil a
+// new StatefulSet is returned, otherwise the spec of sts is updated.
+func renderStatefulSet(sr StatefulResource, sts *appsv1.StatefulSet, imageRewriteRules ImageRewriteRules) *appsv1.StatefulSet {
 	matchLabels := sr.LabelSelector()
 	template := templateInjectLabels(*sr.PodTemplateSpec(), matchLabels)
-
-	createStatefulSet := false
+	imageRewriteRules.rewritePodSpec(&template.Spec)
 
 	if sts == nil {
 		replicas := sr.Replicas()
-		createStatefulSet = true
 		sts = &appsv1.StatefulSet{
 			ObjectMeta: metav1.ObjectMeta{
 				Name:      sr.Name(),
 				Namespace: sr.Namespace(),
-				Labels:    sr.Labels(),
+				Labels:    make(map[string]string, len(sr.Labels())),
 				OwnerReferences: []metav1.OwnerReference{
 					{
 						APIVersion: sr.APIVersion(),
@@ -221,50 +368,53 @@ func (o *Operator) reconcileStatefulset(ctx context.Context, srg StatefulResourc
 		Type: appsv1.OnDeleteStatefulSetStrategyType,
 	}
 
+	additionalLabels, additionalAnnotations := sr.AdditionalMetadata()
+	applyAdditionalMetadata(&sts.ObjectMeta, additionalLabels, additionalAnnotations)
 	for k, v := range sr.Labels() {
 		sts.Labels[k] = v
 	}
+	return sts
+}
 
-	if createStatefulSet {
-		var err error
-		sts, err = o.kube.AppsV1().StatefulSets(sts.Namespace).Create(ctx, sts, metav1.CreateOptions{})
-		if err != nil {
-			return nil, err
-		}
-		o.recorder.Event(sr.Self(), v1.EventTypeNormal, "CreatedStatefulSet",
-			fmt.Sprintf(
-				"Created StatefulSet '%s/%s'",
-				sts.Namespace,
-				sts.Name,
-			))
-	} else {
-		parentGeneration := getSTSParentGeneration(sts)
+// pinImageDigests resolves the images of the StatefulSet pod template to
+// digests, if digest pinning is enabled. This guarantees that all pods of the
+// StatefulSet run the same image even if the tag is moved in the meantime.
+// As long as the rendered pod template doesn't change the digests of the
+// previous pod template are kept, such that e.g. scaling doesn't roll the
+// pods to a moved tag. The images are checked to support the architectures
+// the pods are restricted to, if the resolver can list their platforms. The
+// registries are accessed with the image pull secrets of the pods.
+func (o *Operator) pinImageDigests(ctx context.Context, sts *appsv1.StatefulSet, previous *v1.PodSpec) error {
+	if o.imageResolver == nil {
+		return nil
+	}
 
-		// only update the resource if there are changes.
-		// We determine changes by comparing the parentGeneration
-		// (observed generation) stored on the statefulset with the
-		// generation of the StatefulResource.
-		if parentGeneration != sr.Generation() {
-			if sts.Annotations == nil {
-				sts.Annotations = make(map[string]string, 1)
-			}
-			sts.Annotations[operatorParentGenerationAnnotationKey] = fmt.Sprintf("%d", sr.Generation())
+	hash, err := podTemplateHash(&sts.Spec.Template)
+	if err != nil {
+		return fmt.Errorf("failed to hash pod template of StatefulSet %s/%s: %v", sts.Namespace, sts.Name, err)
+	}
+	if previous != nil && sts.Annotations[podTemplateHashAnnotationKey] == hash && reusePinnedImages(&sts.Spec.Template.Spec, previous) {
+		return nil
+	}
+	sts.Annotations[podTemplateHashAnnotationKey] = hash
 
-			var err error
-			sts, err = o.kube.AppsV1().StatefulSets(sts.Namespace).Update(ctx, sts, metav1.UpdateOptions{})
-			if err != nil {
-				return nil, err
-			}
-			o.recorder.Event(sr.Self(), v1.EventTypeNormal, "UpdatedStatefulSet",
-				fmt.Sprintf(
-					"Updated StatefulSet '%s/%s'",
-					sts.Namespace,
-					sts.Name,
-				))
-		}
+	keychain, err := imagePullKeychain(ctx, o.kube, sts.Namespace, &sts.Spec.Template.Spec)
+	if err != nil {
+		return fmt.Errorf("failed to get image pull secrets for StatefulSet %s/%s: %v", sts.Namespace, sts.Name, err)
 	}
 
-	return sts, nil
+	err = pinImageDigests(ctx, o.imageResolver, keychain, &sts.Spec.Template.Spec)
+	if err != nil {
+		return fmt.Errorf("failed to pin image digests for StatefulSet %s/%s: %v", sts.Namespace, sts.Name, err)
+	}
+
+	if resolver, ok := o.imageResolver.(ImagePlatformResolver); ok {
+		err = checkImagePlatforms(ctx, resolver, keychain, &sts.Spec.Template.Spec)
+		if err != nil {
+			return fmt.Errorf("invalid images for StatefulSet %s/%s: %v", sts.Namespace, sts.Name, err)
+		}
+	}
+	return nil
 }
 
 func getSTSParentGeneration(sts *appsv1.StatefulSet) int64 {
@@ -317,11 +467,38 @@ func (o *Operator) operatePods(ctx context.Context, sts *appsv1.StatefulSet, srg
 		return fmt.Errorf("failed to list pods of StatefulSet: %v", err)
 	}
 
+	err = o.drainTerminatingPods(ctx, sr, pods)
+	if err != nil {
+		return err
+	}
+
 	pod, err := o.getPodToUpdate(ctx, pods, sts, sr)
 	if err != nil {
 		return fmt.Errorf("failed to get Pod to update: %v", err)
 	}
 
+	// don't continue the rolling update while too many Pods are failing
+	// as the update is likely the cause.
+	if pod != nil && sr.MaxFailingPods() != nil {
+		var nodes corelisters.NodeLister
+		if o.nodeInformer != nil {
+			nodes = o.nodeInformer.Lister()
+		}
+		failing := failingPodCount(podFailures(pods, nodes, time.Now()))
+		if failing > int(*sr.MaxFailingPods()) {
+			o.recorder.Event(sr.Self(), v1.EventTypeWarning, "RollingUpdatePaused",
+				fmt.Sprintf("Paused rolling update of StatefulSet '%s/%s': %d Pods are failing, maximum is %d",
+					sts.Namespace, sts.Name, failing, *sr.MaxFailingPods()))
+			return nil
+		}
+	}
+
+	// don't drain Pods while the cluster is unstable as it would only make
+	// things worse, or while its volumes are backed up.
+	if (pod != nil || replicas > desiredReplicas) && !o.clusterStable(ctx, sr, sts) {
+		return nil
+	}
+
 	// return if there are no Pods to be updated.
 	if pod == nil {
 		err := o.rescaleStatefulSet(ctx, sts, srg)
@@ -361,11 +538,19 @@ func (o *Operator) operatePods(ctx context.Context, sts *appsv1.StatefulSet, srg
 	// have different UUIDs).
 
 	// mark Pod draining
-	err = o.annotatePod(ctx, pod, operatorPodDrainingAnnotationKey, "true")
+	err = annotatePod(ctx, o.kube, pod, operatorPodDrainingAnnotationKey, "true")
 	if err != nil {
 		return fmt.Errorf("failed to mark Pod %s/%s draining: %v", pod.Namespace, pod.Name, err)
 	}
 
+	err = o.publishAddresses(ctx, sr, pod)
+	if err != nil {
+		return err
+	}
+
+	// determine the grace period while the Pod still holds its data.
+	gracePeriod := sr.TerminationGracePeriod(ctx, pod)
+
 	// drain Pod
 	o.recorder.Event(sr.Self(), v1.EventTypeNormal, "DrainingPod", fmt.Sprintf("Draining Pod '%s/%s'", pod.Namespace,
 		pod.Name))
@@ -373,22 +558,37 @@ func (o *Operator) operatePods(ctx context.Context, sts *appsv1.StatefulSet, srg
 	if err != nil {
 		return fmt.Errorf("failed to drain Pod %s/%s: %v", pod.Namespace, pod.Name, err)
 	}
+
+	err = sr.PrepareRestart(ctx, pod)
+	if err != nil {
+		return fmt.Errorf("failed to prepare the restart of Pod %s/%s: %v", pod.Namespace, pod.Name, err)
+	}
 	o.recorder.Event(sr.Self(), v1.EventTypeNormal, "DrainedPod", fmt.Sprintf("Successfully drained Pod '%s/%s'",
 		pod.Namespace,
 		pod.Name))
 
+	err = o.markPodDrained(ctx, pod)
+	if err != nil {
+		return fmt.Errorf("failed to mark Pod %s/%s drained: %v", pod.Namespace, pod.Name, err)
+	}
+
+	err = o.steerTrafficAway(ctx, sr, []*v1.Pod{pod})
+	if err != nil {
+		return err
+	}
+
 	// delete Pod
 	o.recorder.Event(sr.Self(), v1.EventTypeNormal, "DeletingPod", fmt.Sprintf("Deleting Pod '%s/%s'", pod.Namespace,
 		pod.Name))
 	err = o.kube.CoreV1().Pods(pod.Namespace).Delete(ctx, pod.Name, metav1.DeleteOptions{
-		GracePeriodSeconds: pod.Spec.TerminationGracePeriodSeconds,
+		GracePeriodSeconds: gracePeriod,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to delete Pod %s/%s: %v", pod.Namespace, pod.Name, err)
 	}
 
 	// wait for Pod to be terminated and gone from the node.
-	err = waitForPodTermination(ctx, o.kube, pod)
+	err = waitForPodTermination(ctx, o.kube, pod, gracePeriod)
 	if err != nil {
 		log.Warnf("Pod %s/%s not terminated within grace period: %v", pod.Namespace, pod.Name, err)
 	}
@@ -401,6 +601,54 @@ func (o *Operator) operatePods(ctx context.Context, sts *appsv1.StatefulSet, srg
 	return sr.OnStableReplicasHook(ctx)
 }
 
+// clusterStable returns false if the cluster is too unstable to drain the
+// pods of the StatefulSet. The DrainingPaused and DrainingResumed events are
+// only recorded when draining is paused or resumed, not at every check.
+func (o *Operator) clusterStable(ctx context.Context, sr StatefulResource, sts *appsv1.StatefulSet) bool {
+	if o.drainingPause == nil {
+		o.drainingPause = &drainingPause{}
+	}
+
+	stable, reason := sr.ClusterStable(ctx)
+	switch {
+	case !stable && !o.drainingPause.paused:
+		o.recorder.Event(sr.Self(), v1.EventTypeWarning, "DrainingPaused",
+			fmt.Sprintf("Paused draining Pods of StatefulSet '%s/%s': %s", sts.Namespace, sts.Name, reason))
+	case stable && o.drainingPause.paused:
+		o.recorder.Event(sr.Self(), v1.EventTypeNormal, "DrainingResumed",
+			fmt.Sprintf("Resumed draining Pods of StatefulSet '%s/%s'", sts.Namespace, sts.Name))
+	}
+	o.drainingPause.paused = !stable
+	return stable
+}
+
+// drainPods drains the pods concurrently and marks them as drained. It
+// returns after all drains finished, with the errors of the failed drains.
+func (o *Operator) drainPods(ctx context.Context, sr StatefulResource, pods []*v1.Pod) error {
+	errs := make([]error, len(pods))
+	var wg sync.WaitGroup
+	for i, pod := range pods {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			log.Infof("Draining Pod %s/%s for scaledown", pod.Namespace, pod.Name)
+			err := sr.Drain(ctx, pod)
+			if err != nil {
+				errs[i] = fmt.Errorf("failed to drain pod %s/%s: %v", pod.Namespace, pod.Name, err)
+				return
+			}
+			log.Infof("Pod %s/%s drained", pod.Namespace, pod.Name)
+
+			err = o.markPodDrained(ctx, pod)
+			if err != nil {
+				errs[i] = fmt.Errorf("failed to mark pod %s/%s drained: %v", pod.Namespace, pod.Name, err)
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
 // rescaleStatefulSet rescales the StatefulSet
 func (o *Operator) rescaleStatefulSet(ctx context.Context, sts *appsv1.StatefulSet, srg StatefulResourceGetter) error {
 	replicaDiff := 0
@@ -425,6 +673,11 @@ func (o *Operator) rescaleStatefulSet(ctx context.Context, sts *appsv1.StatefulS
 	replicas := currentReplicas
 	if replicaDiff > 0 {
 		replicas += replicaDiff
+
+		err := sr.PreScaleUpHook(ctx, sts, int32(replicas))
+		if err != nil {
+			return err
+		}
 	} else if replicaDiff < 0 && replicas > 0 {
 		// When scaledown is desired trigger the PreScaleDown Hook.
 		// It's ensured that the hook is triggered at least once for
@@ -435,7 +688,7 @@ func (o *Operator) rescaleStatefulSet(ctx context.Context, sts *appsv1.StatefulS
 		}
 
 		// TODO: optimize by scaling down all pending pods
-		replicas--
+		replicas -= min(-replicaDiff, max(sr.MaxParallelDrains(), 1))
 	}
 
 	labelSelector := labels.Set(sts.Spec.Selector.MatchLabels).AsSelector()
@@ -449,15 +702,30 @@ func (o *Operator) rescaleStatefulSet(ctx context.Context, sts *appsv1.StatefulS
 	// Pods are named with an increasing number when part of a StatefulSet.
 	// We use this property to sort Pods by the lowest ordinal number and
 	// drain those that would be scaled down by Kubernetes when reducing
-	// the replica count on the StatefulSet.
+	// the replica count on the StatefulSet. Unlike updates, scale-downs
+	// can't prefer the pods which are cheapest to drain.
 	pods, err = sortStatefulSetPods(pods)
 	if err != nil {
 		return err
 	}
 
+	drained := make([]*v1.Pod, 0)
 	if len(pods) > replicas {
 		log.Infof("Starting pod draining from %d to %d pods", len(pods), replicas)
+		err = o.publishAddresses(ctx, sr, pods[replicas:]...)
+		if err != nil {
+			return err
+		}
+
+		// if pod is Pending we don't need to safely drain it.
+		draining := make([]*v1.Pod, 0, len(pods)-replicas)
 		for _, pod := range pods[replicas:] {
+			if pod.Status.Phase != v1.PodPending {
+				draining = append(draining, pod)
+			}
+		}
+
+		for len(draining) > 0 {
 			// first, check if we need to opt-out of the loop because the EDS changed.
 			newSR, err := srg.Get(ctx)
 			if err != nil {
@@ -469,11 +737,6 @@ func (o *Operator) rescaleStatefulSet(ctx context.Context, sts *appsv1.StatefulS
 				return nil
 			}
 
-			// if pod is Pending we don't need to safely drain it.
-			if pod.Status.Phase == v1.PodPending {
-				continue
-			}
-
 			// wait for StatefulSet to be stable before continuing
 			// always ensure a stable StatefulSet before draining
 			err = waitForStableStatefulSet(ctx, o.kube, sts, stabilizationTimeout)
@@ -481,16 +744,27 @@ func (o *Operator) rescaleStatefulSet(ctx context.Context, sts *appsv1.StatefulS
 				return fmt.Errorf("StatefulSet %s/%s is not stable: %v", sts.Namespace, sts.Name, err)
 			}
 
-			log.Infof("Draining Pod %s/%s for scaledown", pod.Namespace, pod.Name)
-			err = newSR.Drain(ctx, pod)
+			// the previous batch may have destabilized the cluster.
+			if !o.clusterStable(ctx, newSR, sts) {
+				return nil
+			}
+
+			// drain up to the parallel drains at once
+			batch := draining[:min(len(draining), max(newSR.MaxParallelDrains(), 1))]
+			draining = draining[len(batch):]
+			err = o.drainPods(ctx, newSR, batch)
 			if err != nil {
-				return fmt.Errorf("failed to drain pod %s/%s: %v", pod.Namespace, pod.Name, err)
+				return err
 			}
-			log.Infof("Pod %s/%s drained", pod.Namespace, pod.Name)
+			drained = append(drained, batch...)
 		}
 	}
 
-	// always scale down by one
+	err = o.steerTrafficAway(ctx, sr, drained)
+	if err != nil {
+		return err
+	}
+
 	replicasInt32 := int32(replicas)
 	sts.Spec.Replicas = &replicasInt32
 
@@ -597,10 +871,10 @@ func (o *Operator) getNodes(_ context.Context) (map[string]v1.Node, map[string]v
 
 // annotatePod annotates the Pod with the specified annotation key and value.
 // If the key/value is already present on the Pod, this is a no-op.
-func (o *Operator) annotatePod(ctx context.Context, pod *v1.Pod, annotationKey, annotationValue string) error {
+func annotatePod(ctx context.Context, client kubernetes.Interface, pod *v1.Pod, annotationKey, annotationValue string) error {
 	if value, ok := pod.Annotations[annotationKey]; !ok || value != annotationValue {
 		annotation := []byte(fmt.Sprintf(`{"metadata": {"annotations": {"%s": "%s"}}}`, annotationKey, annotationValue))
-		_, err := o.kube.CoreV1().Pods(pod.Namespace).Patch(ctx, pod.Name, types.StrategicMergePatchType, annotation, metav1.PatchOptions{})
+		_, err := client.CoreV1().Pods(pod.Namespace).Patch(ctx, pod.Name, types.StrategicMergePatchType, annotation, metav1.PatchOptions{})
 		if err != nil {
 			return err
 		}
@@ -610,12 +884,12 @@ func (o *Operator) annotatePod(ctx context.Context, pod *v1.Pod, annotationKey,
 
 // waitForPodTermination waits for a Pod to be terminated by looking up the Pod
 // in the API server.
-// It waits for up to TerminationGracePeriodSeconds as specified on the Pod +
-// an additional eviction head room.
+// It waits for up to the grace period the Pod was deleted with + an
+// additional eviction head room.
 // This is to fully respect the termination expectations as described in:
 // https://kubernetes.io/docs/concepts/workloads/pods/pod/#termination-of-pods
-func waitForPodTermination(ctx context.Context, client kubernetes.Interface, pod *v1.Pod) error {
-	if pod.Spec.TerminationGracePeriodSeconds == nil {
+func waitForPodTermination(ctx context.Context, client kubernetes.Interface, pod *v1.Pod, terminationGracePeriodSeconds *int64) error {
+	if terminationGracePeriodSeconds == nil {
 		// if no grace period is defined, we don't wait.
 		return nil
 	}
@@ -623,7 +897,7 @@ func waitForPodTermination(ctx context.Context, client kubernetes.Interface, pod
 	waitForTermination := func() error {
 		newpod, err := client.CoreV1().Pods(pod.Namespace).Get(ctx, pod.Name, metav1.GetOptions{})
 		if err != nil {
-			if errors.IsNotFound(err) {
+			if apierrors.IsNotFound(err) {
 				return nil
 			}
 			return err
@@ -637,7 +911,7 @@ func waitForPodTermination(ctx context.Context, client kubernetes.Interface, pod
 		return nil
 	}
 
-	gracePeriod := time.Duration(*pod.Spec.TerminationGracePeriodSeconds)*time.Second + podEvictionHeadroom
+	gracePeriod := time.Duration(*terminationGracePeriodSeconds)*time.Second + podEvictionHeadroom
 
 	backoffCfg := backoff.NewExponentialBackOff()
 	backoffCfg.MaxElapsedTime = gracePeriod
@@ -670,9 +944,11 @@ func waitForStableStatefulSet(ctx context.Context, client kubernetes.Interface,
 }
 
 type updatePriority struct {
-	Pod      v1.Pod
-	Priority int
-	Number   int
+	Pod       v1.Pod
+	Priority  int
+	Number    int
+	DrainGiB  int64
+	Primaries int64
 }
 
 const (
@@ -723,6 +999,9 @@ func priorityNames(priority int) []string {
 // 2. Pods NOT on a priority node get high priority.
 // 3. Pods not up to date with StatefulSet revision get high priority.
 // 4. Pods part of a StatefulSet where desired replicas != actual replicas get medium priority.
+//
+// Pods of the same priority are ordered by their drain cost, so the pods
+// which are cheapest to move are updated first.
 func prioritizePodsForUpdate(pods []*v1.Pod, sts *appsv1.StatefulSet, sr StatefulResource, priorityNodes, unschedulableNodes map[string]v1.Node) ([]v1.Pod, error) {
 	priorities := make([]*updatePriority, 0, len(pods))
 	for _, pod := range pods {
@@ -736,6 +1015,7 @@ func prioritizePodsForUpdate(pods []*v1.Pod, sts *appsv1.StatefulSet, sr Statefu
 			Pod:    *pod.DeepCopy(),
 			Number: number,
 		}
+		prio.DrainGiB, prio.Primaries = podDrainCost(pod)
 
 		// if Pod is marked draining it gets the highest priority.
 		if _, ok := pod.Annotations[operatorPodDrainingAnnotationKey]; ok {
@@ -764,7 +1044,8 @@ func prioritizePodsForUpdate(pods []*v1.Pod, sts *appsv1.StatefulSet, sr Statefu
 		// if Pod has a different revision than the updated revision on
 		// the StatefulSet then it gets high priority.
 		// TODO: check if UpdateRevision is always set.
-		if hash, ok := pod.Labels[controllerRevisionHashLabelKey]; ok && sts.Status.UpdateRevision != hash {
+		// Pods aren't updated while the rollout is paused.
+		if hash, ok := pod.Labels[controllerRevisionHashLabelKey]; ok && sts.Status.UpdateRevision != hash && !sr.RolloutPaused() {
 			prio.Priority += podOldRevisionPriority
 		}
 
@@ -785,12 +1066,19 @@ func prioritizePodsForUpdate(pods []*v1.Pod, sts *appsv1.StatefulSet, sr Statefu
 		priorities = append(priorities, prio)
 	}
 
-	// sort by priority, ordinal number
+	// sort by priority, drain cost, ordinal number
 	sort.Slice(priorities, func(i, j int) bool {
-		if priorities[i].Priority == priorities[j].Priority {
-			return priorities[i].Number < priorities[j].Number
+		a, b := priorities[i], priorities[j]
+		if a.Priority != b.Priority {
+			return a.Priority > b.Priority
+		}
+		if a.DrainGiB != b.DrainGiB {
+			return a.DrainGiB < b.DrainGiB
+		}
+		if a.Primaries != b.Primaries {
+			return a.Primaries < b.Primaries
 		}
-		return priorities[i].Priority > priorities[j].Priority
+		return a.Number < b.Number
 	})
 
 	sortedPods := make([]v1.Pod, 0, len(pods))
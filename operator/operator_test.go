@@ -4,14 +4,21 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	"github.com/zalando-incubator/es-operator/pkg/clientset"
 	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
 )
 
 type mockResource struct {
@@ -27,28 +34,61 @@ type mockResource struct {
 	eds                  *zv1.ElasticsearchDataSet
 	podTemplateSpec      *v1.PodTemplateSpec
 	volumeClaimTemplates []v1.PersistentVolumeClaim
+	paused               bool
+	drain                func(pod *v1.Pod) error
+	unstable             string
 }
 
-func (r *mockResource) Name() string                         { return r.name }
-func (r *mockResource) Namespace() string                    { return r.namespace }
-func (r *mockResource) APIVersion() string                   { return r.apiVersion }
-func (r *mockResource) Kind() string                         { return r.kind }
-func (r *mockResource) Labels() map[string]string            { return r.labels }
-func (r *mockResource) LabelSelector() map[string]string     { return r.labelSelector }
+func (r *mockResource) Name() string                     { return r.name }
+func (r *mockResource) Namespace() string                { return r.namespace }
+func (r *mockResource) APIVersion() string               { return r.apiVersion }
+func (r *mockResource) Kind() string                     { return r.kind }
+func (r *mockResource) Labels() map[string]string        { return r.labels }
+func (r *mockResource) LabelSelector() map[string]string { return r.labelSelector }
+func (r *mockResource) AdditionalMetadata() (map[string]string, map[string]string) {
+	return nil, nil
+}
+func (r *mockResource) ServingGate() *ServingGate            { return nil }
 func (r *mockResource) Generation() int64                    { return r.generation }
 func (r *mockResource) UID() types.UID                       { return r.uid }
 func (r *mockResource) Replicas() int32                      { return r.replicas }
+func (r *mockResource) MaxFailingPods() *int32               { return nil }
+func (r *mockResource) RolloutPaused() bool                  { return r.paused }
 func (r *mockResource) PodTemplateSpec() *v1.PodTemplateSpec { return r.podTemplateSpec }
 func (r *mockResource) VolumeClaimTemplates() []v1.PersistentVolumeClaim {
 	return r.volumeClaimTemplates
 }
-func (r *mockResource) Self() runtime.Object                                            { return r.eds }
-func (r *mockResource) EnsureResources(ctx context.Context) error                       { return nil }
-func (r *mockResource) UpdateStatus(ctx context.Context, sts *appsv1.StatefulSet) error { return nil }
-func (r *mockResource) PreScaleDownHook(ctx context.Context) error                      { return nil }
-func (r *mockResource) OnStableReplicasHook(ctx context.Context) error                  { return nil }
-func (r *mockResource) Drain(ctx context.Context, pod *v1.Pod) error                    { return nil }
-func (r *mockResource) Get(ctx context.Context) (StatefulResource, error)               { return r, nil }
+func (r *mockResource) Self() runtime.Object                      { return r.eds }
+func (r *mockResource) EnsureResources(ctx context.Context) error { return nil }
+func (r *mockResource) OnObservedHook(ctx context.Context, sts *appsv1.StatefulSet, pods []*v1.Pod) error {
+	return nil
+}
+func (r *mockResource) UpdateStatus(ctx context.Context, sts *appsv1.StatefulSet, pods []*v1.Pod) error {
+	return nil
+}
+func (r *mockResource) PreScaleDownHook(ctx context.Context) error { return nil }
+func (r *mockResource) PreScaleUpHook(ctx context.Context, sts *appsv1.StatefulSet, replicas int32) error {
+	return nil
+}
+func (r *mockResource) OnStableReplicasHook(ctx context.Context) error { return nil }
+func (r *mockResource) Drain(ctx context.Context, pod *v1.Pod) error {
+	if r.drain != nil {
+		return r.drain(pod)
+	}
+	return nil
+}
+func (r *mockResource) PrepareRestart(ctx context.Context, pod *v1.Pod) error { return nil }
+func (r *mockResource) MaxParallelDrains() int                                { return 1 }
+func (r *mockResource) ClusterStable(ctx context.Context) (bool, string) {
+	return r.unstable == "", r.unstable
+}
+func (r *mockResource) TerminationGracePeriod(ctx context.Context, pod *v1.Pod) *int64 {
+	return pod.Spec.TerminationGracePeriodSeconds
+}
+func (r *mockResource) PublishAddresses(ctx context.Context, addresses []string) error {
+	return nil
+}
+func (r *mockResource) Get(ctx context.Context) (StatefulResource, error) { return r, nil }
 
 func TestPrioritizePodsForUpdate(t *testing.T) {
 	updatingPod := v1.Pod{
@@ -187,6 +227,17 @@ func TestPrioritizePodsForUpdate(t *testing.T) {
 	assert.Len(t, sortedPods, 2)
 	assert.Equal(t, stsPod0, sortedPods[0])
 
+	// pods of the same priority should be sorted by drain cost
+	expensivePod := stsPod0.DeepCopy()
+	expensivePod.Annotations = map[string]string{drainCostGiBAnnotationKey: "10", drainCostPrimariesAnnotationKey: "1"}
+	cheapPod := stsPod.DeepCopy()
+	cheapPod.Annotations = map[string]string{drainCostGiBAnnotationKey: "2", drainCostPrimariesAnnotationKey: "5"}
+	pods = []*v1.Pod{expensivePod, cheapPod}
+	sortedPods, err = prioritizePodsForUpdate(pods, sts, sr, priorityNodes, unschedulableNodes)
+	assert.NoError(t, err)
+	assert.Len(t, sortedPods, 2)
+	assert.Equal(t, *cheapPod, sortedPods[0])
+
 	// pods on unschedulable nodes should get higher priority
 	pods = []*v1.Pod{&stsPod, &stsPod2}
 	sortedPods, err = prioritizePodsForUpdate(pods, sts, sr, priorityNodes, unschedulableNodes)
@@ -200,6 +251,18 @@ func TestPrioritizePodsForUpdate(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Len(t, sortedPods, 0)
 
+	// pods of an old revision aren't updated while the rollout is paused
+	podOldRevision := podUpToDate.DeepCopy()
+	podOldRevision.Labels[controllerRevisionHashLabelKey] = "old"
+	pods = []*v1.Pod{podOldRevision}
+	sortedPods, err = prioritizePodsForUpdate(pods, sts, sr, priorityNodes, unschedulableNodes)
+	assert.NoError(t, err)
+	assert.Len(t, sortedPods, 1)
+	sr.paused = true
+	sortedPods, err = prioritizePodsForUpdate(pods, sts, sr, priorityNodes, unschedulableNodes)
+	assert.NoError(t, err)
+	assert.Len(t, sortedPods, 0)
+
 	// don't prioritize pods part of unscaled statefulset
 	desiredReplicas := int32(1)
 	currentReplicas := int32(3)
@@ -239,3 +302,169 @@ func TestSortStatefulSetPods(t *testing.T) {
 	assert.Equal(t, sortedPods[12].Name, "sts-12")
 	assert.Equal(t, sortedPods[0].Name, "sts-0")
 }
+
+func TestDrainPods(t *testing.T) {
+	pods := make([]*v1.Pod, 0, 3)
+	for _, num := range []int{3, 4, 5} {
+		pods = append(pods, &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("sts-%d", num),
+				Namespace: "default",
+			},
+		})
+	}
+
+	started := make(chan struct{}, len(pods))
+	release := make(chan struct{})
+	sr := &mockResource{
+		drain: func(pod *v1.Pod) error {
+			started <- struct{}{}
+			<-release
+			if pod.Name == "sts-4" {
+				return fmt.Errorf("shards remaining")
+			}
+			return nil
+		},
+	}
+
+	o := &Operator{}
+	result := make(chan error)
+	go func() {
+		result <- o.drainPods(context.Background(), sr, pods)
+	}()
+
+	// all pods are drained at the same time.
+	for range pods {
+		select {
+		case <-started:
+		case <-time.After(5 * time.Second):
+			t.Fatal("pods aren't drained in parallel")
+		}
+	}
+	close(release)
+
+	err := <-result
+	assert.EqualError(t, err, "failed to drain pod default/sts-4: shards remaining")
+}
+
+func TestRescaleStatefulSetPausedWhileUnstable(t *testing.T) {
+	replicas := int32(3)
+	sts := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "sts", Namespace: "default"},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "es"}},
+		},
+		Status: appsv1.StatefulSetStatus{ReadyReplicas: replicas},
+	}
+	kube := fake.NewSimpleClientset(sts)
+	podInformer := informers.NewSharedInformerFactory(kube, 0).Core().V1().Pods()
+	for i := 0; i < int(replicas); i++ {
+		require.NoError(t, podInformer.Informer().GetIndexer().Add(&v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("sts-%d", i), GenerateName: "sts-", Namespace: "default", Labels: map[string]string{"app": "es"}},
+			Status:     v1.PodStatus{Phase: v1.PodRunning},
+		}))
+	}
+
+	recorder := record.NewFakeRecorder(10)
+	o := &Operator{
+		kube:        &clientset.Clientset{Interface: kube},
+		podInformer: podInformer,
+		recorder:    recorder,
+	}
+	var drained []string
+	sr := &mockResource{
+		name:          "sts",
+		namespace:     "default",
+		labelSelector: map[string]string{"app": "es"},
+		replicas:      2,
+		eds:           &zv1.ElasticsearchDataSet{},
+		unstable:      "master elected 3 times in the last 10m0s",
+		drain: func(pod *v1.Pod) error {
+			drained = append(drained, pod.Name)
+			return nil
+		},
+	}
+
+	// the cluster is checked again right before draining.
+	require.NoError(t, o.rescaleStatefulSet(context.Background(), sts, sr))
+	require.Empty(t, drained)
+	require.EqualValues(t, 3, *sts.Spec.Replicas)
+	require.Equal(t, "Warning DrainingPaused Paused draining Pods of StatefulSet 'default/sts': master elected 3 times in the last 10m0s", <-recorder.Events)
+}
+
+func TestRescaleStatefulSetDrainsHighestOrdinals(t *testing.T) {
+	replicas := int32(3)
+	sts := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "sts", Namespace: "default"},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "es"}},
+		},
+		Status: appsv1.StatefulSetStatus{ReadyReplicas: replicas},
+	}
+	kube := fake.NewSimpleClientset(sts)
+	// there's no StatefulSet controller, the replicas are ready at once.
+	kube.PrependReactor("update", "statefulsets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		updated := action.(k8stesting.UpdateAction).GetObject().(*appsv1.StatefulSet)
+		updated.Status.ReadyReplicas = *updated.Spec.Replicas
+		return false, nil, nil
+	})
+	podInformer := informers.NewSharedInformerFactory(kube, 0).Core().V1().Pods()
+	// the pod with the highest ordinal is the most expensive to drain.
+	for i, cost := range []string{"1", "10", "100"} {
+		require.NoError(t, podInformer.Informer().GetIndexer().Add(&v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:         fmt.Sprintf("sts-%d", i),
+				GenerateName: "sts-",
+				Namespace:    "default",
+				Labels:       map[string]string{"app": "es"},
+				Annotations:  map[string]string{drainCostGiBAnnotationKey: cost, drainCostPrimariesAnnotationKey: cost},
+			},
+			Status: v1.PodStatus{Phase: v1.PodRunning},
+		}))
+	}
+
+	o := &Operator{
+		kube:        &clientset.Clientset{Interface: kube},
+		podInformer: podInformer,
+		recorder:    record.NewFakeRecorder(10),
+	}
+	var drained []string
+	sr := &mockResource{
+		name:          "sts",
+		namespace:     "default",
+		labelSelector: map[string]string{"app": "es"},
+		replicas:      2,
+		eds:           &zv1.ElasticsearchDataSet{},
+		drain: func(pod *v1.Pod) error {
+			drained = append(drained, pod.Name)
+			return nil
+		},
+	}
+
+	// the StatefulSet removes the highest ordinal, the drain cost doesn't
+	// change the pod which is drained.
+	require.NoError(t, o.rescaleStatefulSet(context.Background(), sts, sr))
+	require.Equal(t, []string{"sts-2"}, drained)
+	require.EqualValues(t, 2, *sts.Spec.Replicas)
+}
+
+func TestClusterStableEvents(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	o := &Operator{recorder: recorder}
+	sts := &appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: "sts", Namespace: "default"}}
+	sr := &mockResource{eds: &zv1.ElasticsearchDataSet{}, unstable: "backup in progress"}
+
+	// pausing is only reported once.
+	require.False(t, o.clusterStable(context.Background(), sr, sts))
+	require.False(t, o.clusterStable(context.Background(), sr, sts))
+	require.Len(t, recorder.Events, 1)
+	require.Equal(t, "Warning DrainingPaused Paused draining Pods of StatefulSet 'default/sts': backup in progress", <-recorder.Events)
+
+	sr.unstable = ""
+	require.True(t, o.clusterStable(context.Background(), sr, sts))
+	require.True(t, o.clusterStable(context.Background(), sr, sts))
+	require.Len(t, recorder.Events, 1)
+	require.Equal(t, "Normal DrainingResumed Resumed draining Pods of StatefulSet 'default/sts'", <-recorder.Events)
+}
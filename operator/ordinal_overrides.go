@@ -0,0 +1,117 @@
+package operator
+
+import (
+	"fmt"
+	"strings"
+
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	ordinalOverridesContainer = "es-operator-ordinal-overrides"
+	ordinalOverridesVolume    = "es-operator-ordinal-overrides"
+	ordinalOverridesMountPath = "/usr/share/elasticsearch/config/jvm.options.d"
+	ordinalOverridesInitPath  = "/var/run/es-operator/jvm.options.d"
+	ordinalOverridesFile      = "ordinal.options"
+
+	// maxCapacityReplicas bounds the search for replicas providing a
+	// capacity.
+	maxCapacityReplicas = 10000
+)
+
+// shellQuote quotes the value for a POSIX shell.
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// ordinalOverridesScript writes the JVM options of the overrides matching
+// the ordinal of the pod, taken from its hostname, to a jvm.options.d file.
+func ordinalOverridesScript(overrides []zv1.ElasticsearchDataSetOrdinalOverride) string {
+	var script strings.Builder
+	file := ordinalOverridesInitPath + "/" + ordinalOverridesFile
+	fmt.Fprintf(&script, "ordinal=\"${HOSTNAME##*-}\"\n: > %s\n", file)
+	for _, override := range overrides {
+		options := strings.Fields(override.JavaOpts)
+		if len(options) == 0 {
+			continue
+		}
+		quoted := make([]string, 0, len(options))
+		for _, option := range options {
+			quoted = append(quoted, shellQuote(option))
+		}
+		fmt.Fprintf(&script, "if [ \"$ordinal\" -ge %d ] && [ \"$ordinal\" -le %d ]; then printf '%%s\\n' %s >> %s; fi\n",
+			override.From, override.To, strings.Join(quoted, " "), file)
+	}
+	return script.String()
+}
+
+// injectOrdinalOverrides adds an init container to the pod template which
+// writes the JVM options of the ordinal overrides to the jvm.options.d
+// directory of the Elasticsearch container.
+func injectOrdinalOverrides(spec *v1.PodSpec, overrides []zv1.ElasticsearchDataSetOrdinalOverride) {
+	if len(spec.Containers) == 0 {
+		return
+	}
+	javaOpts := false
+	for _, override := range overrides {
+		if strings.TrimSpace(override.JavaOpts) != "" {
+			javaOpts = true
+		}
+	}
+	if !javaOpts {
+		return
+	}
+
+	container := &spec.Containers[0]
+	spec.Volumes = append(spec.Volumes, v1.Volume{
+		Name:         ordinalOverridesVolume,
+		VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}},
+	})
+	spec.InitContainers = append(spec.InitContainers, v1.Container{
+		Name:            ordinalOverridesContainer,
+		Image:           container.Image,
+		ImagePullPolicy: container.ImagePullPolicy,
+		Command:         []string{"sh", "-c", ordinalOverridesScript(overrides)},
+		VolumeMounts: []v1.VolumeMount{
+			{Name: ordinalOverridesVolume, MountPath: ordinalOverridesInitPath},
+		},
+	})
+	container.VolumeMounts = append(container.VolumeMounts, v1.VolumeMount{
+		Name:      ordinalOverridesVolume,
+		MountPath: ordinalOverridesMountPath,
+	})
+}
+
+// ordinalCapacity returns the capacity of the pod with the ordinal relative
+// to a pod without override.
+func ordinalCapacity(overrides []zv1.ElasticsearchDataSetOrdinalOverride, ordinal int32) float64 {
+	for _, override := range overrides {
+		if ordinal >= override.From && ordinal <= override.To && override.CapacityPercent > 0 {
+			return float64(override.CapacityPercent) / 100
+		}
+	}
+	return 1
+}
+
+// capacityNodes returns the capacity of the replicas in nodes without
+// override.
+func capacityNodes(overrides []zv1.ElasticsearchDataSetOrdinalOverride, replicas int32) float64 {
+	capacity := 0.0
+	for ordinal := int32(0); ordinal < replicas; ordinal++ {
+		capacity += ordinalCapacity(overrides, ordinal)
+	}
+	return capacity
+}
+
+// replicasForCapacity returns the number of replicas providing at least the
+// capacity in nodes without override.
+func replicasForCapacity(overrides []zv1.ElasticsearchDataSetOrdinalOverride, capacity float64) int32 {
+	replicas := int32(0)
+	provided := 0.0
+	for provided < capacity && replicas < maxCapacityReplicas {
+		provided += ordinalCapacity(overrides, replicas)
+		replicas++
+	}
+	return replicas
+}
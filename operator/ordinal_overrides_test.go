@@ -0,0 +1,67 @@
+package operator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestOrdinalOverridesScript(t *testing.T) {
+	script := ordinalOverridesScript([]zv1.ElasticsearchDataSetOrdinalOverride{
+		{From: 0, To: 1, JavaOpts: "-Xms8g  -Xmx8g"},
+		{From: 2, To: 9, CapacityPercent: 50},
+		{From: 10, To: 10, JavaOpts: "-Dname='x'"},
+	})
+	require.Equal(t, `ordinal="${HOSTNAME##*-}"
+: > /var/run/es-operator/jvm.options.d/ordinal.options
+if [ "$ordinal" -ge 0 ] && [ "$ordinal" -le 1 ]; then printf '%s\n' '-Xms8g' '-Xmx8g' >> /var/run/es-operator/jvm.options.d/ordinal.options; fi
+if [ "$ordinal" -ge 10 ] && [ "$ordinal" -le 10 ]; then printf '%s\n' '-Dname='\''x'\''' >> /var/run/es-operator/jvm.options.d/ordinal.options; fi
+`, script)
+}
+
+func TestInjectOrdinalOverrides(t *testing.T) {
+	spec := &v1.PodSpec{Containers: []v1.Container{{Name: "elasticsearch", Image: "es:8"}}}
+
+	// only capacity overrides
+	injectOrdinalOverrides(spec, []zv1.ElasticsearchDataSetOrdinalOverride{{From: 0, To: 1, CapacityPercent: 200}})
+	require.Empty(t, spec.InitContainers)
+
+	injectOrdinalOverrides(spec, []zv1.ElasticsearchDataSetOrdinalOverride{{From: 0, To: 1, JavaOpts: "-Xmx8g"}})
+	require.Len(t, spec.InitContainers, 1)
+	require.Equal(t, "es:8", spec.InitContainers[0].Image)
+	require.Len(t, spec.Volumes, 1)
+	require.Equal(t, ordinalOverridesMountPath, spec.Containers[0].VolumeMounts[0].MountPath)
+}
+
+func TestCapacity(t *testing.T) {
+	overrides := []zv1.ElasticsearchDataSetOrdinalOverride{
+		{From: 0, To: 1, CapacityPercent: 300},
+		{From: 2, To: 2, JavaOpts: "-Xmx2g"},
+	}
+	require.Equal(t, 0.0, capacityNodes(overrides, 0))
+	require.Equal(t, 6.0, capacityNodes(overrides, 2))
+	require.Equal(t, 8.0, capacityNodes(overrides, 4))
+	require.Equal(t, 4.0, capacityNodes(nil, 4))
+
+	require.Equal(t, int32(2), replicasForCapacity(overrides, 6))
+	require.Equal(t, int32(5), replicasForCapacity(overrides, 8.5))
+	require.Equal(t, int32(3), replicasForCapacity(nil, 2.5))
+}
+
+func TestScaleUpWithOrdinalCapacity(t *testing.T) {
+	eds := edsTestFixture(4)
+	eds.Spec.OrdinalOverrides = []zv1.ElasticsearchDataSetOrdinalOverride{{From: 0, To: 1, CapacityPercent: 300}}
+	esIndices := map[string]ESIndex{
+		"ad1": {Replicas: 5, Primaries: 10, Index: "ad1"},
+	}
+
+	as := systemUnderTest(eds, nil, nil)
+
+	// 60 shards need a capacity of 10 nodes at 6 shards per node, which
+	// the two anchors and 4 regular nodes provide.
+	actual := as.calculateScalingOperation(esIndices, make([]ESNode, 0), NONE)
+	require.Equal(t, int32(6), *actual.NodeReplicas, actual.Description)
+	require.Equal(t, UP, actual.ScalingDirection, actual.Description)
+}
@@ -0,0 +1,81 @@
+package operator
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/prometheus/client_golang/prometheus"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+)
+
+// overprovisionedReplicasMetric exposes the replicas kept as headroom on top
+// of the replicas needed by an EDS, e.g. to attribute their cost.
+var overprovisionedReplicasMetric = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "es_operator",
+	Name:      "overprovisioned_replicas",
+	Help:      "Replicas of an EDS kept as headroom on top of the replicas needed according to the scaling settings.",
+}, []string{"namespace", "eds"})
+
+func init() {
+	prometheus.MustRegister(overprovisionedReplicasMetric)
+}
+
+// overprovisionReplicas returns the replicas to keep on top of the needed
+// replicas for the percentage, rounded up.
+func overprovisionReplicas(needed, percent int32) int32 {
+	if percent <= 0 {
+		return 0
+	}
+	return int32(math.Ceil(float64(needed) * float64(percent) / 100))
+}
+
+// neededReplicasEDS returns the EDS with the replicas needed according to
+// the scaling settings, without the overprovisioned replicas recorded in the
+// status, such that the scaling operation is computed for the actual need.
+func neededReplicasEDS(eds *zv1.ElasticsearchDataSet) *zv1.ElasticsearchDataSet {
+	overprovisioned := eds.Status.OverprovisionedReplicas
+	if overprovisioned <= 0 || eds.Spec.Replicas == nil {
+		return eds
+	}
+	needed := *eds.Spec.Replicas - overprovisioned
+	if needed < 1 {
+		needed = 1
+	}
+	neededEDS := *eds
+	neededEDS.Spec.Replicas = &needed
+	return &neededEDS
+}
+
+// overprovision adds the overprovisioned replicas to the scaling operation
+// computed for the needed replicas. An operation is returned to adjust the
+// replicas if only the overprovisioning changed.
+func (as *AutoScaler) overprovision(scalingOperation *ScalingOperation) *ScalingOperation {
+	scaling := as.eds.Spec.Scaling
+	if as.eds.Spec.Replicas == nil || (scaling.OverprovisionPercent <= 0 && as.overprovisioned <= 0) {
+		return scalingOperation
+	}
+
+	needed := *as.eds.Spec.Replicas
+	currentReplicas := needed + as.overprovisioned
+	if scalingOperation.NodeReplicas != nil {
+		needed = *scalingOperation.NodeReplicas
+	}
+	replicas := needed + overprovisionReplicas(needed, scaling.OverprovisionPercent)
+	if scaling.MaxReplicas > 0 && replicas > scaling.MaxReplicas {
+		replicas = scaling.MaxReplicas
+		if replicas < needed {
+			replicas = needed
+		}
+	}
+
+	if scalingOperation.NodeReplicas == nil && replicas != currentReplicas {
+		scalingOperation.Description = fmt.Sprintf("Adjusting overprovisioned replicas to %d.", replicas-needed)
+	}
+	scalingOperation.NodeReplicas = &replicas
+	scalingOperation.OverprovisionedReplicas = replicas - needed
+	// index replicas are scaled up even if the node replicas don't change.
+	if len(scalingOperation.IndexReplicas) == 0 || scalingOperation.ScalingDirection == NONE {
+		scalingOperation.ScalingDirection = as.calculateScalingDirection(currentReplicas, replicas)
+	}
+	return scalingOperation
+}
@@ -0,0 +1,58 @@
+package operator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOverprovisionReplicas(t *testing.T) {
+	require.EqualValues(t, 0, overprovisionReplicas(10, 0))
+	require.EqualValues(t, 2, overprovisionReplicas(10, 20))
+	require.EqualValues(t, 1, overprovisionReplicas(3, 10))
+}
+
+func TestOverprovision(t *testing.T) {
+	// 5 replicas are needed for the shards, 2 more are kept as headroom.
+	eds := edsTestFixture(4)
+	eds.Spec.Scaling.OverprovisionPercent = 40
+	esIndices := map[string]ESIndex{
+		"ad1": {Replicas: 3, Primaries: 6, Index: "ad1"},
+	}
+	as := systemUnderTest(eds, nil, nil)
+	actual := as.overprovision(as.calculateScalingOperation(esIndices, nil, UP))
+	require.EqualValues(t, 7, *actual.NodeReplicas, actual.Description)
+	require.EqualValues(t, 2, actual.OverprovisionedReplicas)
+	require.Equal(t, UP, actual.ScalingDirection)
+
+	// the scaling operation is computed for the needed replicas.
+	eds = edsTestFixture(7)
+	eds.Spec.Scaling.OverprovisionPercent = 40
+	eds.Status.OverprovisionedReplicas = 2
+	as = systemUnderTest(eds, nil, nil)
+	require.EqualValues(t, 5, *as.eds.Spec.Replicas)
+	actual = as.overprovision(noopScalingOperation("Nothing to do"))
+	require.EqualValues(t, 7, *actual.NodeReplicas)
+	require.Equal(t, NONE, actual.ScalingDirection)
+
+	// the headroom is bounded by maxReplicas.
+	eds.Spec.Scaling.MaxReplicas = 6
+	as = systemUnderTest(eds, nil, nil)
+	actual = as.overprovision(noopScalingOperation("Nothing to do"))
+	require.EqualValues(t, 6, *actual.NodeReplicas)
+	require.EqualValues(t, 1, actual.OverprovisionedReplicas)
+	require.Equal(t, DOWN, actual.ScalingDirection, actual.Description)
+
+	// disabling the overprovisioning removes the headroom.
+	eds.Spec.Scaling.MaxReplicas = 0
+	eds.Spec.Scaling.OverprovisionPercent = 0
+	as = systemUnderTest(eds, nil, nil)
+	actual = as.overprovision(noopScalingOperation("Nothing to do"))
+	require.EqualValues(t, 5, *actual.NodeReplicas)
+	require.EqualValues(t, 0, actual.OverprovisionedReplicas)
+	require.Equal(t, DOWN, actual.ScalingDirection)
+
+	// nothing changes without overprovisioning.
+	as = systemUnderTest(edsTestFixture(4), nil, nil)
+	require.Nil(t, as.overprovision(noopScalingOperation("Nothing to do")).NodeReplicas)
+}
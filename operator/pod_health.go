@@ -0,0 +1,152 @@
+package operator
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	v1 "k8s.io/api/core/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+)
+
+const (
+	// recentPodFailureWindow is the time a container which is running
+	// again is still considered failing after it was terminated with an
+	// error, e.g. because it was OOMKilled.
+	recentPodFailureWindow = 10 * time.Minute
+)
+
+// failingWaitingReasons are the reasons of a waiting container which
+// indicate that the container is failing rather than starting up.
+var failingWaitingReasons = map[string]bool{
+	"CrashLoopBackOff":           true,
+	"ImagePullBackOff":           true,
+	"ErrImagePull":               true,
+	"CreateContainerConfigError": true,
+	"RunContainerError":          true,
+}
+
+// podFailures returns the failing containers of the pods sorted by pod and
+// container name. Pods failing because of their node are reported without
+// their containers, whose status is stale or caused by the node. The nodes
+// of the pods are only checked if a node lister is given.
+func podFailures(pods []*v1.Pod, nodes corelisters.NodeLister, now time.Time) []zv1.ElasticsearchDataSetPodFailure {
+	failures := make([]zv1.ElasticsearchDataSetPodFailure, 0)
+	for _, pod := range pods {
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+
+		if failure := podNodeFailure(pod, nodes); failure != nil {
+			failure.PodName = pod.Name
+			failures = append(failures, *failure)
+			continue
+		}
+
+		for _, statuses := range [][]v1.ContainerStatus{pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses} {
+			for _, status := range statuses {
+				if failure := containerFailure(status, now); failure != nil {
+					failure.PodName = pod.Name
+					failures = append(failures, *failure)
+				}
+			}
+		}
+	}
+
+	sort.Slice(failures, func(i, j int) bool {
+		if failures[i].PodName != failures[j].PodName {
+			return failures[i].PodName < failures[j].PodName
+		}
+		return failures[i].ContainerName < failures[j].ContainerName
+	})
+	return failures
+}
+
+// podNodeFailure returns the failure of a pod which was evicted, can't be
+// scheduled or runs on a NotReady node, or nil if the pod isn't failing
+// because of its node.
+func podNodeFailure(pod *v1.Pod, nodes corelisters.NodeLister) *zv1.ElasticsearchDataSetPodFailure {
+	if pod.Status.Phase == v1.PodFailed && pod.Status.Reason == "Evicted" {
+		return &zv1.ElasticsearchDataSetPodFailure{Reason: "Evicted", Message: pod.Status.Message}
+	}
+
+	for _, condition := range pod.Status.Conditions {
+		switch {
+		// set when the pod is about to be evicted, e.g. because of a
+		// NoExecute taint of the node or node pressure.
+		case condition.Type == v1.DisruptionTarget && condition.Status == v1.ConditionTrue:
+			return &zv1.ElasticsearchDataSetPodFailure{Reason: "Evicted", Message: condition.Message}
+		case condition.Type == v1.PodScheduled && condition.Status == v1.ConditionFalse &&
+			condition.Reason == v1.PodReasonUnschedulable:
+			return &zv1.ElasticsearchDataSetPodFailure{Reason: v1.PodReasonUnschedulable, Message: condition.Message}
+		}
+	}
+
+	if nodes == nil || pod.Spec.NodeName == "" {
+		return nil
+	}
+	node, err := nodes.Get(pod.Spec.NodeName)
+	if err != nil || nodeReady(node) {
+		return nil
+	}
+	return &zv1.ElasticsearchDataSetPodFailure{
+		Reason:  "NodeNotReady",
+		Message: fmt.Sprintf("Node %s is NotReady.", node.Name),
+	}
+}
+
+// containerFailure returns the failure of the container or nil if the
+// container is healthy.
+func containerFailure(status v1.ContainerStatus, now time.Time) *zv1.ElasticsearchDataSetPodFailure {
+	failure := &zv1.ElasticsearchDataSetPodFailure{
+		ContainerName: status.Name,
+		RestartCount:  status.RestartCount,
+	}
+
+	if last := status.LastTerminationState.Terminated; last != nil {
+		failure.ExitReason = last.Reason
+		failure.ExitCode = last.ExitCode
+	}
+
+	switch {
+	case status.State.Waiting != nil:
+		if !failingWaitingReasons[status.State.Waiting.Reason] {
+			return nil
+		}
+		failure.Reason = status.State.Waiting.Reason
+	case status.State.Terminated != nil:
+		terminated := status.State.Terminated
+		if terminated.ExitCode == 0 && terminated.Reason != "OOMKilled" {
+			return nil
+		}
+		failure.Reason = terminated.Reason
+		failure.ExitReason = terminated.Reason
+		failure.ExitCode = terminated.ExitCode
+	case status.State.Running != nil:
+		// a container which was recently restarted after an error
+		// is still considered failing as it's likely to fail again.
+		last := status.LastTerminationState.Terminated
+		if last == nil || (last.ExitCode == 0 && last.Reason != "OOMKilled") ||
+			now.Sub(last.FinishedAt.Time) > recentPodFailureWindow {
+			return nil
+		}
+		failure.Reason = last.Reason
+	default:
+		return nil
+	}
+
+	if failure.Reason == "" {
+		failure.Reason = "Error"
+	}
+	return failure
+}
+
+// failingPodCount returns the number of distinct pods with failures.
+func failingPodCount(failures []zv1.ElasticsearchDataSetPodFailure) int {
+	pods := make(map[string]struct{}, len(failures))
+	for _, failure := range failures {
+		pods[failure.PodName] = struct{}{}
+	}
+	return len(pods)
+}
@@ -0,0 +1,198 @@
+package operator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestPodFailures(t *testing.T) {
+	now := time.Now()
+
+	pod := func(name string, statuses ...v1.ContainerStatus) *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status:     v1.PodStatus{ContainerStatuses: statuses},
+		}
+	}
+
+	oomKilled := v1.ContainerState{
+		Terminated: &v1.ContainerStateTerminated{
+			Reason:     "OOMKilled",
+			ExitCode:   137,
+			FinishedAt: metav1.NewTime(now.Add(-time.Minute)),
+		},
+	}
+
+	pods := []*v1.Pod{
+		pod("es-data-2", v1.ContainerStatus{
+			Name:                 "elasticsearch",
+			State:                v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+			LastTerminationState: oomKilled,
+			RestartCount:         5,
+		}),
+		pod("es-data-1", v1.ContainerStatus{
+			Name:                 "elasticsearch",
+			State:                v1.ContainerState{Running: &v1.ContainerStateRunning{}},
+			LastTerminationState: oomKilled,
+			RestartCount:         1,
+		}),
+		// healthy pod which was OOMKilled a long time ago.
+		pod("es-data-0", v1.ContainerStatus{
+			Name:  "elasticsearch",
+			State: v1.ContainerState{Running: &v1.ContainerStateRunning{}},
+			LastTerminationState: v1.ContainerState{
+				Terminated: &v1.ContainerStateTerminated{
+					Reason:     "OOMKilled",
+					ExitCode:   137,
+					FinishedAt: metav1.NewTime(now.Add(-time.Hour)),
+				},
+			},
+			RestartCount: 1,
+		}),
+		// starting pod.
+		pod("es-data-3", v1.ContainerStatus{
+			Name:  "elasticsearch",
+			State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "ContainerCreating"}},
+		}),
+		pod("es-data-4", v1.ContainerStatus{
+			Name: "elasticsearch",
+			State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{
+				ExitCode: 1,
+			}},
+		}),
+	}
+
+	failures := podFailures(pods, nil, now)
+	assert.Equal(t, []zv1.ElasticsearchDataSetPodFailure{
+		{
+			PodName:       "es-data-1",
+			ContainerName: "elasticsearch",
+			Reason:        "OOMKilled",
+			ExitReason:    "OOMKilled",
+			ExitCode:      137,
+			RestartCount:  1,
+		},
+		{
+			PodName:       "es-data-2",
+			ContainerName: "elasticsearch",
+			Reason:        "CrashLoopBackOff",
+			ExitReason:    "OOMKilled",
+			ExitCode:      137,
+			RestartCount:  5,
+		},
+		{
+			PodName:       "es-data-4",
+			ContainerName: "elasticsearch",
+			Reason:        "Error",
+			ExitCode:      1,
+		},
+	}, failures)
+	assert.Equal(t, 3, failingPodCount(failures))
+
+	// pods being deleted are ignored.
+	deleted := pods[1].DeepCopy()
+	deleted.DeletionTimestamp = &metav1.Time{Time: now}
+	assert.Empty(t, podFailures([]*v1.Pod{deleted}, nil, now))
+}
+
+func TestPodNodeFailures(t *testing.T) {
+	now := time.Now()
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	require.NoError(t, indexer.Add(zoneNode("ready", "a", true)))
+	require.NoError(t, indexer.Add(zoneNode("not-ready", "a", false)))
+	nodes := corelisters.NewNodeLister(indexer)
+
+	// the containers of pods failing because of their node aren't
+	// reported.
+	crashLooping := []v1.ContainerStatus{{
+		Name:  "elasticsearch",
+		State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+	}}
+	pods := []*v1.Pod{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "es-data-0"},
+			Spec:       v1.PodSpec{NodeName: "not-ready"},
+			Status: v1.PodStatus{
+				Phase:             v1.PodRunning,
+				ContainerStatuses: crashLooping,
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "es-data-1"},
+			Spec:       v1.PodSpec{NodeName: "ready"},
+			Status: v1.PodStatus{
+				Phase:   v1.PodFailed,
+				Reason:  "Evicted",
+				Message: "The node was low on resource: ephemeral-storage.",
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "es-data-2"},
+			Spec:       v1.PodSpec{NodeName: "ready"},
+			Status: v1.PodStatus{
+				Phase: v1.PodRunning,
+				Conditions: []v1.PodCondition{{
+					Type:    v1.DisruptionTarget,
+					Status:  v1.ConditionTrue,
+					Reason:  "DeletionByTaintManager",
+					Message: "Taint manager: deleting due to NoExecute taint",
+				}},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "es-data-3"},
+			Status: v1.PodStatus{
+				Phase: v1.PodPending,
+				Conditions: []v1.PodCondition{{
+					Type:    v1.PodScheduled,
+					Status:  v1.ConditionFalse,
+					Reason:  v1.PodReasonUnschedulable,
+					Message: "0/3 nodes are available: 3 Insufficient memory.",
+				}},
+			},
+		},
+		// pending pod which isn't scheduled yet.
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "es-data-4"},
+			Status: v1.PodStatus{
+				Phase:      v1.PodPending,
+				Conditions: []v1.PodCondition{{Type: v1.PodScheduled, Status: v1.ConditionFalse}},
+			},
+		},
+		// healthy pod on a ready node.
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "es-data-5"},
+			Spec:       v1.PodSpec{NodeName: "ready"},
+			Status:     v1.PodStatus{Phase: v1.PodRunning},
+		},
+		// pod on a node which isn't known (yet).
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "es-data-6"},
+			Spec:       v1.PodSpec{NodeName: "unknown"},
+			Status:     v1.PodStatus{Phase: v1.PodRunning},
+		},
+	}
+
+	failures := podFailures(pods, nodes, now)
+	assert.Equal(t, []zv1.ElasticsearchDataSetPodFailure{
+		{PodName: "es-data-0", Reason: "NodeNotReady", Message: "Node not-ready is NotReady."},
+		{PodName: "es-data-1", Reason: "Evicted", Message: "The node was low on resource: ephemeral-storage."},
+		{PodName: "es-data-2", Reason: "Evicted", Message: "Taint manager: deleting due to NoExecute taint"},
+		{PodName: "es-data-3", Reason: "Unschedulable", Message: "0/3 nodes are available: 3 Insufficient memory."},
+	}, failures)
+	assert.Equal(t, 4, failingPodCount(failures))
+
+	// the nodes aren't checked without a lister.
+	assert.Equal(t, []zv1.ElasticsearchDataSetPodFailure{
+		{PodName: "es-data-0", ContainerName: "elasticsearch", Reason: "CrashLoopBackOff"},
+	}, podFailures(pods[:1], nil, now))
+}
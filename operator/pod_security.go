@@ -0,0 +1,319 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	podSecurityEnforceLabelKey = "pod-security.kubernetes.io/enforce"
+	podSecurityBaseline        = "baseline"
+	podSecurityRestricted      = "restricted"
+
+	// elasticsearchUID is the user of the official Elasticsearch images.
+	elasticsearchUID = 1000
+
+	elasticsearchConfigPath      = "/usr/share/elasticsearch/config"
+	podSecurityConfigVolume      = "es-operator-config"
+	podSecurityTmpVolume         = "es-operator-tmp"
+	podSecurityLogsVolume        = "es-operator-logs"
+	podSecurityInitConfigName    = "es-operator-init-config"
+	podSecurityInitConfigMountAt = "/mnt/config"
+)
+
+// podSecurityWritableDirs are the directories of the Elasticsearch
+// container backed by emptyDir volumes when the root filesystem is
+// read-only.
+var podSecurityWritableDirs = []struct {
+	volume string
+	path   string
+}{
+	{volume: podSecurityTmpVolume, path: "/tmp"},
+	{volume: podSecurityLogsVolume, path: "/usr/share/elasticsearch/logs"},
+}
+
+// baselineCapabilities are the capabilities which may be added in the
+// baseline profile.
+var baselineCapabilities = map[v1.Capability]struct{}{
+	"AUDIT_WRITE": {}, "CHOWN": {}, "DAC_OVERRIDE": {}, "FOWNER": {}, "FSETID": {}, "KILL": {}, "MKNOD": {},
+	"NET_BIND_SERVICE": {}, "SETFCAP": {}, "SETGID": {}, "SETPCAP": {}, "SETUID": {}, "SYS_CHROOT": {},
+}
+
+// baselineSELinuxTypes are the SELinux types which may be set in the
+// baseline profile.
+var baselineSELinuxTypes = map[string]struct{}{
+	"": {}, "container_t": {}, "container_init_t": {}, "container_kvm_t": {}, "container_engine_t": {},
+}
+
+// safeSysctls are the sysctls which may be set in the baseline profile.
+var safeSysctls = map[string]struct{}{
+	"kernel.shm_rmid_forced": {}, "net.ipv4.ip_local_port_range": {}, "net.ipv4.ip_unprivileged_port_start": {},
+	"net.ipv4.tcp_syncookies": {}, "net.ipv4.ping_group_range": {}, "net.ipv4.ip_local_reserved_ports": {},
+	"net.ipv4.tcp_keepalive_time": {}, "net.ipv4.tcp_fin_timeout": {}, "net.ipv4.tcp_keepalive_intvl": {},
+	"net.ipv4.tcp_keepalive_probes": {},
+}
+
+// restrictedVolumeType returns false if the volume is of a type not
+// allowed in the restricted profile.
+func restrictedVolumeType(volume v1.Volume) bool {
+	source := volume.VolumeSource
+	return source.ConfigMap != nil || source.CSI != nil || source.DownwardAPI != nil || source.EmptyDir != nil ||
+		source.Ephemeral != nil || source.PersistentVolumeClaim != nil || source.Projected != nil || source.Secret != nil
+}
+
+// injectPodSecurity makes the pod spec comply with the profile. Fields set
+// in the pod template are left untouched, such that they are reported by
+// the validation if they violate the profile. The Elasticsearch container
+// gets writable directories for a read-only root filesystem and its config
+// directory is copied from the image into a volume by an init container.
+func injectPodSecurity(spec *v1.PodSpec, config *zv1.ElasticsearchDataSetPodSecurity) {
+	if config == nil || config.Profile != podSecurityRestricted || len(spec.Containers) == 0 {
+		return
+	}
+
+	if spec.SecurityContext == nil {
+		spec.SecurityContext = &v1.PodSecurityContext{}
+	}
+	podContext := spec.SecurityContext
+	if podContext.RunAsNonRoot == nil {
+		runAsNonRoot := true
+		podContext.RunAsNonRoot = &runAsNonRoot
+	}
+	if podContext.RunAsUser == nil {
+		runAsUser := int64(elasticsearchUID)
+		podContext.RunAsUser = &runAsUser
+	}
+	if podContext.FSGroup == nil {
+		fsGroup := int64(elasticsearchUID)
+		podContext.FSGroup = &fsGroup
+	}
+	if podContext.SeccompProfile == nil {
+		podContext.SeccompProfile = &v1.SeccompProfile{Type: v1.SeccompProfileTypeRuntimeDefault}
+	}
+
+	elasticsearch := &spec.Containers[0]
+	for _, dir := range podSecurityWritableDirs {
+		addEmptyDirMount(spec, elasticsearch, dir.volume, dir.path)
+	}
+	if !mountedAt(elasticsearch, elasticsearchConfigPath) {
+		addEmptyDirMount(spec, elasticsearch, podSecurityConfigVolume, elasticsearchConfigPath)
+		spec.InitContainers = append(spec.InitContainers, v1.Container{
+			Name:    podSecurityInitConfigName,
+			Image:   elasticsearch.Image,
+			Command: []string{"sh", "-c", fmt.Sprintf("cp -a %s/. %s/", elasticsearchConfigPath, podSecurityInitConfigMountAt)},
+			VolumeMounts: []v1.VolumeMount{
+				{Name: podSecurityConfigVolume, MountPath: podSecurityInitConfigMountAt},
+			},
+		})
+	}
+
+	for i := range spec.InitContainers {
+		restrictContainer(&spec.InitContainers[i])
+	}
+	for i := range spec.Containers {
+		restrictContainer(&spec.Containers[i])
+	}
+}
+
+// restrictContainer sets the unset fields of the security context of the
+// container as required by the restricted profile.
+func restrictContainer(container *v1.Container) {
+	if container.SecurityContext == nil {
+		container.SecurityContext = &v1.SecurityContext{}
+	}
+	sc := container.SecurityContext
+	if sc.AllowPrivilegeEscalation == nil {
+		allowPrivilegeEscalation := false
+		sc.AllowPrivilegeEscalation = &allowPrivilegeEscalation
+	}
+	if sc.ReadOnlyRootFilesystem == nil {
+		readOnlyRootFilesystem := true
+		sc.ReadOnlyRootFilesystem = &readOnlyRootFilesystem
+	}
+	if sc.Capabilities == nil {
+		sc.Capabilities = &v1.Capabilities{}
+	}
+	for _, capability := range sc.Capabilities.Drop {
+		if capability == "ALL" {
+			return
+		}
+	}
+	sc.Capabilities.Drop = append(sc.Capabilities.Drop, "ALL")
+}
+
+// addEmptyDirMount mounts an emptyDir volume at the path of the container
+// unless something is mounted there already.
+func addEmptyDirMount(spec *v1.PodSpec, container *v1.Container, volume, path string) {
+	if mountedAt(container, path) {
+		return
+	}
+	spec.Volumes = append(spec.Volumes, v1.Volume{
+		Name:         volume,
+		VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}},
+	})
+	container.VolumeMounts = append(container.VolumeMounts, v1.VolumeMount{Name: volume, MountPath: path})
+}
+
+func mountedAt(container *v1.Container, path string) bool {
+	for _, mount := range container.VolumeMounts {
+		if mount.MountPath == path && mount.SubPath == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// podSecurityViolations returns the violations of the Pod Security Standard
+// level by the pod spec, following the checks of the Pod Security
+// Admission.
+func podSecurityViolations(spec *v1.PodSpec, level string) []string {
+	if level != podSecurityBaseline && level != podSecurityRestricted {
+		return nil
+	}
+	restricted := level == podSecurityRestricted
+
+	violations := make([]string, 0)
+	if spec.HostNetwork || spec.HostPID || spec.HostIPC {
+		violations = append(violations, "hostNetwork, hostPID and hostIPC must not be set")
+	}
+	for _, volume := range spec.Volumes {
+		if volume.HostPath != nil {
+			violations = append(violations, fmt.Sprintf("volume %q must not be a hostPath volume", volume.Name))
+		} else if restricted && !restrictedVolumeType(volume) {
+			violations = append(violations, fmt.Sprintf("volume %q must be of type configMap, csi, downwardAPI, emptyDir, ephemeral, persistentVolumeClaim, projected or secret", volume.Name))
+		}
+	}
+
+	podContext := spec.SecurityContext
+	if podContext == nil {
+		podContext = &v1.PodSecurityContext{}
+	}
+	for _, sysctl := range podContext.Sysctls {
+		if _, ok := safeSysctls[sysctl.Name]; !ok {
+			violations = append(violations, fmt.Sprintf("sysctl %s must not be set, configure it on the nodes instead", sysctl.Name))
+		}
+	}
+	if podContext.SeccompProfile != nil && podContext.SeccompProfile.Type == v1.SeccompProfileTypeUnconfined {
+		violations = append(violations, "securityContext.seccompProfile.type must not be Unconfined")
+	}
+	if restricted && podContext.RunAsUser != nil && *podContext.RunAsUser == 0 {
+		violations = append(violations, "securityContext.runAsUser must not be 0")
+	}
+
+	containers := make([]v1.Container, 0, len(spec.InitContainers)+len(spec.Containers))
+	containers = append(containers, spec.InitContainers...)
+	containers = append(containers, spec.Containers...)
+	for _, container := range containers {
+		for _, violation := range containerSecurityViolations(container, podContext, restricted) {
+			violations = append(violations, fmt.Sprintf("container %q: %s", container.Name, violation))
+		}
+	}
+	return violations
+}
+
+// containerSecurityViolations returns the violations of the baseline or
+// restricted level by the container.
+func containerSecurityViolations(container v1.Container, podContext *v1.PodSecurityContext, restricted bool) []string {
+	violations := make([]string, 0)
+	sc := container.SecurityContext
+	if sc == nil {
+		sc = &v1.SecurityContext{}
+	}
+	capabilities := sc.Capabilities
+	if capabilities == nil {
+		capabilities = &v1.Capabilities{}
+	}
+
+	if sc.Privileged != nil && *sc.Privileged {
+		violations = append(violations, "securityContext.privileged must not be true")
+	}
+	for _, port := range container.Ports {
+		if port.HostPort != 0 {
+			violations = append(violations, fmt.Sprintf("hostPort %d must not be set", port.HostPort))
+		}
+	}
+	for _, capability := range capabilities.Add {
+		if restricted && capability != "NET_BIND_SERVICE" {
+			violations = append(violations, fmt.Sprintf("capability %s must not be added, only NET_BIND_SERVICE is allowed", capability))
+		} else if _, ok := baselineCapabilities[capability]; !ok && !restricted {
+			violations = append(violations, fmt.Sprintf("capability %s must not be added", capability))
+		}
+	}
+	if sc.SeccompProfile != nil && sc.SeccompProfile.Type == v1.SeccompProfileTypeUnconfined {
+		violations = append(violations, "securityContext.seccompProfile.type must not be Unconfined")
+	}
+	if sc.AppArmorProfile != nil && sc.AppArmorProfile.Type == v1.AppArmorProfileTypeUnconfined {
+		violations = append(violations, "securityContext.appArmorProfile.type must not be Unconfined")
+	}
+	if options := sc.SELinuxOptions; options != nil {
+		if _, ok := baselineSELinuxTypes[options.Type]; !ok {
+			violations = append(violations, fmt.Sprintf("securityContext.seLinuxOptions.type %s must not be set, only container_t, container_init_t, container_kvm_t and container_engine_t are allowed", options.Type))
+		}
+		if options.User != "" || options.Role != "" {
+			violations = append(violations, "securityContext.seLinuxOptions.user and role must not be set")
+		}
+	}
+	if !restricted {
+		return violations
+	}
+
+	if sc.AllowPrivilegeEscalation == nil || *sc.AllowPrivilegeEscalation {
+		violations = append(violations, "securityContext.allowPrivilegeEscalation must be false")
+	}
+	dropsAll := false
+	for _, capability := range capabilities.Drop {
+		dropsAll = dropsAll || capability == "ALL"
+	}
+	if !dropsAll {
+		violations = append(violations, "securityContext.capabilities.drop must contain ALL")
+	}
+	runAsNonRoot := sc.RunAsNonRoot
+	if runAsNonRoot == nil {
+		runAsNonRoot = podContext.RunAsNonRoot
+	}
+	if runAsNonRoot == nil || !*runAsNonRoot {
+		violations = append(violations, "securityContext.runAsNonRoot must be true")
+	}
+	if sc.RunAsUser != nil && *sc.RunAsUser == 0 {
+		violations = append(violations, "securityContext.runAsUser must not be 0")
+	}
+	seccomp := sc.SeccompProfile
+	if seccomp == nil {
+		seccomp = podContext.SeccompProfile
+	}
+	if seccomp == nil || (seccomp.Type != v1.SeccompProfileTypeRuntimeDefault && seccomp.Type != v1.SeccompProfileTypeLocalhost) {
+		violations = append(violations, "securityContext.seccompProfile.type must be RuntimeDefault or Localhost")
+	}
+	return violations
+}
+
+// validatePodSecurity validates the pod template against the Pod Security
+// Standard enforced on the namespace of the EDS, such that violations are
+// reported before the StatefulSet fails to create pods.
+func (r *EDSResource) validatePodSecurity(ctx context.Context) error {
+	namespace, err := r.kube.CoreV1().Namespaces().Get(ctx, r.eds.Namespace, metav1.GetOptions{})
+	if err != nil {
+		log.Warnf("Failed to get namespace %s to validate the pod security: %v", r.eds.Namespace, err)
+		return nil
+	}
+	level := namespace.Labels[podSecurityEnforceLabelKey]
+
+	violations := podSecurityViolations(&r.PodTemplateSpec().Spec, level)
+	if len(violations) == 0 {
+		return nil
+	}
+
+	message := fmt.Sprintf("Pod template violates the %s Pod Security Standard enforced on namespace %s: %s",
+		level, r.eds.Namespace, strings.Join(violations, "; "))
+	if r.eds.Spec.PodSecurity == nil {
+		message += ". Set spec.podSecurity.profile to restricted to comply where possible"
+	}
+	r.recorder.Event(r.eds, v1.EventTypeWarning, "PodSecurityViolation", message)
+	return fmt.Errorf("%s", message)
+}
@@ -0,0 +1,103 @@
+package operator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	"github.com/zalando-incubator/es-operator/pkg/clientset"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestInjectPodSecurity(t *testing.T) {
+	spec := &v1.PodSpec{
+		Containers: []v1.Container{
+			{
+				Name:  "elasticsearch",
+				Image: "elasticsearch:8.15.0",
+				VolumeMounts: []v1.VolumeMount{
+					{Name: "config", MountPath: elasticsearchConfigPath + "/elasticsearch.yml", SubPath: "elasticsearch.yml"},
+				},
+			},
+			{Name: "exporter", SecurityContext: &v1.SecurityContext{Capabilities: &v1.Capabilities{Drop: []v1.Capability{"ALL"}}}},
+		},
+		Volumes: []v1.Volume{
+			{Name: "config", VolumeSource: v1.VolumeSource{ConfigMap: &v1.ConfigMapVolumeSource{}}},
+		},
+	}
+	assert.NotEmpty(t, podSecurityViolations(spec, podSecurityRestricted))
+
+	injectPodSecurity(spec, &zv1.ElasticsearchDataSetPodSecurity{Profile: podSecurityRestricted})
+	assert.Empty(t, podSecurityViolations(spec, podSecurityRestricted))
+
+	require.Len(t, spec.InitContainers, 1)
+	assert.Equal(t, podSecurityInitConfigName, spec.InitContainers[0].Name)
+	assert.Equal(t, "elasticsearch:8.15.0", spec.InitContainers[0].Image)
+	assert.Equal(t, []v1.VolumeMount{
+		{Name: "config", MountPath: elasticsearchConfigPath + "/elasticsearch.yml", SubPath: "elasticsearch.yml"},
+		{Name: podSecurityTmpVolume, MountPath: "/tmp"},
+		{Name: podSecurityLogsVolume, MountPath: "/usr/share/elasticsearch/logs"},
+		{Name: podSecurityConfigVolume, MountPath: elasticsearchConfigPath},
+	}, spec.Containers[0].VolumeMounts)
+	assert.True(t, *spec.Containers[0].SecurityContext.ReadOnlyRootFilesystem)
+	assert.Equal(t, []v1.Capability{"ALL"}, spec.Containers[1].SecurityContext.Capabilities.Drop)
+	assert.EqualValues(t, elasticsearchUID, *spec.SecurityContext.RunAsUser)
+}
+
+func TestPodSecurityViolations(t *testing.T) {
+	privileged := true
+	spec := &v1.PodSpec{
+		InitContainers: []v1.Container{
+			{Name: "sysctl", SecurityContext: &v1.SecurityContext{Privileged: &privileged}},
+		},
+		Containers: []v1.Container{
+			{Name: "elasticsearch", Ports: []v1.ContainerPort{{ContainerPort: 9200, HostPort: 9200}}},
+		},
+		Volumes: []v1.Volume{
+			{Name: "data", VolumeSource: v1.VolumeSource{HostPath: &v1.HostPathVolumeSource{Path: "/data"}}},
+		},
+	}
+
+	assert.Empty(t, podSecurityViolations(spec, "privileged"))
+	assert.Equal(t, []string{
+		`volume "data" must not be a hostPath volume`,
+		`container "sysctl": securityContext.privileged must not be true`,
+		`container "elasticsearch": hostPort 9200 must not be set`,
+	}, podSecurityViolations(spec, podSecurityBaseline))
+	assert.Contains(t, podSecurityViolations(spec, podSecurityRestricted), `container "elasticsearch": securityContext.runAsNonRoot must be true`)
+}
+
+func TestValidatePodSecurity(t *testing.T) {
+	namespace := &v1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "default",
+			Labels: map[string]string{podSecurityEnforceLabelKey: podSecurityRestricted},
+		},
+	}
+	recorder := record.NewFakeRecorder(10)
+	r := &EDSResource{
+		eds: &zv1.ElasticsearchDataSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "es-data", Namespace: "default"},
+			Spec: zv1.ElasticsearchDataSetSpec{
+				Template: zv1.PodTemplateSpec{
+					Spec: v1.PodSpec{Containers: []v1.Container{{Name: "elasticsearch", Image: "elasticsearch:8.15.0"}}},
+				},
+			},
+		},
+		kube:     &clientset.Clientset{Interface: fake.NewSimpleClientset(namespace)},
+		recorder: recorder,
+	}
+
+	err := r.validatePodSecurity(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Set spec.podSecurity.profile to restricted")
+	assert.Contains(t, <-recorder.Events, "PodSecurityViolation")
+
+	r.eds.Spec.PodSecurity = &zv1.ElasticsearchDataSetPodSecurity{Profile: podSecurityRestricted}
+	require.NoError(t, r.validatePodSecurity(context.Background()))
+}
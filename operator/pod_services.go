@@ -0,0 +1,211 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	// podServiceOrdinalLabelKey labels the per-pod Services with the
+	// ordinal of their pod.
+	podServiceOrdinalLabelKey  = "es-operator.zalando.org/pod-ordinal"
+	elasticsearchTransportPort = 9300
+)
+
+// podServiceName returns the name of the Service of the pod with the
+// ordinal, which is the name of the pod.
+func podServiceName(name string, ordinal int32) string {
+	return fmt.Sprintf("%s-%d", name, ordinal)
+}
+
+// podServicePorts returns the ports of the per-pod Services, the ports of the
+// EDS service followed by the Elasticsearch transport port.
+func podServicePorts(ports []zv1.ElasticsearchDataSetPort) []v1.ServicePort {
+	return append(edsServicePorts(ports), v1.ServicePort{
+		Name:       "transport",
+		Protocol:   v1.ProtocolTCP,
+		Port:       elasticsearchTransportPort,
+		TargetPort: intstr.FromInt(elasticsearchTransportPort),
+	})
+}
+
+// podServiceReplicas returns the number of pods which need a Service. Pods
+// being removed by a scale-down keep their Service until they are gone.
+func (r *EDSResource) podServiceReplicas() int32 {
+	if r.eds.Spec.PodServices == nil {
+		return 0
+	}
+	replicas := r.Replicas()
+	if r.eds.Status.Replicas > replicas {
+		replicas = r.eds.Status.Replicas
+	}
+	return replicas
+}
+
+// applyPodService sets the desired spec and metadata of the Service of the
+// pod with the ordinal. Returns true if the Service was changed.
+func (r *EDSResource) applyPodService(svc *v1.Service, ordinal int32) bool {
+	config := r.eds.Spec.PodServices
+	serviceType := config.Type
+	if serviceType == "" {
+		serviceType = v1.ServiceTypeClusterIP
+	}
+	selector := r.LabelSelector()
+	selector[appsv1.StatefulSetPodNameLabel] = podServiceName(r.eds.Name, ordinal)
+	ports := podServicePorts(r.eds.Spec.ExtraPorts)
+
+	additionalLabels, annotations := r.AdditionalMetadata()
+	for key, value := range config.Annotations {
+		annotations[key] = value
+	}
+	changed := applyAdditionalMetadata(&svc.ObjectMeta, additionalLabels, annotations)
+	for k, v := range r.eds.Labels {
+		if current, ok := svc.Labels[k]; !ok || current != v {
+			svc.Labels[k] = v
+			changed = true
+		}
+	}
+	for k, v := range map[string]string{
+		esDataSetLabelKey:         r.eds.Name,
+		podServiceOrdinalLabelKey: strconv.Itoa(int(ordinal)),
+	} {
+		if current, ok := svc.Labels[k]; !ok || current != v {
+			svc.Labels[k] = v
+			changed = true
+		}
+	}
+
+	if svc.Spec.Type != serviceType {
+		// the node ports are allocated again for the new type.
+		for i := range svc.Spec.Ports {
+			svc.Spec.Ports[i].NodePort = 0
+		}
+		svc.Spec.Type = serviceType
+		changed = true
+	}
+	if !equality.Semantic.DeepEqual(svc.Spec.Selector, selector) {
+		svc.Spec.Selector = selector
+		changed = true
+	}
+	if svc.Spec.PublishNotReadyAddresses != config.PublishNotReadyAddresses {
+		svc.Spec.PublishNotReadyAddresses = config.PublishNotReadyAddresses
+		changed = true
+	}
+	if !podServicePortsEqual(svc.Spec.Ports, ports) {
+		svc.Spec.Ports = ports
+		changed = true
+	}
+	return changed
+}
+
+// podServicePortsEqual compares the ports ignoring the node ports allocated
+// by Kubernetes.
+func podServicePortsEqual(current, desired []v1.ServicePort) bool {
+	if len(current) != len(desired) {
+		return false
+	}
+	for i := range current {
+		port := current[i]
+		port.NodePort = 0
+		if !equality.Semantic.DeepEqual(port, desired[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// ensurePodServices creates a Service for each pod of the EDS, keeps them up
+// to date and deletes the Services of pods removed by a scale-down or of all
+// pods once the per-pod Services are disabled.
+func (r *EDSResource) ensurePodServices(ctx context.Context) error {
+	selector := labels.Set{esDataSetLabelKey: r.eds.Name}.String() + "," + podServiceOrdinalLabelKey
+	services, err := r.kube.CoreV1().Services(r.eds.Namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return fmt.Errorf("failed to list Services of pods for %s %s/%s: %v", r.eds.Kind, r.eds.Namespace, r.eds.Name, err)
+	}
+
+	existing := make(map[int32]*v1.Service, len(services.Items))
+	for i := range services.Items {
+		svc := &services.Items[i]
+		if !isOwnedReference(r, svc.ObjectMeta) {
+			continue
+		}
+		ordinal, err := strconv.Atoi(svc.Labels[podServiceOrdinalLabelKey])
+		if err != nil {
+			continue
+		}
+		existing[int32(ordinal)] = svc
+	}
+
+	replicas := r.podServiceReplicas()
+	for ordinal := int32(0); ordinal < replicas; ordinal++ {
+		svc, ok := existing[ordinal]
+		if !ok {
+			svc = &v1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      podServiceName(r.eds.Name, ordinal),
+					Namespace: r.eds.Namespace,
+					OwnerReferences: []metav1.OwnerReference{
+						{
+							APIVersion: r.eds.APIVersion,
+							Kind:       r.eds.Kind,
+							Name:       r.eds.Name,
+							UID:        r.eds.UID,
+						},
+					},
+				},
+			}
+			r.applyPodService(svc, ordinal)
+			_, err = r.kube.CoreV1().Services(svc.Namespace).Create(ctx, svc, metav1.CreateOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to create Service %s/%s: %v", svc.Namespace, svc.Name, err)
+			}
+			r.recorder.Event(r.eds, v1.EventTypeNormal, "CreatedService", fmt.Sprintf(
+				"Created Service '%s/%s' for Pod %s",
+				svc.Namespace, svc.Name, podServiceName(r.eds.Name, ordinal),
+			))
+			continue
+		}
+
+		if !r.applyPodService(svc, ordinal) {
+			continue
+		}
+		_, err = r.kube.CoreV1().Services(svc.Namespace).Update(ctx, svc, metav1.UpdateOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to update Service %s/%s: %v", svc.Namespace, svc.Name, err)
+		}
+		r.recorder.Event(r.eds, v1.EventTypeNormal, "UpdatedService", fmt.Sprintf(
+			"Updated Service '%s/%s' for Pod %s",
+			svc.Namespace, svc.Name, podServiceName(r.eds.Name, ordinal),
+		))
+	}
+
+	removed := make([]int32, 0, len(existing))
+	for ordinal := range existing {
+		if ordinal >= replicas {
+			removed = append(removed, ordinal)
+		}
+	}
+	sort.Slice(removed, func(i, j int) bool { return removed[i] < removed[j] })
+	for _, ordinal := range removed {
+		svc := existing[ordinal]
+		err = r.kube.CoreV1().Services(svc.Namespace).Delete(ctx, svc.Name, metav1.DeleteOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to delete Service %s/%s: %v", svc.Namespace, svc.Name, err)
+		}
+		r.recorder.Event(r.eds, v1.EventTypeNormal, "DeletedService", fmt.Sprintf(
+			"Deleted Service '%s/%s' of removed Pod", svc.Namespace, svc.Name,
+		))
+	}
+	return nil
+}
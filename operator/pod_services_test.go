@@ -0,0 +1,84 @@
+package operator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	"github.com/zalando-incubator/es-operator/pkg/clientset"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestEnsurePodServices(t *testing.T) {
+	replicas := int32(3)
+	eds := &zv1.ElasticsearchDataSet{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "zalando.org/v1", Kind: "ElasticsearchDataSet"},
+		ObjectMeta: metav1.ObjectMeta{Name: "es-data", Namespace: "default", UID: "uid"},
+		Spec: zv1.ElasticsearchDataSetSpec{
+			Replicas:    &replicas,
+			PodServices: &zv1.ElasticsearchDataSetPodServices{},
+		},
+	}
+	// a Service not owned by the EDS is left alone.
+	foreign := &v1.Service{ObjectMeta: metav1.ObjectMeta{
+		Name:      "es-data-5",
+		Namespace: "default",
+		Labels:    map[string]string{esDataSetLabelKey: "es-data", podServiceOrdinalLabelKey: "5"},
+	}}
+	kube := fake.NewSimpleClientset(foreign)
+	r := &EDSResource{
+		eds:      eds,
+		kube:     &clientset.Clientset{Interface: kube},
+		recorder: record.NewFakeRecorder(100),
+	}
+	serviceNames := func() []string {
+		services, err := kube.CoreV1().Services("default").List(context.Background(), metav1.ListOptions{})
+		require.NoError(t, err)
+		names := make([]string, 0, len(services.Items))
+		for _, svc := range services.Items {
+			names = append(names, svc.Name)
+		}
+		return names
+	}
+
+	require.NoError(t, r.ensurePodServices(context.Background()))
+	assert.ElementsMatch(t, []string{"es-data-0", "es-data-1", "es-data-2", "es-data-5"}, serviceNames())
+	svc, err := kube.CoreV1().Services("default").Get(context.Background(), "es-data-1", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, v1.ServiceTypeClusterIP, svc.Spec.Type)
+	assert.Equal(t, map[string]string{esDataSetLabelKey: "es-data", "statefulset.kubernetes.io/pod-name": "es-data-1"}, svc.Spec.Selector)
+	require.Len(t, svc.Spec.Ports, 2)
+	assert.Equal(t, "transport", svc.Spec.Ports[1].Name)
+
+	// the configuration is applied to the existing Services.
+	eds.Spec.PodServices = &zv1.ElasticsearchDataSetPodServices{
+		Type:                     v1.ServiceTypeLoadBalancer,
+		PublishNotReadyAddresses: true,
+		Annotations:              map[string]string{"service.beta.kubernetes.io/aws-load-balancer-internal": "true"},
+	}
+	require.NoError(t, r.ensurePodServices(context.Background()))
+	svc, err = kube.CoreV1().Services("default").Get(context.Background(), "es-data-1", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, v1.ServiceTypeLoadBalancer, svc.Spec.Type)
+	assert.True(t, svc.Spec.PublishNotReadyAddresses)
+	assert.Equal(t, "true", svc.Annotations["service.beta.kubernetes.io/aws-load-balancer-internal"])
+
+	// the Services of pods being removed are kept until the pods are gone.
+	replicas = 1
+	eds.Status.Replicas = 3
+	require.NoError(t, r.ensurePodServices(context.Background()))
+	assert.Len(t, serviceNames(), 4)
+	eds.Status.Replicas = 1
+	require.NoError(t, r.ensurePodServices(context.Background()))
+	assert.ElementsMatch(t, []string{"es-data-0", "es-data-5"}, serviceNames())
+
+	// all Services are deleted once disabled.
+	eds.Spec.PodServices = nil
+	require.NoError(t, r.ensurePodServices(context.Background()))
+	assert.ElementsMatch(t, []string{"es-data-5"}, serviceNames())
+}
@@ -0,0 +1,126 @@
+package operator
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+)
+
+const (
+	defaultPredictiveLookaheadSeconds = 1800
+	defaultPredictiveMinSamples       = 120
+
+	// maxBaselineSamples bounds the weight of the past samples of an hour,
+	// so the baseline follows changing usage patterns.
+	maxBaselineSamples = 1000
+)
+
+// predictiveScalingSettings returns the lookahead and the minimum number of
+// samples of the configuration with defaults applied.
+func predictiveScalingSettings(config *zv1.ElasticsearchDataSetPredictiveScaling) (time.Duration, int32) {
+	lookahead := time.Duration(config.LookaheadSeconds) * time.Second
+	if config.LookaheadSeconds <= 0 {
+		lookahead = defaultPredictiveLookaheadSeconds * time.Second
+	}
+	minSamples := config.MinSamples
+	if minSamples <= 0 {
+		minSamples = defaultPredictiveMinSamples
+	}
+	return lookahead, minSamples
+}
+
+// predictiveScalingEnabled returns true if predictive scaling is enabled for
+// the EDS and not disabled by its feature gate.
+func predictiveScalingEnabled(eds *zv1.ElasticsearchDataSet) bool {
+	scaling := eds.Spec.Scaling
+	return featureEnabled(PredictiveScaling) && scaling != nil && scaling.Predictive != nil && scaling.Predictive.Enabled
+}
+
+// updateBaseline adds the sample to the average of its hour of the week.
+func updateBaseline(baseline []zv1.ElasticsearchMetricBaseline, sample zv1.ElasticsearchMetric) []zv1.ElasticsearchMetricBaseline {
+	timestamp := sample.Timestamp.UTC()
+	weekday, hour := int32(timestamp.Weekday()), int32(timestamp.Hour())
+
+	for i, bucket := range baseline {
+		if bucket.Weekday != weekday || bucket.Hour != hour {
+			continue
+		}
+		samples := bucket.Samples + 1
+		if samples > maxBaselineSamples {
+			samples = maxBaselineSamples
+		}
+		value := float64(bucket.Value) + float64(sample.Value-bucket.Value)/float64(samples)
+		baseline[i].Value = int32(math.Round(value))
+		baseline[i].Samples = samples
+		return baseline
+	}
+
+	baseline = append(baseline, zv1.ElasticsearchMetricBaseline{
+		Weekday: weekday,
+		Hour:    hour,
+		Value:   sample.Value,
+		Samples: 1,
+	})
+	sort.Slice(baseline, func(i, j int) bool {
+		if baseline[i].Weekday != baseline[j].Weekday {
+			return baseline[i].Weekday < baseline[j].Weekday
+		}
+		return baseline[i].Hour < baseline[j].Hour
+	})
+	return baseline
+}
+
+// predictedCPUUsage returns the highest average CPU usage of the hours of the
+// week from now until the end of the lookahead. Only hours with at least
+// minSamples samples are considered, false is returned if there is none.
+func predictedCPUUsage(baseline []zv1.ElasticsearchMetricBaseline, now time.Time, lookahead time.Duration, minSamples int32) (int32, bool) {
+	end := now.UTC().Add(lookahead)
+	peak, confident := int32(0), false
+	for t := now.UTC().Truncate(time.Hour); !t.After(end); t = t.Add(time.Hour) {
+		for _, bucket := range baseline {
+			if bucket.Weekday != int32(t.Weekday()) || bucket.Hour != int32(t.Hour()) || bucket.Samples < minSamples {
+				continue
+			}
+			if !confident || bucket.Value > peak {
+				peak = bucket.Value
+			}
+			confident = true
+		}
+	}
+	return peak, confident
+}
+
+// predictedPeak returns the predicted peak CPU usage within the lookahead if
+// predictive scaling is enabled and the prediction is confident.
+func (as *AutoScaler) predictedPeak(now time.Time) (int32, bool) {
+	if !predictiveScalingEnabled(as.eds) || as.esMSet == nil {
+		return 0, false
+	}
+	lookahead, minSamples := predictiveScalingSettings(as.eds.Spec.Scaling.Predictive)
+	return predictedCPUUsage(as.esMSet.Baseline, now, lookahead, minSamples)
+}
+
+// predictiveScaleUp returns true if the EDS should be scaled up ahead of a
+// predicted peak. It's scaled up at most once per lookahead, as the baseline
+// doesn't reflect the reduced usage per node after scaling up.
+func (as *AutoScaler) predictiveScaleUp(now time.Time) bool {
+	peak, ok := as.predictedPeak(now)
+	if !ok || peak <= as.eds.Spec.Scaling.ScaleUpCPUBoundary {
+		return false
+	}
+
+	lookahead, _ := predictiveScalingSettings(as.eds.Spec.Scaling.Predictive)
+	cooldown := time.Duration(as.eds.Spec.Scaling.ScaleUpCooldownSeconds) * time.Second
+	if cooldown < lookahead {
+		cooldown = lookahead
+	}
+	lastScaleUp := as.eds.Status.LastScaleUpStarted
+	if lastScaleUp != nil && lastScaleUp.Time.After(now.Add(-cooldown)) {
+		return false
+	}
+
+	as.logger.Infof("Predicted CPU usage of %d%% within %s exceeds the scale-up boundary.", peak, lookahead)
+	return true
+}
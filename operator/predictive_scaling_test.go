@@ -0,0 +1,107 @@
+package operator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestUpdateBaseline(t *testing.T) {
+	// Monday
+	monday := time.Date(2024, 1, 1, 10, 30, 0, 0, time.UTC)
+	sunday := time.Date(2024, 1, 7, 23, 0, 0, 0, time.UTC)
+
+	baseline := updateBaseline(nil, zv1.ElasticsearchMetric{Timestamp: metav1.NewTime(monday), Value: 40})
+	baseline = updateBaseline(baseline, zv1.ElasticsearchMetric{Timestamp: metav1.NewTime(monday.Add(time.Minute)), Value: 60})
+	baseline = updateBaseline(baseline, zv1.ElasticsearchMetric{Timestamp: metav1.NewTime(sunday), Value: 10})
+
+	require.Equal(t, []zv1.ElasticsearchMetricBaseline{
+		{Weekday: 0, Hour: 23, Value: 10, Samples: 1},
+		{Weekday: 1, Hour: 10, Value: 50, Samples: 2},
+	}, baseline)
+
+	// the weight of past samples is bounded
+	baseline = []zv1.ElasticsearchMetricBaseline{{Weekday: 1, Hour: 10, Value: 50, Samples: maxBaselineSamples}}
+	baseline = updateBaseline(baseline, zv1.ElasticsearchMetric{Timestamp: metav1.NewTime(monday), Value: 1050})
+	require.Equal(t, []zv1.ElasticsearchMetricBaseline{{Weekday: 1, Hour: 10, Value: 51, Samples: maxBaselineSamples}}, baseline)
+}
+
+func TestPredictedCPUUsage(t *testing.T) {
+	now := time.Date(2024, 1, 1, 9, 45, 0, 0, time.UTC)
+	baseline := []zv1.ElasticsearchMetricBaseline{
+		{Weekday: 1, Hour: 9, Value: 30, Samples: 200},
+		{Weekday: 1, Hour: 10, Value: 80, Samples: 200},
+		{Weekday: 1, Hour: 11, Value: 90, Samples: 200},
+		{Weekday: 1, Hour: 12, Value: 95, Samples: 10},
+	}
+
+	peak, ok := predictedCPUUsage(baseline, now, 10*time.Minute, 120)
+	require.True(t, ok)
+	require.Equal(t, int32(30), peak)
+
+	peak, ok = predictedCPUUsage(baseline, now, 30*time.Minute, 120)
+	require.True(t, ok)
+	require.Equal(t, int32(80), peak)
+
+	// hours with too few samples are ignored
+	peak, ok = predictedCPUUsage(baseline, now, 3*time.Hour, 120)
+	require.True(t, ok)
+	require.Equal(t, int32(90), peak)
+
+	_, ok = predictedCPUUsage(baseline, now.Add(24*time.Hour), time.Hour, 120)
+	require.False(t, ok)
+}
+
+func TestPredictiveScalingHint(t *testing.T) {
+	SetFeatureGates(FeatureGates{PredictiveScaling: true})
+	defer SetFeatureGates(nil)
+
+	now := time.Now().UTC()
+	eds := edsTestFixture(4)
+	eds.Spec.Scaling.ScaleUpCPUBoundary = 50
+	eds.Spec.Scaling.ScaleUpThresholdDurationSeconds = 120
+	eds.Spec.Scaling.ScaleDownCPUBoundary = 25
+	eds.Spec.Scaling.ScaleDownThresholdDurationSeconds = 120
+	eds.Spec.Scaling.Predictive = &zv1.ElasticsearchDataSetPredictiveScaling{
+		Enabled:          true,
+		LookaheadSeconds: 3600,
+		MinSamples:       1,
+	}
+
+	esMSet := &zv1.ElasticsearchMetricSet{
+		Metrics: []zv1.ElasticsearchMetric{
+			{Timestamp: metav1.NewTime(now), Value: 30},
+			{Timestamp: metav1.NewTime(now), Value: 30},
+		},
+	}
+	for _, hour := range []time.Time{now, now.Add(time.Hour)} {
+		esMSet.Baseline = append(esMSet.Baseline, zv1.ElasticsearchMetricBaseline{
+			Weekday: int32(hour.Weekday()),
+			Hour:    int32(hour.Hour()),
+			Value:   70,
+			Samples: 1,
+		})
+	}
+
+	// scale up ahead of the predicted peak
+	require.Equal(t, UP, systemUnderTest(eds, esMSet, nil).scalingHint())
+
+	// at most once per lookahead
+	lastScaleUp := metav1.NewTime(now.Add(-30 * time.Minute))
+	eds.Status.LastScaleUpStarted = &lastScaleUp
+	require.Equal(t, NONE, systemUnderTest(eds, esMSet, nil).scalingHint())
+
+	// don't scale down ahead of the predicted peak
+	esMSet.Metrics = []zv1.ElasticsearchMetric{
+		{Timestamp: metav1.NewTime(now), Value: 10},
+		{Timestamp: metav1.NewTime(now), Value: 10},
+	}
+	require.Equal(t, NONE, systemUnderTest(eds, esMSet, nil).scalingHint())
+
+	// fall back to reactive scaling without a confident prediction
+	eds.Spec.Scaling.Predictive.MinSamples = 2
+	require.Equal(t, DOWN, systemUnderTest(eds, esMSet, nil).scalingHint())
+}
@@ -0,0 +1,117 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// recommendedPrimaries exposes the recommended number of primary shards of
+// the indices which limit the scale-up of an EDS.
+var recommendedPrimaries = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "es_operator",
+	Name:      "index_recommended_primaries",
+	Help:      "Recommended number of primary shards of indices limiting the scale-up of an EDS.",
+}, []string{"namespace", "eds", "index"})
+
+func init() {
+	prometheus.MustRegister(recommendedPrimaries)
+}
+
+// primariesRecommendations returns the indices which can't make use of more
+// nodes because all their shard copies are already on separate nodes and
+// their replicas are at the maximum. The recommended primaries are the
+// smallest multiple of the current primaries which allows using the maximum
+// replicas of the EDS, sorted by index.
+func primariesRecommendations(indices map[string]ESIndex, nodes int32, scaling *zv1.ElasticsearchDataSetScaling, now time.Time) []zv1.ElasticsearchDataSetPrimariesRecommendation {
+	recommendations := make([]zv1.ElasticsearchDataSetPrimariesRecommendation, 0)
+	for _, index := range indices {
+		if index.Primaries <= 0 || index.Replicas < scaling.MaxIndexReplicas || index.Primaries*(index.Replicas+1) > nodes {
+			continue
+		}
+
+		// primaries needed to place a copy on each of the max nodes.
+		needed := (scaling.MaxReplicas + index.Replicas) / (index.Replicas + 1)
+		factor := (needed + index.Primaries - 1) / index.Primaries
+		if factor < 2 {
+			factor = 2
+		}
+		recommendations = append(recommendations, zv1.ElasticsearchDataSetPrimariesRecommendation{
+			Index:                index.Index,
+			Primaries:            index.Primaries,
+			RecommendedPrimaries: index.Primaries * factor,
+			Since:                metav1.NewTime(now),
+		})
+	}
+	sort.Slice(recommendations, func(i, j int) bool {
+		return recommendations[i].Index < recommendations[j].Index
+	})
+	return recommendations
+}
+
+// mergePrimariesRecommendations keeps the time of the previous
+// recommendations for the same index and primaries.
+func mergePrimariesRecommendations(previous, current []zv1.ElasticsearchDataSetPrimariesRecommendation) []zv1.ElasticsearchDataSetPrimariesRecommendation {
+	since := make(map[string]metav1.Time, len(previous))
+	for _, recommendation := range previous {
+		since[fmt.Sprintf("%s/%d", recommendation.Index, recommendation.Primaries)] = recommendation.Since
+	}
+	for i, recommendation := range current {
+		if t, ok := since[fmt.Sprintf("%s/%d", recommendation.Index, recommendation.Primaries)]; ok {
+			current[i].Since = t
+		}
+	}
+	if len(current) == 0 {
+		return nil
+	}
+	return current
+}
+
+// updatePrimariesRecommendations records the recommendations of the
+// autoscaler in the status of the EDS, emits an event for new ones and
+// exposes them as metric. Recommendations are made under scale-up pressure
+// and dropped once the EDS is scaled down.
+func (o *ElasticsearchOperator) updatePrimariesRecommendations(ctx context.Context, eds *zv1.ElasticsearchDataSet, direction ScalingDirection, recommendations []zv1.ElasticsearchDataSetPrimariesRecommendation) (*zv1.ElasticsearchDataSet, error) {
+	var current []zv1.ElasticsearchDataSetPrimariesRecommendation
+	switch direction {
+	case UP:
+		current = mergePrimariesRecommendations(eds.Status.PrimariesRecommendations, recommendations)
+	case DOWN:
+		current = nil
+	default:
+		return eds, nil
+	}
+	if reflect.DeepEqual(eds.Status.PrimariesRecommendations, current) {
+		return eds, nil
+	}
+
+	known := make(map[string]struct{}, len(eds.Status.PrimariesRecommendations))
+	for _, recommendation := range eds.Status.PrimariesRecommendations {
+		known[recommendation.Index] = struct{}{}
+		recommendedPrimaries.DeleteLabelValues(eds.Namespace, eds.Name, recommendation.Index)
+	}
+	for _, recommendation := range current {
+		recommendedPrimaries.WithLabelValues(eds.Namespace, eds.Name, recommendation.Index).Set(float64(recommendation.RecommendedPrimaries))
+		if _, ok := known[recommendation.Index]; !ok {
+			o.recorder.Event(eds, v1.EventTypeWarning, "IncreasePrimaries", fmt.Sprintf(
+				"Index %s can't make use of more nodes with %d primaries, consider splitting it or rolling over to %d primaries",
+				recommendation.Index, recommendation.Primaries, recommendation.RecommendedPrimaries))
+		}
+	}
+
+	replicas := eds.Spec.Replicas
+	eds.Status.PrimariesRecommendations = current
+	updated, err := o.kube.ZalandoV1().ElasticsearchDataSets(eds.Namespace).UpdateStatus(ctx, eds, metav1.UpdateOptions{})
+	if err != nil {
+		return eds, err
+	}
+	updated.Spec.Replicas = replicas
+	return updated, nil
+}
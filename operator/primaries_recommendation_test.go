@@ -0,0 +1,55 @@
+package operator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPrimariesRecommendations(t *testing.T) {
+	now := time.Now()
+	scaling := &zv1.ElasticsearchDataSetScaling{MaxReplicas: 12, MaxIndexReplicas: 2}
+	indices := map[string]ESIndex{
+		// 6 shard copies on 6 nodes
+		"logs": {Index: "logs", Primaries: 2, Replicas: 2},
+		// replicas can still be increased
+		"users": {Index: "users", Primaries: 1, Replicas: 1},
+		// more shard copies than nodes
+		"events": {Index: "events", Primaries: 3, Replicas: 2},
+		// one copy on each of the max nodes needs 4 primaries
+		"audit": {Index: "audit", Primaries: 1, Replicas: 2},
+	}
+
+	recommendations := primariesRecommendations(indices, 6, scaling, now)
+	require.Equal(t, []zv1.ElasticsearchDataSetPrimariesRecommendation{
+		{Index: "audit", Primaries: 1, RecommendedPrimaries: 4, Since: metav1.NewTime(now)},
+		{Index: "logs", Primaries: 2, RecommendedPrimaries: 4, Since: metav1.NewTime(now)},
+	}, recommendations)
+
+	// at least twice the primaries are recommended
+	scaling.MaxReplicas = 6
+	recommendations = primariesRecommendations(map[string]ESIndex{"logs": indices["logs"]}, 6, scaling, now)
+	require.Equal(t, int32(4), recommendations[0].RecommendedPrimaries)
+}
+
+func TestMergePrimariesRecommendations(t *testing.T) {
+	before := metav1.NewTime(time.Now().Add(-time.Hour))
+	now := metav1.Now()
+	previous := []zv1.ElasticsearchDataSetPrimariesRecommendation{
+		{Index: "logs", Primaries: 2, RecommendedPrimaries: 4, Since: before},
+		{Index: "audit", Primaries: 1, RecommendedPrimaries: 4, Since: before},
+	}
+	current := []zv1.ElasticsearchDataSetPrimariesRecommendation{
+		{Index: "logs", Primaries: 2, RecommendedPrimaries: 4, Since: now},
+		// the index was split meanwhile
+		{Index: "audit", Primaries: 2, RecommendedPrimaries: 4, Since: now},
+	}
+
+	merged := mergePrimariesRecommendations(previous, current)
+	require.Equal(t, before, merged[0].Since)
+	require.Equal(t, now, merged[1].Since)
+	require.Nil(t, mergePrimariesRecommendations(previous, nil))
+}
@@ -0,0 +1,257 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+)
+
+const (
+	// prometheusRuleNameSuffix is appended to the name of the EDS to get
+	// the name of its PrometheusRule.
+	prometheusRuleNameSuffix = "-es-operator"
+	prometheusRuleGroupName  = "es-operator"
+
+	// the durations after which the alerts of an EDS fire.
+	clusterRedAlertFor     = "5m"
+	drainStuckAlertFor     = "30m"
+	rolloutStalledAlertFor = "1h"
+	scalingBlockedAlertFor = "1h"
+)
+
+// prometheusRuleResource is the resource of the PrometheusRules of the
+// Prometheus operator.
+var prometheusRuleResource = schema.GroupVersionResource{
+	Group:    "monitoring.coreos.com",
+	Version:  "v1",
+	Resource: "prometheusrules",
+}
+
+var (
+	edsCondition = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "es_operator",
+		Subsystem: "eds",
+		Name:      "condition",
+		Help:      "Status condition of an EDS by type and reason, 1 if the condition is true.",
+	}, []string{"namespace", "eds", "type", "reason"})
+	edsClusterHealth = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "es_operator",
+		Subsystem: "eds",
+		Name:      "cluster_health",
+		Help:      "Health of the Elasticsearch cluster of an EDS: 0 green, 1 yellow, 2 red.",
+	}, []string{"namespace", "eds"})
+	edsScalingBlocked = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "es_operator",
+		Subsystem: "eds",
+		Name:      "scaling_blocked",
+		Help:      "1 if the autoscaler would scale an EDS but the scaling is not possible, e.g. because of the limits or scale-down guards.",
+	}, []string{"namespace", "eds"})
+)
+
+func init() {
+	prometheus.MustRegister(edsCondition, edsClusterHealth, edsScalingBlocked)
+}
+
+// clusterHealthValue maps the cluster health status to the value of the
+// cluster health metric.
+func clusterHealthValue(status string) (float64, bool) {
+	switch status {
+	case "green":
+		return 0, true
+	case "yellow":
+		return 1, true
+	case "red":
+		return 2, true
+	default:
+		return 0, false
+	}
+}
+
+// setConditionMetrics exposes the status conditions of the EDS as metrics.
+func setConditionMetrics(eds *zv1.ElasticsearchDataSet) {
+	for _, condition := range eds.Status.Conditions {
+		edsCondition.DeletePartialMatch(prometheus.Labels{"namespace": eds.Namespace, "eds": eds.Name, "type": condition.Type})
+		value := 0.0
+		if condition.Status == metav1.ConditionTrue {
+			value = 1
+		}
+		edsCondition.WithLabelValues(eds.Namespace, eds.Name, condition.Type, condition.Reason).Set(value)
+	}
+}
+
+// setScalingBlockedMetric exposes whether the autoscaler would scale the
+// EDS but the scaling operation doesn't.
+func setScalingBlockedMetric(eds *zv1.ElasticsearchDataSet, operation *ScalingOperation) {
+	blocked := 0.0
+	if operation.ScalingHint != NONE && operation.ScalingDirection == NONE {
+		blocked = 1
+	}
+	edsScalingBlocked.WithLabelValues(eds.Namespace, eds.Name).Set(blocked)
+}
+
+// deleteEDSMetrics deletes the metrics of the alerts of a removed EDS.
+func deleteEDSMetrics(namespace, name string) {
+	labels := prometheus.Labels{"namespace": namespace, "eds": name}
+	edsCondition.DeletePartialMatch(labels)
+	edsClusterHealth.Delete(labels)
+	edsScalingBlocked.Delete(labels)
+}
+
+// runPrometheusRules periodically updates the metrics of the alerts and
+// creates a PrometheusRule with the alerts for every EDS owned by the
+// operator.
+func (o *ElasticsearchOperator) runPrometheusRules(ctx context.Context) {
+	nextCheck := time.Now().Add(-o.interval)
+	known := make(map[types.NamespacedName]struct{})
+
+	for {
+		o.logger.Debug("Checking PrometheusRules")
+		select {
+		case <-time.After(time.Until(nextCheck)):
+			nextCheck = time.Now().Add(o.interval)
+
+			edss, err := o.kube.ZalandoV1().ElasticsearchDataSets(o.namespace).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				o.logger.Error(err)
+				continue
+			}
+
+			seen := make(map[types.NamespacedName]struct{}, len(edss.Items))
+			for _, eds := range edss.Items {
+				eds := eds
+				if !o.hasOwnership(&eds) {
+					continue
+				}
+				seen[types.NamespacedName{Namespace: eds.Namespace, Name: eds.Name}] = struct{}{}
+
+				setConditionMetrics(&eds)
+				health, err := (&ESClient{Endpoint: o.getElasticsearchEndpoint(&eds), health: eds.Spec.Health, transport: o.elasticsearchTransport(&eds)}).ClusterHealth()
+				if err != nil {
+					o.logger.Warnf("Failed to get cluster health of EDS %s/%s: %v", eds.Namespace, eds.Name, err)
+				} else if value, ok := clusterHealthValue(health.Status); ok {
+					edsClusterHealth.WithLabelValues(eds.Namespace, eds.Name).Set(value)
+				}
+
+				err = ensurePrometheusRule(ctx, o.kube.Dynamic(), &eds, o.prometheusRuleLabels)
+				if err != nil {
+					o.logger.Errorf("Failed to update PrometheusRule of EDS %s/%s: %v", eds.Namespace, eds.Name, err)
+				}
+			}
+
+			for key := range known {
+				if _, ok := seen[key]; !ok {
+					deleteEDSMetrics(key.Namespace, key.Name)
+				}
+			}
+			known = seen
+		case <-ctx.Done():
+			o.logger.Info("Terminating PrometheusRule loop.")
+			return
+		}
+	}
+}
+
+// ensurePrometheusRule creates or updates the PrometheusRule of the EDS. The
+// rule is owned by the EDS and deleted with it.
+func ensurePrometheusRule(ctx context.Context, client dynamic.Interface, eds *zv1.ElasticsearchDataSet, labels map[string]string) error {
+	rules := client.Resource(prometheusRuleResource).Namespace(eds.Namespace)
+	desired := renderPrometheusRule(eds, labels)
+
+	current, err := rules.Get(ctx, desired.GetName(), metav1.GetOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return err
+		}
+		_, err = rules.Create(ctx, desired, metav1.CreateOptions{})
+		return err
+	}
+
+	if reflect.DeepEqual(current.Object["spec"], desired.Object["spec"]) &&
+		reflect.DeepEqual(current.GetLabels(), desired.GetLabels()) {
+		return nil
+	}
+	current.SetLabels(desired.GetLabels())
+	current.SetOwnerReferences(desired.GetOwnerReferences())
+	current.Object["spec"] = desired.Object["spec"]
+	_, err = rules.Update(ctx, current, metav1.UpdateOptions{})
+	return err
+}
+
+// renderPrometheusRule renders the PrometheusRule with the alerts of the
+// EDS: red cluster health, stuck draining, stalled rollouts and blocked
+// scaling.
+func renderPrometheusRule(eds *zv1.ElasticsearchDataSet, labels map[string]string) *unstructured.Unstructured {
+	selector := fmt.Sprintf(`namespace="%s",eds="%s"`, eds.Namespace, eds.Name)
+	alertLabels := map[string]interface{}{
+		"namespace": eds.Namespace,
+		"eds":       eds.Name,
+	}
+	alert := func(name, expr, duration, severity, summary, description string) interface{} {
+		ruleLabels := map[string]interface{}{"severity": severity}
+		for key, value := range alertLabels {
+			ruleLabels[key] = value
+		}
+		return map[string]interface{}{
+			"alert":  name,
+			"expr":   expr,
+			"for":    duration,
+			"labels": ruleLabels,
+			"annotations": map[string]interface{}{
+				"summary":     summary,
+				"description": description,
+			},
+		}
+	}
+
+	rule := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"groups": []interface{}{
+				map[string]interface{}{
+					"name": prometheusRuleGroupName,
+					"rules": []interface{}{
+						alert("ElasticsearchDataSetClusterRed",
+							fmt.Sprintf("es_operator_eds_cluster_health{%s} == 2", selector), clusterRedAlertFor, "critical",
+							fmt.Sprintf("Elasticsearch cluster of EDS %s/%s is red", eds.Namespace, eds.Name),
+							fmt.Sprintf("The Elasticsearch cluster of EDS %s/%s has unassigned primary shards for more than %s.", eds.Namespace, eds.Name, clusterRedAlertFor)),
+						alert("ElasticsearchDataSetDrainStuck",
+							fmt.Sprintf(`es_operator_eds_condition{%s,type="%s"} == 1`, selector, zv1.ConditionDrainInProgress), drainStuckAlertFor, "warning",
+							fmt.Sprintf("Draining of EDS %s/%s is stuck", eds.Namespace, eds.Name),
+							fmt.Sprintf("Pods of EDS %s/%s are being drained for more than %s.", eds.Namespace, eds.Name, drainStuckAlertFor)),
+						alert("ElasticsearchDataSetRolloutStalled",
+							fmt.Sprintf(`es_operator_eds_condition{%s,type="%s",reason="%s"} == 1`, selector, zv1.ConditionProgressing, zv1.ReasonRollingOut), rolloutStalledAlertFor, "warning",
+							fmt.Sprintf("Rollout of EDS %s/%s is stalled", eds.Namespace, eds.Name),
+							fmt.Sprintf("The rollout of EDS %s/%s is in progress for more than %s.", eds.Namespace, eds.Name, rolloutStalledAlertFor)),
+						alert("ElasticsearchDataSetScalingBlocked",
+							fmt.Sprintf("es_operator_eds_scaling_blocked{%s} == 1", selector), scalingBlockedAlertFor, "warning",
+							fmt.Sprintf("Scaling of EDS %s/%s is blocked", eds.Namespace, eds.Name),
+							fmt.Sprintf("The autoscaler of EDS %s/%s would scale for more than %s but is blocked, e.g. by the replica limits or scale-down guards.", eds.Namespace, eds.Name, scalingBlockedAlertFor)),
+					},
+				},
+			},
+		},
+	}}
+	rule.SetAPIVersion(prometheusRuleResource.GroupVersion().String())
+	rule.SetKind("PrometheusRule")
+	rule.SetNamespace(eds.Namespace)
+	rule.SetName(eds.Name + prometheusRuleNameSuffix)
+	if len(labels) > 0 {
+		rule.SetLabels(labels)
+	}
+	rule.SetOwnerReferences([]metav1.OwnerReference{{
+		APIVersion: "zalando.org/v1",
+		Kind:       "ElasticsearchDataSet",
+		Name:       eds.Name,
+		UID:        eds.UID,
+	}})
+	return rule
+}
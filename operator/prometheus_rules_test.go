@@ -0,0 +1,105 @@
+package operator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func TestRenderPrometheusRule(t *testing.T) {
+	eds := &zv1.ElasticsearchDataSet{ObjectMeta: metav1.ObjectMeta{Name: "es-data", Namespace: "default", UID: "eds-uid"}}
+	rule := renderPrometheusRule(eds, map[string]string{"release": "prometheus"})
+	require.Equal(t, "monitoring.coreos.com/v1", rule.GetAPIVersion())
+	require.Equal(t, "PrometheusRule", rule.GetKind())
+	require.Equal(t, "es-data-es-operator", rule.GetName())
+	require.Equal(t, map[string]string{"release": "prometheus"}, rule.GetLabels())
+	require.Equal(t, "eds-uid", string(rule.GetOwnerReferences()[0].UID))
+
+	groups, _, err := unstructured.NestedSlice(rule.Object, "spec", "groups")
+	require.NoError(t, err)
+	require.Len(t, groups, 1)
+	rules := groups[0].(map[string]interface{})["rules"].([]interface{})
+	alerts := make(map[string]map[string]interface{}, len(rules))
+	for _, r := range rules {
+		alert := r.(map[string]interface{})
+		alerts[alert["alert"].(string)] = alert
+	}
+	require.Len(t, alerts, 4)
+	require.Equal(t, `es_operator_eds_cluster_health{namespace="default",eds="es-data"} == 2`, alerts["ElasticsearchDataSetClusterRed"]["expr"])
+	require.Equal(t, `es_operator_eds_condition{namespace="default",eds="es-data",type="DrainInProgress"} == 1`, alerts["ElasticsearchDataSetDrainStuck"]["expr"])
+	require.Equal(t, `es_operator_eds_condition{namespace="default",eds="es-data",type="Progressing",reason="RollingOut"} == 1`, alerts["ElasticsearchDataSetRolloutStalled"]["expr"])
+	require.Equal(t, drainStuckAlertFor, alerts["ElasticsearchDataSetDrainStuck"]["for"])
+	require.Equal(t, "critical", alerts["ElasticsearchDataSetClusterRed"]["labels"].(map[string]interface{})["severity"])
+
+	// the rule is valid JSON for the API server.
+	_, err = rule.MarshalJSON()
+	require.NoError(t, err)
+}
+
+func TestEnsurePrometheusRule(t *testing.T) {
+	ctx := context.Background()
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		prometheusRuleResource: "PrometheusRuleList",
+	})
+	eds := &zv1.ElasticsearchDataSet{ObjectMeta: metav1.ObjectMeta{Name: "es-data", Namespace: "default", UID: "eds-uid"}}
+	rules := client.Resource(prometheusRuleResource).Namespace("default")
+
+	require.NoError(t, ensurePrometheusRule(ctx, client, eds, nil))
+	rule, err := rules.Get(ctx, "es-data-es-operator", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Empty(t, rule.GetLabels())
+
+	// unchanged rules aren't updated.
+	client.ClearActions()
+	require.NoError(t, ensurePrometheusRule(ctx, client, eds, nil))
+	require.Len(t, client.Actions(), 1)
+
+	// changed labels and alerts are updated.
+	require.NoError(t, unstructured.SetNestedSlice(rule.Object, nil, "spec", "groups"))
+	_, err = rules.Update(ctx, rule, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	require.NoError(t, ensurePrometheusRule(ctx, client, eds, map[string]string{"release": "prometheus"}))
+	rule, err = rules.Get(ctx, "es-data-es-operator", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"release": "prometheus"}, rule.GetLabels())
+	require.Equal(t, renderPrometheusRule(eds, nil).Object["spec"], rule.Object["spec"])
+}
+
+func TestAlertMetrics(t *testing.T) {
+	eds := &zv1.ElasticsearchDataSet{ObjectMeta: metav1.ObjectMeta{Name: "alerts", Namespace: "default"}}
+	eds.Status.Conditions = []metav1.Condition{
+		{Type: zv1.ConditionProgressing, Status: metav1.ConditionTrue, Reason: zv1.ReasonRollingOut},
+		{Type: zv1.ConditionDrainInProgress, Status: metav1.ConditionFalse, Reason: zv1.ReasonNoDrain},
+	}
+	setConditionMetrics(eds)
+	require.EqualValues(t, 1, testutil.ToFloat64(edsCondition.WithLabelValues("default", "alerts", zv1.ConditionProgressing, zv1.ReasonRollingOut)))
+	require.EqualValues(t, 0, testutil.ToFloat64(edsCondition.WithLabelValues("default", "alerts", zv1.ConditionDrainInProgress, zv1.ReasonNoDrain)))
+
+	// the series of the previous reason is removed.
+	eds.Status.Conditions[0] = metav1.Condition{Type: zv1.ConditionProgressing, Status: metav1.ConditionFalse, Reason: zv1.ReasonComplete}
+	setConditionMetrics(eds)
+	require.Equal(t, 2, testutil.CollectAndCount(edsCondition))
+
+	setScalingBlockedMetric(eds, &ScalingOperation{ScalingHint: UP, ScalingDirection: NONE})
+	require.EqualValues(t, 1, testutil.ToFloat64(edsScalingBlocked.WithLabelValues("default", "alerts")))
+	setScalingBlockedMetric(eds, &ScalingOperation{ScalingHint: UP, ScalingDirection: UP})
+	require.EqualValues(t, 0, testutil.ToFloat64(edsScalingBlocked.WithLabelValues("default", "alerts")))
+
+	deleteEDSMetrics("default", "alerts")
+	require.Equal(t, 0, testutil.CollectAndCount(edsCondition))
+	require.Equal(t, 0, testutil.CollectAndCount(edsScalingBlocked))
+
+	value, ok := clusterHealthValue("red")
+	require.True(t, ok)
+	require.EqualValues(t, 2, value)
+	_, ok = clusterHealthValue("")
+	require.False(t, ok)
+}
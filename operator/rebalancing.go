@@ -0,0 +1,265 @@
+package operator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	defaultRebalancingThresholdPercent = 20
+	defaultRebalancingCooldown         = 30 * time.Minute
+
+	allocationGroupSetting = "index.routing.allocation.include.group"
+)
+
+// rebalancingGroup is an EDS of a rebalancing pool.
+type rebalancingGroup struct {
+	es    *ESResource
+	group string
+	// cpuUsagePercent is the average of the CPU usage samples of the
+	// metric set.
+	cpuUsagePercent float64
+}
+
+// indexMove is the move of an index from one group to another.
+type indexMove struct {
+	index string
+	from  *rebalancingGroup
+	to    *rebalancingGroup
+}
+
+// GetIndexAllocationGroups returns the group the indices matching the
+// patterns, or all indices if none are given, are allocated to with
+// index.routing.allocation.include.group, keyed by index. Indices without
+// the setting are mapped to an empty group.
+func (c *ESClient) GetIndexAllocationGroups(patterns ...string) (map[string]string, error) {
+	indices := "_all"
+	if len(patterns) > 0 {
+		indices = strings.Join(patterns, ",")
+	}
+	resp, err := c.httpClient().R().
+		Get(fmt.Sprintf("%s/%s/_settings/%s?flat_settings=true&expand_wildcards=open", c.Endpoint.String(), indices, allocationGroupSetting))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, fmt.Errorf("code status %d - %s", resp.StatusCode(), resp.Body())
+	}
+
+	var settings map[string]struct {
+		Settings map[string]string `json:"settings"`
+	}
+	err = json.Unmarshal(resp.Body(), &settings)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make(map[string]string, len(settings))
+	for index, s := range settings {
+		groups[index] = s.Settings[allocationGroupSetting]
+	}
+	return groups, nil
+}
+
+// SetIndexAllocationGroup allocates the index to the nodes of the group.
+func (c *ESClient) SetIndexAllocationGroup(index, group string) error {
+	resp, err := c.httpClient().R().
+		SetHeader("Content-Type", "application/json").
+		SetBody(map[string]string{allocationGroupSetting: group}).
+		Put(fmt.Sprintf("%s/%s/_settings", c.Endpoint.String(), index))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return fmt.Errorf("code status %d - %s", resp.StatusCode(), resp.Body())
+	}
+	return nil
+}
+
+// rebalancingSettings returns the threshold and cooldown of the rebalancing
+// configuration with defaults applied.
+func rebalancingSettings(rebalancing *zv1.ElasticsearchDataSetRebalancing) (float64, time.Duration) {
+	threshold := float64(defaultRebalancingThresholdPercent)
+	if rebalancing.ThresholdPercent > 0 {
+		threshold = float64(rebalancing.ThresholdPercent)
+	}
+	cooldown := defaultRebalancingCooldown
+	if rebalancing.CooldownSeconds > 0 {
+		cooldown = time.Duration(rebalancing.CooldownSeconds) * time.Second
+	}
+	return threshold, cooldown
+}
+
+// averageCPUUsage returns the average CPU usage of the samples of the metric
+// set and false if there are none.
+func averageCPUUsage(metricSet *zv1.ElasticsearchMetricSet) (float64, bool) {
+	if metricSet == nil || len(metricSet.Metrics) == 0 {
+		return 0, false
+	}
+	sum := 0.0
+	for _, metric := range metricSet.Metrics {
+		sum += float64(metric.Value)
+	}
+	return sum / float64(len(metricSet.Metrics)), true
+}
+
+// rebalancingPools returns the groups of the EDS with rebalancing enabled,
+// keyed by namespace and pool. EDS without group label or CPU usage samples
+// are omitted.
+func rebalancingPools(resources map[types.UID]*ESResource) map[string][]*rebalancingGroup {
+	pools := make(map[string][]*rebalancingGroup)
+	for _, es := range resources {
+		eds := es.ElasticsearchDataSet
+		rebalancing := eds.Spec.Rebalancing
+		if rebalancing == nil || !rebalancing.Enabled {
+			continue
+		}
+		group := eds.Labels[groupLabelKey]
+		if group == "" {
+			continue
+		}
+		cpuUsage, ok := averageCPUUsage(es.MetricSet)
+		if !ok {
+			continue
+		}
+		pool := eds.Namespace + "/" + rebalancing.Pool
+		pools[pool] = append(pools[pool], &rebalancingGroup{es: es, group: group, cpuUsagePercent: cpuUsage})
+	}
+	for _, groups := range pools {
+		sort.Slice(groups, func(i, j int) bool {
+			return groups[i].cpuUsagePercent > groups[j].cpuUsagePercent
+		})
+	}
+	return pools
+}
+
+// rebalancingMove returns the index to move from the busiest to the least
+// busy group of the pool, if their CPU usage differs by more than the
+// threshold. The smallest index of the busiest group is moved, so the load
+// is shifted gradually. The last index of a group is never moved.
+func rebalancingMove(groups []*rebalancingGroup, allocation map[string]string, indices []ESIndex, threshold float64) *indexMove {
+	if len(groups) < 2 {
+		return nil
+	}
+	busiest, leastBusy := groups[0], groups[len(groups)-1]
+	if busiest.cpuUsagePercent-leastBusy.cpuUsagePercent <= threshold {
+		return nil
+	}
+
+	candidates := make([]ESIndex, 0)
+	for _, index := range indices {
+		if allocation[index.Index] == busiest.group {
+			candidates = append(candidates, index)
+		}
+	}
+	if len(candidates) < 2 {
+		return nil
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		si := candidates[i].Primaries * (candidates[i].Replicas + 1)
+		sj := candidates[j].Primaries * (candidates[j].Replicas + 1)
+		if si != sj {
+			return si < sj
+		}
+		return candidates[i].Index < candidates[j].Index
+	})
+	return &indexMove{index: candidates[0].Index, from: busiest, to: leastBusy}
+}
+
+// runRebalancer checks at an interval if the CPU usage of the groups of a
+// rebalancing pool differs by more than the threshold and moves an index
+// from the busiest to the least busy group. The groups are resized by the
+// autoscaler based on the shards allocated to them.
+func (o *ElasticsearchOperator) runRebalancer(ctx context.Context) {
+	nextCheck := time.Now().Add(-o.autoscalerInterval)
+	lastMoves := make(map[string]time.Time)
+
+	for {
+		o.logger.Debug("Checking rebalancing")
+		select {
+		case <-time.After(time.Until(nextCheck)):
+			nextCheck = time.Now().Add(o.autoscalerInterval)
+
+			resources, err := o.collectResources(ctx)
+			if err != nil {
+				o.logger.Error(err)
+				continue
+			}
+
+			for pool, groups := range rebalancingPools(resources) {
+				_, cooldown := rebalancingSettings(groups[0].es.ElasticsearchDataSet.Spec.Rebalancing)
+				if time.Since(lastMoves[pool]) < cooldown {
+					continue
+				}
+
+				moved, err := o.rebalancePool(groups)
+				if err != nil {
+					o.logger.Errorf("Failed to rebalance pool %s: %v", pool, err)
+					continue
+				}
+				if moved {
+					lastMoves[pool] = time.Now()
+				}
+			}
+		case <-ctx.Done():
+			o.logger.Info("Terminating rebalancing loop.")
+			return
+		}
+	}
+}
+
+// rebalancePool moves an index between the groups of the pool if their CPU
+// usage is unbalanced. It returns true if an index was moved.
+func (o *ElasticsearchOperator) rebalancePool(groups []*rebalancingGroup) (bool, error) {
+	threshold, _ := rebalancingSettings(groups[0].es.ElasticsearchDataSet.Spec.Rebalancing)
+	eds := groups[0].es.ElasticsearchDataSet
+	client := &ESClient{
+		Endpoint:             o.getElasticsearchEndpoint(eds),
+		excludeSystemIndices: true,
+		transport:            o.elasticsearchTransport(eds),
+	}
+
+	// don't move indices while shards are still relocating.
+	health, err := client.ClusterHealth()
+	if err != nil {
+		return false, err
+	}
+	if health.Status != "green" || health.RelocatingShards > 0 {
+		return false, nil
+	}
+
+	allocation, err := client.GetIndexAllocationGroups()
+	if err != nil {
+		return false, err
+	}
+	indices, err := client.GetIndices()
+	if err != nil {
+		return false, err
+	}
+
+	move := rebalancingMove(groups, allocation, indices, threshold)
+	if move == nil {
+		return false, nil
+	}
+
+	err = client.SetIndexAllocationGroup(move.index, move.to.group)
+	if err != nil {
+		return false, err
+	}
+
+	message := fmt.Sprintf("Moved index %s from group %s (%.0f%% CPU) to group %s (%.0f%% CPU)",
+		move.index, move.from.group, move.from.cpuUsagePercent, move.to.group, move.to.cpuUsagePercent)
+	o.recorder.Event(move.from.es.ElasticsearchDataSet, v1.EventTypeNormal, "RebalancedIndex", message)
+	o.recorder.Event(move.to.es.ElasticsearchDataSet, v1.EventTypeNormal, "RebalancedIndex", message)
+	return true, nil
+}
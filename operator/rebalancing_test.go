@@ -0,0 +1,99 @@
+package operator
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestGetIndexAllocationGroups(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_all/_settings/index.routing.allocation.include.group",
+		httpmock.NewStringResponder(200, `{"logs-1":{"settings":{"index.routing.allocation.include.group":"a"}},"logs-2":{"settings":{"index.routing.allocation.include.group":"b"}},"other":{"settings":{}}}`))
+	httpmock.RegisterResponder("PUT", "http://elasticsearch:9200/logs-1/_settings",
+		httpmock.NewStringResponder(200, `{"acknowledged":true}`))
+
+	url, _ := url.Parse("http://elasticsearch:9200")
+	client := &ESClient{Endpoint: url}
+
+	groups, err := client.GetIndexAllocationGroups()
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"logs-1": "a", "logs-2": "b", "other": ""}, groups)
+
+	require.NoError(t, client.SetIndexAllocationGroup("logs-1", "b"))
+	require.Error(t, client.SetIndexAllocationGroup("missing", "b"))
+}
+
+func rebalancingResource(name, pool, group string, cpuUsage ...int32) *ESResource {
+	eds := &zv1.ElasticsearchDataSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			Labels:    map[string]string{groupLabelKey: group},
+		},
+		Spec: zv1.ElasticsearchDataSetSpec{
+			Rebalancing: &zv1.ElasticsearchDataSetRebalancing{Enabled: true, Pool: pool},
+		},
+	}
+	es := &ESResource{ElasticsearchDataSet: eds}
+	if len(cpuUsage) > 0 {
+		es.MetricSet = &zv1.ElasticsearchMetricSet{}
+		for _, value := range cpuUsage {
+			es.MetricSet.Metrics = append(es.MetricSet.Metrics, zv1.ElasticsearchMetric{Value: value})
+		}
+	}
+	return es
+}
+
+func TestRebalancingPools(t *testing.T) {
+	disabled := rebalancingResource("disabled", "logs", "d", 50)
+	disabled.ElasticsearchDataSet.Spec.Rebalancing.Enabled = false
+
+	pools := rebalancingPools(map[types.UID]*ESResource{
+		"a":        rebalancingResource("a", "logs", "a", 20, 40),
+		"b":        rebalancingResource("b", "logs", "b", 80, 80),
+		"c":        rebalancingResource("c", "metrics", "c", 50),
+		"no-group": rebalancingResource("no-group", "logs", "", 50),
+		"no-usage": rebalancingResource("no-usage", "logs", "e"),
+		"disabled": disabled,
+	})
+
+	require.Len(t, pools, 2)
+	require.Len(t, pools["default/logs"], 2)
+	require.Equal(t, "b", pools["default/logs"][0].group)
+	require.Equal(t, 80.0, pools["default/logs"][0].cpuUsagePercent)
+	require.Equal(t, "a", pools["default/logs"][1].group)
+	require.Equal(t, 30.0, pools["default/logs"][1].cpuUsagePercent)
+	require.Len(t, pools["default/metrics"], 1)
+}
+
+func TestRebalancingMove(t *testing.T) {
+	busy := &rebalancingGroup{group: "a", cpuUsagePercent: 80}
+	idle := &rebalancingGroup{group: "b", cpuUsagePercent: 30}
+	allocation := map[string]string{"large": "a", "small": "a", "other": "b"}
+	indices := []ESIndex{
+		{Index: "large", Primaries: 6, Replicas: 1},
+		{Index: "small", Primaries: 2, Replicas: 1},
+		{Index: "other", Primaries: 1, Replicas: 1},
+	}
+
+	move := rebalancingMove([]*rebalancingGroup{busy, idle}, allocation, indices, 20)
+	require.Equal(t, &indexMove{index: "small", from: busy, to: idle}, move)
+
+	// balanced within the threshold
+	require.Nil(t, rebalancingMove([]*rebalancingGroup{busy, idle}, allocation, indices, 50))
+
+	// single group
+	require.Nil(t, rebalancingMove([]*rebalancingGroup{busy}, allocation, indices, 20))
+
+	// the last index of a group isn't moved
+	allocation["large"] = "b"
+	require.Nil(t, rebalancingMove([]*rebalancingGroup{busy, idle}, allocation, indices, 20))
+}
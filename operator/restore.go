@@ -0,0 +1,272 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// groupLabelKey is the EDS label holding the value of the 'group' node
+	// attribute of its nodes.
+	groupLabelKey = "group"
+)
+
+// snapshotRestoreRequest is the body of a _snapshot/<repo>/<snapshot>/_restore
+// request.
+type snapshotRestoreRequest struct {
+	Indices            string            `json:"indices"`
+	IncludeGlobalState bool              `json:"include_global_state"`
+	RenamePattern      string            `json:"rename_pattern,omitempty"`
+	RenameReplacement  string            `json:"rename_replacement,omitempty"`
+	IndexSettings      map[string]string `json:"index_settings,omitempty"`
+}
+
+// RestoreSnapshot starts the restore of a snapshot without waiting for it to
+// complete.
+func (c *ESClient) RestoreSnapshot(repository, snapshot string, request snapshotRestoreRequest) error {
+	resp, err := c.httpClient().R().
+		SetHeader("Content-Type", "application/json").
+		SetBody(request).
+		Post(fmt.Sprintf("%s/_snapshot/%s/%s/_restore", c.Endpoint.String(), repository, snapshot))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode() != http.StatusOK && resp.StatusCode() != http.StatusAccepted {
+		return fmt.Errorf("code status %d - %s", resp.StatusCode(), resp.Body())
+	}
+	return nil
+}
+
+// restoreRequest returns the restore request for the restore, allocating the
+// restored indices to the nodes of the group.
+func restoreRequest(spec zv1.ElasticsearchRestoreSpec, group string) snapshotRestoreRequest {
+	request := snapshotRestoreRequest{
+		Indices:           strings.Join(spec.Indices, ","),
+		RenamePattern:     spec.RenamePattern,
+		RenameReplacement: spec.RenameReplacement,
+		IndexSettings: map[string]string{
+			"index.routing.allocation.include.group": group,
+		},
+	}
+	if spec.IndexReplicas != nil {
+		request.IndexSettings["index.number_of_replicas"] = strconv.Itoa(int(*spec.IndexReplicas))
+	}
+	return request
+}
+
+// restoredIndices returns the patterns of the restored indices, with the
+// rename of the restore applied.
+func restoredIndices(spec zv1.ElasticsearchRestoreSpec) ([]string, error) {
+	if spec.RenamePattern == "" {
+		return spec.Indices, nil
+	}
+
+	pattern, err := regexp.Compile(spec.RenamePattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rename pattern: %v", err)
+	}
+
+	indices := make([]string, 0, len(spec.Indices))
+	for _, index := range spec.Indices {
+		indices = append(indices, pattern.ReplaceAllString(index, spec.RenameReplacement))
+	}
+	return indices, nil
+}
+
+// runRestores reconciles ElasticsearchRestore resources at an interval.
+func (o *ElasticsearchOperator) runRestores(ctx context.Context) {
+	nextCheck := time.Now().Add(-o.interval)
+
+	for {
+		o.logger.Debug("Checking restores")
+		select {
+		case <-time.After(time.Until(nextCheck)):
+			nextCheck = time.Now().Add(o.interval)
+
+			restores, err := o.kube.ZalandoV1().ElasticsearchRestores(o.namespace).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				o.logger.Error(err)
+				continue
+			}
+
+			for _, restore := range restores.Items {
+				restore := restore
+				if !o.hasOwnership(&restore) {
+					continue
+				}
+
+				err := o.reconcileRestore(ctx, &restore)
+				if err != nil {
+					o.logger.Errorf("Failed to reconcile restore %s/%s: %v", restore.Namespace, restore.Name, err)
+				}
+			}
+		case <-ctx.Done():
+			o.logger.Info("Terminating restore loop.")
+			return
+		}
+	}
+}
+
+// reconcileRestore performs the next step of an ElasticsearchRestore. The
+// EDS is scaled up to the minimum replicas first, then the restore is
+// started and finally it's completed once all restored indices are green.
+func (o *ElasticsearchOperator) reconcileRestore(ctx context.Context, restore *zv1.ElasticsearchRestore) error {
+	// set TypeMeta manually because of this bug:
+	// https://github.com/kubernetes/client-go/issues/308
+	restore.APIVersion = "zalando.org/v1"
+	restore.Kind = "ElasticsearchRestore"
+
+	if restore.Status.Phase == zv1.RestorePhaseCompleted || restore.Status.Phase == zv1.RestorePhaseFailed {
+		return nil
+	}
+
+	status := restore.Status.DeepCopy()
+
+	eds, err := o.kube.ZalandoV1().ElasticsearchDataSets(restore.Namespace).Get(ctx, restore.Spec.DataSet, metav1.GetOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return err
+		}
+		status.Message = fmt.Sprintf("Waiting for EDS %s to be created.", restore.Spec.DataSet)
+		return o.updateRestoreStatus(ctx, restore, status)
+	}
+	if !o.hasOwnership(eds) {
+		return nil
+	}
+
+	client := &ESClient{Endpoint: o.getElasticsearchEndpoint(eds), health: eds.Spec.Health, transport: o.elasticsearchTransport(eds)}
+
+	switch status.Phase {
+	case "", zv1.RestorePhaseScalingUp:
+		ready, err := o.scaleUpForRestore(ctx, restore, eds, status)
+		if err != nil {
+			return err
+		}
+		if !ready {
+			return o.updateRestoreStatus(ctx, restore, status)
+		}
+
+		group := restore.Spec.Group
+		if group == "" {
+			group = eds.Labels[groupLabelKey]
+		}
+		if group == "" {
+			status.Phase = zv1.RestorePhaseFailed
+			status.Message = fmt.Sprintf("EDS %s has no '%s' label and no group is specified.", eds.Name, groupLabelKey)
+			o.recorder.Event(restore, v1.EventTypeWarning, "RestoreFailed", status.Message)
+			return o.updateRestoreStatus(ctx, restore, status)
+		}
+
+		err = client.RestoreSnapshot(restore.Spec.Repository, restore.Spec.Snapshot, restoreRequest(restore.Spec, group))
+		if err != nil {
+			status.Phase = zv1.RestorePhaseFailed
+			status.Message = fmt.Sprintf("Failed to start restore: %v", err)
+			o.recorder.Event(restore, v1.EventTypeWarning, "RestoreFailed", status.Message)
+			return o.updateRestoreStatus(ctx, restore, status)
+		}
+
+		now := metav1.Now()
+		status.Phase = zv1.RestorePhaseRestoring
+		status.StartTime = &now
+		status.Message = fmt.Sprintf("Restoring snapshot %s onto group %s.", restore.Spec.Snapshot, group)
+		o.recorder.Event(restore, v1.EventTypeNormal, "RestoreStarted", status.Message)
+		o.decisions.Record("Restore", eds.Namespace, eds.Name, fmt.Sprintf(
+			"Restoring snapshot '%s/%s' onto group '%s' for restore '%s'", restore.Spec.Repository, restore.Spec.Snapshot, group, restore.Name,
+		))
+	case zv1.RestorePhaseRestoring:
+		indices, err := restoredIndices(restore.Spec)
+		if err != nil {
+			return err
+		}
+
+		health, err := client.GetIndicesHealth(indices)
+		if err != nil {
+			return fmt.Errorf("failed to get health of restored indices: %v", err)
+		}
+
+		notGreen := make([]string, 0, len(health))
+		for index, indexHealth := range health {
+			if indexHealth != "green" {
+				notGreen = append(notGreen, index)
+			}
+		}
+		sort.Strings(notGreen)
+
+		switch {
+		case len(health) == 0:
+			status.Message = "Waiting for the restored indices to be created."
+		case len(notGreen) > 0:
+			status.Message = fmt.Sprintf("%d/%d restored indices are green, waiting for: %s.",
+				len(health)-len(notGreen), len(health), strings.Join(notGreen, ", "))
+		default:
+			now := metav1.Now()
+			status.Phase = zv1.RestorePhaseCompleted
+			status.CompletionTime = &now
+			status.Message = fmt.Sprintf("Restored %d indices.", len(health))
+			o.recorder.Event(restore, v1.EventTypeNormal, "RestoreCompleted", status.Message)
+		}
+	}
+
+	return o.updateRestoreStatus(ctx, restore, status)
+}
+
+// scaleUpForRestore scales up the EDS to the minimum replicas of the restore
+// and returns true once it has the minimum replicas ready.
+func (o *ElasticsearchOperator) scaleUpForRestore(ctx context.Context, restore *zv1.ElasticsearchRestore, eds *zv1.ElasticsearchDataSet, status *zv1.ElasticsearchRestoreStatus) (bool, error) {
+	if restore.Spec.MinReplicas == nil {
+		return true, nil
+	}
+	minReplicas := *restore.Spec.MinReplicas
+
+	if edsReplicas(eds) < minReplicas {
+		eds.Spec.Replicas = &minReplicas
+		_, err := o.kube.ZalandoV1().ElasticsearchDataSets(eds.Namespace).Update(ctx, eds, metav1.UpdateOptions{})
+		if err != nil {
+			return false, fmt.Errorf("failed to scale up EDS %s/%s: %v", eds.Namespace, eds.Name, err)
+		}
+		o.recorder.Event(restore, v1.EventTypeNormal, "ScaledDataSet", fmt.Sprintf(
+			"Scaled up EDS '%s/%s' to %d replicas for the restore", eds.Namespace, eds.Name, minReplicas,
+		))
+		o.decisions.Record("Restore", eds.Namespace, eds.Name, fmt.Sprintf(
+			"Scaled up to %d replicas for restore '%s'", minReplicas, restore.Name,
+		))
+	}
+
+	readyReplicas := int32(0)
+	sts, err := o.kube.AppsV1().StatefulSets(eds.Namespace).Get(ctx, eds.Name, metav1.GetOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return false, err
+	}
+	if err == nil {
+		readyReplicas = sts.Status.ReadyReplicas
+	}
+
+	if readyReplicas < minReplicas {
+		status.Phase = zv1.RestorePhaseScalingUp
+		status.Message = fmt.Sprintf("Waiting for %d/%d replicas of EDS %s to be ready.", readyReplicas, minReplicas, eds.Name)
+		return false, nil
+	}
+	return true, nil
+}
+
+// updateRestoreStatus updates the status of the restore if it changed.
+func (o *ElasticsearchOperator) updateRestoreStatus(ctx context.Context, restore *zv1.ElasticsearchRestore, status *zv1.ElasticsearchRestoreStatus) error {
+	if equality.Semantic.DeepEqual(restore.Status, *status) {
+		return nil
+	}
+	restore.Status = *status
+	_, err := o.kube.ZalandoV1().ElasticsearchRestores(restore.Namespace).UpdateStatus(ctx, restore, metav1.UpdateOptions{})
+	return err
+}
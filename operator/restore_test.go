@@ -0,0 +1,77 @@
+package operator
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+)
+
+func TestRestoreRequest(t *testing.T) {
+	replicas := int32(0)
+	request := restoreRequest(zv1.ElasticsearchRestoreSpec{
+		Indices:           []string{"orders-*", "users"},
+		RenamePattern:     "(.+)",
+		RenameReplacement: "restored-$1",
+		IndexReplicas:     &replicas,
+	}, "restore")
+
+	assert.Equal(t, snapshotRestoreRequest{
+		Indices:           "orders-*,users",
+		RenamePattern:     "(.+)",
+		RenameReplacement: "restored-$1",
+		IndexSettings: map[string]string{
+			"index.routing.allocation.include.group": "restore",
+			"index.number_of_replicas":               "0",
+		},
+	}, request)
+}
+
+func TestRestoredIndices(t *testing.T) {
+	indices, err := restoredIndices(zv1.ElasticsearchRestoreSpec{Indices: []string{"orders-*"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"orders-*"}, indices)
+
+	indices, err = restoredIndices(zv1.ElasticsearchRestoreSpec{
+		Indices:           []string{"orders-*", "users"},
+		RenamePattern:     "orders-(.+)",
+		RenameReplacement: "restored-orders-$1",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"restored-orders-*", "users"}, indices)
+
+	_, err = restoredIndices(zv1.ElasticsearchRestoreSpec{Indices: []string{"orders"}, RenamePattern: "("})
+	assert.Error(t, err)
+}
+
+func TestRestoreSnapshot(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var body snapshotRestoreRequest
+	httpmock.RegisterResponder("POST", "http://elasticsearch:9200/_snapshot/backups/nightly/_restore",
+		func(req *http.Request) (*http.Response, error) {
+			err := json.NewDecoder(req.Body).Decode(&body)
+			if err != nil {
+				return nil, err
+			}
+			return httpmock.NewStringResponse(200, `{"accepted":true}`), nil
+		})
+
+	esURL, _ := url.Parse("http://elasticsearch:9200")
+	client := &ESClient{Endpoint: esURL}
+	request := snapshotRestoreRequest{Indices: "orders", IndexSettings: map[string]string{"index.routing.allocation.include.group": "a"}}
+	err := client.RestoreSnapshot("backups", "nightly", request)
+	require.NoError(t, err)
+	assert.Equal(t, request, body)
+
+	httpmock.RegisterResponder("POST", "http://elasticsearch:9200/_snapshot/backups/missing/_restore",
+		httpmock.NewStringResponder(404, `{"error":"snapshot_missing_exception"}`))
+	err = client.RestoreSnapshot("backups", "missing", request)
+	assert.Error(t, err)
+}
@@ -0,0 +1,326 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	defaultRolloutContainerName = "elasticsearch"
+)
+
+// rolloutDataSet is the rollout relevant state of a single EDS selected by
+// an ElasticsearchRollout.
+type rolloutDataSet struct {
+	eds *zv1.ElasticsearchDataSet
+	// updated is true if the EDS pod template references the new image.
+	updated bool
+	// rolledOut is true if all pods of the EDS run the current pod
+	// template and are ready.
+	rolledOut bool
+	// healthy is true if the Elasticsearch cluster of the EDS is not red.
+	healthy bool
+}
+
+// rolloutStep is the next step of an ElasticsearchRollout.
+type rolloutStep struct {
+	phase   zv1.ElasticsearchRolloutPhase
+	wave    int32
+	update  []*zv1.ElasticsearchDataSet
+	message string
+}
+
+// planRollout calculates the next step of the rollout based on the current
+// state of the selected ElasticsearchDataSets. The dataSets are expected to
+// be sorted in the order they should be updated.
+func planRollout(rollout *zv1.ElasticsearchRollout, dataSets []rolloutDataSet) rolloutStep {
+	waves := rollout.Spec.Waves
+	if len(waves) == 0 {
+		waves = []intstr.IntOrString{intstr.FromString("100%")}
+	}
+
+	wave := rollout.Status.CurrentWave
+	if rollout.Status.ObservedGeneration != rollout.Generation {
+		// the spec changed, start from the beginning.
+		wave = 0
+	} else if rollout.Status.Phase == zv1.RolloutPhaseHalted {
+		return rolloutStep{
+			phase:   zv1.RolloutPhaseHalted,
+			wave:    wave,
+			message: rollout.Status.Message,
+		}
+	}
+
+	updated := 0
+	for _, ds := range dataSets {
+		if !ds.updated {
+			continue
+		}
+		updated++
+
+		if ds.rolledOut && !ds.healthy {
+			return rolloutStep{
+				phase:   zv1.RolloutPhaseHalted,
+				wave:    wave,
+				message: fmt.Sprintf("Halted because EDS %s is unhealthy after the update.", ds.eds.Name),
+			}
+		}
+	}
+
+	for _, ds := range dataSets {
+		if ds.updated && !ds.rolledOut {
+			return rolloutStep{
+				phase:   zv1.RolloutPhaseProgressing,
+				wave:    wave,
+				message: fmt.Sprintf("Waiting for EDS %s to be rolled out.", ds.eds.Name),
+			}
+		}
+	}
+
+	// skip waves which are already fully rolled out.
+	for int(wave) < len(waves) && updated >= rolloutWaveTarget(waves[wave], len(dataSets)) {
+		wave++
+	}
+
+	if int(wave) >= len(waves) {
+		return rolloutStep{
+			phase:   zv1.RolloutPhaseCompleted,
+			wave:    int32(len(waves) - 1),
+			message: fmt.Sprintf("Rolled out %d/%d EDS.", updated, len(dataSets)),
+		}
+	}
+
+	if rollout.Spec.Paused {
+		return rolloutStep{
+			phase:   zv1.RolloutPhasePaused,
+			wave:    wave,
+			message: fmt.Sprintf("Paused before wave %d. Rolled out %d/%d EDS.", wave, updated, len(dataSets)),
+		}
+	}
+
+	target := rolloutWaveTarget(waves[wave], len(dataSets))
+	update := make([]*zv1.ElasticsearchDataSet, 0, target-updated)
+	for _, ds := range dataSets {
+		if len(update) >= target-updated {
+			break
+		}
+		if !ds.updated {
+			update = append(update, ds.eds)
+		}
+	}
+
+	return rolloutStep{
+		phase:   zv1.RolloutPhaseProgressing,
+		wave:    wave,
+		update:  update,
+		message: fmt.Sprintf("Rolling out wave %d: updating %d EDS.", wave, len(update)),
+	}
+}
+
+// rolloutWaveTarget returns the cumulative number of ElasticsearchDataSets
+// which should be updated after the wave. It's at least 1 and at most total.
+func rolloutWaveTarget(wave intstr.IntOrString, total int) int {
+	target, err := intstr.GetScaledValueFromIntOrPercent(&wave, total, true)
+	if err != nil {
+		return total
+	}
+	return int(math.Max(1, math.Min(float64(target), float64(total))))
+}
+
+// runRollouts reconciles ElasticsearchRollout resources at an interval.
+func (o *ElasticsearchOperator) runRollouts(ctx context.Context) {
+	nextCheck := time.Now().Add(-o.interval)
+
+	for {
+		o.logger.Debug("Checking rollouts")
+		select {
+		case <-time.After(time.Until(nextCheck)):
+			nextCheck = time.Now().Add(o.interval)
+
+			rollouts, err := o.kube.ZalandoV1().ElasticsearchRollouts(o.namespace).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				o.logger.Error(err)
+				continue
+			}
+
+			for _, rollout := range rollouts.Items {
+				rollout := rollout
+				if !o.hasOwnership(&rollout) {
+					continue
+				}
+
+				err := o.reconcileRollout(ctx, &rollout)
+				if err != nil {
+					o.logger.Errorf("Failed to reconcile rollout %s/%s: %v", rollout.Namespace, rollout.Name, err)
+				}
+			}
+		case <-ctx.Done():
+			o.logger.Info("Terminating rollout loop.")
+			return
+		}
+	}
+}
+
+// reconcileRollout performs the next step of an ElasticsearchRollout.
+func (o *ElasticsearchOperator) reconcileRollout(ctx context.Context, rollout *zv1.ElasticsearchRollout) error {
+	// set TypeMeta manually because of this bug:
+	// https://github.com/kubernetes/client-go/issues/308
+	rollout.APIVersion = "zalando.org/v1"
+	rollout.Kind = "ElasticsearchRollout"
+
+	selector, err := metav1.LabelSelectorAsSelector(rollout.Spec.Selector)
+	if err != nil {
+		return fmt.Errorf("invalid selector: %v", err)
+	}
+
+	edss, err := o.kube.ZalandoV1().ElasticsearchDataSets(rollout.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: selector.String(),
+	})
+	if err != nil {
+		return err
+	}
+
+	containerName := rollout.Spec.ContainerName
+	if containerName == "" {
+		containerName = defaultRolloutContainerName
+	}
+
+	dataSets := make([]rolloutDataSet, 0, len(edss.Items))
+	for _, eds := range edss.Items {
+		eds := eds
+		if !o.hasOwnership(&eds) {
+			continue
+		}
+
+		ds, err := o.rolloutDataSetState(ctx, &eds, containerName, rollout.Spec.Image)
+		if err != nil {
+			return err
+		}
+		dataSets = append(dataSets, ds)
+	}
+
+	sort.Slice(dataSets, func(i, j int) bool {
+		return dataSets[i].eds.Name < dataSets[j].eds.Name
+	})
+
+	step := planRollout(rollout, dataSets)
+
+	for _, eds := range step.update {
+		for i, container := range eds.Spec.Template.Spec.Containers {
+			if container.Name == containerName {
+				eds.Spec.Template.Spec.Containers[i].Image = rollout.Spec.Image
+			}
+		}
+
+		_, err := o.kube.ZalandoV1().ElasticsearchDataSets(eds.Namespace).Update(ctx, eds, metav1.UpdateOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to update EDS %s/%s: %v", eds.Namespace, eds.Name, err)
+		}
+		o.recorder.Event(rollout, v1.EventTypeNormal, "UpdatedDataSet", fmt.Sprintf(
+			"Updated image of EDS '%s/%s' to '%s'", eds.Namespace, eds.Name, rollout.Spec.Image,
+		))
+		o.decisions.Record("Rollout", eds.Namespace, eds.Name, fmt.Sprintf(
+			"Updated image to '%s' in wave %d of rollout '%s'", rollout.Spec.Image, step.wave, rollout.Name,
+		))
+	}
+
+	updatedDataSets := make([]string, 0, len(dataSets))
+	for _, ds := range dataSets {
+		if ds.updated {
+			updatedDataSets = append(updatedDataSets, ds.eds.Name)
+		}
+	}
+	for _, eds := range step.update {
+		updatedDataSets = append(updatedDataSets, eds.Name)
+	}
+	sort.Strings(updatedDataSets)
+
+	if step.phase == zv1.RolloutPhaseHalted && rollout.Status.Phase != zv1.RolloutPhaseHalted {
+		o.recorder.Event(rollout, v1.EventTypeWarning, "RolloutHalted", step.message)
+	}
+
+	status := zv1.ElasticsearchRolloutStatus{
+		ObservedGeneration: rollout.Generation,
+		Phase:              step.phase,
+		CurrentWave:        step.wave,
+		UpdatedDataSets:    updatedDataSets,
+		Message:            step.message,
+	}
+
+	if equalRolloutStatus(rollout.Status, status) {
+		return nil
+	}
+
+	rollout.Status = status
+	_, err = o.kube.ZalandoV1().ElasticsearchRollouts(rollout.Namespace).UpdateStatus(ctx, rollout, metav1.UpdateOptions{})
+	return err
+}
+
+// rolloutDataSetState determines the rollout state of a single EDS.
+func (o *ElasticsearchOperator) rolloutDataSetState(ctx context.Context, eds *zv1.ElasticsearchDataSet, containerName, image string) (rolloutDataSet, error) {
+	ds := rolloutDataSet{eds: eds}
+	for _, container := range eds.Spec.Template.Spec.Containers {
+		if container.Name == containerName && container.Image == image {
+			ds.updated = true
+		}
+	}
+
+	if !ds.updated {
+		return ds, nil
+	}
+
+	sts, err := o.kube.AppsV1().StatefulSets(eds.Namespace).Get(ctx, eds.Name, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return ds, nil
+		}
+		return ds, err
+	}
+
+	replicas := int32(0)
+	if sts.Spec.Replicas != nil {
+		replicas = *sts.Spec.Replicas
+	}
+
+	ds.rolledOut = getSTSParentGeneration(sts) == eds.Generation &&
+		sts.Status.ObservedGeneration == sts.Generation &&
+		sts.Status.UpdatedReplicas == replicas &&
+		sts.Status.ReadyReplicas == replicas
+
+	if !ds.rolledOut {
+		return ds, nil
+	}
+
+	client := &ESClient{Endpoint: o.getElasticsearchEndpoint(eds), health: eds.Spec.Health, transport: o.elasticsearchTransport(eds)}
+	health, err := client.ClusterHealth()
+	if err != nil {
+		return ds, fmt.Errorf("failed to get cluster health for EDS %s/%s: %v", eds.Namespace, eds.Name, err)
+	}
+	ds.healthy = health.Status != "red"
+	return ds, nil
+}
+
+func equalRolloutStatus(a, b zv1.ElasticsearchRolloutStatus) bool {
+	if a.ObservedGeneration != b.ObservedGeneration ||
+		a.Phase != b.Phase ||
+		a.CurrentWave != b.CurrentWave ||
+		a.Message != b.Message ||
+		len(a.UpdatedDataSets) != len(b.UpdatedDataSets) {
+		return false
+	}
+	for i := range a.UpdatedDataSets {
+		if a.UpdatedDataSets[i] != b.UpdatedDataSets[i] {
+			return false
+		}
+	}
+	return true
+}
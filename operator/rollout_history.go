@@ -0,0 +1,134 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// pausedAnnotationKey pauses the rollout of the EDS when set to
+	// "true". Pods of old revisions aren't updated until it's removed.
+	pausedAnnotationKey = "es-operator.zalando.org/paused"
+	// undoAnnotationKey rolls the Pod template of the EDS back to the
+	// revision of the value, or the previous revision if empty. It's
+	// removed once handled.
+	undoAnnotationKey = "es-operator.zalando.org/undo"
+
+	defaultRevisionHistoryLimit = 5
+)
+
+// rolloutPaused returns true if the rollout of the EDS is paused.
+func rolloutPaused(eds *zv1.ElasticsearchDataSet) bool {
+	return eds.Annotations[pausedAnnotationKey] == "true"
+}
+
+// recordTemplateRevision returns the history with the template added as the
+// latest revision, unless it already is. A template equal to an older
+// revision moves to the end with a new revision number. The history is
+// bounded by the limit, dropping the oldest revisions.
+func recordTemplateRevision(history []zv1.ElasticsearchDataSetTemplateRevision, template *zv1.PodTemplateSpec, generation int64, now time.Time, limit int) []zv1.ElasticsearchDataSetTemplateRevision {
+	if len(history) > 0 && reflect.DeepEqual(history[len(history)-1].Template, *template) {
+		return history
+	}
+
+	revision := int64(0)
+	updated := make([]zv1.ElasticsearchDataSetTemplateRevision, 0, len(history)+1)
+	for _, entry := range history {
+		if entry.Revision > revision {
+			revision = entry.Revision
+		}
+		if !reflect.DeepEqual(entry.Template, *template) {
+			updated = append(updated, entry)
+		}
+	}
+	updated = append(updated, zv1.ElasticsearchDataSetTemplateRevision{
+		Revision:   revision + 1,
+		Generation: generation,
+		Created:    metav1.NewTime(now),
+		Template:   *template.DeepCopy(),
+	})
+
+	if len(updated) > limit {
+		updated = updated[len(updated)-limit:]
+	}
+	return updated
+}
+
+// undoTarget returns the revision of the history to roll back to. An empty
+// value refers to the previous revision.
+func undoTarget(history []zv1.ElasticsearchDataSetTemplateRevision, value string) (*zv1.ElasticsearchDataSetTemplateRevision, error) {
+	if value == "" {
+		if len(history) < 2 {
+			return nil, fmt.Errorf("no previous revision")
+		}
+		return &history[len(history)-2], nil
+	}
+
+	revision, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid revision '%s'", value)
+	}
+	for i := range history {
+		if history[i].Revision == revision {
+			return &history[i], nil
+		}
+	}
+	return nil, fmt.Errorf("revision %d not found in history", revision)
+}
+
+// templateHistory returns the template history of the status with the
+// current template recorded once it's reconciled into the StatefulSet.
+func (r *EDSResource) templateHistory(parentGeneration int64, now time.Time) []zv1.ElasticsearchDataSetTemplateRevision {
+	history := r.eds.Status.TemplateHistory
+	if parentGeneration != r.eds.Generation {
+		return history
+	}
+
+	limit := defaultRevisionHistoryLimit
+	if r.eds.Spec.RevisionHistoryLimit != nil {
+		limit = int(*r.eds.Spec.RevisionHistoryLimit)
+	}
+	return recordTemplateRevision(history, &r.eds.Spec.Template, r.eds.Generation, now, limit)
+}
+
+// undoRollout handles the undo annotation by setting the Pod template of the
+// EDS to the requested revision and removing the annotation.
+func (r *EDSResource) undoRollout(ctx context.Context) error {
+	value, ok := r.eds.Annotations[undoAnnotationKey]
+	if !ok {
+		return nil
+	}
+
+	eds := r.eds.DeepCopy()
+	delete(eds.Annotations, undoAnnotationKey)
+
+	target, err := undoTarget(r.eds.Status.TemplateHistory, value)
+	if err == nil {
+		eds.Spec.Template = *target.Template.DeepCopy()
+	}
+
+	eds, updateErr := r.kube.ZalandoV1().ElasticsearchDataSets(eds.Namespace).Update(ctx, eds, metav1.UpdateOptions{})
+	if updateErr != nil {
+		return fmt.Errorf("failed to undo rollout of EDS %s/%s: %v", r.eds.Namespace, r.eds.Name, updateErr)
+	}
+
+	// set TypeMeta manually because of this bug:
+	// https://github.com/kubernetes/client-go/issues/308
+	eds.APIVersion = "zalando.org/v1"
+	eds.Kind = "ElasticsearchDataSet"
+	r.eds = eds
+
+	if err != nil {
+		r.recorder.Event(r.eds, v1.EventTypeWarning, "UndoFailed", fmt.Sprintf("Failed to undo rollout: %v", err))
+		return nil
+	}
+	r.recorder.Event(r.eds, v1.EventTypeNormal, "RolledBack", fmt.Sprintf("Rolled back Pod template to revision %d", target.Revision))
+	return nil
+}
@@ -0,0 +1,79 @@
+package operator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func imageTemplate(image string) *zv1.PodTemplateSpec {
+	return &zv1.PodTemplateSpec{
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{{Name: "elasticsearch", Image: image}},
+		},
+	}
+}
+
+func TestRecordTemplateRevision(t *testing.T) {
+	now := time.Now()
+	history := recordTemplateRevision(nil, imageTemplate("es:1"), 1, now, 2)
+	require.Len(t, history, 1)
+	require.Equal(t, int64(1), history[0].Revision)
+
+	// unchanged template
+	require.Equal(t, history, recordTemplateRevision(history, imageTemplate("es:1"), 2, now, 2))
+
+	history = recordTemplateRevision(history, imageTemplate("es:2"), 3, now, 2)
+	require.Len(t, history, 2)
+	require.Equal(t, int64(2), history[1].Revision)
+	require.Equal(t, int64(3), history[1].Generation)
+
+	// rolled back template becomes the latest revision
+	history = recordTemplateRevision(history, imageTemplate("es:1"), 4, now, 2)
+	require.Len(t, history, 2)
+	require.Equal(t, int64(2), history[0].Revision)
+	require.Equal(t, int64(3), history[1].Revision)
+	require.Equal(t, "es:1", history[1].Template.Spec.Containers[0].Image)
+
+	// bounded by the limit
+	history = recordTemplateRevision(history, imageTemplate("es:3"), 5, now, 2)
+	require.Len(t, history, 2)
+	require.Equal(t, int64(3), history[0].Revision)
+	require.Equal(t, int64(4), history[1].Revision)
+}
+
+func TestUndoTarget(t *testing.T) {
+	history := []zv1.ElasticsearchDataSetTemplateRevision{
+		{Revision: 1, Template: *imageTemplate("es:1")},
+		{Revision: 2, Template: *imageTemplate("es:2")},
+		{Revision: 3, Template: *imageTemplate("es:3")},
+	}
+
+	target, err := undoTarget(history, "")
+	require.NoError(t, err)
+	require.Equal(t, int64(2), target.Revision)
+
+	target, err = undoTarget(history, "1")
+	require.NoError(t, err)
+	require.Equal(t, "es:1", target.Template.Spec.Containers[0].Image)
+
+	_, err = undoTarget(history, "4")
+	require.Error(t, err)
+	_, err = undoTarget(history, "latest")
+	require.Error(t, err)
+	_, err = undoTarget(history[:1], "")
+	require.Error(t, err)
+}
+
+func TestRolloutPaused(t *testing.T) {
+	eds := &zv1.ElasticsearchDataSet{}
+	require.False(t, rolloutPaused(eds))
+	eds.Annotations = map[string]string{pausedAnnotationKey: "true"}
+	require.True(t, rolloutPaused(eds))
+	eds.ObjectMeta = metav1.ObjectMeta{Annotations: map[string]string{pausedAnnotationKey: "false"}}
+	require.False(t, rolloutPaused(eds))
+}
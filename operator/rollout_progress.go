@@ -0,0 +1,81 @@
+package operator
+
+import (
+	"sort"
+
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+)
+
+// podReady returns true if the pod has the Ready condition.
+func podReady(pod *v1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == v1.PodReady {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// rolloutProgress returns the progress of the rollout of the update revision
+// of the StatefulSet based on the revisions of the pods. It returns nil if
+// the StatefulSet has no update revision yet.
+func rolloutProgress(generation int64, sts *appsv1.StatefulSet, pods []*v1.Pod) *zv1.ElasticsearchDataSetRolloutProgress {
+	if sts.Status.UpdateRevision == "" {
+		return nil
+	}
+
+	replicas := int32(0)
+	if sts.Spec.Replicas != nil {
+		replicas = *sts.Spec.Replicas
+	}
+
+	progress := &zv1.ElasticsearchDataSetRolloutProgress{
+		UpdateRevision: sts.Status.UpdateRevision,
+		Replicas:       replicas,
+	}
+	if sts.Status.CurrentRevision != sts.Status.UpdateRevision {
+		progress.CurrentRevision = sts.Status.CurrentRevision
+	}
+
+	revisions := make(map[string]*zv1.ElasticsearchDataSetRevisionPods)
+	for _, pod := range pods {
+		revision, ok := pod.Labels[controllerRevisionHashLabelKey]
+		if !ok || pod.DeletionTimestamp != nil {
+			continue
+		}
+		if _, ok := revisions[revision]; !ok {
+			revisions[revision] = &zv1.ElasticsearchDataSetRevisionPods{Revision: revision}
+		}
+		revisions[revision].Pods++
+		if podReady(pod) {
+			revisions[revision].ReadyPods++
+		}
+	}
+	for _, revision := range revisions {
+		progress.Revisions = append(progress.Revisions, *revision)
+	}
+	sort.Slice(progress.Revisions, func(i, j int) bool {
+		return progress.Revisions[i].Revision < progress.Revisions[j].Revision
+	})
+
+	if updated, ok := revisions[progress.UpdateRevision]; ok {
+		progress.UpdatedReplicas = updated.ReadyPods
+	}
+
+	progress.ProgressPercent = 100
+	if replicas > 0 {
+		updatedReplicas := progress.UpdatedReplicas
+		if updatedReplicas > replicas {
+			updatedReplicas = replicas
+		}
+		progress.ProgressPercent = updatedReplicas * 100 / replicas
+	}
+
+	progress.Complete = getSTSParentGeneration(sts) == generation &&
+		sts.Status.ObservedGeneration == sts.Generation &&
+		progress.UpdatedReplicas >= replicas &&
+		len(progress.Revisions) <= 1
+	return progress
+}
@@ -0,0 +1,80 @@
+package operator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func revisionPod(revision string, ready bool) *v1.Pod {
+	status := v1.ConditionFalse
+	if ready {
+		status = v1.ConditionTrue
+	}
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{controllerRevisionHashLabelKey: revision},
+		},
+		Status: v1.PodStatus{
+			Conditions: []v1.PodCondition{{Type: v1.PodReady, Status: status}},
+		},
+	}
+}
+
+func TestRolloutProgress(t *testing.T) {
+	replicas := int32(4)
+	sts := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Generation:  3,
+			Annotations: map[string]string{operatorParentGenerationAnnotationKey: "2"},
+		},
+		Spec: appsv1.StatefulSetSpec{Replicas: &replicas},
+		Status: appsv1.StatefulSetStatus{
+			ObservedGeneration: 3,
+			CurrentRevision:    "old",
+			UpdateRevision:     "new",
+		},
+	}
+
+	// no update revision
+	require.Nil(t, rolloutProgress(2, &appsv1.StatefulSet{}, nil))
+
+	// in progress
+	pods := []*v1.Pod{
+		revisionPod("new", true),
+		revisionPod("new", false),
+		revisionPod("old", true),
+		revisionPod("old", true),
+	}
+	require.Equal(t, &zv1.ElasticsearchDataSetRolloutProgress{
+		UpdateRevision:  "new",
+		CurrentRevision: "old",
+		Replicas:        4,
+		UpdatedReplicas: 1,
+		ProgressPercent: 25,
+		Revisions: []zv1.ElasticsearchDataSetRevisionPods{
+			{Revision: "new", Pods: 2, ReadyPods: 1},
+			{Revision: "old", Pods: 2, ReadyPods: 2},
+		},
+	}, rolloutProgress(2, sts, pods))
+
+	// complete
+	sts.Status.CurrentRevision = "new"
+	pods = []*v1.Pod{
+		revisionPod("new", true),
+		revisionPod("new", true),
+		revisionPod("new", true),
+		revisionPod("new", true),
+	}
+	progress := rolloutProgress(2, sts, pods)
+	require.True(t, progress.Complete)
+	require.Equal(t, int32(100), progress.ProgressPercent)
+	require.Empty(t, progress.CurrentRevision)
+
+	// newer generation not reconciled yet
+	require.False(t, rolloutProgress(3, sts, pods).Complete)
+}
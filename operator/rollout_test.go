@@ -0,0 +1,124 @@
+package operator
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func rolloutDataSets(n int) []rolloutDataSet {
+	dataSets := make([]rolloutDataSet, 0, n)
+	for i := 0; i < n; i++ {
+		dataSets = append(dataSets, rolloutDataSet{
+			eds: &zv1.ElasticsearchDataSet{
+				ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("eds-%02d", i)},
+			},
+		})
+	}
+	return dataSets
+}
+
+func edsNames(edss []*zv1.ElasticsearchDataSet) []string {
+	names := make([]string, 0, len(edss))
+	for _, eds := range edss {
+		names = append(names, eds.Name)
+	}
+	return names
+}
+
+func TestRolloutWaveTarget(t *testing.T) {
+	assert.Equal(t, 1, rolloutWaveTarget(intstr.FromInt(1), 20))
+	assert.Equal(t, 2, rolloutWaveTarget(intstr.FromString("10%"), 20))
+	assert.Equal(t, 1, rolloutWaveTarget(intstr.FromString("10%"), 3))
+	assert.Equal(t, 20, rolloutWaveTarget(intstr.FromString("100%"), 20))
+	assert.Equal(t, 3, rolloutWaveTarget(intstr.FromInt(10), 3))
+	assert.Equal(t, 1, rolloutWaveTarget(intstr.FromInt(0), 3))
+}
+
+func TestPlanRollout(t *testing.T) {
+	rollout := &zv1.ElasticsearchRollout{
+		ObjectMeta: metav1.ObjectMeta{Generation: 1},
+		Spec: zv1.ElasticsearchRolloutSpec{
+			Waves: []intstr.IntOrString{
+				intstr.FromInt(1),
+				intstr.FromString("50%"),
+				intstr.FromString("100%"),
+			},
+		},
+	}
+
+	// first wave updates a single EDS.
+	dataSets := rolloutDataSets(4)
+	step := planRollout(rollout, dataSets)
+	assert.Equal(t, zv1.RolloutPhaseProgressing, step.phase)
+	assert.EqualValues(t, 0, step.wave)
+	assert.Equal(t, []string{"eds-00"}, edsNames(step.update))
+
+	// wait for the EDS to be rolled out.
+	rollout.Status = zv1.ElasticsearchRolloutStatus{ObservedGeneration: 1, Phase: step.phase, CurrentWave: step.wave}
+	dataSets[0].updated = true
+	step = planRollout(rollout, dataSets)
+	assert.Equal(t, zv1.RolloutPhaseProgressing, step.phase)
+	assert.EqualValues(t, 0, step.wave)
+	assert.Empty(t, step.update)
+
+	// next wave once the EDS is rolled out and healthy.
+	dataSets[0].rolledOut = true
+	dataSets[0].healthy = true
+	step = planRollout(rollout, dataSets)
+	assert.Equal(t, zv1.RolloutPhaseProgressing, step.phase)
+	assert.EqualValues(t, 1, step.wave)
+	assert.Equal(t, []string{"eds-01"}, edsNames(step.update))
+
+	// paused rollouts don't start the next wave.
+	rollout.Status.CurrentWave = step.wave
+	dataSets[1] = rolloutDataSet{eds: dataSets[1].eds, updated: true, rolledOut: true, healthy: true}
+	rollout.Spec.Paused = true
+	step = planRollout(rollout, dataSets)
+	assert.Equal(t, zv1.RolloutPhasePaused, step.phase)
+	assert.EqualValues(t, 2, step.wave)
+	assert.Empty(t, step.update)
+
+	rollout.Spec.Paused = false
+	step = planRollout(rollout, dataSets)
+	assert.Equal(t, zv1.RolloutPhaseProgressing, step.phase)
+	assert.EqualValues(t, 2, step.wave)
+	assert.Equal(t, []string{"eds-02", "eds-03"}, edsNames(step.update))
+
+	// halt on health regression.
+	rollout.Status.CurrentWave = step.wave
+	dataSets[2] = rolloutDataSet{eds: dataSets[2].eds, updated: true, rolledOut: true, healthy: false}
+	step = planRollout(rollout, dataSets)
+	assert.Equal(t, zv1.RolloutPhaseHalted, step.phase)
+	assert.Empty(t, step.update)
+
+	// stays halted until the spec changes.
+	rollout.Status.Phase = step.phase
+	rollout.Status.Message = step.message
+	dataSets[2].healthy = true
+	step = planRollout(rollout, dataSets)
+	assert.Equal(t, zv1.RolloutPhaseHalted, step.phase)
+
+	rollout.Generation = 2
+	step = planRollout(rollout, dataSets)
+	assert.Equal(t, zv1.RolloutPhaseProgressing, step.phase)
+	assert.Equal(t, []string{"eds-03"}, edsNames(step.update))
+
+	// completed when all EDS are rolled out.
+	rollout.Status = zv1.ElasticsearchRolloutStatus{ObservedGeneration: 2, Phase: step.phase, CurrentWave: step.wave}
+	dataSets[3] = rolloutDataSet{eds: dataSets[3].eds, updated: true, rolledOut: true, healthy: true}
+	step = planRollout(rollout, dataSets)
+	assert.Equal(t, zv1.RolloutPhaseCompleted, step.phase)
+	assert.Empty(t, step.update)
+}
+
+func TestPlanRolloutDefaultWave(t *testing.T) {
+	rollout := &zv1.ElasticsearchRollout{}
+	step := planRollout(rollout, rolloutDataSets(3))
+	assert.Equal(t, zv1.RolloutPhaseProgressing, step.phase)
+	assert.Equal(t, []string{"eds-00", "eds-01", "eds-02"}, edsNames(step.update))
+}
@@ -0,0 +1,128 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const defaultScaleDownRecoveryWindow = 10 * time.Minute
+
+// scaleDownRecoveryWindow returns the window of the scale-down recovery
+// configuration with defaults applied.
+func scaleDownRecoveryWindow(config *zv1.ElasticsearchDataSetScaleDownRecovery) time.Duration {
+	if config.WindowSeconds > 0 {
+		return time.Duration(config.WindowSeconds) * time.Second
+	}
+	return defaultScaleDownRecoveryWindow
+}
+
+// scaleDownRecoveryStatus returns the status to monitor the recovery of a
+// scale-down from currentReplicas to replicas, or nil if it isn't monitored.
+func scaleDownRecoveryStatus(scaling *zv1.ElasticsearchDataSetScaling, currentReplicas, replicas int32) *zv1.ElasticsearchDataSetScaleDownRecoveryStatus {
+	if scaling == nil || scaling.ScaleDownRecovery == nil || replicas >= currentReplicas {
+		return nil
+	}
+	return &zv1.ElasticsearchDataSetScaleDownRecoveryStatus{
+		FromReplicas: currentReplicas,
+		ToReplicas:   replicas,
+	}
+}
+
+// scaleDownFailure returns why the cluster didn't recover from the
+// scale-down, or an empty string if it's healthy.
+func scaleDownFailure(health *ESHealth) string {
+	if health.Status == "red" {
+		return "cluster health is red"
+	}
+	if health.UnassignedShards > 0 {
+		return fmt.Sprintf("%d shards are unassigned", health.UnassignedShards)
+	}
+	return ""
+}
+
+// scaleDownRecovery monitors the cluster after the pods of a scale-down are
+// removed. If it's red or has unassigned shards for the configured window
+// the scale-down is undone by restoring the previous replicas and clearing
+// the allocation exclusions of the removed nodes. Monitoring stops once the
+// cluster stays healthy for the window after the removal. It returns the
+// updated status.
+func (r *EDSResource) scaleDownRecovery(ctx context.Context, sts *appsv1.StatefulSet, now time.Time) (*zv1.ElasticsearchDataSetScaleDownRecoveryStatus, error) {
+	previous := r.eds.Status.ScaleDownRecovery
+	if previous == nil {
+		return nil, nil
+	}
+	scaling := r.eds.Spec.Scaling
+	if scaling == nil || scaling.ScaleDownRecovery == nil {
+		return nil, nil
+	}
+	// the replicas were changed since the scale-down.
+	if r.eds.Spec.Replicas == nil || *r.eds.Spec.Replicas != previous.ToReplicas {
+		return nil, nil
+	}
+
+	status := previous.DeepCopy()
+	if status.PodsRemoved == nil {
+		if sts.Status.Replicas > status.ToReplicas {
+			return status, nil
+		}
+		status.PodsRemoved = &metav1.Time{Time: now}
+	}
+
+	health, err := r.esClient.ClusterHealth()
+	if err != nil {
+		return status, fmt.Errorf("failed to get cluster health: %v", err)
+	}
+
+	window := scaleDownRecoveryWindow(scaling.ScaleDownRecovery)
+	failure := scaleDownFailure(health)
+	if failure == "" {
+		if now.Sub(status.PodsRemoved.Time) >= window {
+			return nil, nil
+		}
+		status.UnhealthySince = nil
+		return status, nil
+	}
+
+	if status.UnhealthySince == nil {
+		status.UnhealthySince = &metav1.Time{Time: now}
+	}
+	if now.Sub(status.UnhealthySince.Time) < window {
+		return status, nil
+	}
+
+	eds := r.eds.DeepCopy()
+	eds.Spec.Replicas = &status.FromReplicas
+	eds, err = r.kube.ZalandoV1().ElasticsearchDataSets(eds.Namespace).Update(ctx, eds, metav1.UpdateOptions{})
+	if err != nil {
+		return status, fmt.Errorf("failed to undo scale-down of EDS %s/%s: %v", r.eds.Namespace, r.eds.Name, err)
+	}
+
+	// set TypeMeta manually because of this bug:
+	// https://github.com/kubernetes/client-go/issues/308
+	eds.APIVersion = "zalando.org/v1"
+	eds.Kind = "ElasticsearchDataSet"
+	r.eds = eds
+
+	// restart the cooldowns to not scale down right away again.
+	started := metav1.NewTime(now)
+	r.eds.Status.LastScaleUpStarted = &started
+	r.eds.Status.LastScaleDownStarted = &started
+
+	r.recorder.Event(r.eds, v1.EventTypeWarning, "ScaleDownUndone", fmt.Sprintf(
+		"Scaling back up from %d to %d replicas: %s for %s after the scale-down",
+		status.ToReplicas, status.FromReplicas, failure, window))
+
+	// the exclusions are also cleared once the replicas are stable again.
+	err = r.esClient.Cleanup(ctx)
+	if err != nil {
+		r.recorder.Event(r.eds, v1.EventTypeWarning, "ScaleDownUndone", fmt.Sprintf(
+			"Failed to clear allocation exclusions: %v", err))
+	}
+	return nil, nil
+}
@@ -0,0 +1,91 @@
+package operator
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestScaleDownRecoveryStatus(t *testing.T) {
+	scaling := &zv1.ElasticsearchDataSetScaling{}
+	require.Nil(t, scaleDownRecoveryStatus(scaling, 4, 3))
+
+	scaling.ScaleDownRecovery = &zv1.ElasticsearchDataSetScaleDownRecovery{}
+	require.Nil(t, scaleDownRecoveryStatus(scaling, 3, 4))
+	require.Equal(t, &zv1.ElasticsearchDataSetScaleDownRecoveryStatus{FromReplicas: 4, ToReplicas: 3}, scaleDownRecoveryStatus(scaling, 4, 3))
+}
+
+func TestScaleDownFailure(t *testing.T) {
+	require.Empty(t, scaleDownFailure(&ESHealth{Status: "green"}))
+	require.Equal(t, "cluster health is red", scaleDownFailure(&ESHealth{Status: "red", UnassignedShards: 2}))
+	require.Equal(t, "2 shards are unassigned", scaleDownFailure(&ESHealth{Status: "yellow", UnassignedShards: 2}))
+}
+
+func TestScaleDownRecovery(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	health := `{"status":"yellow","unassigned_shards":3}`
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_cluster/health",
+		func(req *http.Request) (*http.Response, error) {
+			return httpmock.NewStringResponse(200, health), nil
+		})
+
+	esURL, _ := url.Parse("http://elasticsearch:9200")
+	replicas := int32(3)
+	eds := &zv1.ElasticsearchDataSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "logs", Namespace: "default"},
+		Spec: zv1.ElasticsearchDataSetSpec{
+			Replicas: &replicas,
+			Scaling: &zv1.ElasticsearchDataSetScaling{
+				ScaleDownRecovery: &zv1.ElasticsearchDataSetScaleDownRecovery{WindowSeconds: 300},
+			},
+		},
+		Status: zv1.ElasticsearchDataSetStatus{
+			ScaleDownRecovery: &zv1.ElasticsearchDataSetScaleDownRecoveryStatus{FromReplicas: 4, ToReplicas: 3},
+		},
+	}
+	r := &EDSResource{eds: eds, esClient: &ESClient{Endpoint: esURL}, recorder: record.NewFakeRecorder(10)}
+	sts := &appsv1.StatefulSet{Status: appsv1.StatefulSetStatus{Replicas: 4}}
+	now := time.Now()
+
+	// the pods aren't removed yet
+	status, err := r.scaleDownRecovery(context.Background(), sts, now)
+	require.NoError(t, err)
+	require.Nil(t, status.PodsRemoved)
+
+	// unhealthy, but within the window
+	sts.Status.Replicas = 3
+	status, err = r.scaleDownRecovery(context.Background(), sts, now)
+	require.NoError(t, err)
+	require.Equal(t, now.Unix(), status.PodsRemoved.Unix())
+	require.Equal(t, now.Unix(), status.UnhealthySince.Unix())
+
+	// the cluster recovered
+	eds.Status.ScaleDownRecovery = status
+	health = `{"status":"green","unassigned_shards":0}`
+	status, err = r.scaleDownRecovery(context.Background(), sts, now.Add(time.Minute))
+	require.NoError(t, err)
+	require.Nil(t, status.UnhealthySince)
+
+	// and stayed healthy for the window
+	eds.Status.ScaleDownRecovery = status
+	status, err = r.scaleDownRecovery(context.Background(), sts, now.Add(5*time.Minute))
+	require.NoError(t, err)
+	require.Nil(t, status)
+
+	// monitoring stops if the replicas were changed
+	replicas = 5
+	status, err = r.scaleDownRecovery(context.Background(), sts, now)
+	require.NoError(t, err)
+	require.Nil(t, status)
+}
@@ -0,0 +1,149 @@
+package operator
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+)
+
+// scalingGuardContext is the state of the cluster a scaling guard is
+// evaluated against.
+type scalingGuardContext struct {
+	Replicas            int32   `json:"replicas"`
+	TargetReplicas      int32   `json:"targetReplicas"`
+	Indices             int     `json:"indices"`
+	Shards              int32   `json:"shards"`
+	ShardsPerNode       float64 `json:"shardsPerNode"`
+	TargetShardsPerNode float64 `json:"targetShardsPerNode"`
+	DiskUsedPercent     float64 `json:"diskUsedPercent"`
+	CPUUsagePercent     float64 `json:"cpuUsagePercent"`
+}
+
+func (c scalingGuardContext) variables() map[string]interface{} {
+	return map[string]interface{}{
+		"replicas":            int64(c.Replicas),
+		"targetReplicas":      int64(c.TargetReplicas),
+		"indices":             int64(c.Indices),
+		"shards":              int64(c.Shards),
+		"shardsPerNode":       c.ShardsPerNode,
+		"targetShardsPerNode": c.TargetShardsPerNode,
+		"diskUsedPercent":     c.DiskUsedPercent,
+		"cpuUsagePercent":     c.CPUUsagePercent,
+	}
+}
+
+// scalingGuardEnv returns the CEL environment declaring the variables of the
+// scaling guard context.
+func scalingGuardEnv() (*cel.Env, error) {
+	return cel.NewEnv(
+		cel.Variable("replicas", cel.IntType),
+		cel.Variable("targetReplicas", cel.IntType),
+		cel.Variable("indices", cel.IntType),
+		cel.Variable("shards", cel.IntType),
+		cel.Variable("shardsPerNode", cel.DoubleType),
+		cel.Variable("targetShardsPerNode", cel.DoubleType),
+		cel.Variable("diskUsedPercent", cel.DoubleType),
+		cel.Variable("cpuUsagePercent", cel.DoubleType),
+	)
+}
+
+// compileScalingGuard compiles the expression of the guard to a program.
+func compileScalingGuard(env *cel.Env, guard zv1.ElasticsearchDataSetScalingGuard) (cel.Program, error) {
+	ast, issues := env.Compile(guard.Expression)
+	if issues != nil && issues.Err() != nil {
+		return nil, fmt.Errorf("invalid expression of scaling guard %s: %v", guard.Name, issues.Err())
+	}
+	if ast.OutputType() != cel.BoolType {
+		return nil, fmt.Errorf("expression of scaling guard %s must evaluate to bool, not %s", guard.Name, ast.OutputType())
+	}
+	return env.Program(ast)
+}
+
+// validateScalingGuards checks that the expressions of the guards compile.
+func validateScalingGuards(guards []zv1.ElasticsearchDataSetScalingGuard) error {
+	if len(guards) == 0 {
+		return nil
+	}
+	env, err := scalingGuardEnv()
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, guard := range guards {
+		if _, err := compileScalingGuard(env, guard); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// failedScalingGuard evaluates the guards against the context and returns
+// the name of the first guard which isn't satisfied. Guards which can't be
+// evaluated are treated as not satisfied.
+func failedScalingGuard(guards []zv1.ElasticsearchDataSetScalingGuard, context scalingGuardContext) (string, error) {
+	if len(guards) == 0 {
+		return "", nil
+	}
+	env, err := scalingGuardEnv()
+	if err != nil {
+		return "", err
+	}
+
+	variables := context.variables()
+	for _, guard := range guards {
+		program, err := compileScalingGuard(env, guard)
+		if err != nil {
+			return guard.Name, err
+		}
+		result, _, err := program.Eval(variables)
+		if err != nil {
+			return guard.Name, fmt.Errorf("failed to evaluate scaling guard %s: %v", guard.Name, err)
+		}
+		if ok, _ := result.Value().(bool); !ok {
+			return guard.Name, nil
+		}
+	}
+	return "", nil
+}
+
+// scalingContext returns the state of the cluster for the scaling
+// operation.
+func (as *AutoScaler) scalingContext(managedIndices map[string]ESIndex, managedNodes []ESNode, currentDesiredNodeReplicas int32, operation *ScalingOperation) scalingGuardContext {
+	targetReplicas := currentDesiredNodeReplicas
+	if operation.NodeReplicas != nil {
+		targetReplicas = *operation.NodeReplicas
+	}
+
+	targetIndexReplicas := make(map[string]int32, len(operation.IndexReplicas))
+	for _, index := range operation.IndexReplicas {
+		targetIndexReplicas[index.Index] = index.Replicas
+	}
+
+	shards, targetShards := int32(0), int32(0)
+	for _, index := range managedIndices {
+		shards += index.Primaries * (index.Replicas + 1)
+		replicas, ok := targetIndexReplicas[index.Index]
+		if !ok {
+			replicas = index.Replicas
+		}
+		targetShards += index.Primaries * (replicas + 1)
+	}
+
+	cpuUsagePercent := 0.0
+	if as.esMSet != nil && len(as.esMSet.Metrics) > 0 {
+		cpuUsagePercent = float64(as.esMSet.Metrics[len(as.esMSet.Metrics)-1].Value)
+	}
+
+	return scalingGuardContext{
+		Replicas:            currentDesiredNodeReplicas,
+		TargetReplicas:      targetReplicas,
+		Indices:             len(managedIndices),
+		Shards:              shards,
+		ShardsPerNode:       shardToNodeRatio(shards, currentDesiredNodeReplicas),
+		TargetShardsPerNode: shardToNodeRatio(targetShards, targetReplicas),
+		DiskUsedPercent:     as.getMaxDiskUsage(managedNodes),
+		CPUUsagePercent:     cpuUsagePercent,
+	}
+}
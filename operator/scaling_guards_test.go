@@ -0,0 +1,81 @@
+package operator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+)
+
+func TestValidateScalingGuards(t *testing.T) {
+	require.NoError(t, validateScalingGuards(nil))
+	require.NoError(t, validateScalingGuards([]zv1.ElasticsearchDataSetScalingGuard{
+		{Name: "shards", Expression: "shardsPerNode < 30.0 && diskUsedPercent < 70.0"},
+		{Name: "replicas", Expression: "targetReplicas >= 3"},
+	}))
+
+	err := validateScalingGuards([]zv1.ElasticsearchDataSetScalingGuard{
+		{Name: "syntax", Expression: "shardsPerNode <"},
+		{Name: "unknown", Expression: "heap < 3"},
+		{Name: "type", Expression: "replicas + 1"},
+	})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "syntax")
+	require.Contains(t, err.Error(), "unknown")
+	require.Contains(t, err.Error(), "type")
+}
+
+func TestFailedScalingGuard(t *testing.T) {
+	context := scalingGuardContext{
+		Replicas:        4,
+		TargetReplicas:  3,
+		ShardsPerNode:   20,
+		DiskUsedPercent: 80,
+	}
+
+	guard, err := failedScalingGuard(nil, context)
+	require.NoError(t, err)
+	require.Empty(t, guard)
+
+	guard, err = failedScalingGuard([]zv1.ElasticsearchDataSetScalingGuard{
+		{Name: "shards", Expression: "shardsPerNode < 30.0"},
+		{Name: "disk", Expression: "diskUsedPercent < 70.0"},
+	}, context)
+	require.NoError(t, err)
+	require.Equal(t, "disk", guard)
+
+	guard, err = failedScalingGuard([]zv1.ElasticsearchDataSetScalingGuard{
+		{Name: "replicas", Expression: "targetReplicas >= replicas - 1"},
+	}, context)
+	require.NoError(t, err)
+	require.Empty(t, guard)
+
+	guard, err = failedScalingGuard([]zv1.ElasticsearchDataSetScalingGuard{
+		{Name: "division", Expression: "replicas / (targetReplicas - 3) > 1"},
+	}, context)
+	require.Error(t, err)
+	require.Equal(t, "division", guard)
+}
+
+func TestScaleDownGuards(t *testing.T) {
+	eds := edsTestFixture(4)
+	esNodes := []ESNode{{IP: "1.2.3.4", DiskUsedPercent: 60}}
+	esIndices := map[string]ESIndex{
+		"ad1": {Replicas: 1, Primaries: 6, Index: "ad1"},
+	}
+
+	eds.Spec.Scaling.ScaleDownGuards = []zv1.ElasticsearchDataSetScalingGuard{
+		{Name: "disk", Expression: "diskUsedPercent < 50.0"},
+	}
+	actual := systemUnderTest(eds, nil, nil).calculateScalingOperation(esIndices, esNodes, DOWN)
+	require.Equal(t, NONE, actual.ScalingDirection, actual.Description)
+	require.Contains(t, actual.Description, "disk")
+
+	eds.Spec.Scaling.ScaleDownGuards = []zv1.ElasticsearchDataSetScalingGuard{
+		{Name: "disk", Expression: "diskUsedPercent < 70.0"},
+		{Name: "shards", Expression: "shards == 12 && targetShardsPerNode == 4.0"},
+	}
+	actual = systemUnderTest(eds, nil, nil).calculateScalingOperation(esIndices, esNodes, DOWN)
+	require.Equal(t, DOWN, actual.ScalingDirection, actual.Description)
+	require.Equal(t, int32(3), *actual.NodeReplicas, actual.Description)
+}
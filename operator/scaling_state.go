@@ -0,0 +1,163 @@
+package operator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// scalingStateVersion is the version of the format of the scaling state in
+// the status of an EDS.
+const scalingStateVersion = 1
+
+// scalingDirectionState converts the scaling direction to its API form.
+func scalingDirectionState(direction ScalingDirection) zv1.ElasticsearchDataSetScalingDirection {
+	switch direction {
+	case UP:
+		return zv1.ScalingDirectionUp
+	case DOWN:
+		return zv1.ScalingDirectionDown
+	}
+	return zv1.ScalingDirectionNone
+}
+
+// scalingDirectionFromState converts the API form of a scaling direction.
+func scalingDirectionFromState(direction zv1.ElasticsearchDataSetScalingDirection) ScalingDirection {
+	switch direction {
+	case zv1.ScalingDirectionUp:
+		return UP
+	case zv1.ScalingDirectionDown:
+		return DOWN
+	}
+	return NONE
+}
+
+// scalingOperationState converts the scaling operation to the form stored in
+// the scaling state.
+func scalingOperationState(operation *ScalingOperation) *zv1.ElasticsearchDataSetScalingOperation {
+	state := &zv1.ElasticsearchDataSetScalingOperation{
+		Direction:   scalingDirectionState(operation.ScalingDirection),
+		Description: operation.Description,
+	}
+	if operation.NodeReplicas != nil {
+		replicas := *operation.NodeReplicas
+		state.NodeReplicas = &replicas
+	}
+	for _, index := range operation.IndexReplicas {
+		state.IndexReplicas = append(state.IndexReplicas, zv1.ElasticsearchDataSetIndexReplicas{
+			Index:     index.Index,
+			Primaries: index.Primaries,
+			Replicas:  index.Replicas,
+		})
+	}
+	return state
+}
+
+// scalingOperationFromState converts a scaling operation stored in the
+// scaling state.
+func scalingOperationFromState(state *zv1.ElasticsearchDataSetScalingOperation) *ScalingOperation {
+	operation := &ScalingOperation{
+		ScalingDirection: scalingDirectionFromState(state.Direction),
+		Description:      state.Description,
+	}
+	if state.NodeReplicas != nil {
+		replicas := *state.NodeReplicas
+		operation.NodeReplicas = &replicas
+	}
+	for _, index := range state.IndexReplicas {
+		operation.IndexReplicas = append(operation.IndexReplicas, ESIndex{
+			Index:     index.Index,
+			Primaries: index.Primaries,
+			Replicas:  index.Replicas,
+		})
+	}
+	return operation
+}
+
+// edsScalingOperation returns the pending scaling operation of the EDS from
+// its scaling state, or from the legacy scaling-operation annotation written
+// by older operators. If no operation is defined it will return nil.
+func edsScalingOperation(eds *zv1.ElasticsearchDataSet) (*ScalingOperation, error) {
+	if state := eds.Status.ScalingState; state != nil && state.Operation != nil {
+		return scalingOperationFromState(state.Operation), nil
+	}
+	if op, ok := eds.Annotations[esScalingOperationKey]; ok {
+		scalingOperation := &ScalingOperation{}
+		err := json.Unmarshal([]byte(op), scalingOperation)
+		if err != nil {
+			return nil, err
+		}
+		return scalingOperation, nil
+	}
+	return nil, nil
+}
+
+// hasScalingOperation returns true if the EDS has a pending scaling
+// operation.
+func hasScalingOperation(eds *zv1.ElasticsearchDataSet) bool {
+	if state := eds.Status.ScalingState; state != nil && state.Operation != nil {
+		return true
+	}
+	_, ok := eds.Annotations[esScalingOperationKey]
+	return ok
+}
+
+// staleScalingOperation returns true if the node replicas of the operation
+// don't match the EDS anymore, e.g. because the replicas couldn't be updated
+// after the operation was stored or were changed since.
+func staleScalingOperation(eds *zv1.ElasticsearchDataSet, operation *ScalingOperation) bool {
+	return operation.NodeReplicas != nil && eds.Spec.Replicas != nil && *operation.NodeReplicas != *eds.Spec.Replicas
+}
+
+// updateScalingState returns the scaling state of the EDS after an
+// evaluation of the autoscaler. The operation is only replaced if a new one
+// is given.
+func updateScalingState(current *zv1.ElasticsearchDataSetScalingState, hint ScalingDirection, scaleUpSamples, scaleDownSamples int32, operation *ScalingOperation) *zv1.ElasticsearchDataSetScalingState {
+	state := &zv1.ElasticsearchDataSetScalingState{}
+	if current != nil {
+		state = current.DeepCopy()
+	}
+	state.Version = scalingStateVersion
+	state.LastDirection = scalingDirectionState(hint)
+	state.ScaleUpSamples = scaleUpSamples
+	state.ScaleDownSamples = scaleDownSamples
+	if operation != nil {
+		state.Operation = scalingOperationState(operation)
+	}
+	return state
+}
+
+// removeScalingOperation removes the applied scaling operation from the
+// scaling state and the legacy 'scaling-operation' annotation of the EDS.
+// If the operation is already gone, this is a no-op.
+func (r *EDSResource) removeScalingOperation(ctx context.Context) error {
+	if _, ok := r.eds.Annotations[esScalingOperationKey]; ok {
+		delete(r.eds.Annotations, esScalingOperationKey)
+		eds, err := r.kube.ZalandoV1().
+			ElasticsearchDataSets(r.eds.Namespace).
+			Update(ctx, r.eds, metav1.UpdateOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to remove 'scaling-operating' annotation from EDS: %v", err)
+		}
+		// set TypeMeta manually because of this bug:
+		// https://github.com/kubernetes/client-go/issues/308
+		eds.APIVersion = "zalando.org/v1"
+		eds.Kind = "ElasticsearchDataSet"
+		r.eds = eds
+	}
+
+	if state := r.eds.Status.ScalingState; state != nil && state.Operation != nil {
+		oldStatus := r.eds.Status.DeepCopy()
+		r.eds.Status.ScalingState = state.DeepCopy()
+		r.eds.Status.ScalingState.Operation = nil
+		eds, err := patchEDSStatus(ctx, r.kube.ZalandoV1(), r.eds, oldStatus)
+		if err != nil {
+			return fmt.Errorf("failed to remove scaling operation from the status of EDS: %v", err)
+		}
+		r.eds = eds
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package operator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestScalingOperationState(t *testing.T) {
+	replicas := int32(4)
+	operation := &ScalingOperation{
+		ScalingDirection: DOWN,
+		NodeReplicas:     &replicas,
+		IndexReplicas:    []ESIndex{{Index: "logs", Primaries: 6, Replicas: 1}},
+		Description:      "Decreasing node replicas to 4.",
+	}
+	state := scalingOperationState(operation)
+	require.Equal(t, zv1.ScalingDirectionDown, state.Direction)
+	require.Equal(t, []zv1.ElasticsearchDataSetIndexReplicas{{Index: "logs", Primaries: 6, Replicas: 1}}, state.IndexReplicas)
+	require.Equal(t, operation, scalingOperationFromState(state))
+}
+
+func TestEDSScalingOperation(t *testing.T) {
+	eds := &zv1.ElasticsearchDataSet{}
+	operation, err := edsScalingOperation(eds)
+	require.NoError(t, err)
+	require.Nil(t, operation)
+	require.False(t, hasScalingOperation(eds))
+
+	// operations of older operators are read from the annotation.
+	eds.Annotations = map[string]string{esScalingOperationKey: `{"ScalingDirection":2,"NodeReplicas":3,"IndexReplicas":null,"Description":"up"}`}
+	operation, err = edsScalingOperation(eds)
+	require.NoError(t, err)
+	require.Equal(t, UP, operation.ScalingDirection)
+	require.EqualValues(t, 3, *operation.NodeReplicas)
+	require.True(t, hasScalingOperation(eds))
+
+	// the scaling state takes precedence.
+	eds.Status.ScalingState = &zv1.ElasticsearchDataSetScalingState{
+		Operation: &zv1.ElasticsearchDataSetScalingOperation{Direction: zv1.ScalingDirectionDown, Description: "down"},
+	}
+	operation, err = edsScalingOperation(eds)
+	require.NoError(t, err)
+	require.Equal(t, DOWN, operation.ScalingDirection)
+	require.Nil(t, operation.NodeReplicas)
+}
+
+func TestStaleScalingOperation(t *testing.T) {
+	replicas := int32(3)
+	eds := &zv1.ElasticsearchDataSet{Spec: zv1.ElasticsearchDataSetSpec{Replicas: &replicas}}
+	require.False(t, staleScalingOperation(eds, &ScalingOperation{}))
+
+	nodes := int32(3)
+	require.False(t, staleScalingOperation(eds, &ScalingOperation{NodeReplicas: &nodes}))
+
+	nodes = 4
+	require.True(t, staleScalingOperation(eds, &ScalingOperation{NodeReplicas: &nodes}))
+}
+
+func TestUpdateScalingState(t *testing.T) {
+	operation := &ScalingOperation{ScalingDirection: UP, Description: "up"}
+	state := updateScalingState(nil, UP, 3, 0, operation)
+	require.EqualValues(t, scalingStateVersion, state.Version)
+	require.Equal(t, zv1.ScalingDirectionUp, state.LastDirection)
+	require.EqualValues(t, 3, state.ScaleUpSamples)
+	require.Equal(t, "up", state.Operation.Description)
+
+	// the pending operation is kept.
+	next := updateScalingState(state, NONE, 0, 1, nil)
+	require.Equal(t, zv1.ScalingDirectionNone, next.LastDirection)
+	require.EqualValues(t, 1, next.ScaleDownSamples)
+	require.Equal(t, state.Operation, next.Operation)
+	require.Equal(t, zv1.ScalingDirectionUp, state.LastDirection)
+}
+
+func TestSampleWindows(t *testing.T) {
+	eds := edsTestFixture(3)
+	eds.Spec.Scaling.ScaleUpCPUBoundary = 50
+	eds.Spec.Scaling.ScaleUpThresholdDurationSeconds = 120
+	eds.Spec.Scaling.ScaleDownCPUBoundary = 20
+	eds.Spec.Scaling.ScaleDownThresholdDurationSeconds = 600
+	metrics := func(values ...int32) *zv1.ElasticsearchMetricSet {
+		metricSet := &zv1.ElasticsearchMetricSet{}
+		for _, value := range values {
+			metricSet.Metrics = append(metricSet.Metrics, zv1.ElasticsearchMetric{Timestamp: metav1.Now(), Value: value})
+		}
+		return metricSet
+	}
+
+	up, down := systemUnderTest(eds, nil, nil).sampleWindows()
+	require.Zero(t, up)
+	require.Zero(t, down)
+
+	// the scale-up window is capped at 2 samples.
+	up, down = systemUnderTest(eds, metrics(10, 60, 70, 80), nil).sampleWindows()
+	require.EqualValues(t, 2, up)
+	require.Zero(t, down)
+
+	up, down = systemUnderTest(eds, metrics(10, 60, 10, 10), nil).sampleWindows()
+	require.Zero(t, up)
+	require.EqualValues(t, 2, down)
+}
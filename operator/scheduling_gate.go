@@ -0,0 +1,156 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// clusterHealthSchedulingGate holds pods back from being scheduled
+	// until the cluster health reaches the minimum status.
+	clusterHealthSchedulingGate = "es-operator.zalando.org/cluster-health"
+
+	defaultSchedulingGateMinHealth      = "green"
+	defaultSchedulingGateTimeoutSeconds = 600
+)
+
+// schedulingGateSettings returns the minimum health and the timeout of the
+// scheduling gate with defaults applied.
+func schedulingGateSettings(config *zv1.ElasticsearchDataSetSchedulingGate) (string, time.Duration) {
+	minHealth := config.MinHealth
+	if minHealth == "" {
+		minHealth = defaultSchedulingGateMinHealth
+	}
+	timeoutSeconds := config.TimeoutSeconds
+	if timeoutSeconds <= 0 {
+		timeoutSeconds = defaultSchedulingGateTimeoutSeconds
+	}
+	return minHealth, time.Duration(timeoutSeconds) * time.Second
+}
+
+// healthAtLeast returns true if the cluster health status is at least the
+// minimum status.
+func healthAtLeast(status, minHealth string) bool {
+	value, ok := clusterHealthValue(status)
+	if !ok {
+		return false
+	}
+	minValue, _ := clusterHealthValue(minHealth)
+	return value <= minValue
+}
+
+// injectSchedulingGate adds the scheduling gate of the operator to the pod
+// template.
+func injectSchedulingGate(spec *v1.PodSpec, config *zv1.ElasticsearchDataSetSchedulingGate) {
+	if config == nil || !config.Enabled || hasSchedulingGate(spec) {
+		return
+	}
+	spec.SchedulingGates = append(spec.SchedulingGates, v1.PodSchedulingGate{Name: clusterHealthSchedulingGate})
+}
+
+// hasSchedulingGate returns true if the pod spec has the scheduling gate of
+// the operator.
+func hasSchedulingGate(spec *v1.PodSpec) bool {
+	for _, gate := range spec.SchedulingGates {
+		if gate.Name == clusterHealthSchedulingGate {
+			return true
+		}
+	}
+	return false
+}
+
+// runSchedulingGates periodically releases the gated pods of the EDS.
+func (o *ElasticsearchOperator) runSchedulingGates(ctx context.Context) {
+	nextCheck := time.Now().Add(-o.interval)
+
+	for {
+		o.logger.Debug("Checking scheduling gates")
+		select {
+		case <-time.After(time.Until(nextCheck)):
+			nextCheck = time.Now().Add(o.interval)
+
+			resources, err := o.collectResources(ctx)
+			if err != nil {
+				o.logger.Error(err)
+				continue
+			}
+
+			for _, es := range resources {
+				err := o.releaseGatedPods(ctx, es, time.Now())
+				if err != nil {
+					o.logger.Errorf("Failed to release gated pods of EDS %s/%s: %v", es.ElasticsearchDataSet.Namespace, es.ElasticsearchDataSet.Name, err)
+				}
+			}
+		case <-ctx.Done():
+			o.logger.Info("Terminating scheduling gate loop.")
+			return
+		}
+	}
+}
+
+// releaseGatedPods removes the scheduling gate of the operator from the pods
+// of the EDS once the cluster health reached the minimum status, or from
+// the pods waiting for longer than the timeout. The gate is removed even if
+// it was disabled in the meantime.
+func (o *ElasticsearchOperator) releaseGatedPods(ctx context.Context, es *ESResource, now time.Time) error {
+	eds := es.ElasticsearchDataSet
+	var gated []v1.Pod
+	for _, pod := range es.Pods {
+		if hasSchedulingGate(&pod.Spec) {
+			gated = append(gated, pod)
+		}
+	}
+	if len(gated) == 0 {
+		return nil
+	}
+
+	config := eds.Spec.SchedulingGate
+	if config == nil {
+		config = &zv1.ElasticsearchDataSetSchedulingGate{}
+	}
+	minHealth, timeout := schedulingGateSettings(config)
+	healthy := !config.Enabled
+	reason := "the scheduling gate is disabled"
+	if config.Enabled {
+		client := &ESClient{Endpoint: o.getElasticsearchEndpoint(eds), health: eds.Spec.Health, transport: o.elasticsearchTransport(eds)}
+		health, err := client.ClusterHealth()
+		if err != nil {
+			o.logger.Warnf("Failed to get cluster health of EDS %s/%s: %v", eds.Namespace, eds.Name, err)
+			reason = "the cluster health is unknown"
+		} else {
+			healthy = healthAtLeast(health.Status, minHealth)
+			reason = fmt.Sprintf("the cluster health is %s", health.Status)
+		}
+	}
+
+	for _, pod := range gated {
+		release := healthy
+		eventType, message := v1.EventTypeNormal, fmt.Sprintf("Released Pod '%s/%s' to the scheduler, %s", pod.Namespace, pod.Name, reason)
+		if !release && now.Sub(pod.CreationTimestamp.Time) > timeout {
+			release = true
+			eventType, message = v1.EventTypeWarning, fmt.Sprintf("Released Pod '%s/%s' to the scheduler after %s, %s", pod.Namespace, pod.Name, timeout, reason)
+		}
+		if !release {
+			continue
+		}
+
+		updated := pod.DeepCopy()
+		updated.Spec.SchedulingGates = nil
+		for _, gate := range pod.Spec.SchedulingGates {
+			if gate.Name != clusterHealthSchedulingGate {
+				updated.Spec.SchedulingGates = append(updated.Spec.SchedulingGates, gate)
+			}
+		}
+		_, err := o.kube.CoreV1().Pods(pod.Namespace).Update(ctx, updated, metav1.UpdateOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to release pod %s/%s: %v", pod.Namespace, pod.Name, err)
+		}
+		o.recorder.Event(eds, eventType, "ReleasedPod", message)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package operator
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	"github.com/zalando-incubator/es-operator/pkg/clientset"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestInjectSchedulingGate(t *testing.T) {
+	spec := &v1.PodSpec{SchedulerName: "custom", SchedulingGates: []v1.PodSchedulingGate{{Name: "example.org/quota"}}}
+	injectSchedulingGate(spec, nil)
+	require.Len(t, spec.SchedulingGates, 1)
+
+	config := &zv1.ElasticsearchDataSetSchedulingGate{Enabled: true}
+	injectSchedulingGate(spec, config)
+	injectSchedulingGate(spec, config)
+	require.Equal(t, []v1.PodSchedulingGate{{Name: "example.org/quota"}, {Name: clusterHealthSchedulingGate}}, spec.SchedulingGates)
+	require.Equal(t, "custom", spec.SchedulerName)
+}
+
+func TestHealthAtLeast(t *testing.T) {
+	require.True(t, healthAtLeast("green", "green"))
+	require.True(t, healthAtLeast("green", "yellow"))
+	require.True(t, healthAtLeast("yellow", "yellow"))
+	require.False(t, healthAtLeast("yellow", "green"))
+	require.False(t, healthAtLeast("red", "yellow"))
+	require.False(t, healthAtLeast("", "yellow"))
+
+	minHealth, timeout := schedulingGateSettings(&zv1.ElasticsearchDataSetSchedulingGate{})
+	require.Equal(t, "green", minHealth)
+	require.Equal(t, 10*time.Minute, timeout)
+}
+
+func TestReleaseGatedPods(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_cluster/health",
+		httpmock.NewStringResponder(200, `{"status":"yellow"}`))
+
+	now := time.Now()
+	gatedPod := func(name string, created time.Time) v1.Pod {
+		return v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", CreationTimestamp: metav1.NewTime(created)},
+			Spec: v1.PodSpec{SchedulingGates: []v1.PodSchedulingGate{
+				{Name: clusterHealthSchedulingGate},
+				{Name: "example.org/quota"},
+			}},
+		}
+	}
+	pods := []v1.Pod{gatedPod("es-data-0", now), gatedPod("es-data-1", now.Add(-time.Hour)), {ObjectMeta: metav1.ObjectMeta{Name: "es-data-2", Namespace: "default"}}}
+	kube := fake.NewSimpleClientset(&pods[0], &pods[1], &pods[2])
+	endpoint, err := url.Parse("http://elasticsearch:9200")
+	require.NoError(t, err)
+	esOperator := NewElasticsearchOperator(&clientset.Clientset{Interface: kube}, Options{Interval: time.Second, AutoscalerInterval: time.Second, ClusterDNSZone: "cluster.local.", ElasticsearchEndpoint: endpoint})
+	recorder := record.NewFakeRecorder(10)
+	esOperator.recorder = recorder
+
+	es := &ESResource{
+		ElasticsearchDataSet: &zv1.ElasticsearchDataSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "es-data", Namespace: "default"},
+			Spec: zv1.ElasticsearchDataSetSpec{
+				SchedulingGate: &zv1.ElasticsearchDataSetSchedulingGate{Enabled: true},
+			},
+		},
+		Pods: pods,
+	}
+	gates := func(name string) []v1.PodSchedulingGate {
+		pod, err := kube.CoreV1().Pods("default").Get(context.Background(), name, metav1.GetOptions{})
+		require.NoError(t, err)
+		return pod.Spec.SchedulingGates
+	}
+
+	// only the pod waiting longer than the timeout is released while the
+	// cluster is yellow.
+	require.NoError(t, esOperator.releaseGatedPods(context.Background(), es, now))
+	require.Len(t, gates("es-data-0"), 2)
+	require.Equal(t, []v1.PodSchedulingGate{{Name: "example.org/quota"}}, gates("es-data-1"))
+	require.Contains(t, <-recorder.Events, "Warning ReleasedPod")
+
+	es.Pods = pods[:1]
+	es.ElasticsearchDataSet.Spec.SchedulingGate.MinHealth = "yellow"
+	require.NoError(t, esOperator.releaseGatedPods(context.Background(), es, now))
+	require.Equal(t, []v1.PodSchedulingGate{{Name: "example.org/quota"}}, gates("es-data-0"))
+	require.Contains(t, <-recorder.Events, "Normal ReleasedPod")
+}
@@ -0,0 +1,68 @@
+package operator
+
+import (
+	"fmt"
+	"strconv"
+
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+)
+
+const (
+	// esSchemaVersionKey is the annotation recording the schema version of
+	// the operator managed state on the EDS, e.g. the scaling state in the
+	// status.
+	esSchemaVersionKey = "es-operator.zalando.org/schema-version"
+
+	// currentSchemaVersion is the version of the state the operator stores
+	// in annotations and status of an EDS. It must be increased whenever
+	// the format of this state changes in a way which older operator
+	// versions could misinterpret.
+	//
+	// Version 2 moved the pending scaling operation from the
+	// 'es-operator.zalando.org/current-scaling-operation' annotation to
+	// status.scalingState, which older operators don't read.
+	currentSchemaVersion = 2
+)
+
+// edsSchemaVersion returns the highest schema version recorded on the EDS,
+// either via the schema-version annotation or the status. An EDS which was
+// never written by a schema aware operator has version 0.
+func edsSchemaVersion(eds *zv1.ElasticsearchDataSet) (int32, error) {
+	version := eds.Status.SchemaVersion
+	if value, ok := eds.Annotations[esSchemaVersionKey]; ok {
+		v, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			return 0, fmt.Errorf("invalid '%s' annotation '%s': %v", esSchemaVersionKey, value, err)
+		}
+		if int32(v) > version {
+			version = int32(v)
+		}
+	}
+	return version, nil
+}
+
+// checkSchemaVersion returns an error if the state of the EDS was written by
+// an operator using a newer schema version than this operator understands.
+// Operating on such an EDS could misinterpret the stored state, so it must
+// be left alone until the operator is upgraded.
+func checkSchemaVersion(eds *zv1.ElasticsearchDataSet) error {
+	version, err := edsSchemaVersion(eds)
+	if err != nil {
+		return err
+	}
+
+	if version > currentSchemaVersion {
+		return fmt.Errorf("EDS %s/%s has schema version %d, the operator only supports up to version %d", eds.Namespace, eds.Name, version, currentSchemaVersion)
+	}
+	return nil
+}
+
+// setSchemaVersion records the current schema version in the annotations of
+// the EDS. Older schema versions are migrated implicitly as the managed
+// annotations are compatible up to the current version.
+func setSchemaVersion(eds *zv1.ElasticsearchDataSet) {
+	if eds.Annotations == nil {
+		eds.Annotations = make(map[string]string, 1)
+	}
+	eds.Annotations[esSchemaVersionKey] = strconv.Itoa(currentSchemaVersion)
+}
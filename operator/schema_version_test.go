@@ -0,0 +1,67 @@
+package operator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCheckSchemaVersion(t *testing.T) {
+	for _, tc := range []struct {
+		msg         string
+		annotations map[string]string
+		status      int32
+		valid       bool
+	}{
+		{
+			msg:   "EDS without schema version is valid",
+			valid: true,
+		},
+		{
+			msg:         "current schema version is valid",
+			annotations: map[string]string{esSchemaVersionKey: "1"},
+			status:      currentSchemaVersion,
+			valid:       true,
+		},
+		{
+			msg:         "newer schema version in annotation is invalid",
+			annotations: map[string]string{esSchemaVersionKey: "3"},
+			valid:       false,
+		},
+		{
+			msg:    "newer schema version in status is invalid",
+			status: currentSchemaVersion + 1,
+			valid:  false,
+		},
+		{
+			msg:         "malformed annotation is invalid",
+			annotations: map[string]string{esSchemaVersionKey: "v1"},
+			valid:       false,
+		},
+	} {
+		t.Run(tc.msg, func(t *testing.T) {
+			eds := &zv1.ElasticsearchDataSet{
+				ObjectMeta: metav1.ObjectMeta{Annotations: tc.annotations},
+				Status:     zv1.ElasticsearchDataSetStatus{SchemaVersion: tc.status},
+			}
+			err := checkSchemaVersion(eds)
+			if tc.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestSetSchemaVersion(t *testing.T) {
+	eds := &zv1.ElasticsearchDataSet{}
+	setSchemaVersion(eds)
+
+	version, err := edsSchemaVersion(eds)
+	require.NoError(t, err)
+	assert.EqualValues(t, currentSchemaVersion, version)
+}
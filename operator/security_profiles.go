@@ -0,0 +1,139 @@
+package operator
+
+import (
+	"fmt"
+	"strings"
+
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	v1 "k8s.io/api/core/v1"
+)
+
+// appArmorAnnotationKeyPrefix is the prefix of the deprecated annotations
+// setting the AppArmor profile of a container. They conflict with the
+// appArmorProfile field of the security context.
+const appArmorAnnotationKeyPrefix = "container.apparmor.security.beta.kubernetes.io/"
+
+// injectSecurityProfiles sets the AppArmor, seccomp and SELinux profiles
+// configured in the EDS on the Elasticsearch container.
+func injectSecurityProfiles(spec *v1.PodSpec, config *zv1.ElasticsearchDataSetPodSecurity) {
+	if config == nil || len(spec.Containers) == 0 {
+		return
+	}
+	if config.AppArmorProfile == nil && config.SeccompProfile == nil && config.SELinuxOptions == nil {
+		return
+	}
+
+	container := &spec.Containers[0]
+	if container.SecurityContext == nil {
+		container.SecurityContext = &v1.SecurityContext{}
+	}
+	if config.AppArmorProfile != nil {
+		container.SecurityContext.AppArmorProfile = config.AppArmorProfile.DeepCopy()
+	}
+	if config.SeccompProfile != nil {
+		container.SecurityContext.SeccompProfile = config.SeccompProfile.DeepCopy()
+	}
+	if config.SELinuxOptions != nil {
+		container.SecurityContext.SELinuxOptions = config.SELinuxOptions.DeepCopy()
+	}
+}
+
+// localhostProfileError returns an error if the localhost profile isn't set
+// exactly for the Localhost type.
+func localhostProfileError(field string, localhost bool, profile *string) string {
+	switch {
+	case localhost && (profile == nil || *profile == ""):
+		return fmt.Sprintf("%s.localhostProfile must be set for type Localhost", field)
+	case !localhost && profile != nil:
+		return fmt.Sprintf("%s.localhostProfile must only be set for type Localhost", field)
+	}
+	return ""
+}
+
+// securityProfileErrors returns the errors of the security profiles
+// configured in the EDS, which would make the API server reject the pods.
+func securityProfileErrors(template *v1.PodTemplateSpec, config *zv1.ElasticsearchDataSetPodSecurity) []string {
+	errors := make([]string, 0)
+	if config == nil {
+		return errors
+	}
+
+	if profile := config.AppArmorProfile; profile != nil {
+		switch profile.Type {
+		case v1.AppArmorProfileTypeRuntimeDefault, v1.AppArmorProfileTypeLocalhost, v1.AppArmorProfileTypeUnconfined:
+			if err := localhostProfileError("appArmorProfile", profile.Type == v1.AppArmorProfileTypeLocalhost, profile.LocalhostProfile); err != "" {
+				errors = append(errors, err)
+			}
+		default:
+			errors = append(errors, fmt.Sprintf("appArmorProfile.type %s must be RuntimeDefault, Localhost or Unconfined", profile.Type))
+		}
+		if len(template.Spec.Containers) > 0 {
+			key := appArmorAnnotationKeyPrefix + template.Spec.Containers[0].Name
+			if _, ok := template.Annotations[key]; ok {
+				errors = append(errors, fmt.Sprintf("annotation %s of the pod template conflicts with appArmorProfile and must be removed", key))
+			}
+		}
+	}
+
+	if profile := config.SeccompProfile; profile != nil {
+		switch profile.Type {
+		case v1.SeccompProfileTypeRuntimeDefault, v1.SeccompProfileTypeLocalhost, v1.SeccompProfileTypeUnconfined:
+			if err := localhostProfileError("seccompProfile", profile.Type == v1.SeccompProfileTypeLocalhost, profile.LocalhostProfile); err != "" {
+				errors = append(errors, err)
+			}
+		default:
+			errors = append(errors, fmt.Sprintf("seccompProfile.type %s must be RuntimeDefault, Localhost or Unconfined", profile.Type))
+		}
+	}
+
+	if options := config.SELinuxOptions; options != nil && options.Level != "" && !strings.HasPrefix(options.Level, "s") {
+		errors = append(errors, fmt.Sprintf("seLinuxOptions.level %s must be an MLS/MCS level like s0:c123,c456", options.Level))
+	}
+	return errors
+}
+
+// securityProfileRecommendations returns recommendations for the security
+// profiles configured in the EDS which are valid but not suited well for
+// Elasticsearch.
+func securityProfileRecommendations(config *zv1.ElasticsearchDataSetPodSecurity) []string {
+	recommendations := make([]string, 0)
+	if config == nil {
+		return recommendations
+	}
+
+	if profile := config.AppArmorProfile; profile != nil && profile.Type == v1.AppArmorProfileTypeUnconfined {
+		recommendations = append(recommendations, "Elasticsearch doesn't need an Unconfined AppArmor profile, use RuntimeDefault")
+	}
+	if profile := config.SeccompProfile; profile != nil {
+		switch profile.Type {
+		case v1.SeccompProfileTypeUnconfined:
+			recommendations = append(recommendations, "Elasticsearch installs its own system call filter and doesn't need an Unconfined seccomp profile, use RuntimeDefault")
+		case v1.SeccompProfileTypeLocalhost:
+			recommendations = append(recommendations, fmt.Sprintf("the seccomp profile %s must allow the seccomp and prctl system calls, otherwise Elasticsearch fails to install its system call filter on bootstrap", *profile.LocalhostProfile))
+		}
+	}
+	if options := config.SELinuxOptions; options != nil {
+		if options.Type == "spc_t" {
+			recommendations = append(recommendations, "the SELinux type spc_t doesn't confine the container, use container_t")
+		}
+		recommendations = append(recommendations, "volumes are relabeled for the SELinux options on every mount, which delays the start of pods with large data volumes unless the CSI driver supports SELinux mount options")
+	}
+	return recommendations
+}
+
+// validateSecurityProfiles reports invalid security profiles of the EDS
+// before the StatefulSet fails to create pods, along with recommendations
+// for Elasticsearch.
+func (r *EDSResource) validateSecurityProfiles() error {
+	config := r.eds.Spec.PodSecurity
+	errors := securityProfileErrors(r.PodTemplateSpec(), config)
+	if len(errors) > 0 {
+		message := fmt.Sprintf("Invalid security profiles in spec.podSecurity: %s", strings.Join(errors, "; "))
+		r.recorder.Event(r.eds, v1.EventTypeWarning, "InvalidSecurityProfile", message)
+		return fmt.Errorf("%s", message)
+	}
+	for _, recommendation := range securityProfileRecommendations(config) {
+		r.recorder.Event(r.eds, v1.EventTypeNormal, "SecurityProfileRecommendation", recommendation)
+	}
+	return nil
+}
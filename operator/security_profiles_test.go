@@ -0,0 +1,61 @@
+package operator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestInjectSecurityProfiles(t *testing.T) {
+	profile := "es-seccomp.json"
+	spec := &v1.PodSpec{Containers: []v1.Container{{Name: "elasticsearch"}, {Name: "exporter"}}}
+
+	injectSecurityProfiles(spec, &zv1.ElasticsearchDataSetPodSecurity{
+		AppArmorProfile: &v1.AppArmorProfile{Type: v1.AppArmorProfileTypeRuntimeDefault},
+		SeccompProfile:  &v1.SeccompProfile{Type: v1.SeccompProfileTypeLocalhost, LocalhostProfile: &profile},
+		SELinuxOptions:  &v1.SELinuxOptions{Level: "s0:c123,c456"},
+	})
+
+	assert.Equal(t, &v1.SecurityContext{
+		AppArmorProfile: &v1.AppArmorProfile{Type: v1.AppArmorProfileTypeRuntimeDefault},
+		SeccompProfile:  &v1.SeccompProfile{Type: v1.SeccompProfileTypeLocalhost, LocalhostProfile: &profile},
+		SELinuxOptions:  &v1.SELinuxOptions{Level: "s0:c123,c456"},
+	}, spec.Containers[0].SecurityContext)
+	assert.Nil(t, spec.Containers[1].SecurityContext)
+}
+
+func TestSecurityProfileErrors(t *testing.T) {
+	profile := "es-apparmor"
+	template := &v1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{appArmorAnnotationKeyPrefix + "elasticsearch": "runtime/default"},
+		},
+		Spec: v1.PodSpec{Containers: []v1.Container{{Name: "elasticsearch"}}},
+	}
+
+	assert.Empty(t, securityProfileErrors(template, nil))
+	assert.Equal(t, []string{
+		"appArmorProfile.localhostProfile must only be set for type Localhost",
+		"annotation container.apparmor.security.beta.kubernetes.io/elasticsearch of the pod template conflicts with appArmorProfile and must be removed",
+		"seccompProfile.localhostProfile must be set for type Localhost",
+		"seLinuxOptions.level c123 must be an MLS/MCS level like s0:c123,c456",
+	}, securityProfileErrors(template, &zv1.ElasticsearchDataSetPodSecurity{
+		AppArmorProfile: &v1.AppArmorProfile{Type: v1.AppArmorProfileTypeRuntimeDefault, LocalhostProfile: &profile},
+		SeccompProfile:  &v1.SeccompProfile{Type: v1.SeccompProfileTypeLocalhost},
+		SELinuxOptions:  &v1.SELinuxOptions{Level: "c123"},
+	}))
+}
+
+func TestSecurityProfileRecommendations(t *testing.T) {
+	assert.Empty(t, securityProfileRecommendations(&zv1.ElasticsearchDataSetPodSecurity{
+		SeccompProfile: &v1.SeccompProfile{Type: v1.SeccompProfileTypeRuntimeDefault},
+	}))
+	assert.Len(t, securityProfileRecommendations(&zv1.ElasticsearchDataSetPodSecurity{
+		AppArmorProfile: &v1.AppArmorProfile{Type: v1.AppArmorProfileTypeUnconfined},
+		SeccompProfile:  &v1.SeccompProfile{Type: v1.SeccompProfileTypeUnconfined},
+		SELinuxOptions:  &v1.SELinuxOptions{Type: "spc_t"},
+	}), 4)
+}
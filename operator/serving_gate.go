@@ -0,0 +1,208 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// podServingConditionType is the readiness gate of pods controlled by the
+// operator. Pods are only added to the endpoints of Services while it's
+// true.
+const podServingConditionType v1.PodConditionType = "es-operator.zalando.org/serving"
+
+// ServingGate configures the readiness gate controlling whether the pods of
+// a resource receive client traffic.
+type ServingGate struct {
+	// DrainDelay is the time between removing a pod from the endpoints of
+	// Services and deleting it.
+	DrainDelay time.Duration
+	// SlowStart is the minimum time the containers of a (re)started pod
+	// are ready before it's added to the endpoints of Services.
+	SlowStart time.Duration
+	// Warmup warms the caches of a (re)started pod before it's added to
+	// the endpoints of Services. It returns an error if the pod isn't
+	// warm yet.
+	Warmup func(ctx context.Context, pod *v1.Pod) error
+}
+
+// slowStart returns true if (re)started pods are only added to the
+// endpoints of Services once they are warm.
+func (g *ServingGate) slowStart() bool {
+	return g != nil && (g.SlowStart > 0 || g.Warmup != nil)
+}
+
+// servingGate returns the serving gate configured by the traffic steering
+// of the EDS, or nil if it's not configured. Warmup queries are sent like
+// the requests of the ES client of the EDS, if any.
+func servingGate(config *zv1.ElasticsearchDataSetTrafficSteering, client *ESClient) *ServingGate {
+	if config == nil {
+		return nil
+	}
+	gate := &ServingGate{
+		DrainDelay: time.Duration(config.DrainDelaySeconds) * time.Second,
+	}
+	if slowStart := config.SlowStart; slowStart != nil {
+		gate.SlowStart = time.Duration(slowStart.DurationSeconds) * time.Second
+		if len(slowStart.WarmupQueries) > 0 {
+			queries := slowStart.WarmupQueries
+			gate.Warmup = func(ctx context.Context, pod *v1.Pod) error {
+				return runWarmupQueries(ctx, pod, queries, client)
+			}
+		}
+	}
+	return gate
+}
+
+// injectServingGate adds the serving readiness gate to the pod spec.
+func injectServingGate(spec *v1.PodSpec, gate *ServingGate) {
+	if gate == nil || hasServingGate(spec) {
+		return
+	}
+	spec.ReadinessGates = append(spec.ReadinessGates, v1.PodReadinessGate{ConditionType: podServingConditionType})
+}
+
+func hasServingGate(spec *v1.PodSpec) bool {
+	for _, gate := range spec.ReadinessGates {
+		if gate.ConditionType == podServingConditionType {
+			return true
+		}
+	}
+	return false
+}
+
+// podCondition returns the condition of the pod, or nil if it's not set.
+func podCondition(pod *v1.Pod, conditionType v1.PodConditionType) *v1.PodCondition {
+	for i := range pod.Status.Conditions {
+		if pod.Status.Conditions[i].Type == conditionType {
+			return &pod.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// podServingStatus returns the status of the serving condition of the pod.
+func podServingStatus(pod *v1.Pod) v1.ConditionStatus {
+	if condition := podCondition(pod, podServingConditionType); condition != nil {
+		return condition.Status
+	}
+	return v1.ConditionUnknown
+}
+
+// setPodServing sets the serving condition of the pod.
+func (o *Operator) setPodServing(ctx context.Context, pod *v1.Pod, status v1.ConditionStatus, reason, message string) error {
+	updated := pod.DeepCopy()
+	condition := v1.PodCondition{
+		Type:               podServingConditionType,
+		Status:             status,
+		LastTransitionTime: metav1.Now(),
+		Reason:             reason,
+		Message:            message,
+	}
+	found := false
+	for i := range updated.Status.Conditions {
+		if updated.Status.Conditions[i].Type == podServingConditionType {
+			updated.Status.Conditions[i] = condition
+			found = true
+		}
+	}
+	if !found {
+		updated.Status.Conditions = append(updated.Status.Conditions, condition)
+	}
+	_, err := o.kube.CoreV1().Pods(pod.Namespace).UpdateStatus(ctx, updated, metav1.UpdateOptions{})
+	return err
+}
+
+// updateServingConditions lets pods with the serving readiness gate receive
+// client traffic, unless they are draining or terminating. With slow start,
+// pods whose containers (re)started only receive traffic once they are
+// warm.
+func (o *Operator) updateServingConditions(ctx context.Context, sr StatefulResource, pods []*v1.Pod, now time.Time) {
+	gate := sr.ServingGate()
+	for _, pod := range pods {
+		if !hasServingGate(&pod.Spec) || pod.DeletionTimestamp != nil {
+			continue
+		}
+		if _, ok := pod.Annotations[operatorPodDrainingAnnotationKey]; ok {
+			continue
+		}
+
+		status, reason, message := servingCondition(ctx, gate, pod, now)
+		current := podCondition(pod, podServingConditionType)
+		if current != nil && current.Status == status && current.Reason == reason {
+			continue
+		}
+		err := o.setPodServing(ctx, pod, status, reason, message)
+		if err != nil {
+			o.logger.Warnf("Failed to update serving condition of pod %s/%s: %v", pod.Namespace, pod.Name, err)
+			continue
+		}
+		if status == v1.ConditionTrue && gate.slowStart() {
+			o.logger.Infof("Pod %s/%s is warm, adding it to Service endpoints", pod.Namespace, pod.Name)
+		}
+	}
+}
+
+// servingCondition returns the desired serving condition of the pod.
+func servingCondition(ctx context.Context, gate *ServingGate, pod *v1.Pod, now time.Time) (v1.ConditionStatus, string, string) {
+	if !gate.slowStart() {
+		return v1.ConditionTrue, "Serving", ""
+	}
+
+	ready := podCondition(pod, v1.ContainersReady)
+	if ready == nil || ready.Status != v1.ConditionTrue {
+		return v1.ConditionFalse, "WarmingUp", "Waiting for the containers to be ready"
+	}
+	serving := podCondition(pod, podServingConditionType)
+	if serving != nil && serving.Status == v1.ConditionTrue && !ready.LastTransitionTime.After(serving.LastTransitionTime.Time) {
+		return v1.ConditionTrue, "Serving", ""
+	}
+
+	if warm := ready.LastTransitionTime.Add(gate.SlowStart); now.Before(warm) {
+		return v1.ConditionFalse, "WarmingUp", fmt.Sprintf("Waiting until %s for the caches to warm up", warm.UTC().Format(time.RFC3339))
+	}
+	if gate.Warmup != nil {
+		if err := gate.Warmup(ctx, pod); err != nil {
+			return v1.ConditionFalse, "WarmingUp", fmt.Sprintf("Warmup queries failed: %v", err)
+		}
+	}
+	return v1.ConditionTrue, "Serving", ""
+}
+
+// steerTrafficAway removes the pods from the endpoints of Services and waits
+// for the drain delay, such that in-flight requests of clients complete
+// before the pods are deleted.
+func (o *Operator) steerTrafficAway(ctx context.Context, sr StatefulResource, pods []*v1.Pod) error {
+	gate := sr.ServingGate()
+	if gate == nil || gate.DrainDelay <= 0 {
+		return nil
+	}
+
+	steered := 0
+	for _, pod := range pods {
+		if !hasServingGate(&pod.Spec) || podServingStatus(pod) == v1.ConditionFalse {
+			continue
+		}
+		err := o.setPodServing(ctx, pod, v1.ConditionFalse, "Draining", "The pod is about to be deleted")
+		if err != nil {
+			return fmt.Errorf("failed to remove pod %s/%s from Service endpoints: %v", pod.Namespace, pod.Name, err)
+		}
+		steered++
+	}
+	if steered == 0 {
+		return nil
+	}
+
+	o.recorder.Event(sr.Self(), v1.EventTypeNormal, "SteeringTraffic", fmt.Sprintf(
+		"Removed %d Pods from Service endpoints, waiting %s for in-flight requests", steered, gate.DrainDelay))
+	select {
+	case <-time.After(gate.DrainDelay):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
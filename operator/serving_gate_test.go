@@ -0,0 +1,139 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	"github.com/zalando-incubator/es-operator/pkg/clientset"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+)
+
+type mockServingResource struct {
+	mockResource
+	gate *ServingGate
+}
+
+func (r *mockServingResource) ServingGate() *ServingGate { return r.gate }
+
+func TestInjectServingGate(t *testing.T) {
+	spec := &v1.PodSpec{}
+	injectServingGate(spec, nil)
+	assert.Empty(t, spec.ReadinessGates)
+
+	gate := servingGate(&zv1.ElasticsearchDataSetTrafficSteering{DrainDelaySeconds: 10}, nil)
+	require.Equal(t, 10*time.Second, gate.DrainDelay)
+	injectServingGate(spec, gate)
+	injectServingGate(spec, gate)
+	assert.Equal(t, []v1.PodReadinessGate{{ConditionType: podServingConditionType}}, spec.ReadinessGates)
+}
+
+func TestSteerTrafficAway(t *testing.T) {
+	gated := v1.PodSpec{ReadinessGates: []v1.PodReadinessGate{{ConditionType: podServingConditionType}}}
+	pods := []*v1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Name: "es-data-0", Namespace: "default"}, Spec: gated},
+		{ObjectMeta: metav1.ObjectMeta{Name: "es-data-1", Namespace: "default", Annotations: map[string]string{operatorPodDrainingAnnotationKey: "true"}}, Spec: gated},
+		{ObjectMeta: metav1.ObjectMeta{Name: "es-data-2", Namespace: "default"}},
+	}
+	kube := fake.NewSimpleClientset(pods[0], pods[1], pods[2])
+	recorder := record.NewFakeRecorder(10)
+	o := &Operator{
+		kube:     &clientset.Clientset{Interface: kube},
+		logger:   log.WithField("test", t.Name()),
+		recorder: recorder,
+	}
+	podStatus := func(name string) v1.ConditionStatus {
+		pod, err := kube.CoreV1().Pods("default").Get(context.Background(), name, metav1.GetOptions{})
+		require.NoError(t, err)
+		return podServingStatus(pod)
+	}
+
+	// pods receive traffic unless they are draining.
+	sr := &mockServingResource{}
+	o.updateServingConditions(context.Background(), sr, pods, time.Now())
+	assert.Equal(t, v1.ConditionTrue, podStatus("es-data-0"))
+	assert.Equal(t, v1.ConditionUnknown, podStatus("es-data-1"))
+	assert.Equal(t, v1.ConditionUnknown, podStatus("es-data-2"))
+
+	require.NoError(t, o.steerTrafficAway(context.Background(), sr, pods[:1]))
+	assert.Equal(t, v1.ConditionTrue, podStatus("es-data-0"))
+
+	sr.gate = &ServingGate{DrainDelay: time.Millisecond}
+	require.NoError(t, o.steerTrafficAway(context.Background(), sr, pods[:1]))
+	assert.Equal(t, v1.ConditionFalse, podStatus("es-data-0"))
+	assert.Contains(t, <-recorder.Events, "SteeringTraffic")
+}
+
+func TestServingCondition(t *testing.T) {
+	now := time.Now()
+	pod := func(readySince time.Time, serving *v1.PodCondition) *v1.Pod {
+		pod := &v1.Pod{
+			Status: v1.PodStatus{
+				PodIP: "10.2.10.1",
+				Conditions: []v1.PodCondition{
+					{Type: v1.ContainersReady, Status: v1.ConditionTrue, LastTransitionTime: metav1.NewTime(readySince)},
+				},
+			},
+		}
+		if serving != nil {
+			pod.Status.Conditions = append(pod.Status.Conditions, *serving)
+		}
+		return pod
+	}
+	warm := false
+	gate := &ServingGate{
+		SlowStart: time.Minute,
+		Warmup: func(ctx context.Context, pod *v1.Pod) error {
+			if !warm {
+				return fmt.Errorf("not warm")
+			}
+			return nil
+		},
+	}
+	status := func(gate *ServingGate, pod *v1.Pod) v1.ConditionStatus {
+		status, _, _ := servingCondition(context.Background(), gate, pod, now)
+		return status
+	}
+
+	// without slow start pods serve right away.
+	require.Equal(t, v1.ConditionTrue, status(nil, pod(now, nil)))
+
+	// the containers are ready for less than the slow start duration.
+	require.Equal(t, v1.ConditionFalse, status(gate, pod(now.Add(-30*time.Second), nil)))
+
+	// the warmup queries fail until the pod is warm.
+	require.Equal(t, v1.ConditionFalse, status(gate, pod(now.Add(-2*time.Minute), nil)))
+	warm = true
+	require.Equal(t, v1.ConditionTrue, status(gate, pod(now.Add(-2*time.Minute), nil)))
+
+	// serving pods keep serving, unless their containers restarted.
+	serving := &v1.PodCondition{Type: podServingConditionType, Status: v1.ConditionTrue, LastTransitionTime: metav1.NewTime(now.Add(-time.Minute))}
+	require.Equal(t, v1.ConditionTrue, status(gate, pod(now.Add(-2*time.Minute), serving)))
+	require.Equal(t, v1.ConditionFalse, status(gate, pod(now.Add(-30*time.Second), serving)))
+}
+
+func TestRunWarmupQueries(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("POST", "http://10.2.10.1:9200/logs-%2A/_search",
+		httpmock.NewStringResponder(200, `{"hits":{"hits":[]}}`))
+	httpmock.RegisterResponder("POST", "http://10.2.10.1:9200/users/_search",
+		httpmock.NewStringResponder(503, `{}`))
+
+	pod := &v1.Pod{Status: v1.PodStatus{PodIP: "10.2.10.1"}}
+	queries := []zv1.ElasticsearchDataSetWarmupQuery{{Index: "logs-*", Body: `{"size":0}`}}
+	require.NoError(t, runWarmupQueries(context.Background(), pod, queries, nil))
+
+	queries = append(queries, zv1.ElasticsearchDataSetWarmupQuery{Index: "users"})
+	require.Error(t, runWarmupQueries(context.Background(), pod, queries, nil))
+}
@@ -0,0 +1,67 @@
+package operator
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+)
+
+var (
+	shadowCurrentReplicas = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "es_operator",
+		Subsystem: "shadow",
+		Name:      "current_replicas",
+		Help:      "Replicas of an EDS in shadow scaling mode, as set by the scaling mechanism in charge.",
+	}, []string{"namespace", "eds"})
+	shadowDesiredReplicas = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "es_operator",
+		Subsystem: "shadow",
+		Name:      "desired_replicas",
+		Help:      "Replicas the operator would scale an EDS in shadow scaling mode to.",
+	}, []string{"namespace", "eds"})
+	shadowDesiredIndexReplicas = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "es_operator",
+		Subsystem: "shadow",
+		Name:      "desired_index_replicas",
+		Help:      "Index replicas the operator would set for an index of an EDS in shadow scaling mode.",
+	}, []string{"namespace", "eds", "index"})
+	shadowScalingDecisions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "es_operator",
+		Subsystem: "shadow",
+		Name:      "scaling_decisions_total",
+		Help:      "Scaling decisions of the operator for an EDS in shadow scaling mode by direction.",
+	}, []string{"namespace", "eds", "direction"})
+)
+
+func init() {
+	prometheus.MustRegister(shadowCurrentReplicas, shadowDesiredReplicas, shadowDesiredIndexReplicas, shadowScalingDecisions)
+}
+
+// shadowScaling computes the scaling operation of an EDS in shadow scaling
+// mode and exposes it as metrics instead of applying it.
+func (o *ElasticsearchOperator) shadowScaling(eds *zv1.ElasticsearchDataSet, as *AutoScaler, currentReplicas int32) error {
+	scalingOperation, err := as.GetScalingOperation()
+	if err != nil {
+		return err
+	}
+
+	desiredReplicas := currentReplicas
+	if scalingOperation.NodeReplicas != nil {
+		desiredReplicas = *scalingOperation.NodeReplicas
+	}
+	shadowCurrentReplicas.WithLabelValues(eds.Namespace, eds.Name).Set(float64(currentReplicas))
+	shadowDesiredReplicas.WithLabelValues(eds.Namespace, eds.Name).Set(float64(desiredReplicas))
+	for _, index := range scalingOperation.IndexReplicas {
+		shadowDesiredIndexReplicas.WithLabelValues(eds.Namespace, eds.Name, index.Index).Set(float64(index.Replicas))
+	}
+	shadowScalingDecisions.WithLabelValues(eds.Namespace, eds.Name, scalingOperation.ScalingDirection.String()).Inc()
+
+	if scalingOperation.ScalingDirection != NONE {
+		o.logger.Infof("Shadow scaling for EDS '%s/%s' would scale %s from %d to %d replicas. %s",
+			eds.Namespace, eds.Name, scalingOperation.ScalingDirection, currentReplicas, desiredReplicas, scalingOperation.Description)
+		o.decisions.Record("ShadowScaling", eds.Namespace, eds.Name, fmt.Sprintf("%s to %d replicas. %s",
+			scalingOperation.ScalingDirection, desiredReplicas, scalingOperation.Description))
+	}
+	return nil
+}
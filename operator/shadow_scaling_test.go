@@ -0,0 +1,51 @@
+package operator
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestShadowScaling(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_cat/indices",
+		httpmock.NewStringResponder(200, `[{"index":"logs","pri":"2","rep":"1"}]`))
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_cat/shards",
+		httpmock.NewStringResponder(200, `[{"index":"logs","ip":"10.0.0.1"}]`))
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_cat/nodes",
+		httpmock.NewStringResponder(200, `[{"ip":"10.0.0.1","dup":"10.0"}]`))
+
+	replicas := int32(4)
+	eds := &zv1.ElasticsearchDataSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "shadow", Namespace: "default"},
+		Spec: zv1.ElasticsearchDataSetSpec{
+			// set by the scaling mechanism in charge
+			Replicas: &replicas,
+			Scaling: &zv1.ElasticsearchDataSetScaling{
+				Enabled:     true,
+				Shadow:      true,
+				MinReplicas: 6,
+				MaxReplicas: 10,
+			},
+		},
+	}
+	require.Equal(t, int32(4), edsReplicas(eds))
+
+	esURL, _ := url.Parse("http://elasticsearch:9200")
+	es := &ESResource{ElasticsearchDataSet: eds}
+	as := NewAutoScaler(es, time.Minute, &ESClient{Endpoint: esURL})
+	o := &ElasticsearchOperator{logger: as.logger, decisions: newDecisionLog(defaultDecisionLogSize)}
+
+	require.NoError(t, o.shadowScaling(eds, as, replicas))
+	require.Equal(t, 4.0, testutil.ToFloat64(shadowCurrentReplicas.WithLabelValues("default", "shadow")))
+	require.Equal(t, 4.0, testutil.ToFloat64(shadowDesiredReplicas.WithLabelValues("default", "shadow")))
+	require.Equal(t, 1.0, testutil.ToFloat64(shadowScalingDecisions.WithLabelValues("default", "shadow", "NONE")))
+}
@@ -0,0 +1,227 @@
+package operator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	maxShardsPerNodeSetting = "cluster.max_shards_per_node"
+	// defaultMaxShardsPerNode is the default of cluster.max_shards_per_node.
+	defaultMaxShardsPerNode = 1000
+)
+
+// GetMaxShardsPerNode returns the effective cluster.max_shards_per_node
+// setting and its persistent value, if set.
+func (c *ESClient) GetMaxShardsPerNode() (int32, *int32, error) {
+	resp, err := c.httpClient().R().
+		Get(c.Endpoint.String() + "/_cluster/settings?include_defaults=true&flat_settings=true")
+	if err != nil {
+		return 0, nil, err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return 0, nil, fmt.Errorf("code status %d - %s", resp.StatusCode(), resp.Body())
+	}
+
+	var settings struct {
+		Persistent map[string]string `json:"persistent"`
+		Transient  map[string]string `json:"transient"`
+		Defaults   map[string]string `json:"defaults"`
+	}
+	err = json.Unmarshal(resp.Body(), &settings)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	parse := func(values map[string]string) (*int32, error) {
+		value, ok := values[maxShardsPerNodeSetting]
+		if !ok {
+			return nil, nil
+		}
+		limit, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %v", maxShardsPerNodeSetting, err)
+		}
+		result := int32(limit)
+		return &result, nil
+	}
+
+	persistent, err := parse(settings.Persistent)
+	if err != nil {
+		return 0, nil, err
+	}
+	limit := int32(defaultMaxShardsPerNode)
+	for _, values := range []map[string]string{settings.Defaults, settings.Persistent, settings.Transient} {
+		value, err := parse(values)
+		if err != nil {
+			return 0, nil, err
+		}
+		if value != nil {
+			limit = *value
+		}
+	}
+	return limit, persistent, nil
+}
+
+// SetMaxShardsPerNode sets the persistent cluster.max_shards_per_node
+// setting. It's reset to the default if the limit is nil.
+func (c *ESClient) SetMaxShardsPerNode(limit *int32) error {
+	body := map[string]map[string]*int32{
+		"persistent": {
+			maxShardsPerNodeSetting: limit,
+		},
+	}
+
+	resp, err := c.httpClient().R().
+		SetHeader("Content-Type", "application/json").
+		SetBody(body).
+		Put(c.Endpoint.String() + "/_cluster/settings")
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return fmt.Errorf("code status %d - %s", resp.StatusCode(), resp.Body())
+	}
+	return nil
+}
+
+// clusterShards returns the number of shard copies counted against the
+// cluster.max_shards_per_node setting.
+func clusterShards(health *ESHealth) int32 {
+	return int32(health.ActiveShards + health.InitializingShards + health.UnassignedShards)
+}
+
+// requiredShardsPerNode returns the shards per data node required to hold
+// the shards.
+func requiredShardsPerNode(shards, dataNodes int32) int32 {
+	if dataNodes <= 0 {
+		return 0
+	}
+	return (shards + dataNodes - 1) / dataNodes
+}
+
+// shardsAfterScaling returns the number of shard copies in the cluster after
+// the index replicas of the scaling operation are applied.
+func shardsAfterScaling(shards int32, managedIndices map[string]ESIndex, operation *ScalingOperation) int32 {
+	for _, index := range operation.IndexReplicas {
+		if current, ok := managedIndices[index.Index]; ok {
+			shards += current.Primaries * (index.Replicas - current.Replicas)
+		}
+	}
+	return shards
+}
+
+// reviewShardLimit checks that the shards of the cluster fit into the
+// cluster.max_shards_per_node setting after a scale-down. If they don't,
+// the scaling operation either asks for the setting to be raised, if
+// allowed, or is turned into a no-op.
+func (as *AutoScaler) reviewShardLimit(managedIndices map[string]ESIndex, operation *ScalingOperation) (*ScalingOperation, error) {
+	config := as.eds.Spec.Scaling.ClusterShardLimit
+	if config == nil || operation.ScalingDirection != DOWN || operation.NodeReplicas == nil || as.eds.Spec.Replicas == nil {
+		return operation, nil
+	}
+	removedNodes := *as.eds.Spec.Replicas - *operation.NodeReplicas
+	if removedNodes <= 0 {
+		return operation, nil
+	}
+
+	health, err := as.esClient.ClusterHealth()
+	if err != nil {
+		return nil, err
+	}
+	shards := shardsAfterScaling(clusterShards(health), managedIndices, operation)
+	dataNodes := int32(health.NumberOfDataNodes) - removedNodes
+	required := requiredShardsPerNode(shards, dataNodes)
+
+	limit, _, err := as.esClient.GetMaxShardsPerNode()
+	if err != nil {
+		return nil, err
+	}
+	if required <= limit {
+		return operation, nil
+	}
+	if required <= config.MaxShardsPerNode {
+		operation.RaiseShardLimit = required
+		return operation, nil
+	}
+
+	operation = noopScalingOperation(fmt.Sprintf("Not scaling down, %d shards on %d data nodes would exceed %s of %d.", shards, dataNodes, maxShardsPerNodeSetting, limit))
+	as.logger.Info(operation.Description)
+	return operation, nil
+}
+
+// adjustShardLimit raises the cluster.max_shards_per_node setting as asked
+// for by the scaling operation and restores the original value once the
+// shards of the cluster fit into it again. The raised setting is recorded
+// in the status of the EDS.
+func (o *ElasticsearchOperator) adjustShardLimit(ctx context.Context, eds *zv1.ElasticsearchDataSet, client *ESClient, operation *ScalingOperation) (*zv1.ElasticsearchDataSet, error) {
+	status := eds.Status.ClusterShardLimit
+	if operation.RaiseShardLimit == 0 && status == nil {
+		return eds, nil
+	}
+
+	limit, persistent, err := client.GetMaxShardsPerNode()
+	if err != nil {
+		return eds, err
+	}
+
+	var updated *zv1.ElasticsearchDataSetClusterShardLimitStatus
+	switch {
+	case operation.RaiseShardLimit > 0:
+		original := persistent
+		if status != nil {
+			original = status.Original
+		}
+		raised := operation.RaiseShardLimit
+		if raised > limit {
+			err = client.SetMaxShardsPerNode(&raised)
+			if err != nil {
+				return eds, fmt.Errorf("failed to raise %s: %v", maxShardsPerNodeSetting, err)
+			}
+			o.recorder.Event(eds, v1.EventTypeNormal, "RaisedShardLimit", fmt.Sprintf(
+				"Raised %s from %d to %d for scaling down", maxShardsPerNodeSetting, limit, raised))
+		} else {
+			raised = limit
+		}
+		updated = &zv1.ElasticsearchDataSetClusterShardLimitStatus{Original: original, Raised: raised}
+	case limit == status.Raised:
+		health, err := client.ClusterHealth()
+		if err != nil {
+			return eds, err
+		}
+		original := int32(defaultMaxShardsPerNode)
+		if status.Original != nil {
+			original = *status.Original
+		}
+		if requiredShardsPerNode(clusterShards(health), int32(health.NumberOfDataNodes)) > original {
+			return eds, nil
+		}
+		err = client.SetMaxShardsPerNode(status.Original)
+		if err != nil {
+			return eds, fmt.Errorf("failed to restore %s: %v", maxShardsPerNodeSetting, err)
+		}
+		o.recorder.Event(eds, v1.EventTypeNormal, "RestoredShardLimit", fmt.Sprintf(
+			"Restored %s to %d", maxShardsPerNodeSetting, original))
+	}
+	// the setting was changed by someone else if it's neither raised nor
+	// restored, so it's not managed anymore.
+
+	if updated != nil && status != nil && *updated == *status {
+		return eds, nil
+	}
+	replicas := eds.Spec.Replicas
+	eds.Status.ClusterShardLimit = updated
+	result, err := o.kube.ZalandoV1().ElasticsearchDataSets(eds.Namespace).UpdateStatus(ctx, eds, metav1.UpdateOptions{})
+	if err != nil {
+		return eds, err
+	}
+	result.Spec.Replicas = replicas
+	return result, nil
+}
@@ -0,0 +1,97 @@
+package operator
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestMaxShardsPerNode(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_cluster/settings",
+		httpmock.NewStringResponder(200, `{"persistent":{"cluster.max_shards_per_node":"1200"},"transient":{},"defaults":{"cluster.max_shards_per_node":"1000"}}`))
+	httpmock.RegisterResponder("PUT", "http://elasticsearch:9200/_cluster/settings",
+		httpmock.NewStringResponder(200, `{"acknowledged":true}`))
+
+	esURL, _ := url.Parse("http://elasticsearch:9200")
+	client := &ESClient{Endpoint: esURL}
+
+	limit, persistent, err := client.GetMaxShardsPerNode()
+	require.NoError(t, err)
+	require.Equal(t, int32(1200), limit)
+	require.Equal(t, int32(1200), *persistent)
+
+	require.NoError(t, client.SetMaxShardsPerNode(nil))
+}
+
+func TestShardsAfterScaling(t *testing.T) {
+	managedIndices := map[string]ESIndex{"logs": {Index: "logs", Primaries: 5, Replicas: 2}}
+	operation := &ScalingOperation{IndexReplicas: []ESIndex{{Index: "logs", Primaries: 5, Replicas: 1}}}
+	require.Equal(t, int32(95), shardsAfterScaling(100, managedIndices, operation))
+	require.Equal(t, int32(34), requiredShardsPerNode(100, 3))
+	require.Equal(t, int32(0), requiredShardsPerNode(100, 0))
+}
+
+func TestReviewShardLimit(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_cluster/health",
+		httpmock.NewStringResponder(200, `{"status":"green","number_of_data_nodes":4,"active_shards":3000,"initializing_shards":0,"unassigned_shards":0}`))
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_cluster/settings",
+		httpmock.NewStringResponder(200, `{"persistent":{},"transient":{},"defaults":{"cluster.max_shards_per_node":"1000"}}`))
+
+	esURL, _ := url.Parse("http://elasticsearch:9200")
+	replicas := int32(4)
+	config := &zv1.ElasticsearchDataSetClusterShardLimit{}
+	as := &AutoScaler{
+		logger: log.WithField("eds", "logs"),
+		eds: &zv1.ElasticsearchDataSet{Spec: zv1.ElasticsearchDataSetSpec{
+			Replicas: &replicas,
+			Scaling:  &zv1.ElasticsearchDataSetScaling{ClusterShardLimit: config},
+		}},
+		esClient: &ESClient{Endpoint: esURL},
+	}
+	scaleDown := func(nodes int32) *ScalingOperation {
+		return &ScalingOperation{ScalingDirection: DOWN, NodeReplicas: &nodes}
+	}
+
+	// 3000 shards fit on 3 nodes
+	operation, err := as.reviewShardLimit(nil, scaleDown(3))
+	require.NoError(t, err)
+	require.Equal(t, DOWN, operation.ScalingDirection)
+	require.Zero(t, operation.RaiseShardLimit)
+
+	// 3000 shards on 2 nodes are blocked
+	operation, err = as.reviewShardLimit(nil, scaleDown(2))
+	require.NoError(t, err)
+	require.Equal(t, NONE, operation.ScalingDirection)
+	require.Contains(t, operation.Description, "cluster.max_shards_per_node")
+
+	// unless the limit may be raised
+	config.MaxShardsPerNode = 1500
+	operation, err = as.reviewShardLimit(nil, scaleDown(2))
+	require.NoError(t, err)
+	require.Equal(t, DOWN, operation.ScalingDirection)
+	require.Equal(t, int32(1500), operation.RaiseShardLimit)
+
+	// the raised limit is kept while the shards don't fit into the original
+	eds := &zv1.ElasticsearchDataSet{Status: zv1.ElasticsearchDataSetStatus{
+		ClusterShardLimit: &zv1.ElasticsearchDataSetClusterShardLimitStatus{Raised: 1000},
+	}}
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_cluster/health",
+		httpmock.NewStringResponder(200, `{"status":"green","number_of_data_nodes":2,"active_shards":3000}`))
+	o := &ElasticsearchOperator{recorder: record.NewFakeRecorder(10)}
+	updated, err := o.adjustShardLimit(context.Background(), eds, as.esClient, &ScalingOperation{})
+	require.NoError(t, err)
+	require.Equal(t, eds, updated)
+	require.Equal(t, 0, httpmock.GetCallCountInfo()["PUT http://elasticsearch:9200/_cluster/settings"])
+}
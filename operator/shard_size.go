@@ -0,0 +1,128 @@
+package operator
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+// ESShardSize is the size of a primary shard.
+type ESShardSize struct {
+	Index string
+	Shard int32
+	Bytes int64
+}
+
+// _ESShardSize represents a shard copy from the response of _cat/shards
+// (only used internally)
+type _ESShardSize struct {
+	Index  string `json:"index"`
+	Shard  string `json:"shard"`
+	PriRep string `json:"prirep"`
+	Store  string `json:"store"`
+}
+
+// GetShardSizes returns the size of the primary shards in the cluster.
+// Unassigned primaries have no size and are left out.
+func (c *ESClient) GetShardSizes() ([]ESShardSize, error) {
+	resp, err := c.httpClient().R().
+		Get(c.Endpoint.String() + "/_cat/shards?h=index,shard,prirep,store&bytes=b&format=json")
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, fmt.Errorf("code status %d - %s", resp.StatusCode(), resp.Body())
+	}
+
+	var esShards []_ESShardSize
+	err = json.Unmarshal(resp.Body(), &esShards)
+	if err != nil {
+		return nil, err
+	}
+
+	shards := make([]ESShardSize, 0, len(esShards))
+	for _, shard := range esShards {
+		if shard.PriRep != "p" || shard.Store == "" {
+			continue
+		}
+		number, err := strconv.Atoi(shard.Shard)
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.ParseInt(shard.Store, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		shards = append(shards, ESShardSize{Index: shard.Index, Shard: int32(number), Bytes: size})
+	}
+	return shards, nil
+}
+
+// oversizedShards returns the shards of the managed indices exceeding the
+// maximum size, largest first.
+func oversizedShards(shards []ESShardSize, managedIndices map[string]ESIndex, maxBytes int64) []ESShardSize {
+	oversized := make([]ESShardSize, 0)
+	for _, shard := range shards {
+		if _, ok := managedIndices[shard.Index]; ok && shard.Bytes > maxBytes {
+			oversized = append(oversized, shard)
+		}
+	}
+	sort.Slice(oversized, func(i, j int) bool {
+		if oversized[i].Bytes != oversized[j].Bytes {
+			return oversized[i].Bytes > oversized[j].Bytes
+		}
+		if oversized[i].Index != oversized[j].Index {
+			return oversized[i].Index < oversized[j].Index
+		}
+		return oversized[i].Shard < oversized[j].Shard
+	})
+	return oversized
+}
+
+// reducedOversizedIndex returns the first index with oversized shards whose
+// replicas are reduced by the scaling operation, if any.
+func reducedOversizedIndex(managedIndices map[string]ESIndex, oversized []ESShardSize, operation *ScalingOperation) string {
+	indices := make(map[string]struct{}, len(oversized))
+	for _, shard := range oversized {
+		indices[shard.Index] = struct{}{}
+	}
+	for _, index := range operation.IndexReplicas {
+		if _, ok := indices[index.Index]; !ok {
+			continue
+		}
+		if current, ok := managedIndices[index.Index]; ok && index.Replicas < current.Replicas {
+			return index.Index
+		}
+	}
+	return ""
+}
+
+// reviewShardSize records the oversized shards in the scaling operation and
+// turns it into a no-op if it reduces the replicas of an index with
+// oversized shards and this is configured to be blocked.
+func (as *AutoScaler) reviewShardSize(managedIndices map[string]ESIndex, operation *ScalingOperation) (*ScalingOperation, error) {
+	config := as.eds.Spec.Scaling.ShardSize
+	if config == nil || config.MaxShardSize.IsZero() {
+		return operation, nil
+	}
+
+	shards, err := as.esClient.GetShardSizes()
+	if err != nil {
+		return nil, err
+	}
+	oversized := oversizedShards(shards, managedIndices, config.MaxShardSize.Value())
+	if len(oversized) == 0 {
+		return operation, nil
+	}
+
+	if config.BlockReplicaReduction && operation.ScalingDirection == DOWN {
+		if index := reducedOversizedIndex(managedIndices, oversized, operation); index != "" {
+			operation = noopScalingOperation(fmt.Sprintf("Not scaling down, index %s has shards larger than %s.", index, config.MaxShardSize.String()))
+			as.logger.Info(operation.Description)
+		}
+	}
+	operation.OversizedShards = oversized
+	return operation, nil
+}
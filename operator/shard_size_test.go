@@ -0,0 +1,85 @@
+package operator
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestOversizedShards(t *testing.T) {
+	managedIndices := map[string]ESIndex{
+		"logs":  {Index: "logs", Primaries: 2, Replicas: 2},
+		"users": {Index: "users", Primaries: 1, Replicas: 1},
+	}
+	shards := []ESShardSize{
+		{Index: "logs", Shard: 0, Bytes: 60},
+		{Index: "logs", Shard: 1, Bytes: 40},
+		{Index: "users", Shard: 0, Bytes: 80},
+		// not managed by the EDS
+		{Index: "other", Shard: 0, Bytes: 100},
+	}
+
+	oversized := oversizedShards(shards, managedIndices, 50)
+	require.Equal(t, []ESShardSize{
+		{Index: "users", Shard: 0, Bytes: 80},
+		{Index: "logs", Shard: 0, Bytes: 60},
+	}, oversized)
+
+	operation := &ScalingOperation{
+		ScalingDirection: DOWN,
+		IndexReplicas: []ESIndex{
+			{Index: "other", Primaries: 1, Replicas: 0},
+			{Index: "logs", Primaries: 2, Replicas: 2},
+		},
+	}
+	require.Empty(t, reducedOversizedIndex(managedIndices, oversized, operation))
+
+	operation.IndexReplicas = append(operation.IndexReplicas, ESIndex{Index: "users", Primaries: 1, Replicas: 0})
+	require.Equal(t, "users", reducedOversizedIndex(managedIndices, oversized, operation))
+}
+
+func TestReviewShardSize(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_cat/shards",
+		httpmock.NewStringResponder(200, `[{"index":"logs","shard":"0","prirep":"p","store":"2147483648"},{"index":"logs","shard":"0","prirep":"r","store":"2147483648"},{"index":"logs","shard":"1","prirep":"p","store":null}]`))
+
+	esURL, _ := url.Parse("http://elasticsearch:9200")
+	client := &ESClient{Endpoint: esURL}
+
+	shards, err := client.GetShardSizes()
+	require.NoError(t, err)
+	require.Equal(t, []ESShardSize{{Index: "logs", Shard: 0, Bytes: 2147483648}}, shards)
+
+	config := &zv1.ElasticsearchDataSetShardSizeGuardrail{MaxShardSize: resource.MustParse("1Gi")}
+	as := &AutoScaler{
+		logger:   log.WithField("eds", "logs"),
+		eds:      &zv1.ElasticsearchDataSet{Spec: zv1.ElasticsearchDataSetSpec{Scaling: &zv1.ElasticsearchDataSetScaling{ShardSize: config}}},
+		esClient: client,
+	}
+	managedIndices := map[string]ESIndex{"logs": {Index: "logs", Primaries: 2, Replicas: 1}}
+	scaleDown := func() *ScalingOperation {
+		return &ScalingOperation{
+			ScalingDirection: DOWN,
+			IndexReplicas:    []ESIndex{{Index: "logs", Primaries: 2, Replicas: 0}},
+		}
+	}
+
+	// only warn by default
+	operation, err := as.reviewShardSize(managedIndices, scaleDown())
+	require.NoError(t, err)
+	require.Equal(t, DOWN, operation.ScalingDirection)
+	require.Len(t, operation.OversizedShards, 1)
+
+	config.BlockReplicaReduction = true
+	operation, err = as.reviewShardSize(managedIndices, scaleDown())
+	require.NoError(t, err)
+	require.Equal(t, NONE, operation.ScalingDirection)
+	require.Len(t, operation.OversizedShards, 1)
+}
@@ -0,0 +1,110 @@
+package operator
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+)
+
+const useForPeerRecoverySetting = "use_for_peer_recovery"
+
+// snapshotRepository is a snapshot repository as returned by
+// _snapshot/<name>.
+type snapshotRepository struct {
+	Type     string            `json:"type"`
+	Settings map[string]string `json:"settings"`
+}
+
+// GetSnapshotRepository returns the snapshot repository with the name or nil
+// if it doesn't exist.
+func (c *ESClient) GetSnapshotRepository(name string) (*snapshotRepository, error) {
+	resp, err := c.httpClient().R().
+		Get(fmt.Sprintf("%s/_snapshot/%s", c.Endpoint.String(), name))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, fmt.Errorf("code status %d - %s", resp.StatusCode(), resp.Body())
+	}
+
+	var repositories map[string]snapshotRepository
+	err = json.Unmarshal(resp.Body(), &repositories)
+	if err != nil {
+		return nil, err
+	}
+	repository, ok := repositories[name]
+	if !ok {
+		return nil, nil
+	}
+	return &repository, nil
+}
+
+// PutSnapshotRepository registers or updates the snapshot repository.
+func (c *ESClient) PutSnapshotRepository(name string, repository *snapshotRepository) error {
+	resp, err := c.httpClient().R().
+		SetHeader("Content-Type", "application/json").
+		SetBody(repository).
+		Put(fmt.Sprintf("%s/_snapshot/%s", c.Endpoint.String(), name))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return fmt.Errorf("code status %d - %s", resp.StatusCode(), resp.Body())
+	}
+	return nil
+}
+
+// peerRecoveryRepository returns the repository with peer recoveries enabled
+// based on the existing repository, and whether it has to be updated.
+func peerRecoveryRepository(config *zv1.ElasticsearchDataSetSnapshotPeerRecovery, existing *snapshotRepository) (*snapshotRepository, bool, error) {
+	if existing == nil {
+		if config.Type == "" {
+			return nil, false, fmt.Errorf("snapshot repository %s doesn't exist and no type is configured to register it", config.Repository)
+		}
+		repository := &snapshotRepository{
+			Type:     config.Type,
+			Settings: map[string]string{useForPeerRecoverySetting: "true"},
+		}
+		for k, v := range config.Settings {
+			repository.Settings[k] = v
+		}
+		return repository, true, nil
+	}
+
+	if existing.Settings[useForPeerRecoverySetting] == "true" {
+		return existing, false, nil
+	}
+
+	repository := &snapshotRepository{
+		Type:     existing.Type,
+		Settings: map[string]string{useForPeerRecoverySetting: "true"},
+	}
+	for k, v := range existing.Settings {
+		if k != useForPeerRecoverySetting {
+			repository.Settings[k] = v
+		}
+	}
+	return repository, true, nil
+}
+
+// ensurePeerRecoveryRepository makes sure the configured snapshot repository
+// exists and is used for peer recoveries.
+func (c *ESClient) ensurePeerRecoveryRepository(config *zv1.ElasticsearchDataSetSnapshotPeerRecovery) error {
+	existing, err := c.GetSnapshotRepository(config.Repository)
+	if err != nil {
+		return err
+	}
+
+	repository, update, err := peerRecoveryRepository(config, existing)
+	if err != nil || !update {
+		return err
+	}
+
+	c.logger().Infof("Enabling peer recoveries from snapshot repository %s", config.Repository)
+	return c.PutSnapshotRepository(config.Repository, repository)
+}
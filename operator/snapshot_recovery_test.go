@@ -0,0 +1,80 @@
+package operator
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+)
+
+func TestPeerRecoveryRepository(t *testing.T) {
+	config := &zv1.ElasticsearchDataSetSnapshotPeerRecovery{
+		Enabled:    true,
+		Repository: "recovery",
+		Type:       "s3",
+		Settings:   map[string]string{"bucket": "es-recovery"},
+	}
+
+	// registered if missing.
+	repository, update, err := peerRecoveryRepository(config, nil)
+	require.NoError(t, err)
+	assert.True(t, update)
+	assert.Equal(t, &snapshotRepository{
+		Type:     "s3",
+		Settings: map[string]string{"bucket": "es-recovery", useForPeerRecoverySetting: "true"},
+	}, repository)
+
+	// existing repositories keep their settings.
+	existing := &snapshotRepository{Type: "gcs", Settings: map[string]string{"bucket": "backups", useForPeerRecoverySetting: "false"}}
+	repository, update, err = peerRecoveryRepository(config, existing)
+	require.NoError(t, err)
+	assert.True(t, update)
+	assert.Equal(t, &snapshotRepository{
+		Type:     "gcs",
+		Settings: map[string]string{"bucket": "backups", useForPeerRecoverySetting: "true"},
+	}, repository)
+
+	_, update, err = peerRecoveryRepository(config, repository)
+	require.NoError(t, err)
+	assert.False(t, update)
+
+	_, _, err = peerRecoveryRepository(&zv1.ElasticsearchDataSetSnapshotPeerRecovery{Repository: "recovery"}, nil)
+	assert.Error(t, err)
+}
+
+func TestEnsurePeerRecoveryRepository(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_snapshot/recovery",
+		httpmock.NewStringResponder(200, `{"recovery":{"type":"s3","settings":{"bucket":"es-recovery"}}}`))
+	var body snapshotRepository
+	httpmock.RegisterResponder("PUT", "http://elasticsearch:9200/_snapshot/recovery",
+		func(req *http.Request) (*http.Response, error) {
+			err := json.NewDecoder(req.Body).Decode(&body)
+			if err != nil {
+				return nil, err
+			}
+			return httpmock.NewStringResponse(200, `{"acknowledged":true}`), nil
+		})
+
+	esURL, _ := url.Parse("http://elasticsearch:9200")
+	client := &ESClient{Endpoint: esURL}
+	err := client.ensurePeerRecoveryRepository(&zv1.ElasticsearchDataSetSnapshotPeerRecovery{Enabled: true, Repository: "recovery"})
+	require.NoError(t, err)
+	assert.Equal(t, snapshotRepository{
+		Type:     "s3",
+		Settings: map[string]string{"bucket": "es-recovery", useForPeerRecoverySetting: "true"},
+	}, body)
+
+	// unknown repositories can't be registered without a type.
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_snapshot/missing",
+		httpmock.NewStringResponder(404, `{"error":"repository_missing_exception"}`))
+	err = client.ensurePeerRecoveryRepository(&zv1.ElasticsearchDataSetSnapshotPeerRecovery{Enabled: true, Repository: "missing"})
+	assert.Error(t, err)
+}
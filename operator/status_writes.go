@@ -0,0 +1,142 @@
+package operator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	zalandov1 "github.com/zalando-incubator/es-operator/pkg/client/clientset/versioned/typed/zalando.org/v1"
+	jsonpatch "gopkg.in/evanphx/json-patch.v4"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+)
+
+// scalingSamplesWriteInterval is the minimum time between two status writes
+// of an EDS which only update the sample counters of its scaling state.
+const scalingSamplesWriteInterval = 5 * time.Minute
+
+// patchEDSStatus writes the changes of the status of the EDS since old as a
+// merge patch of the status subresource. Unlike an update it only sends the
+// changed fields. The patch is conditional on the resource version of the
+// EDS, on a conflict the changes are applied to the latest status and
+// written again. Nothing is written if the status didn't change.
+func patchEDSStatus(ctx context.Context, client zalandov1.ZalandoV1Interface, eds *zv1.ElasticsearchDataSet, old *zv1.ElasticsearchDataSetStatus) (*zv1.ElasticsearchDataSet, error) {
+	from, err := json.Marshal(map[string]interface{}{"status": old})
+	if err != nil {
+		return nil, err
+	}
+	to, err := json.Marshal(map[string]interface{}{"status": &eds.Status})
+	if err != nil {
+		return nil, err
+	}
+	changes, err := jsonpatch.CreateMergePatch(from, to)
+	if err != nil {
+		return nil, err
+	}
+	if string(changes) == "{}" {
+		return eds, nil
+	}
+
+	patched, resourceVersion, data := eds, eds.ResourceVersion, changes
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		patch, err := jsonpatch.MergePatch(data, []byte(fmt.Sprintf(`{"metadata":{"resourceVersion":%q}}`, resourceVersion)))
+		if err != nil {
+			return err
+		}
+		patched, err = client.ElasticsearchDataSets(eds.Namespace).Patch(ctx, eds.Name, types.MergePatchType, patch, metav1.PatchOptions{}, "status")
+		if !apierrors.IsConflict(err) {
+			return err
+		}
+
+		latest, getErr := client.ElasticsearchDataSets(eds.Namespace).Get(ctx, eds.Name, metav1.GetOptions{})
+		if getErr != nil {
+			return getErr
+		}
+		data, getErr = rebaseStatusChanges(&latest.Status, changes)
+		if getErr != nil {
+			return getErr
+		}
+		patched, resourceVersion = latest, latest.ResourceVersion
+		if string(data) == "{}" {
+			// the changes were written concurrently.
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// set TypeMeta manually because of this bug:
+	// https://github.com/kubernetes/client-go/issues/308
+	patched.APIVersion = "zalando.org/v1"
+	patched.Kind = "ElasticsearchDataSet"
+	return patched, nil
+}
+
+// rebaseStatusChanges returns the merge patch which applies the changes to
+// the latest status.
+func rebaseStatusChanges(latest *zv1.ElasticsearchDataSetStatus, changes []byte) ([]byte, error) {
+	from, err := json.Marshal(map[string]interface{}{"status": latest})
+	if err != nil {
+		return nil, err
+	}
+	to, err := jsonpatch.MergePatch(from, changes)
+	if err != nil {
+		return nil, err
+	}
+	return jsonpatch.CreateMergePatch(from, to)
+}
+
+// scalingSamplesOnly returns true if the scaling states only differ in their
+// sample counters.
+func scalingSamplesOnly(old, state *zv1.ElasticsearchDataSetScalingState) bool {
+	if old == nil || state == nil {
+		return false
+	}
+	state = state.DeepCopy()
+	state.ScaleUpSamples = old.ScaleUpSamples
+	state.ScaleDownSamples = old.ScaleDownSamples
+	return equality.Semantic.DeepEqual(old, state)
+}
+
+// statusWriteLimiter limits the rate of status writes per EDS.
+type statusWriteLimiter struct {
+	sync.Mutex
+	interval time.Duration
+	written  map[types.UID]time.Time
+}
+
+// newStatusWriteLimiter returns a limiter allowing one write per EDS within
+// the interval.
+func newStatusWriteLimiter(interval time.Duration) *statusWriteLimiter {
+	return &statusWriteLimiter{interval: interval, written: make(map[types.UID]time.Time)}
+}
+
+// allow returns true if the status of the EDS wasn't written within the
+// interval before now.
+func (l *statusWriteLimiter) allow(uid types.UID, now time.Time) bool {
+	l.Lock()
+	defer l.Unlock()
+	return now.Sub(l.written[uid]) >= l.interval
+}
+
+// record records a write of the status of the EDS.
+func (l *statusWriteLimiter) record(uid types.UID, now time.Time) {
+	l.Lock()
+	defer l.Unlock()
+	l.written[uid] = now
+}
+
+// forget removes the writes of the EDS, e.g. once it's deleted.
+func (l *statusWriteLimiter) forget(uid types.UID) {
+	l.Lock()
+	defer l.Unlock()
+	delete(l.written, uid)
+}
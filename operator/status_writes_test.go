@@ -0,0 +1,131 @@
+package operator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	zfake "github.com/zalando-incubator/es-operator/pkg/client/clientset/versioned/fake"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestPatchEDSStatus(t *testing.T) {
+	eds := edsTestFixture(3)
+	eds.Namespace = "default"
+	eds.ResourceVersion = "1"
+	eds.Status.Replicas = 3
+	client := zfake.NewSimpleClientset(eds.DeepCopy())
+
+	// nothing is written if the status didn't change.
+	old := eds.Status.DeepCopy()
+	patched, err := patchEDSStatus(context.Background(), client.ZalandoV1(), eds, old)
+	require.NoError(t, err)
+	require.Equal(t, eds, patched)
+	require.Empty(t, client.Actions())
+
+	eds.Status.Replicas = 4
+	generation := int64(2)
+	eds.Status.ObservedGeneration = &generation
+	patched, err = patchEDSStatus(context.Background(), client.ZalandoV1(), eds, old)
+	require.NoError(t, err)
+	require.Len(t, client.Actions(), 1)
+	require.Equal(t, "status", client.Actions()[0].GetSubresource())
+	// only the changed fields are sent.
+	require.JSONEq(t, `{"metadata":{"resourceVersion":"1"},"status":{"observedGeneration":2,"replicas":4}}`, string(client.Actions()[0].(k8stesting.PatchAction).GetPatch()))
+	require.EqualValues(t, 4, patched.Status.Replicas)
+	require.EqualValues(t, 2, *patched.Status.ObservedGeneration)
+	require.Equal(t, "ElasticsearchDataSet", patched.Kind)
+
+	stored, err := client.ZalandoV1().ElasticsearchDataSets("default").Get(context.Background(), eds.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	require.EqualValues(t, 4, stored.Status.Replicas)
+
+	// removed fields are set to null.
+	old = patched.Status.DeepCopy()
+	patched.Status.ObservedGeneration = nil
+	_, err = patchEDSStatus(context.Background(), client.ZalandoV1(), patched, old)
+	require.NoError(t, err)
+	require.Len(t, client.Actions(), 3)
+	require.JSONEq(t, `{"metadata":{"resourceVersion":"1"},"status":{"observedGeneration":null}}`, string(client.Actions()[2].(k8stesting.PatchAction).GetPatch()))
+}
+
+func TestPatchEDSStatusConflict(t *testing.T) {
+	eds := edsTestFixture(3)
+	eds.Namespace = "default"
+	eds.ResourceVersion = "1"
+	eds.Status.Replicas = 3
+	old := eds.Status.DeepCopy()
+
+	// the status was written concurrently.
+	latest := eds.DeepCopy()
+	latest.ResourceVersion = "2"
+	latest.Status.Replicas = 4
+	latest.Status.LastScaleUpStarted = &metav1.Time{Time: time.Unix(1000, 0)}
+	client := zfake.NewSimpleClientset(latest)
+	conflicts := 0
+	client.PrependReactor("patch", "elasticsearchdatasets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		patch := map[string]interface{}{}
+		err := json.Unmarshal(action.(k8stesting.PatchAction).GetPatch(), &patch)
+		require.NoError(t, err)
+		if patch["metadata"].(map[string]interface{})["resourceVersion"] != "2" {
+			conflicts++
+			return true, nil, apierrors.NewConflict(zv1.Resource("elasticsearchdatasets"), eds.Name, errors.New("modified"))
+		}
+		return false, nil, nil
+	})
+
+	eds.Status.Replicas = 5
+	patched, err := patchEDSStatus(context.Background(), client.ZalandoV1(), eds, old)
+	require.NoError(t, err)
+	require.Equal(t, 1, conflicts)
+	require.Len(t, client.Actions(), 3)
+	require.JSONEq(t, `{"metadata":{"resourceVersion":"2"},"status":{"replicas":5}}`, string(client.Actions()[2].(k8stesting.PatchAction).GetPatch()))
+	// concurrent changes of other fields are kept.
+	require.EqualValues(t, 5, patched.Status.Replicas)
+	require.NotNil(t, patched.Status.LastScaleUpStarted)
+
+	// nothing is written if the changes were written concurrently.
+	client.ClearActions()
+	eds.ResourceVersion = "1"
+	eds.Status.Replicas = 5
+	patched, err = patchEDSStatus(context.Background(), client.ZalandoV1(), eds, old)
+	require.NoError(t, err)
+	require.Len(t, client.Actions(), 2)
+	require.EqualValues(t, 5, patched.Status.Replicas)
+}
+
+func TestScalingSamplesOnly(t *testing.T) {
+	old := &zv1.ElasticsearchDataSetScalingState{LastDirection: zv1.ScalingDirectionUp, ScaleUpSamples: 1}
+	require.False(t, scalingSamplesOnly(nil, old))
+
+	state := old.DeepCopy()
+	state.ScaleUpSamples = 2
+	state.ScaleDownSamples = 1
+	require.True(t, scalingSamplesOnly(old, state))
+
+	state.LastDirection = zv1.ScalingDirectionNone
+	require.False(t, scalingSamplesOnly(old, state))
+}
+
+func TestStatusWriteLimiter(t *testing.T) {
+	limiter := newStatusWriteLimiter(time.Minute)
+	uid := types.UID("eds-uid")
+	now := time.Now()
+	require.True(t, limiter.allow(uid, now))
+
+	limiter.record(uid, now)
+	require.False(t, limiter.allow(uid, now.Add(time.Second)))
+	require.True(t, limiter.allow("other-uid", now))
+	require.True(t, limiter.allow(uid, now.Add(time.Minute)))
+
+	limiter.forget(uid)
+	require.True(t, limiter.allow(uid, now.Add(time.Second)))
+}
@@ -0,0 +1,195 @@
+package operator
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+var (
+	// taskActions are the actions of the long-running tasks listed by the
+	// admin API.
+	taskActions = []string{
+		"internal:index/shard/recovery*",
+		"indices:data/write/reindex*",
+		"indices:data/write/update/byquery*",
+		"indices:data/write/delete/byquery*",
+		"indices:admin/forcemerge*",
+	}
+
+	// drainBlockingTaskActions are the actions of the tasks reported when
+	// they block a drain. Recoveries are left out as they move the shards
+	// off the drained node.
+	drainBlockingTaskActions = taskActions[1:]
+)
+
+// ESTask is a task running on an Elasticsearch node.
+type ESTask struct {
+	ID             string    `json:"id"`
+	Node           string    `json:"node"`
+	NodeIP         string    `json:"nodeIP"`
+	Action         string    `json:"action"`
+	Description    string    `json:"description,omitempty"`
+	StartTime      time.Time `json:"startTime"`
+	RunningSeconds int64     `json:"runningSeconds"`
+	Cancellable    bool      `json:"cancellable"`
+	ParentTaskID   string    `json:"parentTaskID,omitempty"`
+}
+
+// GetTasks returns the tasks with the actions, or all tasks if none are
+// given, longest running first.
+func (c *ESClient) GetTasks(actions ...string) ([]ESTask, error) {
+	query := url.Values{"detailed": []string{"true"}}
+	if len(actions) > 0 {
+		query.Set("actions", strings.Join(actions, ","))
+	}
+	resp, err := c.httpClient().R().
+		Get(fmt.Sprintf("%s/_tasks?%s", c.Endpoint.String(), query.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, fmt.Errorf("code status %d - %s", resp.StatusCode(), resp.Body())
+	}
+
+	var response struct {
+		Nodes map[string]struct {
+			Name             string `json:"name"`
+			Host             string `json:"host"`
+			TransportAddress string `json:"transport_address"`
+			Tasks            map[string]struct {
+				Action            string `json:"action"`
+				Description       string `json:"description"`
+				StartTimeInMillis int64  `json:"start_time_in_millis"`
+				RunningTimeInNs   int64  `json:"running_time_in_nanos"`
+				Cancellable       bool   `json:"cancellable"`
+				ParentTaskID      string `json:"parent_task_id"`
+			} `json:"tasks"`
+		} `json:"nodes"`
+	}
+	err = json.Unmarshal(resp.Body(), &response)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := make([]ESTask, 0)
+	for _, node := range response.Nodes {
+		ip := node.Host
+		if host, _, err := net.SplitHostPort(node.TransportAddress); err == nil {
+			ip = host
+		}
+		for id, task := range node.Tasks {
+			tasks = append(tasks, ESTask{
+				ID:             id,
+				Node:           node.Name,
+				NodeIP:         ip,
+				Action:         task.Action,
+				Description:    task.Description,
+				StartTime:      time.UnixMilli(task.StartTimeInMillis).UTC(),
+				RunningSeconds: int64(time.Duration(task.RunningTimeInNs) / time.Second),
+				Cancellable:    task.Cancellable,
+				ParentTaskID:   task.ParentTaskID,
+			})
+		}
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		if tasks[i].RunningSeconds != tasks[j].RunningSeconds {
+			return tasks[i].RunningSeconds > tasks[j].RunningSeconds
+		}
+		return tasks[i].ID < tasks[j].ID
+	})
+	return tasks, nil
+}
+
+// CancelTask cancels the task.
+func (c *ESClient) CancelTask(id string) error {
+	resp, err := c.httpClient().R().
+		Post(fmt.Sprintf("%s/_tasks/%s/_cancel", c.Endpoint.String(), url.PathEscape(id)))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return fmt.Errorf("code status %d - %s", resp.StatusCode(), resp.Body())
+	}
+	return nil
+}
+
+// checkDrainTasks reports the long-running tasks on the node of the pod in
+// events once the drain, started at the given time, took longer than the
+// task deadline. The tasks are cancelled if configured. Indices shrunk by
+// their lifecycle policy are reported too, as the shrink keeps their shards
+// on the node. Each task and index is only handled once.
+func (c *ESClient) checkDrainTasks(pod *v1.Pod, started, now time.Time, handled map[string]struct{}) error {
+	if c.DrainingConfig == nil || c.DrainingConfig.TaskDeadline <= 0 || now.Sub(started) < c.DrainingConfig.TaskDeadline {
+		return nil
+	}
+
+	tasks, err := c.GetTasks(drainBlockingTaskActions...)
+	if err != nil {
+		return err
+	}
+	for _, task := range tasks {
+		if _, ok := handled[task.ID]; ok || task.NodeIP != pod.Status.PodIP {
+			continue
+		}
+		handled[task.ID] = struct{}{}
+
+		if c.DrainingConfig.CancelTasks && task.Cancellable {
+			err := c.CancelTask(task.ID)
+			if err != nil {
+				return fmt.Errorf("failed to cancel task %s: %v", task.ID, err)
+			}
+			c.event(v1.EventTypeWarning, "CancelledTask", fmt.Sprintf(
+				"Cancelled task %s (%s) on Pod '%s/%s' running for %ds, which blocked the drain past its deadline",
+				task.ID, task.Action, pod.Namespace, pod.Name, task.RunningSeconds))
+			continue
+		}
+		c.event(v1.EventTypeWarning, "DrainBlockedByTask", fmt.Sprintf(
+			"Task %s (%s) on Pod '%s/%s' running for %ds blocks the drain past its deadline",
+			task.ID, task.Action, pod.Namespace, pod.Name, task.RunningSeconds))
+	}
+
+	return c.checkDrainShrinks(pod, handled)
+}
+
+// checkDrainShrinks reports the indices with shards on the node of the pod
+// which are shrunk by their lifecycle policy.
+func (c *ESClient) checkDrainShrinks(pod *v1.Pod, handled map[string]struct{}) error {
+	indices, err := c.GetShrinkingIndices()
+	if err != nil {
+		return fmt.Errorf("failed to get shrinking indices: %v", err)
+	}
+	if len(indices) == 0 {
+		return nil
+	}
+	shards, err := c.GetShards()
+	if err != nil {
+		return err
+	}
+
+	shrinking := make(map[string]struct{}, len(indices))
+	for _, index := range indices {
+		shrinking[index] = struct{}{}
+	}
+	for _, shard := range shards {
+		key := lifecycleShrinkAction + "/" + shard.Index
+		if _, ok := shrinking[shard.Index]; !ok || shard.IP != pod.Status.PodIP {
+			continue
+		}
+		if _, ok := handled[key]; ok {
+			continue
+		}
+		handled[key] = struct{}{}
+		c.event(v1.EventTypeWarning, "DrainBlockedByShrink", fmt.Sprintf(
+			"Index %s on Pod '%s/%s' is shrunk by its lifecycle policy, which keeps its shards on the node until the shrink completes",
+			shard.Index, pod.Namespace, pod.Name))
+	}
+	return nil
+}
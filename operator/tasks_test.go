@@ -0,0 +1,104 @@
+package operator
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const tasksResponse = `{"nodes":{
+	"node-1":{"name":"es-data-0","host":"10.0.0.1","transport_address":"10.0.0.1:9300","tasks":{
+		"node-1:10":{"action":"indices:data/write/reindex","description":"reindex from [a] to [b]","start_time_in_millis":1704196800000,"running_time_in_nanos":600000000000,"cancellable":true},
+		"node-1:11":{"action":"indices:admin/forcemerge","start_time_in_millis":1704197100000,"running_time_in_nanos":300000000000,"cancellable":false}
+	}},
+	"node-2":{"name":"es-data-1","host":"10.0.0.2","transport_address":"10.0.0.2:9300","tasks":{
+		"node-2:20":{"action":"indices:data/write/update/byquery","start_time_in_millis":1704196200000,"running_time_in_nanos":1200000000000,"cancellable":true,"parent_task_id":"node-1:5"}
+	}}
+}}`
+
+func TestGetTasks(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_tasks",
+		httpmock.NewStringResponder(200, tasksResponse))
+
+	url, _ := url.Parse("http://elasticsearch:9200")
+	client := &ESClient{Endpoint: url}
+	tasks, err := client.GetTasks(drainBlockingTaskActions...)
+	require.NoError(t, err)
+	require.Len(t, tasks, 3)
+	require.Equal(t, ESTask{
+		ID:             "node-2:20",
+		Node:           "es-data-1",
+		NodeIP:         "10.0.0.2",
+		Action:         "indices:data/write/update/byquery",
+		StartTime:      time.Date(2024, 1, 2, 11, 50, 0, 0, time.UTC),
+		RunningSeconds: 1200,
+		Cancellable:    true,
+		ParentTaskID:   "node-1:5",
+	}, tasks[0])
+	require.Equal(t, "node-1:10", tasks[1].ID)
+	require.Equal(t, "node-1:11", tasks[2].ID)
+
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_tasks",
+		httpmock.NewStringResponder(500, `{"error":"failed"}`))
+	_, err = client.GetTasks()
+	require.Error(t, err)
+}
+
+func TestCheckDrainTasks(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_tasks",
+		httpmock.NewStringResponder(200, tasksResponse))
+	httpmock.RegisterResponder("POST", "http://elasticsearch:9200/_tasks/node-1:10/_cancel",
+		httpmock.NewStringResponder(200, `{"nodes":{}}`))
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_all/_ilm/explain",
+		httpmock.NewStringResponder(200, `{"indices":{"logs-1":{"action":"shrink"},"logs-2":{"action":"shrink"}}}`))
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_cat/shards",
+		httpmock.NewStringResponder(200, `[{"index":"logs-1","ip":"10.0.0.1"},{"index":"logs-1","ip":"10.0.0.1"},{"index":"logs-2","ip":"10.0.0.2"}]`))
+
+	var reasons []string
+	url, _ := url.Parse("http://elasticsearch:9200")
+	client := &ESClient{
+		Endpoint:       url,
+		DrainingConfig: &DrainingConfig{TaskDeadline: time.Hour},
+		distribution:   distributionElasticsearch,
+		recordEvent: func(eventType, reason, message string) {
+			require.Equal(t, v1.EventTypeWarning, eventType)
+			reasons = append(reasons, reason)
+		},
+	}
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "es-data-0", Namespace: "default"},
+		Status:     v1.PodStatus{PodIP: "10.0.0.1"},
+	}
+	started := time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC)
+	handled := make(map[string]struct{})
+
+	// before the deadline the tasks aren't checked.
+	require.NoError(t, client.checkDrainTasks(pod, started, started.Add(time.Minute), handled))
+	require.Empty(t, reasons)
+	require.Zero(t, httpmock.GetTotalCallCount())
+
+	// past the deadline the tasks and shrinks on the node are reported
+	// once.
+	require.NoError(t, client.checkDrainTasks(pod, started, started.Add(2*time.Hour), handled))
+	require.Equal(t, []string{"DrainBlockedByTask", "DrainBlockedByTask", "DrainBlockedByShrink"}, reasons)
+	require.NoError(t, client.checkDrainTasks(pod, started, started.Add(3*time.Hour), handled))
+	require.Len(t, reasons, 3)
+
+	// cancellable tasks are cancelled if configured.
+	reasons = nil
+	client.DrainingConfig.CancelTasks = true
+	require.NoError(t, client.checkDrainTasks(pod, started, started.Add(2*time.Hour), make(map[string]struct{})))
+	require.Equal(t, []string{"CancelledTask", "DrainBlockedByTask", "DrainBlockedByShrink"}, reasons)
+	require.Equal(t, 1, httpmock.GetCallCountInfo()["POST http://elasticsearch:9200/_tasks/node-1:10/_cancel"])
+}
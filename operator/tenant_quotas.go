@@ -0,0 +1,284 @@
+package operator
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// indexUsage is the number of shard copies of an index and their size on
+// disk.
+type indexUsage struct {
+	shards int32
+	bytes  int64
+}
+
+// getIndexUsage returns the usage of all indices of the cluster. Unassigned
+// shard copies are counted, but have no size.
+func (c *ESClient) getIndexUsage() (map[string]indexUsage, error) {
+	resp, err := c.httpClient().R().
+		Get(c.Endpoint.String() + "/_cat/shards?h=index,store&bytes=b&format=json")
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, fmt.Errorf("code status %d - %s", resp.StatusCode(), resp.Body())
+	}
+
+	var esShards []struct {
+		Index string `json:"index"`
+		Store string `json:"store"`
+	}
+	err = json.Unmarshal(resp.Body(), &esShards)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := make(map[string]indexUsage)
+	for _, shard := range esShards {
+		index := usage[shard.Index]
+		index.shards++
+		if shard.Store != "" {
+			size, err := strconv.ParseInt(shard.Store, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid store size '%s' of index %s: %v", shard.Store, shard.Index, err)
+			}
+			index.bytes += size
+		}
+		usage[shard.Index] = index
+	}
+	return usage, nil
+}
+
+// tenantIndices returns the indices of each tenant by its name. An index
+// belongs to the tenant with the longest matching prefix.
+func tenantIndices(quotas []zv1.ElasticsearchClusterTenantQuota, usage map[string]indexUsage) map[string][]string {
+	indices := make(map[string][]string, len(quotas))
+	for index := range usage {
+		tenant, longest := "", 0
+		for _, quota := range quotas {
+			for _, prefix := range quota.IndexPrefixes {
+				if len(prefix) > longest && strings.HasPrefix(index, prefix) {
+					tenant, longest = quota.Name, len(prefix)
+				}
+			}
+		}
+		if tenant != "" {
+			indices[tenant] = append(indices[tenant], index)
+		}
+	}
+	for _, tenantIndices := range indices {
+		sort.Strings(tenantIndices)
+	}
+	return indices
+}
+
+// tenantViolations returns the limits of the quota the usage of the tenant
+// exceeds, e.g. '1200 shards > 1000'.
+func tenantViolations(quota zv1.ElasticsearchClusterTenantQuota, tenant zv1.ElasticsearchClusterTenantQuotaStatus) []string {
+	var violations []string
+	if quota.MaxShards != nil && tenant.Shards > *quota.MaxShards {
+		violations = append(violations, fmt.Sprintf("%d shards > %d", tenant.Shards, *quota.MaxShards))
+	}
+	if quota.MaxStorage != nil && tenant.Storage.Cmp(*quota.MaxStorage) > 0 {
+		violations = append(violations, fmt.Sprintf("%s storage > %s", tenant.Storage.String(), quota.MaxStorage.String()))
+	}
+	return violations
+}
+
+// blockTenantWrites blocks writes to the indices of the tenant which aren't
+// blocked yet. Indices already blocked by others are left out, so their
+// block isn't removed with the blocks of the operator.
+func (c *ESClient) blockTenantWrites(tenant *zv1.ElasticsearchClusterTenantQuotaStatus, indices []string) error {
+	blockedByOperator := make(map[string]bool, len(tenant.BlockedIndices))
+	for _, index := range tenant.BlockedIndices {
+		blockedByOperator[index] = true
+	}
+	candidates := make([]string, 0, len(indices))
+	blocked := make([]string, 0, len(indices))
+	for _, index := range indices {
+		if blockedByOperator[index] {
+			blocked = append(blocked, index)
+		} else {
+			candidates = append(candidates, index)
+		}
+	}
+	// blocked indices which were deleted are dropped.
+	tenant.BlockedIndices = blocked
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	alreadyBlocked, err := c.writeBlockedIndices(candidates)
+	if err != nil {
+		return err
+	}
+	unblocked := make([]string, 0, len(candidates))
+	for _, index := range candidates {
+		if !alreadyBlocked[index] {
+			unblocked = append(unblocked, index)
+		}
+	}
+	if len(unblocked) == 0 {
+		return nil
+	}
+	err = c.setWriteBlock(unblocked, true)
+	if err != nil {
+		return err
+	}
+	tenant.BlockedIndices = append(tenant.BlockedIndices, unblocked...)
+	sort.Strings(tenant.BlockedIndices)
+	return nil
+}
+
+// unblockTenantWrites removes the write blocks set by the operator from the
+// indices of the tenant which still exist.
+func (c *ESClient) unblockTenantWrites(tenant *zv1.ElasticsearchClusterTenantQuotaStatus, usage map[string]indexUsage) error {
+	existing := make([]string, 0, len(tenant.BlockedIndices))
+	for _, index := range tenant.BlockedIndices {
+		if _, ok := usage[index]; ok {
+			existing = append(existing, index)
+		}
+	}
+	if len(existing) > 0 {
+		err := c.setWriteBlock(existing, false)
+		if err != nil {
+			return err
+		}
+	}
+	tenant.BlockedIndices = nil
+	return nil
+}
+
+// enforceTenantQuotas compares the usage of the tenants of the cluster with
+// their quota, blocks writes to the indices of tenants exceeding it unless
+// they're only reported, and removes the blocks once they're back within
+// the quota or their quota is removed. The usage and the
+// TenantQuotasExceeded condition are set in the status.
+func (o *ElasticsearchOperator) enforceTenantQuotas(cluster *zv1.ElasticsearchCluster, dataSets map[string]*zv1.ElasticsearchDataSet, status *zv1.ElasticsearchClusterStatus) error {
+	quotas := cluster.Spec.TenantQuotas
+	if len(quotas) == 0 && len(status.TenantQuotas) == 0 {
+		meta.RemoveStatusCondition(&status.Conditions, zv1.ConditionTenantQuotasExceeded)
+		return nil
+	}
+
+	client := o.clusterClient(cluster, dataSets)
+	if client == nil {
+		return nil
+	}
+	usage, err := client.getIndexUsage()
+	if err != nil {
+		return fmt.Errorf("failed to get index usage: %v", err)
+	}
+
+	previous := make(map[string]zv1.ElasticsearchClusterTenantQuotaStatus, len(status.TenantQuotas))
+	for _, tenant := range status.TenantQuotas {
+		previous[tenant.Name] = tenant
+	}
+
+	// the blocks of tenants whose quota was removed are removed.
+	removed := make(map[string]bool, len(previous))
+	for name := range previous {
+		removed[name] = true
+	}
+	for _, quota := range quotas {
+		delete(removed, quota.Name)
+	}
+	for _, tenant := range status.TenantQuotas {
+		if !removed[tenant.Name] {
+			continue
+		}
+		err := client.unblockTenantWrites(&tenant, usage)
+		if err != nil {
+			return fmt.Errorf("failed to unblock writes of tenant %s: %v", tenant.Name, err)
+		}
+	}
+
+	indices := tenantIndices(quotas, usage)
+	tenants := make([]zv1.ElasticsearchClusterTenantQuotaStatus, 0, len(quotas))
+	var exceeded, blocked []string
+	var enforceErr error
+	for _, quota := range quotas {
+		tenant := zv1.ElasticsearchClusterTenantQuotaStatus{
+			Name:           quota.Name,
+			Indices:        int32(len(indices[quota.Name])),
+			BlockedIndices: previous[quota.Name].BlockedIndices,
+		}
+		var bytes int64
+		for _, index := range indices[quota.Name] {
+			tenant.Shards += usage[index].shards
+			bytes += usage[index].bytes
+		}
+		tenant.Storage = *resource.NewQuantity(bytes, resource.BinarySI)
+
+		violations := tenantViolations(quota, tenant)
+		tenant.Exceeded = len(violations) > 0
+		switch {
+		case tenant.Exceeded && !quota.ReportOnly:
+			err = client.blockTenantWrites(&tenant, indices[quota.Name])
+			if err != nil {
+				enforceErr = fmt.Errorf("failed to block writes of tenant %s: %v", quota.Name, err)
+			}
+		case len(tenant.BlockedIndices) > 0:
+			err = client.unblockTenantWrites(&tenant, usage)
+			if err != nil {
+				enforceErr = fmt.Errorf("failed to unblock writes of tenant %s: %v", quota.Name, err)
+			} else {
+				o.recorder.Event(cluster, v1.EventTypeNormal, "TenantWritesUnblocked",
+					fmt.Sprintf("Writes of tenant %s are unblocked.", quota.Name))
+			}
+		}
+
+		if tenant.Exceeded {
+			message := fmt.Sprintf("%s (%s)", quota.Name, strings.Join(violations, ", "))
+			exceeded = append(exceeded, message)
+			if len(tenant.BlockedIndices) > 0 {
+				blocked = append(blocked, quota.Name)
+			}
+			// only report tenants newly exceeding their quota, it's
+			// checked at every interval.
+			if !previous[quota.Name].Exceeded {
+				o.recorder.Event(cluster, v1.EventTypeWarning, "TenantQuotaExceeded",
+					fmt.Sprintf("Tenant %s exceeds its quota: %s.", quota.Name, strings.Join(violations, ", ")))
+			}
+		}
+		tenants = append(tenants, tenant)
+	}
+	status.TenantQuotas = tenants
+	if len(status.TenantQuotas) == 0 {
+		status.TenantQuotas = nil
+	}
+
+	condition := metav1.Condition{
+		Type:               zv1.ConditionTenantQuotasExceeded,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: cluster.Generation,
+	}
+	switch {
+	case len(quotas) == 0:
+		meta.RemoveStatusCondition(&status.Conditions, zv1.ConditionTenantQuotasExceeded)
+		return enforceErr
+	case len(exceeded) == 0:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = zv1.ReasonWithinQuota
+		condition.Message = "All tenants are within their quota."
+	case len(blocked) > 0:
+		condition.Reason = zv1.ReasonWritesBlocked
+		condition.Message = fmt.Sprintf("Tenants exceed their quota: %s. Writes of %s are blocked.",
+			strings.Join(exceeded, ", "), strings.Join(blocked, ", "))
+	default:
+		condition.Reason = zv1.ReasonQuotaExceeded
+		condition.Message = fmt.Sprintf("Tenants exceed their quota: %s.", strings.Join(exceeded, ", "))
+	}
+	meta.SetStatusCondition(&status.Conditions, condition)
+	return enforceErr
+}
@@ -0,0 +1,147 @@
+package operator
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestTenantIndices(t *testing.T) {
+	quotas := []zv1.ElasticsearchClusterTenantQuota{
+		{Name: "team-a", IndexPrefixes: []string{"team-a-", "a-"}},
+		{Name: "team-a-logs", IndexPrefixes: []string{"team-a-logs-"}},
+	}
+	usage := map[string]indexUsage{
+		"team-a-2":      {},
+		"team-a-1":      {},
+		"a-1":           {},
+		"team-a-logs-1": {},
+		"team-b-1":      {},
+	}
+	require.Equal(t, map[string][]string{
+		"team-a":      {"a-1", "team-a-1", "team-a-2"},
+		"team-a-logs": {"team-a-logs-1"},
+	}, tenantIndices(quotas, usage))
+}
+
+func TestTenantViolations(t *testing.T) {
+	maxShards := int32(10)
+	maxStorage := resource.MustParse("1Gi")
+	quota := zv1.ElasticsearchClusterTenantQuota{MaxShards: &maxShards, MaxStorage: &maxStorage}
+
+	require.Empty(t, tenantViolations(quota, zv1.ElasticsearchClusterTenantQuotaStatus{Shards: 10, Storage: resource.MustParse("1Gi")}))
+	require.Equal(t, []string{"11 shards > 10", "2Gi storage > 1Gi"},
+		tenantViolations(quota, zv1.ElasticsearchClusterTenantQuotaStatus{Shards: 11, Storage: resource.MustParse("2Gi")}))
+	require.Empty(t, tenantViolations(zv1.ElasticsearchClusterTenantQuota{}, zv1.ElasticsearchClusterTenantQuotaStatus{Shards: 11}))
+}
+
+func TestEnforceTenantQuotas(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	shards := `[
+		{"index":"team-a-1","store":"100"},{"index":"team-a-1","store":"100"},
+		{"index":"team-a-2","store":"100"},{"index":"team-a-2","store":null},
+		{"index":"team-a-logs-1","store":"2048"},
+		{"index":"team-b-1","store":"100"}
+	]`
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_cat/shards",
+		func(req *http.Request) (*http.Response, error) {
+			return httpmock.NewStringResponse(200, shards), nil
+		})
+	// team-a-2 was blocked by someone else.
+	httpmock.RegisterResponder("GET", "=~^http://elasticsearch:9200/[^/]+/_settings/index.blocks.write",
+		httpmock.NewStringResponder(200, `{"team-a-1":{"settings":{}},"team-a-2":{"settings":{"index.blocks.write":"true"}}}`))
+	var puts []string
+	httpmock.RegisterResponder("PUT", "=~^http://elasticsearch:9200/[^/]+/_settings",
+		func(req *http.Request) (*http.Response, error) {
+			var body map[string]interface{}
+			err := json.NewDecoder(req.Body).Decode(&body)
+			if err != nil {
+				return nil, err
+			}
+			puts = append(puts, req.URL.Path+" "+string(mustMarshal(t, body)))
+			return httpmock.NewStringResponse(200, `{"acknowledged":true}`), nil
+		})
+
+	endpoint, _ := url.Parse("http://elasticsearch:9200")
+	recorder := record.NewFakeRecorder(10)
+	o := &ElasticsearchOperator{elasticsearchEndpoint: endpoint, recorder: recorder}
+	maxShards := int32(3)
+	maxStorage := resource.MustParse("1Ki")
+	cluster := &zv1.ElasticsearchCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "es", Namespace: "default"},
+		Spec: zv1.ElasticsearchClusterSpec{
+			DataSets: []zv1.ElasticsearchClusterDataSet{{Name: "data"}},
+			TenantQuotas: []zv1.ElasticsearchClusterTenantQuota{
+				{Name: "team-a", IndexPrefixes: []string{"team-a-"}, MaxShards: &maxShards},
+				{Name: "team-a-logs", IndexPrefixes: []string{"team-a-logs-"}, MaxStorage: &maxStorage, ReportOnly: true},
+				{Name: "team-b", IndexPrefixes: []string{"team-b-"}, MaxShards: &maxShards},
+			},
+		},
+	}
+	dataSets := map[string]*zv1.ElasticsearchDataSet{"data": {ObjectMeta: metav1.ObjectMeta{Name: "es-data", Namespace: "default"}}}
+	status := &zv1.ElasticsearchClusterStatus{}
+
+	// writes of tenants exceeding their quota are blocked, unless they're
+	// only reported.
+	require.NoError(t, o.enforceTenantQuotas(cluster, dataSets, status))
+	require.Len(t, status.TenantQuotas, 3)
+	teamA := status.TenantQuotas[0]
+	require.EqualValues(t, 2, teamA.Indices)
+	require.EqualValues(t, 4, teamA.Shards)
+	require.Equal(t, int64(300), teamA.Storage.Value())
+	require.True(t, teamA.Exceeded)
+	require.Equal(t, []string{"team-a-1"}, teamA.BlockedIndices)
+	require.True(t, status.TenantQuotas[1].Exceeded)
+	require.Empty(t, status.TenantQuotas[1].BlockedIndices)
+	require.False(t, status.TenantQuotas[2].Exceeded)
+	require.Equal(t, []string{`/team-a-1/_settings {"index.blocks.write":true}`}, puts)
+	condition := meta.FindStatusCondition(status.Conditions, zv1.ConditionTenantQuotasExceeded)
+	require.Equal(t, metav1.ConditionTrue, condition.Status)
+	require.Equal(t, zv1.ReasonWritesBlocked, condition.Reason)
+	require.Equal(t, "Tenants exceed their quota: team-a (4 shards > 3), team-a-logs (2Ki storage > 1Ki). Writes of team-a are blocked.", condition.Message)
+	require.Contains(t, <-recorder.Events, "Tenant team-a exceeds its quota: 4 shards > 3.")
+	require.Contains(t, <-recorder.Events, "Tenant team-a-logs exceeds its quota: 2Ki storage > 1Ki.")
+
+	// violations are only reported once.
+	require.NoError(t, o.enforceTenantQuotas(cluster, dataSets, status))
+	require.Empty(t, recorder.Events)
+	require.Len(t, puts, 1)
+
+	// the blocks are removed once the tenant is back within its quota.
+	shards = `[{"index":"team-a-1","store":"100"},{"index":"team-a-1","store":"100"},{"index":"team-b-1","store":"100"}]`
+	require.NoError(t, o.enforceTenantQuotas(cluster, dataSets, status))
+	require.Nil(t, status.TenantQuotas[0].BlockedIndices)
+	require.False(t, status.TenantQuotas[0].Exceeded)
+	require.Equal(t, `/team-a-1/_settings {"index.blocks.write":null}`, puts[1])
+	require.Contains(t, <-recorder.Events, "TenantWritesUnblocked")
+	condition = meta.FindStatusCondition(status.Conditions, zv1.ConditionTenantQuotasExceeded)
+	require.Equal(t, metav1.ConditionFalse, condition.Status)
+	require.Equal(t, zv1.ReasonWithinQuota, condition.Reason)
+
+	// the blocks of removed quotas are removed.
+	shards = `[{"index":"team-a-1","store":"100"},{"index":"team-a-2","store":"100"},{"index":"team-a-3","store":"100"},{"index":"team-a-4","store":"100"}]`
+	require.NoError(t, o.enforceTenantQuotas(cluster, dataSets, status))
+	require.Equal(t, []string{"team-a-1", "team-a-3", "team-a-4"}, status.TenantQuotas[0].BlockedIndices)
+	cluster.Spec.TenantQuotas = nil
+	require.NoError(t, o.enforceTenantQuotas(cluster, dataSets, status))
+	require.Equal(t, `/team-a-1,team-a-3,team-a-4/_settings {"index.blocks.write":null}`, puts[len(puts)-1])
+	require.Nil(t, status.TenantQuotas)
+	require.Nil(t, meta.FindStatusCondition(status.Conditions, zv1.ConditionTenantQuotasExceeded))
+}
+
+func mustMarshal(t *testing.T, v interface{}) []byte {
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+	return data
+}
@@ -0,0 +1,57 @@
+package operator
+
+import (
+	"context"
+
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	v1 "k8s.io/api/core/v1"
+)
+
+const bytesPerGiB = 1024 * 1024 * 1024
+
+// terminationGracePeriodSeconds computes the termination grace period for a
+// node holding the number of shards and bytes of data, bounded by the
+// configured minimum and maximum.
+func terminationGracePeriodSeconds(config *zv1.ElasticsearchDataSetTerminationGracePeriod, shards int, bytes int64) int64 {
+	grace := config.MinSeconds +
+		int64(shards)*config.SecondsPerShard +
+		(bytes*config.SecondsPerGiB+bytesPerGiB-1)/bytesPerGiB
+
+	if config.MaxSeconds > 0 && grace > config.MaxSeconds {
+		grace = config.MaxSeconds
+	}
+	if grace < config.MinSeconds {
+		grace = config.MinSeconds
+	}
+	return grace
+}
+
+// TerminationGracePeriod returns the termination grace period for deleting
+// the pod. If configured, it's sized based on the data the node holds, but
+// never shorter than the grace period of the pod template. Otherwise the
+// grace period of the pod is used.
+func (r *EDSResource) TerminationGracePeriod(_ context.Context, pod *v1.Pod) *int64 {
+	config := r.eds.Spec.TerminationGracePeriod
+	if config == nil || r.esClient == nil || pod.Status.PodIP == "" {
+		return pod.Spec.TerminationGracePeriodSeconds
+	}
+
+	var grace int64
+	shards, bytes, err := r.esClient.GetNodeShardStats(pod.Status.PodIP)
+	switch {
+	case err != nil && config.MaxSeconds <= 0:
+		r.esClient.logger().Warnf("Failed to get shards of Pod %s/%s: %v", pod.Namespace, pod.Name, err)
+		return pod.Spec.TerminationGracePeriodSeconds
+	case err != nil:
+		r.esClient.logger().Warnf("Failed to get shards of Pod %s/%s: %v", pod.Namespace, pod.Name, err)
+		// without knowing the data on the node, give it the maximum.
+		grace = config.MaxSeconds
+	default:
+		grace = terminationGracePeriodSeconds(config, shards, bytes)
+	}
+
+	if template := r.eds.Spec.Template.Spec.TerminationGracePeriodSeconds; template != nil && grace < *template {
+		grace = *template
+	}
+	return &grace
+}
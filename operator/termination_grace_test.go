@@ -0,0 +1,81 @@
+package operator
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestTerminationGracePeriodSeconds(t *testing.T) {
+	config := &zv1.ElasticsearchDataSetTerminationGracePeriod{
+		MinSeconds:      30,
+		MaxSeconds:      600,
+		SecondsPerShard: 2,
+		SecondsPerGiB:   10,
+	}
+
+	for _, tc := range []struct {
+		msg      string
+		shards   int
+		bytes    int64
+		expected int64
+	}{
+		{msg: "empty node", expected: 30},
+		{msg: "shards and data", shards: 10, bytes: 5 * bytesPerGiB, expected: 30 + 20 + 50},
+		{msg: "partial GiB is rounded up", shards: 1, bytes: bytesPerGiB / 2, expected: 30 + 2 + 5},
+		{msg: "bounded by max", shards: 100, bytes: 100 * bytesPerGiB, expected: 600},
+	} {
+		t.Run(tc.msg, func(t *testing.T) {
+			assert.Equal(t, tc.expected, terminationGracePeriodSeconds(config, tc.shards, tc.bytes))
+		})
+	}
+}
+
+func TestEDSResourceTerminationGracePeriod(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_cat/shards",
+		httpmock.NewStringResponder(200, `[{"ip":"1.2.3.4","store":"2147483648"},{"ip":"1.2.3.4","store":""},{"ip":"1.2.3.5","store":"1024"}]`))
+
+	esURL, _ := url.Parse("http://elasticsearch:9200")
+	podGrace := int64(30)
+	pod := &v1.Pod{
+		Spec:   v1.PodSpec{TerminationGracePeriodSeconds: &podGrace},
+		Status: v1.PodStatus{PodIP: "1.2.3.4"},
+	}
+	r := &EDSResource{
+		eds:      &zv1.ElasticsearchDataSet{},
+		esClient: &ESClient{Endpoint: esURL},
+	}
+
+	// the grace period of the pod is used by default.
+	assert.Equal(t, &podGrace, r.TerminationGracePeriod(context.Background(), pod))
+
+	r.eds.Spec.TerminationGracePeriod = &zv1.ElasticsearchDataSetTerminationGracePeriod{
+		MinSeconds:      60,
+		MaxSeconds:      600,
+		SecondsPerShard: 5,
+		SecondsPerGiB:   30,
+	}
+	grace := r.TerminationGracePeriod(context.Background(), pod)
+	require.NotNil(t, grace)
+	assert.Equal(t, int64(60+2*5+2*30), *grace)
+
+	// the grace period of the pod template is the lower bound.
+	templateGrace := int64(300)
+	r.eds.Spec.Template.Spec.TerminationGracePeriodSeconds = &templateGrace
+	assert.Equal(t, &templateGrace, r.TerminationGracePeriod(context.Background(), pod))
+
+	// also when the maximum is used as the data on the node is unknown.
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_cat/shards",
+		httpmock.NewStringResponder(500, `{}`))
+	r.eds.Spec.TerminationGracePeriod.MaxSeconds = 120
+	assert.Equal(t, &templateGrace, r.TerminationGracePeriod(context.Background(), pod))
+}
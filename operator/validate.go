@@ -0,0 +1,72 @@
+package operator
+
+import (
+	"errors"
+	"fmt"
+
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ValidateElasticsearchDataSet validates the EDS like the operator does
+// before managing it. All problems found are returned.
+func ValidateElasticsearchDataSet(eds *zv1.ElasticsearchDataSet) error {
+	var errs []error
+	if eds.Name == "" {
+		errs = append(errs, fmt.Errorf("metadata.name must be set"))
+	}
+	if len(eds.Spec.Template.Spec.Containers) == 0 {
+		errs = append(errs, fmt.Errorf("spec.template.spec.containers must not be empty"))
+	} else if eds.Spec.Template.Spec.Containers[0].Image == "" {
+		errs = append(errs, fmt.Errorf("spec.template.spec.containers[0].image of the Elasticsearch container must be set"))
+	}
+	errs = append(errs, validateVolumeClaimTemplates(eds)...)
+	if expansion := eds.Spec.VolumeExpansion; expansion != nil && expansion.Enabled {
+		if expansion.MaxSize == nil {
+			errs = append(errs, fmt.Errorf("spec.volumeExpansion.maxSize must be set"))
+		}
+		if volumeExpansionTemplate(eds) == nil {
+			if expansion.VolumeClaimTemplate == "" {
+				errs = append(errs, fmt.Errorf("spec.volumeExpansion requires spec.volumeClaimTemplates"))
+			} else {
+				errs = append(errs, fmt.Errorf("spec.volumeExpansion.volumeClaimTemplate %s is not a volume claim template", expansion.VolumeClaimTemplate))
+			}
+		}
+	}
+	if retention := eds.Spec.VolumeClaimRetention; retention != nil && retention.RetentionDays != nil && retention.WhenScaled != "Retain" {
+		errs = append(errs, fmt.Errorf("spec.volumeClaimRetention.retentionDays requires whenScaled Retain"))
+	}
+	if err := checkSchemaVersion(eds); err != nil {
+		errs = append(errs, err)
+	}
+	if err := validateScalingSettings(eds.Spec.Scaling); err != nil {
+		errs = append(errs, fmt.Errorf("spec.scaling: %v", err))
+	}
+	if isMasterRole(eds) && eds.Spec.Scaling != nil && eds.Spec.Scaling.Enabled {
+		errs = append(errs, fmt.Errorf("spec.scaling.enabled is not supported for the master role"))
+	}
+	if arch, ok := eds.Spec.Template.Spec.NodeSelector[v1.LabelArchStable]; ok && eds.Spec.Architecture != "" && arch != string(eds.Spec.Architecture) {
+		errs = append(errs, fmt.Errorf("spec.template.spec.nodeSelector selects architecture %s instead of %s", arch, eds.Spec.Architecture))
+	}
+	return errors.Join(errs...)
+}
+
+// RenderStatefulSet validates the EDS and returns the StatefulSet the
+// operator would create for it. Image rewrite rules are applied like by the
+// operator, image digests are not pinned.
+func RenderStatefulSet(eds *zv1.ElasticsearchDataSet, imageRewriteRules map[string]string) (*appsv1.StatefulSet, error) {
+	err := ValidateElasticsearchDataSet(eds)
+	if err != nil {
+		return nil, err
+	}
+
+	eds = eds.DeepCopy()
+	eds.APIVersion = "zalando.org/v1"
+	eds.Kind = "ElasticsearchDataSet"
+
+	sts := renderStatefulSet(&EDSResource{eds: eds}, nil, ImageRewriteRules(imageRewriteRules))
+	sts.TypeMeta = metav1.TypeMeta{APIVersion: "apps/v1", Kind: "StatefulSet"}
+	return sts, nil
+}
@@ -0,0 +1,182 @@
+package operator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// volumeClaimDelete deletes the claims of pods removed by scale-downs.
+	volumeClaimDelete = "Delete"
+
+	// orphanedAtAnnotationKey is the time a retained volume claim was
+	// first found without a pod.
+	orphanedAtAnnotationKey = "es-operator.zalando.org/orphaned-at"
+
+	// minOrphanedVolumeClaimAge protects claims created for a scale-up,
+	// e.g. from a volume snapshot, which the StatefulSet doesn't use yet.
+	minOrphanedVolumeClaimAge = 10 * time.Minute
+)
+
+// volumeClaimOrdinal returns the ordinal of the pod of the StatefulSet the
+// claim was created for, or false if it doesn't belong to the StatefulSet.
+func volumeClaimOrdinal(sts *appsv1.StatefulSet, claim *v1.PersistentVolumeClaim) (int32, bool) {
+	for _, template := range sts.Spec.VolumeClaimTemplates {
+		ordinal, ok := strings.CutPrefix(claim.Name, template.Name+"-"+sts.Name+"-")
+		if !ok {
+			continue
+		}
+		number, err := strconv.ParseInt(ordinal, 10, 32)
+		if err != nil || number < 0 {
+			continue
+		}
+		return int32(number), true
+	}
+	return 0, false
+}
+
+// runVolumeClaimRetention periodically applies the volume claim retention of
+// the EDS to the claims of pods removed by scale-downs.
+func (o *ElasticsearchOperator) runVolumeClaimRetention(ctx context.Context) {
+	nextCheck := time.Now().Add(-o.interval)
+
+	for {
+		o.logger.Debug("Checking volume claim retention")
+		select {
+		case <-time.After(time.Until(nextCheck)):
+			nextCheck = time.Now().Add(o.interval)
+
+			resources, err := o.collectResources(ctx)
+			if err != nil {
+				o.logger.Error(err)
+				continue
+			}
+
+			for _, es := range resources {
+				err := o.retainVolumeClaims(ctx, es, time.Now())
+				if err != nil {
+					o.logger.Errorf("Failed to apply volume claim retention of EDS %s/%s: %v", es.ElasticsearchDataSet.Namespace, es.ElasticsearchDataSet.Name, err)
+				}
+			}
+		case <-ctx.Done():
+			o.logger.Info("Terminating volume claim retention loop.")
+			return
+		}
+	}
+}
+
+// retainVolumeClaims deletes or marks the volume claims of the StatefulSet
+// of the EDS which belong to ordinals above the current and desired
+// replicas. Retained claims which are used again are unmarked.
+func (o *ElasticsearchOperator) retainVolumeClaims(ctx context.Context, es *ESResource, now time.Time) error {
+	eds, sts := es.ElasticsearchDataSet, es.StatefulSet
+	config := eds.Spec.VolumeClaimRetention
+	if config == nil || sts == nil || sts.Spec.Selector == nil || len(sts.Spec.VolumeClaimTemplates) == 0 {
+		return nil
+	}
+
+	// claims of ordinals below any of the replicas are in use or about to
+	// be used.
+	replicas := sts.Status.Replicas
+	if sts.Spec.Replicas != nil && *sts.Spec.Replicas > replicas {
+		replicas = *sts.Spec.Replicas
+	}
+	if desired := es.Replicas(); desired > replicas {
+		replicas = desired
+	}
+	pods := make(map[string]struct{}, len(es.Pods))
+	for _, pod := range es.Pods {
+		pods[pod.Name] = struct{}{}
+	}
+
+	claims, err := o.kube.CoreV1().PersistentVolumeClaims(sts.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: labels.Set(sts.Spec.Selector.MatchLabels).String(),
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, claim := range claims.Items {
+		ordinal, ok := volumeClaimOrdinal(sts, &claim)
+		if !ok || claim.DeletionTimestamp != nil {
+			continue
+		}
+		_, hasPod := pods[fmt.Sprintf("%s-%d", sts.Name, ordinal)]
+		_, marked := claim.Annotations[orphanedAtAnnotationKey]
+		if ordinal < replicas || hasPod {
+			if marked {
+				err := o.markVolumeClaimOrphaned(ctx, &claim, nil)
+				if err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if now.Sub(claim.CreationTimestamp.Time) < minOrphanedVolumeClaimAge {
+			continue
+		}
+
+		reason := ""
+		switch {
+		case config.WhenScaled == volumeClaimDelete:
+			reason = "its pod was removed by a scale-down"
+		case config.RetentionDays == nil:
+			continue
+		case !marked:
+			err := o.markVolumeClaimOrphaned(ctx, &claim, &now)
+			if err != nil {
+				return err
+			}
+			continue
+		default:
+			orphanedAt, err := time.Parse(time.RFC3339, claim.Annotations[orphanedAtAnnotationKey])
+			retention := time.Duration(*config.RetentionDays) * 24 * time.Hour
+			if err == nil && now.Sub(orphanedAt) < retention {
+				continue
+			}
+			reason = fmt.Sprintf("it wasn't used for %d days", *config.RetentionDays)
+		}
+
+		err := o.kube.CoreV1().PersistentVolumeClaims(claim.Namespace).Delete(ctx, claim.Name, metav1.DeleteOptions{
+			Preconditions: &metav1.Preconditions{UID: &claim.UID},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to delete PersistentVolumeClaim %s/%s: %v", claim.Namespace, claim.Name, err)
+		}
+		o.recorder.Event(eds, v1.EventTypeNormal, "DeletedVolumeClaim",
+			fmt.Sprintf("Deleted PersistentVolumeClaim '%s/%s' as %s", claim.Namespace, claim.Name, reason))
+	}
+	return nil
+}
+
+// markVolumeClaimOrphaned sets the orphaned-at annotation of the claim to
+// the time, or removes it if the time is nil.
+func (o *ElasticsearchOperator) markVolumeClaimOrphaned(ctx context.Context, claim *v1.PersistentVolumeClaim, orphanedAt *time.Time) error {
+	var value interface{}
+	if orphanedAt != nil {
+		value = orphanedAt.UTC().Format(time.RFC3339)
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{orphanedAtAnnotationKey: value},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = o.kube.CoreV1().PersistentVolumeClaims(claim.Namespace).Patch(ctx, claim.Name, types.MergePatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to annotate PersistentVolumeClaim %s/%s: %v", claim.Namespace, claim.Name, err)
+	}
+	return nil
+}
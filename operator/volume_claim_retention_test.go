@@ -0,0 +1,122 @@
+package operator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	"github.com/zalando-incubator/es-operator/pkg/clientset"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestVolumeClaimOrdinal(t *testing.T) {
+	sts := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "es-data"},
+		Spec: appsv1.StatefulSetSpec{VolumeClaimTemplates: []v1.PersistentVolumeClaim{
+			{ObjectMeta: metav1.ObjectMeta{Name: "data"}},
+		}},
+	}
+	for name, expected := range map[string]int32{"data-es-data-0": 0, "data-es-data-12": 12, "data-es-data-x": -1, "data-es-data2-0": -1, "logs-es-data-0": -1} {
+		ordinal, ok := volumeClaimOrdinal(sts, &v1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: name}})
+		require.Equal(t, expected >= 0, ok, name)
+		if ok {
+			require.Equal(t, expected, ordinal, name)
+		}
+	}
+}
+
+func TestRetainVolumeClaims(t *testing.T) {
+	now := time.Now()
+	selector := map[string]string{"application": "es-data"}
+	claim := func(name string, created time.Time, annotations map[string]string) *v1.PersistentVolumeClaim {
+		return &v1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         "default",
+			UID:               types.UID(name),
+			Labels:            selector,
+			Annotations:       annotations,
+			CreationTimestamp: metav1.NewTime(created),
+		}}
+	}
+	kube := fake.NewSimpleClientset(
+		claim("data-es-data-0", now.Add(-time.Hour), map[string]string{orphanedAtAnnotationKey: now.Format(time.RFC3339)}),
+		claim("data-es-data-2", now.Add(-time.Hour), nil),
+		claim("data-es-data-3", now, nil),
+	)
+	recorder := record.NewFakeRecorder(10)
+	o := &ElasticsearchOperator{
+		kube:     &clientset.Clientset{Interface: kube},
+		recorder: recorder,
+	}
+
+	replicas := int32(2)
+	es := &ESResource{
+		ElasticsearchDataSet: &zv1.ElasticsearchDataSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "es-data", Namespace: "default"},
+			Spec: zv1.ElasticsearchDataSetSpec{
+				Replicas:             &replicas,
+				VolumeClaimRetention: &zv1.ElasticsearchDataSetVolumeClaimRetention{WhenScaled: "Retain"},
+			},
+		},
+		StatefulSet: &appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "es-data", Namespace: "default"},
+			Spec: appsv1.StatefulSetSpec{
+				Replicas:             &replicas,
+				Selector:             &metav1.LabelSelector{MatchLabels: selector},
+				VolumeClaimTemplates: []v1.PersistentVolumeClaim{{ObjectMeta: metav1.ObjectMeta{Name: "data"}}},
+			},
+		},
+	}
+	getClaim := func(name string) *v1.PersistentVolumeClaim {
+		claim, err := kube.CoreV1().PersistentVolumeClaims("default").Get(context.Background(), name, metav1.GetOptions{})
+		if err != nil {
+			return nil
+		}
+		return claim
+	}
+
+	// claims are kept forever, claims in use again are unmarked.
+	require.NoError(t, o.retainVolumeClaims(context.Background(), es, now))
+	require.NotContains(t, getClaim("data-es-data-0").Annotations, orphanedAtAnnotationKey)
+	require.NotContains(t, getClaim("data-es-data-2").Annotations, orphanedAtAnnotationKey)
+
+	// claims are deleted after the retention.
+	days := int32(7)
+	es.ElasticsearchDataSet.Spec.VolumeClaimRetention.RetentionDays = &days
+	require.NoError(t, o.retainVolumeClaims(context.Background(), es, now))
+	require.Equal(t, now.UTC().Format(time.RFC3339), getClaim("data-es-data-2").Annotations[orphanedAtAnnotationKey])
+	require.NotContains(t, getClaim("data-es-data-3").Annotations, orphanedAtAnnotationKey)
+	require.NoError(t, o.retainVolumeClaims(context.Background(), es, now.Add(6*24*time.Hour)))
+	require.NotNil(t, getClaim("data-es-data-2"))
+	require.NoError(t, o.retainVolumeClaims(context.Background(), es, now.Add(8*24*time.Hour)))
+	require.Nil(t, getClaim("data-es-data-2"))
+	require.Equal(t, "Normal DeletedVolumeClaim Deleted PersistentVolumeClaim 'default/data-es-data-2' as it wasn't used for 7 days", <-recorder.Events)
+
+	// claims above the desired replicas are deleted right away, except
+	// new ones.
+	replicas = 1
+	es.ElasticsearchDataSet.Spec.VolumeClaimRetention = &zv1.ElasticsearchDataSetVolumeClaimRetention{WhenScaled: "Delete"}
+	es.Pods = []v1.Pod{{ObjectMeta: metav1.ObjectMeta{Name: "es-data-0"}}}
+	require.NoError(t, o.retainVolumeClaims(context.Background(), es, now))
+	require.NotNil(t, getClaim("data-es-data-0"))
+	require.NotNil(t, getClaim("data-es-data-3"))
+	require.NoError(t, o.retainVolumeClaims(context.Background(), es, now.Add(time.Hour)))
+	require.Nil(t, getClaim("data-es-data-3"))
+	require.Equal(t, "Normal DeletedVolumeClaim Deleted PersistentVolumeClaim 'default/data-es-data-3' as its pod was removed by a scale-down", <-recorder.Events)
+}
+
+func TestValidateVolumeClaimRetention(t *testing.T) {
+	days := int32(7)
+	eds := conversionTestFixture()
+	eds.Spec.VolumeClaimRetention = &zv1.ElasticsearchDataSetVolumeClaimRetention{WhenScaled: "Retain", RetentionDays: &days}
+	require.NoError(t, ValidateElasticsearchDataSet(eds))
+	eds.Spec.VolumeClaimRetention.WhenScaled = "Delete"
+	require.EqualError(t, ValidateElasticsearchDataSet(eds), "spec.volumeClaimRetention.retentionDays requires whenScaled Retain")
+}
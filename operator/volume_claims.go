@@ -0,0 +1,89 @@
+package operator
+
+import (
+	"fmt"
+
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	v1 "k8s.io/api/core/v1"
+)
+
+// validateVolumeClaimTemplates validates the volume claim templates of the
+// EDS. Each claim needs a unique name, which doesn't shadow a volume of the
+// pod template, and a storage request.
+func validateVolumeClaimTemplates(eds *zv1.ElasticsearchDataSet) []error {
+	volumes := make(map[string]struct{}, len(eds.Spec.Template.Spec.Volumes))
+	for _, volume := range eds.Spec.Template.Spec.Volumes {
+		volumes[volume.Name] = struct{}{}
+	}
+
+	var errs []error
+	names := make(map[string]struct{}, len(eds.Spec.VolumeClaimTemplates))
+	for i, claim := range eds.Spec.VolumeClaimTemplates {
+		field := fmt.Sprintf("spec.volumeClaimTemplates[%d]", i)
+		if claim.Name == "" {
+			errs = append(errs, fmt.Errorf("%s.metadata.name must be set", field))
+			continue
+		}
+		if _, ok := names[claim.Name]; ok {
+			errs = append(errs, fmt.Errorf("%s.metadata.name %s is not unique", field, claim.Name))
+		}
+		names[claim.Name] = struct{}{}
+		if _, ok := volumes[claim.Name]; ok {
+			errs = append(errs, fmt.Errorf("%s.metadata.name %s is also a volume of spec.template.spec.volumes", field, claim.Name))
+		}
+		storage, ok := claim.Spec.Resources.Requests[v1.ResourceStorage]
+		if !ok || storage.Sign() <= 0 {
+			errs = append(errs, fmt.Errorf("%s.spec.resources.requests.storage must be set", field))
+		}
+	}
+	return errs
+}
+
+// volumeClaimTemplateChanges returns the changes of the names and storage
+// classes and the decreases of the sizes from the current to the desired
+// volume claim templates. These only apply to new volumes, so existing pods
+// would keep volumes of a different class or size than new pods. Increases
+// of the sizes are applied to the volumes by expanding them.
+func volumeClaimTemplateChanges(current, desired []v1.PersistentVolumeClaim) []string {
+	var changes []string
+	if len(current) != len(desired) {
+		return append(changes, fmt.Sprintf("the number of volume claim templates changed from %d to %d", len(current), len(desired)))
+	}
+	for i := range desired {
+		if current[i].Name != desired[i].Name {
+			changes = append(changes, fmt.Sprintf("volume claim template %s was renamed to %s", current[i].Name, desired[i].Name))
+			continue
+		}
+		currentClass, desiredClass := storageClassName(&current[i]), storageClassName(&desired[i])
+		if currentClass != desiredClass {
+			changes = append(changes, fmt.Sprintf("storage class of volume claim template %s changed from %s to %s", desired[i].Name, currentClass, desiredClass))
+		}
+		currentSize := current[i].Spec.Resources.Requests[v1.ResourceStorage]
+		desiredSize := desired[i].Spec.Resources.Requests[v1.ResourceStorage]
+		if currentSize.Cmp(desiredSize) > 0 {
+			changes = append(changes, fmt.Sprintf("storage size of volume claim template %s decreased from %s to %s", desired[i].Name, currentSize.String(), desiredSize.String()))
+		}
+	}
+	return changes
+}
+
+// storageClassName returns the storage class of the claim, or <default> if
+// the claim uses the default storage class.
+func storageClassName(claim *v1.PersistentVolumeClaim) string {
+	if claim.Spec.StorageClassName == nil {
+		return "<default>"
+	}
+	return *claim.Spec.StorageClassName
+}
+
+// withoutStorageRequests returns a copy of the volume claim templates without
+// their storage requests.
+func withoutStorageRequests(claims []zv1.PersistentVolumeClaim) []zv1.PersistentVolumeClaim {
+	copied := make([]zv1.PersistentVolumeClaim, 0, len(claims))
+	for _, claim := range claims {
+		claim := *claim.DeepCopy()
+		delete(claim.Spec.Resources.Requests, v1.ResourceStorage)
+		copied = append(copied, claim)
+	}
+	return copied
+}
@@ -0,0 +1,125 @@
+package operator
+
+import (
+	"context"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	"github.com/zalando-incubator/es-operator/pkg/clientset"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+)
+
+func testVolumeClaim(name, storageClass, size string) v1.PersistentVolumeClaim {
+	claim := v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: v1.PersistentVolumeClaimSpec{
+			AccessModes: []v1.PersistentVolumeAccessMode{v1.ReadWriteOnce},
+			Resources: v1.VolumeResourceRequirements{
+				Requests: v1.ResourceList{v1.ResourceStorage: resource.MustParse(size)},
+			},
+		},
+	}
+	if storageClass != "" {
+		claim.Spec.StorageClassName = &storageClass
+	}
+	return claim
+}
+
+func TestValidateVolumeClaimTemplates(t *testing.T) {
+	eds := conversionTestFixture()
+	eds.Spec.Template.Spec.Volumes = []v1.Volume{{Name: "config"}}
+	eds.Spec.VolumeClaimTemplates = []zv1.PersistentVolumeClaim{
+		{EmbeddedObjectMetaWithName: zv1.EmbeddedObjectMetaWithName{Name: "data"}, Spec: testVolumeClaim("", "", "10Gi").Spec},
+	}
+	require.NoError(t, ValidateElasticsearchDataSet(eds))
+
+	eds.Spec.VolumeClaimTemplates = append(eds.Spec.VolumeClaimTemplates,
+		zv1.PersistentVolumeClaim{EmbeddedObjectMetaWithName: zv1.EmbeddedObjectMetaWithName{Name: "data"}, Spec: testVolumeClaim("", "", "10Gi").Spec},
+		zv1.PersistentVolumeClaim{EmbeddedObjectMetaWithName: zv1.EmbeddedObjectMetaWithName{Name: "config"}},
+		zv1.PersistentVolumeClaim{},
+	)
+	err := ValidateElasticsearchDataSet(eds)
+	require.Error(t, err)
+	require.Equal(t, `spec.volumeClaimTemplates[1].metadata.name data is not unique
+spec.volumeClaimTemplates[2].metadata.name config is also a volume of spec.template.spec.volumes
+spec.volumeClaimTemplates[2].spec.resources.requests.storage must be set
+spec.volumeClaimTemplates[3].metadata.name must be set`, err.Error())
+}
+
+func TestVolumeClaimTemplateChanges(t *testing.T) {
+	current := []v1.PersistentVolumeClaim{testVolumeClaim("data", "", "10Gi")}
+	require.Empty(t, volumeClaimTemplateChanges(current, []v1.PersistentVolumeClaim{testVolumeClaim("data", "", "10240Mi")}))
+
+	require.Empty(t, volumeClaimTemplateChanges(current, []v1.PersistentVolumeClaim{testVolumeClaim("data", "", "20Gi")}))
+	require.Equal(t, []string{
+		"storage class of volume claim template data changed from <default> to gp3",
+		"storage size of volume claim template data decreased from 10Gi to 5Gi",
+	}, volumeClaimTemplateChanges(current, []v1.PersistentVolumeClaim{testVolumeClaim("data", "gp3", "5Gi")}))
+	require.Equal(t, []string{"volume claim template data was renamed to other"},
+		volumeClaimTemplateChanges(current, []v1.PersistentVolumeClaim{testVolumeClaim("other", "", "10Gi")}))
+	require.Equal(t, []string{"the number of volume claim templates changed from 1 to 0"},
+		volumeClaimTemplateChanges(current, nil))
+}
+
+func TestValidateVolumeClaimTemplatesUpdate(t *testing.T) {
+	old := conversionTestFixture()
+	old.Spec.VolumeClaimTemplates = []zv1.PersistentVolumeClaim{
+		{EmbeddedObjectMetaWithName: zv1.EmbeddedObjectMetaWithName{Name: "data"}, Spec: testVolumeClaim("", "gp2", "10Gi").Spec},
+	}
+	eds := old.DeepCopy()
+	require.NoError(t, ValidateElasticsearchDataSetUpdate(old, eds))
+
+	eds.Spec.VolumeClaimTemplates[0].Spec.StorageClassName = nil
+	err := ValidateElasticsearchDataSetUpdate(old, eds)
+	require.Error(t, err)
+	require.Equal(t, "spec.volumeClaimTemplates is immutable, storage class of volume claim template data changed from gp2 to <default>", err.Error())
+
+	eds = old.DeepCopy()
+	eds.Spec.VolumeClaimTemplates[0].Spec.Resources.Requests[v1.ResourceStorage] = resource.MustParse("20Gi")
+	require.NoError(t, ValidateElasticsearchDataSetUpdate(old, eds))
+
+	eds = old.DeepCopy()
+	eds.Spec.VolumeClaimTemplates[0].Labels = map[string]string{"team": "search"}
+	err = ValidateElasticsearchDataSetUpdate(old, eds)
+	require.Error(t, err)
+	require.Equal(t, "spec.volumeClaimTemplates is immutable", err.Error())
+}
+
+func TestReconcileStatefulSetIgnoresVolumeClaimTemplates(t *testing.T) {
+	kube := fake.NewSimpleClientset()
+	recorder := record.NewFakeRecorder(10)
+	o := &Operator{
+		kube:     &clientset.Clientset{Interface: kube},
+		recorder: recorder,
+		logger:   log.WithField("test", t.Name()),
+	}
+	sr := &mockResource{
+		apiVersion:           "zalando.org/v1",
+		kind:                 "ElasticsearchDataSet",
+		name:                 "es-data",
+		namespace:            "default",
+		uid:                  "uid",
+		generation:           1,
+		podTemplateSpec:      &v1.PodTemplateSpec{},
+		volumeClaimTemplates: []v1.PersistentVolumeClaim{testVolumeClaim("data", "", "10Gi")},
+		eds:                  &zv1.ElasticsearchDataSet{},
+	}
+
+	_, err := o.reconcileStatefulset(context.Background(), sr)
+	require.NoError(t, err)
+	require.Contains(t, <-recorder.Events, "Normal CreatedStatefulSet")
+
+	sr.generation = 2
+	sr.volumeClaimTemplates = []v1.PersistentVolumeClaim{testVolumeClaim("data", "", "5Gi")}
+	sts, err := o.reconcileStatefulset(context.Background(), sr)
+	require.NoError(t, err)
+	require.Equal(t, "Warning IgnoredVolumeClaimTemplates Ignored change of StatefulSet 'default/es-data': storage size of volume claim template data decreased from 10Gi to 5Gi", <-recorder.Events)
+	require.Contains(t, <-recorder.Events, "Normal UpdatedStatefulSet")
+	require.Equal(t, resource.MustParse("10Gi"), sts.Spec.VolumeClaimTemplates[0].Spec.Resources.Requests[v1.ResourceStorage])
+}
@@ -0,0 +1,209 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const defaultVolumeExpansionUsedPercentThreshold = 80
+
+var defaultVolumeExpansionStep = resource.MustParse("10Gi")
+
+// volumeExpansionTemplate returns the volume claim template of the data
+// volume, or nil if the EDS has none.
+func volumeExpansionTemplate(eds *zv1.ElasticsearchDataSet) *zv1.PersistentVolumeClaim {
+	name := ""
+	if eds.Spec.VolumeExpansion != nil {
+		name = eds.Spec.VolumeExpansion.VolumeClaimTemplate
+	}
+	for i, template := range eds.Spec.VolumeClaimTemplates {
+		if name == "" || template.Name == name {
+			return &eds.Spec.VolumeClaimTemplates[i]
+		}
+	}
+	return nil
+}
+
+// volumeResizing returns true if the last expansion of the claim didn't
+// complete yet.
+func volumeResizing(claim *v1.PersistentVolumeClaim) bool {
+	requested := claim.Spec.Resources.Requests[v1.ResourceStorage]
+	capacity, ok := claim.Status.Capacity[v1.ResourceStorage]
+	if !ok || capacity.Cmp(requested) < 0 {
+		return true
+	}
+	for _, condition := range claim.Status.Conditions {
+		if condition.Status == v1.ConditionTrue &&
+			(condition.Type == v1.PersistentVolumeClaimResizing || condition.Type == v1.PersistentVolumeClaimFileSystemResizePending) {
+			return true
+		}
+	}
+	return false
+}
+
+// expandedVolumeSize returns the size the volume with the disk usage is
+// expanded to, which is the current size if it's not expanded. The second
+// value is true if the volume should grow but is at the maximum size.
+func expandedVolumeSize(config *zv1.ElasticsearchDataSetVolumeExpansion, current resource.Quantity, disk ESNodeDisk) (resource.Quantity, bool) {
+	threshold := int64(config.UsedPercentThreshold)
+	if threshold <= 0 {
+		threshold = defaultVolumeExpansionUsedPercentThreshold
+	}
+	if disk.TotalBytes <= 0 || disk.UsedBytes*100 < disk.TotalBytes*threshold || config.MaxSize == nil {
+		return current, false
+	}
+	if current.Cmp(*config.MaxSize) >= 0 {
+		return current, true
+	}
+
+	step := defaultVolumeExpansionStep
+	if config.Step != nil && config.Step.Sign() > 0 {
+		step = *config.Step
+	}
+	expanded := current.DeepCopy()
+	expanded.Add(step)
+	if expanded.Cmp(*config.MaxSize) > 0 {
+		expanded = config.MaxSize.DeepCopy()
+	}
+	return expanded, false
+}
+
+// runVolumeExpansion periodically expands the volumes of the EDS.
+func (o *ElasticsearchOperator) runVolumeExpansion(ctx context.Context) {
+	nextCheck := time.Now().Add(-o.interval)
+
+	for {
+		o.logger.Debug("Checking volume expansion")
+		select {
+		case <-time.After(time.Until(nextCheck)):
+			nextCheck = time.Now().Add(o.interval)
+
+			resources, err := o.collectResources(ctx)
+			if err != nil {
+				o.logger.Error(err)
+				continue
+			}
+
+			for _, es := range resources {
+				err := o.expandVolumes(ctx, es)
+				if err != nil {
+					o.logger.Errorf("Failed to expand volumes of EDS %s/%s: %v", es.ElasticsearchDataSet.Namespace, es.ElasticsearchDataSet.Name, err)
+				}
+			}
+		case <-ctx.Done():
+			o.logger.Info("Terminating volume expansion loop.")
+			return
+		}
+	}
+}
+
+// expandVolumes expands the data volumes of the pods of the EDS to the size
+// requested by the volume claim template, which may have grown since the
+// StatefulSet was created, and, if enabled, by a step once their disk usage
+// reaches the threshold. Volumes are only expanded if their storage class
+// allows it and the previous expansion completed.
+func (o *ElasticsearchOperator) expandVolumes(ctx context.Context, es *ESResource) error {
+	eds := es.ElasticsearchDataSet
+	template := volumeExpansionTemplate(eds)
+	if template == nil || es.StatefulSet == nil {
+		return nil
+	}
+	minSize := template.Spec.Resources.Requests[v1.ResourceStorage]
+
+	config := eds.Spec.VolumeExpansion
+	disks := make(map[string]ESNodeDisk)
+	if config != nil && config.Enabled {
+		client := &ESClient{Endpoint: o.getElasticsearchEndpoint(eds), transport: o.elasticsearchTransport(eds)}
+		nodes, err := client.GetNodesDisk()
+		if err != nil {
+			return err
+		}
+		for _, node := range nodes {
+			disks[node.IP] = node
+		}
+	}
+
+	expandable := make(map[string]bool)
+	for _, pod := range es.Pods {
+		name := template.Name + "-" + pod.Name
+		claim, err := o.kube.CoreV1().PersistentVolumeClaims(pod.Namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+		if volumeResizing(claim) {
+			continue
+		}
+
+		current := claim.Spec.Resources.Requests[v1.ResourceStorage]
+		desired := current
+		reason := ""
+		if minSize.Cmp(current) > 0 {
+			desired = minSize
+			reason = fmt.Sprintf("the volume claim template requests %s", minSize.String())
+		}
+		if disk, ok := disks[pod.Status.PodIP]; ok {
+			expanded, limited := expandedVolumeSize(config, current, disk)
+			if limited {
+				o.recorder.Event(eds, v1.EventTypeWarning, "VolumeExpansionLimitReached",
+					fmt.Sprintf("Can't expand PersistentVolumeClaim '%s/%s' beyond %s, %d%% of the disk are used", claim.Namespace, claim.Name, config.MaxSize.String(), disk.UsedBytes*100/disk.TotalBytes))
+			}
+			if expanded.Cmp(desired) > 0 {
+				desired = expanded
+				reason = fmt.Sprintf("%d%% of the disk are used", disk.UsedBytes*100/disk.TotalBytes)
+			}
+		}
+		if desired.Cmp(current) <= 0 {
+			continue
+		}
+
+		className := storageClassName(claim)
+		allowed, ok := expandable[className]
+		if !ok {
+			allowed, err = o.volumeExpansionAllowed(ctx, claim)
+			if err != nil {
+				return err
+			}
+			expandable[className] = allowed
+		}
+		if !allowed {
+			o.recorder.Event(eds, v1.EventTypeWarning, "VolumeExpansionNotAllowed",
+				fmt.Sprintf("Can't expand PersistentVolumeClaim '%s/%s' to %s, storage class %s doesn't allow volume expansion", claim.Namespace, claim.Name, desired.String(), className))
+			continue
+		}
+
+		claim.Spec.Resources.Requests[v1.ResourceStorage] = desired
+		_, err = o.kube.CoreV1().PersistentVolumeClaims(claim.Namespace).Update(ctx, claim, metav1.UpdateOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to expand PersistentVolumeClaim %s/%s: %v", claim.Namespace, claim.Name, err)
+		}
+		o.recorder.Event(eds, v1.EventTypeNormal, "ExpandedVolume",
+			fmt.Sprintf("Expanded PersistentVolumeClaim '%s/%s' from %s to %s as %s", claim.Namespace, claim.Name, current.String(), desired.String(), reason))
+	}
+	return nil
+}
+
+// volumeExpansionAllowed returns true if the storage class of the claim
+// allows volume expansion.
+func (o *ElasticsearchOperator) volumeExpansionAllowed(ctx context.Context, claim *v1.PersistentVolumeClaim) (bool, error) {
+	if claim.Spec.StorageClassName == nil || *claim.Spec.StorageClassName == "" {
+		return false, nil
+	}
+	class, err := o.kube.StorageV1().StorageClasses().Get(ctx, *claim.Spec.StorageClassName, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return class.AllowVolumeExpansion != nil && *class.AllowVolumeExpansion, nil
+}
@@ -0,0 +1,120 @@
+package operator
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	"github.com/zalando-incubator/es-operator/pkg/clientset"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestExpandedVolumeSize(t *testing.T) {
+	maxSize := resource.MustParse("100Gi")
+	config := &zv1.ElasticsearchDataSetVolumeExpansion{Enabled: true, MaxSize: &maxSize}
+	current := resource.MustParse("50Gi")
+
+	size, limited := expandedVolumeSize(config, current, ESNodeDisk{UsedBytes: 79, TotalBytes: 100})
+	require.Equal(t, current, size)
+	require.False(t, limited)
+
+	size, limited = expandedVolumeSize(config, current, ESNodeDisk{UsedBytes: 80, TotalBytes: 100})
+	require.Equal(t, "60Gi", size.String())
+	require.False(t, limited)
+
+	step := resource.MustParse("60Gi")
+	config.Step = &step
+	size, _ = expandedVolumeSize(config, current, ESNodeDisk{UsedBytes: 80, TotalBytes: 100})
+	require.Equal(t, "100Gi", size.String())
+
+	size, limited = expandedVolumeSize(config, maxSize, ESNodeDisk{UsedBytes: 95, TotalBytes: 100})
+	require.Equal(t, maxSize, size)
+	require.True(t, limited)
+}
+
+func TestVolumeResizing(t *testing.T) {
+	claim := testVolumeClaim("data-es-data-0", "", "20Gi")
+	require.True(t, volumeResizing(&claim))
+	claim.Status.Capacity = v1.ResourceList{v1.ResourceStorage: resource.MustParse("10Gi")}
+	require.True(t, volumeResizing(&claim))
+	claim.Status.Capacity = v1.ResourceList{v1.ResourceStorage: resource.MustParse("20Gi")}
+	require.False(t, volumeResizing(&claim))
+	claim.Status.Conditions = []v1.PersistentVolumeClaimCondition{{Type: v1.PersistentVolumeClaimFileSystemResizePending, Status: v1.ConditionTrue}}
+	require.True(t, volumeResizing(&claim))
+}
+
+func TestExpandVolumes(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_cat/allocation?h=ip,disk.used,disk.total&bytes=b&format=json",
+		httpmock.NewStringResponder(200, `[{"ip":"1.2.3.4","disk.used":"90","disk.total":"100"},{"ip":"1.2.3.5","disk.used":"10","disk.total":"100"}]`))
+
+	claim := func(name, class, size string) *v1.PersistentVolumeClaim {
+		claim := testVolumeClaim(name, class, size)
+		claim.Namespace = "default"
+		claim.Status.Capacity = claim.Spec.Resources.Requests.DeepCopy()
+		return &claim
+	}
+	allowVolumeExpansion := true
+	kube := fake.NewSimpleClientset(
+		&storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "gp3"}, AllowVolumeExpansion: &allowVolumeExpansion},
+		&storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "local"}},
+		claim("data-es-data-0", "gp3", "50Gi"),
+		claim("data-es-data-1", "gp3", "10Gi"),
+		claim("data-es-data-2", "local", "10Gi"),
+	)
+	recorder := record.NewFakeRecorder(10)
+	endpoint, _ := url.Parse("http://elasticsearch:9200")
+	o := NewElasticsearchOperator(&clientset.Clientset{Interface: kube}, Options{Interval: time.Second, AutoscalerInterval: time.Second, ClusterDNSZone: "cluster.local.", ElasticsearchEndpoint: endpoint})
+	o.recorder = recorder
+
+	maxSize := resource.MustParse("100Gi")
+	pod := func(name, ip string) v1.Pod {
+		return v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"}, Status: v1.PodStatus{PodIP: ip}}
+	}
+	es := &ESResource{
+		ElasticsearchDataSet: &zv1.ElasticsearchDataSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "es-data", Namespace: "default"},
+			Spec: zv1.ElasticsearchDataSetSpec{
+				VolumeClaimTemplates: []zv1.PersistentVolumeClaim{{
+					EmbeddedObjectMetaWithName: zv1.EmbeddedObjectMetaWithName{Name: "data"},
+					Spec:                       testVolumeClaim("", "gp3", "20Gi").Spec,
+				}},
+				VolumeExpansion: &zv1.ElasticsearchDataSetVolumeExpansion{Enabled: true, MaxSize: &maxSize},
+			},
+		},
+		StatefulSet: &appsv1.StatefulSet{},
+		Pods:        []v1.Pod{pod("es-data-0", "1.2.3.4"), pod("es-data-1", "1.2.3.5"), pod("es-data-2", "1.2.3.6"), pod("es-data-3", "1.2.3.7")},
+	}
+	size := func(name string) string {
+		claim, err := kube.CoreV1().PersistentVolumeClaims("default").Get(context.Background(), name, metav1.GetOptions{})
+		require.NoError(t, err)
+		quantity := claim.Spec.Resources.Requests[v1.ResourceStorage]
+		return quantity.String()
+	}
+
+	require.NoError(t, o.expandVolumes(context.Background(), es))
+	// expanded by a step as the disk is full.
+	require.Equal(t, "60Gi", size("data-es-data-0"))
+	require.Equal(t, "Normal ExpandedVolume Expanded PersistentVolumeClaim 'default/data-es-data-0' from 50Gi to 60Gi as 90% of the disk are used", <-recorder.Events)
+	// expanded to the size of the grown template.
+	require.Equal(t, "20Gi", size("data-es-data-1"))
+	require.Equal(t, "Normal ExpandedVolume Expanded PersistentVolumeClaim 'default/data-es-data-1' from 10Gi to 20Gi as the volume claim template requests 20Gi", <-recorder.Events)
+	// not expandable.
+	require.Equal(t, "10Gi", size("data-es-data-2"))
+	require.Equal(t, "Warning VolumeExpansionNotAllowed Can't expand PersistentVolumeClaim 'default/data-es-data-2' to 20Gi, storage class local doesn't allow volume expansion", <-recorder.Events)
+
+	// the expansion of the volume is pending.
+	require.NoError(t, o.expandVolumes(context.Background(), es))
+	require.Equal(t, "60Gi", size("data-es-data-0"))
+}
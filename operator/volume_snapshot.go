@@ -0,0 +1,195 @@
+package operator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// restoredFromSnapshotAnnotationKey is set on volumes created from a
+	// VolumeSnapshot.
+	restoredFromSnapshotAnnotationKey = "es-operator.zalando.org/restored-from-snapshot"
+
+	volumeSnapshotAPIGroup = "snapshot.storage.k8s.io"
+	volumeSnapshotKind     = "VolumeSnapshot"
+
+	defaultVolumeSnapshotMaxAgeSeconds = 86400
+)
+
+// volumeSnapshot is the part of a VolumeSnapshot resource relevant for
+// creating volumes from it.
+type volumeSnapshot struct {
+	Metadata struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+	Spec struct {
+		Source struct {
+			PersistentVolumeClaimName string `json:"persistentVolumeClaimName"`
+		} `json:"source"`
+	} `json:"spec"`
+	Status *volumeSnapshotStatus `json:"status"`
+}
+
+type volumeSnapshotStatus struct {
+	ReadyToUse   bool               `json:"readyToUse"`
+	CreationTime *metav1.Time       `json:"creationTime"`
+	RestoreSize  *resource.Quantity `json:"restoreSize"`
+}
+
+type volumeSnapshotList struct {
+	Items []volumeSnapshot `json:"items"`
+}
+
+// listVolumeSnapshots returns the VolumeSnapshots of the namespace.
+func listVolumeSnapshots(ctx context.Context, kube kubernetes.Interface, namespace string) ([]volumeSnapshot, error) {
+	body, err := kube.Discovery().RESTClient().Get().
+		AbsPath("/apis", volumeSnapshotAPIGroup, "v1", "namespaces", namespace, "volumesnapshots").
+		DoRaw(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list VolumeSnapshots: %v", err)
+	}
+
+	var snapshots volumeSnapshotList
+	err = json.Unmarshal(body, &snapshots)
+	if err != nil {
+		return nil, err
+	}
+	return snapshots.Items, nil
+}
+
+// latestVolumeSnapshot returns the most recent ready VolumeSnapshot of a
+// volume created from the claim template of the StatefulSet, which is not
+// older than maxAge.
+func latestVolumeSnapshot(snapshots []volumeSnapshot, claimTemplate, sts string, now time.Time, maxAge time.Duration) *volumeSnapshot {
+	claimPattern := regexp.MustCompile("^" + regexp.QuoteMeta(claimTemplate+"-"+sts) + "-[0-9]+$")
+
+	var latest *volumeSnapshot
+	for i, snapshot := range snapshots {
+		if snapshot.Status == nil || !snapshot.Status.ReadyToUse || snapshot.Status.CreationTime == nil {
+			continue
+		}
+		if !claimPattern.MatchString(snapshot.Spec.Source.PersistentVolumeClaimName) {
+			continue
+		}
+		if now.Sub(snapshot.Status.CreationTime.Time) > maxAge {
+			continue
+		}
+		if latest == nil || snapshot.Status.CreationTime.After(latest.Status.CreationTime.Time) {
+			latest = &snapshots[i]
+		}
+	}
+	return latest
+}
+
+// volumeFromSnapshot returns the volume of the StatefulSet pod with the
+// ordinal for the claim template, created from the VolumeSnapshot.
+func volumeFromSnapshot(sts *appsv1.StatefulSet, claimTemplate v1.PersistentVolumeClaim, ordinal int32, snapshot *volumeSnapshot) *v1.PersistentVolumeClaim {
+	pvc := &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			// the name the StatefulSet controller uses for the volume of
+			// the pod, so the pod picks it up.
+			Name:        fmt.Sprintf("%s-%s-%d", claimTemplate.Name, sts.Name, ordinal),
+			Namespace:   sts.Namespace,
+			Labels:      map[string]string{},
+			Annotations: map[string]string{},
+		},
+		Spec: *claimTemplate.Spec.DeepCopy(),
+	}
+	for k, v := range claimTemplate.Labels {
+		pvc.Labels[k] = v
+	}
+	if sts.Spec.Selector != nil {
+		for k, v := range sts.Spec.Selector.MatchLabels {
+			pvc.Labels[k] = v
+		}
+	}
+	for k, v := range claimTemplate.Annotations {
+		pvc.Annotations[k] = v
+	}
+	pvc.Annotations[restoredFromSnapshotAnnotationKey] = snapshot.Metadata.Name
+
+	apiGroup := volumeSnapshotAPIGroup
+	pvc.Spec.DataSource = &v1.TypedLocalObjectReference{
+		APIGroup: &apiGroup,
+		Kind:     volumeSnapshotKind,
+		Name:     snapshot.Metadata.Name,
+	}
+	pvc.Spec.DataSourceRef = nil
+
+	// the volume must be at least as large as the snapshot.
+	if size := snapshot.Status.RestoreSize; size != nil {
+		if pvc.Spec.Resources.Requests == nil {
+			pvc.Spec.Resources.Requests = v1.ResourceList{}
+		}
+		if request, ok := pvc.Spec.Resources.Requests[v1.ResourceStorage]; !ok || request.Cmp(*size) < 0 {
+			pvc.Spec.Resources.Requests[v1.ResourceStorage] = *size
+		}
+	}
+	return pvc
+}
+
+// PreScaleUpHook prepares the recovery of the new pods. If configured, the
+// snapshot repository is set up for peer recoveries, and the volumes of the
+// new pods are created from the latest VolumeSnapshot of another pod. Pods
+// for which no recent snapshot exists get empty volumes as usual.
+func (r *EDSResource) PreScaleUpHook(ctx context.Context, sts *appsv1.StatefulSet, replicas int32) error {
+	if recovery := r.eds.Spec.SnapshotPeerRecovery; recovery != nil && recovery.Enabled && r.esClient != nil {
+		err := r.esClient.ensurePeerRecoveryRepository(recovery)
+		if err != nil {
+			// new nodes still recover from their peers.
+			r.recorder.Event(r.eds, v1.EventTypeWarning, "SnapshotPeerRecoveryFailed",
+				fmt.Sprintf("Failed to set up snapshot repository %s for peer recoveries: %v", recovery.Repository, err))
+		}
+	}
+
+	config := r.eds.Spec.VolumeSnapshotRecovery
+	if config == nil || !config.Enabled || sts.Spec.Replicas == nil {
+		return nil
+	}
+	maxAge := time.Duration(config.MaxAgeSeconds) * time.Second
+	if config.MaxAgeSeconds <= 0 {
+		maxAge = defaultVolumeSnapshotMaxAgeSeconds * time.Second
+	}
+
+	snapshots, err := listVolumeSnapshots(ctx, r.kube, sts.Namespace)
+	if err != nil {
+		// scale up with empty volumes rather than not at all.
+		r.recorder.Event(r.eds, v1.EventTypeWarning, "VolumeSnapshotRecoveryFailed", err.Error())
+		return nil
+	}
+
+	now := time.Now().UTC()
+	for _, claimTemplate := range sts.Spec.VolumeClaimTemplates {
+		snapshot := latestVolumeSnapshot(snapshots, claimTemplate.Name, sts.Name, now, maxAge)
+		if snapshot == nil {
+			continue
+		}
+
+		for ordinal := *sts.Spec.Replicas; ordinal < replicas; ordinal++ {
+			pvc := volumeFromSnapshot(sts, claimTemplate, ordinal, snapshot)
+			_, err := r.kube.CoreV1().PersistentVolumeClaims(pvc.Namespace).Create(ctx, pvc, metav1.CreateOptions{})
+			if err != nil {
+				// a retained volume of a previous pod is reused as is.
+				if apierrors.IsAlreadyExists(err) {
+					continue
+				}
+				r.recorder.Event(r.eds, v1.EventTypeWarning, "VolumeSnapshotRecoveryFailed",
+					fmt.Sprintf("Failed to create PersistentVolumeClaim %s/%s from VolumeSnapshot %s: %v", pvc.Namespace, pvc.Name, snapshot.Metadata.Name, err))
+				continue
+			}
+			r.recorder.Event(r.eds, v1.EventTypeNormal, "CreatedVolumeFromSnapshot",
+				fmt.Sprintf("Created PersistentVolumeClaim %s/%s from VolumeSnapshot %s", pvc.Namespace, pvc.Name, snapshot.Metadata.Name))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package operator
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestLatestVolumeSnapshot(t *testing.T) {
+	var snapshots volumeSnapshotList
+	err := json.Unmarshal([]byte(`{"items":[
+		{"metadata":{"name":"old"},"spec":{"source":{"persistentVolumeClaimName":"data-es-data-0"}},"status":{"readyToUse":true,"creationTime":"2024-01-01T00:00:00Z"}},
+		{"metadata":{"name":"recent"},"spec":{"source":{"persistentVolumeClaimName":"data-es-data-1"}},"status":{"readyToUse":true,"creationTime":"2024-01-02T10:00:00Z"}},
+		{"metadata":{"name":"not-ready"},"spec":{"source":{"persistentVolumeClaimName":"data-es-data-0"}},"status":{"readyToUse":false,"creationTime":"2024-01-02T11:00:00Z"}},
+		{"metadata":{"name":"other-eds"},"spec":{"source":{"persistentVolumeClaimName":"data-es-data-other-0"}},"status":{"readyToUse":true,"creationTime":"2024-01-02T11:00:00Z"}},
+		{"metadata":{"name":"pending"},"spec":{"source":{"persistentVolumeClaimName":"data-es-data-0"}}}
+	]}`), &snapshots)
+	require.NoError(t, err)
+
+	now := time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC)
+	snapshot := latestVolumeSnapshot(snapshots.Items, "data", "es-data", now, 24*time.Hour)
+	require.NotNil(t, snapshot)
+	assert.Equal(t, "recent", snapshot.Metadata.Name)
+
+	assert.Nil(t, latestVolumeSnapshot(snapshots.Items, "data", "es-data", now, time.Hour))
+	assert.Nil(t, latestVolumeSnapshot(snapshots.Items, "logs", "es-data", now, 24*time.Hour))
+}
+
+func TestVolumeFromSnapshot(t *testing.T) {
+	sts := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "es-data", Namespace: "default"},
+		Spec: appsv1.StatefulSetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"es-operator-dataset": "es-data"}},
+		},
+	}
+	claimTemplate := v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "data", Labels: map[string]string{"team": "search"}},
+		Spec: v1.PersistentVolumeClaimSpec{
+			Resources: v1.VolumeResourceRequirements{
+				Requests: v1.ResourceList{v1.ResourceStorage: resource.MustParse("10Gi")},
+			},
+		},
+	}
+	restoreSize := resource.MustParse("20Gi")
+	snapshot := &volumeSnapshot{}
+	snapshot.Metadata.Name = "recent"
+	snapshot.Status = &volumeSnapshotStatus{ReadyToUse: true, RestoreSize: &restoreSize}
+
+	pvc := volumeFromSnapshot(sts, claimTemplate, 3, snapshot)
+	assert.Equal(t, "data-es-data-3", pvc.Name)
+	assert.Equal(t, "default", pvc.Namespace)
+	assert.Equal(t, map[string]string{"team": "search", "es-operator-dataset": "es-data"}, pvc.Labels)
+	assert.Equal(t, "recent", pvc.Annotations[restoredFromSnapshotAnnotationKey])
+	require.NotNil(t, pvc.Spec.DataSource)
+	assert.Equal(t, volumeSnapshotKind, pvc.Spec.DataSource.Kind)
+	assert.Equal(t, "recent", pvc.Spec.DataSource.Name)
+	// the volume is grown to the size of the snapshot.
+	assert.True(t, restoreSize.Equal(pvc.Spec.Resources.Requests[v1.ResourceStorage]))
+	// the claim template is not modified.
+	assert.True(t, resource.MustParse("10Gi").Equal(claimTemplate.Spec.Resources.Requests[v1.ResourceStorage]))
+}
@@ -0,0 +1,45 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	v1 "k8s.io/api/core/v1"
+)
+
+// runWarmupQueries sends the warmup queries to the Elasticsearch node of the
+// pod, which coordinates them and searches its local shards where possible.
+// It returns an error if any of them fails. The queries are sent with the
+// scheme and the transport of the ES client, if any.
+func runWarmupQueries(ctx context.Context, pod *v1.Pod, queries []zv1.ElasticsearchDataSetWarmupQuery, client *ESClient) error {
+	if pod.Status.PodIP == "" {
+		return fmt.Errorf("pod has no IP")
+	}
+	scheme := "http"
+	if client == nil {
+		client = &ESClient{}
+	} else if client.Endpoint != nil {
+		scheme = client.Endpoint.Scheme
+	}
+	endpoint := fmt.Sprintf("%s://%s:%d", scheme, pod.Status.PodIP, defaultElasticsearchDataSetEndpointPort)
+
+	for _, query := range queries {
+		req := client.httpClient().R().
+			SetContext(ctx).
+			SetHeader("Content-Type", "application/json")
+		if query.Body != "" {
+			req.SetBody(query.Body)
+		}
+		resp, err := req.Post(fmt.Sprintf("%s/%s/_search?preference=_local", endpoint, url.PathEscape(query.Index)))
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode() != http.StatusOK {
+			return fmt.Errorf("search of %s returned code status %d - %s", query.Index, resp.StatusCode(), resp.Body())
+		}
+	}
+	return nil
+}
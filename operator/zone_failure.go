@@ -0,0 +1,266 @@
+package operator
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	defaultZoneLabel          = "topology.kubernetes.io/zone"
+	defaultAwarenessAttribute = "zone"
+
+	zoneFailureConditionType = zv1.ConditionZoneFailure
+)
+
+// forcedAwarenessSetting returns the setting holding the forced awareness
+// values of the attribute.
+func forcedAwarenessSetting(attribute string) string {
+	return fmt.Sprintf("cluster.routing.allocation.awareness.force.%s.values", attribute)
+}
+
+// GetForcedAwarenessValues returns the persistent forced awareness values of
+// the attribute.
+func (c *ESClient) GetForcedAwarenessValues(attribute string) ([]string, error) {
+	resp, err := c.httpClient().R().
+		Get(c.Endpoint.String() + "/_cluster/settings?flat_settings=true")
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, fmt.Errorf("code status %d - %s", resp.StatusCode(), resp.Body())
+	}
+
+	var settings struct {
+		Persistent map[string]json.RawMessage `json:"persistent"`
+	}
+	err = json.Unmarshal(resp.Body(), &settings)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, ok := settings.Persistent[forcedAwarenessSetting(attribute)]
+	if !ok {
+		return nil, nil
+	}
+
+	// list settings are returned either as list or as comma separated
+	// string.
+	var values []string
+	if err := json.Unmarshal(raw, &values); err == nil {
+		return values, nil
+	}
+	var value string
+	err = json.Unmarshal(raw, &value)
+	if err != nil {
+		return nil, err
+	}
+	if value == "" {
+		return nil, nil
+	}
+	return strings.Split(value, ","), nil
+}
+
+// SetForcedAwarenessValues sets the persistent forced awareness values of
+// the attribute.
+func (c *ESClient) SetForcedAwarenessValues(attribute string, values []string) error {
+	body := map[string]map[string]string{
+		"persistent": {
+			forcedAwarenessSetting(attribute): strings.Join(values, ","),
+		},
+	}
+
+	resp, err := c.httpClient().R().
+		SetHeader("Content-Type", "application/json").
+		SetBody(body).
+		Put(c.Endpoint.String() + "/_cluster/settings")
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return fmt.Errorf("code status %d - %s", resp.StatusCode(), resp.Body())
+	}
+	return nil
+}
+
+// nodeReady returns true if the node has the Ready condition.
+func nodeReady(node *v1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == v1.NodeReady {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// lostZones returns the zones hosting pods in which all nodes are NotReady,
+// and the number of pods in those zones.
+func lostZones(nodes []*v1.Node, pods []*v1.Pod, zoneLabel string) ([]string, int32) {
+	nodeZones := make(map[string]string, len(nodes))
+	readyZones := make(map[string]bool)
+	for _, node := range nodes {
+		zone, ok := node.Labels[zoneLabel]
+		if !ok {
+			continue
+		}
+		nodeZones[node.Name] = zone
+		readyZones[zone] = readyZones[zone] || nodeReady(node)
+	}
+
+	lost := make(map[string]struct{})
+	lostPods := int32(0)
+	for _, pod := range pods {
+		zone, ok := nodeZones[pod.Spec.NodeName]
+		if !ok || readyZones[zone] {
+			continue
+		}
+		lost[zone] = struct{}{}
+		lostPods++
+	}
+
+	zones := make([]string, 0, len(lost))
+	for zone := range lost {
+		zones = append(zones, zone)
+	}
+	sort.Strings(zones)
+	return zones, lostPods
+}
+
+// withoutZones returns the values without the zones.
+func withoutZones(values, zones []string) []string {
+	remaining := make([]string, 0, len(values))
+	for _, value := range values {
+		lost := false
+		for _, zone := range zones {
+			if value == zone {
+				lost = true
+				break
+			}
+		}
+		if !lost {
+			remaining = append(remaining, value)
+		}
+	}
+	return remaining
+}
+
+// zoneFailureSettings returns the zone label and awareness attribute of the
+// configuration with defaults applied.
+func zoneFailureSettings(config *zv1.ElasticsearchDataSetZoneFailure) (string, string) {
+	zoneLabel := config.ZoneLabel
+	if zoneLabel == "" {
+		zoneLabel = defaultZoneLabel
+	}
+	attribute := config.AwarenessAttribute
+	if attribute == "" {
+		attribute = defaultAwarenessAttribute
+	}
+	return zoneLabel, attribute
+}
+
+// zoneFailureStatus detects lost zones and returns the resulting zone failure
+// status. While zones are lost they are removed from the forced awareness
+// values, the original values are restored once all zones are available.
+// Nil is returned if no zone is lost or zone failure handling is disabled.
+func (r *EDSResource) zoneFailureStatus(pods []*v1.Pod) *zv1.ElasticsearchDataSetZoneFailureStatus {
+	config := r.eds.Spec.ZoneFailure
+	if config == nil || !config.Enabled || r.nodeLister == nil {
+		return nil
+	}
+	zoneLabel, attribute := zoneFailureSettings(config)
+
+	nodes, err := r.nodeLister.List(labels.Everything())
+	if err != nil {
+		r.esClient.logger().Warnf("Failed to list nodes: %v", err)
+		return r.eds.Status.ZoneFailure
+	}
+
+	previous := r.eds.Status.ZoneFailure
+	zones, lostPods := lostZones(nodes, pods, zoneLabel)
+	if len(zones) == 0 {
+		if previous == nil {
+			return nil
+		}
+		if len(previous.ForcedAwarenessValues) > 0 {
+			err := r.esClient.SetForcedAwarenessValues(attribute, previous.ForcedAwarenessValues)
+			if err != nil {
+				r.recorder.Event(r.eds, v1.EventTypeWarning, "ZoneFailure",
+					fmt.Sprintf("Failed to restore forced awareness values: %v", err))
+				return previous
+			}
+		}
+		r.recorder.Event(r.eds, v1.EventTypeNormal, "ZoneRecovered",
+			fmt.Sprintf("All zones are available again, removing %d replacement Pods", previous.ReplacementReplicas))
+		return nil
+	}
+
+	status := &zv1.ElasticsearchDataSetZoneFailureStatus{
+		Zones:               zones,
+		ReplacementReplicas: lostPods,
+		Since:               metav1.NewTime(time.Now().UTC().Truncate(time.Second)),
+	}
+	if previous != nil {
+		status.Since = previous.Since
+		status.ForcedAwarenessValues = previous.ForcedAwarenessValues
+	} else {
+		r.recorder.Event(r.eds, v1.EventTypeWarning, "ZoneFailure",
+			fmt.Sprintf("Lost zones %s, adding %d replacement Pods", strings.Join(zones, ","), lostPods))
+	}
+
+	forced := status.ForcedAwarenessValues
+	if len(forced) == 0 {
+		forced, err = r.esClient.GetForcedAwarenessValues(attribute)
+		if err != nil {
+			r.recorder.Event(r.eds, v1.EventTypeWarning, "ZoneFailure",
+				fmt.Sprintf("Failed to get forced awareness values: %v", err))
+			return status
+		}
+	}
+
+	remaining := withoutZones(forced, zones)
+	// update the forced values if the lost zones weren't removed yet or
+	// changed.
+	if len(remaining) < len(forced) && (len(status.ForcedAwarenessValues) == 0 || !reflect.DeepEqual(withoutZones(forced, previous.Zones), remaining)) {
+		err := r.esClient.SetForcedAwarenessValues(attribute, remaining)
+		if err != nil {
+			r.recorder.Event(r.eds, v1.EventTypeWarning, "ZoneFailure",
+				fmt.Sprintf("Failed to remove lost zones from forced awareness values: %v", err))
+			return status
+		}
+		status.ForcedAwarenessValues = forced
+	}
+	return status
+}
+
+// setZoneFailureCondition sets the ZoneFailure condition based on the zone
+// failure status.
+func setZoneFailureCondition(eds *zv1.ElasticsearchDataSet, conditions *[]metav1.Condition, status *zv1.ElasticsearchDataSetZoneFailureStatus) {
+	if eds.Spec.ZoneFailure == nil || !eds.Spec.ZoneFailure.Enabled {
+		meta.RemoveStatusCondition(conditions, zoneFailureConditionType)
+		return
+	}
+
+	condition := metav1.Condition{
+		Type:               zoneFailureConditionType,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: eds.Generation,
+		Reason:             zv1.ReasonZonesAvailable,
+		Message:            "All zones are available",
+	}
+	if status != nil {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = zv1.ReasonZonesLost
+		condition.Message = fmt.Sprintf("Lost zones %s, running %d replacement Pods", strings.Join(status.Zones, ","), status.ReplacementReplicas)
+	}
+	meta.SetStatusCondition(conditions, condition)
+}
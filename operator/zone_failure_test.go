@@ -0,0 +1,130 @@
+package operator
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+)
+
+func zoneNode(name, zone string, ready bool) *v1.Node {
+	status := v1.ConditionTrue
+	if !ready {
+		status = v1.ConditionFalse
+	}
+	return &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{defaultZoneLabel: zone}},
+		Status: v1.NodeStatus{
+			Conditions: []v1.NodeCondition{{Type: v1.NodeReady, Status: status}},
+		},
+	}
+}
+
+func zonePod(name, node string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       v1.PodSpec{NodeName: node},
+	}
+}
+
+func TestLostZones(t *testing.T) {
+	nodes := []*v1.Node{
+		zoneNode("a-1", "a", true),
+		zoneNode("b-1", "b", false),
+		zoneNode("b-2", "b", false),
+		zoneNode("c-1", "c", false),
+		zoneNode("c-2", "c", true),
+		zoneNode("d-1", "d", false),
+	}
+	pods := []*v1.Pod{
+		zonePod("es-0", "a-1"),
+		zonePod("es-1", "b-1"),
+		zonePod("es-2", "b-2"),
+		zonePod("es-3", "c-1"),
+		zonePod("es-4", ""),
+	}
+
+	// zone 'd' doesn't host any pods.
+	zones, lostPods := lostZones(nodes, pods, defaultZoneLabel)
+	assert.Equal(t, []string{"b"}, zones)
+	assert.Equal(t, int32(2), lostPods)
+}
+
+func TestZoneFailureStatus(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "http://elasticsearch:9200/_cluster/settings",
+		httpmock.NewStringResponder(200, `{"persistent":{"cluster.routing.allocation.awareness.force.zone.values":"a,b,c"},"transient":{}}`))
+	var forced []string
+	httpmock.RegisterResponder("PUT", "http://elasticsearch:9200/_cluster/settings",
+		func(req *http.Request) (*http.Response, error) {
+			body := map[string]map[string]string{}
+			err := json.NewDecoder(req.Body).Decode(&body)
+			if err != nil {
+				return nil, err
+			}
+			forced = append(forced, body["persistent"]["cluster.routing.allocation.awareness.force.zone.values"])
+			return httpmock.NewStringResponse(200, `{}`), nil
+		})
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, node := range []*v1.Node{zoneNode("a-1", "a", true), zoneNode("b-1", "b", false), zoneNode("c-1", "c", true)} {
+		require.NoError(t, indexer.Add(node))
+	}
+	pods := []*v1.Pod{zonePod("es-0", "a-1"), zonePod("es-1", "b-1"), zonePod("es-2", "c-1")}
+
+	esURL, _ := url.Parse("http://elasticsearch:9200")
+	replicas := int32(3)
+	r := &EDSResource{
+		eds: &zv1.ElasticsearchDataSet{
+			Spec: zv1.ElasticsearchDataSetSpec{
+				Replicas:    &replicas,
+				ZoneFailure: &zv1.ElasticsearchDataSetZoneFailure{Enabled: true},
+			},
+		},
+		esClient:   &ESClient{Endpoint: esURL},
+		recorder:   record.NewFakeRecorder(10),
+		nodeLister: corelisters.NewNodeLister(indexer),
+	}
+
+	status := r.zoneFailureStatus(pods)
+	require.NotNil(t, status)
+	assert.Equal(t, []string{"b"}, status.Zones)
+	assert.Equal(t, int32(1), status.ReplacementReplicas)
+	assert.Equal(t, []string{"a", "b", "c"}, status.ForcedAwarenessValues)
+	assert.Equal(t, []string{"a,c"}, forced)
+
+	var conditions []metav1.Condition
+	setZoneFailureCondition(r.eds, &conditions, status)
+	assert.True(t, meta.IsStatusConditionTrue(conditions, zoneFailureConditionType))
+
+	// the replacement pods are added to the replicas.
+	r.eds.Status.ZoneFailure = status
+	assert.Equal(t, int32(4), r.Replicas())
+
+	// the forced awareness values are only updated once.
+	status = r.zoneFailureStatus(pods)
+	require.NotNil(t, status)
+	assert.Len(t, forced, 1)
+
+	// the forced awareness values are restored on recovery.
+	require.NoError(t, indexer.Update(zoneNode("b-1", "b", true)))
+	r.eds.Status.ZoneFailure = status
+	assert.Nil(t, r.zoneFailureStatus(pods))
+	assert.Equal(t, []string{"a,c", "a,b,c"}, forced)
+
+	setZoneFailureCondition(r.eds, &conditions, nil)
+	assert.True(t, meta.IsStatusConditionFalse(conditions, zoneFailureConditionType))
+}
@@ -33,6 +33,12 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&ElasticsearchDataSetList{},
 		&ElasticsearchMetricSet{},
 		&ElasticsearchMetricSetList{},
+		&ElasticsearchRollout{},
+		&ElasticsearchRolloutList{},
+		&ElasticsearchRestore{},
+		&ElasticsearchRestoreList{},
+		&ElasticsearchCluster{},
+		&ElasticsearchClusterList{},
 	)
 	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
 	return nil
@@ -2,7 +2,9 @@ package v1
 
 import (
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // +genclient
@@ -13,9 +15,13 @@ import (
 // by the es-operator.
 // +k8s:deepcopy-gen=true
 // +kubebuilder:resource:categories="all",shortName=eds
+// +kubebuilder:storageversion
 // +kubebuilder:printcolumn:name="Desired",type=integer,JSONPath=`.spec.replicas`,description="The desired number of replicas for the stateful set"
 // +kubebuilder:printcolumn:name="Current",type=integer,JSONPath=`.status.replicas`,description="The current number of replicas for the stateful set"
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`,description="Whether the latest generation is reconciled and all desired Pods are updated and ready"
+// +kubebuilder:printcolumn:name="Progress",type=integer,JSONPath=`.status.rolloutProgress.progressPercent`,description="The percentage of updated and ready Pods of the ongoing rollout",priority=1
 // +kubebuilder:subresource:status
+// +kubebuilder:subresource:scale:specpath=.spec.replicas,statuspath=.status.replicas,selectorpath=.status.selector
 type ElasticsearchDataSet struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
@@ -30,6 +36,7 @@ type ElasticsearchDataSet struct {
 type ElasticsearchDataSetSpec struct {
 	// Number of desired pods. This is a pointer to distinguish between explicit
 	// zero and not specified. Defaults to 1.
+	// +kubebuilder:validation:Minimum=0
 	// +optional
 	Replicas *int32 `json:"replicas,omitempty" protobuf:"varint,1,opt,name=replicas"`
 
@@ -42,6 +49,15 @@ type ElasticsearchDataSetSpec struct {
 	// +optional
 	SkipDraining bool `json:"skipDraining"`
 
+	// Role is the role of the Elasticsearch nodes of the EDS. Pods of the
+	// 'master' role are master-eligible nodes without data: they aren't
+	// drained, instead their vote is withdrawn before they are restarted
+	// or removed, and only while the remaining masters keep the quorum.
+	// The role can't be changed and autoscaling isn't supported for
+	// masters. Defaults to 'data'.
+	// +optional
+	Role ElasticsearchDataSetRole `json:"role,omitempty"`
+
 	// // serviceName is the name of the service that governs this StatefulSet.
 	// // This service must exist before the StatefulSet, and is responsible for
 	// // the network identity of the set. Pods get DNS/hostnames that follow the
@@ -58,219 +74,2541 @@ type ElasticsearchDataSetSpec struct {
 	// Template describe the volumeClaimTemplates
 	VolumeClaimTemplates []PersistentVolumeClaim `json:"volumeClaimTemplates,omitempty" protobuf:"bytes,4,rep,name=volumeClaimTemplates"`
 
-	// Experimental represents configurations marked as experimental that may change in future releases.
-	// Currently, manages the draining behavior.
+	// VolumeClaimRetention configures what happens to the volume claims of
+	// pods removed by scale-downs. Claims are retained if not set.
 	// +optional
-	Experimental *ExperimentalSpec `json:"experimental,omitempty"`
-}
+	VolumeClaimRetention *ElasticsearchDataSetVolumeClaimRetention `json:"volumeClaimRetention,omitempty"`
 
-// ExperimentalSpec represents the configurations that might change in the future.
-// IMPORTANT: These fields might change in a none backward compatible manner.
-// +k8s:deepcopy-gen=true
-type ExperimentalSpec struct {
-	// Draining controls behaviour of the EDS while draining nodes
+	// VolumeExpansion grows the volume claims of the pods when their disk
+	// usage reaches a threshold.
 	// +optional
-	Draining *ElasticsearchDataSetDraining `json:"draining,omitempty"`
-}
+	VolumeExpansion *ElasticsearchDataSetVolumeExpansion `json:"volumeExpansion,omitempty"`
 
-// ElasticsearchDataSetDraining represents the configuration for draining nodes within an ElasticsearchDataSet.
-// +k8s:deepcopy-gen=true
-type ElasticsearchDataSetDraining struct {
+	// TLS makes the operator connect to Elasticsearch with HTTPS.
+	// +optional
+	TLS *ElasticsearchDataSetTLS `json:"tls,omitempty"`
 
-	// MaxRetries specifies the maximum number of attempts to drain a node. The default value is 999.
-	// +kubebuilder:validation:Minimum=0
-	// +kubebuilder:default=999
-	MaxRetries int32 `json:"maxRetries"`
+	// Elasticsearch configures how the operator talks to Elasticsearch.
+	// +optional
+	Elasticsearch *ElasticsearchDataSetElasticsearch `json:"elasticsearch,omitempty"`
 
-	// MinimumWaitTimeDurationSeconds specifies the minimum wait time in seconds between retry attempts after a failed node drain.
-	// The default value is 10 seconds.
+	// MaxFailingPods pauses rolling updates of the EDS while more than the
+	// given number of pods are failing, e.g. in CrashLoopBackOff. Rolling
+	// updates are never paused if not set.
 	// +kubebuilder:validation:Minimum=0
-	// +kubebuilder:default=10
-	MinimumWaitTimeDurationSeconds int64 `json:"minimumWaitTimeDurationSeconds"`
+	// +optional
+	MaxFailingPods *int32 `json:"maxFailingPods,omitempty"`
 
-	// MaximumWaitTimeDurationSeconds specifies the maximum wait time in seconds between retry attempts after a failed node drain.
-	// The default value is 30 seconds.
-	// +kubebuilder:validation:Minimum=0
-	// +kubebuilder:default=30
-	MaximumWaitTimeDurationSeconds int64 `json:"maximumWaitTimeDurationSeconds"`
-}
+	// OrdinalOverrides configure JVM options and the relative capacity of
+	// ranges of Pod ordinals, e.g. for a few larger anchor nodes.
+	// +optional
+	OrdinalOverrides []ElasticsearchDataSetOrdinalOverride `json:"ordinalOverrides,omitempty"`
 
-// PersistentVolumeClaim is a user's request for and claim to a persistent volume
-// +k8s:deepcopy-gen=true
-type PersistentVolumeClaim struct {
-	EmbeddedObjectMetaWithName `json:"metadata,omitempty" protobuf:"bytes,1,opt,name=metadata"`
+	// AutoRollback configures rolling back the Pod template to the previous
+	// revision when a rollout fails.
+	// +optional
+	AutoRollback *ElasticsearchDataSetAutoRollback `json:"autoRollback,omitempty"`
 
-	// Spec defines the desired characteristics of a volume requested by a pod author.
-	// More info: https://kubernetes.io/docs/concepts/storage/persistent-volumes#persistentvolumeclaims
+	// RevisionHistoryLimit is the number of Pod template revisions kept in
+	// the status for rollbacks. Defaults to 5.
+	// +kubebuilder:validation:Minimum=1
 	// +optional
-	Spec v1.PersistentVolumeClaimSpec `json:"spec,omitempty" protobuf:"bytes,2,opt,name=spec"`
-}
+	RevisionHistoryLimit *int32 `json:"revisionHistoryLimit,omitempty"`
 
-// EmbeddedObjectWithName defines the metadata which can be attached
-// to a resource. It's a slimmed down version of metav1.ObjectMeta only
-// containing name, labels and annotations.
-// +k8s:deepcopy-gen=true
-type EmbeddedObjectMetaWithName struct {
-	// Name must be unique within a namespace. Is required when creating resources, although
-	// some resources may allow a client to request the generation of an appropriate name
-	// automatically. Name is primarily intended for creation idempotence and configuration
-	// definition.
-	// Cannot be updated.
-	// More info: http://kubernetes.io/docs/user-guide/identifiers#names
-	Name string `json:"name,omitempty" protobuf:"bytes,1,opt,name=name"`
+	// MemoryAdjustment increases the memory of the pods when they are
+	// repeatedly OOMKilled.
+	// +optional
+	MemoryAdjustment *ElasticsearchDataSetMemoryAdjustment `json:"memoryAdjustment,omitempty"`
 
-	// Map of string keys and values that can be used to organize and categorize
-	// (scope and select) objects. May match selectors of replication controllers
-	// and services.
-	// More info: http://kubernetes.io/docs/user-guide/labels
+	// HeapDumps configures the JVM to write heap dumps and fatal error
+	// logs to a volume and optionally uploads them to object storage.
 	// +optional
-	Labels map[string]string `json:"labels,omitempty" protobuf:"bytes,11,rep,name=labels"`
+	HeapDumps *ElasticsearchDataSetHeapDumps `json:"heapDumps,omitempty"`
 
-	// Annotations is an unstructured key value map stored with a resource that may be
-	// set by external tools to store and retrieve arbitrary metadata. They are not
-	// queryable and should be preserved when modifying objects.
-	// More info: http://kubernetes.io/docs/user-guide/annotations
+	// DiskPreparation adds an init container pre-touching the data volume
+	// and checking its mount options before Elasticsearch starts.
 	// +optional
-	Annotations map[string]string `json:"annotations,omitempty" protobuf:"bytes,12,rep,name=annotations"`
+	DiskPreparation *ElasticsearchDataSetDiskPreparation `json:"diskPreparation,omitempty"`
+
+	// SchedulingGate holds new pods back from being scheduled until the
+	// cluster health reaches a minimum status. A custom scheduler and
+	// further gates can be set in the pod template.
+	// +optional
+	SchedulingGate *ElasticsearchDataSetSchedulingGate `json:"schedulingGate,omitempty"`
+
+	// GCRecycling recycles pods with sustained high old generation heap
+	// usage.
+	// +optional
+	GCRecycling *ElasticsearchDataSetGCRecycling `json:"gcRecycling,omitempty"`
+
+	// ExtraPorts are additional ports, e.g. of a metrics exporter sidecar,
+	// added to the containers of the pods and to the EDS service.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	ExtraPorts []ElasticsearchDataSetPort `json:"extraPorts,omitempty"`
+
+	// PodServices creates a Service with a stable name and IP for each
+	// pod of the EDS, e.g. for clients or remote cluster seeds pinned to
+	// individual nodes. Services of removed pods are deleted.
+	// +optional
+	PodServices *ElasticsearchDataSetPodServices `json:"podServices,omitempty"`
+
+	// ExternalDNS publishes the addresses of the pods serving clients as
+	// a DNS record managed by external-dns, for clients outside of the
+	// cluster. Pods are removed from the record before they are drained.
+	// +optional
+	ExternalDNS *ElasticsearchDataSetExternalDNS `json:"externalDNS,omitempty"`
+
+	// Architecture schedules the pods on nodes of the CPU architecture
+	// only. The images are checked to support the platform when image
+	// digests are pinned.
+	// +optional
+	Architecture ElasticsearchDataSetArchitecture `json:"architecture,omitempty"`
+
+	// AdditionalResourceLabels are added to the StatefulSet, pods,
+	// Service and PodDisruptionBudget of the EDS. Changes are applied to
+	// the existing pods without recreating them. Labels of the label
+	// selector can't be overridden.
+	// +optional
+	AdditionalResourceLabels map[string]string `json:"additionalResourceLabels,omitempty"`
+
+	// AdditionalResourceAnnotations are added to the StatefulSet, pods,
+	// Service and PodDisruptionBudget of the EDS. Changes are applied to
+	// the existing pods without recreating them.
+	// +optional
+	AdditionalResourceAnnotations map[string]string `json:"additionalResourceAnnotations,omitempty"`
+
+	// PodSecurity makes the pods comply with a Pod Security Standard
+	// profile and configures the AppArmor, seccomp and SELinux profiles
+	// of the Elasticsearch container.
+	// +optional
+	PodSecurity *ElasticsearchDataSetPodSecurity `json:"podSecurity,omitempty"`
+
+	// Lifecycle coordinates the termination of the containers of the
+	// pods with the operator.
+	// +optional
+	Lifecycle *ElasticsearchDataSetLifecycle `json:"lifecycle,omitempty"`
+
+	// TerminationGracePeriod sizes the termination grace period of pods
+	// deleted by the operator based on the data they hold.
+	// +optional
+	TerminationGracePeriod *ElasticsearchDataSetTerminationGracePeriod `json:"terminationGracePeriod,omitempty"`
+
+	// Health configures the health check used for pod readiness and to
+	// gate operations of the operator.
+	// +optional
+	Health *ElasticsearchDataSetHealth `json:"health,omitempty"`
+
+	// CriticalIndices are index patterns whose health must be green before
+	// and while pods are drained. A drain is aborted and the pod is
+	// included in the shard allocation again if they degrade.
+	// +optional
+	CriticalIndices []string `json:"criticalIndices,omitempty"`
+
+	// TrafficSteering removes pods from the endpoints of Services with a
+	// readiness gate before they are deleted, such that in-flight
+	// requests of clients complete, and optionally adds restarted pods
+	// only once they are warm.
+	// +optional
+	TrafficSteering *ElasticsearchDataSetTrafficSteering `json:"trafficSteering,omitempty"`
+
+	// DrainWriteBlock blocks writes to indices while their last primaries
+	// relocate off a drained pod, for a bounded duration.
+	// +optional
+	DrainWriteBlock *ElasticsearchDataSetDrainWriteBlock `json:"drainWriteBlock,omitempty"`
+
+	// MasterStability configures the monitoring of the elected master of
+	// the cluster. Pods are not drained while the master is unstable.
+	// +optional
+	MasterStability *ElasticsearchDataSetMasterStability `json:"masterStability,omitempty"`
+
+	// BackupHooks configures Velero backup hooks which flush the indices
+	// before the volumes of the pods are snapshotted. Scaling and draining
+	// are paused while a Velero backup of the namespace is in progress.
+	// +optional
+	BackupHooks *ElasticsearchDataSetBackupHooks `json:"backupHooks,omitempty"`
+
+	// VolumeSnapshotRecovery configures creating the volumes of new pods
+	// from a recent VolumeSnapshot of another pod on scale-up, so
+	// Elasticsearch only needs to recover the changes since the snapshot.
+	// +optional
+	VolumeSnapshotRecovery *ElasticsearchDataSetVolumeSnapshotRecovery `json:"volumeSnapshotRecovery,omitempty"`
+
+	// SnapshotPeerRecovery configures a snapshot repository used for peer
+	// recoveries, so new nodes recover most of their data from the
+	// repository instead of from other nodes.
+	// +optional
+	SnapshotPeerRecovery *ElasticsearchDataSetSnapshotPeerRecovery `json:"snapshotPeerRecovery,omitempty"`
+
+	// ZoneFailure configures the handling of the loss of an availability
+	// zone. Replacement pods are added while a zone is lost.
+	// +optional
+	ZoneFailure *ElasticsearchDataSetZoneFailure `json:"zoneFailure,omitempty"`
+
+	// Indices are the index patterns hosted by the EDS. Matching indices
+	// are allocated to the nodes of the EDS with
+	// 'index.routing.allocation.include.group' set to the 'group' label of
+	// the EDS once its first pod is ready.
+	// +optional
+	Indices []string `json:"indices,omitempty"`
+
+	// Decommission configures migrating all indices off the EDS before it's
+	// deleted.
+	// +optional
+	Decommission *ElasticsearchDataSetDecommission `json:"decommission,omitempty"`
+
+	// Rebalancing configures moving indices between the groups of a
+	// rebalancing pool to equalize their CPU usage.
+	// +optional
+	Rebalancing *ElasticsearchDataSetRebalancing `json:"rebalancing,omitempty"`
+
+	// Experimental represents configurations marked as experimental that may change in future releases.
+	// Currently, manages the draining behavior.
+	// +optional
+	Experimental *ExperimentalSpec `json:"experimental,omitempty"`
 }
 
-// EmbeddedObject defines the metadata which can be attached
-// to a resource. It's a slimmed down version of metav1.ObjectMeta only
-// containing labels and annotations.
+// ElasticsearchDataSetPodServices configures the Services created for each
+// pod of the EDS, named after the pod.
 // +k8s:deepcopy-gen=true
-type EmbeddedObjectMeta struct {
-	// Map of string keys and values that can be used to organize and categorize
-	// (scope and select) objects. May match selectors of replication controllers
-	// and services.
-	// More info: http://kubernetes.io/docs/user-guide/labels
+type ElasticsearchDataSetPodServices struct {
+	// Type of the Services. Defaults to ClusterIP.
+	// +kubebuilder:validation:Enum=ClusterIP;NodePort;LoadBalancer
 	// +optional
-	Labels map[string]string `json:"labels,omitempty" protobuf:"bytes,11,rep,name=labels"`
+	Type v1.ServiceType `json:"type,omitempty"`
 
-	// Annotations is an unstructured key value map stored with a resource that may be
-	// set by external tools to store and retrieve arbitrary metadata. They are not
-	// queryable and should be preserved when modifying objects.
-	// More info: http://kubernetes.io/docs/user-guide/annotations
+	// PublishNotReadyAddresses keeps the pods in the endpoints of their
+	// Services while they aren't ready, e.g. for remote cluster seeds
+	// connecting to the transport port.
 	// +optional
-	Annotations map[string]string `json:"annotations,omitempty" protobuf:"bytes,12,rep,name=annotations"`
+	PublishNotReadyAddresses bool `json:"publishNotReadyAddresses,omitempty"`
+
+	// Annotations are added to the Services, e.g. to configure load
+	// balancers.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
 }
 
-// PodTemplateSpec describes the data a pod should have when created from a template
+// ElasticsearchDataSetExternalDNS configures the DNS record with the
+// addresses of the pods, published via a DNSEndpoint resource of
+// external-dns.
 // +k8s:deepcopy-gen=true
-type PodTemplateSpec struct {
-	// Object's metadata.
+type ElasticsearchDataSetExternalDNS struct {
+	// Hostname is the name of the A record. Defaults to
+	// '<name>.<namespace>.<zone>' with the zone configured for the
+	// operator by --external-dns-zone.
 	// +optional
-	EmbeddedObjectMeta `json:"metadata,omitempty" protobuf:"bytes,1,opt,name=metadata"`
+	Hostname string `json:"hostname,omitempty"`
 
-	// Specification of the desired behavior of the pod.
-	// More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#spec-and-status
+	// RecordTTLSeconds is the TTL of the record. Defaults to 30.
+	// +kubebuilder:validation:Minimum=1
 	// +optional
-	Spec v1.PodSpec `json:"spec,omitempty" protobuf:"bytes,2,opt,name=spec"`
+	RecordTTLSeconds int64 `json:"recordTTLSeconds,omitempty"`
 }
 
-// ElasticsearchDataSetScaling is the scaling section of the ElasticsearchDataSet
-// resource.
+// ElasticsearchDataSetMemoryAdjustment configures the automatic increase of
+// memory requests and limits of OOMKilled pods.
 // +k8s:deepcopy-gen=true
-type ElasticsearchDataSetScaling struct {
+type ElasticsearchDataSetMemoryAdjustment struct {
+	// Enabled enables the automatic memory adjustment.
 	// +optional
 	Enabled bool `json:"enabled"`
-	// +kubebuilder:validation:Minimum=0
+
+	// ContainerName is the name of the container to adjust. Defaults to
+	// 'elasticsearch'.
 	// +optional
-	MinReplicas int32 `json:"minReplicas"`
-	// +kubebuilder:validation:Minimum=0
+	ContainerName string `json:"containerName,omitempty"`
+
+	// OOMKillThreshold is the number of OOM kills after which the memory
+	// is increased. Defaults to 3.
+	// +kubebuilder:validation:Minimum=1
 	// +optional
-	MaxReplicas int32 `json:"maxReplicas"`
-	// +kubebuilder:validation:Minimum=0
+	OOMKillThreshold int32 `json:"oomKillThreshold,omitempty"`
+
+	// Step is the amount of memory added to the requests and limits on
+	// each adjustment.
+	Step resource.Quantity `json:"step"`
+
+	// Max is the upper bound for the memory of the container.
+	Max resource.Quantity `json:"max"`
+}
+
+// ElasticsearchDataSetPodSecurity configures the Pod Security Standard
+// profile the pods comply with and the security profiles of the
+// Elasticsearch container.
+// +k8s:deepcopy-gen=true
+type ElasticsearchDataSetPodSecurity struct {
+	// Profile is the Pod Security Standard profile. With 'restricted' the
+	// containers run as non-root with a read-only root filesystem, all
+	// capabilities dropped and the RuntimeDefault seccomp profile, unless
+	// set differently in the pod template.
+	// +kubebuilder:validation:Enum=restricted
 	// +optional
-	MinIndexReplicas int32 `json:"minIndexReplicas"`
-	// +kubebuilder:validation:Minimum=0
+	Profile string `json:"profile,omitempty"`
+
+	// AppArmorProfile of the Elasticsearch container. It's set as field
+	// of the security context instead of the deprecated annotation.
 	// +optional
-	MaxIndexReplicas int32 `json:"maxIndexReplicas"`
-	// +kubebuilder:validation:Minimum=1
+	AppArmorProfile *v1.AppArmorProfile `json:"appArmorProfile,omitempty"`
+
+	// SeccompProfile of the Elasticsearch container.
 	// +optional
-	MinShardsPerNode int32 `json:"minShardsPerNode"`
-	// +kubebuilder:validation:Minimum=1
+	SeccompProfile *v1.SeccompProfile `json:"seccompProfile,omitempty"`
+
+	// SELinuxOptions of the Elasticsearch container.
 	// +optional
-	MaxShardsPerNode int32 `json:"maxShardsPerNode"`
+	SELinuxOptions *v1.SELinuxOptions `json:"seLinuxOptions,omitempty"`
+}
+
+// ElasticsearchDataSetTrafficSteering configures the readiness gate
+// steering client traffic away from pods before they are deleted and
+// towards them once they are warm.
+// +k8s:deepcopy-gen=true
+type ElasticsearchDataSetTrafficSteering struct {
+	// DrainDelaySeconds is the time between removing a pod from the
+	// endpoints of Services and deleting it.
 	// +kubebuilder:validation:Minimum=0
 	// +optional
-	ScaleUpCPUBoundary int32 `json:"scaleUpCPUBoundary"`
-	// +kubebuilder:validation:Minimum=0
+	DrainDelaySeconds int64 `json:"drainDelaySeconds,omitempty"`
+
+	// SlowStart delays adding restarted pods to the endpoints of Services
+	// until their caches are warm.
 	// +optional
-	ScaleUpThresholdDurationSeconds int64 `json:"scaleUpThresholdDurationSeconds"`
+	SlowStart *ElasticsearchDataSetSlowStart `json:"slowStart,omitempty"`
+}
+
+// ElasticsearchDataSetSlowStart configures when pods which (re)started are
+// added to the endpoints of Services again.
+// +k8s:deepcopy-gen=true
+type ElasticsearchDataSetSlowStart struct {
+	// DurationSeconds is the minimum time the containers of a pod are
+	// ready before it's added to the endpoints.
 	// +kubebuilder:validation:Minimum=0
 	// +optional
-	ScaleUpCooldownSeconds int64 `json:"scaleUpCooldownSeconds"`
-	// +kubebuilder:validation:Minimum=0
+	DurationSeconds int64 `json:"durationSeconds,omitempty"`
+
+	// WarmupQueries are sent to the pod once the duration passed. The pod
+	// is added to the endpoints once all of them succeeded.
 	// +optional
-	ScaleDownCPUBoundary int32 `json:"scaleDownCPUBoundary"`
-	// +kubebuilder:validation:Minimum=0
+	WarmupQueries []ElasticsearchDataSetWarmupQuery `json:"warmupQueries,omitempty"`
+}
+
+// ElasticsearchDataSetWarmupQuery is a search warming the caches of a pod.
+// +k8s:deepcopy-gen=true
+type ElasticsearchDataSetWarmupQuery struct {
+	// Index is the index pattern searched.
+	Index string `json:"index"`
+
+	// Body is the JSON body of the search request.
 	// +optional
-	ScaleDownThresholdDurationSeconds int64 `json:"scaleDownThresholdDurationSeconds"`
-	// +kubebuilder:validation:Minimum=0
+	Body string `json:"body,omitempty"`
+}
+
+// ElasticsearchDataSetDrainWriteBlock configures the index.blocks.write
+// setting applied during the riskiest phase of a drain, when the last
+// primaries relocate off the pod.
+// +k8s:deepcopy-gen=true
+type ElasticsearchDataSetDrainWriteBlock struct {
+	// IndexPatterns are the patterns of the indices whose writes are
+	// blocked.
+	// +kubebuilder:validation:MinItems=1
+	IndexPatterns []string `json:"indexPatterns"`
+
+	// MaxPrimaries is the number of primaries of the matching indices
+	// left on the pod from which on writes are blocked. Defaults to 1.
+	// +kubebuilder:validation:Minimum=1
 	// +optional
-	ScaleDownCooldownSeconds int64 `json:"scaleDownCooldownSeconds"`
-	// +kubebuilder:validation:Minimum=0
-	// +kubebuilder:validation:Maximum=100
+	MaxPrimaries int32 `json:"maxPrimaries,omitempty"`
+
+	// MaxDurationSeconds is the maximum time writes are blocked during a
+	// drain. Defaults to 300.
+	// +kubebuilder:validation:Minimum=1
 	// +optional
-	DiskUsagePercentScaledownWatermark int32 `json:"diskUsagePercentScaledownWatermark"`
+	MaxDurationSeconds int64 `json:"maxDurationSeconds,omitempty"`
 }
 
-// ElasticsearchDataSetStatus is the status section of the ElasticsearchDataSet
-// resource.
+// ElasticsearchDataSetHeapDumps configures the collection of heap dumps
+// written on an OutOfMemoryError of the JVM and of fatal error logs.
 // +k8s:deepcopy-gen=true
-type ElasticsearchDataSetStatus struct {
-	// observedGeneration is the most recent generation observed for this
-	// ElasticsearchDataSet. It corresponds to the ElasticsearchDataSets
-	// generation, which is updated on mutation by the API Server.
+type ElasticsearchDataSetHeapDumps struct {
+	// Enabled enables writing heap dumps to a volume of the pod.
 	// +optional
-	ObservedGeneration *int64 `json:"observedGeneration,omitempty" protobuf:"varint,1,opt,name=observedGeneration"`
-	// Replicas is the number of Pods by the underlying StatefulSet.
-	Replicas int32 `json:"replicas" protobuf:"varint,2,opt,name=replicas"`
+	Enabled bool `json:"enabled"`
 
-	LastScaleUpStarted   *metav1.Time `json:"lastScaleUpStarted,omitempty"`
-	LastScaleUpEnded     *metav1.Time `json:"lastScaleUpEnded,omitempty"`
-	LastScaleDownStarted *metav1.Time `json:"lastScaleDownStarted,omitempty"`
-	LastScaleDownEnded   *metav1.Time `json:"lastScaleDownEnded,omitempty"`
-}
+	// SizeLimit is the size limit of the volume. Should be larger than
+	// the heap.
+	// +optional
+	SizeLimit *resource.Quantity `json:"sizeLimit,omitempty"`
 
-// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+	// Upload configures a sidecar uploading the heap dumps and removing
+	// them from the volume.
+	// +optional
+	Upload *ElasticsearchDataSetHeapDumpUpload `json:"upload,omitempty"`
+}
 
-// ElasticsearchDataSetList is a list of ElasticsearchDataSets.
+// ElasticsearchDataSetDiskPreparation configures the init container preparing
+// the data volume of the pods.
 // +k8s:deepcopy-gen=true
-type ElasticsearchDataSetList struct {
-	metav1.TypeMeta `json:",inline"`
-	metav1.ListMeta `json:"metadata,omitempty"`
+type ElasticsearchDataSetDiskPreparation struct {
+	// Enabled enables the init container.
+	// +optional
+	Enabled bool `json:"enabled"`
 
-	Items []ElasticsearchDataSet `json:"items"`
-}
+	// MountPath is the path the data volume is mounted at in the
+	// Elasticsearch container. Defaults to
+	// '/usr/share/elasticsearch/data'.
+	// +optional
+	MountPath string `json:"mountPath,omitempty"`
 
-// +genclient
-// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+	// PreTouchSize is the amount of data written to a fresh volume and
+	// removed again, so the first writes of Elasticsearch don't pay for
+	// lazily allocated or restored blocks. The volume is pre-touched only
+	// once. Disabled if not set.
+	// +optional
+	PreTouchSize *resource.Quantity `json:"preTouchSize,omitempty"`
 
-// ElasticsearchMetricSet is the metrics holding section of the ElasticsearchDataSet
-// resource.
-// +k8s:deepcopy-gen=true
-type ElasticsearchMetricSet struct {
-	metav1.TypeMeta   `json:",inline"`
-	metav1.ObjectMeta `json:"metadata,omitempty"`
-	Metrics           []ElasticsearchMetric `json:"metrics"`
+	// Noatime is the check of the 'noatime' mount option of the data
+	// volume: 'warn' logs volumes mounted without it, 'require' fails the
+	// pod. Defaults to 'warn'.
+	// +kubebuilder:validation:Enum=warn;require;skip
+	// +optional
+	Noatime string `json:"noatime,omitempty"`
 }
 
-// ElasticsearchMetric is the single metric sample of the ElasticsearchDataSet
-// resource.
+// ElasticsearchDataSetSchedulingGate configures the scheduling gate of the
+// operator.
 // +k8s:deepcopy-gen=true
-type ElasticsearchMetric struct {
-	Timestamp metav1.Time `json:"timestamp"`
-	Value     int32       `json:"value"`
-}
+type ElasticsearchDataSetSchedulingGate struct {
+	// Enabled adds the scheduling gate to the pods.
+	// +optional
+	Enabled bool `json:"enabled"`
 
-// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+	// MinHealth is the cluster health status at which pods are released
+	// to the scheduler. Defaults to green.
+	// +kubebuilder:validation:Enum=green;yellow
+	// +optional
+	MinHealth string `json:"minHealth,omitempty"`
 
-// ElasticsearchDataSetList is a list of ElasticsearchDataSets.
+	// TimeoutSeconds is the time after the creation of a pod after which
+	// it's released regardless of the cluster health. Defaults to 600.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	TimeoutSeconds int32 `json:"timeoutSeconds,omitempty"`
+}
+
+// ElasticsearchDataSetVolumeClaimRetention configures what happens to the
+// volume claims of pods removed by scale-downs.
+// +kubebuilder:validation:XValidation:rule="!has(self.retentionDays) || self.whenScaled == 'Retain'",message="retentionDays requires whenScaled Retain"
 // +k8s:deepcopy-gen=true
-type ElasticsearchMetricSetList struct {
+type ElasticsearchDataSetVolumeClaimRetention struct {
+	// WhenScaled is Delete to delete the claims of removed pods, or Retain
+	// to keep them for a later scale-up.
+	// +kubebuilder:validation:Enum=Retain;Delete
+	WhenScaled string `json:"whenScaled"`
+
+	// RetentionDays deletes retained claims once they weren't used for
+	// this number of days. Retained claims are kept forever if not set.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	RetentionDays *int32 `json:"retentionDays,omitempty"`
+}
+
+// ElasticsearchDataSetVolumeExpansion configures the expansion of the volume
+// claims of the pods driven by their disk usage.
+// +kubebuilder:validation:XValidation:rule="!has(self.enabled) || !self.enabled || has(self.maxSize)",message="maxSize is required"
+// +k8s:deepcopy-gen=true
+type ElasticsearchDataSetVolumeExpansion struct {
+	// Enabled expands the volume claims of the pods.
+	// +optional
+	Enabled bool `json:"enabled"`
+
+	// VolumeClaimTemplate is the name of the volume claim template of the
+	// data volume. Defaults to the first volume claim template.
+	// +optional
+	VolumeClaimTemplate string `json:"volumeClaimTemplate,omitempty"`
+
+	// UsedPercentThreshold is the disk usage in percent, as reported by
+	// Elasticsearch, from which on a volume is expanded. Defaults to 80,
+	// below the default low disk watermark.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=99
+	// +optional
+	UsedPercentThreshold int32 `json:"usedPercentThreshold,omitempty"`
+
+	// Step is the size added to a volume on each expansion. Defaults to
+	// 10Gi.
+	// +optional
+	Step *resource.Quantity `json:"step,omitempty"`
+
+	// MaxSize is the size volumes aren't expanded beyond.
+	// +optional
+	MaxSize *resource.Quantity `json:"maxSize,omitempty"`
+}
+
+// ElasticsearchDataSetTLS configures the HTTPS connections of the operator
+// to Elasticsearch. The CA bundle and the client certificate are read from
+// the 'ca.crt', 'tls.crt' and 'tls.key' keys of a Secret in the namespace of
+// the EDS. Changes of the Secret are picked up within a minute.
+// +k8s:deepcopy-gen=true
+type ElasticsearchDataSetTLS struct {
+	// SecretName is the name of the Secret. Without 'ca.crt' the server
+	// certificate is verified with the system roots, without 'tls.crt' and
+	// 'tls.key' no client certificate is sent.
+	// +kubebuilder:validation:MinLength=1
+	SecretName string `json:"secretName"`
+
+	// ServerName is the name the server certificate is verified against.
+	// Defaults to the host of the Elasticsearch endpoint.
+	// +optional
+	ServerName string `json:"serverName,omitempty"`
+}
+
+// ElasticsearchDataSetElasticsearch configures the requests of the operator
+// to Elasticsearch.
+// +k8s:deepcopy-gen=true
+type ElasticsearchDataSetElasticsearch struct {
+	// CredentialsSecretRef references a Secret in the namespace of the EDS
+	// authenticating the operator. An 'apiKey' key, the encoded API key,
+	// is sent as API key, otherwise the 'username' and 'password' keys are
+	// sent with basic auth.
+	// +optional
+	CredentialsSecretRef *v1.LocalObjectReference `json:"credentialsSecretRef,omitempty"`
+
+	// Distribution is the distribution of the cluster, 'elasticsearch' or
+	// 'opensearch'. With 'opensearch' the operator uses the APIs of
+	// OpenSearch, e.g. ISM instead of ILM, and never the node shutdown API.
+	// Detected from the version of the cluster if not set.
+	// +kubebuilder:validation:Enum=elasticsearch;opensearch
+	// +optional
+	Distribution string `json:"distribution,omitempty"`
+}
+
+// ElasticsearchDataSetHeapDumpUpload configures the upload of heap dumps to
+// S3 or GCS. Dumps are uploaded to '<url>/<namespace>/<eds>/<pod>/' and
+// tagged with the namespace, EDS and pod.
+// +k8s:deepcopy-gen=true
+type ElasticsearchDataSetHeapDumpUpload struct {
+	// URL is the bucket and prefix to upload to, e.g.
+	// 's3://bucket/heap-dumps' or 'gs://bucket/heap-dumps'.
+	// +kubebuilder:validation:Pattern=`^(s3|gs)://.+`
+	URL string `json:"url"`
+
+	// Image of the uploader with the AWS CLI for S3 or gsutil for GCS.
+	Image string `json:"image"`
+
+	// SecretName is the name of a Secret with the credentials exposed as
+	// environment variables to the uploader. Not needed with workload
+	// identities.
+	// +optional
+	SecretName string `json:"secretName,omitempty"`
+}
+
+// ElasticsearchDataSetGCRecycling configures the recycling of pods suffering
+// from GC pressure. Pods are recycled by draining and deleting them, one at a
+// time.
+// +k8s:deepcopy-gen=true
+type ElasticsearchDataSetGCRecycling struct {
+	// Enabled enables recycling of pods under GC pressure.
+	// +optional
+	Enabled bool `json:"enabled"`
+
+	// OldGenUsedPercentThreshold is the old generation heap usage in
+	// percent above which a pod is considered under GC pressure. Defaults
+	// to 85.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	OldGenUsedPercentThreshold int32 `json:"oldGenUsedPercentThreshold,omitempty"`
+
+	// ThresholdDurationSeconds is the duration the old generation heap
+	// usage must be above the threshold before the pod is recycled.
+	// Defaults to 600.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	ThresholdDurationSeconds int64 `json:"thresholdDurationSeconds,omitempty"`
+}
+
+// ElasticsearchDataSetPort is an additional port of the EDS pods and
+// service.
+// +k8s:deepcopy-gen=true
+type ElasticsearchDataSetPort struct {
+	// Name is the name of the container and service port.
+	// +kubebuilder:validation:MaxLength=15
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Name string `json:"name"`
+
+	// ContainerPort is the port the container listens on.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	ContainerPort int32 `json:"containerPort"`
+
+	// ServicePort is the port exposed by the EDS service. Defaults to
+	// the container port.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	ServicePort int32 `json:"servicePort,omitempty"`
+
+	// Protocol is the protocol of the port. Defaults to TCP.
+	// +kubebuilder:validation:Enum=TCP;UDP;SCTP
+	// +optional
+	Protocol v1.Protocol `json:"protocol,omitempty"`
+
+	// Container is the name of the container the port is added to.
+	// Defaults to the first container of the pod template.
+	// +optional
+	Container string `json:"container,omitempty"`
+}
+
+// ElasticsearchDataSetLifecycle configures the coordinated termination of
+// the containers of the EDS pods.
+// +k8s:deepcopy-gen=true
+type ElasticsearchDataSetLifecycle struct {
+	// CoordinatedTermination adds preStop hooks to the containers, so the
+	// Elasticsearch container only receives SIGTERM after the operator
+	// drained the pod and sidecars terminate after the Elasticsearch
+	// container. The containers must provide /bin/sh.
+	// +optional
+	CoordinatedTermination bool `json:"coordinatedTermination"`
+
+	// Container is the name of the Elasticsearch container. Defaults to
+	// 'elasticsearch'.
+	// +optional
+	Container string `json:"container,omitempty"`
+
+	// DrainTimeoutSeconds is the maximum time the Elasticsearch container
+	// waits for the operator to drain the pod before it's terminated
+	// anyway. Defaults to 300.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	DrainTimeoutSeconds int64 `json:"drainTimeoutSeconds,omitempty"`
+
+	// ShutdownSeconds is the time given to Elasticsearch to shut down
+	// after SIGTERM before the sidecars are terminated. Defaults to 60.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	ShutdownSeconds int64 `json:"shutdownSeconds,omitempty"`
+}
+
+// ElasticsearchDataSetTerminationGracePeriod configures the termination
+// grace period of pods deleted by the operator. The grace period is
+// MinSeconds plus SecondsPerShard for each shard and SecondsPerGiB for each
+// GiB of data on the node, bounded by MaxSeconds.
+// +k8s:deepcopy-gen=true
+// +kubebuilder:validation:XValidation:rule="self.minSeconds <= self.maxSeconds",message="minSeconds can't be greater than maxSeconds"
+type ElasticsearchDataSetTerminationGracePeriod struct {
+	// MinSeconds is the minimum grace period.
+	// +kubebuilder:validation:Minimum=0
+	MinSeconds int64 `json:"minSeconds"`
+
+	// MaxSeconds is the maximum grace period.
+	// +kubebuilder:validation:Minimum=0
+	MaxSeconds int64 `json:"maxSeconds"`
+
+	// SecondsPerShard is added for each shard on the node.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	SecondsPerShard int64 `json:"secondsPerShard,omitempty"`
+
+	// SecondsPerGiB is added for each GiB of data on the node.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	SecondsPerGiB int64 `json:"secondsPerGiB,omitempty"`
+}
+
+// ElasticsearchDataSetArchitecture is the CPU architecture of the nodes
+// running the pods of an EDS, as in the 'kubernetes.io/arch' node label.
+// +kubebuilder:validation:Enum=amd64;arm64
+type ElasticsearchDataSetArchitecture string
+
+const (
+	// ArchitectureAMD64 schedules the pods on x86-64 nodes.
+	ArchitectureAMD64 ElasticsearchDataSetArchitecture = "amd64"
+	// ArchitectureARM64 schedules the pods on 64-bit ARM nodes.
+	ArchitectureARM64 ElasticsearchDataSetArchitecture = "arm64"
+)
+
+// ElasticsearchDataSetRole is the role of the Elasticsearch nodes of an EDS.
+// +kubebuilder:validation:Enum=data;master
+type ElasticsearchDataSetRole string
+
+const (
+	// RoleData are data nodes, which are drained of their shards.
+	RoleData ElasticsearchDataSetRole = "data"
+	// RoleMaster are dedicated master-eligible nodes.
+	RoleMaster ElasticsearchDataSetRole = "master"
+)
+
+// ElasticsearchDataSetHealthSource is the source of the health check.
+// +kubebuilder:validation:Enum=cluster;local;index
+type ElasticsearchDataSetHealthSource string
+
+const (
+	// HealthSourceCluster checks the health of the cluster as seen by the
+	// elected master.
+	HealthSourceCluster ElasticsearchDataSetHealthSource = "cluster"
+	// HealthSourceLocal checks the health of the cluster as seen by the
+	// queried node, without involving the master.
+	HealthSourceLocal ElasticsearchDataSetHealthSource = "local"
+	// HealthSourceIndex checks the health of a single index.
+	HealthSourceIndex ElasticsearchDataSetHealthSource = "index"
+)
+
+// ElasticsearchDataSetHealth configures the health check of an
+// ElasticsearchDataSet.
+// +k8s:deepcopy-gen=true
+// +kubebuilder:validation:XValidation:rule="!has(self.source) || self.source != 'index' || (has(self.index) && size(self.index) > 0)",message="index is required with the 'index' source"
+type ElasticsearchDataSetHealth struct {
+	// Source is the source of the health check. One of 'cluster', 'local'
+	// or 'index'. Defaults to 'cluster'.
+	// +optional
+	Source ElasticsearchDataSetHealthSource `json:"source,omitempty"`
+
+	// Index is the index or index pattern to check with the 'index'
+	// source.
+	// +optional
+	Index string `json:"index,omitempty"`
+
+	// ReadinessProbe adds a readiness probe based on the health check to
+	// the Elasticsearch container, unless it already has one.
+	// +optional
+	ReadinessProbe bool `json:"readinessProbe,omitempty"`
+}
+
+// ElasticsearchDataSetBackupHooks configures the Velero backup hooks of an
+// ElasticsearchDataSet.
+// +k8s:deepcopy-gen=true
+type ElasticsearchDataSetBackupHooks struct {
+	// Enabled enables the backup hooks.
+	// +optional
+	Enabled bool `json:"enabled"`
+
+	// Container is the name of the container running the hooks. Defaults
+	// to 'elasticsearch'.
+	// +optional
+	Container string `json:"container,omitempty"`
+
+	// TimeoutSeconds is the time the flush may take before the backup
+	// fails. Defaults to 60.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	TimeoutSeconds int32 `json:"timeoutSeconds,omitempty"`
+}
+
+// ElasticsearchDataSetVolumeSnapshotRecovery configures the creation of
+// volumes from VolumeSnapshots of other pods of an ElasticsearchDataSet.
+// +k8s:deepcopy-gen=true
+type ElasticsearchDataSetVolumeSnapshotRecovery struct {
+	// Enabled enables creating volumes from VolumeSnapshots.
+	// +optional
+	Enabled bool `json:"enabled"`
+
+	// MaxAgeSeconds is the maximum age of a VolumeSnapshot to be used.
+	// Defaults to 86400.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxAgeSeconds int64 `json:"maxAgeSeconds,omitempty"`
+}
+
+// ElasticsearchDataSetSnapshotPeerRecovery configures snapshot based peer
+// recoveries of an ElasticsearchDataSet.
+// +k8s:deepcopy-gen=true
+type ElasticsearchDataSetSnapshotPeerRecovery struct {
+	// Enabled enables snapshot based peer recoveries.
+	// +optional
+	Enabled bool `json:"enabled"`
+
+	// Repository is the name of the snapshot repository used for
+	// recoveries.
+	// +kubebuilder:validation:MinLength=1
+	Repository string `json:"repository"`
+
+	// Type is the type of the repository, e.g. 's3'. If set, the operator
+	// registers the repository if it doesn't exist.
+	// +optional
+	Type string `json:"type,omitempty"`
+
+	// Settings are the settings of the repository registered by the
+	// operator.
+	// +optional
+	Settings map[string]string `json:"settings,omitempty"`
+}
+
+// ElasticsearchDataSetZoneFailure configures the handling of the loss of an
+// availability zone. A zone is lost if all its nodes are NotReady.
+// +k8s:deepcopy-gen=true
+type ElasticsearchDataSetZoneFailure struct {
+	// Enabled enables the handling of zone failures.
+	// +optional
+	Enabled bool `json:"enabled"`
+
+	// ZoneLabel is the node label holding the zone. Defaults to
+	// 'topology.kubernetes.io/zone'.
+	// +optional
+	ZoneLabel string `json:"zoneLabel,omitempty"`
+
+	// AwarenessAttribute is the node attribute used for shard allocation
+	// awareness. If forced awareness is configured for it, the lost zone
+	// is removed from the forced values while it's lost, so replicas can
+	// be allocated in the remaining zones. Defaults to 'zone'.
+	// +optional
+	AwarenessAttribute string `json:"awarenessAttribute,omitempty"`
+}
+
+// ElasticsearchDataSetDecommission configures the decommissioning of an EDS.
+// The indices allocated to the 'group' label of the EDS are allocated to the
+// target groups and the EDS is protected by a finalizer until no shards are
+// left on its nodes.
+// +k8s:deepcopy-gen=true
+type ElasticsearchDataSetDecommission struct {
+	// Enabled starts the decommissioning. Auto-scaling of the EDS is
+	// suspended while it's enabled.
+	// +optional
+	Enabled bool `json:"enabled"`
+
+	// TargetGroups are the groups the indices are moved to. Indices are
+	// distributed over the groups.
+	// +kubebuilder:validation:MinItems=1
+	TargetGroups []string `json:"targetGroups"`
+
+	// IngestionPause pauses the ingestion into the write indices of an
+	// alias while they are moved to the target groups.
+	// +optional
+	IngestionPause *ElasticsearchDataSetIngestionPause `json:"ingestionPause,omitempty"`
+}
+
+// ElasticsearchDataSetIngestionPause configures pausing the ingestion into
+// the write indices of an alias during decommissioning. Ingestion is paused
+// right before the write indices are moved to the target groups and resumed
+// once none of their shards are left on the Pods, so log shippers retry
+// instead of writing to indices switching over between groups.
+// +k8s:deepcopy-gen=true
+type ElasticsearchDataSetIngestionPause struct {
+	// WriteAlias is the alias whose write indices are paused.
+	// +kubebuilder:validation:MinLength=1
+	WriteAlias string `json:"writeAlias"`
+
+	// Pipeline is an ingest pipeline replaced by one failing all documents
+	// while ingestion is paused, and restored once it's resumed.
+	// It must be the final pipeline of the write indices. If set, the
+	// pipeline is toggled instead of blocking writes to the write indices.
+	// +optional
+	Pipeline string `json:"pipeline,omitempty"`
+}
+
+// ElasticsearchDataSetRebalancing configures the rebalancing of indices
+// between EDS groups. The EDS of a pool are identified by the pool name and
+// their 'group' label, which must match the 'group' node attribute their
+// indices are allocated to with 'index.routing.allocation.include.group'.
+// +k8s:deepcopy-gen=true
+type ElasticsearchDataSetRebalancing struct {
+	// Enabled enables the rebalancing of the EDS with the other EDS of the
+	// pool.
+	// +optional
+	Enabled bool `json:"enabled"`
+
+	// Pool is the name of the rebalancing pool. Indices are only moved
+	// between EDS of the same pool and namespace.
+	// +kubebuilder:validation:MinLength=1
+	Pool string `json:"pool"`
+
+	// ThresholdPercent is the difference of the CPU usage of the busiest
+	// and the least busy group of the pool above which an index is moved.
+	// Defaults to 20.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	ThresholdPercent int32 `json:"thresholdPercent,omitempty"`
+
+	// CooldownSeconds is the minimum time between two index moves in the
+	// pool. Defaults to 1800.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	CooldownSeconds int64 `json:"cooldownSeconds,omitempty"`
+}
+
+// ElasticsearchDataSetMasterStability configures the monitoring of the
+// elected master. The master is unstable if no master is elected or if more
+// than MaxElections elections happened within the window.
+// +k8s:deepcopy-gen=true
+type ElasticsearchDataSetMasterStability struct {
+	// Enabled enables the monitoring of the elected master.
+	// +optional
+	Enabled bool `json:"enabled"`
+
+	// MaxElections is the maximum number of master elections within the
+	// window for the master to be considered stable. Defaults to 2.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxElections *int32 `json:"maxElections,omitempty"`
+
+	// WindowSeconds is the window in seconds in which master elections are
+	// counted. Defaults to 600.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	WindowSeconds int64 `json:"windowSeconds,omitempty"`
+}
+
+// ExperimentalSpec represents the configurations that might change in the future.
+// IMPORTANT: These fields might change in a none backward compatible manner.
+// +k8s:deepcopy-gen=true
+type ExperimentalSpec struct {
+	// Draining controls behaviour of the EDS while draining nodes
+	// +optional
+	Draining *ElasticsearchDataSetDraining `json:"draining,omitempty"`
+}
+
+// ElasticsearchDataSetDraining represents the configuration for draining nodes within an ElasticsearchDataSet.
+// +k8s:deepcopy-gen=true
+// +kubebuilder:validation:XValidation:rule="self.minimumWaitTimeDurationSeconds <= self.maximumWaitTimeDurationSeconds",message="minimumWaitTimeDurationSeconds can't be greater than maximumWaitTimeDurationSeconds"
+type ElasticsearchDataSetDraining struct {
+
+	// MaxRetries specifies the maximum number of attempts to drain a node. The default value is 999.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:default=999
+	MaxRetries int32 `json:"maxRetries"`
+
+	// MinimumWaitTimeDurationSeconds specifies the minimum wait time in seconds between retry attempts after a failed node drain.
+	// The default value is 10 seconds.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:default=10
+	MinimumWaitTimeDurationSeconds int64 `json:"minimumWaitTimeDurationSeconds"`
+
+	// MaximumWaitTimeDurationSeconds specifies the maximum wait time in seconds between retry attempts after a failed node drain.
+	// The default value is 30 seconds.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:default=30
+	MaximumWaitTimeDurationSeconds int64 `json:"maximumWaitTimeDurationSeconds"`
+
+	// NodeShutdown specifies whether nodes are drained using the node
+	// shutdown API on Elasticsearch 7.14 and later instead of allocation
+	// exclusions. It requires the GracefulShutdownAPI feature gate. The
+	// default value is false.
+	// +optional
+	NodeShutdown *bool `json:"nodeShutdown,omitempty"`
+
+	// TaskDeadlineSeconds is the time after which the long-running tasks
+	// on a node still being drained, e.g. reindex or force merge, are
+	// reported in events. Tasks aren't checked if not set.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	TaskDeadlineSeconds *int64 `json:"taskDeadlineSeconds,omitempty"`
+
+	// CancelTasks cancels the cancellable tasks reported after the task
+	// deadline.
+	// +optional
+	CancelTasks bool `json:"cancelTasks,omitempty"`
+
+	// MaxParallelDrains is the maximum number of pods drained at the same
+	// time when scaling down by more than one replica. Rolling updates
+	// still drain one pod at a time. The default value is 1.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxParallelDrains *int32 `json:"maxParallelDrains,omitempty"`
+}
+
+// PersistentVolumeClaim is a user's request for and claim to a persistent volume
+// +k8s:deepcopy-gen=true
+type PersistentVolumeClaim struct {
+	EmbeddedObjectMetaWithName `json:"metadata,omitempty" protobuf:"bytes,1,opt,name=metadata"`
+
+	// Spec defines the desired characteristics of a volume requested by a pod author.
+	// More info: https://kubernetes.io/docs/concepts/storage/persistent-volumes#persistentvolumeclaims
+	// +optional
+	Spec v1.PersistentVolumeClaimSpec `json:"spec,omitempty" protobuf:"bytes,2,opt,name=spec"`
+}
+
+// EmbeddedObjectWithName defines the metadata which can be attached
+// to a resource. It's a slimmed down version of metav1.ObjectMeta only
+// containing name, labels and annotations.
+// +k8s:deepcopy-gen=true
+type EmbeddedObjectMetaWithName struct {
+	// Name must be unique within a namespace. Is required when creating resources, although
+	// some resources may allow a client to request the generation of an appropriate name
+	// automatically. Name is primarily intended for creation idempotence and configuration
+	// definition.
+	// Cannot be updated.
+	// More info: http://kubernetes.io/docs/user-guide/identifiers#names
+	Name string `json:"name,omitempty" protobuf:"bytes,1,opt,name=name"`
+
+	// Map of string keys and values that can be used to organize and categorize
+	// (scope and select) objects. May match selectors of replication controllers
+	// and services.
+	// More info: http://kubernetes.io/docs/user-guide/labels
+	// +optional
+	Labels map[string]string `json:"labels,omitempty" protobuf:"bytes,11,rep,name=labels"`
+
+	// Annotations is an unstructured key value map stored with a resource that may be
+	// set by external tools to store and retrieve arbitrary metadata. They are not
+	// queryable and should be preserved when modifying objects.
+	// More info: http://kubernetes.io/docs/user-guide/annotations
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty" protobuf:"bytes,12,rep,name=annotations"`
+}
+
+// EmbeddedObject defines the metadata which can be attached
+// to a resource. It's a slimmed down version of metav1.ObjectMeta only
+// containing labels and annotations.
+// +k8s:deepcopy-gen=true
+type EmbeddedObjectMeta struct {
+	// Map of string keys and values that can be used to organize and categorize
+	// (scope and select) objects. May match selectors of replication controllers
+	// and services.
+	// More info: http://kubernetes.io/docs/user-guide/labels
+	// +optional
+	Labels map[string]string `json:"labels,omitempty" protobuf:"bytes,11,rep,name=labels"`
+
+	// Annotations is an unstructured key value map stored with a resource that may be
+	// set by external tools to store and retrieve arbitrary metadata. They are not
+	// queryable and should be preserved when modifying objects.
+	// More info: http://kubernetes.io/docs/user-guide/annotations
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty" protobuf:"bytes,12,rep,name=annotations"`
+}
+
+// PodTemplateSpec describes the data a pod should have when created from a template
+// +k8s:deepcopy-gen=true
+type PodTemplateSpec struct {
+	// Object's metadata.
+	// +optional
+	EmbeddedObjectMeta `json:"metadata,omitempty" protobuf:"bytes,1,opt,name=metadata"`
+
+	// Specification of the desired behavior of the pod.
+	// More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#spec-and-status
+	// +optional
+	Spec v1.PodSpec `json:"spec,omitempty" protobuf:"bytes,2,opt,name=spec"`
+}
+
+// ElasticsearchDataSetScaling is the scaling section of the ElasticsearchDataSet
+// resource. The consistency of the settings is only validated if scaling
+// is enabled.
+// +k8s:deepcopy-gen=true
+// +kubebuilder:validation:XValidation:rule="!has(self.enabled) || !self.enabled || (has(self.minReplicas) ? self.minReplicas : 0) <= (has(self.maxReplicas) ? self.maxReplicas : 0)",message="minReplicas can't be greater than maxReplicas"
+// +kubebuilder:validation:XValidation:rule="!has(self.enabled) || !self.enabled || (has(self.minIndexReplicas) ? self.minIndexReplicas : 0) <= (has(self.maxIndexReplicas) ? self.maxIndexReplicas : 0)",message="minIndexReplicas can't be greater than maxIndexReplicas"
+// +kubebuilder:validation:XValidation:rule="!has(self.enabled) || !self.enabled || (has(self.minShardsPerNode) ? self.minShardsPerNode : 0) <= (has(self.maxShardsPerNode) ? self.maxShardsPerNode : 0)",message="minShardsPerNode can't be greater than maxShardsPerNode"
+// +kubebuilder:validation:XValidation:rule="!has(self.enabled) || !self.enabled || (has(self.minReplicas) ? self.minReplicas : 0) >= (has(self.minIndexReplicas) ? self.minIndexReplicas : 0) + 1",message="minReplicas can not be less than minIndexReplicas+1"
+// +kubebuilder:validation:XValidation:rule="!has(self.enabled) || !self.enabled || (has(self.maxReplicas) ? self.maxReplicas : 0) >= (has(self.maxIndexReplicas) ? self.maxIndexReplicas : 0) + 1",message="maxReplicas can not be less than maxIndexReplicas+1"
+// +kubebuilder:validation:XValidation:rule="!has(self.enabled) || !self.enabled || (has(self.scaleDownCPUBoundary) ? self.scaleDownCPUBoundary : 0) <= (has(self.scaleUpCPUBoundary) ? self.scaleUpCPUBoundary : 0)",message="scaleDownCPUBoundary can't be greater than scaleUpCPUBoundary"
+type ElasticsearchDataSetScaling struct {
+	// +optional
+	Enabled bool `json:"enabled"`
+	// Shadow computes the scaling decisions and exposes them as metrics
+	// without applying them, e.g. to compare them with another scaling
+	// mechanism which remains in charge of the replicas.
+	// +optional
+	Shadow bool `json:"shadow,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MinReplicas int32 `json:"minReplicas"`
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxReplicas int32 `json:"maxReplicas"`
+	// OverprovisionPercent keeps this percentage of the replicas needed
+	// according to the scaling settings as headroom on top, rounded up and
+	// bounded by maxReplicas, to absorb load spikes without waiting for a
+	// scale-up.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	OverprovisionPercent int32 `json:"overprovisionPercent,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MinIndexReplicas int32 `json:"minIndexReplicas"`
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxIndexReplicas int32 `json:"maxIndexReplicas"`
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MinShardsPerNode int32 `json:"minShardsPerNode"`
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxShardsPerNode int32 `json:"maxShardsPerNode"`
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	ScaleUpCPUBoundary int32 `json:"scaleUpCPUBoundary"`
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	ScaleUpThresholdDurationSeconds int64 `json:"scaleUpThresholdDurationSeconds"`
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	ScaleUpCooldownSeconds int64 `json:"scaleUpCooldownSeconds"`
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	ScaleDownCPUBoundary int32 `json:"scaleDownCPUBoundary"`
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	ScaleDownThresholdDurationSeconds int64 `json:"scaleDownThresholdDurationSeconds"`
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	ScaleDownCooldownSeconds int64 `json:"scaleDownCooldownSeconds"`
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	DiskUsagePercentScaledownWatermark int32 `json:"diskUsagePercentScaledownWatermark"`
+	// CaptureHotThreads captures the hot threads of the nodes when
+	// scaling up because of high CPU usage. The output is stored in the
+	// ConfigMap '<name>-hot-threads'.
+	// +optional
+	CaptureHotThreads bool `json:"captureHotThreads,omitempty"`
+	// ScaleDownGuards are CEL expressions which must all evaluate to true
+	// for a scale-down to proceed, e.g.
+	// 'shardsPerNode < 30.0 && diskUsedPercent < 70.0'.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	ScaleDownGuards []ElasticsearchDataSetScalingGuard `json:"scaleDownGuards,omitempty"`
+	// DecisionWebhook configures an external webhook which reviews the
+	// scaling decisions of the operator.
+	// +optional
+	DecisionWebhook *ElasticsearchDataSetDecisionWebhook `json:"decisionWebhook,omitempty"`
+	// Predictive configures scaling up ahead of recurring peaks of the CPU
+	// usage based on a seasonal baseline of the past CPU usage.
+	// +optional
+	Predictive *ElasticsearchDataSetPredictiveScaling `json:"predictive,omitempty"`
+	// HotShards configures detecting shards which dominate the load of a
+	// node.
+	// +optional
+	HotShards *ElasticsearchDataSetHotShards `json:"hotShards,omitempty"`
+	// Aggregation configures how the CPU usage of the Pods is aggregated
+	// into one sample. Defaults to the median.
+	// +optional
+	Aggregation *ElasticsearchDataSetCPUAggregation `json:"aggregation,omitempty"`
+	// ShardSize configures warnings about shards exceeding a maximum size,
+	// which make draining and recovering nodes slow.
+	// +optional
+	ShardSize *ElasticsearchDataSetShardSizeGuardrail `json:"shardSize,omitempty"`
+	// ClusterShardLimit configures how scale-downs exceeding the
+	// cluster.max_shards_per_node setting of the cluster are handled. They
+	// are blocked unless the setting may be raised far enough.
+	// +optional
+	ClusterShardLimit *ElasticsearchDataSetClusterShardLimit `json:"clusterShardLimit,omitempty"`
+	// DiskHeadroom blocks scale-downs which would fill the disks of the
+	// remaining nodes above the flood-stage watermark, projected from the
+	// sizes of the shards moved to them.
+	// +optional
+	DiskHeadroom *ElasticsearchDataSetDiskHeadroom `json:"diskHeadroom,omitempty"`
+	// ScaleDownRecovery scales back up if the cluster doesn't recover
+	// after the pods of a scale-down are removed.
+	// +optional
+	ScaleDownRecovery *ElasticsearchDataSetScaleDownRecovery `json:"scaleDownRecovery,omitempty"`
+}
+
+// ElasticsearchDataSetScaleDownRecovery configures the automatic undo of
+// scale-downs after which the cluster doesn't recover.
+// +k8s:deepcopy-gen=true
+type ElasticsearchDataSetScaleDownRecovery struct {
+	// WindowSeconds is how long the cluster may be red or have unassigned
+	// shards after the pods of a scale-down are removed before the
+	// scale-down is undone. It's also how long the cluster is monitored
+	// after the removal. Defaults to 600.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	WindowSeconds int64 `json:"windowSeconds,omitempty"`
+}
+
+// ElasticsearchDataSetDiskHeadroom configures the projection of the disk
+// usage of the remaining nodes before a scale-down.
+// +k8s:deepcopy-gen=true
+type ElasticsearchDataSetDiskHeadroom struct {
+	// HeadroomPercent is added to the projected disk usage of each node to
+	// account for uneven shard allocation, merges and growth. Defaults to
+	// 10.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	HeadroomPercent *int32 `json:"headroomPercent,omitempty"`
+}
+
+// ElasticsearchDataSetClusterShardLimit configures the management of the
+// cluster.max_shards_per_node setting.
+// +k8s:deepcopy-gen=true
+type ElasticsearchDataSetClusterShardLimit struct {
+	// MaxShardsPerNode is the maximum value the operator raises
+	// cluster.max_shards_per_node to for a scale-down. The previous value
+	// is restored once the shards fit into it again. If not set, such
+	// scale-downs are blocked.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxShardsPerNode int32 `json:"maxShardsPerNode,omitempty"`
+}
+
+// ElasticsearchDataSetShardSizeGuardrail configures the maximum size of the
+// primary shards of the indices of the EDS.
+// +k8s:deepcopy-gen=true
+type ElasticsearchDataSetShardSizeGuardrail struct {
+	// MaxShardSize is the maximum size of a shard, e.g. 50Gi.
+	MaxShardSize resource.Quantity `json:"maxShardSize"`
+	// BlockReplicaReduction prevents scale-downs reducing the replicas of
+	// indices with oversized shards, as the remaining copies take longer
+	// to recover.
+	// +optional
+	BlockReplicaReduction bool `json:"blockReplicaReduction,omitempty"`
+}
+
+// ElasticsearchDataSetHotShards configures the hot shard detection. A node is
+// hot if its CPU usage exceeds the median of the nodes of the EDS by the
+// threshold. A shard is hot if it served more than the dominance percentage
+// of the indexing and search operations of a hot node since the previous
+// check.
+// +k8s:deepcopy-gen=true
+type ElasticsearchDataSetHotShards struct {
+	// Enabled enables the hot shard detection.
+	// +optional
+	Enabled bool `json:"enabled"`
+	// NodeCPUThresholdPercent is the CPU usage of a hot node relative to
+	// the median CPU usage of the nodes. Defaults to 200.
+	// +kubebuilder:validation:Minimum=101
+	// +optional
+	NodeCPUThresholdPercent int32 `json:"nodeCPUThresholdPercent,omitempty"`
+	// DominancePercent is the share of the operations of a hot node served
+	// by a hot shard. Defaults to 50.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	DominancePercent int32 `json:"dominancePercent,omitempty"`
+	// Reroute moves a hot shard to the least busy node of the EDS instead
+	// of scaling out.
+	// +optional
+	Reroute bool `json:"reroute,omitempty"`
+}
+
+// ElasticsearchDataSetCPUAggregation configures the aggregation of the CPU
+// usage of the Pods. The trimmed mean ignores the Pods with the lowest and
+// highest CPU usage, so a single hot or idle node, e.g. due to a hot shard
+// or a noisy neighbor, doesn't scale the whole EDS.
+// +k8s:deepcopy-gen=true
+type ElasticsearchDataSetCPUAggregation struct {
+	// Method is the aggregation method. Defaults to Median.
+	// +kubebuilder:validation:Enum=Median;Mean;TrimmedMean
+	// +optional
+	Method string `json:"method,omitempty"`
+	// TrimPercent is the percentage of the Pods with the lowest and the
+	// highest CPU usage each ignored by the TrimmedMean method. Defaults to
+	// 10.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=49
+	// +optional
+	TrimPercent *int32 `json:"trimPercent,omitempty"`
+}
+
+// ElasticsearchDataSetPredictiveScaling configures predictive scaling. The
+// median CPU usage is recorded per hour of the week in the
+// ElasticsearchMetricSet. If the baseline predicts a CPU usage above the
+// scale-up boundary within the lookahead, the EDS is scaled up ahead of
+// time. It isn't scaled down while a CPU usage above the scale-down boundary
+// is predicted. Without a confident prediction the scaling is purely
+// reactive.
+// +k8s:deepcopy-gen=true
+type ElasticsearchDataSetPredictiveScaling struct {
+	// Enabled enables recording the baseline and predictive scaling.
+	// +optional
+	Enabled bool `json:"enabled"`
+	// LookaheadSeconds is how far ahead peaks are anticipated. Defaults
+	// to 1800.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=86400
+	// +optional
+	LookaheadSeconds int64 `json:"lookaheadSeconds,omitempty"`
+	// MinSamples is the number of samples an hour of the week must be
+	// based on before it's used for predictions. Defaults to 120.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MinSamples int32 `json:"minSamples,omitempty"`
+}
+
+// ElasticsearchDataSetDecisionWebhookFailurePolicy defines how failed calls
+// of the decision webhook are handled.
+// +kubebuilder:validation:Enum=Ignore;Fail
+type ElasticsearchDataSetDecisionWebhookFailurePolicy string
+
+const (
+	// DecisionWebhookFailurePolicyIgnore applies the decision of the
+	// operator if the webhook fails.
+	DecisionWebhookFailurePolicyIgnore ElasticsearchDataSetDecisionWebhookFailurePolicy = "Ignore"
+	// DecisionWebhookFailurePolicyFail doesn't scale if the webhook fails.
+	DecisionWebhookFailurePolicyFail ElasticsearchDataSetDecisionWebhookFailurePolicy = "Fail"
+)
+
+// ElasticsearchDataSetDecisionWebhook configures a webhook which is called
+// with the proposed scaling operation and the metrics it's based on. The
+// webhook can approve, veto or modify the operation.
+// +k8s:deepcopy-gen=true
+type ElasticsearchDataSetDecisionWebhook struct {
+	// URL is the http(s) URL the decisions are POSTed to.
+	// +kubebuilder:validation:Pattern=`^https?://`
+	URL string `json:"url"`
+	// TimeoutSeconds is the timeout of the webhook call. Defaults to 10.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=60
+	// +optional
+	TimeoutSeconds int32 `json:"timeoutSeconds,omitempty"`
+	// FailurePolicy defines how failed webhook calls are handled. One of
+	// 'Ignore' or 'Fail'. Defaults to 'Ignore'.
+	// +optional
+	FailurePolicy ElasticsearchDataSetDecisionWebhookFailurePolicy `json:"failurePolicy,omitempty"`
+}
+
+// ElasticsearchDataSetScalingGuard is a CEL expression guarding a scaling
+// operation. The expression is evaluated with the following variables:
+// replicas, targetReplicas, indices and shards (int), shardsPerNode,
+// targetShardsPerNode, diskUsedPercent and cpuUsagePercent (double).
+// +k8s:deepcopy-gen=true
+type ElasticsearchDataSetScalingGuard struct {
+	// Name identifies the guard in events and the scaling description.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+	// Expression is the CEL expression, which must evaluate to a bool.
+	// +kubebuilder:validation:MinLength=1
+	Expression string `json:"expression"`
+}
+
+// ElasticsearchDataSetStatus is the status section of the ElasticsearchDataSet
+// resource.
+// +k8s:deepcopy-gen=true
+type ElasticsearchDataSetStatus struct {
+	// observedGeneration is the most recent generation observed for this
+	// ElasticsearchDataSet. It corresponds to the ElasticsearchDataSets
+	// generation, which is updated on mutation by the API Server.
+	// +optional
+	ObservedGeneration *int64 `json:"observedGeneration,omitempty" protobuf:"varint,1,opt,name=observedGeneration"`
+	// Replicas is the number of Pods by the underlying StatefulSet.
+	Replicas int32 `json:"replicas" protobuf:"varint,2,opt,name=replicas"`
+	// ReadyReplicas is the number of ready Pods of the underlying
+	// StatefulSet.
+	// +optional
+	ReadyReplicas int32 `json:"readyReplicas,omitempty"`
+	// Selector is the label selector of the Pods of the underlying
+	// StatefulSet, as exposed by the scale subresource.
+	// +optional
+	Selector string `json:"selector,omitempty"`
+
+	LastScaleUpStarted   *metav1.Time `json:"lastScaleUpStarted,omitempty"`
+	LastScaleUpEnded     *metav1.Time `json:"lastScaleUpEnded,omitempty"`
+	LastScaleDownStarted *metav1.Time `json:"lastScaleDownStarted,omitempty"`
+	LastScaleDownEnded   *metav1.Time `json:"lastScaleDownEnded,omitempty"`
+
+	// OverprovisionedReplicas is the number of replicas above the replicas
+	// needed according to the scaling settings, kept as headroom because
+	// of the overprovisionPercent.
+	// +optional
+	OverprovisionedReplicas int32 `json:"overprovisionedReplicas,omitempty"`
+
+	// ScalingState is the bookkeeping of the autoscaler.
+	// +optional
+	ScalingState *ElasticsearchDataSetScalingState `json:"scalingState,omitempty"`
+
+	// ScaleDownRecovery is the scale-down monitored for recovery. Only set
+	// while it's monitored.
+	// +optional
+	ScaleDownRecovery *ElasticsearchDataSetScaleDownRecoveryStatus `json:"scaleDownRecovery,omitempty"`
+
+	// ResolvedImages are the digest pinned images, keyed by container name,
+	// which the pods of the underlying StatefulSet are running. Only set if
+	// the operator runs with image digest pinning enabled.
+	// +optional
+	ResolvedImages map[string]string `json:"resolvedImages,omitempty"`
+
+	// SchemaVersion is the version of the operator managed state of the
+	// ElasticsearchDataSet. Operators refuse to manage an
+	// ElasticsearchDataSet with a schema version newer than they support.
+	// +optional
+	SchemaVersion int32 `json:"schemaVersion,omitempty"`
+
+	// FailingPods are the pods of the ElasticsearchDataSet with containers
+	// which are crash looping or were killed, and pods which were evicted,
+	// can't be scheduled or run on a NotReady node.
+	// +optional
+	FailingPods []ElasticsearchDataSetPodFailure `json:"failingPods,omitempty"`
+
+	// OOMKills is the number of OOM kills of containers observed since
+	// the last memory adjustment.
+	// +optional
+	OOMKills int32 `json:"oomKills,omitempty"`
+
+	// LastOOMKill is the time of the last observed OOM kill.
+	// +optional
+	LastOOMKill *metav1.Time `json:"lastOOMKill,omitempty"`
+
+	// Master is the observed state of the elected master of the cluster.
+	// Only set if master stability monitoring is enabled.
+	// +optional
+	Master *ElasticsearchDataSetMasterStatus `json:"master,omitempty"`
+
+	// ZoneFailure describes a lost availability zone. Only set while a zone
+	// is lost.
+	// +optional
+	ZoneFailure *ElasticsearchDataSetZoneFailureStatus `json:"zoneFailure,omitempty"`
+
+	// AllocatedIndices are the index patterns of the spec whose indices
+	// were allocated to the nodes of the EDS.
+	// +optional
+	AllocatedIndices []string `json:"allocatedIndices,omitempty"`
+
+	// IngestionPauseBlockedIndices are the write indices blocked by the
+	// ingestion pause of the decommissioning. Only these are unblocked once
+	// ingestion is resumed.
+	// +optional
+	IngestionPauseBlockedIndices []string `json:"ingestionPauseBlockedIndices,omitempty"`
+
+	// Burst is the temporary scaling override requested with the
+	// 'es-operator.zalando.org/burst' annotation. It's kept after expiry
+	// until the annotation is removed.
+	// +optional
+	Burst *ElasticsearchDataSetBurstStatus `json:"burst,omitempty"`
+
+	// TemplateHistory are the latest revisions of the Pod template, oldest
+	// first. They can be rolled back to with the
+	// 'es-operator.zalando.org/undo' annotation.
+	// +optional
+	TemplateHistory []ElasticsearchDataSetTemplateRevision `json:"templateHistory,omitempty"`
+
+	// HotShard is the shard dominating the load of a hot node, if any.
+	// +optional
+	HotShard *ElasticsearchDataSetHotShardStatus `json:"hotShard,omitempty"`
+
+	// PrimariesRecommendations are the indices which need more primary
+	// shards to benefit from more nodes under sustained scale-up pressure.
+	// +optional
+	PrimariesRecommendations []ElasticsearchDataSetPrimariesRecommendation `json:"primariesRecommendations,omitempty"`
+
+	// ClusterShardLimit is the cluster.max_shards_per_node setting raised
+	// by the operator for a scale-down.
+	// +optional
+	ClusterShardLimit *ElasticsearchDataSetClusterShardLimitStatus `json:"clusterShardLimit,omitempty"`
+
+	// RolloutProgress is the progress of the rollout of the latest Pod
+	// template revision.
+	// +optional
+	RolloutProgress *ElasticsearchDataSetRolloutProgress `json:"rolloutProgress,omitempty"`
+
+	// Conditions are the latest observations of the state of the
+	// ElasticsearchDataSet.
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// Condition types of the ElasticsearchDataSet status. The types, their
+// reasons and the meaning of their status are a stable contract for tools
+// waiting on the state of an ElasticsearchDataSet, e.g. with
+// 'kubectl wait --for=condition=Ready'. The lastTransitionTime of a
+// condition only changes with its status, its observedGeneration is the
+// generation of the ElasticsearchDataSet it was computed for.
+const (
+	// ConditionReady is True once the latest generation is reconciled and
+	// the desired number of Pods are updated and ready.
+	ConditionReady = "Ready"
+	// ConditionProgressing is True while the ElasticsearchDataSet is
+	// reconciled, scaled or rolled out.
+	ConditionProgressing = "Progressing"
+	// ConditionDegraded is True while Pods are failing, a zone is lost or
+	// the latest rollout failed.
+	ConditionDegraded = "Degraded"
+	// ConditionIndicesAllocated is True once the indices of the spec are
+	// allocated to the nodes of the ElasticsearchDataSet. Only set if
+	// indices are configured.
+	ConditionIndicesAllocated = "IndicesAllocated"
+	// ConditionDecommissioned is True once no shards are left on the Pods
+	// of an ElasticsearchDataSet being deleted. Only set while it's
+	// decommissioned.
+	ConditionDecommissioned = "Decommissioned"
+	// ConditionRolloutFailed is True once a failed rollout was rolled
+	// back. Only set with auto rollback.
+	ConditionRolloutFailed = "RolloutFailed"
+	// ConditionZoneFailure is True while an availability zone is lost.
+	// Only set if the handling of zone failures is enabled.
+	ConditionZoneFailure = "ZoneFailure"
+	// ConditionScalingActive is True while the ElasticsearchDataSet is
+	// scaled, i.e. a scaling operation of the autoscaler is pending, the
+	// number of Pods differs from the replicas or a scale-down is
+	// monitored.
+	ConditionScalingActive = "ScalingActive"
+	// ConditionDrainInProgress is True while Pods are drained before they
+	// are deleted.
+	ConditionDrainInProgress = "DrainInProgress"
+	// ConditionIngestionPaused is True while the ingestion into the write
+	// indices of the alias is paused during decommissioning. Only set if
+	// the ingestion pause is configured.
+	ConditionIngestionPaused = "IngestionPaused"
+	// ConditionSettingsInSync is True while the persistent cluster settings
+	// of an ElasticsearchCluster match its settings baseline. Only set if
+	// a baseline is configured.
+	ConditionSettingsInSync = "SettingsInSync"
+	// ConditionTenantQuotasExceeded is True while tenants of an
+	// ElasticsearchCluster exceed their quota. Only set if tenant quotas
+	// are configured.
+	ConditionTenantQuotasExceeded = "TenantQuotasExceeded"
+)
+
+// Reasons of the conditions of the ElasticsearchDataSet status.
+const (
+	// ReasonReady is the reason of the Ready condition while it's True.
+	ReasonReady = "Ready"
+	// ReasonReconciling is the reason of the Ready and Progressing
+	// conditions while the latest generation isn't reconciled into the
+	// StatefulSet yet.
+	ReasonReconciling = "Reconciling"
+	// ReasonScaling is the reason of the Ready, Progressing and
+	// ScalingActive conditions while the number of Pods is scaled to the
+	// desired number.
+	ReasonScaling = "Scaling"
+	// ReasonRollingOut is the reason of the Ready and Progressing
+	// conditions while Pods of an old revision are replaced.
+	ReasonRollingOut = "RollingOut"
+	// ReasonRolloutPaused is the reason of the Ready and Progressing
+	// conditions while Pods of an old revision are left because the
+	// rollout is paused.
+	ReasonRolloutPaused = "RolloutPaused"
+	// ReasonPodsNotReady is the reason of the Ready condition while Pods
+	// aren't ready outside of a rollout or scaling.
+	ReasonPodsNotReady = "PodsNotReady"
+	// ReasonDataSetsNotReady is the reason of the Ready condition of an
+	// ElasticsearchCluster while some of its ElasticsearchDataSets aren't
+	// ready.
+	ReasonDataSetsNotReady = "DataSetsNotReady"
+	// ReasonComplete is the reason of the Progressing condition while
+	// it's False.
+	ReasonComplete = "Complete"
+
+	// ReasonAsExpected is the reason of the Degraded condition while it's
+	// False and of the SettingsInSync condition while it's True.
+	ReasonAsExpected = "AsExpected"
+	// ReasonPodsFailing is the reason of the Degraded condition while Pods
+	// are failing, see failingPods.
+	ReasonPodsFailing = "PodsFailing"
+	// ReasonRolledBack is the reason of the RolloutFailed condition and of
+	// the Degraded condition while the RolloutFailed condition is True.
+	ReasonRolledBack = "RolledBack"
+
+	// ReasonZonesAvailable is the reason of the ZoneFailure condition
+	// while it's False.
+	ReasonZonesAvailable = "ZonesAvailable"
+	// ReasonZonesLost is the reason of the ZoneFailure condition and of
+	// the Degraded condition while zones are lost.
+	ReasonZonesLost = "ZonesLost"
+
+	// ReasonNoGroup is the reason of the IndicesAllocated condition if the
+	// ElasticsearchDataSet has no group label.
+	ReasonNoGroup = "NoGroup"
+	// ReasonWaitingForPods is the reason of the IndicesAllocated and
+	// Decommissioned conditions while the Pods they need aren't ready.
+	ReasonWaitingForPods = "WaitingForPods"
+	// ReasonAllocationFailed is the reason of the IndicesAllocated
+	// condition if the allocation of the indices couldn't be changed.
+	ReasonAllocationFailed = "AllocationFailed"
+	// ReasonRelocating is the reason of the IndicesAllocated condition
+	// while shards relocate to the nodes.
+	ReasonRelocating = "Relocating"
+	// ReasonAllocated is the reason of the IndicesAllocated condition
+	// while it's True.
+	ReasonAllocated = "Allocated"
+
+	// ReasonInvalid is the reason of the Decommissioned condition if the
+	// decommissioning isn't configured correctly, and of the
+	// SettingsInSync condition if the baseline sets settings managed by the
+	// operator.
+	ReasonInvalid = "Invalid"
+	// ReasonMigrating is the reason of the Decommissioned condition while
+	// shards are moved off the Pods.
+	ReasonMigrating = "Migrating"
+	// ReasonMigrationFailed is the reason of the Decommissioned condition
+	// if the indices couldn't be moved.
+	ReasonMigrationFailed = "MigrationFailed"
+	// ReasonDecommissioned is the reason of the Decommissioned condition
+	// while it's True.
+	ReasonDecommissioned = "Decommissioned"
+
+	// ReasonSwitchover is the reason of the IngestionPaused condition while
+	// shards of the write indices are moved off the Pods.
+	ReasonSwitchover = "Switchover"
+	// ReasonIngestionResumed is the reason of the IngestionPaused
+	// condition while it's False.
+	ReasonIngestionResumed = "IngestionResumed"
+
+	// ReasonScalingOperation is the reason of the ScalingActive condition
+	// while a scaling operation of the autoscaler is pending.
+	ReasonScalingOperation = "ScalingOperation"
+	// ReasonScaleDownMonitored is the reason of the ScalingActive
+	// condition while the cluster is monitored after a scale-down.
+	ReasonScaleDownMonitored = "ScaleDownMonitored"
+	// ReasonStable is the reason of the ScalingActive condition while
+	// it's False.
+	ReasonStable = "Stable"
+
+	// ReasonDraining is the reason of the DrainInProgress condition while
+	// it's True.
+	ReasonDraining = "Draining"
+	// ReasonNoDrain is the reason of the DrainInProgress condition while
+	// it's False.
+	ReasonNoDrain = "NoDrain"
+
+	// ReasonDrifted is the reason of the SettingsInSync condition while
+	// settings drift from the baseline and are only reported.
+	ReasonDrifted = "Drifted"
+	// ReasonCorrected is the reason of the SettingsInSync condition after
+	// drifting settings were set back to the baseline.
+	ReasonCorrected = "Corrected"
+
+	// ReasonWithinQuota is the reason of the TenantQuotasExceeded
+	// condition while it's False.
+	ReasonWithinQuota = "WithinQuota"
+	// ReasonQuotaExceeded is the reason of the TenantQuotasExceeded
+	// condition while tenants exceed their quota and are only reported.
+	ReasonQuotaExceeded = "QuotaExceeded"
+	// ReasonWritesBlocked is the reason of the TenantQuotasExceeded
+	// condition while writes of tenants exceeding their quota are blocked.
+	ReasonWritesBlocked = "WritesBlocked"
+)
+
+// ElasticsearchDataSetClusterShardLimitStatus is a raised
+// cluster.max_shards_per_node setting.
+// +k8s:deepcopy-gen=true
+type ElasticsearchDataSetClusterShardLimitStatus struct {
+	// Original is the persistent value before it was raised. Not set if
+	// the setting wasn't set persistently.
+	// +optional
+	Original *int32 `json:"original,omitempty"`
+	// Raised is the value set by the operator.
+	Raised int32 `json:"raised"`
+}
+
+// ElasticsearchDataSetPrimariesRecommendation recommends increasing the
+// primary shards of an index, e.g. by splitting it or with the next rollover.
+// +k8s:deepcopy-gen=true
+type ElasticsearchDataSetPrimariesRecommendation struct {
+	// Index is the name of the index.
+	Index string `json:"index"`
+	// Primaries is the current number of primary shards.
+	Primaries int32 `json:"primaries"`
+	// RecommendedPrimaries is the recommended number of primary shards,
+	// a multiple of the current number so the index can be split.
+	RecommendedPrimaries int32 `json:"recommendedPrimaries"`
+	// Since is the time the recommendation was made first.
+	Since metav1.Time `json:"since"`
+}
+
+// ElasticsearchDataSetHotShardStatus describes a hot shard.
+// +k8s:deepcopy-gen=true
+type ElasticsearchDataSetHotShardStatus struct {
+	// Index is the index of the shard.
+	Index string `json:"index"`
+	// Shard is the number of the shard.
+	Shard int32 `json:"shard"`
+	// Node is the name of the hot node hosting the shard.
+	Node string `json:"node"`
+	// NodeCPUPercent is the CPU usage of the node.
+	NodeCPUPercent int32 `json:"nodeCPUPercent"`
+	// MedianCPUPercent is the median CPU usage of the nodes of the EDS.
+	MedianCPUPercent int32 `json:"medianCPUPercent"`
+	// LoadPercent is the share of the operations of the node served by
+	// the shard.
+	LoadPercent int32 `json:"loadPercent"`
+	// Detected is the time the shard was detected.
+	Detected metav1.Time `json:"detected"`
+}
+
+// ElasticsearchDataSetRolloutProgress describes the rollout of a Pod template
+// revision of the StatefulSet.
+// +k8s:deepcopy-gen=true
+type ElasticsearchDataSetRolloutProgress struct {
+	// UpdateRevision is the revision of the StatefulSet being rolled out.
+	UpdateRevision string `json:"updateRevision"`
+	// CurrentRevision is the revision of the StatefulSet before the
+	// rollout.
+	// +optional
+	CurrentRevision string `json:"currentRevision,omitempty"`
+	// Replicas is the desired number of Pods.
+	Replicas int32 `json:"replicas"`
+	// UpdatedReplicas is the number of ready Pods of the update revision.
+	UpdatedReplicas int32 `json:"updatedReplicas"`
+	// ProgressPercent is the percentage of the desired Pods which are
+	// updated and ready.
+	ProgressPercent int32 `json:"progressPercent"`
+	// Complete is true once the latest generation of the EDS is reconciled
+	// and all desired Pods are updated and ready.
+	Complete bool `json:"complete"`
+	// UnhealthySince is the time since which the rollout is unhealthy
+	// according to the auto rollback settings.
+	// +optional
+	UnhealthySince *metav1.Time `json:"unhealthySince,omitempty"`
+	// Paused is true if the rollout is paused with the
+	// 'es-operator.zalando.org/paused' annotation.
+	// +optional
+	Paused bool `json:"paused,omitempty"`
+	// Revisions are the number of Pods per revision.
+	// +listType=map
+	// +listMapKey=revision
+	// +optional
+	Revisions []ElasticsearchDataSetRevisionPods `json:"revisions,omitempty"`
+}
+
+// ElasticsearchDataSetOrdinalOverride overrides the settings of the Pods with
+// ordinals from 'from' to 'to', inclusive. As all Pods share the Pod template
+// of the StatefulSet, the container resources must fit the largest override.
+// +k8s:deepcopy-gen=true
+// +kubebuilder:validation:XValidation:rule="self.from <= self.to",message="from can't be greater than to"
+type ElasticsearchDataSetOrdinalOverride struct {
+	// From is the first ordinal of the range.
+	// +kubebuilder:validation:Minimum=0
+	From int32 `json:"from"`
+	// To is the last ordinal of the range.
+	// +kubebuilder:validation:Minimum=0
+	To int32 `json:"to"`
+	// JavaOpts are JVM options added to the Elasticsearch container of the
+	// Pods, separated by whitespace, e.g. '-Xms8g -Xmx8g'.
+	// +optional
+	JavaOpts string `json:"javaOpts,omitempty"`
+	// CapacityPercent is the capacity of the Pods relative to the other
+	// Pods, used by the autoscaler to compute the shard-to-node ratio.
+	// Defaults to 100.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	CapacityPercent int32 `json:"capacityPercent,omitempty"`
+}
+
+// ElasticsearchDataSetAutoRollback configures the automatic rollback of
+// failed rollouts. A rollout fails if more updated Pods than allowed aren't
+// ready, or the cluster health is at least as bad as the unhealthy status,
+// for the whole window.
+// +k8s:deepcopy-gen=true
+type ElasticsearchDataSetAutoRollback struct {
+	// Enabled enables the automatic rollback.
+	// +optional
+	Enabled bool `json:"enabled"`
+	// WindowSeconds is the time a rollout needs to be unhealthy before it's
+	// rolled back. Defaults to 300.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	WindowSeconds int32 `json:"windowSeconds,omitempty"`
+	// MaxUnreadyPods is the number of updated Pods which may be unready.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxUnreadyPods int32 `json:"maxUnreadyPods,omitempty"`
+	// UnhealthyStatus is the cluster health status from which on the
+	// rollout is considered unhealthy. Defaults to red.
+	// +kubebuilder:validation:Enum=yellow;red
+	// +optional
+	UnhealthyStatus string `json:"unhealthyStatus,omitempty"`
+}
+
+// ElasticsearchDataSetTemplateRevision is a revision of the Pod template of
+// an EDS.
+// +k8s:deepcopy-gen=true
+type ElasticsearchDataSetTemplateRevision struct {
+	// Revision is the number of the revision, increasing with every change
+	// of the Pod template.
+	Revision int64 `json:"revision"`
+	// Generation is the generation of the EDS the revision was rolled out
+	// with.
+	Generation int64 `json:"generation"`
+	// Created is the time the revision was recorded.
+	Created metav1.Time `json:"created"`
+	// Template is the Pod template of the revision.
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:Type=object
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Template PodTemplateSpec `json:"template"`
+}
+
+// ElasticsearchDataSetRevisionPods is the number of Pods of a revision.
+// +k8s:deepcopy-gen=true
+type ElasticsearchDataSetRevisionPods struct {
+	// Revision is the controller-revision-hash of the Pods.
+	Revision string `json:"revision"`
+	// Pods is the number of Pods of the revision.
+	Pods int32 `json:"pods"`
+	// ReadyPods is the number of ready Pods of the revision.
+	ReadyPods int32 `json:"readyPods"`
+}
+
+// ElasticsearchDataSetBurstStatus describes a temporary override of the
+// scaling settings.
+// +k8s:deepcopy-gen=true
+type ElasticsearchDataSetBurstStatus struct {
+	// MaxReplicas overrides the maxReplicas of the scaling settings.
+	MaxReplicas int32 `json:"maxReplicas"`
+	// ScaleUpCPUBoundary overrides the scaleUpCPUBoundary of the scaling
+	// settings if set.
+	// +optional
+	ScaleUpCPUBoundary int32 `json:"scaleUpCPUBoundary,omitempty"`
+	// ScaleDownCPUBoundary overrides the scaleDownCPUBoundary of the
+	// scaling settings if set.
+	// +optional
+	ScaleDownCPUBoundary int32 `json:"scaleDownCPUBoundary,omitempty"`
+	// DurationSeconds is the requested duration of the override.
+	DurationSeconds int64 `json:"durationSeconds"`
+	// Started is the time the override was applied.
+	Started metav1.Time `json:"started"`
+	// Expires is the time the scaling settings of the spec apply again.
+	Expires metav1.Time `json:"expires"`
+}
+
+// ElasticsearchDataSetScalingDirection is the direction of a scaling
+// decision.
+// +kubebuilder:validation:Enum=UP;DOWN;NONE
+type ElasticsearchDataSetScalingDirection string
+
+const (
+	// ScalingDirectionUp adds nodes or index replicas.
+	ScalingDirectionUp ElasticsearchDataSetScalingDirection = "UP"
+	// ScalingDirectionDown removes nodes or index replicas.
+	ScalingDirectionDown ElasticsearchDataSetScalingDirection = "DOWN"
+	// ScalingDirectionNone keeps the nodes and index replicas.
+	ScalingDirectionNone ElasticsearchDataSetScalingDirection = "NONE"
+)
+
+// ElasticsearchDataSetScalingState is the bookkeeping of the autoscaler.
+// +k8s:deepcopy-gen=true
+type ElasticsearchDataSetScalingState struct {
+	// Version is the version of the format of the scaling state.
+	Version int32 `json:"version"`
+	// Operation is the scaling operation decided by the autoscaler which
+	// isn't fully applied yet.
+	// +optional
+	Operation *ElasticsearchDataSetScalingOperation `json:"operation,omitempty"`
+	// LastDirection is the direction suggested by the CPU usage at the last
+	// evaluation of the autoscaler.
+	// +optional
+	LastDirection ElasticsearchDataSetScalingDirection `json:"lastDirection,omitempty"`
+	// ScaleUpSamples is the number of the latest consecutive CPU usage
+	// samples above the scale-up boundary, at most the number of samples
+	// needed for a scale-up.
+	// +optional
+	ScaleUpSamples int32 `json:"scaleUpSamples,omitempty"`
+	// ScaleDownSamples is the number of the latest consecutive CPU usage
+	// samples below the scale-down boundary, at most the number of samples
+	// needed for a scale-down.
+	// +optional
+	ScaleDownSamples int32 `json:"scaleDownSamples,omitempty"`
+}
+
+// ElasticsearchDataSetScalingOperation is a scaling operation decided by the
+// autoscaler.
+// +k8s:deepcopy-gen=true
+type ElasticsearchDataSetScalingOperation struct {
+	// Direction is the direction of the operation.
+	Direction ElasticsearchDataSetScalingDirection `json:"direction"`
+	// NodeReplicas are the node replicas of the operation.
+	// +optional
+	NodeReplicas *int32 `json:"nodeReplicas,omitempty"`
+	// IndexReplicas are the index replicas set once the node replicas are
+	// reached, or before removing nodes.
+	// +optional
+	IndexReplicas []ElasticsearchDataSetIndexReplicas `json:"indexReplicas,omitempty"`
+	// Description describes why the operation was decided.
+	// +optional
+	Description string `json:"description,omitempty"`
+}
+
+// ElasticsearchDataSetIndexReplicas are the replicas of an index.
+// +k8s:deepcopy-gen=true
+type ElasticsearchDataSetIndexReplicas struct {
+	// Index is the name of the index.
+	Index string `json:"index"`
+	// Primaries is the number of primary shards of the index.
+	Primaries int32 `json:"primaries"`
+	// Replicas is the number of replicas of the index.
+	Replicas int32 `json:"replicas"`
+}
+
+// ElasticsearchDataSetScaleDownRecoveryStatus describes a scale-down
+// monitored for recovery.
+// +k8s:deepcopy-gen=true
+type ElasticsearchDataSetScaleDownRecoveryStatus struct {
+	// FromReplicas are the replicas before the scale-down, which are
+	// restored if the cluster doesn't recover.
+	FromReplicas int32 `json:"fromReplicas"`
+	// ToReplicas are the replicas after the scale-down.
+	ToReplicas int32 `json:"toReplicas"`
+	// PodsRemoved is the time the pods of the scale-down were observed as
+	// removed.
+	// +optional
+	PodsRemoved *metav1.Time `json:"podsRemoved,omitempty"`
+	// UnhealthySince is the time the cluster was first observed as red or
+	// with unassigned shards after the removal.
+	// +optional
+	UnhealthySince *metav1.Time `json:"unhealthySince,omitempty"`
+}
+
+// ElasticsearchDataSetZoneFailureStatus describes lost availability zones.
+// +k8s:deepcopy-gen=true
+type ElasticsearchDataSetZoneFailureStatus struct {
+	// Zones are the lost zones.
+	Zones []string `json:"zones"`
+	// ReplacementReplicas is the number of pods added to replace the pods
+	// in the lost zones.
+	ReplacementReplicas int32 `json:"replacementReplicas"`
+	// ForcedAwarenessValues are the forced awareness values before the
+	// zones were lost. They are restored once all zones are available.
+	// +optional
+	ForcedAwarenessValues []string `json:"forcedAwarenessValues,omitempty"`
+	// Since is the time the zones were detected as lost.
+	Since metav1.Time `json:"since"`
+}
+
+// ElasticsearchDataSetMasterStatus describes the elected master of the
+// cluster.
+// +k8s:deepcopy-gen=true
+type ElasticsearchDataSetMasterStatus struct {
+	// NodeID is the ID of the elected master node. Empty if no master is
+	// elected.
+	// +optional
+	NodeID string `json:"nodeID,omitempty"`
+	// Stable is true if the master is considered stable.
+	Stable bool `json:"stable"`
+	// Reason describes why the master is unstable.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+	// RecentElections is the number of master elections observed within
+	// the monitoring window.
+	RecentElections int32 `json:"recentElections"`
+	// LastElection is the time of the last observed master election.
+	// +optional
+	LastElection *metav1.Time `json:"lastElection,omitempty"`
+}
+
+// ElasticsearchDataSetPodFailure describes a failing container of a pod of
+// an ElasticsearchDataSet, or a pod failing because of its node.
+// +k8s:deepcopy-gen=true
+type ElasticsearchDataSetPodFailure struct {
+	// PodName is the name of the failing pod.
+	PodName string `json:"podName"`
+	// ContainerName is the name of the failing container. It's empty if
+	// the pod itself is failing.
+	// +optional
+	ContainerName string `json:"containerName,omitempty"`
+	// Reason is the reason the container or pod is failing, e.g.
+	// CrashLoopBackOff, OOMKilled, Evicted, Unschedulable or NodeNotReady.
+	Reason string `json:"reason"`
+	// Message describes why the pod is failing, e.g. why it was evicted
+	// or can't be scheduled.
+	// +optional
+	Message string `json:"message,omitempty"`
+	// ExitReason is the reason of the last termination of the container.
+	// +optional
+	ExitReason string `json:"exitReason,omitempty"`
+	// ExitCode is the exit code of the last termination of the container.
+	// +optional
+	ExitCode int32 `json:"exitCode,omitempty"`
+	// RestartCount is the number of times the container was restarted.
+	// +optional
+	RestartCount int32 `json:"restartCount,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ElasticsearchDataSetList is a list of ElasticsearchDataSets.
+// +k8s:deepcopy-gen=true
+type ElasticsearchDataSetList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []ElasticsearchDataSet `json:"items"`
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ElasticsearchMetricSet is the metrics holding section of the ElasticsearchDataSet
+// resource.
+// +k8s:deepcopy-gen=true
+type ElasticsearchMetricSet struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Metrics           []ElasticsearchMetric `json:"metrics"`
+	// Baseline is the seasonal baseline of the CPU usage used for
+	// predictive scaling. Only recorded if predictive scaling is enabled.
+	// +optional
+	Baseline []ElasticsearchMetricBaseline `json:"baseline,omitempty"`
+	// Samples are the CPU usage samples of the individual pods the latest
+	// metric was aggregated from.
+	// +optional
+	Samples []ElasticsearchMetricSample `json:"samples,omitempty"`
+	// Capacity summarizes the CPU requested and used by the pods of the
+	// latest samples.
+	// +optional
+	Capacity *ElasticsearchMetricCapacity `json:"capacity,omitempty"`
+}
+
+// ElasticsearchMetricSource is the source of a metric sample.
+// +kubebuilder:validation:Enum=metrics-server;kubelet
+type ElasticsearchMetricSource string
+
+const (
+	// MetricSourceMetricsServer is the metrics.k8s.io API of the
+	// metrics-server.
+	MetricSourceMetricsServer ElasticsearchMetricSource = "metrics-server"
+	// MetricSourceKubelet is the summary API of the kubelets, used if the
+	// metrics-server isn't available.
+	MetricSourceKubelet ElasticsearchMetricSource = "kubelet"
+)
+
+// ElasticsearchMetricSample is the CPU usage of a single pod.
+// +k8s:deepcopy-gen=true
+type ElasticsearchMetricSample struct {
+	// Timestamp is the time the sample was collected.
+	Timestamp metav1.Time `json:"timestamp"`
+	// Value is the CPU usage of the pod relative to its requests in
+	// percent, the highest of its containers.
+	Value int32 `json:"value"`
+	// Source is where the sample was collected from.
+	// +optional
+	Source ElasticsearchMetricSource `json:"source,omitempty"`
+	// Pod is the name of the pod.
+	Pod string `json:"pod"`
+	// Node is the name of the node of the pod.
+	// +optional
+	Node string `json:"node,omitempty"`
+	// Labels are the architecture and zone labels of the node.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// ElasticsearchMetricCapacity summarizes the CPU requested and used by the
+// pods of an ElasticsearchDataSet.
+// +k8s:deepcopy-gen=true
+type ElasticsearchMetricCapacity struct {
+	// Pods is the number of pods with samples.
+	Pods int32 `json:"pods"`
+	// RequestedCPU is the CPU requested by the containers of the pods.
+	RequestedCPU resource.Quantity `json:"requestedCPU"`
+	// UsedCPU is the CPU used by the containers of the pods with requests.
+	UsedCPU resource.Quantity `json:"usedCPU"`
+}
+
+// ElasticsearchMetricBaseline is the average CPU usage in an hour of the
+// week.
+// +k8s:deepcopy-gen=true
+type ElasticsearchMetricBaseline struct {
+	// Weekday is the day of the week, starting with 0 for Sunday.
+	Weekday int32 `json:"weekday"`
+	// Hour is the hour of the day in UTC.
+	Hour int32 `json:"hour"`
+	// Value is the average CPU usage.
+	Value int32 `json:"value"`
+	// Samples is the number of samples the average is based on.
+	Samples int32 `json:"samples"`
+}
+
+// ElasticsearchMetric is the single metric sample of the ElasticsearchDataSet
+// resource.
+// +k8s:deepcopy-gen=true
+type ElasticsearchMetric struct {
+	Timestamp metav1.Time `json:"timestamp"`
+	Value     int32       `json:"value"`
+	// Source is where the samples were collected from.
+	// +optional
+	Source ElasticsearchMetricSource `json:"source,omitempty"`
+	// Pods is the number of pod samples aggregated into the value.
+	// +optional
+	Pods int32 `json:"pods,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ElasticsearchDataSetList is a list of ElasticsearchDataSets.
+// +k8s:deepcopy-gen=true
+type ElasticsearchMetricSetList struct {
 	metav1.TypeMeta `json:",inline"`
 	metav1.ListMeta `json:"metadata,omitempty"`
 
 	Items []ElasticsearchMetricSet `json:"items"`
 }
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+
+// ElasticsearchRollout describes the rollout of a new Elasticsearch image
+// across a set of ElasticsearchDataSets in waves.
+// +k8s:deepcopy-gen=true
+// +kubebuilder:resource:categories="all",shortName=esro
+// +kubebuilder:printcolumn:name="Image",type=string,JSONPath=`.spec.image`,description="The image being rolled out"
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`,description="The phase of the rollout"
+// +kubebuilder:printcolumn:name="Wave",type=integer,JSONPath=`.status.currentWave`,description="The current wave of the rollout"
+// +kubebuilder:subresource:status
+type ElasticsearchRollout struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ElasticsearchRolloutSpec `json:"spec"`
+	// +optional
+	Status ElasticsearchRolloutStatus `json:"status"`
+}
+
+// ElasticsearchRolloutSpec is the spec part of the ElasticsearchRollout.
+// +k8s:deepcopy-gen=true
+type ElasticsearchRolloutSpec struct {
+	// Selector selects the ElasticsearchDataSets in the namespace of the
+	// rollout which should be updated.
+	Selector *metav1.LabelSelector `json:"selector"`
+
+	// Image is the image to roll out.
+	// +kubebuilder:validation:MinLength=1
+	Image string `json:"image"`
+
+	// ContainerName is the name of the container in the EDS pod template
+	// to update. Defaults to 'elasticsearch'.
+	// +optional
+	ContainerName string `json:"containerName,omitempty"`
+
+	// Waves defines the cumulative number or percentage of the selected
+	// ElasticsearchDataSets which should be updated after each wave, e.g.
+	// [1, "10%", "100%"]. A wave is only started once all
+	// ElasticsearchDataSets of the previous wave are fully rolled out and
+	// healthy. Defaults to a single wave of "100%".
+	// +optional
+	Waves []intstr.IntOrString `json:"waves,omitempty"`
+
+	// Paused prevents the rollout from starting the next wave.
+	// +optional
+	Paused bool `json:"paused,omitempty"`
+}
+
+// ElasticsearchRolloutPhase is the phase of an ElasticsearchRollout.
+// +kubebuilder:validation:Enum=Progressing;Paused;Halted;Completed
+type ElasticsearchRolloutPhase string
+
+const (
+	// RolloutPhaseProgressing indicates that the rollout is progressing.
+	RolloutPhaseProgressing ElasticsearchRolloutPhase = "Progressing"
+	// RolloutPhasePaused indicates that the rollout is paused by the user.
+	RolloutPhasePaused ElasticsearchRolloutPhase = "Paused"
+	// RolloutPhaseHalted indicates that the rollout was stopped because of
+	// a health regression. It's resumed when the rollout spec is changed.
+	RolloutPhaseHalted ElasticsearchRolloutPhase = "Halted"
+	// RolloutPhaseCompleted indicates that all selected
+	// ElasticsearchDataSets run the new image.
+	RolloutPhaseCompleted ElasticsearchRolloutPhase = "Completed"
+)
+
+// ElasticsearchRolloutStatus is the status part of the ElasticsearchRollout.
+// +k8s:deepcopy-gen=true
+type ElasticsearchRolloutStatus struct {
+	// ObservedGeneration is the most recent generation observed for this
+	// ElasticsearchRollout.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Phase is the current phase of the rollout.
+	// +optional
+	Phase ElasticsearchRolloutPhase `json:"phase,omitempty"`
+
+	// CurrentWave is the index of the wave currently being rolled out.
+	// +optional
+	CurrentWave int32 `json:"currentWave"`
+
+	// UpdatedDataSets are the names of the ElasticsearchDataSets which
+	// have been updated to the new image.
+	// +optional
+	UpdatedDataSets []string `json:"updatedDataSets,omitempty"`
+
+	// Message is a human readable description of the current state.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ElasticsearchRolloutList is a list of ElasticsearchRollouts.
+// +k8s:deepcopy-gen=true
+type ElasticsearchRolloutList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []ElasticsearchRollout `json:"items"`
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+
+// ElasticsearchRestore describes the restore of indices from a snapshot onto
+// the node group of an ElasticsearchDataSet.
+// +k8s:deepcopy-gen=true
+// +kubebuilder:resource:categories="all",shortName=esre
+// +kubebuilder:printcolumn:name="DataSet",type=string,JSONPath=`.spec.dataSet`,description="The ElasticsearchDataSet hosting the restored indices"
+// +kubebuilder:printcolumn:name="Snapshot",type=string,JSONPath=`.spec.snapshot`,description="The snapshot to restore"
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`,description="The phase of the restore"
+// +kubebuilder:subresource:status
+type ElasticsearchRestore struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ElasticsearchRestoreSpec `json:"spec"`
+	// +optional
+	Status ElasticsearchRestoreStatus `json:"status"`
+}
+
+// ElasticsearchRestoreSpec is the spec part of the ElasticsearchRestore.
+// +k8s:deepcopy-gen=true
+type ElasticsearchRestoreSpec struct {
+	// DataSet is the name of the ElasticsearchDataSet in the namespace of
+	// the restore whose nodes host the restored indices.
+	// +kubebuilder:validation:MinLength=1
+	DataSet string `json:"dataSet"`
+
+	// Group is the value of the 'group' node attribute of the nodes of the
+	// ElasticsearchDataSet, which the restored indices are allocated to.
+	// Defaults to the 'group' label of the ElasticsearchDataSet.
+	// +optional
+	Group string `json:"group,omitempty"`
+
+	// Repository is the name of the snapshot repository.
+	// +kubebuilder:validation:MinLength=1
+	Repository string `json:"repository"`
+
+	// Snapshot is the name of the snapshot to restore.
+	// +kubebuilder:validation:MinLength=1
+	Snapshot string `json:"snapshot"`
+
+	// Indices are the indices or index patterns to restore.
+	// +kubebuilder:validation:MinItems=1
+	Indices []string `json:"indices"`
+
+	// RenamePattern is a regular expression matching the names of the
+	// restored indices to rename them.
+	// +optional
+	RenamePattern string `json:"renamePattern,omitempty"`
+
+	// RenameReplacement is the replacement for indices matching the
+	// RenamePattern.
+	// +optional
+	RenameReplacement string `json:"renameReplacement,omitempty"`
+
+	// IndexReplicas is the number of replicas of the restored indices.
+	// Defaults to the number of replicas in the snapshot.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	IndexReplicas *int32 `json:"indexReplicas,omitempty"`
+
+	// MinReplicas is the number of replicas the ElasticsearchDataSet is
+	// scaled up to, if it has fewer, before the restore is started.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MinReplicas *int32 `json:"minReplicas,omitempty"`
+}
+
+// ElasticsearchRestorePhase is the phase of an ElasticsearchRestore.
+// +kubebuilder:validation:Enum=ScalingUp;Restoring;Completed;Failed
+type ElasticsearchRestorePhase string
+
+const (
+	// RestorePhaseScalingUp indicates that the ElasticsearchDataSet is
+	// scaled up before the restore.
+	RestorePhaseScalingUp ElasticsearchRestorePhase = "ScalingUp"
+	// RestorePhaseRestoring indicates that the indices are being restored.
+	RestorePhaseRestoring ElasticsearchRestorePhase = "Restoring"
+	// RestorePhaseCompleted indicates that all restored indices are green.
+	RestorePhaseCompleted ElasticsearchRestorePhase = "Completed"
+	// RestorePhaseFailed indicates that the restore could not be started.
+	RestorePhaseFailed ElasticsearchRestorePhase = "Failed"
+)
+
+// ElasticsearchRestoreStatus is the status part of the ElasticsearchRestore.
+// +k8s:deepcopy-gen=true
+type ElasticsearchRestoreStatus struct {
+	// Phase is the current phase of the restore.
+	// +optional
+	Phase ElasticsearchRestorePhase `json:"phase,omitempty"`
+
+	// StartTime is the time the restore was started.
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// CompletionTime is the time all restored indices were green.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// Message is a human readable description of the current state.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ElasticsearchRestoreList is a list of ElasticsearchRestores.
+// +k8s:deepcopy-gen=true
+type ElasticsearchRestoreList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []ElasticsearchRestore `json:"items"`
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+
+// ElasticsearchCluster describes an Elasticsearch cluster composed of
+// dedicated master nodes and groups of data nodes sharing one
+// elasticsearch.yml. The operator creates the master StatefulSet, the
+// Services and an ElasticsearchDataSet for each group of data nodes and keeps
+// them consistent with the cluster.
+// +k8s:deepcopy-gen=true
+// +kubebuilder:resource:categories="all",shortName=esc
+// +kubebuilder:printcolumn:name="Masters",type=integer,JSONPath=`.status.readyMasters`,description="The number of ready master nodes"
+// +kubebuilder:printcolumn:name="DataSets",type=integer,JSONPath=`.status.readyDataSets`,description="The number of ready ElasticsearchDataSets"
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`,description="Whether the latest generation is reconciled and all nodes are ready"
+// +kubebuilder:subresource:status
+type ElasticsearchCluster struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ElasticsearchClusterSpec `json:"spec"`
+	// +optional
+	Status ElasticsearchClusterStatus `json:"status"`
+}
+
+// ElasticsearchClusterSpec is the spec part of the ElasticsearchCluster.
+// +k8s:deepcopy-gen=true
+type ElasticsearchClusterSpec struct {
+	// Config is the elasticsearch.yml shared by all nodes of the cluster.
+	// cluster.name, network.host, discovery.seed_hosts and
+	// cluster.initial_master_nodes are set by the operator unless they
+	// are set here.
+	// +optional
+	Config string `json:"config,omitempty"`
+
+	// Masters are the dedicated master nodes of the cluster.
+	Masters ElasticsearchClusterMasters `json:"masters"`
+
+	// DataSets are the groups of data nodes of the cluster. Each group is
+	// operated as the ElasticsearchDataSet '<cluster>-<name>'. Removing a
+	// group deletes its ElasticsearchDataSet.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	DataSets []ElasticsearchClusterDataSet `json:"dataSets,omitempty"`
+
+	// Settings is a baseline of persistent cluster settings enforced by
+	// the operator.
+	// +optional
+	Settings *ElasticsearchClusterSettings `json:"settings,omitempty"`
+
+	// TenantQuotas limit the shards and the disk usage of the indices of
+	// the tenants sharing the cluster, identified by their index prefixes.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	TenantQuotas []ElasticsearchClusterTenantQuota `json:"tenantQuotas,omitempty"`
+}
+
+// ElasticsearchClusterSettings is a baseline of persistent cluster settings,
+// e.g. recovery throttles or allocation awareness. The operator compares it
+// with the cluster at every interval, reports settings changed by hand and
+// sets them back.
+// +k8s:deepcopy-gen=true
+type ElasticsearchClusterSettings struct {
+	// Persistent are the persistent cluster settings by their flat key,
+	// e.g. 'indices.recovery.max_bytes_per_sec: 200mb'. Values of list
+	// settings are comma-separated. Settings managed by the operator, like
+	// 'cluster.routing.allocation.exclude._ip', can't be set.
+	// +kubebuilder:validation:MinProperties=1
+	Persistent map[string]string `json:"persistent"`
+
+	// ReportOnly only reports settings drifting from the baseline without
+	// setting them back.
+	// +optional
+	ReportOnly bool `json:"reportOnly,omitempty"`
+}
+
+// ElasticsearchClusterTenantQuota limits the indices of a tenant of the
+// cluster. The operator compares the usage with the quota at every interval
+// and blocks writes to the indices of a tenant exceeding it, until it's back
+// within the quota, e.g. after indices were deleted.
+// +k8s:deepcopy-gen=true
+type ElasticsearchClusterTenantQuota struct {
+	// Name of the tenant, unique within the cluster.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// IndexPrefixes are the prefixes of the names of the indices of the
+	// tenant, e.g. 'team-a-'. An index matching the prefixes of several
+	// tenants belongs to the tenant with the longest matching prefix.
+	// +kubebuilder:validation:MinItems=1
+	IndexPrefixes []string `json:"indexPrefixes"`
+
+	// MaxShards is the maximum number of shard copies, primaries and
+	// replicas, of the indices of the tenant.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxShards *int32 `json:"maxShards,omitempty"`
+
+	// MaxStorage is the maximum size of the shard copies of the indices
+	// of the tenant on disk.
+	// +optional
+	MaxStorage *resource.Quantity `json:"maxStorage,omitempty"`
+
+	// ReportOnly only reports the tenant exceeding its quota without
+	// blocking writes.
+	// +optional
+	ReportOnly bool `json:"reportOnly,omitempty"`
+}
+
+// ElasticsearchClusterMasters describes the dedicated master nodes of the
+// cluster, run by the StatefulSet '<cluster>-master'.
+// +k8s:deepcopy-gen=true
+type ElasticsearchClusterMasters struct {
+	// Replicas is the number of master nodes. Defaults to 3.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Replicas *int32 `json:"replicas,omitempty"`
+
+	// Template describes the pods of the master nodes. The first container
+	// is the Elasticsearch container.
+	Template PodTemplateSpec `json:"template"`
+
+	// VolumeClaimTemplates are the volume claims of the master nodes.
+	// Only used when the StatefulSet is created.
+	// +optional
+	VolumeClaimTemplates []PersistentVolumeClaim `json:"volumeClaimTemplates,omitempty"`
+}
+
+// ElasticsearchClusterDataSet describes a group of data nodes of the
+// cluster.
+// +k8s:deepcopy-gen=true
+type ElasticsearchClusterDataSet struct {
+	// Name of the group, unique within the cluster.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Labels are added to the ElasticsearchDataSet, e.g. the 'group'
+	// label.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Spec is the spec of the ElasticsearchDataSet. The replicas of an
+	// ElasticsearchDataSet with scaling enabled are left to the
+	// autoscaler once it's created.
+	Spec ElasticsearchDataSetSpec `json:"spec"`
+}
+
+// ElasticsearchClusterStatus is the status part of the ElasticsearchCluster.
+// +k8s:deepcopy-gen=true
+type ElasticsearchClusterStatus struct {
+	// ObservedGeneration is the most recent generation observed for this
+	// ElasticsearchCluster.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Masters is the number of master nodes.
+	// +optional
+	Masters int32 `json:"masters"`
+
+	// ReadyMasters is the number of ready master nodes.
+	// +optional
+	ReadyMasters int32 `json:"readyMasters"`
+
+	// ReadyDataSets is the number of ready ElasticsearchDataSets.
+	// +optional
+	ReadyDataSets int32 `json:"readyDataSets"`
+
+	// DataSets are the states of the ElasticsearchDataSets of the cluster.
+	// +optional
+	DataSets []ElasticsearchClusterDataSetStatus `json:"dataSets,omitempty"`
+
+	// SettingsDrift are the settings of the baseline which differed from
+	// the cluster at the last check.
+	// +optional
+	SettingsDrift []ElasticsearchClusterSettingDrift `json:"settingsDrift,omitempty"`
+
+	// TenantQuotas are the usage of the tenants with a quota at the last
+	// check.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	TenantQuotas []ElasticsearchClusterTenantQuotaStatus `json:"tenantQuotas,omitempty"`
+
+	// Conditions are the latest observations of the state of the
+	// cluster. The Ready condition is True once the latest generation is
+	// reconciled and all master nodes and ElasticsearchDataSets are ready.
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// ElasticsearchClusterDataSetStatus is the state of an ElasticsearchDataSet
+// of the cluster.
+// +k8s:deepcopy-gen=true
+type ElasticsearchClusterDataSetStatus struct {
+	// Name of the group.
+	Name string `json:"name"`
+
+	// DataSet is the name of the ElasticsearchDataSet.
+	DataSet string `json:"dataSet"`
+
+	// Replicas is the number of pods of the ElasticsearchDataSet.
+	// +optional
+	Replicas int32 `json:"replicas"`
+
+	// ReadyReplicas is the number of ready pods of the
+	// ElasticsearchDataSet.
+	// +optional
+	ReadyReplicas int32 `json:"readyReplicas"`
+}
+
+// ElasticsearchClusterSettingDrift is a setting of the baseline which
+// differs from the cluster.
+// +k8s:deepcopy-gen=true
+type ElasticsearchClusterSettingDrift struct {
+	// Setting is the flat key of the setting.
+	Setting string `json:"setting"`
+
+	// Desired is the value of the baseline.
+	Desired string `json:"desired"`
+
+	// Persistent is the persistent value in the cluster, empty if unset.
+	// +optional
+	Persistent string `json:"persistent,omitempty"`
+
+	// Transient is the transient value in the cluster overriding the
+	// persistent one, empty if unset.
+	// +optional
+	Transient string `json:"transient,omitempty"`
+}
+
+// ElasticsearchClusterTenantQuotaStatus is the usage of a tenant of the
+// cluster.
+// +k8s:deepcopy-gen=true
+type ElasticsearchClusterTenantQuotaStatus struct {
+	// Name of the tenant.
+	Name string `json:"name"`
+
+	// Indices is the number of indices of the tenant.
+	// +optional
+	Indices int32 `json:"indices"`
+
+	// Shards is the number of shard copies of the indices of the tenant.
+	// +optional
+	Shards int32 `json:"shards"`
+
+	// Storage is the size of the shard copies of the indices of the
+	// tenant on disk.
+	// +optional
+	Storage resource.Quantity `json:"storage"`
+
+	// Exceeded is true while the tenant exceeds its quota.
+	// +optional
+	Exceeded bool `json:"exceeded,omitempty"`
+
+	// BlockedIndices are the indices whose writes are blocked by the
+	// operator because the tenant exceeds its quota. Indices which were
+	// already blocked aren't included and are left blocked.
+	// +optional
+	BlockedIndices []string `json:"blockedIndices,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ElasticsearchClusterList is a list of ElasticsearchClusters.
+// +k8s:deepcopy-gen=true
+type ElasticsearchClusterList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []ElasticsearchCluster `json:"items"`
+}
@@ -22,61 +22,1957 @@ limitations under the License.
 package v1
 
 import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
+	intstr "k8s.io/apimachinery/pkg/util/intstr"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchCluster) DeepCopyInto(out *ElasticsearchCluster) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchCluster.
+func (in *ElasticsearchCluster) DeepCopy() *ElasticsearchCluster {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchCluster)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ElasticsearchCluster) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchClusterDataSet) DeepCopyInto(out *ElasticsearchClusterDataSet) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	in.Spec.DeepCopyInto(&out.Spec)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchClusterDataSet.
+func (in *ElasticsearchClusterDataSet) DeepCopy() *ElasticsearchClusterDataSet {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchClusterDataSet)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchClusterDataSetStatus) DeepCopyInto(out *ElasticsearchClusterDataSetStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchClusterDataSetStatus.
+func (in *ElasticsearchClusterDataSetStatus) DeepCopy() *ElasticsearchClusterDataSetStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchClusterDataSetStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchClusterList) DeepCopyInto(out *ElasticsearchClusterList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ElasticsearchCluster, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchClusterList.
+func (in *ElasticsearchClusterList) DeepCopy() *ElasticsearchClusterList {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchClusterList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ElasticsearchClusterList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchClusterMasters) DeepCopyInto(out *ElasticsearchClusterMasters) {
+	*out = *in
+	if in.Replicas != nil {
+		in, out := &in.Replicas, &out.Replicas
+		*out = new(int32)
+		**out = **in
+	}
+	in.Template.DeepCopyInto(&out.Template)
+	if in.VolumeClaimTemplates != nil {
+		in, out := &in.VolumeClaimTemplates, &out.VolumeClaimTemplates
+		*out = make([]PersistentVolumeClaim, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchClusterMasters.
+func (in *ElasticsearchClusterMasters) DeepCopy() *ElasticsearchClusterMasters {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchClusterMasters)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchClusterSettingDrift) DeepCopyInto(out *ElasticsearchClusterSettingDrift) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchClusterSettingDrift.
+func (in *ElasticsearchClusterSettingDrift) DeepCopy() *ElasticsearchClusterSettingDrift {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchClusterSettingDrift)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchClusterSettings) DeepCopyInto(out *ElasticsearchClusterSettings) {
+	*out = *in
+	if in.Persistent != nil {
+		in, out := &in.Persistent, &out.Persistent
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchClusterSettings.
+func (in *ElasticsearchClusterSettings) DeepCopy() *ElasticsearchClusterSettings {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchClusterSettings)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchClusterSpec) DeepCopyInto(out *ElasticsearchClusterSpec) {
+	*out = *in
+	in.Masters.DeepCopyInto(&out.Masters)
+	if in.DataSets != nil {
+		in, out := &in.DataSets, &out.DataSets
+		*out = make([]ElasticsearchClusterDataSet, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Settings != nil {
+		in, out := &in.Settings, &out.Settings
+		*out = new(ElasticsearchClusterSettings)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TenantQuotas != nil {
+		in, out := &in.TenantQuotas, &out.TenantQuotas
+		*out = make([]ElasticsearchClusterTenantQuota, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchClusterSpec.
+func (in *ElasticsearchClusterSpec) DeepCopy() *ElasticsearchClusterSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchClusterSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchClusterStatus) DeepCopyInto(out *ElasticsearchClusterStatus) {
+	*out = *in
+	if in.DataSets != nil {
+		in, out := &in.DataSets, &out.DataSets
+		*out = make([]ElasticsearchClusterDataSetStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.SettingsDrift != nil {
+		in, out := &in.SettingsDrift, &out.SettingsDrift
+		*out = make([]ElasticsearchClusterSettingDrift, len(*in))
+		copy(*out, *in)
+	}
+	if in.TenantQuotas != nil {
+		in, out := &in.TenantQuotas, &out.TenantQuotas
+		*out = make([]ElasticsearchClusterTenantQuotaStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchClusterStatus.
+func (in *ElasticsearchClusterStatus) DeepCopy() *ElasticsearchClusterStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchClusterStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchClusterTenantQuota) DeepCopyInto(out *ElasticsearchClusterTenantQuota) {
+	*out = *in
+	if in.IndexPrefixes != nil {
+		in, out := &in.IndexPrefixes, &out.IndexPrefixes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.MaxShards != nil {
+		in, out := &in.MaxShards, &out.MaxShards
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxStorage != nil {
+		in, out := &in.MaxStorage, &out.MaxStorage
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchClusterTenantQuota.
+func (in *ElasticsearchClusterTenantQuota) DeepCopy() *ElasticsearchClusterTenantQuota {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchClusterTenantQuota)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchClusterTenantQuotaStatus) DeepCopyInto(out *ElasticsearchClusterTenantQuotaStatus) {
+	*out = *in
+	out.Storage = in.Storage.DeepCopy()
+	if in.BlockedIndices != nil {
+		in, out := &in.BlockedIndices, &out.BlockedIndices
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchClusterTenantQuotaStatus.
+func (in *ElasticsearchClusterTenantQuotaStatus) DeepCopy() *ElasticsearchClusterTenantQuotaStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchClusterTenantQuotaStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ElasticsearchDataSet) DeepCopyInto(out *ElasticsearchDataSet) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	in.Spec.DeepCopyInto(&out.Spec)
-	in.Status.DeepCopyInto(&out.Status)
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSet.
+func (in *ElasticsearchDataSet) DeepCopy() *ElasticsearchDataSet {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataSet)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ElasticsearchDataSet) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataSetAutoRollback) DeepCopyInto(out *ElasticsearchDataSetAutoRollback) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetAutoRollback.
+func (in *ElasticsearchDataSetAutoRollback) DeepCopy() *ElasticsearchDataSetAutoRollback {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataSetAutoRollback)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataSetBackupHooks) DeepCopyInto(out *ElasticsearchDataSetBackupHooks) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetBackupHooks.
+func (in *ElasticsearchDataSetBackupHooks) DeepCopy() *ElasticsearchDataSetBackupHooks {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataSetBackupHooks)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataSetBurstStatus) DeepCopyInto(out *ElasticsearchDataSetBurstStatus) {
+	*out = *in
+	in.Started.DeepCopyInto(&out.Started)
+	in.Expires.DeepCopyInto(&out.Expires)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetBurstStatus.
+func (in *ElasticsearchDataSetBurstStatus) DeepCopy() *ElasticsearchDataSetBurstStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataSetBurstStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataSetCPUAggregation) DeepCopyInto(out *ElasticsearchDataSetCPUAggregation) {
+	*out = *in
+	if in.TrimPercent != nil {
+		in, out := &in.TrimPercent, &out.TrimPercent
+		*out = new(int32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetCPUAggregation.
+func (in *ElasticsearchDataSetCPUAggregation) DeepCopy() *ElasticsearchDataSetCPUAggregation {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataSetCPUAggregation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataSetClusterShardLimit) DeepCopyInto(out *ElasticsearchDataSetClusterShardLimit) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetClusterShardLimit.
+func (in *ElasticsearchDataSetClusterShardLimit) DeepCopy() *ElasticsearchDataSetClusterShardLimit {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataSetClusterShardLimit)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataSetClusterShardLimitStatus) DeepCopyInto(out *ElasticsearchDataSetClusterShardLimitStatus) {
+	*out = *in
+	if in.Original != nil {
+		in, out := &in.Original, &out.Original
+		*out = new(int32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetClusterShardLimitStatus.
+func (in *ElasticsearchDataSetClusterShardLimitStatus) DeepCopy() *ElasticsearchDataSetClusterShardLimitStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataSetClusterShardLimitStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataSetDecisionWebhook) DeepCopyInto(out *ElasticsearchDataSetDecisionWebhook) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetDecisionWebhook.
+func (in *ElasticsearchDataSetDecisionWebhook) DeepCopy() *ElasticsearchDataSetDecisionWebhook {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataSetDecisionWebhook)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataSetDecommission) DeepCopyInto(out *ElasticsearchDataSetDecommission) {
+	*out = *in
+	if in.TargetGroups != nil {
+		in, out := &in.TargetGroups, &out.TargetGroups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.IngestionPause != nil {
+		in, out := &in.IngestionPause, &out.IngestionPause
+		*out = new(ElasticsearchDataSetIngestionPause)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetDecommission.
+func (in *ElasticsearchDataSetDecommission) DeepCopy() *ElasticsearchDataSetDecommission {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataSetDecommission)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataSetDiskHeadroom) DeepCopyInto(out *ElasticsearchDataSetDiskHeadroom) {
+	*out = *in
+	if in.HeadroomPercent != nil {
+		in, out := &in.HeadroomPercent, &out.HeadroomPercent
+		*out = new(int32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetDiskHeadroom.
+func (in *ElasticsearchDataSetDiskHeadroom) DeepCopy() *ElasticsearchDataSetDiskHeadroom {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataSetDiskHeadroom)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataSetDiskPreparation) DeepCopyInto(out *ElasticsearchDataSetDiskPreparation) {
+	*out = *in
+	if in.PreTouchSize != nil {
+		in, out := &in.PreTouchSize, &out.PreTouchSize
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetDiskPreparation.
+func (in *ElasticsearchDataSetDiskPreparation) DeepCopy() *ElasticsearchDataSetDiskPreparation {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataSetDiskPreparation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataSetDrainWriteBlock) DeepCopyInto(out *ElasticsearchDataSetDrainWriteBlock) {
+	*out = *in
+	if in.IndexPatterns != nil {
+		in, out := &in.IndexPatterns, &out.IndexPatterns
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetDrainWriteBlock.
+func (in *ElasticsearchDataSetDrainWriteBlock) DeepCopy() *ElasticsearchDataSetDrainWriteBlock {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataSetDrainWriteBlock)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataSetDraining) DeepCopyInto(out *ElasticsearchDataSetDraining) {
+	*out = *in
+	if in.NodeShutdown != nil {
+		in, out := &in.NodeShutdown, &out.NodeShutdown
+		*out = new(bool)
+		**out = **in
+	}
+	if in.TaskDeadlineSeconds != nil {
+		in, out := &in.TaskDeadlineSeconds, &out.TaskDeadlineSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.MaxParallelDrains != nil {
+		in, out := &in.MaxParallelDrains, &out.MaxParallelDrains
+		*out = new(int32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetDraining.
+func (in *ElasticsearchDataSetDraining) DeepCopy() *ElasticsearchDataSetDraining {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataSetDraining)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataSetElasticsearch) DeepCopyInto(out *ElasticsearchDataSetElasticsearch) {
+	*out = *in
+	if in.CredentialsSecretRef != nil {
+		in, out := &in.CredentialsSecretRef, &out.CredentialsSecretRef
+		*out = new(corev1.LocalObjectReference)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetElasticsearch.
+func (in *ElasticsearchDataSetElasticsearch) DeepCopy() *ElasticsearchDataSetElasticsearch {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataSetElasticsearch)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataSetExternalDNS) DeepCopyInto(out *ElasticsearchDataSetExternalDNS) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetExternalDNS.
+func (in *ElasticsearchDataSetExternalDNS) DeepCopy() *ElasticsearchDataSetExternalDNS {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataSetExternalDNS)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataSetGCRecycling) DeepCopyInto(out *ElasticsearchDataSetGCRecycling) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetGCRecycling.
+func (in *ElasticsearchDataSetGCRecycling) DeepCopy() *ElasticsearchDataSetGCRecycling {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataSetGCRecycling)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataSetHealth) DeepCopyInto(out *ElasticsearchDataSetHealth) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetHealth.
+func (in *ElasticsearchDataSetHealth) DeepCopy() *ElasticsearchDataSetHealth {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataSetHealth)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataSetHeapDumpUpload) DeepCopyInto(out *ElasticsearchDataSetHeapDumpUpload) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetHeapDumpUpload.
+func (in *ElasticsearchDataSetHeapDumpUpload) DeepCopy() *ElasticsearchDataSetHeapDumpUpload {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataSetHeapDumpUpload)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataSetHeapDumps) DeepCopyInto(out *ElasticsearchDataSetHeapDumps) {
+	*out = *in
+	if in.SizeLimit != nil {
+		in, out := &in.SizeLimit, &out.SizeLimit
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.Upload != nil {
+		in, out := &in.Upload, &out.Upload
+		*out = new(ElasticsearchDataSetHeapDumpUpload)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetHeapDumps.
+func (in *ElasticsearchDataSetHeapDumps) DeepCopy() *ElasticsearchDataSetHeapDumps {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataSetHeapDumps)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataSetHotShardStatus) DeepCopyInto(out *ElasticsearchDataSetHotShardStatus) {
+	*out = *in
+	in.Detected.DeepCopyInto(&out.Detected)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetHotShardStatus.
+func (in *ElasticsearchDataSetHotShardStatus) DeepCopy() *ElasticsearchDataSetHotShardStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataSetHotShardStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataSetHotShards) DeepCopyInto(out *ElasticsearchDataSetHotShards) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetHotShards.
+func (in *ElasticsearchDataSetHotShards) DeepCopy() *ElasticsearchDataSetHotShards {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataSetHotShards)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataSetIndexReplicas) DeepCopyInto(out *ElasticsearchDataSetIndexReplicas) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetIndexReplicas.
+func (in *ElasticsearchDataSetIndexReplicas) DeepCopy() *ElasticsearchDataSetIndexReplicas {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataSetIndexReplicas)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataSetIngestionPause) DeepCopyInto(out *ElasticsearchDataSetIngestionPause) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetIngestionPause.
+func (in *ElasticsearchDataSetIngestionPause) DeepCopy() *ElasticsearchDataSetIngestionPause {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataSetIngestionPause)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataSetLifecycle) DeepCopyInto(out *ElasticsearchDataSetLifecycle) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetLifecycle.
+func (in *ElasticsearchDataSetLifecycle) DeepCopy() *ElasticsearchDataSetLifecycle {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataSetLifecycle)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataSetList) DeepCopyInto(out *ElasticsearchDataSetList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ElasticsearchDataSet, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetList.
+func (in *ElasticsearchDataSetList) DeepCopy() *ElasticsearchDataSetList {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataSetList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ElasticsearchDataSetList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataSetMasterStability) DeepCopyInto(out *ElasticsearchDataSetMasterStability) {
+	*out = *in
+	if in.MaxElections != nil {
+		in, out := &in.MaxElections, &out.MaxElections
+		*out = new(int32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetMasterStability.
+func (in *ElasticsearchDataSetMasterStability) DeepCopy() *ElasticsearchDataSetMasterStability {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataSetMasterStability)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataSetMasterStatus) DeepCopyInto(out *ElasticsearchDataSetMasterStatus) {
+	*out = *in
+	if in.LastElection != nil {
+		in, out := &in.LastElection, &out.LastElection
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetMasterStatus.
+func (in *ElasticsearchDataSetMasterStatus) DeepCopy() *ElasticsearchDataSetMasterStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataSetMasterStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataSetMemoryAdjustment) DeepCopyInto(out *ElasticsearchDataSetMemoryAdjustment) {
+	*out = *in
+	out.Step = in.Step.DeepCopy()
+	out.Max = in.Max.DeepCopy()
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetMemoryAdjustment.
+func (in *ElasticsearchDataSetMemoryAdjustment) DeepCopy() *ElasticsearchDataSetMemoryAdjustment {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataSetMemoryAdjustment)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataSetOrdinalOverride) DeepCopyInto(out *ElasticsearchDataSetOrdinalOverride) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetOrdinalOverride.
+func (in *ElasticsearchDataSetOrdinalOverride) DeepCopy() *ElasticsearchDataSetOrdinalOverride {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataSetOrdinalOverride)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataSetPodFailure) DeepCopyInto(out *ElasticsearchDataSetPodFailure) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetPodFailure.
+func (in *ElasticsearchDataSetPodFailure) DeepCopy() *ElasticsearchDataSetPodFailure {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataSetPodFailure)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataSetPodSecurity) DeepCopyInto(out *ElasticsearchDataSetPodSecurity) {
+	*out = *in
+	if in.AppArmorProfile != nil {
+		in, out := &in.AppArmorProfile, &out.AppArmorProfile
+		*out = new(corev1.AppArmorProfile)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SeccompProfile != nil {
+		in, out := &in.SeccompProfile, &out.SeccompProfile
+		*out = new(corev1.SeccompProfile)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SELinuxOptions != nil {
+		in, out := &in.SELinuxOptions, &out.SELinuxOptions
+		*out = new(corev1.SELinuxOptions)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetPodSecurity.
+func (in *ElasticsearchDataSetPodSecurity) DeepCopy() *ElasticsearchDataSetPodSecurity {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataSetPodSecurity)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataSetPodServices) DeepCopyInto(out *ElasticsearchDataSetPodServices) {
+	*out = *in
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetPodServices.
+func (in *ElasticsearchDataSetPodServices) DeepCopy() *ElasticsearchDataSetPodServices {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataSetPodServices)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataSetPort) DeepCopyInto(out *ElasticsearchDataSetPort) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetPort.
+func (in *ElasticsearchDataSetPort) DeepCopy() *ElasticsearchDataSetPort {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataSetPort)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataSetPredictiveScaling) DeepCopyInto(out *ElasticsearchDataSetPredictiveScaling) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetPredictiveScaling.
+func (in *ElasticsearchDataSetPredictiveScaling) DeepCopy() *ElasticsearchDataSetPredictiveScaling {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataSetPredictiveScaling)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataSetPrimariesRecommendation) DeepCopyInto(out *ElasticsearchDataSetPrimariesRecommendation) {
+	*out = *in
+	in.Since.DeepCopyInto(&out.Since)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetPrimariesRecommendation.
+func (in *ElasticsearchDataSetPrimariesRecommendation) DeepCopy() *ElasticsearchDataSetPrimariesRecommendation {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataSetPrimariesRecommendation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataSetRebalancing) DeepCopyInto(out *ElasticsearchDataSetRebalancing) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetRebalancing.
+func (in *ElasticsearchDataSetRebalancing) DeepCopy() *ElasticsearchDataSetRebalancing {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataSetRebalancing)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataSetRevisionPods) DeepCopyInto(out *ElasticsearchDataSetRevisionPods) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetRevisionPods.
+func (in *ElasticsearchDataSetRevisionPods) DeepCopy() *ElasticsearchDataSetRevisionPods {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataSetRevisionPods)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataSetRolloutProgress) DeepCopyInto(out *ElasticsearchDataSetRolloutProgress) {
+	*out = *in
+	if in.UnhealthySince != nil {
+		in, out := &in.UnhealthySince, &out.UnhealthySince
+		*out = (*in).DeepCopy()
+	}
+	if in.Revisions != nil {
+		in, out := &in.Revisions, &out.Revisions
+		*out = make([]ElasticsearchDataSetRevisionPods, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetRolloutProgress.
+func (in *ElasticsearchDataSetRolloutProgress) DeepCopy() *ElasticsearchDataSetRolloutProgress {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataSetRolloutProgress)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataSetScaleDownRecovery) DeepCopyInto(out *ElasticsearchDataSetScaleDownRecovery) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetScaleDownRecovery.
+func (in *ElasticsearchDataSetScaleDownRecovery) DeepCopy() *ElasticsearchDataSetScaleDownRecovery {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataSetScaleDownRecovery)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataSetScaleDownRecoveryStatus) DeepCopyInto(out *ElasticsearchDataSetScaleDownRecoveryStatus) {
+	*out = *in
+	if in.PodsRemoved != nil {
+		in, out := &in.PodsRemoved, &out.PodsRemoved
+		*out = (*in).DeepCopy()
+	}
+	if in.UnhealthySince != nil {
+		in, out := &in.UnhealthySince, &out.UnhealthySince
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetScaleDownRecoveryStatus.
+func (in *ElasticsearchDataSetScaleDownRecoveryStatus) DeepCopy() *ElasticsearchDataSetScaleDownRecoveryStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataSetScaleDownRecoveryStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataSetScaling) DeepCopyInto(out *ElasticsearchDataSetScaling) {
+	*out = *in
+	if in.ScaleDownGuards != nil {
+		in, out := &in.ScaleDownGuards, &out.ScaleDownGuards
+		*out = make([]ElasticsearchDataSetScalingGuard, len(*in))
+		copy(*out, *in)
+	}
+	if in.DecisionWebhook != nil {
+		in, out := &in.DecisionWebhook, &out.DecisionWebhook
+		*out = new(ElasticsearchDataSetDecisionWebhook)
+		**out = **in
+	}
+	if in.Predictive != nil {
+		in, out := &in.Predictive, &out.Predictive
+		*out = new(ElasticsearchDataSetPredictiveScaling)
+		**out = **in
+	}
+	if in.HotShards != nil {
+		in, out := &in.HotShards, &out.HotShards
+		*out = new(ElasticsearchDataSetHotShards)
+		**out = **in
+	}
+	if in.Aggregation != nil {
+		in, out := &in.Aggregation, &out.Aggregation
+		*out = new(ElasticsearchDataSetCPUAggregation)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ShardSize != nil {
+		in, out := &in.ShardSize, &out.ShardSize
+		*out = new(ElasticsearchDataSetShardSizeGuardrail)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ClusterShardLimit != nil {
+		in, out := &in.ClusterShardLimit, &out.ClusterShardLimit
+		*out = new(ElasticsearchDataSetClusterShardLimit)
+		**out = **in
+	}
+	if in.DiskHeadroom != nil {
+		in, out := &in.DiskHeadroom, &out.DiskHeadroom
+		*out = new(ElasticsearchDataSetDiskHeadroom)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ScaleDownRecovery != nil {
+		in, out := &in.ScaleDownRecovery, &out.ScaleDownRecovery
+		*out = new(ElasticsearchDataSetScaleDownRecovery)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetScaling.
+func (in *ElasticsearchDataSetScaling) DeepCopy() *ElasticsearchDataSetScaling {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataSetScaling)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataSetScalingGuard) DeepCopyInto(out *ElasticsearchDataSetScalingGuard) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetScalingGuard.
+func (in *ElasticsearchDataSetScalingGuard) DeepCopy() *ElasticsearchDataSetScalingGuard {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataSetScalingGuard)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataSetScalingOperation) DeepCopyInto(out *ElasticsearchDataSetScalingOperation) {
+	*out = *in
+	if in.NodeReplicas != nil {
+		in, out := &in.NodeReplicas, &out.NodeReplicas
+		*out = new(int32)
+		**out = **in
+	}
+	if in.IndexReplicas != nil {
+		in, out := &in.IndexReplicas, &out.IndexReplicas
+		*out = make([]ElasticsearchDataSetIndexReplicas, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetScalingOperation.
+func (in *ElasticsearchDataSetScalingOperation) DeepCopy() *ElasticsearchDataSetScalingOperation {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataSetScalingOperation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataSetScalingState) DeepCopyInto(out *ElasticsearchDataSetScalingState) {
+	*out = *in
+	if in.Operation != nil {
+		in, out := &in.Operation, &out.Operation
+		*out = new(ElasticsearchDataSetScalingOperation)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetScalingState.
+func (in *ElasticsearchDataSetScalingState) DeepCopy() *ElasticsearchDataSetScalingState {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataSetScalingState)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataSetSchedulingGate) DeepCopyInto(out *ElasticsearchDataSetSchedulingGate) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetSchedulingGate.
+func (in *ElasticsearchDataSetSchedulingGate) DeepCopy() *ElasticsearchDataSetSchedulingGate {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataSetSchedulingGate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataSetShardSizeGuardrail) DeepCopyInto(out *ElasticsearchDataSetShardSizeGuardrail) {
+	*out = *in
+	out.MaxShardSize = in.MaxShardSize.DeepCopy()
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetShardSizeGuardrail.
+func (in *ElasticsearchDataSetShardSizeGuardrail) DeepCopy() *ElasticsearchDataSetShardSizeGuardrail {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataSetShardSizeGuardrail)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataSetSlowStart) DeepCopyInto(out *ElasticsearchDataSetSlowStart) {
+	*out = *in
+	if in.WarmupQueries != nil {
+		in, out := &in.WarmupQueries, &out.WarmupQueries
+		*out = make([]ElasticsearchDataSetWarmupQuery, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetSlowStart.
+func (in *ElasticsearchDataSetSlowStart) DeepCopy() *ElasticsearchDataSetSlowStart {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataSetSlowStart)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataSetSnapshotPeerRecovery) DeepCopyInto(out *ElasticsearchDataSetSnapshotPeerRecovery) {
+	*out = *in
+	if in.Settings != nil {
+		in, out := &in.Settings, &out.Settings
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetSnapshotPeerRecovery.
+func (in *ElasticsearchDataSetSnapshotPeerRecovery) DeepCopy() *ElasticsearchDataSetSnapshotPeerRecovery {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataSetSnapshotPeerRecovery)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataSetSpec) DeepCopyInto(out *ElasticsearchDataSetSpec) {
+	*out = *in
+	if in.Replicas != nil {
+		in, out := &in.Replicas, &out.Replicas
+		*out = new(int32)
+		**out = **in
+	}
+	in.Template.DeepCopyInto(&out.Template)
+	if in.Scaling != nil {
+		in, out := &in.Scaling, &out.Scaling
+		*out = new(ElasticsearchDataSetScaling)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.VolumeClaimTemplates != nil {
+		in, out := &in.VolumeClaimTemplates, &out.VolumeClaimTemplates
+		*out = make([]PersistentVolumeClaim, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.VolumeClaimRetention != nil {
+		in, out := &in.VolumeClaimRetention, &out.VolumeClaimRetention
+		*out = new(ElasticsearchDataSetVolumeClaimRetention)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.VolumeExpansion != nil {
+		in, out := &in.VolumeExpansion, &out.VolumeExpansion
+		*out = new(ElasticsearchDataSetVolumeExpansion)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(ElasticsearchDataSetTLS)
+		**out = **in
+	}
+	if in.Elasticsearch != nil {
+		in, out := &in.Elasticsearch, &out.Elasticsearch
+		*out = new(ElasticsearchDataSetElasticsearch)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MaxFailingPods != nil {
+		in, out := &in.MaxFailingPods, &out.MaxFailingPods
+		*out = new(int32)
+		**out = **in
+	}
+	if in.OrdinalOverrides != nil {
+		in, out := &in.OrdinalOverrides, &out.OrdinalOverrides
+		*out = make([]ElasticsearchDataSetOrdinalOverride, len(*in))
+		copy(*out, *in)
+	}
+	if in.AutoRollback != nil {
+		in, out := &in.AutoRollback, &out.AutoRollback
+		*out = new(ElasticsearchDataSetAutoRollback)
+		**out = **in
+	}
+	if in.RevisionHistoryLimit != nil {
+		in, out := &in.RevisionHistoryLimit, &out.RevisionHistoryLimit
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MemoryAdjustment != nil {
+		in, out := &in.MemoryAdjustment, &out.MemoryAdjustment
+		*out = new(ElasticsearchDataSetMemoryAdjustment)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.HeapDumps != nil {
+		in, out := &in.HeapDumps, &out.HeapDumps
+		*out = new(ElasticsearchDataSetHeapDumps)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DiskPreparation != nil {
+		in, out := &in.DiskPreparation, &out.DiskPreparation
+		*out = new(ElasticsearchDataSetDiskPreparation)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SchedulingGate != nil {
+		in, out := &in.SchedulingGate, &out.SchedulingGate
+		*out = new(ElasticsearchDataSetSchedulingGate)
+		**out = **in
+	}
+	if in.GCRecycling != nil {
+		in, out := &in.GCRecycling, &out.GCRecycling
+		*out = new(ElasticsearchDataSetGCRecycling)
+		**out = **in
+	}
+	if in.ExtraPorts != nil {
+		in, out := &in.ExtraPorts, &out.ExtraPorts
+		*out = make([]ElasticsearchDataSetPort, len(*in))
+		copy(*out, *in)
+	}
+	if in.PodServices != nil {
+		in, out := &in.PodServices, &out.PodServices
+		*out = new(ElasticsearchDataSetPodServices)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ExternalDNS != nil {
+		in, out := &in.ExternalDNS, &out.ExternalDNS
+		*out = new(ElasticsearchDataSetExternalDNS)
+		**out = **in
+	}
+	if in.AdditionalResourceLabels != nil {
+		in, out := &in.AdditionalResourceLabels, &out.AdditionalResourceLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.AdditionalResourceAnnotations != nil {
+		in, out := &in.AdditionalResourceAnnotations, &out.AdditionalResourceAnnotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.PodSecurity != nil {
+		in, out := &in.PodSecurity, &out.PodSecurity
+		*out = new(ElasticsearchDataSetPodSecurity)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Lifecycle != nil {
+		in, out := &in.Lifecycle, &out.Lifecycle
+		*out = new(ElasticsearchDataSetLifecycle)
+		**out = **in
+	}
+	if in.TerminationGracePeriod != nil {
+		in, out := &in.TerminationGracePeriod, &out.TerminationGracePeriod
+		*out = new(ElasticsearchDataSetTerminationGracePeriod)
+		**out = **in
+	}
+	if in.Health != nil {
+		in, out := &in.Health, &out.Health
+		*out = new(ElasticsearchDataSetHealth)
+		**out = **in
+	}
+	if in.CriticalIndices != nil {
+		in, out := &in.CriticalIndices, &out.CriticalIndices
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.TrafficSteering != nil {
+		in, out := &in.TrafficSteering, &out.TrafficSteering
+		*out = new(ElasticsearchDataSetTrafficSteering)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DrainWriteBlock != nil {
+		in, out := &in.DrainWriteBlock, &out.DrainWriteBlock
+		*out = new(ElasticsearchDataSetDrainWriteBlock)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MasterStability != nil {
+		in, out := &in.MasterStability, &out.MasterStability
+		*out = new(ElasticsearchDataSetMasterStability)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.BackupHooks != nil {
+		in, out := &in.BackupHooks, &out.BackupHooks
+		*out = new(ElasticsearchDataSetBackupHooks)
+		**out = **in
+	}
+	if in.VolumeSnapshotRecovery != nil {
+		in, out := &in.VolumeSnapshotRecovery, &out.VolumeSnapshotRecovery
+		*out = new(ElasticsearchDataSetVolumeSnapshotRecovery)
+		**out = **in
+	}
+	if in.SnapshotPeerRecovery != nil {
+		in, out := &in.SnapshotPeerRecovery, &out.SnapshotPeerRecovery
+		*out = new(ElasticsearchDataSetSnapshotPeerRecovery)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ZoneFailure != nil {
+		in, out := &in.ZoneFailure, &out.ZoneFailure
+		*out = new(ElasticsearchDataSetZoneFailure)
+		**out = **in
+	}
+	if in.Indices != nil {
+		in, out := &in.Indices, &out.Indices
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Decommission != nil {
+		in, out := &in.Decommission, &out.Decommission
+		*out = new(ElasticsearchDataSetDecommission)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Rebalancing != nil {
+		in, out := &in.Rebalancing, &out.Rebalancing
+		*out = new(ElasticsearchDataSetRebalancing)
+		**out = **in
+	}
+	if in.Experimental != nil {
+		in, out := &in.Experimental, &out.Experimental
+		*out = new(ExperimentalSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetSpec.
+func (in *ElasticsearchDataSetSpec) DeepCopy() *ElasticsearchDataSetSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataSetSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataSetStatus) DeepCopyInto(out *ElasticsearchDataSetStatus) {
+	*out = *in
+	if in.ObservedGeneration != nil {
+		in, out := &in.ObservedGeneration, &out.ObservedGeneration
+		*out = new(int64)
+		**out = **in
+	}
+	if in.LastScaleUpStarted != nil {
+		in, out := &in.LastScaleUpStarted, &out.LastScaleUpStarted
+		*out = (*in).DeepCopy()
+	}
+	if in.LastScaleUpEnded != nil {
+		in, out := &in.LastScaleUpEnded, &out.LastScaleUpEnded
+		*out = (*in).DeepCopy()
+	}
+	if in.LastScaleDownStarted != nil {
+		in, out := &in.LastScaleDownStarted, &out.LastScaleDownStarted
+		*out = (*in).DeepCopy()
+	}
+	if in.LastScaleDownEnded != nil {
+		in, out := &in.LastScaleDownEnded, &out.LastScaleDownEnded
+		*out = (*in).DeepCopy()
+	}
+	if in.ScalingState != nil {
+		in, out := &in.ScalingState, &out.ScalingState
+		*out = new(ElasticsearchDataSetScalingState)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ScaleDownRecovery != nil {
+		in, out := &in.ScaleDownRecovery, &out.ScaleDownRecovery
+		*out = new(ElasticsearchDataSetScaleDownRecoveryStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ResolvedImages != nil {
+		in, out := &in.ResolvedImages, &out.ResolvedImages
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.FailingPods != nil {
+		in, out := &in.FailingPods, &out.FailingPods
+		*out = make([]ElasticsearchDataSetPodFailure, len(*in))
+		copy(*out, *in)
+	}
+	if in.LastOOMKill != nil {
+		in, out := &in.LastOOMKill, &out.LastOOMKill
+		*out = (*in).DeepCopy()
+	}
+	if in.Master != nil {
+		in, out := &in.Master, &out.Master
+		*out = new(ElasticsearchDataSetMasterStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ZoneFailure != nil {
+		in, out := &in.ZoneFailure, &out.ZoneFailure
+		*out = new(ElasticsearchDataSetZoneFailureStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AllocatedIndices != nil {
+		in, out := &in.AllocatedIndices, &out.AllocatedIndices
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.IngestionPauseBlockedIndices != nil {
+		in, out := &in.IngestionPauseBlockedIndices, &out.IngestionPauseBlockedIndices
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Burst != nil {
+		in, out := &in.Burst, &out.Burst
+		*out = new(ElasticsearchDataSetBurstStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TemplateHistory != nil {
+		in, out := &in.TemplateHistory, &out.TemplateHistory
+		*out = make([]ElasticsearchDataSetTemplateRevision, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.HotShard != nil {
+		in, out := &in.HotShard, &out.HotShard
+		*out = new(ElasticsearchDataSetHotShardStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PrimariesRecommendations != nil {
+		in, out := &in.PrimariesRecommendations, &out.PrimariesRecommendations
+		*out = make([]ElasticsearchDataSetPrimariesRecommendation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ClusterShardLimit != nil {
+		in, out := &in.ClusterShardLimit, &out.ClusterShardLimit
+		*out = new(ElasticsearchDataSetClusterShardLimitStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RolloutProgress != nil {
+		in, out := &in.RolloutProgress, &out.RolloutProgress
+		*out = new(ElasticsearchDataSetRolloutProgress)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetStatus.
+func (in *ElasticsearchDataSetStatus) DeepCopy() *ElasticsearchDataSetStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataSetStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataSetTLS) DeepCopyInto(out *ElasticsearchDataSetTLS) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetTLS.
+func (in *ElasticsearchDataSetTLS) DeepCopy() *ElasticsearchDataSetTLS {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataSetTLS)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataSetTemplateRevision) DeepCopyInto(out *ElasticsearchDataSetTemplateRevision) {
+	*out = *in
+	in.Created.DeepCopyInto(&out.Created)
+	in.Template.DeepCopyInto(&out.Template)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetTemplateRevision.
+func (in *ElasticsearchDataSetTemplateRevision) DeepCopy() *ElasticsearchDataSetTemplateRevision {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataSetTemplateRevision)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataSetTerminationGracePeriod) DeepCopyInto(out *ElasticsearchDataSetTerminationGracePeriod) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetTerminationGracePeriod.
+func (in *ElasticsearchDataSetTerminationGracePeriod) DeepCopy() *ElasticsearchDataSetTerminationGracePeriod {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataSetTerminationGracePeriod)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataSetTrafficSteering) DeepCopyInto(out *ElasticsearchDataSetTrafficSteering) {
+	*out = *in
+	if in.SlowStart != nil {
+		in, out := &in.SlowStart, &out.SlowStart
+		*out = new(ElasticsearchDataSetSlowStart)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetTrafficSteering.
+func (in *ElasticsearchDataSetTrafficSteering) DeepCopy() *ElasticsearchDataSetTrafficSteering {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataSetTrafficSteering)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataSetVolumeClaimRetention) DeepCopyInto(out *ElasticsearchDataSetVolumeClaimRetention) {
+	*out = *in
+	if in.RetentionDays != nil {
+		in, out := &in.RetentionDays, &out.RetentionDays
+		*out = new(int32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetVolumeClaimRetention.
+func (in *ElasticsearchDataSetVolumeClaimRetention) DeepCopy() *ElasticsearchDataSetVolumeClaimRetention {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataSetVolumeClaimRetention)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataSetVolumeExpansion) DeepCopyInto(out *ElasticsearchDataSetVolumeExpansion) {
+	*out = *in
+	if in.Step != nil {
+		in, out := &in.Step, &out.Step
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.MaxSize != nil {
+		in, out := &in.MaxSize, &out.MaxSize
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetVolumeExpansion.
+func (in *ElasticsearchDataSetVolumeExpansion) DeepCopy() *ElasticsearchDataSetVolumeExpansion {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataSetVolumeExpansion)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataSetVolumeSnapshotRecovery) DeepCopyInto(out *ElasticsearchDataSetVolumeSnapshotRecovery) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetVolumeSnapshotRecovery.
+func (in *ElasticsearchDataSetVolumeSnapshotRecovery) DeepCopy() *ElasticsearchDataSetVolumeSnapshotRecovery {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataSetVolumeSnapshotRecovery)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataSetWarmupQuery) DeepCopyInto(out *ElasticsearchDataSetWarmupQuery) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetWarmupQuery.
+func (in *ElasticsearchDataSetWarmupQuery) DeepCopy() *ElasticsearchDataSetWarmupQuery {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataSetWarmupQuery)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataSetZoneFailure) DeepCopyInto(out *ElasticsearchDataSetZoneFailure) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetZoneFailure.
+func (in *ElasticsearchDataSetZoneFailure) DeepCopy() *ElasticsearchDataSetZoneFailure {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataSetZoneFailure)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataSetZoneFailureStatus) DeepCopyInto(out *ElasticsearchDataSetZoneFailureStatus) {
+	*out = *in
+	if in.Zones != nil {
+		in, out := &in.Zones, &out.Zones
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ForcedAwarenessValues != nil {
+		in, out := &in.ForcedAwarenessValues, &out.ForcedAwarenessValues
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.Since.DeepCopyInto(&out.Since)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSet.
-func (in *ElasticsearchDataSet) DeepCopy() *ElasticsearchDataSet {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetZoneFailureStatus.
+func (in *ElasticsearchDataSetZoneFailureStatus) DeepCopy() *ElasticsearchDataSetZoneFailureStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(ElasticsearchDataSet)
+	out := new(ElasticsearchDataSetZoneFailureStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *ElasticsearchDataSet) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchMetric) DeepCopyInto(out *ElasticsearchMetric) {
+	*out = *in
+	in.Timestamp.DeepCopyInto(&out.Timestamp)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchMetric.
+func (in *ElasticsearchMetric) DeepCopy() *ElasticsearchMetric {
+	if in == nil {
+		return nil
 	}
-	return nil
+	out := new(ElasticsearchMetric)
+	in.DeepCopyInto(out)
+	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ElasticsearchDataSetDraining) DeepCopyInto(out *ElasticsearchDataSetDraining) {
+func (in *ElasticsearchMetricBaseline) DeepCopyInto(out *ElasticsearchMetricBaseline) {
 	*out = *in
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetDraining.
-func (in *ElasticsearchDataSetDraining) DeepCopy() *ElasticsearchDataSetDraining {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchMetricBaseline.
+func (in *ElasticsearchMetricBaseline) DeepCopy() *ElasticsearchMetricBaseline {
 	if in == nil {
 		return nil
 	}
-	out := new(ElasticsearchDataSetDraining)
+	out := new(ElasticsearchMetricBaseline)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ElasticsearchDataSetList) DeepCopyInto(out *ElasticsearchDataSetList) {
+func (in *ElasticsearchMetricCapacity) DeepCopyInto(out *ElasticsearchMetricCapacity) {
+	*out = *in
+	out.RequestedCPU = in.RequestedCPU.DeepCopy()
+	out.UsedCPU = in.UsedCPU.DeepCopy()
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchMetricCapacity.
+func (in *ElasticsearchMetricCapacity) DeepCopy() *ElasticsearchMetricCapacity {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchMetricCapacity)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchMetricSample) DeepCopyInto(out *ElasticsearchMetricSample) {
+	*out = *in
+	in.Timestamp.DeepCopyInto(&out.Timestamp)
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchMetricSample.
+func (in *ElasticsearchMetricSample) DeepCopy() *ElasticsearchMetricSample {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchMetricSample)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchMetricSet) DeepCopyInto(out *ElasticsearchMetricSet) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	if in.Metrics != nil {
+		in, out := &in.Metrics, &out.Metrics
+		*out = make([]ElasticsearchMetric, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Baseline != nil {
+		in, out := &in.Baseline, &out.Baseline
+		*out = make([]ElasticsearchMetricBaseline, len(*in))
+		copy(*out, *in)
+	}
+	if in.Samples != nil {
+		in, out := &in.Samples, &out.Samples
+		*out = make([]ElasticsearchMetricSample, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Capacity != nil {
+		in, out := &in.Capacity, &out.Capacity
+		*out = new(ElasticsearchMetricCapacity)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchMetricSet.
+func (in *ElasticsearchMetricSet) DeepCopy() *ElasticsearchMetricSet {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchMetricSet)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ElasticsearchMetricSet) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchMetricSetList) DeepCopyInto(out *ElasticsearchMetricSetList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]ElasticsearchDataSet, len(*in))
+		*out = make([]ElasticsearchMetricSet, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -84,18 +1980,18 @@ func (in *ElasticsearchDataSetList) DeepCopyInto(out *ElasticsearchDataSetList)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetList.
-func (in *ElasticsearchDataSetList) DeepCopy() *ElasticsearchDataSetList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchMetricSetList.
+func (in *ElasticsearchMetricSetList) DeepCopy() *ElasticsearchMetricSetList {
 	if in == nil {
 		return nil
 	}
-	out := new(ElasticsearchDataSetList)
+	out := new(ElasticsearchMetricSetList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *ElasticsearchDataSetList) DeepCopyObject() runtime.Object {
+func (in *ElasticsearchMetricSetList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -103,141 +1999,143 @@ func (in *ElasticsearchDataSetList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ElasticsearchDataSetScaling) DeepCopyInto(out *ElasticsearchDataSetScaling) {
+func (in *ElasticsearchRestore) DeepCopyInto(out *ElasticsearchRestore) {
 	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetScaling.
-func (in *ElasticsearchDataSetScaling) DeepCopy() *ElasticsearchDataSetScaling {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchRestore.
+func (in *ElasticsearchRestore) DeepCopy() *ElasticsearchRestore {
 	if in == nil {
 		return nil
 	}
-	out := new(ElasticsearchDataSetScaling)
+	out := new(ElasticsearchRestore)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ElasticsearchRestore) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ElasticsearchDataSetSpec) DeepCopyInto(out *ElasticsearchDataSetSpec) {
+func (in *ElasticsearchRestoreList) DeepCopyInto(out *ElasticsearchRestoreList) {
 	*out = *in
-	if in.Replicas != nil {
-		in, out := &in.Replicas, &out.Replicas
-		*out = new(int32)
-		**out = **in
-	}
-	in.Template.DeepCopyInto(&out.Template)
-	if in.Scaling != nil {
-		in, out := &in.Scaling, &out.Scaling
-		*out = new(ElasticsearchDataSetScaling)
-		**out = **in
-	}
-	if in.VolumeClaimTemplates != nil {
-		in, out := &in.VolumeClaimTemplates, &out.VolumeClaimTemplates
-		*out = make([]PersistentVolumeClaim, len(*in))
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ElasticsearchRestore, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
-	if in.Experimental != nil {
-		in, out := &in.Experimental, &out.Experimental
-		*out = new(ExperimentalSpec)
-		(*in).DeepCopyInto(*out)
-	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetSpec.
-func (in *ElasticsearchDataSetSpec) DeepCopy() *ElasticsearchDataSetSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchRestoreList.
+func (in *ElasticsearchRestoreList) DeepCopy() *ElasticsearchRestoreList {
 	if in == nil {
 		return nil
 	}
-	out := new(ElasticsearchDataSetSpec)
+	out := new(ElasticsearchRestoreList)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ElasticsearchRestoreList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ElasticsearchDataSetStatus) DeepCopyInto(out *ElasticsearchDataSetStatus) {
+func (in *ElasticsearchRestoreSpec) DeepCopyInto(out *ElasticsearchRestoreSpec) {
 	*out = *in
-	if in.ObservedGeneration != nil {
-		in, out := &in.ObservedGeneration, &out.ObservedGeneration
-		*out = new(int64)
-		**out = **in
+	if in.Indices != nil {
+		in, out := &in.Indices, &out.Indices
+		*out = make([]string, len(*in))
+		copy(*out, *in)
 	}
-	if in.LastScaleUpStarted != nil {
-		in, out := &in.LastScaleUpStarted, &out.LastScaleUpStarted
-		*out = (*in).DeepCopy()
-	}
-	if in.LastScaleUpEnded != nil {
-		in, out := &in.LastScaleUpEnded, &out.LastScaleUpEnded
-		*out = (*in).DeepCopy()
-	}
-	if in.LastScaleDownStarted != nil {
-		in, out := &in.LastScaleDownStarted, &out.LastScaleDownStarted
-		*out = (*in).DeepCopy()
+	if in.IndexReplicas != nil {
+		in, out := &in.IndexReplicas, &out.IndexReplicas
+		*out = new(int32)
+		**out = **in
 	}
-	if in.LastScaleDownEnded != nil {
-		in, out := &in.LastScaleDownEnded, &out.LastScaleDownEnded
-		*out = (*in).DeepCopy()
+	if in.MinReplicas != nil {
+		in, out := &in.MinReplicas, &out.MinReplicas
+		*out = new(int32)
+		**out = **in
 	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetStatus.
-func (in *ElasticsearchDataSetStatus) DeepCopy() *ElasticsearchDataSetStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchRestoreSpec.
+func (in *ElasticsearchRestoreSpec) DeepCopy() *ElasticsearchRestoreSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(ElasticsearchDataSetStatus)
+	out := new(ElasticsearchRestoreSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ElasticsearchMetric) DeepCopyInto(out *ElasticsearchMetric) {
+func (in *ElasticsearchRestoreStatus) DeepCopyInto(out *ElasticsearchRestoreStatus) {
 	*out = *in
-	in.Timestamp.DeepCopyInto(&out.Timestamp)
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchMetric.
-func (in *ElasticsearchMetric) DeepCopy() *ElasticsearchMetric {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchRestoreStatus.
+func (in *ElasticsearchRestoreStatus) DeepCopy() *ElasticsearchRestoreStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(ElasticsearchMetric)
+	out := new(ElasticsearchRestoreStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ElasticsearchMetricSet) DeepCopyInto(out *ElasticsearchMetricSet) {
+func (in *ElasticsearchRollout) DeepCopyInto(out *ElasticsearchRollout) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	if in.Metrics != nil {
-		in, out := &in.Metrics, &out.Metrics
-		*out = make([]ElasticsearchMetric, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchMetricSet.
-func (in *ElasticsearchMetricSet) DeepCopy() *ElasticsearchMetricSet {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchRollout.
+func (in *ElasticsearchRollout) DeepCopy() *ElasticsearchRollout {
 	if in == nil {
 		return nil
 	}
-	out := new(ElasticsearchMetricSet)
+	out := new(ElasticsearchRollout)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *ElasticsearchMetricSet) DeepCopyObject() runtime.Object {
+func (in *ElasticsearchRollout) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -245,13 +2143,13 @@ func (in *ElasticsearchMetricSet) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ElasticsearchMetricSetList) DeepCopyInto(out *ElasticsearchMetricSetList) {
+func (in *ElasticsearchRolloutList) DeepCopyInto(out *ElasticsearchRolloutList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]ElasticsearchMetricSet, len(*in))
+		*out = make([]ElasticsearchRollout, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -259,24 +2157,71 @@ func (in *ElasticsearchMetricSetList) DeepCopyInto(out *ElasticsearchMetricSetLi
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchMetricSetList.
-func (in *ElasticsearchMetricSetList) DeepCopy() *ElasticsearchMetricSetList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchRolloutList.
+func (in *ElasticsearchRolloutList) DeepCopy() *ElasticsearchRolloutList {
 	if in == nil {
 		return nil
 	}
-	out := new(ElasticsearchMetricSetList)
+	out := new(ElasticsearchRolloutList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *ElasticsearchMetricSetList) DeepCopyObject() runtime.Object {
+func (in *ElasticsearchRolloutList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchRolloutSpec) DeepCopyInto(out *ElasticsearchRolloutSpec) {
+	*out = *in
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Waves != nil {
+		in, out := &in.Waves, &out.Waves
+		*out = make([]intstr.IntOrString, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchRolloutSpec.
+func (in *ElasticsearchRolloutSpec) DeepCopy() *ElasticsearchRolloutSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchRolloutSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchRolloutStatus) DeepCopyInto(out *ElasticsearchRolloutStatus) {
+	*out = *in
+	if in.UpdatedDataSets != nil {
+		in, out := &in.UpdatedDataSets, &out.UpdatedDataSets
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchRolloutStatus.
+func (in *ElasticsearchRolloutStatus) DeepCopy() *ElasticsearchRolloutStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchRolloutStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EmbeddedObjectMeta) DeepCopyInto(out *EmbeddedObjectMeta) {
 	*out = *in
@@ -343,7 +2288,7 @@ func (in *ExperimentalSpec) DeepCopyInto(out *ExperimentalSpec) {
 	if in.Draining != nil {
 		in, out := &in.Draining, &out.Draining
 		*out = new(ElasticsearchDataSetDraining)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	return
 }
@@ -0,0 +1,251 @@
+package v2
+
+import (
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+)
+
+const (
+	defaultDrainingMaxRetries                     = 999
+	defaultDrainingMinimumWaitTimeDurationSeconds = 10
+	defaultDrainingMaximumWaitTimeDurationSeconds = 30
+)
+
+// ConvertFromV1 converts the v1 EDS to v2.
+func ConvertFromV1(in *zv1.ElasticsearchDataSet) *ElasticsearchDataSet {
+	in = in.DeepCopy()
+	spec := in.Spec
+	out := &ElasticsearchDataSet{
+		TypeMeta:   in.TypeMeta,
+		ObjectMeta: in.ObjectMeta,
+		Spec: ElasticsearchDataSetSpec{
+			Replicas:                      spec.Replicas,
+			ExcludeSystemIndices:          spec.ExcludeSystemIndices,
+			Role:                          spec.Role,
+			Template:                      spec.Template,
+			Scaling:                       convertScalingFromV1(spec.Scaling),
+			Draining:                      convertDrainingFromV1(&spec),
+			VolumeClaimTemplates:          spec.VolumeClaimTemplates,
+			VolumeClaimRetention:          spec.VolumeClaimRetention,
+			VolumeExpansion:               spec.VolumeExpansion,
+			TLS:                           spec.TLS,
+			Elasticsearch:                 spec.Elasticsearch,
+			MaxFailingPods:                spec.MaxFailingPods,
+			OrdinalOverrides:              spec.OrdinalOverrides,
+			AutoRollback:                  spec.AutoRollback,
+			RevisionHistoryLimit:          spec.RevisionHistoryLimit,
+			MemoryAdjustment:              spec.MemoryAdjustment,
+			HeapDumps:                     spec.HeapDumps,
+			DiskPreparation:               spec.DiskPreparation,
+			SchedulingGate:                spec.SchedulingGate,
+			GCRecycling:                   spec.GCRecycling,
+			ExtraPorts:                    spec.ExtraPorts,
+			PodServices:                   spec.PodServices,
+			ExternalDNS:                   spec.ExternalDNS,
+			Architecture:                  spec.Architecture,
+			AdditionalResourceLabels:      spec.AdditionalResourceLabels,
+			AdditionalResourceAnnotations: spec.AdditionalResourceAnnotations,
+			PodSecurity:                   spec.PodSecurity,
+			Lifecycle:                     spec.Lifecycle,
+			TerminationGracePeriod:        spec.TerminationGracePeriod,
+			Health:                        spec.Health,
+			CriticalIndices:               spec.CriticalIndices,
+			TrafficSteering:               spec.TrafficSteering,
+			MasterStability:               spec.MasterStability,
+			BackupHooks:                   spec.BackupHooks,
+			VolumeSnapshotRecovery:        spec.VolumeSnapshotRecovery,
+			SnapshotPeerRecovery:          spec.SnapshotPeerRecovery,
+			ZoneFailure:                   spec.ZoneFailure,
+			Indices:                       spec.Indices,
+			Decommission:                  spec.Decommission,
+			Rebalancing:                   spec.Rebalancing,
+		},
+		Status: in.Status,
+	}
+	out.APIVersion = SchemeGroupVersion.String()
+	return out
+}
+
+// ConvertToV1 converts the v2 EDS to v1.
+func ConvertToV1(in *ElasticsearchDataSet) *zv1.ElasticsearchDataSet {
+	in = in.DeepCopy()
+	spec := in.Spec
+	out := &zv1.ElasticsearchDataSet{
+		TypeMeta:   in.TypeMeta,
+		ObjectMeta: in.ObjectMeta,
+		Spec: zv1.ElasticsearchDataSetSpec{
+			Replicas:                      spec.Replicas,
+			ExcludeSystemIndices:          spec.ExcludeSystemIndices,
+			Role:                          spec.Role,
+			Template:                      spec.Template,
+			Scaling:                       convertScalingToV1(spec.Scaling),
+			VolumeClaimTemplates:          spec.VolumeClaimTemplates,
+			VolumeClaimRetention:          spec.VolumeClaimRetention,
+			VolumeExpansion:               spec.VolumeExpansion,
+			TLS:                           spec.TLS,
+			Elasticsearch:                 spec.Elasticsearch,
+			MaxFailingPods:                spec.MaxFailingPods,
+			OrdinalOverrides:              spec.OrdinalOverrides,
+			AutoRollback:                  spec.AutoRollback,
+			RevisionHistoryLimit:          spec.RevisionHistoryLimit,
+			MemoryAdjustment:              spec.MemoryAdjustment,
+			HeapDumps:                     spec.HeapDumps,
+			DiskPreparation:               spec.DiskPreparation,
+			SchedulingGate:                spec.SchedulingGate,
+			GCRecycling:                   spec.GCRecycling,
+			ExtraPorts:                    spec.ExtraPorts,
+			PodServices:                   spec.PodServices,
+			ExternalDNS:                   spec.ExternalDNS,
+			Architecture:                  spec.Architecture,
+			AdditionalResourceLabels:      spec.AdditionalResourceLabels,
+			AdditionalResourceAnnotations: spec.AdditionalResourceAnnotations,
+			PodSecurity:                   spec.PodSecurity,
+			Lifecycle:                     spec.Lifecycle,
+			TerminationGracePeriod:        spec.TerminationGracePeriod,
+			Health:                        spec.Health,
+			CriticalIndices:               spec.CriticalIndices,
+			TrafficSteering:               spec.TrafficSteering,
+			MasterStability:               spec.MasterStability,
+			BackupHooks:                   spec.BackupHooks,
+			VolumeSnapshotRecovery:        spec.VolumeSnapshotRecovery,
+			SnapshotPeerRecovery:          spec.SnapshotPeerRecovery,
+			ZoneFailure:                   spec.ZoneFailure,
+			Indices:                       spec.Indices,
+			Decommission:                  spec.Decommission,
+			Rebalancing:                   spec.Rebalancing,
+		},
+		Status: in.Status,
+	}
+	convertDrainingToV1(spec.Draining, &out.Spec)
+	out.APIVersion = zv1.SchemeGroupVersion.String()
+	return out
+}
+
+func convertScalingFromV1(in *zv1.ElasticsearchDataSetScaling) *ElasticsearchDataSetScaling {
+	if in == nil {
+		return nil
+	}
+	return &ElasticsearchDataSetScaling{
+		Enabled:              in.Enabled,
+		Shadow:               in.Shadow,
+		Replicas:             ElasticsearchDataSetScalingRange{Min: in.MinReplicas, Max: in.MaxReplicas},
+		OverprovisionPercent: in.OverprovisionPercent,
+		IndexReplicas:        ElasticsearchDataSetScalingRange{Min: in.MinIndexReplicas, Max: in.MaxIndexReplicas},
+		ShardsPerNode:        ElasticsearchDataSetScalingRange{Min: in.MinShardsPerNode, Max: in.MaxShardsPerNode},
+		ScaleUp: ElasticsearchDataSetScalingRule{
+			CPUBoundary:              in.ScaleUpCPUBoundary,
+			ThresholdDurationSeconds: in.ScaleUpThresholdDurationSeconds,
+			CooldownSeconds:          in.ScaleUpCooldownSeconds,
+		},
+		ScaleDown: ElasticsearchDataSetScaleDown{
+			ElasticsearchDataSetScalingRule: ElasticsearchDataSetScalingRule{
+				CPUBoundary:              in.ScaleDownCPUBoundary,
+				ThresholdDurationSeconds: in.ScaleDownThresholdDurationSeconds,
+				CooldownSeconds:          in.ScaleDownCooldownSeconds,
+			},
+			DiskUsagePercentWatermark: in.DiskUsagePercentScaledownWatermark,
+			Guards:                    in.ScaleDownGuards,
+			ClusterShardLimit:         in.ClusterShardLimit,
+			DiskHeadroom:              in.DiskHeadroom,
+			Recovery:                  in.ScaleDownRecovery,
+		},
+		CaptureHotThreads: in.CaptureHotThreads,
+		DecisionWebhook:   in.DecisionWebhook,
+		Predictive:        in.Predictive,
+		HotShards:         in.HotShards,
+		Aggregation:       in.Aggregation,
+		ShardSize:         in.ShardSize,
+	}
+}
+
+func convertScalingToV1(in *ElasticsearchDataSetScaling) *zv1.ElasticsearchDataSetScaling {
+	if in == nil {
+		return nil
+	}
+	return &zv1.ElasticsearchDataSetScaling{
+		Enabled:                            in.Enabled,
+		Shadow:                             in.Shadow,
+		MinReplicas:                        in.Replicas.Min,
+		MaxReplicas:                        in.Replicas.Max,
+		OverprovisionPercent:               in.OverprovisionPercent,
+		MinIndexReplicas:                   in.IndexReplicas.Min,
+		MaxIndexReplicas:                   in.IndexReplicas.Max,
+		MinShardsPerNode:                   in.ShardsPerNode.Min,
+		MaxShardsPerNode:                   in.ShardsPerNode.Max,
+		ScaleUpCPUBoundary:                 in.ScaleUp.CPUBoundary,
+		ScaleUpThresholdDurationSeconds:    in.ScaleUp.ThresholdDurationSeconds,
+		ScaleUpCooldownSeconds:             in.ScaleUp.CooldownSeconds,
+		ScaleDownCPUBoundary:               in.ScaleDown.CPUBoundary,
+		ScaleDownThresholdDurationSeconds:  in.ScaleDown.ThresholdDurationSeconds,
+		ScaleDownCooldownSeconds:           in.ScaleDown.CooldownSeconds,
+		DiskUsagePercentScaledownWatermark: in.ScaleDown.DiskUsagePercentWatermark,
+		CaptureHotThreads:                  in.CaptureHotThreads,
+		ScaleDownGuards:                    in.ScaleDown.Guards,
+		DecisionWebhook:                    in.DecisionWebhook,
+		Predictive:                         in.Predictive,
+		HotShards:                          in.HotShards,
+		Aggregation:                        in.Aggregation,
+		ShardSize:                          in.ShardSize,
+		ClusterShardLimit:                  in.ScaleDown.ClusterShardLimit,
+		DiskHeadroom:                       in.ScaleDown.DiskHeadroom,
+		ScaleDownRecovery:                  in.ScaleDown.Recovery,
+	}
+}
+
+// convertDrainingFromV1 gathers skipDraining, drainWriteBlock and
+// experimental.draining of the v1 spec.
+func convertDrainingFromV1(spec *zv1.ElasticsearchDataSetSpec) *ElasticsearchDataSetDraining {
+	out := &ElasticsearchDataSetDraining{
+		Skip:       spec.SkipDraining,
+		WriteBlock: spec.DrainWriteBlock,
+	}
+	if spec.Experimental != nil && spec.Experimental.Draining != nil {
+		in := spec.Experimental.Draining
+		out.MaxRetries = &in.MaxRetries
+		out.MinimumWaitTimeDurationSeconds = &in.MinimumWaitTimeDurationSeconds
+		out.MaximumWaitTimeDurationSeconds = &in.MaximumWaitTimeDurationSeconds
+		out.NodeShutdown = in.NodeShutdown
+		out.TaskDeadlineSeconds = in.TaskDeadlineSeconds
+		out.CancelTasks = in.CancelTasks
+		out.MaxParallelDrains = in.MaxParallelDrains
+	}
+	if *out == (ElasticsearchDataSetDraining{}) {
+		return nil
+	}
+	return out
+}
+
+// convertDrainingToV1 sets skipDraining, drainWriteBlock and
+// experimental.draining of the v1 spec. The settings left out of the
+// draining section are set to their defaults if experimental.draining is
+// needed for the others.
+func convertDrainingToV1(in *ElasticsearchDataSetDraining, spec *zv1.ElasticsearchDataSetSpec) {
+	if in == nil {
+		return
+	}
+	spec.SkipDraining = in.Skip
+	spec.DrainWriteBlock = in.WriteBlock
+	if in.MaxRetries == nil && in.MinimumWaitTimeDurationSeconds == nil && in.MaximumWaitTimeDurationSeconds == nil && in.NodeShutdown == nil &&
+		in.TaskDeadlineSeconds == nil && !in.CancelTasks && in.MaxParallelDrains == nil {
+		return
+	}
+
+	draining := &zv1.ElasticsearchDataSetDraining{
+		MaxRetries:                     defaultDrainingMaxRetries,
+		MinimumWaitTimeDurationSeconds: defaultDrainingMinimumWaitTimeDurationSeconds,
+		MaximumWaitTimeDurationSeconds: defaultDrainingMaximumWaitTimeDurationSeconds,
+		NodeShutdown:                   in.NodeShutdown,
+		TaskDeadlineSeconds:            in.TaskDeadlineSeconds,
+		CancelTasks:                    in.CancelTasks,
+		MaxParallelDrains:              in.MaxParallelDrains,
+	}
+	if in.MaxRetries != nil {
+		draining.MaxRetries = *in.MaxRetries
+	}
+	if in.MinimumWaitTimeDurationSeconds != nil {
+		draining.MinimumWaitTimeDurationSeconds = *in.MinimumWaitTimeDurationSeconds
+	}
+	if in.MaximumWaitTimeDurationSeconds != nil {
+		draining.MaximumWaitTimeDurationSeconds = *in.MaximumWaitTimeDurationSeconds
+	}
+	spec.Experimental = &zv1.ExperimentalSpec{Draining: draining}
+}
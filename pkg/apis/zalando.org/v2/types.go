@@ -0,0 +1,442 @@
+package v2
+
+import (
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+
+// ElasticsearchDataSet describes an Elasticsearch dataset which is operated
+// by the es-operator. It's converted from and to the v1 version, which is
+// the version stored and operated on.
+// +k8s:deepcopy-gen=true
+// +kubebuilder:resource:categories="all",shortName=eds
+// +kubebuilder:unservedversion
+// +kubebuilder:printcolumn:name="Desired",type=integer,JSONPath=`.spec.replicas`,description="The desired number of replicas for the stateful set"
+// +kubebuilder:printcolumn:name="Current",type=integer,JSONPath=`.status.replicas`,description="The current number of replicas for the stateful set"
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`,description="Whether the latest generation is reconciled and all desired Pods are updated and ready"
+// +kubebuilder:printcolumn:name="Progress",type=integer,JSONPath=`.status.rolloutProgress.progressPercent`,description="The percentage of updated and ready Pods of the ongoing rollout",priority=1
+// +kubebuilder:subresource:status
+// +kubebuilder:subresource:scale:specpath=.spec.replicas,statuspath=.status.replicas,selectorpath=.status.selector
+type ElasticsearchDataSet struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ElasticsearchDataSetSpec `json:"spec"`
+	// +optional
+	Status zv1.ElasticsearchDataSetStatus `json:"status"`
+}
+
+// ElasticsearchDataSetSpec is the spec part of the Elasticsearch dataset.
+// Compared to v1 the scaling settings are grouped by what they bound and
+// all settings of draining pods are gathered in the draining section.
+// +k8s:deepcopy-gen=true
+type ElasticsearchDataSetSpec struct {
+	// Number of desired pods. This is a pointer to distinguish between explicit
+	// zero and not specified. Defaults to 1.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Replicas *int32 `json:"replicas,omitempty"`
+
+	// Exclude management of System Indices on this Data Set. Defaults to false
+	// +optional
+	ExcludeSystemIndices bool `json:"excludeSystemIndices"`
+
+	// Role is the role of the Elasticsearch nodes of the EDS, 'data' or
+	// 'master'. Defaults to 'data'.
+	// +optional
+	Role zv1.ElasticsearchDataSetRole `json:"role,omitempty"`
+
+	// Template describes the pods that will be created.
+	Template zv1.PodTemplateSpec `json:"template"`
+
+	// Scaling describes the scaling properties.
+	// +optional
+	Scaling *ElasticsearchDataSetScaling `json:"scaling,omitempty"`
+
+	// Draining configures how pods are drained before they are deleted.
+	// +optional
+	Draining *ElasticsearchDataSetDraining `json:"draining,omitempty"`
+
+	// Template describe the volumeClaimTemplates
+	VolumeClaimTemplates []zv1.PersistentVolumeClaim `json:"volumeClaimTemplates,omitempty"`
+
+	// VolumeClaimRetention configures what happens to the volume claims of
+	// pods removed by scale-downs. Claims are retained if not set.
+	// +optional
+	VolumeClaimRetention *zv1.ElasticsearchDataSetVolumeClaimRetention `json:"volumeClaimRetention,omitempty"`
+
+	// VolumeExpansion grows the volume claims of the pods when their disk
+	// usage reaches a threshold.
+	// +optional
+	VolumeExpansion *zv1.ElasticsearchDataSetVolumeExpansion `json:"volumeExpansion,omitempty"`
+
+	// TLS makes the operator connect to Elasticsearch with HTTPS.
+	// +optional
+	TLS *zv1.ElasticsearchDataSetTLS `json:"tls,omitempty"`
+
+	// Elasticsearch configures how the operator talks to Elasticsearch.
+	// +optional
+	Elasticsearch *zv1.ElasticsearchDataSetElasticsearch `json:"elasticsearch,omitempty"`
+
+	// MaxFailingPods pauses rolling updates of the EDS while more than the
+	// given number of pods are failing, e.g. in CrashLoopBackOff. Rolling
+	// updates are never paused if not set.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxFailingPods *int32 `json:"maxFailingPods,omitempty"`
+
+	// OrdinalOverrides configure JVM options and the relative capacity of
+	// ranges of Pod ordinals, e.g. for a few larger anchor nodes.
+	// +optional
+	OrdinalOverrides []zv1.ElasticsearchDataSetOrdinalOverride `json:"ordinalOverrides,omitempty"`
+
+	// AutoRollback configures rolling back the Pod template to the previous
+	// revision when a rollout fails.
+	// +optional
+	AutoRollback *zv1.ElasticsearchDataSetAutoRollback `json:"autoRollback,omitempty"`
+
+	// RevisionHistoryLimit is the number of Pod template revisions kept in
+	// the status for rollbacks. Defaults to 5.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	RevisionHistoryLimit *int32 `json:"revisionHistoryLimit,omitempty"`
+
+	// MemoryAdjustment increases the memory of the pods when they are
+	// repeatedly OOMKilled.
+	// +optional
+	MemoryAdjustment *zv1.ElasticsearchDataSetMemoryAdjustment `json:"memoryAdjustment,omitempty"`
+
+	// HeapDumps configures the JVM to write heap dumps and fatal error
+	// logs to a volume and optionally uploads them to object storage.
+	// +optional
+	HeapDumps *zv1.ElasticsearchDataSetHeapDumps `json:"heapDumps,omitempty"`
+
+	// DiskPreparation adds an init container pre-touching the data volume
+	// and checking its mount options before Elasticsearch starts.
+	// +optional
+	DiskPreparation *zv1.ElasticsearchDataSetDiskPreparation `json:"diskPreparation,omitempty"`
+
+	// SchedulingGate holds new pods back from being scheduled until the
+	// cluster health reaches a minimum status.
+	// +optional
+	SchedulingGate *zv1.ElasticsearchDataSetSchedulingGate `json:"schedulingGate,omitempty"`
+
+	// GCRecycling recycles pods with sustained high old generation heap
+	// usage.
+	// +optional
+	GCRecycling *zv1.ElasticsearchDataSetGCRecycling `json:"gcRecycling,omitempty"`
+
+	// ExtraPorts are additional ports, e.g. of a metrics exporter sidecar,
+	// added to the containers of the pods and to the EDS service.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	ExtraPorts []zv1.ElasticsearchDataSetPort `json:"extraPorts,omitempty"`
+
+	// PodServices creates a Service with a stable name and IP for each
+	// pod of the EDS, e.g. for clients or remote cluster seeds pinned to
+	// individual nodes. Services of removed pods are deleted.
+	// +optional
+	PodServices *zv1.ElasticsearchDataSetPodServices `json:"podServices,omitempty"`
+
+	// ExternalDNS publishes the addresses of the pods serving clients as
+	// a DNS record managed by external-dns, for clients outside of the
+	// cluster. Pods are removed from the record before they are drained.
+	// +optional
+	ExternalDNS *zv1.ElasticsearchDataSetExternalDNS `json:"externalDNS,omitempty"`
+
+	// Architecture schedules the pods on nodes of the CPU architecture
+	// only. The images are checked to support the platform when image
+	// digests are pinned.
+	// +optional
+	Architecture zv1.ElasticsearchDataSetArchitecture `json:"architecture,omitempty"`
+
+	// AdditionalResourceLabels are added to the StatefulSet, pods,
+	// Service and PodDisruptionBudget of the EDS. Changes are applied to
+	// the existing pods without recreating them. Labels of the label
+	// selector can't be overridden.
+	// +optional
+	AdditionalResourceLabels map[string]string `json:"additionalResourceLabels,omitempty"`
+
+	// AdditionalResourceAnnotations are added to the StatefulSet, pods,
+	// Service and PodDisruptionBudget of the EDS. Changes are applied to
+	// the existing pods without recreating them.
+	// +optional
+	AdditionalResourceAnnotations map[string]string `json:"additionalResourceAnnotations,omitempty"`
+
+	// PodSecurity makes the pods comply with a Pod Security Standard
+	// profile and configures the AppArmor, seccomp and SELinux profiles
+	// of the Elasticsearch container.
+	// +optional
+	PodSecurity *zv1.ElasticsearchDataSetPodSecurity `json:"podSecurity,omitempty"`
+
+	// Lifecycle coordinates the termination of the containers of the
+	// pods with the operator.
+	// +optional
+	Lifecycle *zv1.ElasticsearchDataSetLifecycle `json:"lifecycle,omitempty"`
+
+	// TerminationGracePeriod sizes the termination grace period of pods
+	// deleted by the operator based on the data they hold.
+	// +optional
+	TerminationGracePeriod *zv1.ElasticsearchDataSetTerminationGracePeriod `json:"terminationGracePeriod,omitempty"`
+
+	// Health configures the health check used for pod readiness and to
+	// gate operations of the operator.
+	// +optional
+	Health *zv1.ElasticsearchDataSetHealth `json:"health,omitempty"`
+
+	// CriticalIndices are index patterns whose health must be green before
+	// and while pods are drained. A drain is aborted and the pod is
+	// included in the shard allocation again if they degrade.
+	// +optional
+	CriticalIndices []string `json:"criticalIndices,omitempty"`
+
+	// TrafficSteering removes pods from the endpoints of Services with a
+	// readiness gate before they are deleted, such that in-flight
+	// requests of clients complete, and optionally adds restarted pods
+	// only once they are warm.
+	// +optional
+	TrafficSteering *zv1.ElasticsearchDataSetTrafficSteering `json:"trafficSteering,omitempty"`
+
+	// MasterStability configures the monitoring of the elected master of
+	// the cluster. Pods are not drained while the master is unstable.
+	// +optional
+	MasterStability *zv1.ElasticsearchDataSetMasterStability `json:"masterStability,omitempty"`
+
+	// BackupHooks configures Velero backup hooks which flush the indices
+	// before the volumes of the pods are snapshotted. Scaling and draining
+	// are paused while a Velero backup of the namespace is in progress.
+	// +optional
+	BackupHooks *zv1.ElasticsearchDataSetBackupHooks `json:"backupHooks,omitempty"`
+
+	// VolumeSnapshotRecovery configures creating the volumes of new pods
+	// from a recent VolumeSnapshot of another pod on scale-up, so
+	// Elasticsearch only needs to recover the changes since the snapshot.
+	// +optional
+	VolumeSnapshotRecovery *zv1.ElasticsearchDataSetVolumeSnapshotRecovery `json:"volumeSnapshotRecovery,omitempty"`
+
+	// SnapshotPeerRecovery configures a snapshot repository used for peer
+	// recoveries, so new nodes recover most of their data from the
+	// repository instead of from other nodes.
+	// +optional
+	SnapshotPeerRecovery *zv1.ElasticsearchDataSetSnapshotPeerRecovery `json:"snapshotPeerRecovery,omitempty"`
+
+	// ZoneFailure configures the handling of the loss of an availability
+	// zone. Replacement pods are added while a zone is lost.
+	// +optional
+	ZoneFailure *zv1.ElasticsearchDataSetZoneFailure `json:"zoneFailure,omitempty"`
+
+	// Indices are the index patterns hosted by the EDS. Matching indices
+	// are allocated to the nodes of the EDS with
+	// 'index.routing.allocation.include.group' set to the 'group' label of
+	// the EDS once its first pod is ready.
+	// +optional
+	Indices []string `json:"indices,omitempty"`
+
+	// Decommission configures migrating all indices off the EDS before it's
+	// deleted.
+	// +optional
+	Decommission *zv1.ElasticsearchDataSetDecommission `json:"decommission,omitempty"`
+
+	// Rebalancing configures moving indices between the groups of a
+	// rebalancing pool to equalize their CPU usage.
+	// +optional
+	Rebalancing *zv1.ElasticsearchDataSetRebalancing `json:"rebalancing,omitempty"`
+}
+
+// ElasticsearchDataSetScaling describes the scaling properties of the EDS.
+// +k8s:deepcopy-gen=true
+type ElasticsearchDataSetScaling struct {
+	// +optional
+	Enabled bool `json:"enabled"`
+	// Shadow computes the scaling decisions and exposes them as metrics
+	// without applying them, e.g. to compare them with another scaling
+	// mechanism which remains in charge of the replicas.
+	// +optional
+	Shadow bool `json:"shadow,omitempty"`
+	// Replicas bounds the number of pods.
+	// +optional
+	Replicas ElasticsearchDataSetScalingRange `json:"replicas"`
+	// OverprovisionPercent keeps this percentage of the replicas needed
+	// according to the scaling settings as headroom on top, rounded up and
+	// bounded by the maximum replicas, to absorb load spikes without
+	// waiting for a scale-up.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	OverprovisionPercent int32 `json:"overprovisionPercent,omitempty"`
+	// IndexReplicas bounds the number of replicas of the indices.
+	// +optional
+	IndexReplicas ElasticsearchDataSetScalingRange `json:"indexReplicas"`
+	// ShardsPerNode bounds the number of shards per pod.
+	// +optional
+	ShardsPerNode ElasticsearchDataSetScalingRange `json:"shardsPerNode"`
+	// ScaleUp configures when the EDS is scaled up.
+	// +optional
+	ScaleUp ElasticsearchDataSetScalingRule `json:"scaleUp"`
+	// ScaleDown configures when the EDS is scaled down.
+	// +optional
+	ScaleDown ElasticsearchDataSetScaleDown `json:"scaleDown"`
+	// CaptureHotThreads captures the hot threads of the nodes when
+	// scaling up because of high CPU usage. The output is stored in the
+	// ConfigMap '<name>-hot-threads'.
+	// +optional
+	CaptureHotThreads bool `json:"captureHotThreads,omitempty"`
+	// DecisionWebhook configures an external webhook which reviews the
+	// scaling decisions of the operator.
+	// +optional
+	DecisionWebhook *zv1.ElasticsearchDataSetDecisionWebhook `json:"decisionWebhook,omitempty"`
+	// Predictive configures scaling up ahead of recurring peaks of the CPU
+	// usage based on a seasonal baseline of the past CPU usage.
+	// +optional
+	Predictive *zv1.ElasticsearchDataSetPredictiveScaling `json:"predictive,omitempty"`
+	// HotShards configures detecting shards which dominate the load of a
+	// node.
+	// +optional
+	HotShards *zv1.ElasticsearchDataSetHotShards `json:"hotShards,omitempty"`
+	// Aggregation configures how the CPU usage of the Pods is aggregated
+	// into one sample. Defaults to the median.
+	// +optional
+	Aggregation *zv1.ElasticsearchDataSetCPUAggregation `json:"aggregation,omitempty"`
+	// ShardSize configures warnings about shards exceeding a maximum size,
+	// which make draining and recovering nodes slow.
+	// +optional
+	ShardSize *zv1.ElasticsearchDataSetShardSizeGuardrail `json:"shardSize,omitempty"`
+}
+
+// ElasticsearchDataSetScalingRange is the range a value is scaled in.
+// +k8s:deepcopy-gen=true
+type ElasticsearchDataSetScalingRange struct {
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Min int32 `json:"min"`
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Max int32 `json:"max"`
+}
+
+// ElasticsearchDataSetScalingRule configures the CPU usage the EDS is scaled
+// at.
+// +k8s:deepcopy-gen=true
+type ElasticsearchDataSetScalingRule struct {
+	// CPUBoundary is the CPU usage in percent beyond which the EDS is
+	// scaled.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	CPUBoundary int32 `json:"cpuBoundary"`
+	// ThresholdDurationSeconds is how long the CPU usage must be beyond
+	// the boundary before the EDS is scaled.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	ThresholdDurationSeconds int64 `json:"thresholdDurationSeconds"`
+	// CooldownSeconds is the minimum time between two scaling operations
+	// in this direction.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	CooldownSeconds int64 `json:"cooldownSeconds"`
+}
+
+// ElasticsearchDataSetScaleDown configures when and how far the EDS is
+// scaled down.
+// +k8s:deepcopy-gen=true
+type ElasticsearchDataSetScaleDown struct {
+	ElasticsearchDataSetScalingRule `json:",inline"`
+	// DiskUsagePercentWatermark blocks scale-downs while the disk usage is
+	// above this percentage.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	DiskUsagePercentWatermark int32 `json:"diskUsagePercentWatermark"`
+	// Guards are CEL expressions which must all evaluate to true for a
+	// scale-down to proceed, e.g.
+	// 'shardsPerNode < 30.0 && diskUsedPercent < 70.0'.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	Guards []zv1.ElasticsearchDataSetScalingGuard `json:"guards,omitempty"`
+	// ClusterShardLimit configures how scale-downs exceeding the
+	// cluster.max_shards_per_node setting of the cluster are handled. They
+	// are blocked unless the setting may be raised far enough.
+	// +optional
+	ClusterShardLimit *zv1.ElasticsearchDataSetClusterShardLimit `json:"clusterShardLimit,omitempty"`
+	// DiskHeadroom blocks scale-downs which would fill the disks of the
+	// remaining nodes above the flood-stage watermark, projected from the
+	// sizes of the shards moved to them.
+	// +optional
+	DiskHeadroom *zv1.ElasticsearchDataSetDiskHeadroom `json:"diskHeadroom,omitempty"`
+	// Recovery scales back up if the cluster doesn't recover after the
+	// pods of a scale-down are removed.
+	// +optional
+	Recovery *zv1.ElasticsearchDataSetScaleDownRecovery `json:"recovery,omitempty"`
+}
+
+// ElasticsearchDataSetDraining configures how the pods of the EDS are
+// drained before they are deleted.
+// +k8s:deepcopy-gen=true
+// +kubebuilder:validation:XValidation:rule="!has(self.minimumWaitTimeDurationSeconds) || !has(self.maximumWaitTimeDurationSeconds) || self.minimumWaitTimeDurationSeconds <= self.maximumWaitTimeDurationSeconds",message="minimumWaitTimeDurationSeconds can't be greater than maximumWaitTimeDurationSeconds"
+type ElasticsearchDataSetDraining struct {
+	// Skip deletes pods without draining them first.
+	// +optional
+	Skip bool `json:"skip,omitempty"`
+
+	// MaxRetries is the maximum number of attempts to drain a pod.
+	// Defaults to 999.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxRetries *int32 `json:"maxRetries,omitempty"`
+
+	// MinimumWaitTimeDurationSeconds is the minimum wait time in seconds
+	// between attempts to drain a pod. Defaults to 10.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MinimumWaitTimeDurationSeconds *int64 `json:"minimumWaitTimeDurationSeconds,omitempty"`
+
+	// MaximumWaitTimeDurationSeconds is the maximum wait time in seconds
+	// between attempts to drain a pod. Defaults to 30.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaximumWaitTimeDurationSeconds *int64 `json:"maximumWaitTimeDurationSeconds,omitempty"`
+
+	// NodeShutdown specifies whether pods are drained using the node
+	// shutdown API on Elasticsearch 7.14 and later instead of allocation
+	// exclusions. Defaults to true.
+	// +optional
+	NodeShutdown *bool `json:"nodeShutdown,omitempty"`
+
+	// TaskDeadlineSeconds is the time after which the long-running tasks
+	// on a node still being drained are reported in events. Tasks aren't
+	// checked if not set.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	TaskDeadlineSeconds *int64 `json:"taskDeadlineSeconds,omitempty"`
+
+	// CancelTasks cancels the cancellable tasks reported after the task
+	// deadline.
+	// +optional
+	CancelTasks bool `json:"cancelTasks,omitempty"`
+
+	// MaxParallelDrains is the maximum number of pods drained at the same
+	// time when scaling down by more than one replica. Defaults to 1.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxParallelDrains *int32 `json:"maxParallelDrains,omitempty"`
+
+	// WriteBlock blocks writes to indices while their last primaries
+	// relocate off a drained pod, for a bounded duration.
+	// +optional
+	WriteBlock *zv1.ElasticsearchDataSetDrainWriteBlock `json:"writeBlock,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ElasticsearchDataSetList is a list of ElasticsearchDataSets.
+// +k8s:deepcopy-gen=true
+type ElasticsearchDataSetList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []ElasticsearchDataSet `json:"items"`
+}
@@ -0,0 +1,457 @@
+//go:build !ignore_autogenerated
+// +build !ignore_autogenerated
+
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by deepcopy-gen. DO NOT EDIT.
+
+package v2
+
+import (
+	v1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+)
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataSet) DeepCopyInto(out *ElasticsearchDataSet) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSet.
+func (in *ElasticsearchDataSet) DeepCopy() *ElasticsearchDataSet {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataSet)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ElasticsearchDataSet) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataSetDraining) DeepCopyInto(out *ElasticsearchDataSetDraining) {
+	*out = *in
+	if in.MaxRetries != nil {
+		in, out := &in.MaxRetries, &out.MaxRetries
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MinimumWaitTimeDurationSeconds != nil {
+		in, out := &in.MinimumWaitTimeDurationSeconds, &out.MinimumWaitTimeDurationSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.MaximumWaitTimeDurationSeconds != nil {
+		in, out := &in.MaximumWaitTimeDurationSeconds, &out.MaximumWaitTimeDurationSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.NodeShutdown != nil {
+		in, out := &in.NodeShutdown, &out.NodeShutdown
+		*out = new(bool)
+		**out = **in
+	}
+	if in.TaskDeadlineSeconds != nil {
+		in, out := &in.TaskDeadlineSeconds, &out.TaskDeadlineSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.MaxParallelDrains != nil {
+		in, out := &in.MaxParallelDrains, &out.MaxParallelDrains
+		*out = new(int32)
+		**out = **in
+	}
+	if in.WriteBlock != nil {
+		in, out := &in.WriteBlock, &out.WriteBlock
+		*out = new(v1.ElasticsearchDataSetDrainWriteBlock)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetDraining.
+func (in *ElasticsearchDataSetDraining) DeepCopy() *ElasticsearchDataSetDraining {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataSetDraining)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataSetList) DeepCopyInto(out *ElasticsearchDataSetList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ElasticsearchDataSet, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetList.
+func (in *ElasticsearchDataSetList) DeepCopy() *ElasticsearchDataSetList {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataSetList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ElasticsearchDataSetList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataSetScaleDown) DeepCopyInto(out *ElasticsearchDataSetScaleDown) {
+	*out = *in
+	out.ElasticsearchDataSetScalingRule = in.ElasticsearchDataSetScalingRule
+	if in.Guards != nil {
+		in, out := &in.Guards, &out.Guards
+		*out = make([]v1.ElasticsearchDataSetScalingGuard, len(*in))
+		copy(*out, *in)
+	}
+	if in.ClusterShardLimit != nil {
+		in, out := &in.ClusterShardLimit, &out.ClusterShardLimit
+		*out = new(v1.ElasticsearchDataSetClusterShardLimit)
+		**out = **in
+	}
+	if in.DiskHeadroom != nil {
+		in, out := &in.DiskHeadroom, &out.DiskHeadroom
+		*out = new(v1.ElasticsearchDataSetDiskHeadroom)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Recovery != nil {
+		in, out := &in.Recovery, &out.Recovery
+		*out = new(v1.ElasticsearchDataSetScaleDownRecovery)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetScaleDown.
+func (in *ElasticsearchDataSetScaleDown) DeepCopy() *ElasticsearchDataSetScaleDown {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataSetScaleDown)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataSetScaling) DeepCopyInto(out *ElasticsearchDataSetScaling) {
+	*out = *in
+	out.Replicas = in.Replicas
+	out.IndexReplicas = in.IndexReplicas
+	out.ShardsPerNode = in.ShardsPerNode
+	out.ScaleUp = in.ScaleUp
+	in.ScaleDown.DeepCopyInto(&out.ScaleDown)
+	if in.DecisionWebhook != nil {
+		in, out := &in.DecisionWebhook, &out.DecisionWebhook
+		*out = new(v1.ElasticsearchDataSetDecisionWebhook)
+		**out = **in
+	}
+	if in.Predictive != nil {
+		in, out := &in.Predictive, &out.Predictive
+		*out = new(v1.ElasticsearchDataSetPredictiveScaling)
+		**out = **in
+	}
+	if in.HotShards != nil {
+		in, out := &in.HotShards, &out.HotShards
+		*out = new(v1.ElasticsearchDataSetHotShards)
+		**out = **in
+	}
+	if in.Aggregation != nil {
+		in, out := &in.Aggregation, &out.Aggregation
+		*out = new(v1.ElasticsearchDataSetCPUAggregation)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ShardSize != nil {
+		in, out := &in.ShardSize, &out.ShardSize
+		*out = new(v1.ElasticsearchDataSetShardSizeGuardrail)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetScaling.
+func (in *ElasticsearchDataSetScaling) DeepCopy() *ElasticsearchDataSetScaling {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataSetScaling)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataSetScalingRange) DeepCopyInto(out *ElasticsearchDataSetScalingRange) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetScalingRange.
+func (in *ElasticsearchDataSetScalingRange) DeepCopy() *ElasticsearchDataSetScalingRange {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataSetScalingRange)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataSetScalingRule) DeepCopyInto(out *ElasticsearchDataSetScalingRule) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetScalingRule.
+func (in *ElasticsearchDataSetScalingRule) DeepCopy() *ElasticsearchDataSetScalingRule {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataSetScalingRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchDataSetSpec) DeepCopyInto(out *ElasticsearchDataSetSpec) {
+	*out = *in
+	if in.Replicas != nil {
+		in, out := &in.Replicas, &out.Replicas
+		*out = new(int32)
+		**out = **in
+	}
+	in.Template.DeepCopyInto(&out.Template)
+	if in.Scaling != nil {
+		in, out := &in.Scaling, &out.Scaling
+		*out = new(ElasticsearchDataSetScaling)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Draining != nil {
+		in, out := &in.Draining, &out.Draining
+		*out = new(ElasticsearchDataSetDraining)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.VolumeClaimTemplates != nil {
+		in, out := &in.VolumeClaimTemplates, &out.VolumeClaimTemplates
+		*out = make([]v1.PersistentVolumeClaim, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.VolumeClaimRetention != nil {
+		in, out := &in.VolumeClaimRetention, &out.VolumeClaimRetention
+		*out = new(v1.ElasticsearchDataSetVolumeClaimRetention)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.VolumeExpansion != nil {
+		in, out := &in.VolumeExpansion, &out.VolumeExpansion
+		*out = new(v1.ElasticsearchDataSetVolumeExpansion)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(v1.ElasticsearchDataSetTLS)
+		**out = **in
+	}
+	if in.Elasticsearch != nil {
+		in, out := &in.Elasticsearch, &out.Elasticsearch
+		*out = new(v1.ElasticsearchDataSetElasticsearch)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MaxFailingPods != nil {
+		in, out := &in.MaxFailingPods, &out.MaxFailingPods
+		*out = new(int32)
+		**out = **in
+	}
+	if in.OrdinalOverrides != nil {
+		in, out := &in.OrdinalOverrides, &out.OrdinalOverrides
+		*out = make([]v1.ElasticsearchDataSetOrdinalOverride, len(*in))
+		copy(*out, *in)
+	}
+	if in.AutoRollback != nil {
+		in, out := &in.AutoRollback, &out.AutoRollback
+		*out = new(v1.ElasticsearchDataSetAutoRollback)
+		**out = **in
+	}
+	if in.RevisionHistoryLimit != nil {
+		in, out := &in.RevisionHistoryLimit, &out.RevisionHistoryLimit
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MemoryAdjustment != nil {
+		in, out := &in.MemoryAdjustment, &out.MemoryAdjustment
+		*out = new(v1.ElasticsearchDataSetMemoryAdjustment)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.HeapDumps != nil {
+		in, out := &in.HeapDumps, &out.HeapDumps
+		*out = new(v1.ElasticsearchDataSetHeapDumps)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DiskPreparation != nil {
+		in, out := &in.DiskPreparation, &out.DiskPreparation
+		*out = new(v1.ElasticsearchDataSetDiskPreparation)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SchedulingGate != nil {
+		in, out := &in.SchedulingGate, &out.SchedulingGate
+		*out = new(v1.ElasticsearchDataSetSchedulingGate)
+		**out = **in
+	}
+	if in.GCRecycling != nil {
+		in, out := &in.GCRecycling, &out.GCRecycling
+		*out = new(v1.ElasticsearchDataSetGCRecycling)
+		**out = **in
+	}
+	if in.ExtraPorts != nil {
+		in, out := &in.ExtraPorts, &out.ExtraPorts
+		*out = make([]v1.ElasticsearchDataSetPort, len(*in))
+		copy(*out, *in)
+	}
+	if in.PodServices != nil {
+		in, out := &in.PodServices, &out.PodServices
+		*out = new(v1.ElasticsearchDataSetPodServices)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ExternalDNS != nil {
+		in, out := &in.ExternalDNS, &out.ExternalDNS
+		*out = new(v1.ElasticsearchDataSetExternalDNS)
+		**out = **in
+	}
+	if in.AdditionalResourceLabels != nil {
+		in, out := &in.AdditionalResourceLabels, &out.AdditionalResourceLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.AdditionalResourceAnnotations != nil {
+		in, out := &in.AdditionalResourceAnnotations, &out.AdditionalResourceAnnotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.PodSecurity != nil {
+		in, out := &in.PodSecurity, &out.PodSecurity
+		*out = new(v1.ElasticsearchDataSetPodSecurity)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Lifecycle != nil {
+		in, out := &in.Lifecycle, &out.Lifecycle
+		*out = new(v1.ElasticsearchDataSetLifecycle)
+		**out = **in
+	}
+	if in.TerminationGracePeriod != nil {
+		in, out := &in.TerminationGracePeriod, &out.TerminationGracePeriod
+		*out = new(v1.ElasticsearchDataSetTerminationGracePeriod)
+		**out = **in
+	}
+	if in.Health != nil {
+		in, out := &in.Health, &out.Health
+		*out = new(v1.ElasticsearchDataSetHealth)
+		**out = **in
+	}
+	if in.CriticalIndices != nil {
+		in, out := &in.CriticalIndices, &out.CriticalIndices
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.TrafficSteering != nil {
+		in, out := &in.TrafficSteering, &out.TrafficSteering
+		*out = new(v1.ElasticsearchDataSetTrafficSteering)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MasterStability != nil {
+		in, out := &in.MasterStability, &out.MasterStability
+		*out = new(v1.ElasticsearchDataSetMasterStability)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.BackupHooks != nil {
+		in, out := &in.BackupHooks, &out.BackupHooks
+		*out = new(v1.ElasticsearchDataSetBackupHooks)
+		**out = **in
+	}
+	if in.VolumeSnapshotRecovery != nil {
+		in, out := &in.VolumeSnapshotRecovery, &out.VolumeSnapshotRecovery
+		*out = new(v1.ElasticsearchDataSetVolumeSnapshotRecovery)
+		**out = **in
+	}
+	if in.SnapshotPeerRecovery != nil {
+		in, out := &in.SnapshotPeerRecovery, &out.SnapshotPeerRecovery
+		*out = new(v1.ElasticsearchDataSetSnapshotPeerRecovery)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ZoneFailure != nil {
+		in, out := &in.ZoneFailure, &out.ZoneFailure
+		*out = new(v1.ElasticsearchDataSetZoneFailure)
+		**out = **in
+	}
+	if in.Indices != nil {
+		in, out := &in.Indices, &out.Indices
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Decommission != nil {
+		in, out := &in.Decommission, &out.Decommission
+		*out = new(v1.ElasticsearchDataSetDecommission)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Rebalancing != nil {
+		in, out := &in.Rebalancing, &out.Rebalancing
+		*out = new(v1.ElasticsearchDataSetRebalancing)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchDataSetSpec.
+func (in *ElasticsearchDataSetSpec) DeepCopy() *ElasticsearchDataSetSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchDataSetSpec)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,145 @@
+// Package await provides polling helpers for waiting on Kubernetes
+// resources to reach a desired state, e.g. in end-to-end tests.
+package await
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cenk/backoff"
+	"github.com/google/go-cmp/cmp"
+)
+
+const (
+	DefaultTimeout         = 15 * time.Minute
+	DefaultInitialInterval = 5 * time.Second
+	DefaultMaxInterval     = 30 * time.Second
+	DefaultMultiplier      = 1.5
+)
+
+// Poll checks if the awaited state is reached. It returns nil if the state is
+// reached. If an error is returned the poll is retried if retry is true,
+// otherwise waiting is aborted with the error.
+type Poll func(ctx context.Context) (retry bool, err error)
+
+// Logf logs a formatted message, e.g. testing.T.Logf.
+type Logf func(format string, args ...interface{})
+
+// Awaiter polls until the awaited state is reached, the timeout expires or
+// the context is cancelled. Polls are retried with exponential backoff.
+type Awaiter struct {
+	description     string
+	poll            Poll
+	timeout         time.Duration
+	initialInterval time.Duration
+	maxInterval     time.Duration
+	multiplier      float64
+	logf            Logf
+}
+
+// New returns an Awaiter for the poll with the default timeout and backoff.
+func New(description string, poll Poll) *Awaiter {
+	return &Awaiter{
+		description:     description,
+		poll:            poll,
+		timeout:         DefaultTimeout,
+		initialInterval: DefaultInitialInterval,
+		maxInterval:     DefaultMaxInterval,
+		multiplier:      DefaultMultiplier,
+		logf:            func(string, ...interface{}) {},
+	}
+}
+
+// WithTimeout sets the maximum time to wait.
+func (a *Awaiter) WithTimeout(timeout time.Duration) *Awaiter {
+	a.timeout = timeout
+	return a
+}
+
+// WithInterval polls at a fixed interval instead of backing off.
+func (a *Awaiter) WithInterval(interval time.Duration) *Awaiter {
+	return a.WithBackoff(interval, interval, 1)
+}
+
+// WithBackoff sets the initial and maximum poll interval and the multiplier
+// applied to the interval after every retry.
+func (a *Awaiter) WithBackoff(initial, max time.Duration, multiplier float64) *Awaiter {
+	a.initialInterval = initial
+	a.maxInterval = max
+	a.multiplier = multiplier
+	return a
+}
+
+// WithLogger logs the progress and the retried errors.
+func (a *Awaiter) WithLogger(logf Logf) *Awaiter {
+	a.logf = logf
+	return a
+}
+
+func (a *Awaiter) backoff() backoff.BackOff {
+	b := backoff.NewExponentialBackOff()
+	b.InitialInterval = a.initialInterval
+	b.MaxInterval = a.maxInterval
+	b.Multiplier = a.multiplier
+	if a.multiplier <= 1 {
+		b.RandomizationFactor = 0
+	}
+	// the timeout is enforced by the context.
+	b.MaxElapsedTime = 0
+	b.Reset()
+	return b
+}
+
+// Await polls until the awaited state is reached. It returns the last poll
+// error if the timeout expires or the context is cancelled before.
+func (a *Awaiter) Await(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, a.timeout)
+	defer cancel()
+
+	deadline, _ := ctx.Deadline()
+	a.logf("Waiting for %s until %s (UTC)...", a.description, deadline.UTC().Format("3:04PM"))
+
+	b := a.backoff()
+	for {
+		retry, err := a.poll(ctx)
+		if err == nil {
+			a.logf("Finished waiting for %s", a.description)
+			return nil
+		}
+		if !retry {
+			return fmt.Errorf("waiting for %s failed: %w", a.description, err)
+		}
+		a.logf("%v", err)
+
+		select {
+		case <-time.After(b.NextBackOff()):
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return fmt.Errorf("timed out after %s waiting for %s: %w", a.timeout, a.description, err)
+			}
+			return fmt.Errorf("cancelled waiting for %s: %w", a.description, err)
+		}
+	}
+}
+
+// All runs all checks and returns the joined errors of the failed ones, so a
+// poll reports every unmet condition at once.
+func All(checks ...func() error) error {
+	errs := make([]error, 0, len(checks))
+	for _, check := range checks {
+		if err := check(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Equal returns an error with a diff if actual is not equal to expected.
+func Equal(description string, expected, actual interface{}) error {
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		return fmt.Errorf("%s mismatch (-expected +actual):\n%s", description, diff)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package await
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAwaitRetriesUntilDone(t *testing.T) {
+	polls := 0
+	err := New("test", func(_ context.Context) (bool, error) {
+		polls++
+		if polls < 3 {
+			return true, errors.New("not yet")
+		}
+		return false, nil
+	}).WithInterval(time.Millisecond).Await(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 3, polls)
+}
+
+func TestAwaitStopsOnPermanentError(t *testing.T) {
+	polls := 0
+	err := New("test", func(_ context.Context) (bool, error) {
+		polls++
+		return false, errors.New("permanent")
+	}).WithInterval(time.Millisecond).Await(context.Background())
+	require.EqualError(t, err, "waiting for test failed: permanent")
+	require.Equal(t, 1, polls)
+}
+
+func TestAwaitTimeout(t *testing.T) {
+	err := New("test", func(_ context.Context) (bool, error) {
+		return true, errors.New("not yet")
+	}).WithTimeout(20 * time.Millisecond).WithInterval(time.Millisecond).Await(context.Background())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "timed out after 20ms waiting for test: not yet")
+}
+
+func TestAwaitCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	err := New("test", func(_ context.Context) (bool, error) {
+		cancel()
+		return true, errors.New("not yet")
+	}).WithInterval(time.Hour).Await(ctx)
+	require.EqualError(t, err, "cancelled waiting for test: not yet")
+}
+
+func TestAwaitBackoff(t *testing.T) {
+	b := New("test", nil).WithBackoff(time.Second, 4*time.Second, 2).backoff()
+	var intervals []time.Duration
+	for i := 0; i < 4; i++ {
+		intervals = append(intervals, b.NextBackOff())
+	}
+	// randomized by +-50%
+	require.InDelta(t, time.Second, intervals[0], float64(500*time.Millisecond))
+	require.InDelta(t, 4*time.Second, intervals[3], float64(2*time.Second))
+
+	b = New("test", nil).WithInterval(time.Second).backoff()
+	for i := 0; i < 3; i++ {
+		require.Equal(t, time.Second, b.NextBackOff())
+	}
+}
+
+func TestAll(t *testing.T) {
+	require.NoError(t, All(func() error { return nil }))
+
+	err := All(
+		func() error { return errors.New("a") },
+		func() error { return nil },
+		func() error { return errors.New("b") },
+	)
+	require.EqualError(t, err, "a\nb")
+}
+
+func TestEqual(t *testing.T) {
+	require.NoError(t, Equal("labels", map[string]string{"a": "b"}, map[string]string{"a": "b"}))
+
+	err := Equal("labels", map[string]string{"a": "b"}, map[string]string{"a": "c"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "labels mismatch (-expected +actual)")
+	assert.Contains(t, err.Error(), `"a": "c"`)
+}
@@ -0,0 +1,61 @@
+package await
+
+import (
+	"context"
+	"fmt"
+
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Getter gets a resource by name. It's implemented by the typed client-go
+// interfaces, e.g. StatefulSetInterface or ElasticsearchDataSetInterface.
+type Getter[T any] interface {
+	Get(ctx context.Context, name string, opts metav1.GetOptions) (T, error)
+}
+
+// Created returns an Awaiter waiting for the resource to be created.
+func Created[T any](getter Getter[T], kind, name string) *Awaiter {
+	return New(fmt.Sprintf("creation of %s %s", kind, name), func(ctx context.Context) (bool, error) {
+		_, err := getter.Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return apiErrors.IsNotFound(err), fmt.Errorf("failed to get %s %s: %w", kind, name, err)
+		}
+		return false, nil
+	})
+}
+
+// Deleted returns an Awaiter waiting for the resource to be deleted.
+func Deleted[T any](getter Getter[T], kind, name string) *Awaiter {
+	return New(fmt.Sprintf("deletion of %s %s", kind, name), func(ctx context.Context) (bool, error) {
+		_, err := getter.Get(ctx, name, metav1.GetOptions{})
+		if err == nil {
+			return true, fmt.Errorf("%s %s still exists", kind, name)
+		}
+		if apiErrors.IsNotFound(err) {
+			return false, nil
+		}
+		return true, fmt.Errorf("failed to get %s %s: %w", kind, name, err)
+	})
+}
+
+// Condition returns an Awaiter waiting for the resource to meet all
+// conditions. A missing resource is retried and the errors of all unmet
+// conditions are reported.
+func Condition[T any](getter Getter[T], kind, name string, conditions ...func(T) error) *Awaiter {
+	return New(fmt.Sprintf("%s %s to reach desired condition", kind, name), func(ctx context.Context) (bool, error) {
+		resource, err := getter.Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return apiErrors.IsNotFound(err), fmt.Errorf("failed to get %s %s: %w", kind, name, err)
+		}
+
+		checks := make([]func() error, 0, len(conditions))
+		for _, condition := range conditions {
+			checks = append(checks, func() error { return condition(resource) })
+		}
+		if err := All(checks...); err != nil {
+			return true, fmt.Errorf("%s %s: %w", kind, name, err)
+		}
+		return false, nil
+	})
+}
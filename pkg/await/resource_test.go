@@ -0,0 +1,71 @@
+package await
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestCreated(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	statefulSets := client.AppsV1().StatefulSets("default")
+
+	err := Created(statefulSets, "sts", "foo").WithTimeout(20 * time.Millisecond).WithInterval(time.Millisecond).Await(context.Background())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `failed to get sts foo: statefulsets.apps "foo" not found`)
+
+	_, err = statefulSets.Create(context.Background(), &appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: "foo"}}, metav1.CreateOptions{})
+	require.NoError(t, err)
+	err = Created(statefulSets, "sts", "foo").WithInterval(time.Millisecond).Await(context.Background())
+	require.NoError(t, err)
+}
+
+func TestDeleted(t *testing.T) {
+	client := fake.NewSimpleClientset(&appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default"}})
+	statefulSets := client.AppsV1().StatefulSets("default")
+
+	err := Deleted(statefulSets, "sts", "foo").WithTimeout(20 * time.Millisecond).WithInterval(time.Millisecond).Await(context.Background())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "sts foo still exists")
+
+	err = statefulSets.Delete(context.Background(), "foo", metav1.DeleteOptions{})
+	require.NoError(t, err)
+	err = Deleted(statefulSets, "sts", "foo").WithInterval(time.Millisecond).Await(context.Background())
+	require.NoError(t, err)
+}
+
+func TestCondition(t *testing.T) {
+	replicas := int32(2)
+	client := fake.NewSimpleClientset(&appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default"},
+		Spec:       appsv1.StatefulSetSpec{Replicas: &replicas},
+	})
+	statefulSets := client.AppsV1().StatefulSets("default")
+
+	hasReplicas := func(expected int32) func(*appsv1.StatefulSet) error {
+		return func(sts *appsv1.StatefulSet) error {
+			return Equal("replicas", expected, *sts.Spec.Replicas)
+		}
+	}
+	isReady := func(sts *appsv1.StatefulSet) error {
+		if sts.Status.ReadyReplicas != *sts.Spec.Replicas {
+			return errors.New("not ready")
+		}
+		return nil
+	}
+
+	err := Condition(statefulSets, "sts", "foo", hasReplicas(3), isReady).
+		WithTimeout(20 * time.Millisecond).WithInterval(time.Millisecond).Await(context.Background())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "replicas mismatch")
+	require.Contains(t, err.Error(), "not ready")
+
+	err = Condition(statefulSets, "sts", "foo", hasReplicas(2)).WithInterval(time.Millisecond).Await(context.Background())
+	require.NoError(t, err)
+}
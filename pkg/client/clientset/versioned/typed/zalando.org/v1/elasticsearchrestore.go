@@ -0,0 +1,69 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1
+
+import (
+	"context"
+
+	v1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	scheme "github.com/zalando-incubator/es-operator/pkg/client/clientset/versioned/scheme"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	gentype "k8s.io/client-go/gentype"
+)
+
+// ElasticsearchRestoresGetter has a method to return a ElasticsearchRestoreInterface.
+// A group's client should implement this interface.
+type ElasticsearchRestoresGetter interface {
+	ElasticsearchRestores(namespace string) ElasticsearchRestoreInterface
+}
+
+// ElasticsearchRestoreInterface has methods to work with ElasticsearchRestore resources.
+type ElasticsearchRestoreInterface interface {
+	Create(ctx context.Context, elasticsearchRestore *v1.ElasticsearchRestore, opts metav1.CreateOptions) (*v1.ElasticsearchRestore, error)
+	Update(ctx context.Context, elasticsearchRestore *v1.ElasticsearchRestore, opts metav1.UpdateOptions) (*v1.ElasticsearchRestore, error)
+	// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+	UpdateStatus(ctx context.Context, elasticsearchRestore *v1.ElasticsearchRestore, opts metav1.UpdateOptions) (*v1.ElasticsearchRestore, error)
+	Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error
+	DeleteCollection(ctx context.Context, opts metav1.DeleteOptions, listOpts metav1.ListOptions) error
+	Get(ctx context.Context, name string, opts metav1.GetOptions) (*v1.ElasticsearchRestore, error)
+	List(ctx context.Context, opts metav1.ListOptions) (*v1.ElasticsearchRestoreList, error)
+	Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error)
+	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (result *v1.ElasticsearchRestore, err error)
+	ElasticsearchRestoreExpansion
+}
+
+// elasticsearchRestores implements ElasticsearchRestoreInterface
+type elasticsearchRestores struct {
+	*gentype.ClientWithList[*v1.ElasticsearchRestore, *v1.ElasticsearchRestoreList]
+}
+
+// newElasticsearchRestores returns a ElasticsearchRestores
+func newElasticsearchRestores(c *ZalandoV1Client, namespace string) *elasticsearchRestores {
+	return &elasticsearchRestores{
+		gentype.NewClientWithList[*v1.ElasticsearchRestore, *v1.ElasticsearchRestoreList](
+			"elasticsearchrestores",
+			c.RESTClient(),
+			scheme.ParameterCodec,
+			namespace,
+			func() *v1.ElasticsearchRestore { return &v1.ElasticsearchRestore{} },
+			func() *v1.ElasticsearchRestoreList { return &v1.ElasticsearchRestoreList{} }),
+	}
+}
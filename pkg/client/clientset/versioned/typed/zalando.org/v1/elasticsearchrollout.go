@@ -0,0 +1,69 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1
+
+import (
+	"context"
+
+	v1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	scheme "github.com/zalando-incubator/es-operator/pkg/client/clientset/versioned/scheme"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	gentype "k8s.io/client-go/gentype"
+)
+
+// ElasticsearchRolloutsGetter has a method to return a ElasticsearchRolloutInterface.
+// A group's client should implement this interface.
+type ElasticsearchRolloutsGetter interface {
+	ElasticsearchRollouts(namespace string) ElasticsearchRolloutInterface
+}
+
+// ElasticsearchRolloutInterface has methods to work with ElasticsearchRollout resources.
+type ElasticsearchRolloutInterface interface {
+	Create(ctx context.Context, elasticsearchRollout *v1.ElasticsearchRollout, opts metav1.CreateOptions) (*v1.ElasticsearchRollout, error)
+	Update(ctx context.Context, elasticsearchRollout *v1.ElasticsearchRollout, opts metav1.UpdateOptions) (*v1.ElasticsearchRollout, error)
+	// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+	UpdateStatus(ctx context.Context, elasticsearchRollout *v1.ElasticsearchRollout, opts metav1.UpdateOptions) (*v1.ElasticsearchRollout, error)
+	Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error
+	DeleteCollection(ctx context.Context, opts metav1.DeleteOptions, listOpts metav1.ListOptions) error
+	Get(ctx context.Context, name string, opts metav1.GetOptions) (*v1.ElasticsearchRollout, error)
+	List(ctx context.Context, opts metav1.ListOptions) (*v1.ElasticsearchRolloutList, error)
+	Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error)
+	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (result *v1.ElasticsearchRollout, err error)
+	ElasticsearchRolloutExpansion
+}
+
+// elasticsearchRollouts implements ElasticsearchRolloutInterface
+type elasticsearchRollouts struct {
+	*gentype.ClientWithList[*v1.ElasticsearchRollout, *v1.ElasticsearchRolloutList]
+}
+
+// newElasticsearchRollouts returns a ElasticsearchRollouts
+func newElasticsearchRollouts(c *ZalandoV1Client, namespace string) *elasticsearchRollouts {
+	return &elasticsearchRollouts{
+		gentype.NewClientWithList[*v1.ElasticsearchRollout, *v1.ElasticsearchRolloutList](
+			"elasticsearchrollouts",
+			c.RESTClient(),
+			scheme.ParameterCodec,
+			namespace,
+			func() *v1.ElasticsearchRollout { return &v1.ElasticsearchRollout{} },
+			func() *v1.ElasticsearchRolloutList { return &v1.ElasticsearchRolloutList{} }),
+	}
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	"context"
+
+	v1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	testing "k8s.io/client-go/testing"
+)
+
+// FakeElasticsearchClusters implements ElasticsearchClusterInterface
+type FakeElasticsearchClusters struct {
+	Fake *FakeZalandoV1
+	ns   string
+}
+
+var elasticsearchclustersResource = v1.SchemeGroupVersion.WithResource("elasticsearchclusters")
+
+var elasticsearchclustersKind = v1.SchemeGroupVersion.WithKind("ElasticsearchCluster")
+
+// Get takes name of the elasticsearchCluster, and returns the corresponding elasticsearchCluster object, and an error if there is any.
+func (c *FakeElasticsearchClusters) Get(ctx context.Context, name string, options metav1.GetOptions) (result *v1.ElasticsearchCluster, err error) {
+	emptyResult := &v1.ElasticsearchCluster{}
+	obj, err := c.Fake.
+		Invokes(testing.NewGetActionWithOptions(elasticsearchclustersResource, c.ns, name, options), emptyResult)
+
+	if obj == nil {
+		return emptyResult, err
+	}
+	return obj.(*v1.ElasticsearchCluster), err
+}
+
+// List takes label and field selectors, and returns the list of ElasticsearchClusters that match those selectors.
+func (c *FakeElasticsearchClusters) List(ctx context.Context, opts metav1.ListOptions) (result *v1.ElasticsearchClusterList, err error) {
+	emptyResult := &v1.ElasticsearchClusterList{}
+	obj, err := c.Fake.
+		Invokes(testing.NewListActionWithOptions(elasticsearchclustersResource, elasticsearchclustersKind, c.ns, opts), emptyResult)
+
+	if obj == nil {
+		return emptyResult, err
+	}
+
+	label, _, _ := testing.ExtractFromListOptions(opts)
+	if label == nil {
+		label = labels.Everything()
+	}
+	list := &v1.ElasticsearchClusterList{ListMeta: obj.(*v1.ElasticsearchClusterList).ListMeta}
+	for _, item := range obj.(*v1.ElasticsearchClusterList).Items {
+		if label.Matches(labels.Set(item.Labels)) {
+			list.Items = append(list.Items, item)
+		}
+	}
+	return list, err
+}
+
+// Watch returns a watch.Interface that watches the requested elasticsearchClusters.
+func (c *FakeElasticsearchClusters) Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
+	return c.Fake.
+		InvokesWatch(testing.NewWatchActionWithOptions(elasticsearchclustersResource, c.ns, opts))
+
+}
+
+// Create takes the representation of a elasticsearchCluster and creates it.  Returns the server's representation of the elasticsearchCluster, and an error, if there is any.
+func (c *FakeElasticsearchClusters) Create(ctx context.Context, elasticsearchCluster *v1.ElasticsearchCluster, opts metav1.CreateOptions) (result *v1.ElasticsearchCluster, err error) {
+	emptyResult := &v1.ElasticsearchCluster{}
+	obj, err := c.Fake.
+		Invokes(testing.NewCreateActionWithOptions(elasticsearchclustersResource, c.ns, elasticsearchCluster, opts), emptyResult)
+
+	if obj == nil {
+		return emptyResult, err
+	}
+	return obj.(*v1.ElasticsearchCluster), err
+}
+
+// Update takes the representation of a elasticsearchCluster and updates it. Returns the server's representation of the elasticsearchCluster, and an error, if there is any.
+func (c *FakeElasticsearchClusters) Update(ctx context.Context, elasticsearchCluster *v1.ElasticsearchCluster, opts metav1.UpdateOptions) (result *v1.ElasticsearchCluster, err error) {
+	emptyResult := &v1.ElasticsearchCluster{}
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateActionWithOptions(elasticsearchclustersResource, c.ns, elasticsearchCluster, opts), emptyResult)
+
+	if obj == nil {
+		return emptyResult, err
+	}
+	return obj.(*v1.ElasticsearchCluster), err
+}
+
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+func (c *FakeElasticsearchClusters) UpdateStatus(ctx context.Context, elasticsearchCluster *v1.ElasticsearchCluster, opts metav1.UpdateOptions) (result *v1.ElasticsearchCluster, err error) {
+	emptyResult := &v1.ElasticsearchCluster{}
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateSubresourceActionWithOptions(elasticsearchclustersResource, "status", c.ns, elasticsearchCluster, opts), emptyResult)
+
+	if obj == nil {
+		return emptyResult, err
+	}
+	return obj.(*v1.ElasticsearchCluster), err
+}
+
+// Delete takes name of the elasticsearchCluster and deletes it. Returns an error if one occurs.
+func (c *FakeElasticsearchClusters) Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error {
+	_, err := c.Fake.
+		Invokes(testing.NewDeleteActionWithOptions(elasticsearchclustersResource, c.ns, name, opts), &v1.ElasticsearchCluster{})
+
+	return err
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *FakeElasticsearchClusters) DeleteCollection(ctx context.Context, opts metav1.DeleteOptions, listOpts metav1.ListOptions) error {
+	action := testing.NewDeleteCollectionActionWithOptions(elasticsearchclustersResource, c.ns, opts, listOpts)
+
+	_, err := c.Fake.Invokes(action, &v1.ElasticsearchClusterList{})
+	return err
+}
+
+// Patch applies the patch and returns the patched elasticsearchCluster.
+func (c *FakeElasticsearchClusters) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (result *v1.ElasticsearchCluster, err error) {
+	emptyResult := &v1.ElasticsearchCluster{}
+	obj, err := c.Fake.
+		Invokes(testing.NewPatchSubresourceActionWithOptions(elasticsearchclustersResource, c.ns, name, pt, data, opts, subresources...), emptyResult)
+
+	if obj == nil {
+		return emptyResult, err
+	}
+	return obj.(*v1.ElasticsearchCluster), err
+}
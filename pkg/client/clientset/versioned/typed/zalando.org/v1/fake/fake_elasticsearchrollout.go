@@ -0,0 +1,147 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	"context"
+
+	v1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	testing "k8s.io/client-go/testing"
+)
+
+// FakeElasticsearchRollouts implements ElasticsearchRolloutInterface
+type FakeElasticsearchRollouts struct {
+	Fake *FakeZalandoV1
+	ns   string
+}
+
+var elasticsearchrolloutsResource = v1.SchemeGroupVersion.WithResource("elasticsearchrollouts")
+
+var elasticsearchrolloutsKind = v1.SchemeGroupVersion.WithKind("ElasticsearchRollout")
+
+// Get takes name of the elasticsearchRollout, and returns the corresponding elasticsearchRollout object, and an error if there is any.
+func (c *FakeElasticsearchRollouts) Get(ctx context.Context, name string, options metav1.GetOptions) (result *v1.ElasticsearchRollout, err error) {
+	emptyResult := &v1.ElasticsearchRollout{}
+	obj, err := c.Fake.
+		Invokes(testing.NewGetActionWithOptions(elasticsearchrolloutsResource, c.ns, name, options), emptyResult)
+
+	if obj == nil {
+		return emptyResult, err
+	}
+	return obj.(*v1.ElasticsearchRollout), err
+}
+
+// List takes label and field selectors, and returns the list of ElasticsearchRollouts that match those selectors.
+func (c *FakeElasticsearchRollouts) List(ctx context.Context, opts metav1.ListOptions) (result *v1.ElasticsearchRolloutList, err error) {
+	emptyResult := &v1.ElasticsearchRolloutList{}
+	obj, err := c.Fake.
+		Invokes(testing.NewListActionWithOptions(elasticsearchrolloutsResource, elasticsearchrolloutsKind, c.ns, opts), emptyResult)
+
+	if obj == nil {
+		return emptyResult, err
+	}
+
+	label, _, _ := testing.ExtractFromListOptions(opts)
+	if label == nil {
+		label = labels.Everything()
+	}
+	list := &v1.ElasticsearchRolloutList{ListMeta: obj.(*v1.ElasticsearchRolloutList).ListMeta}
+	for _, item := range obj.(*v1.ElasticsearchRolloutList).Items {
+		if label.Matches(labels.Set(item.Labels)) {
+			list.Items = append(list.Items, item)
+		}
+	}
+	return list, err
+}
+
+// Watch returns a watch.Interface that watches the requested elasticsearchRollouts.
+func (c *FakeElasticsearchRollouts) Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
+	return c.Fake.
+		InvokesWatch(testing.NewWatchActionWithOptions(elasticsearchrolloutsResource, c.ns, opts))
+
+}
+
+// Create takes the representation of a elasticsearchRollout and creates it.  Returns the server's representation of the elasticsearchRollout, and an error, if there is any.
+func (c *FakeElasticsearchRollouts) Create(ctx context.Context, elasticsearchRollout *v1.ElasticsearchRollout, opts metav1.CreateOptions) (result *v1.ElasticsearchRollout, err error) {
+	emptyResult := &v1.ElasticsearchRollout{}
+	obj, err := c.Fake.
+		Invokes(testing.NewCreateActionWithOptions(elasticsearchrolloutsResource, c.ns, elasticsearchRollout, opts), emptyResult)
+
+	if obj == nil {
+		return emptyResult, err
+	}
+	return obj.(*v1.ElasticsearchRollout), err
+}
+
+// Update takes the representation of a elasticsearchRollout and updates it. Returns the server's representation of the elasticsearchRollout, and an error, if there is any.
+func (c *FakeElasticsearchRollouts) Update(ctx context.Context, elasticsearchRollout *v1.ElasticsearchRollout, opts metav1.UpdateOptions) (result *v1.ElasticsearchRollout, err error) {
+	emptyResult := &v1.ElasticsearchRollout{}
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateActionWithOptions(elasticsearchrolloutsResource, c.ns, elasticsearchRollout, opts), emptyResult)
+
+	if obj == nil {
+		return emptyResult, err
+	}
+	return obj.(*v1.ElasticsearchRollout), err
+}
+
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+func (c *FakeElasticsearchRollouts) UpdateStatus(ctx context.Context, elasticsearchRollout *v1.ElasticsearchRollout, opts metav1.UpdateOptions) (result *v1.ElasticsearchRollout, err error) {
+	emptyResult := &v1.ElasticsearchRollout{}
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateSubresourceActionWithOptions(elasticsearchrolloutsResource, "status", c.ns, elasticsearchRollout, opts), emptyResult)
+
+	if obj == nil {
+		return emptyResult, err
+	}
+	return obj.(*v1.ElasticsearchRollout), err
+}
+
+// Delete takes name of the elasticsearchRollout and deletes it. Returns an error if one occurs.
+func (c *FakeElasticsearchRollouts) Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error {
+	_, err := c.Fake.
+		Invokes(testing.NewDeleteActionWithOptions(elasticsearchrolloutsResource, c.ns, name, opts), &v1.ElasticsearchRollout{})
+
+	return err
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *FakeElasticsearchRollouts) DeleteCollection(ctx context.Context, opts metav1.DeleteOptions, listOpts metav1.ListOptions) error {
+	action := testing.NewDeleteCollectionActionWithOptions(elasticsearchrolloutsResource, c.ns, opts, listOpts)
+
+	_, err := c.Fake.Invokes(action, &v1.ElasticsearchRolloutList{})
+	return err
+}
+
+// Patch applies the patch and returns the patched elasticsearchRollout.
+func (c *FakeElasticsearchRollouts) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (result *v1.ElasticsearchRollout, err error) {
+	emptyResult := &v1.ElasticsearchRollout{}
+	obj, err := c.Fake.
+		Invokes(testing.NewPatchSubresourceActionWithOptions(elasticsearchrolloutsResource, c.ns, name, pt, data, opts, subresources...), emptyResult)
+
+	if obj == nil {
+		return emptyResult, err
+	}
+	return obj.(*v1.ElasticsearchRollout), err
+}
@@ -28,6 +28,10 @@ type FakeZalandoV1 struct {
 	*testing.Fake
 }
 
+func (c *FakeZalandoV1) ElasticsearchClusters(namespace string) v1.ElasticsearchClusterInterface {
+	return &FakeElasticsearchClusters{c, namespace}
+}
+
 func (c *FakeZalandoV1) ElasticsearchDataSets(namespace string) v1.ElasticsearchDataSetInterface {
 	return &FakeElasticsearchDataSets{c, namespace}
 }
@@ -36,6 +40,14 @@ func (c *FakeZalandoV1) ElasticsearchMetricSets(namespace string) v1.Elasticsear
 	return &FakeElasticsearchMetricSets{c, namespace}
 }
 
+func (c *FakeZalandoV1) ElasticsearchRestores(namespace string) v1.ElasticsearchRestoreInterface {
+	return &FakeElasticsearchRestores{c, namespace}
+}
+
+func (c *FakeZalandoV1) ElasticsearchRollouts(namespace string) v1.ElasticsearchRolloutInterface {
+	return &FakeElasticsearchRollouts{c, namespace}
+}
+
 // RESTClient returns a RESTClient that is used to communicate
 // with API server by this client implementation.
 func (c *FakeZalandoV1) RESTClient() rest.Interface {
@@ -18,6 +18,12 @@ limitations under the License.
 
 package v1
 
+type ElasticsearchClusterExpansion interface{}
+
 type ElasticsearchDataSetExpansion interface{}
 
 type ElasticsearchMetricSetExpansion interface{}
+
+type ElasticsearchRestoreExpansion interface{}
+
+type ElasticsearchRolloutExpansion interface{}
@@ -28,8 +28,11 @@ import (
 
 type ZalandoV1Interface interface {
 	RESTClient() rest.Interface
+	ElasticsearchClustersGetter
 	ElasticsearchDataSetsGetter
 	ElasticsearchMetricSetsGetter
+	ElasticsearchRestoresGetter
+	ElasticsearchRolloutsGetter
 }
 
 // ZalandoV1Client is used to interact with features provided by the zalando.org group.
@@ -37,6 +40,10 @@ type ZalandoV1Client struct {
 	restClient rest.Interface
 }
 
+func (c *ZalandoV1Client) ElasticsearchClusters(namespace string) ElasticsearchClusterInterface {
+	return newElasticsearchClusters(c, namespace)
+}
+
 func (c *ZalandoV1Client) ElasticsearchDataSets(namespace string) ElasticsearchDataSetInterface {
 	return newElasticsearchDataSets(c, namespace)
 }
@@ -45,6 +52,14 @@ func (c *ZalandoV1Client) ElasticsearchMetricSets(namespace string) Elasticsearc
 	return newElasticsearchMetricSets(c, namespace)
 }
 
+func (c *ZalandoV1Client) ElasticsearchRestores(namespace string) ElasticsearchRestoreInterface {
+	return newElasticsearchRestores(c, namespace)
+}
+
+func (c *ZalandoV1Client) ElasticsearchRollouts(namespace string) ElasticsearchRolloutInterface {
+	return newElasticsearchRollouts(c, namespace)
+}
+
 // NewForConfig creates a new ZalandoV1Client for the given config.
 // NewForConfig is equivalent to NewForConfigAndClient(c, httpClient),
 // where httpClient was generated with rest.HTTPClientFor(c).
@@ -53,10 +53,16 @@ func (f *genericInformer) Lister() cache.GenericLister {
 func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource) (GenericInformer, error) {
 	switch resource {
 	// Group=zalando.org, Version=v1
+	case v1.SchemeGroupVersion.WithResource("elasticsearchclusters"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Zalando().V1().ElasticsearchClusters().Informer()}, nil
 	case v1.SchemeGroupVersion.WithResource("elasticsearchdatasets"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Zalando().V1().ElasticsearchDataSets().Informer()}, nil
 	case v1.SchemeGroupVersion.WithResource("elasticsearchmetricsets"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Zalando().V1().ElasticsearchMetricSets().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("elasticsearchrestores"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Zalando().V1().ElasticsearchRestores().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("elasticsearchrollouts"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Zalando().V1().ElasticsearchRollouts().Informer()}, nil
 
 	}
 
@@ -0,0 +1,90 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by informer-gen. DO NOT EDIT.
+
+package v1
+
+import (
+	"context"
+	time "time"
+
+	zalandoorgv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	versioned "github.com/zalando-incubator/es-operator/pkg/client/clientset/versioned"
+	internalinterfaces "github.com/zalando-incubator/es-operator/pkg/client/informers/externalversions/internalinterfaces"
+	v1 "github.com/zalando-incubator/es-operator/pkg/client/listers/zalando.org/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+	watch "k8s.io/apimachinery/pkg/watch"
+	cache "k8s.io/client-go/tools/cache"
+)
+
+// ElasticsearchRolloutInformer provides access to a shared informer and lister for
+// ElasticsearchRollouts.
+type ElasticsearchRolloutInformer interface {
+	Informer() cache.SharedIndexInformer
+	Lister() v1.ElasticsearchRolloutLister
+}
+
+type elasticsearchRolloutInformer struct {
+	factory          internalinterfaces.SharedInformerFactory
+	tweakListOptions internalinterfaces.TweakListOptionsFunc
+	namespace        string
+}
+
+// NewElasticsearchRolloutInformer constructs a new informer for ElasticsearchRollout type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewElasticsearchRolloutInformer(client versioned.Interface, namespace string, resyncPeriod time.Duration, indexers cache.Indexers) cache.SharedIndexInformer {
+	return NewFilteredElasticsearchRolloutInformer(client, namespace, resyncPeriod, indexers, nil)
+}
+
+// NewFilteredElasticsearchRolloutInformer constructs a new informer for ElasticsearchRollout type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewFilteredElasticsearchRolloutInformer(client versioned.Interface, namespace string, resyncPeriod time.Duration, indexers cache.Indexers, tweakListOptions internalinterfaces.TweakListOptionsFunc) cache.SharedIndexInformer {
+	return cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.ZalandoV1().ElasticsearchRollouts(namespace).List(context.TODO(), options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.ZalandoV1().ElasticsearchRollouts(namespace).Watch(context.TODO(), options)
+			},
+		},
+		&zalandoorgv1.ElasticsearchRollout{},
+		resyncPeriod,
+		indexers,
+	)
+}
+
+func (f *elasticsearchRolloutInformer) defaultInformer(client versioned.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
+	return NewFilteredElasticsearchRolloutInformer(client, f.namespace, resyncPeriod, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}, f.tweakListOptions)
+}
+
+func (f *elasticsearchRolloutInformer) Informer() cache.SharedIndexInformer {
+	return f.factory.InformerFor(&zalandoorgv1.ElasticsearchRollout{}, f.defaultInformer)
+}
+
+func (f *elasticsearchRolloutInformer) Lister() v1.ElasticsearchRolloutLister {
+	return v1.NewElasticsearchRolloutLister(f.Informer().GetIndexer())
+}
@@ -24,10 +24,16 @@ import (
 
 // Interface provides access to all the informers in this group version.
 type Interface interface {
+	// ElasticsearchClusters returns a ElasticsearchClusterInformer.
+	ElasticsearchClusters() ElasticsearchClusterInformer
 	// ElasticsearchDataSets returns a ElasticsearchDataSetInformer.
 	ElasticsearchDataSets() ElasticsearchDataSetInformer
 	// ElasticsearchMetricSets returns a ElasticsearchMetricSetInformer.
 	ElasticsearchMetricSets() ElasticsearchMetricSetInformer
+	// ElasticsearchRestores returns a ElasticsearchRestoreInformer.
+	ElasticsearchRestores() ElasticsearchRestoreInformer
+	// ElasticsearchRollouts returns a ElasticsearchRolloutInformer.
+	ElasticsearchRollouts() ElasticsearchRolloutInformer
 }
 
 type version struct {
@@ -41,6 +47,11 @@ func New(f internalinterfaces.SharedInformerFactory, namespace string, tweakList
 	return &version{factory: f, namespace: namespace, tweakListOptions: tweakListOptions}
 }
 
+// ElasticsearchClusters returns a ElasticsearchClusterInformer.
+func (v *version) ElasticsearchClusters() ElasticsearchClusterInformer {
+	return &elasticsearchClusterInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // ElasticsearchDataSets returns a ElasticsearchDataSetInformer.
 func (v *version) ElasticsearchDataSets() ElasticsearchDataSetInformer {
 	return &elasticsearchDataSetInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
@@ -50,3 +61,13 @@ func (v *version) ElasticsearchDataSets() ElasticsearchDataSetInformer {
 func (v *version) ElasticsearchMetricSets() ElasticsearchMetricSetInformer {
 	return &elasticsearchMetricSetInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
+
+// ElasticsearchRestores returns a ElasticsearchRestoreInformer.
+func (v *version) ElasticsearchRestores() ElasticsearchRestoreInformer {
+	return &elasticsearchRestoreInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
+// ElasticsearchRollouts returns a ElasticsearchRolloutInformer.
+func (v *version) ElasticsearchRollouts() ElasticsearchRolloutInformer {
+	return &elasticsearchRolloutInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
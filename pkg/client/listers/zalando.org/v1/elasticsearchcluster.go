@@ -0,0 +1,70 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1
+
+import (
+	v1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/listers"
+	"k8s.io/client-go/tools/cache"
+)
+
+// ElasticsearchClusterLister helps list ElasticsearchClusters.
+// All objects returned here must be treated as read-only.
+type ElasticsearchClusterLister interface {
+	// List lists all ElasticsearchClusters in the indexer.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*v1.ElasticsearchCluster, err error)
+	// ElasticsearchClusters returns an object that can list and get ElasticsearchClusters.
+	ElasticsearchClusters(namespace string) ElasticsearchClusterNamespaceLister
+	ElasticsearchClusterListerExpansion
+}
+
+// elasticsearchClusterLister implements the ElasticsearchClusterLister interface.
+type elasticsearchClusterLister struct {
+	listers.ResourceIndexer[*v1.ElasticsearchCluster]
+}
+
+// NewElasticsearchClusterLister returns a new ElasticsearchClusterLister.
+func NewElasticsearchClusterLister(indexer cache.Indexer) ElasticsearchClusterLister {
+	return &elasticsearchClusterLister{listers.New[*v1.ElasticsearchCluster](indexer, v1.Resource("elasticsearchcluster"))}
+}
+
+// ElasticsearchClusters returns an object that can list and get ElasticsearchClusters.
+func (s *elasticsearchClusterLister) ElasticsearchClusters(namespace string) ElasticsearchClusterNamespaceLister {
+	return elasticsearchClusterNamespaceLister{listers.NewNamespaced[*v1.ElasticsearchCluster](s.ResourceIndexer, namespace)}
+}
+
+// ElasticsearchClusterNamespaceLister helps list and get ElasticsearchClusters.
+// All objects returned here must be treated as read-only.
+type ElasticsearchClusterNamespaceLister interface {
+	// List lists all ElasticsearchClusters in the indexer for a given namespace.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*v1.ElasticsearchCluster, err error)
+	// Get retrieves the ElasticsearchCluster from the indexer for a given namespace and name.
+	// Objects returned here must be treated as read-only.
+	Get(name string) (*v1.ElasticsearchCluster, error)
+	ElasticsearchClusterNamespaceListerExpansion
+}
+
+// elasticsearchClusterNamespaceLister implements the ElasticsearchClusterNamespaceLister
+// interface.
+type elasticsearchClusterNamespaceLister struct {
+	listers.ResourceIndexer[*v1.ElasticsearchCluster]
+}
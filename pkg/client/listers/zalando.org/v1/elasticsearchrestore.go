@@ -0,0 +1,70 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1
+
+import (
+	v1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/listers"
+	"k8s.io/client-go/tools/cache"
+)
+
+// ElasticsearchRestoreLister helps list ElasticsearchRestores.
+// All objects returned here must be treated as read-only.
+type ElasticsearchRestoreLister interface {
+	// List lists all ElasticsearchRestores in the indexer.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*v1.ElasticsearchRestore, err error)
+	// ElasticsearchRestores returns an object that can list and get ElasticsearchRestores.
+	ElasticsearchRestores(namespace string) ElasticsearchRestoreNamespaceLister
+	ElasticsearchRestoreListerExpansion
+}
+
+// elasticsearchRestoreLister implements the ElasticsearchRestoreLister interface.
+type elasticsearchRestoreLister struct {
+	listers.ResourceIndexer[*v1.ElasticsearchRestore]
+}
+
+// NewElasticsearchRestoreLister returns a new ElasticsearchRestoreLister.
+func NewElasticsearchRestoreLister(indexer cache.Indexer) ElasticsearchRestoreLister {
+	return &elasticsearchRestoreLister{listers.New[*v1.ElasticsearchRestore](indexer, v1.Resource("elasticsearchrestore"))}
+}
+
+// ElasticsearchRestores returns an object that can list and get ElasticsearchRestores.
+func (s *elasticsearchRestoreLister) ElasticsearchRestores(namespace string) ElasticsearchRestoreNamespaceLister {
+	return elasticsearchRestoreNamespaceLister{listers.NewNamespaced[*v1.ElasticsearchRestore](s.ResourceIndexer, namespace)}
+}
+
+// ElasticsearchRestoreNamespaceLister helps list and get ElasticsearchRestores.
+// All objects returned here must be treated as read-only.
+type ElasticsearchRestoreNamespaceLister interface {
+	// List lists all ElasticsearchRestores in the indexer for a given namespace.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*v1.ElasticsearchRestore, err error)
+	// Get retrieves the ElasticsearchRestore from the indexer for a given namespace and name.
+	// Objects returned here must be treated as read-only.
+	Get(name string) (*v1.ElasticsearchRestore, error)
+	ElasticsearchRestoreNamespaceListerExpansion
+}
+
+// elasticsearchRestoreNamespaceLister implements the ElasticsearchRestoreNamespaceLister
+// interface.
+type elasticsearchRestoreNamespaceLister struct {
+	listers.ResourceIndexer[*v1.ElasticsearchRestore]
+}
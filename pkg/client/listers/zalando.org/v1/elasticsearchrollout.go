@@ -0,0 +1,70 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1
+
+import (
+	v1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/listers"
+	"k8s.io/client-go/tools/cache"
+)
+
+// ElasticsearchRolloutLister helps list ElasticsearchRollouts.
+// All objects returned here must be treated as read-only.
+type ElasticsearchRolloutLister interface {
+	// List lists all ElasticsearchRollouts in the indexer.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*v1.ElasticsearchRollout, err error)
+	// ElasticsearchRollouts returns an object that can list and get ElasticsearchRollouts.
+	ElasticsearchRollouts(namespace string) ElasticsearchRolloutNamespaceLister
+	ElasticsearchRolloutListerExpansion
+}
+
+// elasticsearchRolloutLister implements the ElasticsearchRolloutLister interface.
+type elasticsearchRolloutLister struct {
+	listers.ResourceIndexer[*v1.ElasticsearchRollout]
+}
+
+// NewElasticsearchRolloutLister returns a new ElasticsearchRolloutLister.
+func NewElasticsearchRolloutLister(indexer cache.Indexer) ElasticsearchRolloutLister {
+	return &elasticsearchRolloutLister{listers.New[*v1.ElasticsearchRollout](indexer, v1.Resource("elasticsearchrollout"))}
+}
+
+// ElasticsearchRollouts returns an object that can list and get ElasticsearchRollouts.
+func (s *elasticsearchRolloutLister) ElasticsearchRollouts(namespace string) ElasticsearchRolloutNamespaceLister {
+	return elasticsearchRolloutNamespaceLister{listers.NewNamespaced[*v1.ElasticsearchRollout](s.ResourceIndexer, namespace)}
+}
+
+// ElasticsearchRolloutNamespaceLister helps list and get ElasticsearchRollouts.
+// All objects returned here must be treated as read-only.
+type ElasticsearchRolloutNamespaceLister interface {
+	// List lists all ElasticsearchRollouts in the indexer for a given namespace.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*v1.ElasticsearchRollout, err error)
+	// Get retrieves the ElasticsearchRollout from the indexer for a given namespace and name.
+	// Objects returned here must be treated as read-only.
+	Get(name string) (*v1.ElasticsearchRollout, error)
+	ElasticsearchRolloutNamespaceListerExpansion
+}
+
+// elasticsearchRolloutNamespaceLister implements the ElasticsearchRolloutNamespaceLister
+// interface.
+type elasticsearchRolloutNamespaceLister struct {
+	listers.ResourceIndexer[*v1.ElasticsearchRollout]
+}
@@ -18,6 +18,14 @@ limitations under the License.
 
 package v1
 
+// ElasticsearchClusterListerExpansion allows custom methods to be added to
+// ElasticsearchClusterLister.
+type ElasticsearchClusterListerExpansion interface{}
+
+// ElasticsearchClusterNamespaceListerExpansion allows custom methods to be added to
+// ElasticsearchClusterNamespaceLister.
+type ElasticsearchClusterNamespaceListerExpansion interface{}
+
 // ElasticsearchDataSetListerExpansion allows custom methods to be added to
 // ElasticsearchDataSetLister.
 type ElasticsearchDataSetListerExpansion interface{}
@@ -33,3 +41,19 @@ type ElasticsearchMetricSetListerExpansion interface{}
 // ElasticsearchMetricSetNamespaceListerExpansion allows custom methods to be added to
 // ElasticsearchMetricSetNamespaceLister.
 type ElasticsearchMetricSetNamespaceListerExpansion interface{}
+
+// ElasticsearchRestoreListerExpansion allows custom methods to be added to
+// ElasticsearchRestoreLister.
+type ElasticsearchRestoreListerExpansion interface{}
+
+// ElasticsearchRestoreNamespaceListerExpansion allows custom methods to be added to
+// ElasticsearchRestoreNamespaceLister.
+type ElasticsearchRestoreNamespaceListerExpansion interface{}
+
+// ElasticsearchRolloutListerExpansion allows custom methods to be added to
+// ElasticsearchRolloutLister.
+type ElasticsearchRolloutListerExpansion interface{}
+
+// ElasticsearchRolloutNamespaceListerExpansion allows custom methods to be added to
+// ElasticsearchRolloutNamespaceLister.
+type ElasticsearchRolloutNamespaceListerExpansion interface{}
@@ -5,6 +5,7 @@ import (
 
 	clientset "github.com/zalando-incubator/es-operator/pkg/client/clientset/versioned"
 	zalandov1 "github.com/zalando-incubator/es-operator/pkg/client/clientset/versioned/typed/zalando.org/v1"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	metrics "k8s.io/metrics/pkg/client/clientset/versioned"
@@ -17,6 +18,7 @@ type Clientset struct {
 	kubernetes.Interface
 	zInterface clientset.Interface
 	mInterface metrics.Interface
+	dInterface dynamic.Interface
 }
 
 func (c *Clientset) ZalandoV1() zalandov1.ZalandoV1Interface {
@@ -27,6 +29,10 @@ func (c *Clientset) MetricsV1Beta1() v1beta1.MetricsV1beta1Interface {
 	return c.mInterface.MetricsV1beta1()
 }
 
+func (c *Clientset) Dynamic() dynamic.Interface {
+	return c.dInterface
+}
+
 func NewClientset(kubeConfig *rest.Config) (*Clientset, error) {
 	client, err := kubernetes.NewForConfig(kubeConfig)
 	if err != nil {
@@ -43,9 +49,15 @@ func NewClientset(kubeConfig *rest.Config) (*Clientset, error) {
 		return nil, fmt.Errorf("failed to setup Kubernetes metrics client: %v", err)
 	}
 
+	dClient, err := dynamic.NewForConfig(kubeConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to setup Kubernetes dynamic client: %v", err)
+	}
+
 	return &Clientset{
 		Interface:  client,
 		zInterface: zClient,
 		mInterface: mClient,
+		dInterface: dClient,
 	}, nil
 }
@@ -0,0 +1,363 @@
+// Package conformance provides the es-operator conformance suite. It runs
+// the core EDS scenarios (create, scale, drain, update and delete) against
+// an existing cluster with a running operator, so downstream forks and
+// distributions can verify their environment:
+//
+//	func TestConformance(t *testing.T) {
+//		conformance.Run(t, conformance.Config{
+//			KubeClient: kubeClient,
+//			EDSClient:  edsClient,
+//			Namespace:  "es-operator-conformance",
+//			OperatorID: "es-operator",
+//			Version:    "8.6.2",
+//			ConfigMap:  "es8-config",
+//			Endpoint:   endpoint,
+//		})
+//	}
+package conformance
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cenk/backoff"
+	"github.com/stretchr/testify/require"
+	"github.com/zalando-incubator/es-operator/operator"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	"github.com/zalando-incubator/es-operator/pkg/await"
+	clientset "github.com/zalando-incubator/es-operator/pkg/client/clientset/versioned"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	defaultTimeout     = 15 * time.Minute
+	operatorAnnotation = "es-operator.zalando.org/operator"
+)
+
+// Config configures the environment the conformance suite runs against.
+type Config struct {
+	KubeClient kubernetes.Interface
+	EDSClient  clientset.Interface
+	// Namespace is the namespace the test EDS are created in.
+	Namespace string
+	// OperatorID is the ID of the operator managing the test EDS.
+	OperatorID string
+	// Version is the Elasticsearch version of the test EDS.
+	Version string
+	// ConfigMap is the name of the ConfigMap with the elasticsearch.yml
+	// used by the test EDS. It must join the nodes to an existing cluster.
+	ConfigMap string
+	// Endpoint is the Elasticsearch endpoint of the cluster the test EDS
+	// join, e.g. the master service.
+	Endpoint *url.URL
+	// Name is the prefix of the test EDS names. Defaults to
+	// 'conformance'.
+	Name string
+	// Labels are added to the test EDS and propagated to the resources
+	// created by the operator.
+	Labels map[string]string
+	// ImageRewriteRules are applied to the images of the test EDS.
+	ImageRewriteRules operator.ImageRewriteRules
+	// UpdateVersion is the Elasticsearch version the test EDS is updated
+	// to. Defaults to Version, in which case the update only changes the
+	// pod template labels.
+	UpdateVersion string
+	// Timeout is the maximum time to wait for a single step. Defaults to
+	// 15 minutes.
+	Timeout time.Duration
+	// PodSpec returns the pod spec of the test EDS. Defaults to
+	// DefaultPodSpec.
+	PodSpec func(group, image, configMap string) v1.PodSpec
+}
+
+// suite runs the conformance scenarios of a single EDS.
+type suite struct {
+	Config
+	name     string
+	esClient *operator.ESClient
+}
+
+// Run runs the conformance suite. The scenarios build on each other and
+// run sequentially as subtests of t.
+func Run(t *testing.T, config Config) {
+	if config.Name == "" {
+		config.Name = "conformance"
+	}
+	if config.UpdateVersion == "" {
+		config.UpdateVersion = config.Version
+	}
+	if config.Timeout == 0 {
+		config.Timeout = defaultTimeout
+	}
+	if config.PodSpec == nil {
+		config.PodSpec = DefaultPodSpec
+	}
+
+	s := &suite{
+		Config:   config,
+		name:     config.Name + "-" + strings.Replace(config.Version, ".", "", -1),
+		esClient: &operator.ESClient{Endpoint: config.Endpoint},
+	}
+
+	for _, scenario := range []struct {
+		name string
+		run  func(t *testing.T)
+	}{
+		{"create", s.testCreate},
+		{"scale", s.testScale},
+		{"drain", s.testDrain},
+		{"update", s.testUpdate},
+		{"delete", s.testDelete},
+	} {
+		if !t.Run(scenario.name, scenario.run) {
+			// later scenarios depend on the earlier ones.
+			t.FailNow()
+		}
+	}
+}
+
+func (s *suite) awaiter(t *testing.T, awaiter *await.Awaiter) *await.Awaiter {
+	return awaiter.WithTimeout(s.Timeout).WithLogger(t.Logf)
+}
+
+func (s *suite) image(version string) string {
+	return s.ImageRewriteRules.Rewrite("docker.elastic.co/elasticsearch/elasticsearch:" + version)
+}
+
+// waitForReplicas waits until the StatefulSet of the EDS has the expected
+// number of ready and updated replicas.
+func (s *suite) waitForReplicas(t *testing.T, replicas int32) {
+	err := s.awaiter(t, await.Condition(s.KubeClient.AppsV1().StatefulSets(s.Namespace), "sts", s.name, func(sts *appsv1.StatefulSet) error {
+		return await.All(
+			func() error { return await.Equal("observedGeneration", sts.Generation, sts.Status.ObservedGeneration) },
+			func() error { return await.Equal("replicas", replicas, *sts.Spec.Replicas) },
+			func() error { return await.Equal("readyReplicas", replicas, sts.Status.ReadyReplicas) },
+			func() error { return await.Equal("updatedReplicas", replicas, sts.Status.UpdatedReplicas) },
+		)
+	})).Await(context.Background())
+	require.NoError(t, err)
+}
+
+func (s *suite) updateEDS(t *testing.T, update func(eds *zv1.ElasticsearchDataSet)) {
+	edsInterface := s.EDSClient.ZalandoV1().ElasticsearchDataSets(s.Namespace)
+	err := backoff.Retry(func() error {
+		eds, err := edsInterface.Get(context.Background(), s.name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		update(eds)
+		_, err = edsInterface.Update(context.Background(), eds, metav1.UpdateOptions{})
+		return err
+	}, backoff.WithMaxRetries(backoff.NewExponentialBackOff(), 5))
+	require.NoError(t, err)
+}
+
+func (s *suite) testCreate(t *testing.T) {
+	replicas := int32(1)
+	eds := &zv1.ElasticsearchDataSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        s.name,
+			Namespace:   s.Namespace,
+			Labels:      s.Labels,
+			Annotations: map[string]string{operatorAnnotation: s.OperatorID},
+		},
+		Spec: zv1.ElasticsearchDataSetSpec{
+			Replicas: &replicas,
+			Template: zv1.PodTemplateSpec{
+				EmbeddedObjectMeta: zv1.EmbeddedObjectMeta{
+					Labels: map[string]string{
+						"application": "es-operator-conformance",
+						"component":   "elasticsearch",
+					},
+				},
+				Spec: s.PodSpec(s.name, s.image(s.Version), s.ConfigMap),
+			},
+		},
+	}
+	_, err := s.EDSClient.ZalandoV1().ElasticsearchDataSets(s.Namespace).Create(context.Background(), eds, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	err = s.awaiter(t, await.Created(s.KubeClient.CoreV1().Services(s.Namespace), "service", s.name)).Await(context.Background())
+	require.NoError(t, err)
+	s.waitForReplicas(t, replicas)
+}
+
+func (s *suite) testScale(t *testing.T) {
+	s.updateEDS(t, func(eds *zv1.ElasticsearchDataSet) {
+		eds.Spec.Replicas = pint32(2)
+	})
+	s.waitForReplicas(t, 2)
+}
+
+// testDrain scales down with data on the EDS and verifies the data is moved
+// to the remaining node before the pod is removed.
+func (s *suite) testDrain(t *testing.T) {
+	err := backoff.Retry(func() error {
+		return s.esClient.CreateIndex(s.name, s.name, 2, 0)
+	}, backoff.WithMaxRetries(backoff.NewExponentialBackOff(), 10))
+	require.NoError(t, err)
+
+	s.updateEDS(t, func(eds *zv1.ElasticsearchDataSet) {
+		eds.Spec.Replicas = pint32(1)
+	})
+	s.waitForReplicas(t, 1)
+
+	pod, err := s.KubeClient.CoreV1().Pods(s.Namespace).Get(context.Background(), s.name+"-0", metav1.GetOptions{})
+	require.NoError(t, err)
+
+	err = s.awaiter(t, await.New(fmt.Sprintf("shards of index %s on %s", s.name, pod.Name), func(_ context.Context) (bool, error) {
+		shards, err := s.esClient.GetShards()
+		if err != nil {
+			return true, err
+		}
+		count := 0
+		for _, shard := range shards {
+			if shard.Index != s.name {
+				continue
+			}
+			if shard.IP != pod.Status.PodIP {
+				return true, fmt.Errorf("shard of index %s on %s instead of %s", s.name, shard.IP, pod.Status.PodIP)
+			}
+			count++
+		}
+		return true, await.Equal("shards", 2, count)
+	})).Await(context.Background())
+	require.NoError(t, err)
+
+	err = s.esClient.DeleteIndex(s.name)
+	require.NoError(t, err)
+}
+
+func (s *suite) testUpdate(t *testing.T) {
+	s.updateEDS(t, func(eds *zv1.ElasticsearchDataSet) {
+		eds.Spec.Template.Labels["conformance-update"] = "true"
+		eds.Spec.Template.Spec.Containers[0].Image = s.image(s.UpdateVersion)
+	})
+
+	err := s.awaiter(t, await.Condition(s.KubeClient.AppsV1().StatefulSets(s.Namespace), "sts", s.name, func(sts *appsv1.StatefulSet) error {
+		return await.All(
+			func() error {
+				return await.Equal("template label", "true", sts.Spec.Template.Labels["conformance-update"])
+			},
+			func() error {
+				return await.Equal("image", s.image(s.UpdateVersion), sts.Spec.Template.Spec.Containers[0].Image)
+			},
+		)
+	})).Await(context.Background())
+	require.NoError(t, err)
+	s.waitForReplicas(t, 1)
+}
+
+func (s *suite) testDelete(t *testing.T) {
+	err := s.EDSClient.ZalandoV1().ElasticsearchDataSets(s.Namespace).Delete(context.Background(), s.name, metav1.DeleteOptions{})
+	require.NoError(t, err)
+
+	err = s.awaiter(t, await.Deleted(s.EDSClient.ZalandoV1().ElasticsearchDataSets(s.Namespace), "eds", s.name)).Await(context.Background())
+	require.NoError(t, err)
+	err = s.awaiter(t, await.Deleted(s.KubeClient.AppsV1().StatefulSets(s.Namespace), "sts", s.name)).Await(context.Background())
+	require.NoError(t, err)
+	err = s.awaiter(t, await.Deleted(s.KubeClient.CoreV1().Services(s.Namespace), "service", s.name)).Await(context.Background())
+	require.NoError(t, err)
+}
+
+// DefaultPodSpec returns a minimal Elasticsearch data node pod spec joining
+// the nodes to the cluster configured in the ConfigMap.
+func DefaultPodSpec(group, image, configMap string) v1.PodSpec {
+	return v1.PodSpec{
+		SecurityContext: &v1.PodSecurityContext{
+			RunAsUser:  pint64(1000),
+			RunAsGroup: pint64(0),
+			FSGroup:    pint64(0),
+		},
+		Containers: []v1.Container{
+			{
+				Name:  "elasticsearch",
+				Image: image,
+				Ports: []v1.ContainerPort{
+					{ContainerPort: 9200},
+					{ContainerPort: 9300},
+				},
+				Env: []v1.EnvVar{
+					{Name: "ES_JAVA_OPTS", Value: "-Xms356m -Xmx356m"},
+					{Name: "node.roles", Value: "data"},
+					{Name: "node.attr.group", Value: group},
+				},
+				Resources: v1.ResourceRequirements{
+					Limits: v1.ResourceList{
+						v1.ResourceMemory: resource.MustParse("1Gi"),
+						v1.ResourceCPU:    resource.MustParse("100m"),
+					},
+					Requests: v1.ResourceList{
+						v1.ResourceMemory: resource.MustParse("1Gi"),
+						v1.ResourceCPU:    resource.MustParse("100m"),
+					},
+				},
+				ReadinessProbe: &v1.Probe{
+					InitialDelaySeconds: 15,
+					ProbeHandler: v1.ProbeHandler{
+						HTTPGet: &v1.HTTPGetAction{
+							Path:   "/_cluster/health?local=true",
+							Port:   intstr.FromInt(9200),
+							Scheme: v1.URISchemeHTTP,
+						},
+					},
+				},
+				VolumeMounts: []v1.VolumeMount{
+					{
+						Name:      "data",
+						MountPath: "/usr/share/elasticsearch/data",
+					},
+					{
+						Name:      "config",
+						MountPath: "/usr/share/elasticsearch/config/elasticsearch.yml",
+						SubPath:   "elasticsearch.yml",
+					},
+				},
+			},
+		},
+		TerminationGracePeriodSeconds: pint64(5),
+		Volumes: []v1.Volume{
+			{
+				Name: "data",
+				VolumeSource: v1.VolumeSource{
+					EmptyDir: &v1.EmptyDirVolumeSource{
+						Medium: v1.StorageMediumMemory,
+					},
+				},
+			},
+			{
+				Name: "config",
+				VolumeSource: v1.VolumeSource{
+					ConfigMap: &v1.ConfigMapVolumeSource{
+						LocalObjectReference: v1.LocalObjectReference{
+							Name: configMap,
+						},
+						Items: []v1.KeyToPath{
+							{
+								Key:  "elasticsearch.yml",
+								Path: "elasticsearch.yml",
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func pint32(i int32) *int32 {
+	return &i
+}
+
+func pint64(i int64) *int64 {
+	return &i
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/zalando-incubator/es-operator/operator"
+	zv1 "github.com/zalando-incubator/es-operator/pkg/apis/zalando.org/v1"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+)
+
+// validateManifest validates the ElasticsearchDataSets of the manifest file
+// and writes the StatefulSets the operator would create for them to out. A
+// path of '-' reads the manifest from stdin.
+func validateManifest(path string, imageRewriteRules map[string]string, out io.Writer) error {
+	var in io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(in))
+	var errs []error
+	rendered := 0
+	for i := 1; ; i++ {
+		doc, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+
+		output, err := renderManifest(doc, imageRewriteRules)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("document %d: %v", i, err))
+			continue
+		}
+
+		if rendered > 0 {
+			fmt.Fprintln(out, "---")
+		}
+		_, err = out.Write(output)
+		if err != nil {
+			return err
+		}
+		rendered++
+	}
+	return errors.Join(errs...)
+}
+
+// renderManifest validates a single ElasticsearchDataSet and returns the
+// rendered StatefulSet as YAML, with the defaults of the defaulting webhook
+// applied. Unknown fields are rejected.
+func renderManifest(doc []byte, imageRewriteRules map[string]string) ([]byte, error) {
+	var eds zv1.ElasticsearchDataSet
+	err := yaml.UnmarshalStrict(doc, &eds)
+	if err != nil {
+		return nil, err
+	}
+	if eds.Kind != "ElasticsearchDataSet" {
+		return nil, fmt.Errorf("expected kind ElasticsearchDataSet, got '%s'", eds.Kind)
+	}
+
+	// the defaulting webhook sets the defaults before the operator sees
+	// the EDS.
+	raw, err := yaml.YAMLToJSON(doc)
+	if err != nil {
+		return nil, err
+	}
+	raw, err = operator.ApplyElasticsearchDataSetDefaults(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply defaults: %v", err)
+	}
+	eds = zv1.ElasticsearchDataSet{}
+	err = json.Unmarshal(raw, &eds)
+	if err != nil {
+		return nil, err
+	}
+
+	sts, err := operator.RenderStatefulSet(&eds, imageRewriteRules)
+	if err != nil {
+		return nil, fmt.Errorf("ElasticsearchDataSet %s is invalid: %v", eds.Name, err)
+	}
+	return yaml.Marshal(sts)
+}
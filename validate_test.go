@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const validManifest = `apiVersion: zalando.org/v1
+kind: ElasticsearchDataSet
+metadata:
+  name: es-data
+  namespace: default
+spec:
+  replicas: 2
+  template:
+    spec:
+      containers:
+      - name: elasticsearch
+        image: docker.elastic.co/elasticsearch/elasticsearch:8.6.2
+`
+
+func TestValidateManifest(t *testing.T) {
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "valid.yaml")
+	if err := os.WriteFile(path, []byte(validManifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	err := validateManifest(path, map[string]string{"docker.elastic.co/": "mirror.local/"}, &out)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, expected := range []string{"kind: StatefulSet", "serviceName: es-data", "replicas: 2", "image: mirror.local/elasticsearch/elasticsearch:8.6.2", "readinessProbe:"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("expected %q in rendered StatefulSet:\n%s", expected, out.String())
+		}
+	}
+
+	// the defaults of the defaulting webhook are applied.
+	grouped := strings.Replace(validManifest, "  namespace: default\n", "  namespace: default\n  labels:\n    group: hot\n", 1)
+	path = filepath.Join(dir, "grouped.yaml")
+	if err := os.WriteFile(path, []byte(grouped), 0644); err != nil {
+		t.Fatal(err)
+	}
+	out.Reset()
+	err = validateManifest(path, nil, &out)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, expected := range []string{"name: node.attr.group", "value: hot"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("expected %q in rendered StatefulSet:\n%s", expected, out.String())
+		}
+	}
+
+	invalid := validManifest + `  scaling:
+    enabled: true
+    minReplicas: 3
+    maxReplicas: 2
+---
+` + strings.Replace(validManifest, "replicas: 2", "replica: 2", 1)
+	path = filepath.Join(dir, "invalid.yaml")
+	if err := os.WriteFile(path, []byte(invalid), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out.Reset()
+	err = validateManifest(path, nil, &out)
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	for _, expected := range []string{"document 1: ElasticsearchDataSet es-data is invalid", "minReplicas(3) can't be greater than maxReplicas(2)", "document 2:", "unknown field"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected %q in error: %v", expected, err)
+		}
+	}
+}
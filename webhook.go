@@ -0,0 +1,53 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/zalando-incubator/es-operator/operator"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// newWebhookServer returns the webhook server of the manager serving the
+// validating and the defaulting admission webhooks and the conversion
+// webhook on the address. The Kubernetes API server only calls webhooks via
+// TLS, so a certificate is required. The webhooks are served by all replicas,
+// not only by the elected leader.
+func newWebhookServer(address, certFile, keyFile string) (webhook.Server, error) {
+	if certFile == "" || keyFile == "" {
+		return nil, fmt.Errorf("the admission webhooks require a TLS certificate and key")
+	}
+
+	host, portValue, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid webhook address %s: %v", address, err)
+	}
+	port, err := strconv.Atoi(portValue)
+	if err != nil {
+		return nil, fmt.Errorf("invalid webhook address %s: %v", address, err)
+	}
+
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load webhook certificate: %v", err)
+	}
+
+	server := webhook.NewServer(webhook.Options{
+		Host: host,
+		Port: port,
+		TLSOpts: []func(*tls.Config){
+			func(config *tls.Config) {
+				config.MinVersion = tls.VersionTLS12
+				config.GetCertificate = func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+					return &cert, nil
+				}
+			},
+		},
+	})
+	server.Register(operator.ValidatingWebhookPath, operator.ValidatingWebhookHandler())
+	server.Register(operator.MutatingWebhookPath, operator.MutatingWebhookHandler())
+	server.Register(operator.ConversionWebhookPath, operator.ConversionWebhookHandler())
+	return server, nil
+}